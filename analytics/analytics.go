@@ -0,0 +1,42 @@
+// Package analytics exposes the API usage analytics recorded by
+// middleware.UsageTracking. It is part of the stable azf API; the backends
+// behind it live in infrastructure/analytics and may change between minor
+// versions.
+package analytics
+
+import (
+	"github.com/aruncs31s/azf/application/service"
+	"github.com/aruncs31s/azf/domain/api_usage"
+	"github.com/aruncs31s/azf/domain/repository"
+	infraanalytics "github.com/aruncs31s/azf/infrastructure/analytics"
+	"github.com/aruncs31s/azf/initializer"
+)
+
+type (
+	// Service queries endpoint rankings, trends and usage reports
+	Service = service.APIUsageAnalyticsService
+	// Backend stores usage logs and their aggregated statistics
+	Backend = repository.UsageAnalyticsBackend
+	// EndpointRanking is an endpoint with its usage statistics
+	EndpointRanking = api_usage.APIEndpointRanking
+	// Summary is the overall usage of the API
+	Summary = service.UsageSummaryDTO
+)
+
+// Default returns a Service reading from the shared usage analytics backend,
+// which is configured from the environment on first use
+func Default() Service {
+	backend := infraanalytics.Default(initializer.DB)
+	return service.NewAPIUsageAnalyticsService(backend.Logs(), backend.Stats())
+}
+
+// SetBackend replaces the shared usage analytics backend, e.g. with a custom
+// store; call it before the tracking middleware records any request
+func SetBackend(backend Backend) {
+	infraanalytics.SetDefault(backend)
+}
+
+// Close flushes and closes the shared usage analytics backend
+func Close() error {
+	return infraanalytics.Close()
+}
@@ -1,8 +1,10 @@
 package dto
 
+// ResponseMeta describes how a request was served; it is added to every JSON
+// object response as "meta"
 type ResponseMeta struct {
-	APIVersion        string `json:"api_version"`
-	AuthorizationMode string `json:"authorization_mode"`
-	ResponseTimeMs    string `json:"response_time_ms"`
-	RequestID         string `json:"request_id"`
+	APIVersion        string  `json:"api_version"`
+	AuthorizationMode string  `json:"authorization_mode"`
+	ResponseTimeMs    float64 `json:"response_time_ms"`
+	RequestID         string  `json:"request_id"`
 }
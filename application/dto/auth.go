@@ -1,19 +1,87 @@
 package dto
 
+import "time"
+
 type LoginRequest struct {
 	Username string `json:"username" binding:"required,min=1,max=100" form:"username"`
 	Password string `json:"password" binding:"required,min=6,max=255" form:"password"`
+	// OTPCode is the TOTP code, or a backup code, of admins with two-factor
+	// authentication
+	OTPCode string `json:"otp_code,omitempty" form:"otp_code"`
 	// CollegeID string `json:"college_id" binding:"required"`
 }
 
 type AdminLoginResponse struct {
-	Success   bool      `json:"success"`
-	Message   string    `json:"message"`
-	SessionID string    `json:"session_id,omitempty"`
-	Admin     AdminInfo `json:"admin,omitempty"`
-	JWT       string    `json:"jwt,omitempty"`
-	Error     string    `json:"error,omitempty"`
-	Timestamp string    `json:"timestamp"`
+	Success      bool      `json:"success"`
+	Message      string    `json:"message"`
+	SessionID    string    `json:"session_id,omitempty"`
+	Admin        AdminInfo `json:"admin,omitempty"`
+	JWT          string    `json:"jwt,omitempty"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	ExpiresIn    int64     `json:"expires_in,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	Timestamp    string    `json:"timestamp"`
+	// TwoFactorRequired asks for the request again with an otp_code
+	TwoFactorRequired bool `json:"two_factor_required,omitempty"`
+	// TwoFactorEnrollment is the secret to enroll, when two-factor
+	// authentication is enforced and the admin has none; the request is
+	// repeated with an otp_code of the new secret
+	TwoFactorEnrollment *TwoFactorEnrollment `json:"two_factor_enrollment,omitempty"`
+	// BackupCodes are returned once, when sign-in completed the enrollment
+	BackupCodes []string `json:"backup_codes,omitempty"`
+	// Identity is the directory account that signed in, when the credentials
+	// were checked against a directory instead of the configured admin
+	Identity *DirectoryIdentity `json:"-"`
+}
+
+// DirectoryIdentity is a user vouched for by an identity source other than
+// the admin configuration, such as LDAP or a SAML identity provider
+type DirectoryIdentity struct {
+	// Provider names the source, recorded as the user's OAuth provider
+	Provider string
+	// Subject identifies the user at the source, recorded as its OAuth ID
+	Subject  string
+	Username string
+	Email    string
+	Name     string
+	// Roles are the azf roles mapped from the user's groups
+	Roles []string
+}
+
+// TwoFactorEnrollment is a new TOTP secret, pending until confirmed with a code
+type TwoFactorEnrollment struct {
+	Secret string `json:"secret"`
+	// OTPAuthURL is the otpauth:// URL authenticator apps scan as a QR code
+	OTPAuthURL string `json:"otpauth_url"`
+}
+
+// TwoFactorStatus describes the two-factor authentication of an admin
+type TwoFactorStatus struct {
+	Enabled              bool       `json:"enabled"`
+	Required             bool       `json:"required"`
+	BackupCodesRemaining int        `json:"backup_codes_remaining"`
+	EnabledAt            *time.Time `json:"enabled_at,omitempty"`
+}
+
+// TwoFactorCodeRequest carries a TOTP code, or a backup code where accepted
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// AdminTokenPair is an admin access token with the refresh token renewing it
+type AdminTokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	// ExpiresIn and RefreshExpiresIn are lifetimes in seconds
+	ExpiresIn        int64 `json:"expires_in"`
+	RefreshExpiresIn int64 `json:"refresh_expires_in"`
+}
+
+// RefreshTokenRequest exchanges a refresh token for a new token pair; the
+// refresh token cookie is used when the body has none
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
 }
 
 // AdminInfo represents basic admin information
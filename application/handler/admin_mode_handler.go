@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/aruncs31s/azf/application/service"
+	"github.com/gin-gonic/gin"
+)
+
+// AdminModeHandler exposes the read-only mode switch of the admin surface
+type AdminModeHandler struct {
+	adminModeService service.AdminModeService
+}
+
+// NewAdminModeHandler creates a new admin mode handler
+func NewAdminModeHandler(adminModeService service.AdminModeService) (*AdminModeHandler, error) {
+	if err := requireDependencies("AdminModeHandler", dep("adminModeService", adminModeService)); err != nil {
+		return nil, err
+	}
+	return &AdminModeHandler{
+		adminModeService: adminModeService,
+	}, nil
+}
+
+// GetReadOnlyStatus returns the current read-only mode and whether the
+// current admin may change it
+func (h *AdminModeHandler) GetReadOnlyStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":     h.adminModeService.GetReadOnlyStatus(),
+		"can_toggle": h.adminModeService.IsSuperadmin(AdminUsername(c)),
+	})
+}
+
+// SetReadOnly switches read-only mode on or off; superadmins only
+func (h *AdminModeHandler) SetReadOnly(c *gin.Context) {
+	var req service.SetReadOnlyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	status, err := h.adminModeService.SetReadOnly(AdminUsername(c), req)
+	if err != nil {
+		if errors.Is(err, service.ErrNotSuperadmin) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Read-only mode updated",
+		"status":  status,
+	})
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/a-h/templ"
+	"github.com/aruncs31s/azf/application/service"
+	"github.com/aruncs31s/azf/application/templates"
+	"github.com/gin-gonic/gin"
+)
+
+// AdminSessionHandler lists signed-in admin sessions and revokes them
+type AdminSessionHandler struct {
+	sessions service.AdminSessionService
+}
+
+// NewAdminSessionHandler creates a new admin session handler. sessions may
+// be nil when no session store is available; the pages then report so.
+func NewAdminSessionHandler(sessions service.AdminSessionService) *AdminSessionHandler {
+	return &AdminSessionHandler{
+		sessions: sessions,
+	}
+}
+
+// RegisterRoutes registers the sessions page and API behind auth
+func (h *AdminSessionHandler) RegisterRoutes(r gin.IRoutes, auth gin.HandlerFunc) {
+	r.GET("/admin-ui/sessions", auth, h.GetSessionsPage)
+	r.GET("/admin-ui/api/sessions", auth, h.ListSessions)
+	r.DELETE("/admin-ui/api/sessions/:id", auth, h.RevokeSession)
+}
+
+// GetSessionsPage renders the active admin sessions
+func (h *AdminSessionHandler) GetSessionsPage(c *gin.Context) {
+	if h.sessions == nil {
+		c.String(http.StatusServiceUnavailable, "Admin session store not available")
+		return
+	}
+	sessions, err := h.listSessions(c)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Failed to load admin sessions")
+		return
+	}
+
+	data := templates.AdminSessionsPageData{
+		GeneratedAt: time.Now(),
+		Sessions:    sessions,
+	}
+	templ.Handler(templates.AdminSessionsPage(data)).ServeHTTP(c.Writer, c.Request)
+}
+
+// ListSessions returns the active admin sessions, marking the current one
+func (h *AdminSessionHandler) ListSessions(c *gin.Context) {
+	if h.sessions == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "admin session store not available"})
+		return
+	}
+	sessions, err := h.listSessions(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+// RevokeSession ends an admin session server-side; its cookie stops working
+// on the next request
+func (h *AdminSessionHandler) RevokeSession(c *gin.Context) {
+	if h.sessions == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "admin session store not available"})
+		return
+	}
+	if err := h.sessions.RevokeSession(c.Request.Context(), c.Param("id")); err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Admin session revoked"})
+}
+
+func (h *AdminSessionHandler) listSessions(c *gin.Context) ([]service.AdminSessionDTO, error) {
+	sessions, err := h.sessions.ListSessions(c.Request.Context())
+	if err != nil {
+		return nil, err
+	}
+	current := c.GetString("admin_session_id")
+	for i := range *sessions {
+		(*sessions)[i].Current = current != "" && (*sessions)[i].ID == current
+	}
+	return *sessions, nil
+}
@@ -1,11 +1,9 @@
 package handler
 
 import (
-	"fmt"
+	"errors"
 	"log"
 	"net/http"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/a-h/templ"
@@ -13,84 +11,375 @@ import (
 	"github.com/aruncs31s/azf/application/service"
 	"github.com/aruncs31s/azf/application/templates"
 	"github.com/aruncs31s/azf/config"
-	"github.com/aruncs31s/azf/domain/api_usage"
+	identity_access "github.com/aruncs31s/azf/domain/identity_access/model"
+	"github.com/aruncs31s/azf/domain/repository"
+	usermodel "github.com/aruncs31s/azf/domain/user_management/model"
+	"github.com/aruncs31s/azf/infrastructure/analytics"
 	"github.com/aruncs31s/azf/infrastructure/enterprise"
-	"github.com/aruncs31s/azf/infrastructure/persistence"
 	"github.com/aruncs31s/azf/initializer"
+	"github.com/aruncs31s/azf/internal/persistence"
 	helperImpl "github.com/aruncs31s/azf/shared/helper"
 	"github.com/aruncs31s/azf/shared/interface/helper"
 	"github.com/aruncs31s/azf/shared/logger"
 	"github.com/aruncs31s/azf/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 )
 
-// NewPerformanceHandler creates a new PerformanceHandler with its dependencies.
-func NewPerformanceHandler(configProvider *config.AdminConfigProvider) PerformanceHandler {
-	logRepo := persistence.NewAPIUsageRepository(initializer.DB)
-	statsRepo := persistence.NewAPIUsageStatsRepository(initializer.DB)
-	apiUsageAnalytics := service.NewAPIUsageAnalyticsService(logRepo, statsRepo)
-	authService := service.NewAdminAuthenticationService(configProvider)
-	profileService := service.NewAdminProfileService(configProvider)
-
-	return &performanceHandler{
-		apiUsageAnalytics: apiUsageAnalytics,
-		authService:       *authService,
-		profileService:    *profileService,
-		auditService:      nil, // Will be initialized lazily
-	}
-}
-
-type PerformanceHandler interface {
-	PerformanceReader
-	PerformanceWriter
-}
-
-type PerformanceReader interface {
-	GetHomePage(c *gin.Context)
-	GetAPIAnalyticsPage(c *gin.Context)
-	GetEndpointDetailsPage(c *gin.Context)
-	GetRouteMetadataManagementPage(c *gin.Context)
-	GetRoleManagementPage(c *gin.Context)
-	GetRoleDetailsPage(c *gin.Context)
-	GetPolicyManagementPage(c *gin.Context)
-	GetAuditLogsPage(c *gin.Context)
-	GetFeaturesDocumentationPage(c *gin.Context)
-	GetLoginPage(c *gin.Context)
-	GetUsersForRole(c *gin.Context)
-}
-
-type PerformanceWriter interface {
-	SaveRouteMetadata(c *gin.Context)
-	ImportRouteMetadata(c *gin.Context)
-	DeleteRouteMetadata(c *gin.Context)
-	LoginJSON(c *gin.Context)
-	Logout(c *gin.Context)
-	CreateRole(c *gin.Context)
-	UpdateRole(c *gin.Context)
-	AssignRoleToUser(c *gin.Context)
-	RemoveRoleFromUser(c *gin.Context)
-	DeleteRole(c *gin.Context)
-}
-
-// performanceHandler serves the Admin Performance Dashboard and metrics JSON.
-type performanceHandler struct {
+// AdminHandlers are the admin dashboard handlers, sharing their services
+type AdminHandlers struct {
+	Admin         *AdminHandler
+	Analytics     *AnalyticsHandler
+	Roles         *RoleHandler
+	RouteMetadata *RouteMetadataHandler
+	Audit         *AuditHandler
+	Sessions      *AdminSessionHandler
+	TwoFactor     *TwoFactorHandler
+	Users         *UserManagementHandler
+	// SessionService checks the admin session cookie; nil when no session
+	// store is available and only its presence is checked
+	SessionService service.AdminSessionService
+	// RoleSync keeps user roles and grouping policies in step; nil when no
+	// database is available
+	RoleSync service.RoleSyncService
+	// UserManagement purges deleted users once their retention ends; nil
+	// when no database is available
+	UserManagement service.UserManagementService
+}
+
+// NewAdminHandlers creates the admin dashboard handlers with their dependencies.
+// approvals decides overrides of role changes that would lock an admin out.
+// It fails with ErrMissingDependency when a handler is wired incompletely.
+func NewAdminHandlers(configProvider *config.AdminConfigProvider, approvals service.ApprovalService) (*AdminHandlers, error) {
+	usageBackend := analytics.Default(initializer.DB)
+	apiUsageAnalytics := service.NewAPIUsageAnalyticsService(usageBackend.Logs(), usageBackend.Stats())
+	annotationService := service.NewUsageAnnotationService(persistence.NewUsageAnnotationRepository(initializer.DB))
+	authService, directory := newAdminAuthenticator(configProvider)
+	tokenService := newAdminTokenService()
+	sessionService := newAdminSessionService()
+	twoFactorService := newTwoFactorService()
+	var userRepo usermodel.UserRepository
+	var userAudit usermodel.UserAuditRepository
+	var transactions repository.TransactionManager
+	if initializer.DB != nil {
+		userRepo = persistence.NewUserRepository(initializer.DB)
+		userAudit = persistence.NewUserAuditRepository(initializer.DB)
+		transactions = persistence.NewTransactionManager(initializer.DB)
+	}
+	unitOfWork := service.NewUnitOfWork(transactions)
+	roleSync := service.NewRoleSyncService(userRepo, unitOfWork)
+	profileService := service.NewAdminProfileService(configProvider, unitOfWork, approvals, roleSync)
+	userLookup := service.NewUserLookupService(userRepo)
+
+	admin, err := NewAdminHandlerBuilder().
+		WithAuthService(authService).
+		WithTokenService(tokenService).
+		WithSessions(sessionService).
+		WithTwoFactor(twoFactorService).
+		WithProfileService(profileService).
+		WithAdminUsers(service.NewAdminUserService(userRepo, unitOfWork)).
+		WithAPIUsageAnalytics(apiUsageAnalytics).
+		WithSAML(newSAMLService()).
+		WithDirectory(directory).
+		Build()
+	if err != nil {
+		return nil, err
+	}
+	analyticsHandler, err := NewAnalyticsHandler(apiUsageAnalytics, annotationService, userLookup)
+	if err != nil {
+		return nil, err
+	}
+	roles, err := NewRoleHandler(profileService, userLookup, roleSync)
+	if err != nil {
+		return nil, err
+	}
+	userManagement := service.NewUserManagementService(userRepo, unitOfWork, profileService, roleSync, userAudit)
+	users, err := NewUserManagementHandler(userManagement, profileService)
+	if err != nil {
+		return nil, err
+	}
+
+	handlers := &AdminHandlers{
+		Admin:          admin,
+		Analytics:      analyticsHandler,
+		Roles:          roles,
+		RouteMetadata:  NewRouteMetadataHandler(),
+		Audit:          NewAuditHandler(nil),
+		Sessions:       NewAdminSessionHandler(sessionService),
+		TwoFactor:      NewTwoFactorHandler(twoFactorService),
+		Users:          users,
+		SessionService: sessionService,
+	}
+	if userRepo != nil {
+		handlers.RoleSync = roleSync
+		handlers.UserManagement = userManagement
+	}
+	return handlers, nil
+}
+
+// newAdminTokenService creates the admin token service on the configured
+// refresh token store, or returns nil when the store is not available
+func newAdminTokenService() service.AdminTokenService {
+	var repo identity_access.RefreshTokenRepository
+	switch store := config.AdminRefreshTokenStore(); store {
+	case config.AdminTokenStoreRedis:
+		opts, err := redis.ParseURL(config.RedisURL())
+		if err != nil {
+			logger.Warn("Admin refresh tokens need a valid REDIS_URL", zap.Error(err))
+			return nil
+		}
+		repo = persistence.NewRedisRefreshTokenRepository(redis.NewClient(opts))
+	case config.AdminTokenStoreDatabase:
+		if initializer.DB == nil {
+			logger.Warn("Admin refresh tokens disabled: database not available")
+			return nil
+		}
+		repo = persistence.NewRefreshTokenRepository(initializer.DB)
+	default:
+		logger.Warn("Unknown admin refresh token store, refresh tokens disabled", zap.String("store", store))
+		return nil
+	}
+	return service.NewAdminTokenService(repo, config.AdminAccessTokenTTL(), config.AdminRefreshTokenTTL())
+}
+
+// newAdminSessionService creates the admin session service on the configured
+// session store, or returns nil when the store is not available
+func newAdminSessionService() service.AdminSessionService {
+	var store identity_access.SessionStore
+	switch kind := config.AdminSessionStore(); kind {
+	case config.AdminTokenStoreRedis:
+		opts, err := redis.ParseURL(config.RedisURL())
+		if err != nil {
+			logger.Warn("Admin sessions need a valid REDIS_URL", zap.Error(err))
+			return nil
+		}
+		store = persistence.NewRedisAdminSessionRepository(redis.NewClient(opts))
+	case config.AdminTokenStoreDatabase:
+		if initializer.DB == nil {
+			logger.Warn("Admin session store disabled: database not available")
+			return nil
+		}
+		store = persistence.NewAdminSessionRepository(initializer.DB)
+	default:
+		logger.Warn("Unknown admin session store, session store disabled", zap.String("store", kind))
+		return nil
+	}
+	return service.NewAdminSessionService(store, config.AdminSessionIdleTimeout(), config.AdminSessionAbsoluteTimeout())
+}
+
+// newTwoFactorService creates the admin two-factor service, or returns nil
+// when the database is not available
+func newTwoFactorService() service.TwoFactorService {
+	if initializer.DB == nil {
+		if config.AdminRequireTwoFactor() {
+			logger.Warn("ADMIN_REQUIRE_2FA is set but the database is not available; admins sign in without two-factor authentication")
+		}
+		return nil
+	}
+	return service.NewTwoFactorService(
+		persistence.NewAdminTwoFactorRepository(initializer.DB),
+		config.AdminTwoFactorIssuer(),
+		config.AdminRequireTwoFactor(),
+	)
+}
+
+// newAdminAuthenticator creates the authenticator of admin passwords: the
+// LDAP server when LDAP_URL is set, with its health as the directory, and
+// otherwise the configured admin credentials without a directory
+func newAdminAuthenticator(configProvider *config.AdminConfigProvider) (service.AdminAuthenticator, service.DirectoryHealthChecker) {
+	password := service.NewAdminAuthenticationService(configProvider)
+	cfg := config.GetLDAPConfig()
+	if cfg.URL == "" {
+		return password, nil
+	}
+	ldapAuth, err := service.NewLDAPAuthenticator(cfg)
+	if err != nil {
+		// Falling back to the admin password would reopen a sign-in the
+		// operator meant to replace
+		logger.Error("LDAP sign-in misconfigured; admins cannot sign in with a password", zap.Error(err))
+		return ldapUnavailable{err: err}, nil
+	}
+	return ldapAuth, ldapAuth
+}
+
+// ldapUnavailable rejects every password sign-in while LDAP is misconfigured
+type ldapUnavailable struct {
+	err error
+}
+
+func (u ldapUnavailable) Login(*dto.LoginRequest) (*dto.AdminLoginResponse, error) {
+	return &dto.AdminLoginResponse{
+		Success:   false,
+		Message:   "Directory sign-in is misconfigured. Please contact system administrator.",
+		Error:     "server configuration error",
+		Timestamp: time.Now().Format(time.RFC3339),
+	}, u.err
+}
+
+// newSAMLService creates the SAML sign-in service, or returns nil when no
+// identity provider is configured or its settings are invalid
+func newSAMLService() *service.SAMLService {
+	cfg := config.GetSAMLConfig()
+	if cfg.IDPMetadata == "" {
+		return nil
+	}
+	samlService, err := service.NewSAMLService(cfg)
+	if err != nil {
+		logger.Warn("SAML sign-in disabled", zap.Error(err))
+		return nil
+	}
+	return samlService
+}
+
+// RegisterRoutes registers the routes of every admin dashboard handler;
+// auth guards the pages and API that need a signed-in admin
+func (h *AdminHandlers) RegisterRoutes(r gin.IRoutes, auth gin.HandlerFunc) {
+	h.Admin.RegisterRoutes(r, auth)
+	h.Analytics.RegisterRoutes(r, auth)
+	h.RouteMetadata.RegisterRoutes(r, auth)
+	h.Roles.RegisterRoutes(r, auth)
+	h.Audit.RegisterRoutes(r, auth)
+	h.Sessions.RegisterRoutes(r, auth)
+	h.TwoFactor.RegisterRoutes(r, auth)
+	h.Users.RegisterRoutes(r, auth)
+}
+
+// AdminHandler serves admin sign-in, the home dashboard and the features page
+type AdminHandler struct {
+	authService       service.AdminAuthenticator
+	tokenService      service.AdminTokenService
+	sessions          service.AdminSessionService
+	twoFactor         service.TwoFactorService
+	profileService    *service.AdminProfileService
+	adminUsers        service.AdminUserService
 	apiUsageAnalytics service.APIUsageAnalyticsService
-	authService       service.AdminAuthenticationService
-	profileService    service.AdminProfileService
-	auditService      service.AuthorizationAuditService
+	saml              *service.SAMLService
+	directory         service.DirectoryHealthChecker
 	requestHelper     helper.RequestHelper
 	responseHelper    helper.ResponseHelper
 }
 
-func (h *performanceHandler) GetLoginPage(c *gin.Context) {
+// AdminHandlerBuilder collects the dependencies of an AdminHandler. Build
+// fails when a required one is missing.
+type AdminHandlerBuilder struct {
+	handler AdminHandler
+}
+
+// NewAdminHandlerBuilder starts building an admin handler
+func NewAdminHandlerBuilder() *AdminHandlerBuilder {
+	return &AdminHandlerBuilder{
+		handler: AdminHandler{
+			requestHelper:  helperImpl.NewRequestHelper(),
+			responseHelper: helperImpl.NewResponseHelper(),
+		},
+	}
+}
+
+// WithAuthService sets the service checking admin credentials; required
+func (b *AdminHandlerBuilder) WithAuthService(authService service.AdminAuthenticator) *AdminHandlerBuilder {
+	b.handler.authService = authService
+	return b
+}
+
+// WithTokenService sets the service issuing access and refresh tokens.
+// Without it, sign-in issues a single access token and refreshing is not
+// available.
+func (b *AdminHandlerBuilder) WithTokenService(tokenService service.AdminTokenService) *AdminHandlerBuilder {
+	b.handler.tokenService = tokenService
+	return b
+}
+
+// WithSessions sets the session service. Without it, sign-in sets a session
+// cookie that is not stored server-side.
+func (b *AdminHandlerBuilder) WithSessions(sessions service.AdminSessionService) *AdminHandlerBuilder {
+	b.handler.sessions = sessions
+	return b
+}
+
+// WithTwoFactor sets the TOTP second factor service. Without it, admins sign
+// in with their password alone.
+func (b *AdminHandlerBuilder) WithTwoFactor(twoFactor service.TwoFactorService) *AdminHandlerBuilder {
+	b.handler.twoFactor = twoFactor
+	return b
+}
+
+// WithProfileService sets the admin profile service; required
+func (b *AdminHandlerBuilder) WithProfileService(profileService *service.AdminProfileService) *AdminHandlerBuilder {
+	b.handler.profileService = profileService
+	return b
+}
+
+// WithAdminUsers sets the service recording admin sign-ins on user records; required
+func (b *AdminHandlerBuilder) WithAdminUsers(adminUsers service.AdminUserService) *AdminHandlerBuilder {
+	b.handler.adminUsers = adminUsers
+	return b
+}
+
+// WithAPIUsageAnalytics sets the analytics shown on the home page; required
+func (b *AdminHandlerBuilder) WithAPIUsageAnalytics(apiUsageAnalytics service.APIUsageAnalyticsService) *AdminHandlerBuilder {
+	b.handler.apiUsageAnalytics = apiUsageAnalytics
+	return b
+}
+
+// WithSAML sets the SAML sign-in; optional, nil turns it off
+func (b *AdminHandlerBuilder) WithSAML(saml *service.SAMLService) *AdminHandlerBuilder {
+	b.handler.saml = saml
+	return b
+}
+
+// WithDirectory sets the directory admins sign in against, whose connection
+// the dashboard shows; optional
+func (b *AdminHandlerBuilder) WithDirectory(directory service.DirectoryHealthChecker) *AdminHandlerBuilder {
+	b.handler.directory = directory
+	return b
+}
+
+// Build returns the admin handler, or an ErrMissingDependency error
+func (b *AdminHandlerBuilder) Build() (*AdminHandler, error) {
+	if err := requireDependencies("AdminHandler",
+		dep("authService", b.handler.authService),
+		dep("profileService", b.handler.profileService),
+		dep("adminUsers", b.handler.adminUsers),
+		dep("apiUsageAnalytics", b.handler.apiUsageAnalytics),
+		dep("requestHelper", b.handler.requestHelper),
+		dep("responseHelper", b.handler.responseHelper),
+	); err != nil {
+		return nil, err
+	}
+	h := b.handler
+	return &h, nil
+}
+
+// RegisterRoutes registers sign-in and sign-out, SAML sign-in when it is
+// configured, and the home and features pages and directory health behind auth
+func (h *AdminHandler) RegisterRoutes(r gin.IRoutes, auth gin.HandlerFunc) {
+	r.GET("/admin-ui/login", h.GetLoginPage)
+	r.POST("/admin-ui/login/json", h.LoginJSON)
+	r.GET("/admin-ui/logout", h.Logout)
+	r.POST("/admin-ui/token/refresh", h.RefreshToken)
+	if h.saml != nil {
+		r.GET("/admin-ui/saml/metadata", h.SAMLMetadata)
+		r.GET("/admin-ui/saml/login", h.SAMLLogin)
+		r.POST("/admin-ui/saml/acs", h.SAMLACS)
+	}
+
+	r.GET("", auth, h.GetHomePage)
+	r.GET("/admin-ui", auth, h.GetHomePage)
+	r.GET("/admin-ui/features", auth, h.GetFeaturesDocumentationPage)
+	if h.directory != nil {
+		r.GET("/admin-ui/api/ldap/health", auth, h.GetDirectoryHealth)
+	}
+}
+
+func (h *AdminHandler) GetLoginPage(c *gin.Context) {
 	templ.Handler(templates.LoginPage("")).ServeHTTP(c.Writer, c.Request)
 }
 
 // GetHomePage renders the home dashboard page
 // It shows an overview of all available features and management tools
-func (h *performanceHandler) GetHomePage(c *gin.Context) {
+func (h *AdminHandler) GetHomePage(c *gin.Context) {
 	// Get admin username from claims if available
 	adminUsername := "Admin"
 	if claims, ok := c.Get("claims"); ok {
@@ -140,133 +429,32 @@ func (h *performanceHandler) GetHomePage(c *gin.Context) {
 		AverageResponseTime: avgResponseTime,
 		AdminProfile:        adminProfile,
 	}
+	if h.directory != nil {
+		health := h.directory.Health(c.Request.Context())
+		homeData.Directory = &templates.DirectoryStatus{
+			URL:       health.URL,
+			Connected: health.Connected,
+			Latency:   health.Latency.Round(time.Millisecond).String(),
+			Error:     health.Error,
+		}
+	}
 
 	// Render Templ template with sidebar
 	templ.Handler(templates.HomePageWithSidebar(homeData)).ServeHTTP(c.Writer, c.Request)
 }
 
-// GetAPIAnalyticsPage renders the dedicated API Analytics page
-// It shows comprehensive API usage statistics and performance metrics
-func (h *performanceHandler) GetAPIAnalyticsPage(c *gin.Context) {
-	// Get top endpoints
-	topEndpoints, err := h.apiUsageAnalytics.GetTopEndpointsByUsage(10)
-	if err != nil {
-		c.String(http.StatusInternalServerError, "Failed to load API analytics")
-		return
-	}
-	if topEndpoints == nil {
-		topEndpoints = &[]api_usage.APIEndpointRanking{}
-	}
-
-	// Get slowest endpoints
-	slowestEndpoints, err := h.apiUsageAnalytics.GetEndpointsByResponseTime(10)
-	if err != nil {
-		c.String(http.StatusInternalServerError, "Failed to load API analytics")
-		return
-	}
-	if slowestEndpoints == nil {
-		slowestEndpoints = &[]api_usage.APIEndpointRanking{}
-	}
-
-	// Get most errored endpoints
-	erroredEndpoints, err := h.apiUsageAnalytics.GetEndpointsByErrorRate(10)
-	if err != nil {
-		c.String(http.StatusInternalServerError, "Failed to load API analytics")
-		return
-	}
-	if erroredEndpoints == nil {
-		erroredEndpoints = &[]api_usage.APIEndpointRanking{}
-	}
-
-	// Get usage summary
-	usageSummary, err := h.apiUsageAnalytics.GetUsageSummary()
-	if err != nil {
-		c.String(http.StatusInternalServerError, "Failed to load API analytics")
-		return
-	}
-	if usageSummary == nil {
-		usageSummary = &service.UsageSummaryDTO{}
-	}
-
-	// Get trend data
-	trendData, err := h.apiUsageAnalytics.GetUsageTrend(7)
-	if err != nil {
-		c.String(http.StatusInternalServerError, "Failed to load API analytics")
-		return
-	}
-	if trendData == nil {
-		trendData = &[]service.UsageTrendDTO{}
-	}
-
-	// Create analytics data structure for Templ
-	analyticsData := templates.APIAnalyticsPageData{
-		GeneratedAt:          time.Now(),
-		TopEndpoints:         *topEndpoints,
-		SlowestEndpoints:     *slowestEndpoints,
-		MostErroredEndpoints: *erroredEndpoints,
-		UsageSummary:         *usageSummary,
-		TrendData:            *trendData,
-	}
-
-	// Render Templ template
-	templ.Handler(templates.APIAnalyticsPage(analyticsData)).ServeHTTP(c.Writer, c.Request)
-}
-
-// GetEndpointDetailsPage renders the detailed view of who called a specific endpoint
-func (h *performanceHandler) GetEndpointDetailsPage(c *gin.Context) {
-	endpoint := c.Query("endpoint")
-	method := c.Query("method")
-
-	if endpoint == "" {
-		c.String(http.StatusBadRequest, "Endpoint parameter is required")
-		return
-	}
-
-	// Get endpoint details
-	details, err := h.apiUsageAnalytics.GetEndpointDetails(endpoint)
-	if err != nil {
-		c.String(http.StatusInternalServerError, "Failed to load endpoint details")
-		return
-	}
-
-	// Get callers
-	callers, err := h.apiUsageAnalytics.GetEndpointCallers(endpoint, 50)
-	if err != nil {
-		c.String(http.StatusInternalServerError, "Failed to load endpoint callers")
-		return
-	}
-
-	// Create page data
-	pageData := templates.EndpointDetailsPageData{
-		Endpoint: endpoint,
-		Method:   method,
-		Details:  *details,
-		Callers:  *callers,
+// GetDirectoryHealth reports the connection to the directory admins sign in
+// against
+func (h *AdminHandler) GetDirectoryHealth(c *gin.Context) {
+	health := h.directory.Health(c.Request.Context())
+	status := http.StatusOK
+	if !health.Connected {
+		status = http.StatusServiceUnavailable
 	}
-
-	// Render Templ template
-	templ.Handler(templates.EndpointDetailsPage(pageData)).ServeHTTP(c.Writer, c.Request)
+	c.JSON(status, health)
 }
 
-// GetRouteMetadataManagementPage renders the Route Metadata Management page
-// Allows admins to view and manage enterprise route metadata configuration
-func (h *performanceHandler) GetRouteMetadataManagementPage(c *gin.Context) {
-	// Load current route metadata
-	routeMetadata, err := enterprise.LoadEnterpriseRouteMetadata("")
-	if err != nil {
-		c.String(http.StatusInternalServerError, "Failed to load route metadata")
-		return
-	}
-
-	// Create management data structure
-	managementData := templates.RouteMetadataManagementPageData{
-		Routes: routeMetadata,
-	}
-
-	// Render Templ template
-	templ.Handler(templates.RouteMetadataManagementPage(managementData)).ServeHTTP(c.Writer, c.Request)
-}
-func (h *performanceHandler) LoginJSON(c *gin.Context) {
+func (h *AdminHandler) LoginJSON(c *gin.Context) {
 	loginRequest, err := helperImpl.GetJSONDataFromRequest[dto.LoginRequest](c)
 	if err != nil {
 		// Enhanced error message for debugging
@@ -287,7 +475,11 @@ func (h *performanceHandler) LoginJSON(c *gin.Context) {
 	response, err := h.authService.Login(loginRequest)
 	if err != nil {
 		log.Printf("Authentication service error: %v", err)
-		h.responseHelper.Unauthorized(c, "Admin credentials not configured. Please contact system administrator.")
+		message := "Admin credentials not configured. Please contact system administrator."
+		if response != nil && response.Message != "" {
+			message = response.Message
+		}
+		h.responseHelper.Unauthorized(c, message)
 		return
 	}
 	if !response.Success {
@@ -295,40 +487,165 @@ func (h *performanceHandler) LoginJSON(c *gin.Context) {
 		h.responseHelper.Unauthorized(c, response.Message)
 		return
 	}
+	// Continue as the authenticated name, which a directory may spell
+	// differently, so the second factor and user record are found by it
+	if response.Admin.Username != "" {
+		loginRequest.Username = response.Admin.Username
+	}
 
-	// Generate JWT token for API requests
-	jwtToken := h.generateJWTToken(loginRequest.Username, "admin")
+	// Admins with a second factor, or required to enroll one, sign in with a code
+	if !h.checkTwoFactor(c, loginRequest, response) {
+		return
+	}
+
+	// Attribute the session to the admin's user record
+	userID := service.AdminUserID(loginRequest.Username)
+	if response.Identity != nil {
+		user, err := h.adminUsers.RecordDirectoryLogin(c.Request.Context(), response.Identity)
+		switch {
+		case errors.Is(err, service.ErrAdminSignInDenied):
+			logger.Warn("Directory sign-in of blocked user", zap.String("username", loginRequest.Username))
+			h.responseHelper.Unauthorized(c, "Your account may not sign in to the admin UI")
+			return
+		case err != nil:
+			logger.Error("Failed to record directory login on user record",
+				zap.String("username", loginRequest.Username),
+				zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to record sign-in"})
+			return
+		case user != nil:
+			userID = user.GetID()
+		}
+	} else if user, err := h.adminUsers.RecordAdminLogin(c.Request.Context(), loginRequest.Username); err != nil {
+		logger.Warn("Failed to record admin login on user record",
+			zap.String("username", loginRequest.Username),
+			zap.Error(err))
+	} else {
+		userID = user.GetID()
+	}
+
+	if !h.startSession(c, loginRequest.Username, userID, response) {
+		return
+	}
+
+	// Return success response
+	c.JSON(http.StatusOK, response)
+}
+
+// startSession issues the tokens and the session of a signed-in admin, sets
+// their cookies and adds them to response. It responds and reports false when
+// they cannot be issued.
+func (h *AdminHandler) startSession(c *gin.Context, username, userID string, response *dto.AdminLoginResponse) bool {
+	// Issue the access token for API requests, with a refresh token when available
+	jwtToken := ""
+	if h.tokenService != nil {
+		tokens, err := h.tokenService.Issue(c.Request.Context(), username, userID)
+		if err != nil {
+			logger.Error("Failed to issue admin tokens", zap.String("username", username), zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to issue tokens"})
+			return false
+		}
+		h.setTokenCookies(c, tokens)
+		jwtToken = tokens.AccessToken
+		response.RefreshToken = tokens.RefreshToken
+		response.ExpiresIn = tokens.ExpiresIn
+	} else {
+		jwtToken = h.generateJWTToken(username, userID, "admin")
+		h.setAccessTokenCookie(c, jwtToken, int(service.DefaultAccessTokenExpiry/time.Second))
+	}
+
+	// Store the session, so it can be checked and revoked server-side
+	sessionMaxAge := 3600 * 24 // 24 hours
+	if h.sessions != nil {
+		token, err := h.sessions.Start(c.Request.Context(), service.StartAdminSessionRequest{
+			Username:  username,
+			UserID:    userID,
+			ClientIP:  c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+		})
+		if err != nil {
+			logger.Error("Failed to start admin session", zap.String("username", username), zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start session"})
+			return false
+		}
+		response.SessionID = token
+		sessionMaxAge = int(h.sessions.AbsoluteTimeout() / time.Second)
+	}
 
 	// Set session cookie
 	c.SetCookie(
 		"admin_session",
 		response.SessionID,
-		3600*24, // 24 hours
+		sessionMaxAge,
 		"/admin-ui",
 		"",
 		false,
 		true,
 	)
 
-	// Set JWT token cookie for API requests
-	c.SetCookie(
-		"jwt_token",
-		jwtToken,
-		3600*24, // 24 hours
-		"/",
-		"",
-		false,
-		true,
-	)
-
 	// Add JWT token to response
 	response.JWT = jwtToken
+	return true
+}
 
-	// Return success response
-	c.JSON(http.StatusOK, response)
+// checkTwoFactor verifies the second factor of a password-authenticated
+// admin, or enrolls one when it is required and missing. It responds and
+// reports false when sign-in may not continue.
+func (h *AdminHandler) checkTwoFactor(c *gin.Context, loginRequest *dto.LoginRequest, response *dto.AdminLoginResponse) bool {
+	if h.twoFactor == nil {
+		return true
+	}
+	ctx := c.Request.Context()
+	enabled, err := h.twoFactor.IsEnabled(ctx, loginRequest.Username)
+	if err != nil {
+		logger.Error("Failed to check admin two-factor authentication", zap.String("username", loginRequest.Username), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check two-factor authentication"})
+		return false
+	}
+
+	switch {
+	case enabled && loginRequest.OTPCode == "":
+		c.JSON(http.StatusUnauthorized, twoFactorChallenge("Two-factor code required", nil))
+		return false
+	case enabled:
+		if err := h.twoFactor.Verify(ctx, loginRequest.Username, loginRequest.OTPCode); err != nil {
+			logger.Warn("Admin two-factor code rejected", zap.String("username", loginRequest.Username), zap.Error(err))
+			c.JSON(http.StatusUnauthorized, twoFactorChallenge("Invalid two-factor code", nil))
+			return false
+		}
+	case h.twoFactor.Required() && loginRequest.OTPCode == "":
+		enrollment, err := h.twoFactor.BeginEnrollment(ctx, loginRequest.Username)
+		if err != nil {
+			logger.Error("Failed to start admin two-factor enrollment", zap.String("username", loginRequest.Username), zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start two-factor enrollment"})
+			return false
+		}
+		c.JSON(http.StatusUnauthorized, twoFactorChallenge("Two-factor authentication is required; scan the code and enter it to finish signing in", enrollment))
+		return false
+	case h.twoFactor.Required():
+		backupCodes, err := h.twoFactor.ConfirmEnrollment(ctx, loginRequest.Username, loginRequest.OTPCode)
+		if err != nil {
+			logger.Warn("Admin two-factor enrollment code rejected", zap.String("username", loginRequest.Username), zap.Error(err))
+			c.JSON(http.StatusUnauthorized, twoFactorChallenge("Invalid two-factor code", nil))
+			return false
+		}
+		response.BackupCodes = backupCodes
+	}
+	return true
+}
+
+// twoFactorChallenge is the sign-in response asking for a second factor
+func twoFactorChallenge(message string, enrollment *dto.TwoFactorEnrollment) *dto.AdminLoginResponse {
+	return &dto.AdminLoginResponse{
+		Success:             false,
+		Message:             message,
+		TwoFactorRequired:   true,
+		TwoFactorEnrollment: enrollment,
+		Timestamp:           time.Now().Format(time.RFC3339),
+	}
 }
 
-func (h *performanceHandler) Logout(c *gin.Context) {
+func (h *AdminHandler) Logout(c *gin.Context) {
 	// Get session ID from cookie
 	sessionID, err := c.Cookie("admin_session")
 	if err != nil {
@@ -336,8 +653,20 @@ func (h *performanceHandler) Logout(c *gin.Context) {
 		return
 	}
 
-	// Logout
-	h.authService.Logout(sessionID)
+	// End the session server-side, so the cookie no longer works if kept
+	if h.sessions != nil {
+		if err := h.sessions.End(c.Request.Context(), sessionID); err != nil {
+			logger.Warn("Failed to end admin session", zap.Error(err))
+		}
+	}
+
+	// End the login of the refresh token, so neither it nor its rotations work
+	if refreshToken, err := c.Cookie(adminRefreshTokenCookie); err == nil && h.tokenService != nil {
+		if err := h.tokenService.Revoke(c.Request.Context(), refreshToken); err != nil {
+			logger.Warn("Failed to revoke admin refresh token", zap.Error(err))
+		}
+	}
+	c.SetCookie(adminRefreshTokenCookie, "", -1, adminRefreshTokenPath, "", false, true)
 
 	// Clear session cookie
 	c.SetCookie(
@@ -381,211 +710,92 @@ func (h *performanceHandler) Logout(c *gin.Context) {
 	c.Header("Content-Type", "text/html; charset=utf-8")
 	c.String(http.StatusOK, logoutHTML)
 }
-func (h *performanceHandler) generateJWTToken(username, role string) string {
-
-	claims := jwt.MapClaims{
-		"username": username,
-		"role":     role,
-		"user_id":  "admin_" + username,
-		"exp":      time.Now().Add(time.Hour * 24).Unix(),
-		"iat":      time.Now().Unix(),
-	}
-	tokenString, err := service.GenerateToken(claims)
-	if err != nil {
-		log.Println("Error generating JWT token:", err)
-		return ""
-	}
 
-	return tokenString
-}
+// Admin refresh tokens are kept in a cookie only sent to the refresh endpoint
+const (
+	adminRefreshTokenCookie = "admin_refresh_token"
+	adminRefreshTokenPath   = "/admin-ui/token"
+)
 
-// GetRoleManagementPage renders the Role Management page
-// Allows admins to view roles and user role assignments from Casbin
-func (h *performanceHandler) GetRoleManagementPage(c *gin.Context) {
-	// Get all roles from Casbin
-	roleNames, err := h.profileService.GetAllRolesFromCasbin()
-	if err != nil {
-		c.String(http.StatusInternalServerError, "Failed to load roles from Casbin")
+// RefreshToken exchanges a refresh token, from the JSON body or the refresh
+// token cookie, for a new access token and a rotated refresh token
+func (h *AdminHandler) RefreshToken(c *gin.Context) {
+	if h.tokenService == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "token refresh is not available"})
 		return
 	}
 
-	// Get role descriptions
-	roleDescriptions := h.profileService.GetRoleDescriptions()
-
-	// Build role info with descriptions and user counts
-	allRoles := make([]templates.RoleInfo, 0, len(roleNames))
-	userRoleMap := make(map[string][]string) // userID -> roles
-
-	for _, roleName := range roleNames {
-		description := roleDescriptions[roleName]
-		if description == "" {
-			description = roleName + " role" // Fallback description
-		}
-
-		// Get users for this role
-		users, err := h.profileService.GetUsersForRole(roleName)
-		userCount := 0
-		if err == nil {
-			userCount = len(users)
-			// Collect user -> role mappings
-			for _, userID := range users {
-				userRoleMap[userID] = append(userRoleMap[userID], roleName)
-			}
-		}
-
-		allRoles = append(allRoles, templates.RoleInfo{
-			Name:        roleName,
-			Description: description,
-			UserCount:   userCount,
-		})
-	}
-
-	// Build user role assignments from collected data
-	userRoles := make([]templates.UserRoleAssignment, 0, len(userRoleMap))
-	for userID, roles := range userRoleMap {
-		// Use userID as username for now (in a real app, you'd have a user service)
-		username := userID
-		if userID == "admin" || strings.HasPrefix(userID, "admin") {
-			username = "Administrator"
-		}
-
-		userRoles = append(userRoles, templates.UserRoleAssignment{
-			UserID:   userID,
-			Username: username,
-			Roles:    roles,
-		})
-	}
-
-	// Create management data structure
-	managementData := templates.RoleManagementPageData{
-		Roles:     allRoles,
-		UserRoles: userRoles,
-	}
-
-	// Render Templ template
-	templ.Handler(templates.RoleManagementPage(managementData)).ServeHTTP(c.Writer, c.Request)
-}
-
-// GetPolicyManagementPage renders the Policy Management documentation page
-// Provides comprehensive guidance on managing Casbin policies following DDD principles
-func (h *performanceHandler) GetPolicyManagementPage(c *gin.Context) {
-	// Get current policy statistics
-	policyCount := 0
-	groupingCount := 0
-
-	if enforcer := initializer.CasbinEnforcer; enforcer != nil {
-		if policies, err := enforcer.GetPolicy(); err == nil {
-			policyCount = len(policies)
-		}
-		if groupingPolicies, err := enforcer.GetGroupingPolicy(); err == nil {
-			groupingCount = len(groupingPolicies)
+	var request dto.RefreshTokenRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+			return
 		}
 	}
-
-	// Get roles for examples
-	roles, _ := h.profileService.GetAllRolesFromCasbin()
-	if len(roles) == 0 {
-		roles = []string{"admin", "staff", "user"}
-	}
-
-	// Create policy management data
-	policyData := templates.PolicyManagementPageData{
-		PolicyCount:       policyCount,
-		GroupingCount:     groupingCount,
-		AvailableRoles:    roles,
-		CurrentPolicyFile: "config/casbin_rbac_policy.csv",
-		CurrentModelFile:  "config/casbin_rbac_model.conf",
+	if request.RefreshToken == "" {
+		request.RefreshToken, _ = c.Cookie(adminRefreshTokenCookie)
 	}
 
-	// Render Templ template
-	templ.Handler(templates.PolicyManagementPage(policyData)).ServeHTTP(c.Writer, c.Request)
-}
-
-// GetAuditLogsPage renders the Authorization Audit Logs page
-// Shows comprehensive audit trail of authorization decisions
-func (h *performanceHandler) GetAuditLogsPage(c *gin.Context) {
-	// Lazy initialization of audit service
-	if h.auditService == nil && enterprise.EnterpriseAuth != nil {
-		auditRepo := enterprise.EnterpriseAuth.GetAuditRepository()
-		if auditRepo != nil {
-			h.auditService = service.NewAuthorizationAuditService(auditRepo)
+	tokens, err := h.tokenService.Refresh(c.Request.Context(), request.RefreshToken)
+	if err != nil {
+		if errorStatus(err, http.StatusInternalServerError) == http.StatusUnauthorized {
+			c.SetCookie(adminRefreshTokenCookie, "", -1, adminRefreshTokenPath, "", false, true)
 		}
-	}
-
-	if h.auditService == nil {
-		c.String(http.StatusServiceUnavailable, "Audit service not available")
+		respondError(c, err, http.StatusInternalServerError)
 		return
 	}
+	h.setTokenCookies(c, tokens)
+	c.JSON(http.StatusOK, tokens)
+}
 
-	// Get query parameters for filtering
-	limit := 50 // default limit
-	offset := 0
-	userID := c.Query("user_id")
-	result := c.Query("result")
-	resource := c.Query("resource")
-
-	if limitStr := c.Query("limit"); limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 1000 {
-			limit = l
-		}
-	}
-	if offsetStr := c.Query("offset"); offsetStr != "" {
-		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
-			offset = o
-		}
-	}
-
-	var auditLogs *[]service.AuditLogDTO
-	var err error
+// setTokenCookies stores a token pair in the access and refresh token cookies
+func (h *AdminHandler) setTokenCookies(c *gin.Context, tokens *dto.AdminTokenPair) {
+	h.setAccessTokenCookie(c, tokens.AccessToken, int(tokens.ExpiresIn))
+	c.SetCookie(
+		adminRefreshTokenCookie,
+		tokens.RefreshToken,
+		int(tokens.RefreshExpiresIn),
+		adminRefreshTokenPath,
+		"",
+		false,
+		true,
+	)
+}
 
-	// Apply filters
-	if userID != "" {
-		auditLogs, err = h.auditService.GetAuditLogsByUser(userID, limit, offset)
-	} else if result != "" {
-		auditLogs, err = h.auditService.GetAuditLogsByResult(result, limit, offset)
-	} else if resource != "" {
-		auditLogs, err = h.auditService.GetAuditLogsByResource(resource, limit, offset)
-	} else {
-		auditLogs, err = h.auditService.GetAuditLogs(limit, offset)
-	}
+// setAccessTokenCookie stores the access token for API requests from the dashboard
+func (h *AdminHandler) setAccessTokenCookie(c *gin.Context, token string, maxAge int) {
+	c.SetCookie(
+		"jwt_token",
+		token,
+		maxAge,
+		"/",
+		"",
+		false,
+		true,
+	)
+}
 
-	if err != nil {
-		logger.Error("Failed to get audit logs", zap.Error(err))
-		c.String(http.StatusInternalServerError, "Failed to load audit logs")
-		return
-	}
+func (h *AdminHandler) generateJWTToken(username, userID, role string) string {
 
-	if auditLogs == nil {
-		auditLogs = &[]service.AuditLogDTO{}
+	claims := jwt.MapClaims{
+		"username": username,
+		"role":     role,
+		"user_id":  userID,
+		"exp":      time.Now().Add(time.Hour * 24).Unix(),
+		"iat":      time.Now().Unix(),
 	}
-
-	// Get audit summary
-	summary, err := h.auditService.GetAuditSummary()
+	tokenString, err := service.GenerateToken(claims)
 	if err != nil {
-		logger.Warn("Failed to get audit summary", zap.Error(err))
-		summary = &service.AuditSummaryDTO{}
-	}
-
-	// Create audit logs page data
-	auditData := templates.AuditLogsPageData{
-		AuditLogs: *auditLogs,
-		Summary:   *summary,
-		CurrentFilter: map[string]string{
-			"user_id":  userID,
-			"result":   result,
-			"resource": resource,
-		},
-		Limit:  limit,
-		Offset: offset,
+		log.Println("Error generating JWT token:", err)
+		return ""
 	}
 
-	// Render Templ template
-	templ.Handler(templates.AuditLogsPage(auditData)).ServeHTTP(c.Writer, c.Request)
+	return tokenString
 }
 
 // GetFeaturesDocumentationPage renders the comprehensive features documentation page
 // Shows all framework capabilities, architecture, and integration examples
-func (h *performanceHandler) GetFeaturesDocumentationPage(c *gin.Context) {
+func (h *AdminHandler) GetFeaturesDocumentationPage(c *gin.Context) {
 	// Get route count
 	routeMetadata, err := enterprise.LoadEnterpriseRouteMetadata("")
 	totalEndpoints := 0
@@ -602,344 +812,3 @@ func (h *performanceHandler) GetFeaturesDocumentationPage(c *gin.Context) {
 	// Render Templ template
 	templ.Handler(templates.FeaturesDocumentationPage(featuresData)).ServeHTTP(c.Writer, c.Request)
 }
-
-// CreateRole creates a new role
-func (h *performanceHandler) CreateRole(c *gin.Context) {
-	req, err := helperImpl.GetJSONDataFromRequest[struct {
-		Name        string `json:"name" binding:"required"`
-		Description string `json:"description"`
-	}](c)
-	if err != nil {
-		helperImpl.SendBadRequest(c, err.Error())
-		return
-	}
-
-	err = h.profileService.CreateRole(req.Name, req.Description)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusCreated, gin.H{"message": "Role created successfully", "role": req.Name})
-}
-
-// UpdateRole updates an existing role's name and/or description
-func (h *performanceHandler) UpdateRole(c *gin.Context) {
-	var req struct {
-		OldName     string `json:"old_name" binding:"required"`
-		NewName     string `json:"new_name"`
-		Description string `json:"description"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	// If new name is empty, use old name (only updating description)
-	if req.NewName == "" {
-		req.NewName = req.OldName
-	}
-
-	err := h.profileService.UpdateRole(req.OldName, req.NewName, req.Description)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "Role updated successfully", "role": req.NewName})
-}
-
-// AssignRoleToUser assigns a role to a user
-func (h *performanceHandler) AssignRoleToUser(c *gin.Context) {
-	var req struct {
-		UserID string `json:"user_id" binding:"required"`
-		Role   string `json:"role" binding:"required"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	err := h.profileService.AssignRoleToUser(req.UserID, req.Role)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "Role assigned successfully"})
-}
-
-// RemoveRoleFromUser removes a role from a user
-func (h *performanceHandler) RemoveRoleFromUser(c *gin.Context) {
-	var req struct {
-		UserID string `json:"user_id" binding:"required"`
-		Role   string `json:"role" binding:"required"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	err := h.profileService.RemoveRoleFromUser(req.UserID, req.Role)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "Role removed successfully"})
-}
-
-// GetUsersForRole returns users assigned to a specific role
-func (h *performanceHandler) GetUsersForRole(c *gin.Context) {
-	role := c.Query("role")
-	if role == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "role parameter is required"})
-		return
-	}
-
-	users, err := h.profileService.GetUsersForRole(role)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"role": role, "users": users})
-}
-
-// DeleteRole deletes a role and all its assignments
-func (h *performanceHandler) DeleteRole(c *gin.Context) {
-	var req struct {
-		Role string `json:"role" binding:"required"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	err := h.profileService.DeleteRole(req.Role)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "Role deleted successfully"})
-}
-
-// GetRoleDetailsPage renders the detailed view of a specific role
-// Shows users, permissions, and role information
-func (h *performanceHandler) GetRoleDetailsPage(c *gin.Context) {
-	roleName := c.Param("role")
-	if roleName == "" {
-		c.String(http.StatusBadRequest, "Role name is required")
-		return
-	}
-
-	// Get role details from service
-	roleDetails, err := h.profileService.GetRoleDetails(roleName)
-	if err != nil {
-		logger.Error("Failed to get role details", zap.Error(err), zap.String("role", roleName))
-		c.String(http.StatusInternalServerError, "Failed to load role details")
-		return
-	}
-
-	// Extract data from map
-	description := ""
-	if desc, ok := roleDetails["description"].(string); ok {
-		description = desc
-	}
-
-	userCount := 0
-	if count, ok := roleDetails["user_count"].(int); ok {
-		userCount = count
-	}
-
-	users := []string{}
-	if userList, ok := roleDetails["users"].([]string); ok {
-		users = userList
-	}
-
-	permissions := []map[string]string{}
-	if permList, ok := roleDetails["permissions"].([]map[string]string); ok {
-		permissions = permList
-	}
-
-	// Create page data
-	pageData := templates.RoleDetailsPageData{
-		RoleName:    roleName,
-		Description: description,
-		UserCount:   userCount,
-		Users:       users,
-		Permissions: permissions,
-	}
-
-	// Render Templ template
-	templ.Handler(templates.RoleDetailsPage(pageData)).ServeHTTP(c.Writer, c.Request)
-}
-
-// SaveRouteMetadata handles saving updated route metadata
-func (h *performanceHandler) SaveRouteMetadata(c *gin.Context) {
-	// Parse the JSON payload
-	var updateRequest struct {
-		Routes []*enterprise.RouteMetadata `json:"routes"`
-	}
-
-	if err := c.ShouldBindJSON(&updateRequest); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON payload"})
-		return
-	}
-
-	// Validate all routes
-	for _, route := range updateRequest.Routes {
-		if err := route.Validate(); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid route %s %s: %v", route.Method, route.Path, err)})
-			return
-		}
-	}
-
-	// Save to file
-	if err := enterprise.SaveEnterpriseRouteMetadata(updateRequest.Routes, ""); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save route metadata"})
-		return
-	}
-
-	// Update Casbin policies based on the new route metadata
-	if err := enterprise.UpdateCasbinPoliciesFromRoutes(updateRequest.Routes, ""); err != nil {
-		logger.GetLogger().Warn("Failed to update Casbin policies after route metadata save", zap.Error(err))
-		// Don't fail the request, just log the warning
-	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "Route metadata saved successfully"})
-}
-
-// ImportRouteMetadata handles importing route metadata from JSON
-func (h *performanceHandler) ImportRouteMetadata(c *gin.Context) {
-	var importRequest struct {
-		Routes []*enterprise.RouteMetadata `json:"routes"`
-	}
-
-	if err := c.ShouldBindJSON(&importRequest); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON payload"})
-		return
-	}
-
-	if len(importRequest.Routes) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Routes list cannot be empty"})
-		return
-	}
-
-	// Validate all routes
-	for _, route := range importRequest.Routes {
-		if err := route.Validate(); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid route %s %s: %v", route.Method, route.Path, err)})
-			return
-		}
-	}
-
-	// Load existing routes
-	existingRoutes, _ := enterprise.LoadEnterpriseRouteMetadata("")
-	if existingRoutes == nil {
-		existingRoutes = make([]*enterprise.RouteMetadata, 0)
-	}
-
-	// Create a map of existing routes by method:path for easy lookup
-	existingMap := make(map[string]*enterprise.RouteMetadata)
-	for _, route := range existingRoutes {
-		key := fmt.Sprintf("%s:%s", route.Method, route.Path)
-		existingMap[key] = route
-	}
-
-	// Merge imported routes with existing ones (imported routes override existing)
-	mergedRoutes := make([]*enterprise.RouteMetadata, 0, len(existingRoutes))
-	importedKeys := make(map[string]bool)
-
-	// Add imported routes first
-	for _, route := range importRequest.Routes {
-		key := fmt.Sprintf("%s:%s", route.Method, route.Path)
-		mergedRoutes = append(mergedRoutes, route)
-		importedKeys[key] = true
-	}
-
-	// Add existing routes that weren't imported
-	for key, route := range existingMap {
-		if !importedKeys[key] {
-			mergedRoutes = append(mergedRoutes, route)
-		}
-	}
-
-	// Save merged routes to file
-	if err := enterprise.SaveEnterpriseRouteMetadata(mergedRoutes, ""); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save imported routes"})
-		return
-	}
-
-	// Update Casbin policies based on the merged route metadata
-	if err := enterprise.UpdateCasbinPoliciesFromRoutes(mergedRoutes, ""); err != nil {
-		logger.GetLogger().Warn("Failed to update Casbin policies after route import", zap.Error(err))
-		// Don't fail the request, just log the warning
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"message":  "Route metadata imported successfully",
-		"imported": len(importRequest.Routes),
-		"total":    len(mergedRoutes),
-	})
-}
-
-// DeleteRouteMetadata handles deleting a specific route
-func (h *performanceHandler) DeleteRouteMetadata(c *gin.Context) {
-	var deleteRequest struct {
-		Method string `json:"method"`
-		Path   string `json:"path"`
-	}
-
-	if err := c.ShouldBindJSON(&deleteRequest); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON payload"})
-		return
-	}
-
-	if deleteRequest.Method == "" || deleteRequest.Path == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Method and path are required"})
-		return
-	}
-
-	// Load all routes
-	routes, err := enterprise.LoadEnterpriseRouteMetadata("")
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load route metadata"})
-		return
-	}
-
-	// Find and remove the route
-	var updatedRoutes []*enterprise.RouteMetadata
-	found := false
-	for _, route := range routes {
-		if route.Method == deleteRequest.Method && route.Path == deleteRequest.Path {
-			found = true
-			continue // Skip this route (delete it)
-		}
-		updatedRoutes = append(updatedRoutes, route)
-	}
-
-	if !found {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Route not found"})
-		return
-	}
-
-	// Save updated routes
-	if err := enterprise.SaveEnterpriseRouteMetadata(updatedRoutes, ""); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete route"})
-		return
-	}
-
-	// Update Casbin policies based on the updated route metadata
-	if err := enterprise.UpdateCasbinPoliciesFromRoutes(updatedRoutes, ""); err != nil {
-		logger.GetLogger().Warn("Failed to update Casbin policies after route deletion", zap.Error(err))
-		// Don't fail the request, just log the warning
-	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "Route deleted successfully"})
-}
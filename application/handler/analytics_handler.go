@@ -0,0 +1,363 @@
+package handler
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/a-h/templ"
+	"github.com/aruncs31s/azf/application/service"
+	"github.com/aruncs31s/azf/application/templates"
+	"github.com/aruncs31s/azf/domain/api_usage"
+	"github.com/aruncs31s/azf/shared/logger"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// AnalyticsHandler serves the API usage analytics pages and reports
+type AnalyticsHandler struct {
+	apiUsageAnalytics service.APIUsageAnalyticsService
+	annotationService service.UsageAnnotationService
+	userLookup        service.UserLookupService
+}
+
+// NewAnalyticsHandler creates a new analytics handler
+func NewAnalyticsHandler(
+	apiUsageAnalytics service.APIUsageAnalyticsService,
+	annotationService service.UsageAnnotationService,
+	userLookup service.UserLookupService,
+) (*AnalyticsHandler, error) {
+	if err := requireDependencies("AnalyticsHandler",
+		dep("apiUsageAnalytics", apiUsageAnalytics),
+		dep("annotationService", annotationService),
+		dep("userLookup", userLookup),
+	); err != nil {
+		return nil, err
+	}
+	return &AnalyticsHandler{
+		apiUsageAnalytics: apiUsageAnalytics,
+		annotationService: annotationService,
+		userLookup:        userLookup,
+	}, nil
+}
+
+// RegisterRoutes registers the analytics pages and API behind auth
+func (h *AnalyticsHandler) RegisterRoutes(r gin.IRoutes, auth gin.HandlerFunc) {
+	r.GET("/admin-ui/api_analytics", auth, h.GetAPIAnalyticsPage)
+	r.GET("/admin-ui/api_analytics/endpoint", auth, h.GetEndpointDetailsPage)
+	r.GET("/admin-ui/top_consumers", auth, h.GetTopConsumersPage)
+	r.GET("/admin-ui/api/analytics/heatmap", auth, h.GetLatencyHeatmap)
+	r.GET("/admin-ui/api/analytics/rankings", auth, h.GetEndpointRankings)
+	r.GET("/admin-ui/api/analytics/top-consumers", auth, h.GetTopConsumers)
+	r.GET("/admin-ui/api/analytics/reports/monthly", auth, h.GetMonthlyUsageReport)
+}
+
+// GetAPIAnalyticsPage renders the dedicated API Analytics page
+// It shows comprehensive API usage statistics and performance metrics
+func (h *AnalyticsHandler) GetAPIAnalyticsPage(c *gin.Context) {
+	// Get top endpoints
+	topEndpoints, err := h.apiUsageAnalytics.GetTopEndpointsByUsage(10)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Failed to load API analytics")
+		return
+	}
+	if topEndpoints == nil {
+		topEndpoints = &[]api_usage.APIEndpointRanking{}
+	}
+
+	// Get slowest endpoints
+	slowestEndpoints, err := h.apiUsageAnalytics.GetEndpointsByResponseTime(10)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Failed to load API analytics")
+		return
+	}
+	if slowestEndpoints == nil {
+		slowestEndpoints = &[]api_usage.APIEndpointRanking{}
+	}
+
+	// Get most errored endpoints
+	erroredEndpoints, err := h.apiUsageAnalytics.GetEndpointsByErrorRate(10)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Failed to load API analytics")
+		return
+	}
+	if erroredEndpoints == nil {
+		erroredEndpoints = &[]api_usage.APIEndpointRanking{}
+	}
+
+	// Get usage summary
+	usageSummary, err := h.apiUsageAnalytics.GetUsageSummary()
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Failed to load API analytics")
+		return
+	}
+	if usageSummary == nil {
+		usageSummary = &service.UsageSummaryDTO{}
+	}
+
+	// Get trend data
+	trendData, err := h.apiUsageAnalytics.GetUsageTrend(7)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Failed to load API analytics")
+		return
+	}
+	if trendData == nil {
+		trendData = &[]service.UsageTrendDTO{}
+	}
+
+	// Get incident/deployment annotations covering the trend window
+	now := time.Now()
+	trendStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, -6)
+	annotations, err := h.annotationService.ListAnnotations(trendStart, now)
+	if err != nil {
+		logger.Warn("Failed to load usage annotations", zap.Error(err))
+	}
+	if annotations == nil {
+		annotations = &[]service.UsageAnnotationDTO{}
+	}
+
+	// Create analytics data structure for Templ
+	analyticsData := templates.APIAnalyticsPageData{
+		GeneratedAt:          time.Now(),
+		TopEndpoints:         *topEndpoints,
+		SlowestEndpoints:     *slowestEndpoints,
+		MostErroredEndpoints: *erroredEndpoints,
+		UsageSummary:         *usageSummary,
+		TrendData:            *trendData,
+		Annotations:          *annotations,
+	}
+
+	// Render Templ template
+	templ.Handler(templates.APIAnalyticsPage(analyticsData)).ServeHTTP(c.Writer, c.Request)
+}
+
+// GetEndpointDetailsPage renders the detailed view of who called a specific endpoint
+func (h *AnalyticsHandler) GetEndpointDetailsPage(c *gin.Context) {
+	endpoint := c.Query("endpoint")
+	method := c.Query("method")
+
+	if endpoint == "" {
+		c.String(http.StatusBadRequest, "Endpoint parameter is required")
+		return
+	}
+
+	// Get endpoint details
+	details, err := h.apiUsageAnalytics.GetEndpointDetails(endpoint)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Failed to load endpoint details")
+		return
+	}
+
+	// Get callers
+	callers, err := h.apiUsageAnalytics.GetEndpointCallers(endpoint, 50)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Failed to load endpoint callers")
+		return
+	}
+
+	// Show who the callers are rather than their raw IDs
+	callerIDs := make([]string, len(*callers))
+	for i, caller := range *callers {
+		callerIDs[i] = caller.UserID
+	}
+	callerUsers := h.userLookup.Resolve(callerIDs)
+	for i := range *callers {
+		user := callerUsers[(*callers)[i].UserID]
+		(*callers)[i].User = &user
+	}
+
+	// Create page data
+	pageData := templates.EndpointDetailsPageData{
+		Endpoint: endpoint,
+		Method:   method,
+		Details:  *details,
+		Callers:  *callers,
+	}
+
+	// Render Templ template
+	templ.Handler(templates.EndpointDetailsPage(pageData)).ServeHTTP(c.Writer, c.Request)
+}
+
+// GetLatencyHeatmap returns requests and average latency bucketed by weekday and hour-of-day
+func (h *AnalyticsHandler) GetLatencyHeatmap(c *gin.Context) {
+	days := 28
+	if daysStr := c.Query("days"); daysStr != "" {
+		d, err := strconv.Atoi(daysStr)
+		if err != nil || d <= 0 || d > 365 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 365"})
+			return
+		}
+		days = d
+	}
+
+	heatmap, err := h.apiUsageAnalytics.GetLatencyHeatmap(days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, heatmap)
+}
+
+// GetEndpointRankings returns a page of endpoints sorted by
+// ?sort_by=requests|error_rate|error_rate_wilson|p95|avg_response_time|last_24h and ?order=asc|desc.
+// Endpoints with fewer than ?min_requests requests are left out.
+func (h *AnalyticsHandler) GetEndpointRankings(c *gin.Context) {
+	query, err := parseEndpointRankingQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rankings, err := h.apiUsageAnalytics.GetEndpointRankings(query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rankings)
+}
+
+// parseEndpointRankingQuery reads the sort, threshold and pagination query parameters
+func parseEndpointRankingQuery(c *gin.Context) (api_usage.EndpointRankingQuery, error) {
+	query := api_usage.EndpointRankingQuery{
+		SortBy: c.DefaultQuery("sort_by", api_usage.RankingSortRequests),
+	}
+	if !api_usage.IsValidRankingSort(query.SortBy) {
+		return query, fmt.Errorf("sort_by must be one of: requests, error_rate, error_rate_wilson, p95, avg_response_time, last_24h")
+	}
+
+	switch c.DefaultQuery("order", "desc") {
+	case "asc":
+		query.Ascending = true
+	case "desc":
+	default:
+		return query, fmt.Errorf("order must be asc or desc")
+	}
+
+	minRequests, err := strconv.ParseInt(c.DefaultQuery("min_requests", "0"), 10, 64)
+	if err != nil || minRequests < 0 {
+		return query, fmt.Errorf("min_requests must be a non-negative number")
+	}
+	query.MinRequests = minRequests
+
+	query.Limit, err = strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || query.Limit <= 0 || query.Limit > 100 {
+		return query, fmt.Errorf("limit must be between 1 and 100")
+	}
+
+	query.Offset, err = strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || query.Offset < 0 {
+		return query, fmt.Errorf("offset must be a non-negative number")
+	}
+
+	return query, nil
+}
+
+// GetTopConsumersPage renders the top consumers view
+func (h *AnalyticsHandler) GetTopConsumersPage(c *gin.Context) {
+	dimension, days, err := parseTopConsumersQuery(c)
+	if err != nil {
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	consumers, err := h.apiUsageAnalytics.GetTopConsumers(dimension, days, 25)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Failed to load top consumers")
+		return
+	}
+
+	templ.Handler(templates.TopConsumersPage(templates.TopConsumersPageData{
+		GeneratedAt:  time.Now(),
+		TopConsumers: *consumers,
+	})).ServeHTTP(c.Writer, c.Request)
+}
+
+// GetTopConsumers returns the top consumers as JSON
+func (h *AnalyticsHandler) GetTopConsumers(c *gin.Context) {
+	dimension, days, err := parseTopConsumersQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit := 25
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+
+	consumers, err := h.apiUsageAnalytics.GetTopConsumers(dimension, days, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, consumers)
+}
+
+// parseTopConsumersQuery reads the dimension (user, ip, api_key) and window (days) query parameters
+func parseTopConsumersQuery(c *gin.Context) (string, int, error) {
+	dimension := c.DefaultQuery("dimension", api_usage.ClientDimensionUser)
+	if dimension != api_usage.ClientDimensionUser && dimension != api_usage.ClientDimensionIP && dimension != api_usage.ClientDimensionAPIKey {
+		return "", 0, fmt.Errorf("dimension must be one of: user, ip, api_key")
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "7"))
+	if err != nil || days <= 0 || days > 90 {
+		return "", 0, fmt.Errorf("days must be between 1 and 90")
+	}
+
+	return dimension, days, nil
+}
+
+// GetMonthlyUsageReport returns per-user consumption for ?month=YYYY-MM (default: current month).
+// Pass format=csv to download the report as CSV.
+func (h *AnalyticsHandler) GetMonthlyUsageReport(c *gin.Context) {
+	month := time.Now()
+	if monthStr := c.Query("month"); monthStr != "" {
+		parsed, err := time.ParseInLocation("2006-01", monthStr, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "month must be in YYYY-MM format"})
+			return
+		}
+		month = parsed
+	}
+
+	report, err := h.apiUsageAnalytics.GetMonthlyUsageReport(month)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if c.Query("format") != "csv" {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=usage_report_%s.csv", report.Month))
+	c.Header("Content-Type", "text/csv")
+	c.Status(http.StatusOK)
+
+	writer := csv.NewWriter(c.Writer)
+	_ = writer.Write([]string{"Month", "User", "Requests", "Errors", "Bytes In", "Bytes Out"})
+	for _, row := range append(report.Rows, report.Totals) {
+		identity := row.Identity
+		if identity == "" {
+			identity = "TOTAL"
+		}
+		_ = writer.Write([]string{
+			report.Month,
+			identity,
+			strconv.FormatInt(row.TotalRequests, 10),
+			strconv.FormatInt(row.ErrorRequests, 10),
+			strconv.FormatInt(row.RequestBytes, 10),
+			strconv.FormatInt(row.ResponseBytes, 10),
+		})
+	}
+	writer.Flush()
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aruncs31s/azf/application/service"
+	"github.com/gin-gonic/gin"
+)
+
+// AnnotationHandler manages incident/deployment annotations on analytics charts
+type AnnotationHandler struct {
+	annotationService service.UsageAnnotationService
+}
+
+// NewAnnotationHandler creates a new annotation handler
+func NewAnnotationHandler(annotationService service.UsageAnnotationService) (*AnnotationHandler, error) {
+	if err := requireDependencies("AnnotationHandler", dep("annotationService", annotationService)); err != nil {
+		return nil, err
+	}
+	return &AnnotationHandler{
+		annotationService: annotationService,
+	}, nil
+}
+
+// ListAnnotations returns annotations for the last N days (default 7)
+func (h *AnnotationHandler) ListAnnotations(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "7"))
+	if err != nil || days <= 0 || days > 365 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 365"})
+		return
+	}
+
+	to := time.Now()
+	from := to.AddDate(0, 0, -days)
+	annotations, err := h.annotationService.ListAnnotations(from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"annotations": annotations})
+}
+
+// CreateAnnotation records a new incident or deployment annotation
+func (h *AnnotationHandler) CreateAnnotation(c *gin.Context) {
+	var req service.CreateUsageAnnotationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	annotation, err := h.annotationService.CreateAnnotation(req, "admin")
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "Annotation created",
+		"annotation": annotation,
+	})
+}
+
+// DeleteAnnotation removes an annotation
+func (h *AnnotationHandler) DeleteAnnotation(c *gin.Context) {
+	id := c.Param("id")
+	if err := h.annotationService.DeleteAnnotation(id); err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Annotation deleted"})
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/a-h/templ"
+	"github.com/aruncs31s/azf/application/service"
+	"github.com/aruncs31s/azf/application/templates"
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHandler issues and revokes API keys
+type APIKeyHandler struct {
+	apiKeyService service.APIKeyService
+}
+
+// NewAPIKeyHandler creates a new API key handler
+func NewAPIKeyHandler(apiKeyService service.APIKeyService) (*APIKeyHandler, error) {
+	if err := requireDependencies("APIKeyHandler", dep("apiKeyService", apiKeyService)); err != nil {
+		return nil, err
+	}
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+	}, nil
+}
+
+// GetAPIKeysPage renders the issued API keys
+func (h *APIKeyHandler) GetAPIKeysPage(c *gin.Context) {
+	keys, err := h.apiKeyService.ListKeys(c.Request.Context())
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Failed to load API keys")
+		return
+	}
+
+	data := templates.APIKeysPageData{
+		GeneratedAt: time.Now(),
+		Keys:        *keys,
+	}
+	templ.Handler(templates.APIKeysPage(data)).ServeHTTP(c.Writer, c.Request)
+}
+
+// ListKeys returns all issued API keys, without the keys themselves
+func (h *APIKeyHandler) ListKeys(c *gin.Context) {
+	keys, err := h.apiKeyService.ListKeys(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"api_keys": keys})
+}
+
+// IssueKey issues a new API key; the key is only returned here
+func (h *APIKeyHandler) IssueKey(c *gin.Context) {
+	var req service.IssueAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	createdBy := AdminUsername(c)
+	if createdBy == "" {
+		createdBy = "admin"
+	}
+	key, err := h.apiKeyService.IssueKey(c.Request.Context(), req, createdBy)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "API key issued; store it now, it will not be shown again",
+		"api_key": key,
+	})
+}
+
+// RevokeKey revokes an API key
+func (h *APIKeyHandler) RevokeKey(c *gin.Context) {
+	if err := h.apiKeyService.RevokeKey(c.Request.Context(), c.Param("id")); err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked"})
+}
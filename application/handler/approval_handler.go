@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/aruncs31s/azf/application/service"
+	"github.com/gin-gonic/gin"
+)
+
+// ApprovalHandler lets superadmins decide the approval requests of other admins
+type ApprovalHandler struct {
+	approvals service.ApprovalService
+}
+
+// NewApprovalHandler creates a new approval handler
+func NewApprovalHandler(approvals service.ApprovalService) (*ApprovalHandler, error) {
+	if err := requireDependencies("ApprovalHandler", dep("approvals", approvals)); err != nil {
+		return nil, err
+	}
+	return &ApprovalHandler{
+		approvals: approvals,
+	}, nil
+}
+
+// ListApprovals returns the pending and approved requests
+func (h *ApprovalHandler) ListApprovals(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"approvals": h.approvals.ListOpen(),
+		"username":  AdminUsername(c),
+	})
+}
+
+// ApproveRequest approves a pending request; superadmins other than the requester only
+func (h *ApprovalHandler) ApproveRequest(c *gin.Context) {
+	approval, err := h.approvals.Approve(c.Param("id"), AdminUsername(c))
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Request approved",
+		"approval": approval,
+	})
+}
+
+// RejectRequest rejects a pending request; superadmins other than the requester only
+func (h *ApprovalHandler) RejectRequest(c *gin.Context) {
+	approval, err := h.approvals.Reject(c.Param("id"), AdminUsername(c))
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Request rejected",
+		"approval": approval,
+	})
+}
+
+// respondRoleChangeError writes the error of a role change. A change waiting
+// for approval is accepted with its approval request; a self-lockout is
+// flagged so the client can offer to request an override.
+func respondRoleChangeError(c *gin.Context, err error) {
+	var pending *service.ApprovalPendingError
+	if errors.As(err, &pending) {
+		c.JSON(http.StatusAccepted, gin.H{
+			"message":  err.Error(),
+			"approval": pending.Approval,
+		})
+		return
+	}
+	if errors.Is(err, service.ErrSelfLockout) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "lockout": true})
+		return
+	}
+	respondError(c, err, http.StatusInternalServerError)
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/a-h/templ"
+	"github.com/aruncs31s/azf/application/service"
+	"github.com/aruncs31s/azf/application/templates"
+	"github.com/aruncs31s/azf/infrastructure/enterprise"
+	"github.com/aruncs31s/azf/shared/logger"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// AuditHandler serves the authorization audit log page
+type AuditHandler struct {
+	auditService service.AuthorizationAuditService
+}
+
+// NewAuditHandler creates a new audit handler. auditService may be nil; it
+// is then created once enterprise authorization is set up.
+func NewAuditHandler(auditService service.AuthorizationAuditService) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+	}
+}
+
+// RegisterRoutes registers the audit log page behind auth
+func (h *AuditHandler) RegisterRoutes(r gin.IRoutes, auth gin.HandlerFunc) {
+	r.GET("/admin-ui/audit_logs", auth, h.GetAuditLogsPage)
+}
+
+// GetAuditLogsPage renders the Authorization Audit Logs page
+// Shows comprehensive audit trail of authorization decisions
+func (h *AuditHandler) GetAuditLogsPage(c *gin.Context) {
+	// Lazy initialization of audit service
+	if h.auditService == nil && enterprise.EnterpriseAuth != nil {
+		auditRepo := enterprise.EnterpriseAuth.GetAuditRepository()
+		if auditRepo != nil {
+			h.auditService = service.NewAuthorizationAuditService(auditRepo)
+		}
+	}
+
+	if h.auditService == nil {
+		c.String(http.StatusServiceUnavailable, "Audit service not available")
+		return
+	}
+
+	// Get query parameters for filtering; the page shows the last 24 hours
+	// unless another time range is chosen
+	limit := 50 // default limit
+	offset := 0
+	filter, err := parseAuditFilter(c, "24h")
+	if err != nil {
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	timeRange := c.Query("range")
+	if timeRange == "" {
+		timeRange = "24h"
+		if c.Query("from") != "" || c.Query("to") != "" {
+			timeRange = "custom"
+		}
+	}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 1000 {
+			limit = l
+		}
+	}
+	if offsetStr := c.Query("offset"); offsetStr != "" {
+		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
+			offset = o
+		}
+	}
+
+	auditLogs, err := h.auditService.FindAuditLogs(c.Request.Context(), filter, limit, offset)
+	if err != nil {
+		logger.Error("Failed to get audit logs", zap.Error(err))
+		c.String(http.StatusInternalServerError, "Failed to load audit logs")
+		return
+	}
+
+	if auditLogs == nil {
+		auditLogs = &[]service.AuditLogDTO{}
+	}
+
+	// Get audit summary of the same logs
+	summary, err := h.auditService.GetAuditSummary(c.Request.Context(), filter)
+	if err != nil {
+		logger.Warn("Failed to get audit summary", zap.Error(err))
+		summary = &service.AuditSummaryDTO{}
+	}
+
+	// Create audit logs page data
+	auditData := templates.AuditLogsPageData{
+		AuditLogs: *auditLogs,
+		Summary:   *summary,
+		CurrentFilter: map[string]string{
+			"user_id":  filter.UserID,
+			"result":   filter.Result,
+			"resource": filter.Resource,
+			"field":    filter.Field,
+			"value":    filter.Value,
+			"range":    timeRange,
+			"from":     c.Query("from"),
+			"to":       c.Query("to"),
+		},
+		AuditFields: sortedAuditFields(h.auditService),
+		Limit:       limit,
+		Offset:      offset,
+	}
+
+	// Render Templ template
+	templ.Handler(templates.AuditLogsPage(auditData)).ServeHTTP(c.Writer, c.Request)
+}
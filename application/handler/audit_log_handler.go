@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aruncs31s/azf/application/service"
+	"github.com/aruncs31s/azf/shared/logger"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// AuditLogHandler serves the authorization audit trail as JSON
+type AuditLogHandler struct {
+	auditService service.AuthorizationAuditService
+}
+
+// NewAuditLogHandler creates a new audit log handler; auditService may be nil
+// when enterprise authorization is not set up
+func NewAuditLogHandler(auditService service.AuthorizationAuditService) *AuditLogHandler {
+	return &AuditLogHandler{
+		auditService: auditService,
+	}
+}
+
+// ListAuditLogs returns the audit logs matching all of ?request_id=, ?user_id=,
+// ?result=, ?resource=, ?field=&value= for custom audit fields and the time
+// range (see parseAuditFilter); supports ?limit= and ?offset=
+func (h *AuditLogHandler) ListAuditLogs(c *gin.Context) {
+	if h.auditService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Audit service not available"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	if limit <= 0 {
+		limit = 50
+	} else if limit > 1000 {
+		limit = 1000
+	}
+	offset, _ := strconv.Atoi(c.Query("offset"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	filter, err := parseAuditFilter(c, "")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	auditLogs, err := h.auditService.FindAuditLogs(c.Request.Context(), filter, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"logs":   auditLogs,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// GetAuditSummary returns statistics of the audit logs matching the filters
+// and time range ListAuditLogs accepts
+func (h *AuditLogHandler) GetAuditSummary(c *gin.Context) {
+	if h.auditService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Audit service not available"})
+		return
+	}
+
+	filter, err := parseAuditFilter(c, "")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	summary, err := h.auditService.GetAuditSummary(c.Request.Context(), filter)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// ListAuditFields returns the registered custom audit fields and their types
+func (h *AuditLogHandler) ListAuditFields(c *gin.Context) {
+	if h.auditService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Audit service not available"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"fields": h.auditService.GetAuditFields()})
+}
+
+// ExportAuditLogs streams audit logs as a download; supports ?format=csv|jsonl|parquet
+// and the filters and time range ListAuditLogs accepts
+func (h *AuditLogHandler) ExportAuditLogs(c *gin.Context) {
+	if h.auditService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Audit service not available"})
+		return
+	}
+
+	format, err := service.ParseAuditExportFormat(c.DefaultQuery("format", string(service.AuditExportCSV)))
+	if err != nil {
+		respondError(c, err, http.StatusBadRequest)
+		return
+	}
+	filter, err := parseAuditFilter(c, "")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("audit_logs_%s.%s", time.Now().UTC().Format("20060102T150405Z"), format)
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Header("Content-Type", format.ContentType())
+	c.Status(http.StatusOK)
+
+	exported, err := h.auditService.ExportAuditLogs(c.Request.Context(), c.Writer, format, filter)
+	if err != nil {
+		// Errors before the first chunk can still be reported; later ones
+		// leave a truncated download
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Disposition")
+			c.Writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+			respondError(c, err, http.StatusInternalServerError)
+			return
+		}
+		logger.Error("Audit log export aborted", zap.Error(err), zap.Int64("exported", exported))
+		return
+	}
+	logger.Info("Exported audit logs", zap.String("format", string(format)), zap.Int64("exported", exported))
+}
+
+// parseExportTime parses an RFC 3339 time, a datetime-local value or a date;
+// a date used as an end time covers the whole day. Empty values give zero.
+func parseExportTime(value string, end bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02T15:04", value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if end {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
+
+// auditTimeRanges are the presets accepted by ?range=, as durations back
+// from now; "all" selects no range
+var auditTimeRanges = map[string]time.Duration{
+	"1h":  time.Hour,
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+	"all": 0,
+}
+
+// parseAuditFilter reads the audit log filters of the query. The time range
+// is a ?range= preset (1h, 24h, 7d, 30d or all), or ?from= and ?to= (also
+// accepted as ?start= and ?end=) as RFC 3339 times or dates; defaultRange
+// applies when none is given.
+func parseAuditFilter(c *gin.Context, defaultRange string) (service.AuditLogFilter, error) {
+	filter := service.AuditLogFilter{
+		RequestID: c.Query("request_id"),
+		UserID:    c.Query("user_id"),
+		Result:    c.Query("result"),
+		Resource:  c.Query("resource"),
+		Field:     c.Query("field"),
+		Value:     c.Query("value"),
+	}
+
+	from := c.DefaultQuery("from", c.Query("start"))
+	to := c.DefaultQuery("to", c.Query("end"))
+	preset := c.Query("range")
+	if preset == "" && from == "" && to == "" {
+		preset = defaultRange
+	}
+	if preset != "" {
+		window, ok := auditTimeRanges[preset]
+		if !ok {
+			return filter, fmt.Errorf("invalid range %q", preset)
+		}
+		if window > 0 {
+			filter.Start = time.Now().Add(-window)
+		}
+		return filter, nil
+	}
+
+	var err error
+	if filter.Start, err = parseExportTime(from, false); err != nil {
+		return filter, fmt.Errorf("invalid from time")
+	}
+	if filter.End, err = parseExportTime(to, true); err != nil {
+		return filter, fmt.Errorf("invalid to time")
+	}
+	return filter, nil
+}
+
+// sortedAuditFields returns the names of the registered custom audit fields
+func sortedAuditFields(auditService service.AuthorizationAuditService) []string {
+	fields := auditService.GetAuditFields()
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrMissingDependency is returned by handler constructors when a required
+// dependency was not provided, so wiring mistakes fail at startup rather
+// than with a nil pointer panic on the first request
+var ErrMissingDependency = errors.New("missing handler dependency")
+
+// dependency is a named handler dependency checked by requireDependencies
+type dependency struct {
+	name  string
+	value any
+}
+
+// dep names value as a dependency of a handler
+func dep(name string, value any) dependency {
+	return dependency{name: name, value: value}
+}
+
+// requireDependencies returns an ErrMissingDependency error naming every
+// dependency of handler that is nil, including interfaces holding a nil pointer
+func requireDependencies(handler string, deps ...dependency) error {
+	var missing []string
+	for _, d := range deps {
+		if isNil(d.value) {
+			missing = append(missing, d.name)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s: %w: %v", handler, ErrMissingDependency, missing)
+}
+
+func isNil(value any) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		return v.IsNil()
+	}
+	return false
+}
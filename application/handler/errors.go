@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"errors"
+
+	apperrors "github.com/aruncs31s/azf/shared/errors"
+	"github.com/gin-gonic/gin"
+)
+
+// respondError writes err as a JSON error response. Errors tagged with a
+// shared sentinel, such as apperrors.ErrNotFound, get the sentinel's HTTP
+// status; any other error gets fallbackStatus.
+func respondError(c *gin.Context, err error, fallbackStatus int) {
+	c.JSON(errorStatus(err, fallbackStatus), gin.H{"error": err.Error()})
+}
+
+// errorStatus returns the HTTP status for err
+func errorStatus(err error, fallbackStatus int) int {
+	var appErr *apperrors.AppError
+	if errors.As(err, &appErr) {
+		return appErr.HTTPStatus
+	}
+	return fallbackStatus
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/a-h/templ"
+	"github.com/aruncs31s/azf/application/service"
+	"github.com/aruncs31s/azf/application/templates"
+	"github.com/gin-gonic/gin"
+)
+
+// FeatureFlagHandler switches AZF's own subsystems at runtime
+type FeatureFlagHandler struct {
+	featureFlagService service.FeatureFlagService
+}
+
+// NewFeatureFlagHandler creates a new feature flag handler
+func NewFeatureFlagHandler(featureFlagService service.FeatureFlagService) (*FeatureFlagHandler, error) {
+	if err := requireDependencies("FeatureFlagHandler", dep("featureFlagService", featureFlagService)); err != nil {
+		return nil, err
+	}
+	return &FeatureFlagHandler{
+		featureFlagService: featureFlagService,
+	}, nil
+}
+
+// GetFeatureFlagsPage renders the feature flags page; ?environment= selects
+// another environment than the current one
+func (h *FeatureFlagHandler) GetFeatureFlagsPage(c *gin.Context) {
+	environment := c.DefaultQuery("environment", h.featureFlagService.Environment())
+	flags, err := h.featureFlagService.ListFlags(environment)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Failed to load feature flags")
+		return
+	}
+	changes, err := h.featureFlagService.ListChanges(0)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Failed to load feature flag changes")
+		return
+	}
+
+	data := templates.FeatureFlagsPageData{
+		GeneratedAt:        time.Now(),
+		Environment:        environment,
+		CurrentEnvironment: h.featureFlagService.Environment(),
+		Flags:              *flags,
+		Changes:            *changes,
+	}
+	templ.Handler(templates.FeatureFlagsPage(data)).ServeHTTP(c.Writer, c.Request)
+}
+
+// ListFlags returns every flag with its effective value; supports ?environment=
+func (h *FeatureFlagHandler) ListFlags(c *gin.Context) {
+	environment := c.DefaultQuery("environment", h.featureFlagService.Environment())
+	flags, err := h.featureFlagService.ListFlags(environment)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"environment": environment,
+		"flags":       flags,
+	})
+}
+
+// SetFlag switches a flag on or off
+func (h *FeatureFlagHandler) SetFlag(c *gin.Context) {
+	var req service.SetFeatureFlagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	flag, err := h.featureFlagService.SetFlag(c.Param("name"), req, AdminUsername(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Feature flag updated",
+		"flag":    flag,
+	})
+}
+
+// ListChanges returns the audit trail of flag changes; supports ?limit=
+func (h *FeatureFlagHandler) ListChanges(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	changes, err := h.featureFlagService.ListChanges(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"changes": changes})
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/a-h/templ"
+	"github.com/aruncs31s/azf/application/service"
+	"github.com/aruncs31s/azf/application/templates"
+	"github.com/gin-gonic/gin"
+)
+
+// IncidentHandler serves the notification center
+type IncidentHandler struct {
+	incidentService service.IncidentService
+}
+
+// NewIncidentHandler creates a new incident handler
+func NewIncidentHandler(incidentService service.IncidentService) (*IncidentHandler, error) {
+	if err := requireDependencies("IncidentHandler", dep("incidentService", incidentService)); err != nil {
+		return nil, err
+	}
+	return &IncidentHandler{
+		incidentService: incidentService,
+	}, nil
+}
+
+// GetNotificationCenterPage renders the notification center; ?status= filters the incidents
+func (h *IncidentHandler) GetNotificationCenterPage(c *gin.Context) {
+	status := c.Query("status")
+	incidents, err := h.incidentService.ListIncidents(status, 0)
+	if err != nil {
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data := templates.NotificationCenterPageData{
+		GeneratedAt: time.Now(),
+		Providers:   h.incidentService.Providers(),
+		Incidents:   *incidents,
+		Status:      status,
+	}
+	templ.Handler(templates.NotificationCenterPage(data)).ServeHTTP(c.Writer, c.Request)
+}
+
+// ListIncidents returns recent incidents; supports ?status= and ?limit=
+func (h *IncidentHandler) ListIncidents(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	incidents, err := h.incidentService.ListIncidents(c.Query("status"), limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"providers": h.incidentService.Providers(),
+		"incidents": incidents,
+	})
+}
+
+// SyncIncidents pulls the latest acknowledgment status from the providers
+func (h *IncidentHandler) SyncIncidents(c *gin.Context) {
+	if err := h.incidentService.SyncIncidents(); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Incidents synced"})
+}
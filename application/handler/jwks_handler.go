@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/aruncs31s/azf/infrastructure/token"
+	"github.com/gin-gonic/gin"
+)
+
+// JWKSHandler publishes the public keys azf signs tokens with
+type JWKSHandler struct {
+	provider func() (token.TokenProvider, error)
+}
+
+// NewJWKSHandler creates a JWKS handler for the configured token provider
+func NewJWKSHandler() *JWKSHandler {
+	return &JWKSHandler{provider: token.Default}
+}
+
+// GetJWKS serves the key set; tokens signed with a shared secret have no
+// public keys, so it is not found then
+func (h *JWKSHandler) GetJWKS(c *gin.Context) {
+	provider, err := h.provider()
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "token signing is not configured"})
+		return
+	}
+	var set token.JSONWebKeySet
+	if keys, ok := provider.(token.KeySetProvider); ok {
+		set = keys.JWKS()
+	}
+	if len(set.Keys) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "tokens are not signed with public keys"})
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, set)
+}
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/aruncs31s/azf/infrastructure/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsHandler serves the Prometheus metrics
+type MetricsHandler struct {
+	registry *metrics.Registry
+	token    string
+}
+
+// NewMetricsHandler creates a handler serving registry. A non-empty token
+// must be presented as a bearer token by scrapers.
+func NewMetricsHandler(registry *metrics.Registry, token string) (*MetricsHandler, error) {
+	if err := requireDependencies("MetricsHandler", dep("registry", registry)); err != nil {
+		return nil, err
+	}
+	return &MetricsHandler{registry: registry, token: token}, nil
+}
+
+// GetMetrics writes the metrics in the Prometheus text format
+func (h *MetricsHandler) GetMetrics(c *gin.Context) {
+	if h.token != "" {
+		presented := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(presented), []byte(h.token)) != 1 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid metrics token"})
+			return
+		}
+	}
+	h.registry.ServeHTTP(c.Writer, c.Request)
+}
@@ -1,24 +1,38 @@
 package handler
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/aruncs31s/azf/application/service"
+	usermodel "github.com/aruncs31s/azf/domain/user_management/model"
 	"github.com/aruncs31s/azf/shared/logger"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
+// The cookie holding the state, PKCE verifier and nonce of a started sign-in
+const (
+	oauthLoginCookie     = "oauth_login"
+	oauthLoginCookiePath = "/admin-ui/oauth"
+)
+
 // OAuthHandler handles OAuth authentication endpoints
 type OAuthHandler struct {
 	oauthService *service.OAuthService
 }
 
 // NewOAuthHandler creates a new OAuth handler
-func NewOAuthHandler(oauthService *service.OAuthService) *OAuthHandler {
+func NewOAuthHandler(oauthService *service.OAuthService) (*OAuthHandler, error) {
+	if err := requireDependencies("OAuthHandler", dep("oauthService", oauthService)); err != nil {
+		return nil, err
+	}
 	return &OAuthHandler{
 		oauthService: oauthService,
-	}
+	}, nil
 }
 
 // Login initiates OAuth login flow
@@ -29,28 +43,13 @@ func (h *OAuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	var oauthProvider service.OAuthProvider
-	switch provider {
-	case "google":
-		oauthProvider = service.Google
-	case "github":
-		oauthProvider = service.GitHub
-	default:
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported OAuth provider"})
-		return
-	}
-
+	oauthProvider := service.OAuthProvider(provider)
 	if !h.oauthService.IsProviderConfigured(oauthProvider) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "OAuth provider not configured"})
 		return
 	}
 
-	state := c.Query("state")
-	if state == "" {
-		state = "random_state" // In production, generate secure random state
-	}
-
-	authURL, err := h.oauthService.GetAuthURL(oauthProvider, state)
+	login, err := h.oauthService.BeginLogin(c.Request.Context(), oauthProvider)
 	if err != nil {
 		logger.GetLogger().Error("Failed to generate OAuth URL",
 			zap.String("provider", provider),
@@ -58,8 +57,43 @@ func (h *OAuthHandler) Login(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to initiate OAuth login"})
 		return
 	}
+	value, err := json.Marshal(login)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to initiate OAuth login"})
+		return
+	}
 
-	c.Redirect(http.StatusFound, authURL)
+	// The callback is only accepted from the browser holding this cookie
+	c.SetCookie(
+		oauthLoginCookie,
+		base64.RawURLEncoding.EncodeToString(value),
+		int(service.OAuthLoginTTL/time.Second),
+		oauthLoginCookiePath,
+		"",
+		false,
+		true,
+	)
+	c.Redirect(http.StatusFound, login.URL)
+}
+
+// oauthLogin reads the sign-in started by Login from its cookie, clearing
+// it so a callback cannot be replayed
+func oauthLogin(c *gin.Context) *service.OAuthLogin {
+	value, err := c.Cookie(oauthLoginCookie)
+	if err != nil {
+		return nil
+	}
+	c.SetCookie(oauthLoginCookie, "", -1, oauthLoginCookiePath, "", false, true)
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil
+	}
+	var login service.OAuthLogin
+	if err := json.Unmarshal(data, &login); err != nil {
+		return nil
+	}
+	return &login
 }
 
 // Callback handles OAuth provider callback
@@ -70,14 +104,15 @@ func (h *OAuthHandler) Callback(c *gin.Context) {
 		return
 	}
 
-	var oauthProvider service.OAuthProvider
-	switch provider {
-	case "google":
-		oauthProvider = service.Google
-	case "github":
-		oauthProvider = service.GitHub
-	default:
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported OAuth provider"})
+	oauthProvider := service.OAuthProvider(provider)
+	if !h.oauthService.IsProviderConfigured(oauthProvider) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "OAuth provider not configured"})
+		return
+	}
+
+	login := oauthLogin(c)
+	if reason := c.Query("error"); reason != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "OAuth provider denied the sign-in", "reason": reason})
 		return
 	}
 
@@ -90,12 +125,25 @@ func (h *OAuthHandler) Callback(c *gin.Context) {
 	}
 
 	// Handle the OAuth callback
-	response, err := h.oauthService.HandleCallback(oauthProvider, code, state)
+	response, err := h.oauthService.HandleCallback(c.Request.Context(), oauthProvider, code, state, login)
 	if err != nil {
 		logger.GetLogger().Error("OAuth callback failed",
 			zap.String("provider", provider),
 			zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "OAuth authentication failed"})
+		switch {
+		case errors.Is(err, service.ErrInvalidOAuthState):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired OAuth sign-in, start it again"})
+		case errors.Is(err, service.ErrOAuthEmailUnavailable):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Your " + provider + " account has no verified primary email address; verify one and sign in again"})
+		case errors.Is(err, usermodel.ErrDuplicateEmail):
+			c.JSON(http.StatusConflict, gin.H{"error": "An account with this email already exists"})
+		case errors.Is(err, usermodel.ErrDuplicateUsername):
+			c.JSON(http.StatusConflict, gin.H{"error": "An account with this username already exists"})
+		case errors.Is(err, usermodel.ErrUserConflict):
+			c.JSON(http.StatusConflict, gin.H{"error": "Account conflicts with an existing user"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "OAuth authentication failed"})
+		}
 		return
 	}
 
@@ -121,12 +169,8 @@ func (h *OAuthHandler) Callback(c *gin.Context) {
 // GetProviders returns list of configured OAuth providers
 func (h *OAuthHandler) GetProviders(c *gin.Context) {
 	providers := []string{}
-
-	if h.oauthService.IsProviderConfigured(service.Google) {
-		providers = append(providers, "google")
-	}
-	if h.oauthService.IsProviderConfigured(service.GitHub) {
-		providers = append(providers, "github")
+	for _, provider := range h.oauthService.Providers() {
+		providers = append(providers, string(provider))
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/aruncs31s/azf/application/service"
+	"github.com/aruncs31s/azf/constants"
+	"github.com/gin-gonic/gin"
+)
+
+// PolicyAPIHandler serves the versioned JSON API managing policies and role
+// assignments, for automation such as Terraform or CI pipelines
+type PolicyAPIHandler struct {
+	policies service.PolicyService
+}
+
+// NewPolicyAPIHandler creates a new policy API handler
+func NewPolicyAPIHandler(policies service.PolicyService) (*PolicyAPIHandler, error) {
+	if err := requireDependencies("PolicyAPIHandler", dep("policies", policies)); err != nil {
+		return nil, err
+	}
+	return &PolicyAPIHandler{
+		policies: policies,
+	}, nil
+}
+
+// RegisterRoutes registers the policy API under constants.ADMIN_POLICY_API_PATH behind auth
+func (h *PolicyAPIHandler) RegisterRoutes(r gin.IRouter, auth gin.HandlerFunc) {
+	api := r.Group(constants.ADMIN_POLICY_API_PATH, auth)
+	api.GET("", h.ListPolicies)
+	api.POST("", h.AddPolicy)
+	api.DELETE("", h.RemovePolicy)
+	api.POST("/import", h.ImportPolicies)
+}
+
+// ListPolicies returns rules; supports ?ptype=p|g, ?role=, ?resource=,
+// ?limit= and ?offset=
+func (h *PolicyAPIHandler) ListPolicies(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	offset, _ := strconv.Atoi(c.Query("offset"))
+
+	page, err := h.policies.List(service.PolicyFilter{
+		PType:    c.Query("ptype"),
+		Role:     c.Query("role"),
+		Resource: c.Query("resource"),
+		Limit:    limit,
+		Offset:   offset,
+	})
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// AddPolicy adds a rule; responds 201 when added and 200 when it already existed
+func (h *PolicyAPIHandler) AddPolicy(c *gin.Context) {
+	var rule service.PolicyRule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	added, err := h.policies.Add(rule)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	status := http.StatusOK
+	if added {
+		status = http.StatusCreated
+	}
+	c.JSON(status, gin.H{"rule": rule, "added": added})
+}
+
+// RemovePolicy removes the rule given in the body
+func (h *PolicyAPIHandler) RemovePolicy(c *gin.Context) {
+	var rule service.PolicyRule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	removed, err := h.policies.Remove(rule)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	if !removed {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Rule not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rule": rule, "removed": true})
+}
+
+// ImportPolicies adds many rules at once; either all are applied or none
+func (h *PolicyAPIHandler) ImportPolicies(c *gin.Context) {
+	var req struct {
+		Rules []service.PolicyRule `json:"rules" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	result, err := h.policies.Import(req.Rules)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/aruncs31s/azf/application/service"
+	"github.com/gin-gonic/gin"
+)
+
+// sandboxTokenHeader carries the sandbox token on sandbox requests
+const sandboxTokenHeader = "X-CSRF-Token"
+
+// PolicySandboxHandler serves the policy sandbox of the signed-in admin
+type PolicySandboxHandler struct {
+	sandboxes service.PolicySandboxService
+}
+
+// NewPolicySandboxHandler creates a new policy sandbox handler
+func NewPolicySandboxHandler(sandboxes service.PolicySandboxService) (*PolicySandboxHandler, error) {
+	if err := requireDependencies("PolicySandboxHandler", dep("sandboxes", sandboxes)); err != nil {
+		return nil, err
+	}
+	return &PolicySandboxHandler{
+		sandboxes: sandboxes,
+	}, nil
+}
+
+// OpenSandbox returns the sandbox of the admin with its token, creating it
+// from the live policies if needed
+func (h *PolicySandboxHandler) OpenSandbox(c *gin.Context) {
+	sandbox, err := h.sandboxes.Open(AdminUsername(c))
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"sandbox": sandbox})
+}
+
+// GetSandbox returns the staged changes of the sandbox
+func (h *PolicySandboxHandler) GetSandbox(c *gin.Context) {
+	sandbox, err := h.sandboxes.Get(AdminUsername(c), c.GetHeader(sandboxTokenHeader))
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"sandbox": sandbox})
+}
+
+// StageChange stages a policy change in the sandbox
+func (h *PolicySandboxHandler) StageChange(c *gin.Context) {
+	var change service.PolicySandboxChange
+	if err := c.ShouldBindJSON(&change); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	sandbox, err := h.sandboxes.Stage(AdminUsername(c), c.GetHeader(sandboxTokenHeader), change)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"sandbox": sandbox})
+}
+
+// CheckSandbox evaluates a request against the sandbox and the live policies
+func (h *PolicySandboxHandler) CheckSandbox(c *gin.Context) {
+	var req struct {
+		Subject string `json:"subject" binding:"required"`
+		Object  string `json:"object" binding:"required"`
+		Action  string `json:"action" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "subject, object and action are required"})
+		return
+	}
+
+	check, err := h.sandboxes.Check(AdminUsername(c), c.GetHeader(sandboxTokenHeader), req.Subject, req.Object, req.Action)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"check": check})
+}
+
+// SimulatePolicy decides a request as the live policies with the proposed
+// changes would, returning the matched rule and a trace. Nothing is saved.
+func (h *PolicySandboxHandler) SimulatePolicy(c *gin.Context) {
+	var req service.PolicySimulationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	simulation, err := h.sandboxes.Simulate(req)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"simulation": simulation})
+}
+
+// ApplySandbox applies the staged changes to the live policies at once
+func (h *PolicySandboxHandler) ApplySandbox(c *gin.Context) {
+	applied, err := h.sandboxes.Apply(AdminUsername(c), c.GetHeader(sandboxTokenHeader))
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sandbox changes applied",
+		"applied": applied,
+	})
+}
+
+// DiscardSandbox drops the sandbox and its staged changes
+func (h *PolicySandboxHandler) DiscardSandbox(c *gin.Context) {
+	if err := h.sandboxes.Discard(AdminUsername(c), c.GetHeader(sandboxTokenHeader)); err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Sandbox discarded"})
+}
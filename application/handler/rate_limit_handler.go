@@ -1,12 +1,14 @@
 package handler
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strconv"
 	"sync"
 	"time"
 
+	"github.com/aruncs31s/azf/infrastructure/enterprise"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/time/rate"
 )
@@ -184,14 +186,42 @@ func (m *RateLimitManager) GetGlobalLimit() (rate.Limit, int) {
 	return m.globalRate, m.globalBurst
 }
 
+// RateLimitBucketSource reports the buckets of the in-memory rate limiter,
+// or false when there is none
+type RateLimitBucketSource interface {
+	RateLimitBucketStats() (enterprise.RateLimitBucketStats, bool)
+}
+
+// RateLimitBlocker blocks identifiers on the enterprise rate limiter and
+// reports their state
+type RateLimitBlocker interface {
+	TemporaryBlock(ctx context.Context, identifier string, duration time.Duration) error
+	GetStats(ctx context.Context, identifier string) (map[string]interface{}, error)
+}
+
 // RateLimitHandler handles rate limit UI requests
 type RateLimitHandler struct {
 	manager *RateLimitManager
+	buckets RateLimitBucketSource
+	blocker RateLimitBlocker
 }
 
 // NewRateLimitHandler creates a new rate limit handler
-func NewRateLimitHandler(manager *RateLimitManager) *RateLimitHandler {
-	return &RateLimitHandler{manager: manager}
+func NewRateLimitHandler(manager *RateLimitManager) (*RateLimitHandler, error) {
+	if err := requireDependencies("RateLimitHandler", dep("manager", manager)); err != nil {
+		return nil, err
+	}
+	return &RateLimitHandler{manager: manager}, nil
+}
+
+// SetBucketSource adds the buckets of the in-memory rate limiter to the stats
+func (h *RateLimitHandler) SetBucketSource(buckets RateLimitBucketSource) {
+	h.buckets = buckets
+}
+
+// SetBlocker enables blocking identifiers on the enterprise rate limiter
+func (h *RateLimitHandler) SetBlocker(blocker RateLimitBlocker) {
+	h.blocker = blocker
 }
 
 // GetRateLimitPage returns the rate limiting UI page
@@ -215,12 +245,18 @@ func (h *RateLimitHandler) GetRateLimitPage(c *gin.Context) {
 func (h *RateLimitHandler) GetRateLimitStats(c *gin.Context) {
 	stats := h.manager.GetAllStats()
 
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"stats":         stats,
 		"totalIPs":      len(stats),
 		"blockedIPs":    countBlocked(stats),
 		"totalRequests": sumRequests(stats),
-	})
+	}
+	if h.buckets != nil {
+		if buckets, ok := h.buckets.RateLimitBucketStats(); ok {
+			response["buckets"] = buckets
+		}
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 // GetIPStats returns statistics for a specific IP
@@ -247,6 +283,70 @@ func (h *RateLimitHandler) ResetIPLimit(c *gin.Context) {
 	})
 }
 
+// GetIdentifierBlock returns the rate limiter state of an identifier,
+// including the time left on its block
+func (h *RateLimitHandler) GetIdentifierBlock(c *gin.Context) {
+	if h.blocker == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Rate limiter not available"})
+		return
+	}
+	stats, err := h.blocker.GetStats(c.Request.Context(), c.Param("identifier"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"identifier": c.Param("identifier"), "stats": stats})
+}
+
+// BlockIdentifier rejects every request of an identifier for a duration,
+// e.g. {"duration": "30m"}
+func (h *RateLimitHandler) BlockIdentifier(c *gin.Context) {
+	if h.blocker == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Rate limiter not available"})
+		return
+	}
+	var req struct {
+		Duration string `json:"duration" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	duration, err := time.ParseDuration(req.Duration)
+	if err != nil || duration <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "duration must be a positive duration such as 30m"})
+		return
+	}
+
+	identifier := c.Param("identifier")
+	if err := h.blocker.TemporaryBlock(c.Request.Context(), identifier, duration); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":      fmt.Sprintf("%s blocked for %s", identifier, duration),
+		"identifier":   identifier,
+		"blockedUntil": time.Now().Add(duration),
+	})
+}
+
+// UnblockIdentifier lifts the block of an identifier
+func (h *RateLimitHandler) UnblockIdentifier(c *gin.Context) {
+	if h.blocker == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Rate limiter not available"})
+		return
+	}
+	identifier := c.Param("identifier")
+	if err := h.blocker.TemporaryBlock(c.Request.Context(), identifier, 0); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":    fmt.Sprintf("Block lifted for %s", identifier),
+		"identifier": identifier,
+	})
+}
+
 // UpdateGlobalLimit updates the global rate limit
 func (h *RateLimitHandler) UpdateGlobalLimit(c *gin.Context) {
 	var req struct {
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/a-h/templ"
+	"github.com/aruncs31s/azf/application/service"
+	"github.com/aruncs31s/azf/application/templates"
+	"github.com/aruncs31s/azf/config"
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitOverrideHandler manages per-user rate limit overrides
+type RateLimitOverrideHandler struct {
+	overrideService service.RateLimitOverrideService
+}
+
+// NewRateLimitOverrideHandler creates a new rate limit override handler
+func NewRateLimitOverrideHandler(overrideService service.RateLimitOverrideService) (*RateLimitOverrideHandler, error) {
+	if err := requireDependencies("RateLimitOverrideHandler", dep("overrideService", overrideService)); err != nil {
+		return nil, err
+	}
+	return &RateLimitOverrideHandler{
+		overrideService: overrideService,
+	}, nil
+}
+
+// GetOverridesPage renders the overrides page; ?identity= pre-fills the form
+func (h *RateLimitOverrideHandler) GetOverridesPage(c *gin.Context) {
+	overrides, err := h.overrideService.ListOverrides()
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Failed to load rate limit overrides")
+		return
+	}
+
+	data := templates.RateLimitOverridesPageData{
+		GeneratedAt: time.Now(),
+		Overrides:   *overrides,
+		RoleLimits: []templates.RoleRateLimit{
+			{Role: "admin", RequestsPerMinute: config.ADMIN_LIMIT},
+			{Role: "moderator", RequestsPerMinute: config.MODERATOR_LIMIT},
+			{Role: "staff", RequestsPerMinute: config.STAFF_LIMIT},
+			{Role: "student", RequestsPerMinute: config.STUDENT_LIMIT},
+		},
+		DefaultBurst:    config.BURST_ALLOWANCE,
+		PrefillIdentity: c.Query("identity"),
+	}
+	templ.Handler(templates.RateLimitOverridesPage(data)).ServeHTTP(c.Writer, c.Request)
+}
+
+// ListOverrides returns all overrides, including expired ones
+func (h *RateLimitOverrideHandler) ListOverrides(c *gin.Context) {
+	overrides, err := h.overrideService.ListOverrides()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"overrides": overrides})
+}
+
+// SetOverride creates or replaces the override for a user
+func (h *RateLimitOverrideHandler) SetOverride(c *gin.Context) {
+	var req service.SetRateLimitOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	override, err := h.overrideService.SetOverride(c.Param("identity"), req, "admin")
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Rate limit override saved",
+		"override": override,
+	})
+}
+
+// DeleteOverride removes a user's override so role defaults apply again
+func (h *RateLimitOverrideHandler) DeleteOverride(c *gin.Context) {
+	if err := h.overrideService.DeleteOverride(c.Param("identity")); err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Rate limit override removed"})
+}
@@ -0,0 +1,441 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/a-h/templ"
+	"github.com/aruncs31s/azf/application/service"
+	"github.com/aruncs31s/azf/application/templates"
+	"github.com/aruncs31s/azf/config"
+	"github.com/aruncs31s/azf/initializer"
+	helperImpl "github.com/aruncs31s/azf/shared/helper"
+	"github.com/aruncs31s/azf/shared/logger"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// RoleHandler serves role and policy management
+type RoleHandler struct {
+	profileService *service.AdminProfileService
+	userLookup     service.UserLookupService
+	roleSync       service.RoleSyncService
+}
+
+// NewRoleHandler creates a new role handler
+func NewRoleHandler(
+	profileService *service.AdminProfileService,
+	userLookup service.UserLookupService,
+	roleSync service.RoleSyncService,
+) (*RoleHandler, error) {
+	if err := requireDependencies("RoleHandler",
+		dep("profileService", profileService),
+		dep("userLookup", userLookup),
+		dep("roleSync", roleSync),
+	); err != nil {
+		return nil, err
+	}
+	return &RoleHandler{
+		profileService: profileService,
+		userLookup:     userLookup,
+		roleSync:       roleSync,
+	}, nil
+}
+
+// RegisterRoutes registers the role and policy pages and API behind auth
+func (h *RoleHandler) RegisterRoutes(r gin.IRoutes, auth gin.HandlerFunc) {
+	r.GET("/admin-ui/roles", auth, h.GetRoleManagementPage)
+	r.GET("/admin-ui/roles/:role", auth, h.GetRoleDetailsPage)
+	r.GET("/admin-ui/policies", auth, h.GetPolicyManagementPage)
+	r.GET("/admin-ui/role-sync", auth, h.GetRoleSyncPage)
+
+	r.POST("/admin-ui/api/roles", auth, h.CreateRole)
+	r.PUT("/admin-ui/api/roles", auth, h.UpdateRole)
+	r.POST("/admin-ui/api/roles/assign", auth, h.AssignRoleToUser)
+	r.POST("/admin-ui/api/roles/remove", auth, h.RemoveRoleFromUser)
+	r.GET("/admin-ui/api/roles/users", auth, h.GetUsersForRole)
+	r.GET("/admin-ui/api/roles/consistency", auth, h.GetRoleConsistency)
+	r.POST("/admin-ui/api/roles/sync", auth, h.ReconcileRoles)
+	r.POST("/admin-ui/api/roles/delete", auth, h.DeleteRole)
+	r.POST("/admin-ui/api/policies/save", auth, h.SavePolicies)
+}
+
+// GetRoleManagementPage renders the Role Management page
+// Allows admins to view roles and user role assignments from Casbin
+func (h *RoleHandler) GetRoleManagementPage(c *gin.Context) {
+	// Get all roles from Casbin
+	roleNames, err := h.profileService.GetAllRolesFromCasbin()
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Failed to load roles from Casbin")
+		return
+	}
+
+	// Get role descriptions
+	roleDescriptions := h.profileService.GetRoleDescriptions()
+
+	// Build role info with descriptions and user counts
+	allRoles := make([]templates.RoleInfo, 0, len(roleNames))
+	userRoleMap := make(map[string][]string) // userID -> roles
+
+	for _, roleName := range roleNames {
+		description := roleDescriptions[roleName]
+		if description == "" {
+			description = roleName + " role" // Fallback description
+		}
+
+		// Get users for this role
+		users, err := h.profileService.GetUsersForRole(roleName)
+		userCount := 0
+		if err == nil {
+			userCount = len(users)
+			// Collect user -> role mappings
+			for _, userID := range users {
+				userRoleMap[userID] = append(userRoleMap[userID], roleName)
+			}
+		}
+
+		allRoles = append(allRoles, templates.RoleInfo{
+			Name:        roleName,
+			Description: description,
+			UserCount:   userCount,
+		})
+	}
+
+	// Build user role assignments from collected data, named after the user records
+	userIDs := make([]string, 0, len(userRoleMap))
+	for userID := range userRoleMap {
+		userIDs = append(userIDs, userID)
+	}
+	users := h.userLookup.Resolve(userIDs)
+	userRoles := make([]templates.UserRoleAssignment, 0, len(userRoleMap))
+	for userID, roles := range userRoleMap {
+		user := users[userID]
+		username := user.Username
+		if username == "" {
+			username = userID
+		}
+		userRoles = append(userRoles, templates.UserRoleAssignment{
+			UserID:      userID,
+			Username:    username,
+			DisplayName: user.DisplayName,
+			Email:       user.Email,
+			Roles:       roles,
+		})
+	}
+
+	// Create management data structure
+	managementData := templates.RoleManagementPageData{
+		Roles:     allRoles,
+		UserRoles: userRoles,
+		AutoSave:  config.CasbinAutoSave(),
+	}
+
+	// Render Templ template
+	templ.Handler(templates.RoleManagementPage(managementData)).ServeHTTP(c.Writer, c.Request)
+}
+
+// displayNames maps user IDs to the display names of resolved users
+func displayNames(users map[string]service.UserSummaryDTO) map[string]string {
+	names := make(map[string]string, len(users))
+	for userID, user := range users {
+		names[userID] = user.DisplayName
+	}
+	return names
+}
+
+// GetPolicyManagementPage renders the Policy Management documentation page
+// Provides comprehensive guidance on managing Casbin policies following DDD principles
+func (h *RoleHandler) GetPolicyManagementPage(c *gin.Context) {
+	// Get current policy statistics
+	policyCount := 0
+	groupingCount := 0
+
+	if enforcer := initializer.CasbinEnforcer; enforcer != nil {
+		_ = initializer.ReadPolicies(enforcer, func() error {
+			if policies, err := enforcer.GetPolicy(); err == nil {
+				policyCount = len(policies)
+			}
+			if groupingPolicies, err := enforcer.GetGroupingPolicy(); err == nil {
+				groupingCount = len(groupingPolicies)
+			}
+			return nil
+		})
+	}
+
+	// Get roles for examples
+	roles, _ := h.profileService.GetAllRolesFromCasbin()
+	if len(roles) == 0 {
+		roles = []string{"admin", "staff", "user"}
+	}
+
+	// Create policy management data
+	policyData := templates.PolicyManagementPageData{
+		PolicyCount:       policyCount,
+		GroupingCount:     groupingCount,
+		AvailableRoles:    roles,
+		CurrentPolicyFile: currentPolicySource(),
+		CurrentModelFile:  "config/casbin_rbac_model.conf",
+	}
+
+	// Render Templ template
+	templ.Handler(templates.PolicyManagementPage(policyData)).ServeHTTP(c.Writer, c.Request)
+}
+
+// currentPolicySource describes where the Casbin policies are stored
+func currentPolicySource() string {
+	if initializer.StoresPoliciesInDatabase(initializer.CasbinEnforcer) {
+		return "database (casbin_rule table)"
+	}
+	return config.CASBIN_POLICY_FILE
+}
+
+// CreateRole creates a new role
+func (h *RoleHandler) CreateRole(c *gin.Context) {
+	req, err := helperImpl.GetJSONDataFromRequest[struct {
+		Name        string `json:"name" binding:"required"`
+		Description string `json:"description"`
+	}](c)
+	if err != nil {
+		helperImpl.SendBadRequest(c, err.Error())
+		return
+	}
+
+	err = h.profileService.CreateRole(req.Name, req.Description)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Role created successfully", "role": req.Name})
+}
+
+// UpdateRole updates an existing role's name and/or description
+func (h *RoleHandler) UpdateRole(c *gin.Context) {
+	var req struct {
+		OldName         string `json:"old_name" binding:"required"`
+		NewName         string `json:"new_name"`
+		Description     string `json:"description"`
+		OverrideLockout bool   `json:"override_lockout"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// If new name is empty, use old name (only updating description)
+	if req.NewName == "" {
+		req.NewName = req.OldName
+	}
+
+	err := h.profileService.UpdateRole(req.OldName, req.NewName, req.Description, roleSession(c, req.OverrideLockout))
+	if err != nil {
+		respondRoleChangeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role updated successfully", "role": req.NewName})
+}
+
+// AssignRoleToUser assigns a role to a user
+func (h *RoleHandler) AssignRoleToUser(c *gin.Context) {
+	var req struct {
+		UserID string `json:"user_id" binding:"required"`
+		Role   string `json:"role" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := h.profileService.AssignRoleToUser(req.UserID, req.Role)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role assigned successfully"})
+}
+
+// RemoveRoleFromUser removes a role from a user
+func (h *RoleHandler) RemoveRoleFromUser(c *gin.Context) {
+	var req struct {
+		UserID          string `json:"user_id" binding:"required"`
+		Role            string `json:"role" binding:"required"`
+		OverrideLockout bool   `json:"override_lockout"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := h.profileService.RemoveRoleFromUser(req.UserID, req.Role, roleSession(c, req.OverrideLockout))
+	if err != nil {
+		respondRoleChangeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role removed successfully"})
+}
+
+// GetUsersForRole returns users assigned to a specific role
+func (h *RoleHandler) GetUsersForRole(c *gin.Context) {
+	role := c.Query("role")
+	if role == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "role parameter is required"})
+		return
+	}
+
+	users, err := h.profileService.GetUsersForRole(role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"role": role, "users": users})
+}
+
+// GetRoleConsistency reports differences between the roles recorded on users
+// and the Casbin grouping policies
+func (h *RoleHandler) GetRoleConsistency(c *gin.Context) {
+	report, err := h.roleSync.Report()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetRoleSyncPage renders the drift between user roles and grouping policies
+func (h *RoleHandler) GetRoleSyncPage(c *gin.Context) {
+	report, err := h.roleSync.Report()
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Failed to compare user roles with grouping policies: %v", err)
+		return
+	}
+
+	userIDs := make([]string, 0)
+	for _, assignments := range [][]service.RoleAssignmentDTO{report.MissingInCasbin, report.MissingInUsers} {
+		for _, assignment := range assignments {
+			userIDs = append(userIDs, assignment.UserID)
+		}
+	}
+	data := templates.RoleSyncPageData{
+		Report:    *report,
+		UserNames: displayNames(h.userLookup.Resolve(userIDs)),
+		Mode:      config.RoleSyncMode(),
+		Interval:  config.RoleSyncInterval(),
+	}
+	templ.Handler(templates.RoleSyncPage(data)).ServeHTTP(c.Writer, c.Request)
+}
+
+// ReconcileRoles resolves the drift between user roles and grouping
+// policies in the requested mode: merge, users or casbin. Without a mode
+// ROLE_SYNC_MODE is used.
+func (h *RoleHandler) ReconcileRoles(c *gin.Context) {
+	var req struct {
+		Mode string `json:"mode"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.Mode == "" {
+		req.Mode = config.RoleSyncMode()
+	}
+
+	result, err := h.roleSync.Reconcile(c.Request.Context(), req.Mode)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// DeleteRole deletes a role and all its assignments
+func (h *RoleHandler) DeleteRole(c *gin.Context) {
+	var req struct {
+		Role            string `json:"role" binding:"required"`
+		OverrideLockout bool   `json:"override_lockout"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := h.profileService.DeleteRole(req.Role, roleSession(c, req.OverrideLockout))
+	if err != nil {
+		respondRoleChangeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role deleted successfully"})
+}
+
+// SavePolicies writes the current roles and policies to the policy storage,
+// for deployments that disable CASBIN_AUTO_SAVE
+func (h *RoleHandler) SavePolicies(c *gin.Context) {
+	if err := h.profileService.SavePolicies(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Policies saved successfully"})
+}
+
+// GetRoleDetailsPage renders the detailed view of a specific role
+// Shows users, permissions, and role information
+func (h *RoleHandler) GetRoleDetailsPage(c *gin.Context) {
+	roleName := c.Param("role")
+	if roleName == "" {
+		c.String(http.StatusBadRequest, "Role name is required")
+		return
+	}
+
+	// Get role details from service
+	roleDetails, err := h.profileService.GetRoleDetails(roleName)
+	if err != nil {
+		logger.Error("Failed to get role details", zap.Error(err), zap.String("role", roleName))
+		c.String(http.StatusInternalServerError, "Failed to load role details")
+		return
+	}
+
+	// Extract data from map
+	description := ""
+	if desc, ok := roleDetails["description"].(string); ok {
+		description = desc
+	}
+
+	userCount := 0
+	if count, ok := roleDetails["user_count"].(int); ok {
+		userCount = count
+	}
+
+	users := []string{}
+	if userList, ok := roleDetails["users"].([]string); ok {
+		users = userList
+	}
+
+	permissions := []map[string]string{}
+	if permList, ok := roleDetails["permissions"].([]map[string]string); ok {
+		permissions = permList
+	}
+
+	// Create page data
+	pageData := templates.RoleDetailsPageData{
+		RoleName:    roleName,
+		Description: description,
+		UserCount:   userCount,
+		Users:       users,
+		UserNames:   displayNames(h.userLookup.Resolve(users)),
+		Permissions: permissions,
+	}
+
+	// Render Templ template
+	templ.Handler(templates.RoleDetailsPage(pageData)).ServeHTTP(c.Writer, c.Request)
+}
@@ -0,0 +1,228 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/a-h/templ"
+	"github.com/aruncs31s/azf/application/templates"
+	"github.com/aruncs31s/azf/infrastructure/abac"
+	"github.com/aruncs31s/azf/infrastructure/enterprise"
+	"github.com/aruncs31s/azf/initializer"
+	"github.com/aruncs31s/azf/shared/logger"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// RouteMetadataHandler serves the enterprise route metadata editor
+type RouteMetadataHandler struct{}
+
+// NewRouteMetadataHandler creates a new route metadata handler
+func NewRouteMetadataHandler() *RouteMetadataHandler {
+	return &RouteMetadataHandler{}
+}
+
+// RegisterRoutes registers the route metadata page and API behind auth
+func (h *RouteMetadataHandler) RegisterRoutes(r gin.IRoutes, auth gin.HandlerFunc) {
+	r.GET("/admin-ui/route_metadata", auth, h.GetRouteMetadataManagementPage)
+	r.POST("/admin-ui/route_metadata", auth, h.SaveRouteMetadata)
+	r.POST("/admin-ui/route_metadata/import", auth, h.ImportRouteMetadata)
+	r.POST("/admin-ui/route_metadata/delete", auth, h.DeleteRouteMetadata)
+}
+
+// GetRouteMetadataManagementPage renders the Route Metadata Management page
+// Allows admins to view and manage enterprise route metadata configuration
+func (h *RouteMetadataHandler) GetRouteMetadataManagementPage(c *gin.Context) {
+	// Load current route metadata
+	routeMetadata, err := enterprise.LoadEnterpriseRouteMetadata("")
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Failed to load route metadata")
+		return
+	}
+
+	// Create management data structure
+	managementData := templates.RouteMetadataManagementPageData{
+		Routes:              routeMetadata,
+		ConditionAttributes: abac.AttributeDescriptions(),
+		ConditionsEnforced:  abac.IsABACModel(initializer.CasbinEnforcer),
+	}
+
+	// Render Templ template
+	templ.Handler(templates.RouteMetadataManagementPage(managementData)).ServeHTTP(c.Writer, c.Request)
+}
+
+// syncPoliciesFromRoutes regenerates the Casbin policies from route metadata.
+// Policies stored in the database are replaced in place; a policy file is
+// rewritten and the enforcer reloaded from it.
+func syncPoliciesFromRoutes(routes []*enterprise.RouteMetadata) error {
+	if initializer.StoresPoliciesInDatabase(initializer.CasbinEnforcer) {
+		return initializer.ReplacePolicies(initializer.CasbinEnforcer, enterprise.RoutePolicies(routes))
+	}
+	return initializer.ReloadPolicies(initializer.CasbinEnforcer, func() error {
+		return enterprise.UpdateCasbinPoliciesFromRoutes(routes, "")
+	})
+}
+
+// SaveRouteMetadata handles saving updated route metadata
+func (h *RouteMetadataHandler) SaveRouteMetadata(c *gin.Context) {
+	// Parse the JSON payload
+	var updateRequest struct {
+		Routes []*enterprise.RouteMetadata `json:"routes"`
+	}
+
+	if err := c.ShouldBindJSON(&updateRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON payload"})
+		return
+	}
+
+	// Validate all routes
+	for _, route := range updateRequest.Routes {
+		if err := route.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid route %s %s: %v", route.Method, route.Path, err)})
+			return
+		}
+	}
+
+	// Save to file
+	if err := enterprise.SaveEnterpriseRouteMetadata(updateRequest.Routes, ""); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save route metadata"})
+		return
+	}
+
+	// Update Casbin policies based on the new route metadata
+	if err := syncPoliciesFromRoutes(updateRequest.Routes); err != nil {
+		logger.GetLogger().Warn("Failed to update Casbin policies after route metadata save", zap.Error(err))
+		// Don't fail the request, just log the warning
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Route metadata saved successfully"})
+}
+
+// ImportRouteMetadata handles importing route metadata from JSON
+func (h *RouteMetadataHandler) ImportRouteMetadata(c *gin.Context) {
+	var importRequest struct {
+		Routes []*enterprise.RouteMetadata `json:"routes"`
+	}
+
+	if err := c.ShouldBindJSON(&importRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON payload"})
+		return
+	}
+
+	if len(importRequest.Routes) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Routes list cannot be empty"})
+		return
+	}
+
+	// Validate all routes
+	for _, route := range importRequest.Routes {
+		if err := route.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid route %s %s: %v", route.Method, route.Path, err)})
+			return
+		}
+	}
+
+	// Load existing routes
+	existingRoutes, _ := enterprise.LoadEnterpriseRouteMetadata("")
+	if existingRoutes == nil {
+		existingRoutes = make([]*enterprise.RouteMetadata, 0)
+	}
+
+	// Create a map of existing routes by method:path for easy lookup
+	existingMap := make(map[string]*enterprise.RouteMetadata)
+	for _, route := range existingRoutes {
+		key := fmt.Sprintf("%s:%s", route.Method, route.Path)
+		existingMap[key] = route
+	}
+
+	// Merge imported routes with existing ones (imported routes override existing)
+	mergedRoutes := make([]*enterprise.RouteMetadata, 0, len(existingRoutes))
+	importedKeys := make(map[string]bool)
+
+	// Add imported routes first
+	for _, route := range importRequest.Routes {
+		key := fmt.Sprintf("%s:%s", route.Method, route.Path)
+		mergedRoutes = append(mergedRoutes, route)
+		importedKeys[key] = true
+	}
+
+	// Add existing routes that weren't imported
+	for key, route := range existingMap {
+		if !importedKeys[key] {
+			mergedRoutes = append(mergedRoutes, route)
+		}
+	}
+
+	// Save merged routes to file
+	if err := enterprise.SaveEnterpriseRouteMetadata(mergedRoutes, ""); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save imported routes"})
+		return
+	}
+
+	// Update Casbin policies based on the merged route metadata
+	if err := syncPoliciesFromRoutes(mergedRoutes); err != nil {
+		logger.GetLogger().Warn("Failed to update Casbin policies after route import", zap.Error(err))
+		// Don't fail the request, just log the warning
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Route metadata imported successfully",
+		"imported": len(importRequest.Routes),
+		"total":    len(mergedRoutes),
+	})
+}
+
+// DeleteRouteMetadata handles deleting a specific route
+func (h *RouteMetadataHandler) DeleteRouteMetadata(c *gin.Context) {
+	var deleteRequest struct {
+		Method string `json:"method"`
+		Path   string `json:"path"`
+	}
+
+	if err := c.ShouldBindJSON(&deleteRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON payload"})
+		return
+	}
+
+	if deleteRequest.Method == "" || deleteRequest.Path == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Method and path are required"})
+		return
+	}
+
+	// Load all routes
+	routes, err := enterprise.LoadEnterpriseRouteMetadata("")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load route metadata"})
+		return
+	}
+
+	// Find and remove the route
+	var updatedRoutes []*enterprise.RouteMetadata
+	found := false
+	for _, route := range routes {
+		if route.Method == deleteRequest.Method && route.Path == deleteRequest.Path {
+			found = true
+			continue // Skip this route (delete it)
+		}
+		updatedRoutes = append(updatedRoutes, route)
+	}
+
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Route not found"})
+		return
+	}
+
+	// Save updated routes
+	if err := enterprise.SaveEnterpriseRouteMetadata(updatedRoutes, ""); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete route"})
+		return
+	}
+
+	// Update Casbin policies based on the updated route metadata
+	if err := syncPoliciesFromRoutes(updatedRoutes); err != nil {
+		logger.GetLogger().Warn("Failed to update Casbin policies after route deletion", zap.Error(err))
+		// Don't fail the request, just log the warning
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Route deleted successfully"})
+}
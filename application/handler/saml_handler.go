@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/aruncs31s/azf/application/dto"
+	"github.com/aruncs31s/azf/application/service"
+	"github.com/aruncs31s/azf/shared/clock"
+	"github.com/aruncs31s/azf/shared/ids"
+	"github.com/aruncs31s/azf/shared/logger"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// The cookie holding the request ID of a started SAML sign-in
+const (
+	samlLoginCookie     = "saml_login"
+	samlLoginCookiePath = "/admin-ui/saml"
+)
+
+// SAMLMetadata serves the service provider metadata the identity provider is
+// set up with
+func (h *AdminHandler) SAMLMetadata(c *gin.Context) {
+	c.Data(http.StatusOK, "application/samlmetadata+xml", h.saml.Metadata())
+}
+
+// SAMLLogin sends the browser to the identity provider to sign in
+func (h *AdminHandler) SAMLLogin(c *gin.Context) {
+	login, err := h.saml.BeginLogin(c.Request.Context())
+	if err != nil {
+		logger.FromContext(c.Request.Context()).Error("Failed to start SAML sign-in", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to initiate SAML login"})
+		return
+	}
+	value, err := json.Marshal(login)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to initiate SAML login"})
+		return
+	}
+
+	// The identity provider posts the response cross-site, which browsers
+	// only send the cookie with when it is SameSite=None and Secure
+	secure := h.saml.Secure()
+	if secure {
+		c.SetSameSite(http.SameSiteNoneMode)
+	}
+	c.SetCookie(
+		samlLoginCookie,
+		base64.RawURLEncoding.EncodeToString(value),
+		int(service.SAMLLoginTTL/time.Second),
+		samlLoginCookiePath,
+		"",
+		secure,
+		true,
+	)
+	c.Redirect(http.StatusFound, login.URL)
+}
+
+// samlLogin reads the sign-in started by SAMLLogin from its cookie, clearing
+// it so a response cannot be replayed
+func samlLogin(c *gin.Context) *service.SAMLLogin {
+	value, err := c.Cookie(samlLoginCookie)
+	if err != nil {
+		return nil
+	}
+	c.SetCookie(samlLoginCookie, "", -1, samlLoginCookiePath, "", false, true)
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil
+	}
+	var login service.SAMLLogin
+	if err := json.Unmarshal(data, &login); err != nil {
+		return nil
+	}
+	return &login
+}
+
+// SAMLACS is the assertion consumer service: it verifies the response the
+// identity provider posts and signs the admin in with the same tokens and
+// session cookies as a password sign-in
+func (h *AdminHandler) SAMLACS(c *gin.Context) {
+	ctx := c.Request.Context()
+	login := samlLogin(c)
+
+	identity, err := h.saml.HandleResponse(ctx, c.PostForm("SAMLResponse"), login)
+	if err != nil {
+		logger.FromContext(ctx).Warn("SAML sign-in failed", zap.Error(err))
+		switch {
+		case errors.Is(err, service.ErrInvalidSAMLResponse):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired SAML sign-in, start it again"})
+		case errors.Is(err, service.ErrSAMLAccessDenied):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Your account may not sign in to the admin UI"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "SAML authentication failed"})
+		}
+		return
+	}
+
+	userID := service.AdminUserID(identity.Username)
+	user, err := h.adminUsers.RecordDirectoryLogin(ctx, identity.DirectoryIdentity())
+	switch {
+	case errors.Is(err, service.ErrAdminSignInDenied):
+		logger.FromContext(ctx).Warn("SAML sign-in of blocked user", zap.String("username", identity.Username))
+		c.JSON(http.StatusForbidden, gin.H{"error": "Your account may not sign in to the admin UI"})
+		return
+	case err != nil:
+		logger.FromContext(ctx).Error("Failed to record SAML login on user record",
+			zap.String("username", identity.Username),
+			zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "SAML authentication failed"})
+		return
+	case user != nil:
+		userID = user.GetID()
+	}
+
+	response := &dto.AdminLoginResponse{
+		Success:   true,
+		Message:   "SAML login successful",
+		SessionID: "saml_session_" + ids.New(),
+		Admin: dto.AdminInfo{
+			ID:       userID,
+			Username: identity.Username,
+		},
+		Timestamp: clock.Now().Format(time.RFC3339),
+	}
+	if !h.startSession(c, identity.Username, userID, response) {
+		return
+	}
+	c.Redirect(http.StatusSeeOther, "/admin-ui")
+}
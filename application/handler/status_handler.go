@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/a-h/templ"
+	"github.com/aruncs31s/azf/application/service"
+	"github.com/aruncs31s/azf/application/templates"
+	"github.com/gin-gonic/gin"
+)
+
+// StatusHandler serves the public status page and the admin incident banner API
+type StatusHandler struct {
+	statusService service.StatusService
+}
+
+// NewStatusHandler creates a new status handler
+func NewStatusHandler(statusService service.StatusService) (*StatusHandler, error) {
+	if err := requireDependencies("StatusHandler", dep("statusService", statusService)); err != nil {
+		return nil, err
+	}
+	return &StatusHandler{
+		statusService: statusService,
+	}, nil
+}
+
+// SetIncidentRequest is the payload for setting the incident banner
+type SetIncidentRequest struct {
+	Title    string `json:"title" binding:"required"`
+	Message  string `json:"message"`
+	Severity string `json:"severity"`
+}
+
+// GetStatusPage renders the unauthenticated status page
+func (h *StatusHandler) GetStatusPage(c *gin.Context) {
+	status, err := h.statusService.GetPublicStatus()
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Status unavailable")
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	templ.Handler(templates.StatusPage(templates.StatusPageData{Status: *status})).ServeHTTP(c.Writer, c.Request)
+}
+
+// GetStatusJSON returns the unauthenticated status as JSON
+func (h *StatusHandler) GetStatusJSON(c *gin.Context) {
+	status, err := h.statusService.GetPublicStatus()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Status unavailable"})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, status)
+}
+
+// GetIncident returns the current incident banner
+func (h *StatusHandler) GetIncident(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"incident": h.statusService.GetIncident()})
+}
+
+// SetIncident sets the incident banner shown on the status page
+func (h *StatusHandler) SetIncident(c *gin.Context) {
+	var req SetIncidentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	incident, err := h.statusService.SetIncident(req.Title, req.Message, req.Severity)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Incident banner set",
+		"incident": incident,
+	})
+}
+
+// ClearIncident removes the incident banner
+func (h *StatusHandler) ClearIncident(c *gin.Context) {
+	h.statusService.ClearIncident()
+	c.JSON(http.StatusOK, gin.H{"message": "Incident banner cleared"})
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/aruncs31s/azf/application/service"
+	"github.com/gin-gonic/gin"
+)
+
+// StorageHandler exposes storage maintenance jobs to admins
+type StorageHandler struct {
+	maintenanceService service.StorageMaintenanceService
+}
+
+// NewStorageHandler creates a new storage handler
+func NewStorageHandler(maintenanceService service.StorageMaintenanceService) (*StorageHandler, error) {
+	if err := requireDependencies("StorageHandler", dep("maintenanceService", maintenanceService)); err != nil {
+		return nil, err
+	}
+	return &StorageHandler{
+		maintenanceService: maintenanceService,
+	}, nil
+}
+
+// StartTextBackfill starts encoding large text columns of existing usage and audit rows
+func (h *StorageHandler) StartTextBackfill(c *gin.Context) {
+	status, err := h.maintenanceService.StartTextBackfill()
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Text column backfill started",
+		"status":  status,
+	})
+}
+
+// GetTextBackfillStatus returns the progress of the text column backfill
+func (h *StorageHandler) GetTextBackfillStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": h.maintenanceService.GetTextBackfillStatus()})
+}
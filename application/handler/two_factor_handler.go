@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/a-h/templ"
+	"github.com/aruncs31s/azf/application/dto"
+	"github.com/aruncs31s/azf/application/service"
+	"github.com/aruncs31s/azf/application/templates"
+	"github.com/gin-gonic/gin"
+)
+
+// TwoFactorHandler lets the signed-in admin manage their TOTP second factor
+type TwoFactorHandler struct {
+	twoFactor service.TwoFactorService
+}
+
+// NewTwoFactorHandler creates a new two-factor handler. twoFactor may be nil
+// when the database is not available; the pages then report so.
+func NewTwoFactorHandler(twoFactor service.TwoFactorService) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		twoFactor: twoFactor,
+	}
+}
+
+// RegisterRoutes registers the two-factor page and API behind auth
+func (h *TwoFactorHandler) RegisterRoutes(r gin.IRoutes, auth gin.HandlerFunc) {
+	r.GET("/admin-ui/two-factor", auth, h.GetTwoFactorPage)
+	r.GET("/admin-ui/api/two-factor", auth, h.GetStatus)
+	r.POST("/admin-ui/api/two-factor/enroll", auth, h.BeginEnrollment)
+	r.POST("/admin-ui/api/two-factor/confirm", auth, h.ConfirmEnrollment)
+	r.POST("/admin-ui/api/two-factor/backup-codes", auth, h.RegenerateBackupCodes)
+	r.POST("/admin-ui/api/two-factor/disable", auth, h.Disable)
+}
+
+// GetTwoFactorPage renders the two-factor settings of the current admin
+func (h *TwoFactorHandler) GetTwoFactorPage(c *gin.Context) {
+	username, ok := h.currentAdmin(c)
+	if !ok {
+		return
+	}
+	status, err := h.twoFactor.Status(c.Request.Context(), username)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Failed to load two-factor authentication")
+		return
+	}
+
+	data := templates.TwoFactorPageData{
+		Username: username,
+		Status:   *status,
+	}
+	templ.Handler(templates.TwoFactorPage(data)).ServeHTTP(c.Writer, c.Request)
+}
+
+// GetStatus returns the two-factor authentication of the current admin
+func (h *TwoFactorHandler) GetStatus(c *gin.Context) {
+	username, ok := h.currentAdmin(c)
+	if !ok {
+		return
+	}
+	status, err := h.twoFactor.Status(c.Request.Context(), username)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// BeginEnrollment creates a pending secret for the current admin to scan
+func (h *TwoFactorHandler) BeginEnrollment(c *gin.Context) {
+	username, ok := h.currentAdmin(c)
+	if !ok {
+		return
+	}
+	enrollment, err := h.twoFactor.BeginEnrollment(c.Request.Context(), username)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// ConfirmEnrollment enables the pending secret and returns the backup codes;
+// they are only returned here
+func (h *TwoFactorHandler) ConfirmEnrollment(c *gin.Context) {
+	username, req, ok := h.codeRequest(c)
+	if !ok {
+		return
+	}
+	codes, err := h.twoFactor.ConfirmEnrollment(c.Request.Context(), username, req.Code)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Two-factor authentication enabled; store the backup codes now, they will not be shown again",
+		"backup_codes": codes,
+	})
+}
+
+// RegenerateBackupCodes replaces the backup codes of the current admin
+func (h *TwoFactorHandler) RegenerateBackupCodes(c *gin.Context) {
+	username, req, ok := h.codeRequest(c)
+	if !ok {
+		return
+	}
+	codes, err := h.twoFactor.RegenerateBackupCodes(c.Request.Context(), username, req.Code)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Backup codes replaced; the previous codes no longer work",
+		"backup_codes": codes,
+	})
+}
+
+// Disable removes the second factor of the current admin
+func (h *TwoFactorHandler) Disable(c *gin.Context) {
+	username, req, ok := h.codeRequest(c)
+	if !ok {
+		return
+	}
+	if err := h.twoFactor.Disable(c.Request.Context(), username, req.Code); err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// currentAdmin returns the username of the signed-in admin, or responds and
+// reports false when the service or the username is not available
+func (h *TwoFactorHandler) currentAdmin(c *gin.Context) (string, bool) {
+	if h.twoFactor == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "two-factor authentication not available"})
+		return "", false
+	}
+	username := AdminUsername(c)
+	if username == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "admin sign-in required"})
+		return "", false
+	}
+	return username, true
+}
+
+// codeRequest binds the code of a request made by the signed-in admin
+func (h *TwoFactorHandler) codeRequest(c *gin.Context) (string, dto.TwoFactorCodeRequest, bool) {
+	var req dto.TwoFactorCodeRequest
+	username, ok := h.currentAdmin(c)
+	if !ok {
+		return "", req, false
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return "", req, false
+	}
+	return username, req, true
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/a-h/templ"
+	"github.com/aruncs31s/azf/application/service"
+	"github.com/aruncs31s/azf/application/templates"
+	"github.com/gin-gonic/gin"
+)
+
+// UsageQuotaHandler manages daily and monthly usage quotas
+type UsageQuotaHandler struct {
+	quotaService service.UsageQuotaService
+}
+
+// NewUsageQuotaHandler creates a new usage quota handler
+func NewUsageQuotaHandler(quotaService service.UsageQuotaService) (*UsageQuotaHandler, error) {
+	if err := requireDependencies("UsageQuotaHandler", dep("quotaService", quotaService)); err != nil {
+		return nil, err
+	}
+	return &UsageQuotaHandler{
+		quotaService: quotaService,
+	}, nil
+}
+
+// GetQuotasPage renders the consumption and remaining quota of every subject
+func (h *UsageQuotaHandler) GetQuotasPage(c *gin.Context) {
+	quotas, err := h.quotaService.ListQuotas()
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Failed to load usage quotas")
+		return
+	}
+
+	data := templates.UsageQuotasPageData{
+		GeneratedAt: time.Now(),
+		Quotas:      *quotas,
+	}
+	templ.Handler(templates.UsageQuotasPage(data)).ServeHTTP(c.Writer, c.Request)
+}
+
+// ListQuotas returns all quotas with their usage in the current period
+func (h *UsageQuotaHandler) ListQuotas(c *gin.Context) {
+	quotas, err := h.quotaService.ListQuotas()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"quotas": quotas})
+}
+
+// SetQuota creates or replaces the quota of a subject for a period
+func (h *UsageQuotaHandler) SetQuota(c *gin.Context) {
+	var req service.SetUsageQuotaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	quota, err := h.quotaService.SetQuota(req, "admin")
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Usage quota saved",
+		"quota":   quota,
+	})
+}
+
+// DeleteQuota removes a quota
+func (h *UsageQuotaHandler) DeleteQuota(c *gin.Context) {
+	if err := h.quotaService.DeleteQuota(c.Param("id")); err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Usage quota removed"})
+}
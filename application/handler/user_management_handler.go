@@ -0,0 +1,326 @@
+package handler
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/a-h/templ"
+	"github.com/aruncs31s/azf/application/service"
+	"github.com/aruncs31s/azf/application/templates"
+	usermodel "github.com/aruncs31s/azf/domain/user_management/model"
+	apperrors "github.com/aruncs31s/azf/shared/errors"
+	"github.com/gin-gonic/gin"
+)
+
+// UserManagementHandler lets admins search users, block, delete and restore
+// them and manage their roles
+type UserManagementHandler struct {
+	users          service.UserManagementService
+	profileService *service.AdminProfileService
+}
+
+// NewUserManagementHandler creates a new user management handler
+func NewUserManagementHandler(users service.UserManagementService, profileService *service.AdminProfileService) (*UserManagementHandler, error) {
+	if err := requireDependencies("UserManagementHandler",
+		dep("users", users),
+		dep("profileService", profileService),
+	); err != nil {
+		return nil, err
+	}
+	return &UserManagementHandler{
+		users:          users,
+		profileService: profileService,
+	}, nil
+}
+
+// RegisterRoutes registers the user pages and API behind auth
+func (h *UserManagementHandler) RegisterRoutes(r gin.IRoutes, auth gin.HandlerFunc) {
+	r.GET("/admin-ui/users", auth, h.GetUsersPage)
+	r.GET("/admin-ui/api/users", auth, h.SearchUsers)
+	r.GET("/admin-ui/api/users/:id", auth, h.GetUser)
+	r.POST("/admin-ui/api/users/:id/block", auth, h.BlockUser)
+	r.POST("/admin-ui/api/users/:id/unblock", auth, h.UnblockUser)
+	r.POST("/admin-ui/api/users/:id/roles", auth, h.AssignRole)
+	r.DELETE("/admin-ui/api/users/:id/roles/:role", auth, h.RemoveRole)
+	r.POST("/admin-ui/api/users/:id/promote", auth, h.PromoteUser)
+	r.POST("/admin-ui/api/users/:id/demote", auth, h.DemoteUser)
+	r.DELETE("/admin-ui/api/users/:id", auth, h.DeleteUser)
+	r.POST("/admin-ui/api/users/:id/restore", auth, h.RestoreUser)
+	r.GET("/admin-ui/api/users/:id/audit", auth, h.GetUserAudit)
+	r.GET("/admin-ui/api/user-audit", auth, h.ListUserAudit)
+}
+
+// GetUsersPage renders the user search page
+func (h *UserManagementHandler) GetUsersPage(c *gin.Context) {
+	filter, err := userSearchFilter(c)
+	if err != nil {
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	page, err := h.users.Search(c.Request.Context(), c.Query("q"), filter)
+	if err != nil {
+		c.String(errorStatus(err, http.StatusInternalServerError), "Failed to load users: %v", err)
+		return
+	}
+	audit, err := h.users.RecentAudit(c.Request.Context(), 0)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Failed to load the user audit trail: %v", err)
+		return
+	}
+	// Roles only suggest names; users may hold roles without policies yet
+	roles, _ := h.profileService.GetAllRolesFromCasbin()
+
+	data := templates.UsersPageData{
+		GeneratedAt: time.Now(),
+		Query:       c.Query("q"),
+		Status:      c.Query("status"),
+		Role:        c.Query("role"),
+		Admin:       c.Query("admin"),
+		Page:        *page,
+		Audit:       audit,
+		Roles:       roles,
+		Statuses: []string{
+			usermodel.StatusActive.String(),
+			usermodel.StatusBlocked.String(),
+			usermodel.StatusPending.String(),
+			usermodel.StatusSuspended.String(),
+			usermodel.StatusDeleted.String(),
+		},
+	}
+	if page.Offset > 0 {
+		data.PrevURL = usersPageURL(c, max(page.Offset-page.Limit, 0))
+	}
+	if page.HasMore {
+		data.NextURL = usersPageURL(c, page.Offset+page.Limit)
+	}
+	templ.Handler(templates.UsersPage(data)).ServeHTTP(c.Writer, c.Request)
+}
+
+// SearchUsers returns a page of users. It takes q, matched against the
+// username, display name and email, and the status, role, admin,
+// created_after, created_before, last_login_after, limit and offset filters.
+func (h *UserManagementHandler) SearchUsers(c *gin.Context) {
+	filter, err := userSearchFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	page, err := h.users.Search(c.Request.Context(), c.Query("q"), filter)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// GetUser returns a user
+func (h *UserManagementHandler) GetUser(c *gin.Context) {
+	user, err := h.users.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// BlockUser blocks a user with a reason
+func (h *UserManagementHandler) BlockUser(c *gin.Context) {
+	var req struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.users.Block(c.Request.Context(), c.Param("id"), req.Reason, roleSession(c, false))
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User blocked", "user": user})
+}
+
+// UnblockUser makes a blocked user active again
+func (h *UserManagementHandler) UnblockUser(c *gin.Context) {
+	user, err := h.users.Unblock(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User unblocked", "user": user})
+}
+
+// AssignRole gives a user a role, on the user and in Casbin
+func (h *UserManagementHandler) AssignRole(c *gin.Context) {
+	var req struct {
+		Role string `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.users.AssignRole(c.Request.Context(), c.Param("id"), req.Role)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Role assigned", "user": user})
+}
+
+// RemoveRole takes a role from a user, on the user and in Casbin. With
+// override_lockout=true a role the session depends on is removed once
+// another superadmin approves it.
+func (h *UserManagementHandler) RemoveRole(c *gin.Context) {
+	override := c.Query("override_lockout") == "true"
+	user, err := h.users.RemoveRole(c.Request.Context(), c.Param("id"), c.Param("role"), roleSession(c, override))
+	if err != nil {
+		respondRoleChangeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Role removed", "user": user})
+}
+
+// PromoteUser marks a user as admin
+func (h *UserManagementHandler) PromoteUser(c *gin.Context) {
+	user, err := h.users.PromoteToAdmin(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User promoted to admin", "user": user})
+}
+
+// DemoteUser removes the admin mark of a user
+func (h *UserManagementHandler) DemoteUser(c *gin.Context) {
+	user, err := h.users.DemoteFromAdmin(c.Request.Context(), c.Param("id"), roleSession(c, false))
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User demoted from admin", "user": user})
+}
+
+// DeleteUser soft-deletes a user, with an optional reason
+func (h *UserManagementHandler) DeleteUser(c *gin.Context) {
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if err := h.users.Delete(c.Request.Context(), c.Param("id"), req.Reason, roleSession(c, false)); err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User deleted"})
+}
+
+// RestoreUser brings back a deleted user with its roles
+func (h *UserManagementHandler) RestoreUser(c *gin.Context) {
+	user, err := h.users.Restore(c.Request.Context(), c.Param("id"), roleSession(c, false))
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User restored", "user": user})
+}
+
+// GetUserAudit returns the audit trail of a user
+func (h *UserManagementHandler) GetUserAudit(c *gin.Context) {
+	entries, err := h.users.History(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"entries": entries})
+}
+
+// ListUserAudit returns the latest audit trail entries of every user;
+// supports ?limit=
+func (h *UserManagementHandler) ListUserAudit(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	entries, err := h.users.RecentAudit(c.Request.Context(), limit)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"entries": entries})
+}
+
+// userSearchFilter reads the user search filters from the query string
+func userSearchFilter(c *gin.Context) (usermodel.UserSearchFilter, error) {
+	var filter usermodel.UserSearchFilter
+	if value := c.Query("status"); value != "" {
+		status, err := usermodel.NewUserStatus(strings.ToUpper(value))
+		if err != nil {
+			return filter, apperrors.Newf(apperrors.ErrValidation, "%v", err)
+		}
+		filter.Status = &status
+	}
+	if value := strings.TrimSpace(c.Query("role")); value != "" {
+		filter.RoleName = &value
+	}
+	if value := c.Query("admin"); value != "" {
+		isAdmin, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, apperrors.Newf(apperrors.ErrValidation, "admin must be true or false")
+		}
+		filter.IsAdmin = &isAdmin
+	}
+	for param, target := range map[string]**time.Time{
+		"created_after":    &filter.CreatedAfter,
+		"created_before":   &filter.CreatedBefore,
+		"last_login_after": &filter.LastLoginAfter,
+	} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		t, err := parseUserFilterTime(value)
+		if err != nil {
+			return filter, apperrors.Newf(apperrors.ErrValidation, "%s must be an RFC 3339 time or a date", param)
+		}
+		*target = &t
+	}
+	for param, target := range map[string]*int{"limit": &filter.Limit, "offset": &filter.Offset} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return filter, apperrors.Newf(apperrors.ErrValidation, "%s must be a non-negative number", param)
+		}
+		*target = n
+	}
+	return filter, nil
+}
+
+// parseUserFilterTime parses an RFC 3339 time or a date
+func parseUserFilterTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
+
+// usersPageURL returns the users page URL with the current filters at offset
+func usersPageURL(c *gin.Context, offset int) string {
+	query := url.Values{}
+	for key, values := range c.Request.URL.Query() {
+		query[key] = values
+	}
+	query.Set("offset", strconv.Itoa(offset))
+	return "/admin-ui/users?" + query.Encode()
+}
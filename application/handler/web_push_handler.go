@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/aruncs31s/azf/application/service"
+	"github.com/gin-gonic/gin"
+)
+
+// pushServiceWorkerJS shows alerts pushed to the admin's browser and opens the
+// dashboard when one is clicked. It is served from /admin-ui so its scope
+// covers the dashboard.
+const pushServiceWorkerJS = `self.addEventListener('push', function (event) {
+	var data = {};
+	try { data = event.data ? event.data.json() : {}; } catch (e) { data = { body: event.data ? event.data.text() : '' }; }
+	event.waitUntil(self.registration.showNotification(data.title || 'AZF alert', {
+		body: data.body || '',
+		tag: data.title || 'azf-alert',
+		requireInteraction: data.severity === 'CRITICAL',
+		data: { url: data.url || '/admin-ui' }
+	}));
+});
+
+self.addEventListener('notificationclick', function (event) {
+	event.notification.close();
+	var url = (event.notification.data && event.notification.data.url) || '/admin-ui';
+	event.waitUntil(clients.openWindow(url));
+});
+`
+
+// pushClientJS registers the service worker and subscribes the browser
+const pushClientJS = `async function azfEnablePush() {
+	if (!('serviceWorker' in navigator) || !('PushManager' in window)) {
+		alert('Push notifications are not supported by this browser');
+		return;
+	}
+	var keyResponse = await fetch('/admin-ui/api/push/public-key');
+	if (!keyResponse.ok) {
+		alert('Push notifications are not enabled on this server');
+		return;
+	}
+	var key = (await keyResponse.json()).public_key;
+	var permission = await Notification.requestPermission();
+	if (permission !== 'granted') {
+		return;
+	}
+	var registration = await navigator.serviceWorker.register('/admin-ui/push-sw.js', { scope: '/admin-ui' });
+	var padding = '='.repeat((4 - key.length % 4) % 4);
+	var raw = atob((key + padding).replace(/-/g, '+').replace(/_/g, '/'));
+	var applicationServerKey = Uint8Array.from(raw, function (c) { return c.charCodeAt(0); });
+	var subscription = await registration.pushManager.subscribe({ userVisibleOnly: true, applicationServerKey: applicationServerKey });
+	var severity = prompt('Push alerts at or above which severity? (INFO, WARNING, CRITICAL)', 'CRITICAL');
+	var body = subscription.toJSON();
+	body.min_severity = severity || 'CRITICAL';
+	var response = await fetch('/admin-ui/api/push/subscriptions', {
+		method: 'POST',
+		headers: { 'Content-Type': 'application/json' },
+		body: JSON.stringify(body)
+	});
+	var result = await response.json();
+	alert(response.ok ? 'Push alerts enabled for this browser' : (result.error || 'Failed to enable push alerts'));
+}
+`
+
+// WebPushHandler manages admin browser push subscriptions
+type WebPushHandler struct {
+	webPushService service.WebPushService
+}
+
+// NewWebPushHandler creates a new web push handler
+func NewWebPushHandler(webPushService service.WebPushService) (*WebPushHandler, error) {
+	if err := requireDependencies("WebPushHandler", dep("webPushService", webPushService)); err != nil {
+		return nil, err
+	}
+	return &WebPushHandler{
+		webPushService: webPushService,
+	}, nil
+}
+
+// GetServiceWorker serves the push service worker script
+func (h *WebPushHandler) GetServiceWorker(c *gin.Context) {
+	c.Header("Service-Worker-Allowed", "/admin-ui")
+	c.Data(http.StatusOK, "application/javascript; charset=utf-8", []byte(pushServiceWorkerJS))
+}
+
+// GetClientScript serves the script that subscribes the dashboard to push alerts
+func (h *WebPushHandler) GetClientScript(c *gin.Context) {
+	c.Data(http.StatusOK, "application/javascript; charset=utf-8", []byte(pushClientJS))
+}
+
+// GetPublicKey returns the VAPID public key browsers subscribe with
+func (h *WebPushHandler) GetPublicKey(c *gin.Context) {
+	key := h.webPushService.PublicKey()
+	if key == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "web push is not enabled"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"public_key": key})
+}
+
+// ListSubscriptions returns the current admin's push subscriptions
+func (h *WebPushHandler) ListSubscriptions(c *gin.Context) {
+	subscriptions, err := h.webPushService.ListSubscriptions(currentAdminUsername(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"subscriptions": subscriptions})
+}
+
+// Subscribe registers the browser push subscription sent by the dashboard
+func (h *WebPushHandler) Subscribe(c *gin.Context) {
+	var req service.WebPushSubscribeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subscription, err := h.webPushService.Subscribe(currentAdminUsername(c), req, c.Request.UserAgent())
+	if err != nil {
+		respondError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":      "Push subscription registered",
+		"subscription": subscription,
+	})
+}
+
+// UpdateSubscription changes the lowest alert severity pushed to a subscription
+func (h *WebPushHandler) UpdateSubscription(c *gin.Context) {
+	var req service.UpdateWebPushSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subscription, err := h.webPushService.UpdateMinSeverity(currentAdminUsername(c), c.Param("id"), req.MinSeverity)
+	if err != nil {
+		respondError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Push subscription updated",
+		"subscription": subscription,
+	})
+}
+
+// DeleteSubscription removes one of the current admin's push subscriptions
+func (h *WebPushHandler) DeleteSubscription(c *gin.Context) {
+	if err := h.webPushService.Unsubscribe(currentAdminUsername(c), c.Param("id")); err != nil {
+		respondError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Push subscription removed"})
+}
+
+// SendTest pushes a test alert to the current admin's browsers
+func (h *WebPushHandler) SendTest(c *gin.Context) {
+	delivered, err := h.webPushService.SendTest(currentAdminUsername(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Test notification sent",
+		"delivered": delivered,
+	})
+}
+
+// currentAdminUsername returns the username of the logged-in admin, or
+// "admin" if it cannot be read
+func currentAdminUsername(c *gin.Context) string {
+	if username := AdminUsername(c); username != "" {
+		return username
+	}
+	return "admin"
+}
+
+// AdminUsername returns the username in the dashboard JWT cookie, or "" if
+// there is no valid token
+func AdminUsername(c *gin.Context) string {
+	username, _ := adminClaims(c)["username"].(string)
+	return username
+}
+
+// roleSession describes the dashboard session making the current request,
+// for role changes that must not lock it out
+func roleSession(c *gin.Context, overrideLockout bool) service.RoleSession {
+	claims := adminClaims(c)
+	username, _ := claims["username"].(string)
+	userID, _ := claims["user_id"].(string)
+	role, _ := claims["role"].(string)
+	return service.RoleSession{
+		Username:        username,
+		UserID:          userID,
+		Role:            role,
+		Resource:        c.Request.URL.Path,
+		Action:          c.Request.Method,
+		OverrideLockout: overrideLockout,
+	}
+}
+
+// adminClaims returns the claims of the dashboard JWT cookie, or nil if
+// there is no valid token
+func adminClaims(c *gin.Context) map[string]interface{} {
+	token, err := c.Cookie("jwt_token")
+	if err != nil || token == "" {
+		return nil
+	}
+	claims, err := service.ValidateJWT(token)
+	if err != nil {
+		return nil
+	}
+	return claims
+}
@@ -0,0 +1,296 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/a-h/templ"
+	"github.com/aruncs31s/azf/application/service"
+	"github.com/aruncs31s/azf/application/templates"
+	"github.com/aruncs31s/azf/infrastructure/webhook"
+	"github.com/gin-gonic/gin"
+)
+
+// maxReplayRequestBody bounds the body of a subscriber replay request
+const maxReplayRequestBody = 4 << 10
+
+// WebhookHandler manages the webhook subscriptions that receive authorization events
+type WebhookHandler struct {
+	webhookService service.WebhookService
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(webhookService service.WebhookService) (*WebhookHandler, error) {
+	if err := requireDependencies("WebhookHandler", dep("webhookService", webhookService)); err != nil {
+		return nil, err
+	}
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}, nil
+}
+
+// GetWebhooksPage renders the subscription list with failed events to replay
+func (h *WebhookHandler) GetWebhooksPage(c *gin.Context) {
+	ctx := c.Request.Context()
+	subscriptions, err := h.webhookService.ListSubscriptions(ctx)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Failed to load webhook subscriptions")
+		return
+	}
+	failedEvents, err := h.webhookService.ListFailedEvents(ctx)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Failed to load failed webhook events")
+		return
+	}
+
+	data := templates.WebhooksPageData{
+		GeneratedAt:   time.Now(),
+		Enabled:       h.webhookService.Enabled(),
+		EventTypes:    h.webhookService.EventTypes(),
+		Subscriptions: *subscriptions,
+		FailedEvents:  *failedEvents,
+	}
+	templ.Handler(templates.WebhooksPage(data)).ServeHTTP(c.Writer, c.Request)
+}
+
+// GetWebhookDetailsPage renders a subscription with its delivery history;
+// ?tab=deliveries opens the delivery history tab
+func (h *WebhookHandler) GetWebhookDetailsPage(c *gin.Context) {
+	ctx := c.Request.Context()
+	subscription, err := h.webhookService.GetSubscription(ctx, c.Param("id"))
+	if err != nil {
+		c.String(errorStatus(err, http.StatusInternalServerError), err.Error())
+		return
+	}
+	deliveries, err := h.webhookService.ListDeliveries(ctx, subscription.ID, 0)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Failed to load webhook deliveries")
+		return
+	}
+
+	data := templates.WebhookDetailsPageData{
+		GeneratedAt:  time.Now(),
+		Subscription: *subscription,
+		Deliveries:   *deliveries,
+		Tab:          templates.WebhookTabOverview,
+	}
+	if c.Query("tab") == templates.WebhookTabDeliveries {
+		data.Tab = templates.WebhookTabDeliveries
+	}
+	templ.Handler(templates.WebhookDetailsPage(data)).ServeHTTP(c.Writer, c.Request)
+}
+
+// ListSubscriptions returns every webhook subscription with its health
+func (h *WebhookHandler) ListSubscriptions(c *gin.Context) {
+	subscriptions, err := h.webhookService.ListSubscriptions(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"subscriptions": subscriptions})
+}
+
+// CreateSubscription registers a webhook subscription. The response holds the
+// signing secret, which is not shown again.
+func (h *WebhookHandler) CreateSubscription(c *gin.Context) {
+	var req service.CreateWebhookSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subscription, err := h.webhookService.CreateSubscription(c.Request.Context(), req)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":      "Webhook subscription created",
+		"subscription": subscription,
+	})
+}
+
+// TestSubscription sends a test event to the subscription endpoint
+func (h *WebhookHandler) TestSubscription(c *gin.Context) {
+	if err := h.webhookService.TestSubscription(c.Request.Context(), c.Param("id")); err != nil {
+		respondError(c, err, http.StatusBadGateway)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Test event delivered"})
+}
+
+// SuspendSubscription stops deliveries to a subscription
+func (h *WebhookHandler) SuspendSubscription(c *gin.Context) {
+	subscription, err := h.webhookService.SuspendSubscription(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Webhook subscription suspended",
+		"subscription": subscription,
+	})
+}
+
+// ActivateSubscription resumes deliveries to a subscription
+func (h *WebhookHandler) ActivateSubscription(c *gin.Context) {
+	subscription, err := h.webhookService.ActivateSubscription(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Webhook subscription activated",
+		"subscription": subscription,
+	})
+}
+
+// UpdateFilters replaces the filters that limit the events sent to a subscription
+func (h *WebhookHandler) UpdateFilters(c *gin.Context) {
+	var req service.UpdateWebhookFiltersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subscription, err := h.webhookService.UpdateFilters(c.Request.Context(), c.Param("id"), req.Filters)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook filters updated", "subscription": subscription})
+}
+
+// UpdateTransport replaces the transport security options of a subscription
+// once the endpoint accepts a test event sent with them
+func (h *WebhookHandler) UpdateTransport(c *gin.Context) {
+	var req service.WebhookTransportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subscription, err := h.webhookService.UpdateTransport(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook transport updated", "subscription": subscription})
+}
+
+// DeleteSubscription removes a webhook subscription
+func (h *WebhookHandler) DeleteSubscription(c *gin.Context) {
+	if err := h.webhookService.DeleteSubscription(c.Request.Context(), c.Param("id")); err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook subscription deleted"})
+}
+
+// ListDeliveries returns the recent delivery attempts of a subscription; supports ?limit=
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	deliveries, err := h.webhookService.ListDeliveries(c.Request.Context(), c.Param("id"), limit)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deliveries": deliveries})
+}
+
+// RedeliverEvent sends an event to the subscription again and returns the attempt
+func (h *WebhookHandler) RedeliverEvent(c *gin.Context) {
+	delivery, err := h.webhookService.RedeliverEvent(c.Request.Context(), c.Param("id"), c.Param("eventId"))
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	message := "Webhook event redelivered"
+	if !delivery.Success {
+		message = "Webhook event redelivery failed"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":  message,
+		"delivery": delivery,
+	})
+}
+
+// ListEventDeliveries returns every delivery attempt of an event
+func (h *WebhookHandler) ListEventDeliveries(c *gin.Context) {
+	deliveries, err := h.webhookService.ListEventDeliveries(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deliveries": deliveries})
+}
+
+// ListFailedEvents returns the events whose delivery failed or was abandoned
+func (h *WebhookHandler) ListFailedEvents(c *gin.Context) {
+	events, err := h.webhookService.ListFailedEvents(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"events": events})
+}
+
+// ReplayEvent delivers a failed event again and returns its new delivery state
+func (h *WebhookHandler) ReplayEvent(c *gin.Context) {
+	event, err := h.webhookService.ReplayEvent(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Webhook event replayed",
+		"event":   event,
+	})
+}
+
+// ReplaySubscriberEvents returns a page of the stored events of a
+// subscription to its subscriber. The JSON body is signed with the
+// subscription secret in the X-Webhook-Timestamp and X-Webhook-Signature
+// headers, the way deliveries are.
+func (h *WebhookHandler) ReplaySubscriberEvents(c *gin.Context) {
+	ctx := c.Request.Context()
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxReplayRequestBody))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err = h.webhookService.AuthenticateSubscriber(ctx, c.Param("id"),
+		c.GetHeader(webhook.HeaderTimestamp), c.GetHeader(webhook.HeaderSignature), body)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	var req service.WebhookReplayRequest
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	page, err := h.webhookService.ReplayEvents(ctx, c.Param("id"), req)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"net/http"
+
+	authorization_audit "github.com/aruncs31s/azf/domain/authorization_audit/model"
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookSchemaHandler publishes the JSON schemas of the webhook payloads, so
+// subscribers can code and test against them
+type WebhookSchemaHandler struct{}
+
+// NewWebhookSchemaHandler creates a webhook schema handler
+func NewWebhookSchemaHandler() *WebhookSchemaHandler {
+	return &WebhookSchemaHandler{}
+}
+
+// ListSchemas returns the schema version and the URL of every schema
+func (h *WebhookSchemaHandler) ListSchemas(c *gin.Context) {
+	schemas := make(map[string]string)
+	for _, name := range authorization_audit.WebhookSchemaNames() {
+		schemas[name] = "/webhooks/schemas/" + name
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, gin.H{
+		"schema_version": authorization_audit.WebhookSchemaVersion,
+		"schemas":        schemas,
+	})
+}
+
+// GetSchema returns the schema of an event type's data, or of the payload
+// around it for "envelope"
+func (h *WebhookSchemaHandler) GetSchema(c *gin.Context) {
+	schema, ok := authorization_audit.WebhookSchemaJSON(c.Param("type"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no schema for " + c.Param("type")})
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	c.Data(http.StatusOK, "application/schema+json", schema)
+}
@@ -1,11 +1,17 @@
 package middleware
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"strings"
 
+	"github.com/aruncs31s/azf/constants"
+	identity_access "github.com/aruncs31s/azf/domain/identity_access/model"
+	"github.com/aruncs31s/azf/shared/logger"
 	"github.com/aruncs31s/azf/utils"
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
 // AdminSessionMiddleware checks for admin session cookie and injects JWT token into Authorization header
@@ -59,16 +65,68 @@ func AdminAuthMiddleware() gin.HandlerFunc {
 	}
 }
 
+// AdminSessionValidator returns the admin session of the session cookie,
+// failing when it is unknown, expired or revoked
+type AdminSessionValidator func(ctx context.Context, token string) (*identity_access.AdminSession, error)
+
+var adminSessionValidator AdminSessionValidator
+
+// SetAdminSessionValidator makes CheckAdminAuth check the session cookie
+// against the session store rather than only its presence
+func SetAdminSessionValidator(validate AdminSessionValidator) {
+	adminSessionValidator = validate
+}
+
 // CheckAdminAuth is a helper middleware that returns to login if not authenticated
 func CheckAdminAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		_, err := c.Cookie("admin_session")
+		sessionID, err := c.Cookie("admin_session")
 		if err != nil {
 			c.Redirect(http.StatusFound, "/admin-ui/login")
 			c.Abort()
 			return
 		}
 
+		if adminSessionValidator != nil {
+			session, err := adminSessionValidator(c.Request.Context(), sessionID)
+			if err != nil {
+				logger.Debug("Admin session rejected", zap.String("path", c.Request.URL.Path), zap.Error(err))
+				c.SetCookie("admin_session", "", -1, "/admin-ui", "", false, true)
+				c.Redirect(http.StatusFound, "/admin-ui/login")
+				c.Abort()
+				return
+			}
+			c.Set("admin_session_id", session.ID)
+		}
+
+		c.Next()
+	}
+}
+
+// AdminAPIAuth authenticates automation calling the admin JSON API. It
+// requires a bearer JWT with the admin role; session cookies are not
+// accepted, so browsers cannot be made to call the API cross-site.
+func AdminAPIAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		if !strings.HasPrefix(authHeader, "Bearer ") {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": utils.ErrNoAuthHeader.Error()})
+			return
+		}
+		claims, err := parseBearerToken(strings.TrimPrefix(authHeader, "Bearer "))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": invalidTokenChallenge(c, err).Error()})
+			return
+		}
+		if role, _ := claims["role"].(string); role != constants.ADMIN {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "admin role required"})
+			return
+		}
+
+		c.Set("jwt_claims", claims)
+		if username, ok := claims["username"].(string); ok {
+			c.Set("admin_username", username)
+		}
 		c.Next()
 	}
 }
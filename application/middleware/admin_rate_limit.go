@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aruncs31s/azf/constants"
+	"github.com/aruncs31s/azf/shared/logger"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+)
+
+// adminPathPrefix is the prefix shared by the admin UI pages and admin API
+const adminPathPrefix = "/admin-ui"
+
+// adminLimiterIdleTTL is how long an unused per-identity limiter is kept
+const adminLimiterIdleTTL = 10 * time.Minute
+
+// isAdminPath reports whether path belongs to the admin UI or admin API
+func isAdminPath(path string) bool {
+	for _, prefix := range []string{adminPathPrefix, constants.ADMIN_POLICY_API_PATH} {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// isAdminLoginAttempt reports whether the request submits admin credentials
+func isAdminLoginAttempt(c *gin.Context) bool {
+	return c.Request.Method == http.MethodPost && strings.HasPrefix(c.Request.URL.Path, adminPathPrefix+"/login")
+}
+
+type adminLimiterEntry struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// AdminRateLimiter keeps a token bucket per admin identity. Buckets that have
+// not been used for a while are dropped so rotating identities cannot grow it
+// without bound.
+type AdminRateLimiter struct {
+	mu        sync.Mutex
+	limiters  map[string]*adminLimiterEntry
+	r         rate.Limit
+	b         int
+	lastSweep time.Time
+}
+
+// NewAdminRateLimiter creates a limiter allowing r requests per second with burst b per identity
+func NewAdminRateLimiter(r rate.Limit, b int) *AdminRateLimiter {
+	return &AdminRateLimiter{
+		limiters:  make(map[string]*adminLimiterEntry),
+		r:         r,
+		b:         b,
+		lastSweep: time.Now(),
+	}
+}
+
+// Allow takes a token for key. When none is available it returns false and
+// how long to wait before the next request would be allowed.
+func (l *AdminRateLimiter) Allow(key string) (bool, time.Duration) {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) >= adminLimiterIdleTTL {
+		for k, entry := range l.limiters {
+			if now.Sub(entry.lastSeen) >= adminLimiterIdleTTL {
+				delete(l.limiters, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	entry, ok := l.limiters[key]
+	if !ok {
+		entry = &adminLimiterEntry{limiter: rate.NewLimiter(l.r, l.b)}
+		l.limiters[key] = entry
+	}
+	entry.lastSeen = now
+
+	reservation := entry.limiter.ReserveN(now, 1)
+	if !reservation.OK() {
+		return false, time.Minute
+	}
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return false, delay
+	}
+	return true, 0
+}
+
+// AdminRateLimitMiddleware limits requests to the admin UI and admin API.
+// Sign-in attempts are limited per client IP by loginLimiter; other admin
+// requests per admin identity, as returned by identify, falling back to the
+// client IP for requests without a valid admin session. Non-admin paths are
+// left to the application rate limiter.
+func AdminRateLimitMiddleware(limiter, loginLimiter *AdminRateLimiter, identify func(c *gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		if !isAdminPath(path) {
+			c.Next()
+			return
+		}
+
+		var key string
+		var l *AdminRateLimiter
+		if isAdminLoginAttempt(c) {
+			key = "ip:" + c.ClientIP()
+			l = loginLimiter
+		} else {
+			if identify != nil {
+				key = identify(c)
+			}
+			if key == "" {
+				key = "ip:" + c.ClientIP()
+			}
+			l = limiter
+		}
+		if l == nil {
+			c.Next()
+			return
+		}
+
+		allowed, retryAfter := l.Allow(key)
+		if !allowed {
+			logger.Warn("Admin rate limit exceeded",
+				zap.String("identity", key),
+				zap.String("path", path))
+			c.Header("Retry-After", fmt.Sprintf("%d", int(math.Ceil(retryAfter.Seconds()))))
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error": "Admin rate limit exceeded. Please try again later.",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// isMutatingMethod reports whether method changes server state
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// AdminReadOnlyMiddleware rejects mutating admin requests while isReadOnly
+// returns true. Paths starting with one of the exempt prefixes stay writable;
+// onBlocked is called for every rejected request so it can be audited.
+func AdminReadOnlyMiddleware(isReadOnly func() bool, exemptPrefixes []string, onBlocked func(c *gin.Context)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		if !isAdminPath(path) || !isMutatingMethod(c.Request.Method) || !isReadOnly() {
+			c.Next()
+			return
+		}
+		for _, prefix := range exemptPrefixes {
+			if strings.HasPrefix(path, prefix) {
+				c.Next()
+				return
+			}
+		}
+
+		if onBlocked != nil {
+			onBlocked(c)
+		}
+		c.JSON(http.StatusLocked, gin.H{
+			"error":     "The admin UI is in read-only mode. Changes are disabled until a superadmin turns it off.",
+			"read_only": true,
+		})
+		c.Abort()
+	}
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"context"
+
+	identity_access "github.com/aruncs31s/azf/domain/identity_access/model"
+	"github.com/aruncs31s/azf/shared/logger"
+	"github.com/aruncs31s/azf/utils"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// APIKeyHeader carries the API key of requests authenticated without a JWT
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyAuthenticator returns the key presented with a request, failing
+// when it is unknown, expired or revoked
+type APIKeyAuthenticator func(ctx context.Context, key string) (*identity_access.APIKey, error)
+
+var apiKeyAuthenticator APIKeyAuthenticator
+
+// SetAPIKeyAuthenticator lets JwtMiddleware accept an X-API-Key header in
+// place of a bearer token
+func SetAPIKeyAuthenticator(authenticate APIKeyAuthenticator) {
+	apiKeyAuthenticator = authenticate
+}
+
+// authenticateAPIKey sets the user ID and role of the key presented with
+// the request, or rejects the request. It reports whether the request may
+// continue.
+func authenticateAPIKey(c *gin.Context, key string) bool {
+	apiKey, err := apiKeyAuthenticator(c.Request.Context(), key)
+	if err != nil {
+		logger.Warn("API key rejected", zap.String("path", c.Request.URL.Path), zap.Error(err))
+		responseHelper.Unauthorized(c, utils.ErrInvalidAPIKey.Error())
+		c.Abort()
+		return false
+	}
+
+	c.Set("api_key", apiKey)
+	c.Set("api_key_id", apiKey.ID)
+	c.Set("user_id", apiKey.UserID)
+	c.Set("user_role", apiKey.Role)
+	return true
+}
+
+// GetAPIKey returns the API key the request authenticated with, or nil
+func GetAPIKey(c *gin.Context) *identity_access.APIKey {
+	if value, exists := c.Get("api_key"); exists {
+		if apiKey, ok := value.(*identity_access.APIKey); ok {
+			return apiKey
+		}
+	}
+	return nil
+}
@@ -8,7 +8,7 @@ import (
 
 	"github.com/aruncs31s/azf/domain/api_usage"
 	"github.com/aruncs31s/azf/domain/repository"
-	"github.com/aruncs31s/azf/infrastructure/persistence"
+	"github.com/aruncs31s/azf/infrastructure/analytics"
 	initializers "github.com/aruncs31s/azf/initializer"
 	"github.com/aruncs31s/azf/shared/logger"
 	"github.com/gin-gonic/gin"
@@ -22,11 +22,19 @@ func InitAPIUsageTracking(repo repository.APIUsageLogRepository) {
 	apiUsageRepo = repo
 }
 
+// usageTrackingEnabled switches usage tracking at runtime; tracking is on until set
+var usageTrackingEnabled = func() bool { return true }
+
+// SetUsageTrackingSwitch installs the runtime switch for usage tracking
+func SetUsageTrackingSwitch(enabled func() bool) {
+	usageTrackingEnabled = enabled
+}
+
 // APIUsageTrackingMiddleware tracks API endpoint usage
 func APIUsageTrackingMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Skip tracking for certain paths
-		if shouldSkipTracking(c.Request.URL.Path) {
+		// Skip tracking for certain paths and for CORS preflight requests
+		if shouldSkipTracking(c.Request.URL.Path) || IsPreflightRequest(c.Request) || !usageTrackingEnabled() {
 			c.Next()
 			return
 		}
@@ -34,11 +42,12 @@ func APIUsageTrackingMiddleware() gin.HandlerFunc {
 		// Record start time
 		startTime := time.Now()
 
-		// Capture request body if it exists
-		var requestBody []byte
+		// Count request body bytes as the handler reads them; the body is not
+		// buffered so per-route body size limits downstream still apply
+		var requestBody *countingReadCloser
 		if c.Request.Body != nil {
-			requestBody, _ = io.ReadAll(c.Request.Body)
-			c.Request.Body = io.NopCloser(bytes.NewBuffer(requestBody))
+			requestBody = &countingReadCloser{ReadCloser: c.Request.Body}
+			c.Request.Body = requestBody
 		}
 
 		// Capture response using response writer wrapper
@@ -58,18 +67,27 @@ func APIUsageTrackingMiddleware() gin.HandlerFunc {
 		// Extract user ID from JWT or context
 		userID := extractUserID(c)
 
+		requestID := GetRequestID(c)
+		if len(requestID) > maxRequestIDLength {
+			requestID = ""
+		}
+
 		// Prepare usage log
 		usageLog := &api_usage.APIUsageLog{
 			ID:           uuid.New().String(),
+			RequestID:    requestID,
 			Endpoint:     c.Request.URL.Path,
 			Method:       c.Request.Method,
 			StatusCode:   c.Writer.Status(),
 			ResponseTime: responseTime,
-			RequestSize:  len(requestBody),
-			ResponseSize: responseWriter.body.Len(),
+			RequestSize:  requestSize(c, requestBody),
+			ResponseSize: int64(responseWriter.Size()),
 			UserID:       userID,
+			APIKeyID:     GetAPIKeyID(c),
 			ClientIP:     c.ClientIP(),
 			UserAgent:    c.Request.UserAgent(),
+			Conditional:  c.GetHeader("If-None-Match") != "" || c.GetHeader("If-Modified-Since") != "",
+			HasValidator: c.Writer.Header().Get("ETag") != "" || c.Writer.Header().Get("Last-Modified") != "",
 			RequestedAt:  startTime,
 			CreatedAt:    time.Now(),
 		}
@@ -90,22 +108,67 @@ func APIUsageTrackingMiddleware() gin.HandlerFunc {
 	}
 }
 
-// responseBodyCapture wraps gin.ResponseWriter to capture response body
+// maxCapturedResponseBytes bounds how much of the response body is kept for
+// the error message; the full size is still taken from the writer
+const maxCapturedResponseBytes = 500
+
+// responseBodyCapture wraps gin.ResponseWriter to capture the start of the response body
 type responseBodyCapture struct {
 	gin.ResponseWriter
 	body *bytes.Buffer
 }
 
 func (w *responseBodyCapture) Write(b []byte) (int, error) {
-	w.body.Write(b)
+	w.capture(b)
 	return w.ResponseWriter.Write(b)
 }
 
 func (w *responseBodyCapture) WriteString(s string) (int, error) {
-	w.body.WriteString(s)
+	w.capture([]byte(s))
 	return w.ResponseWriter.WriteString(s)
 }
 
+func (w *responseBodyCapture) capture(b []byte) {
+	if remaining := maxCapturedResponseBytes - w.body.Len(); remaining > 0 {
+		if len(b) > remaining {
+			b = b[:remaining]
+		}
+		w.body.Write(b)
+	}
+}
+
+// Size returns the number of response body bytes written
+func (w *responseBodyCapture) Size() int {
+	if size := w.ResponseWriter.Size(); size > 0 {
+		return size
+	}
+	return 0
+}
+
+// countingReadCloser counts the bytes read from a request body
+type countingReadCloser struct {
+	io.ReadCloser
+	n int64
+}
+
+func (r *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// requestSize returns the declared Content-Length, falling back to the bytes
+// actually read for chunked requests
+func requestSize(c *gin.Context, body *countingReadCloser) int64 {
+	if c.Request.ContentLength > 0 {
+		return c.Request.ContentLength
+	}
+	if body != nil {
+		return body.n
+	}
+	return 0
+}
+
 // shouldSkipTracking checks if the path should be skipped from tracking
 func shouldSkipTracking(path string) bool {
 	skipPaths := map[string]bool{
@@ -114,6 +177,9 @@ func shouldSkipTracking(path string) bool {
 		"/swagger":          true,
 		"/admin-ui/login":   true,
 		"/admin-ui/metrics": true,
+		"/metrics":          true,
+		"/status":           true,
+		"/status.json":      true,
 	}
 
 	// Check exact matches
@@ -180,12 +246,10 @@ func storeAPIUsageLog(
 
 // UpdateAPIUsageStats updates aggregated statistics for an endpoint
 func UpdateAPIUsageStats(endpoint string, method string) error {
-	statsRepo := persistence.NewAPIUsageStatsRepository(initializers.DB)
-	return statsRepo.RecalculateStats(endpoint, method)
+	return analytics.Default(initializers.DB).Stats().RecalculateStats(endpoint, method)
 }
 
 // CleanupOldAPIUsageLogs removes logs older than specified days
 func CleanupOldAPIUsageLogs(days int) error {
-	repo := persistence.NewAPIUsageRepository(initializers.DB)
-	return repo.DeleteOlderThan(days)
+	return analytics.Default(initializers.DB).Logs().DeleteOlderThan(days)
 }
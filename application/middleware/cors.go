@@ -1,9 +1,12 @@
 package middleware
 
 import (
+	"net/http"
 	"slices"
+	"strconv"
 	"strings"
 
+	"github.com/aruncs31s/azf/config"
 	"github.com/gin-gonic/gin"
 )
 
@@ -35,6 +38,7 @@ func DefaultCORSConfig() *CORSConfig {
 			"Accept-Encoding",
 			"X-CSRF-Token",
 			"Authorization",
+			APIKeyHeader,
 			"Accept",
 			"Cache-Control",
 			"X-Requested-With",
@@ -53,40 +57,66 @@ func DevelopmentCORSConfig() *CORSConfig {
 	return cfg
 }
 
+// CORSResolver returns the CORS policy of the route serving method on the
+// request path, or nil to use the global policy. Preflight requests are
+// resolved for the method in Access-Control-Request-Method.
+type CORSResolver func(c *gin.Context, method string) *CORSConfig
+
 // CORSMiddleware creates a CORS middleware with the given config
 func CORSMiddleware(config *CORSConfig) gin.HandlerFunc {
+	return RouteCORSMiddleware(config, nil)
+}
+
+// RouteCORSMiddleware creates a CORS middleware applying config, or the
+// policy resolve returns for the request's route. Preflight requests are
+// answered with 204 and go no further, so they reach neither authorization
+// nor usage tracking.
+func RouteCORSMiddleware(config *CORSConfig, resolve CORSResolver) gin.HandlerFunc {
 	if config == nil {
 		config = DefaultCORSConfig()
 	}
 
 	return func(c *gin.Context) {
-		origin := c.Request.Header.Get("Origin")
-
-		// Check if origin is allowed
-		if isOriginAllowed(origin, config.AllowedOrigins) {
-			c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
-		} else if len(config.AllowedOrigins) == 1 && config.AllowedOrigins[0] == "*" {
-			c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		}
-
-		// Set other CORS headers
-		if config.AllowCredentials {
-			c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
+		preflight := IsPreflightRequest(c.Request)
+		policy := config
+		if resolve != nil {
+			method := c.Request.Method
+			if preflight {
+				method = c.Request.Header.Get("Access-Control-Request-Method")
+			}
+			if routePolicy := resolve(c, method); routePolicy != nil {
+				policy = routePolicy
+			}
 		}
 
-		c.Writer.Header().Set("Access-Control-Allow-Headers", strings.Join(config.AllowedHeaders, ", "))
-		c.Writer.Header().Set("Access-Control-Allow-Methods", strings.Join(config.AllowedMethods, ", "))
-
-		if len(config.ExposedHeaders) > 0 {
-			c.Writer.Header().Set("Access-Control-Expose-Headers", strings.Join(config.ExposedHeaders, ", "))
+		origin := c.Request.Header.Get("Origin")
+		header := c.Writer.Header()
+		header.Add("Vary", "Origin")
+
+		// Requests from other origins get no CORS headers, so browsers block them
+		if origin != "" && isOriginAllowed(origin, policy.AllowedOrigins) {
+			if slices.Contains(policy.AllowedOrigins, "*") && !policy.AllowCredentials {
+				header.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				header.Set("Access-Control-Allow-Origin", origin)
+			}
+			if policy.AllowCredentials {
+				header.Set("Access-Control-Allow-Credentials", "true")
+			}
+			if len(policy.ExposedHeaders) > 0 {
+				header.Set("Access-Control-Expose-Headers", strings.Join(policy.ExposedHeaders, ", "))
+			}
+			if preflight {
+				header.Set("Access-Control-Allow-Headers", strings.Join(policy.AllowedHeaders, ", "))
+				header.Set("Access-Control-Allow-Methods", strings.Join(policy.AllowedMethods, ", "))
+				if policy.MaxAge > 0 {
+					header.Set("Access-Control-Max-Age", strconv.Itoa(policy.MaxAge))
+				}
+			}
 		}
 
-		// Handle preflight requests
-		if c.Request.Method == "OPTIONS" {
-			if config.MaxAge > 0 {
-				c.Writer.Header().Set("Access-Control-Max-Age", string(rune(config.MaxAge)))
-			}
-			c.AbortWithStatus(204)
+		if preflight {
+			c.AbortWithStatus(http.StatusNoContent)
 			return
 		}
 
@@ -94,6 +124,12 @@ func CORSMiddleware(config *CORSConfig) gin.HandlerFunc {
 	}
 }
 
+// IsPreflightRequest reports whether r is a CORS preflight request, which
+// browsers send without credentials before cross-origin requests
+func IsPreflightRequest(r *http.Request) bool {
+	return r.Method == http.MethodOptions && r.Header.Get("Origin") != ""
+}
+
 // isOriginAllowed checks if an origin is in the allowed list
 func isOriginAllowed(origin string, allowedOrigins []string) bool {
 	if len(allowedOrigins) == 0 {
@@ -116,3 +152,22 @@ func SecureCORSMiddleware(allowedOrigins []string) gin.HandlerFunc {
 	config.AllowedOrigins = allowedOrigins
 	return CORSMiddleware(config)
 }
+
+// NewCORSConfig returns the CORS policy described by cfg, with the default
+// methods and headers where cfg has none
+func NewCORSConfig(cfg config.CORSConfig) *CORSConfig {
+	policy := DefaultCORSConfig()
+	policy.AllowedOrigins = cfg.AllowedOrigins
+	policy.AllowCredentials = cfg.AllowCredentials
+	policy.MaxAge = int(cfg.MaxAge.Seconds())
+	if len(cfg.AllowedMethods) > 0 {
+		policy.AllowedMethods = cfg.AllowedMethods
+	}
+	if len(cfg.AllowedHeaders) > 0 {
+		policy.AllowedHeaders = cfg.AllowedHeaders
+	}
+	if len(cfg.ExposedHeaders) > 0 {
+		policy.ExposedHeaders = cfg.ExposedHeaders
+	}
+	return policy
+}
@@ -1,13 +1,14 @@
 package middleware
 
 import (
-	"fmt"
+	"errors"
 	"log"
 	"os"
 
 	"strings"
 
 	"github.com/aruncs31s/azf/constants"
+	"github.com/aruncs31s/azf/infrastructure/token"
 	"github.com/aruncs31s/azf/shared/logger"
 	"go.uber.org/zap"
 
@@ -65,11 +66,17 @@ func GetUserID(c *gin.Context) string {
 	return ""
 }
 
+// GetAPIKeyID returns the ID of the API key the request authenticated
+// with, or "" for other requests
+func GetAPIKeyID(c *gin.Context) string {
+	return c.GetString("api_key_id")
+}
+
 func SetCors() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-API-Key, accept, origin, Cache-Control, X-Requested-With")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT")
 
 		if c.Request.Method == "OPTIONS" {
@@ -84,44 +91,66 @@ func JwtMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		authHeader := c.GetHeader("Authorization")
+
+		// Without a bearer token, an API key authenticates the request
+		if key := c.GetHeader(APIKeyHeader); authHeader == "" && key != "" && apiKeyAuthenticator != nil {
+			if authenticateAPIKey(c, key) {
+				c.Next()
+			}
+			return
+		}
+
 		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
 			responseHelper.Unauthorized(c, utils.ErrNoAuthHeader.Error())
 			c.Abort()
 			return
 		}
 
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-
-		// Parse the token
-		token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
-			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method")
-			}
-			return GetSecretKey(), nil
-		})
-
-		if err != nil || !token.Valid {
-			responseHelper.Unauthorized(c, utils.ErrUnauthorized.Error())
+		claims, err := parseBearerToken(strings.TrimPrefix(authHeader, "Bearer "))
+		if err != nil {
+			responseHelper.Unauthorized(c, invalidTokenChallenge(c, err).Error())
 			c.Abort()
 			return
 		}
 
-		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			c.Set("jwt_claims", claims)
+		c.Set("jwt_claims", claims)
 
-			if claims["user_id"] != nil {
-				c.Set("user_id", claims["user_id"])
-			}
+		if claims["user_id"] != nil {
+			c.Set("user_id", claims["user_id"])
+		}
 
-			// Extract role from claims for Casbin authorization
-			if role, exists := claims["role"]; exists {
-				c.Set("user_role", role)
-			} else {
-				// Default to staff role if not specified
-				c.Set("user_role", "user")
-			}
+		// Extract role from claims for Casbin authorization
+		if role, exists := claims["role"]; exists {
+			c.Set("user_role", role)
+		} else {
+			// Default to staff role if not specified
+			c.Set("user_role", "user")
 		}
 
 		c.Next()
 	}
 }
+
+// parseBearerToken validates a JWT with the configured token provider and
+// returns its claims
+func parseBearerToken(tokenString string) (jwt.MapClaims, error) {
+	provider, err := token.Default()
+	if err != nil {
+		logger.GetLogger().Error("JWT verification not configured", zap.Error(err))
+		return nil, err
+	}
+	return provider.Verify(tokenString)
+}
+
+// invalidTokenChallenge sets the WWW-Authenticate challenge for a rejected
+// bearer token and returns the error to report. Expired tokens are reported
+// as such, so clients know to get a new one, e.g. from the admin token
+// refresh endpoint, instead of signing in again.
+func invalidTokenChallenge(c *gin.Context, err error) error {
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		c.Header("WWW-Authenticate", `Bearer error="invalid_token", error_description="token expired"`)
+		return utils.ErrTokenExpired
+	}
+	c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+	return utils.ErrUnauthorized
+}
@@ -124,3 +124,88 @@ func TestGetRequestLogger(t *testing.T) {
 		t.Errorf("Expected status 200, got %d", w.Code)
 	}
 }
+
+func TestAdminRateLimitMiddleware(t *testing.T) {
+	router := gin.New()
+	router.Use(AdminRateLimitMiddleware(
+		NewAdminRateLimiter(0, 2),
+		NewAdminRateLimiter(0, 1),
+		func(c *gin.Context) string { return c.GetHeader("X-Admin") },
+	))
+	router.GET("/admin-ui/api/stats", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	router.POST("/admin-ui/login/json", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	router.GET("/api/users", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+
+	send := func(method, path, admin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if admin != "" {
+			req.Header.Set("X-Admin", admin)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := send(http.MethodGet, "/admin-ui/api/stats", "alice"); w.Code != http.StatusOK {
+			t.Fatalf("Expected request %d within burst to pass, got %d", i+1, w.Code)
+		}
+	}
+	w := send(http.MethodGet, "/admin-ui/api/stats", "alice")
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected status 429 once the burst is used, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("Expected Retry-After header on a limited admin request")
+	}
+
+	if w := send(http.MethodGet, "/admin-ui/api/stats", "bob"); w.Code != http.StatusOK {
+		t.Errorf("Expected another admin to have a separate limit, got %d", w.Code)
+	}
+	if w := send(http.MethodGet, "/api/users", "alice"); w.Code != http.StatusOK {
+		t.Errorf("Expected application routes to be unaffected, got %d", w.Code)
+	}
+
+	if w := send(http.MethodPost, "/admin-ui/login/json", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected first login attempt to pass, got %d", w.Code)
+	}
+	if w := send(http.MethodPost, "/admin-ui/login/json", ""); w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected repeated login attempts to be limited, got %d", w.Code)
+	}
+}
+
+func TestAdminReadOnlyMiddleware(t *testing.T) {
+	blocked := 0
+	router := gin.New()
+	router.Use(AdminReadOnlyMiddleware(
+		func() bool { return true },
+		[]string{"/admin-ui/api/read-only"},
+		func(c *gin.Context) { blocked++ },
+	))
+	router.GET("/admin-ui/api/stats", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	router.POST("/admin-ui/api/routes", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	router.PUT("/admin-ui/api/read-only", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	router.POST("/api/users", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+
+	send := func(method, path string) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w.Code
+	}
+
+	if code := send(http.MethodPost, "/admin-ui/api/routes"); code != http.StatusLocked {
+		t.Errorf("Expected admin write to be rejected with 423, got %d", code)
+	}
+	if blocked != 1 {
+		t.Errorf("Expected blocked write to be reported once, got %d", blocked)
+	}
+	if code := send(http.MethodGet, "/admin-ui/api/stats"); code != http.StatusOK {
+		t.Errorf("Expected dashboards to stay available, got %d", code)
+	}
+	if code := send(http.MethodPut, "/admin-ui/api/read-only"); code != http.StatusOK {
+		t.Errorf("Expected exempt path to stay writable, got %d", code)
+	}
+	if code := send(http.MethodPost, "/api/users"); code != http.StatusOK {
+		t.Errorf("Expected application routes to be unaffected, got %d", code)
+	}
+}
@@ -37,8 +37,16 @@ func (i *IPRateLimiter) GetLimiter(ip string) *rate.Limiter {
 	return limiter
 }
 
+// RateLimitMiddleware limits application traffic per client IP. Admin UI and
+// admin API requests are skipped; they have their own limiter so dashboards
+// do not use up the budget of the application.
 func RateLimitMiddleware(limiter *IPRateLimiter) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if isAdminPath(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+
 		ip := c.ClientIP()
 		l := limiter.GetLimiter(ip)
 
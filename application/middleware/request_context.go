@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/aruncs31s/azf/shared/logger"
@@ -9,19 +11,88 @@ import (
 	"go.uber.org/zap"
 )
 
-// RequestIDMiddleware adds a unique request ID to each request
-func RequestIDMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// Check if request ID is already present in header
-		requestID := c.GetHeader("X-Request-ID")
-		if requestID == "" {
-			requestID = uuid.New().String()
+// maxRequestIDLength bounds inbound request IDs; it matches the request_id
+// column of the audit and usage logs
+const maxRequestIDLength = 64
+
+// ResolveRequestID returns the ID of an inbound request: its X-Request-ID
+// header when valid, else the trace ID of its W3C traceparent header, so
+// logs can be correlated with an upstream gateway; otherwise a new UUID
+func ResolveRequestID(r *http.Request) string {
+	if requestID := strings.TrimSpace(r.Header.Get("X-Request-ID")); validRequestID(requestID) {
+		return requestID
+	}
+	if traceID := traceparentTraceID(r.Header.Get("traceparent")); traceID != "" {
+		return traceID
+	}
+	return uuid.New().String()
+}
+
+// validRequestID reports whether an inbound ID is short enough to store and
+// safe to log and echo
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
 		}
+	}
+	return true
+}
 
-		// Set request ID in context and response header
-		c.Set("request_id", requestID)
-		c.Writer.Header().Set("X-Request-ID", requestID)
+// traceparentTraceID returns the trace ID of a version-00 traceparent
+// header (00-<trace-id>-<parent-id>-<flags>), or "" when it is malformed
+func traceparentTraceID(header string) string {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) != 4 || parts[0] != "00" || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return ""
+	}
+	for _, part := range parts[1:] {
+		if !isLowerHex(part) {
+			return ""
+		}
+	}
+	// An all-zero trace ID is invalid
+	if strings.Trim(parts[1], "0") == "" {
+		return ""
+	}
+	return parts[1]
+}
+
+func isLowerHex(s string) bool {
+	for _, r := range s {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
 
+// ensureRequestID assigns the request its ID unless an earlier middleware
+// did: it is stored in the gin context and the request context, where
+// logger.FromContext picks it up, and echoed in the X-Request-ID header
+func ensureRequestID(c *gin.Context) string {
+	if requestID := GetRequestID(c); requestID != "" {
+		return requestID
+	}
+	requestID := ResolveRequestID(c.Request)
+	c.Set("request_id", requestID)
+	c.Request = c.Request.WithContext(logger.ContextWithRequestID(c.Request.Context(), requestID))
+	c.Writer.Header().Set("X-Request-ID", requestID)
+	return requestID
+}
+
+// RequestIDMiddleware assigns each request an ID, reusing the one sent by the
+// client or an upstream gateway (see ResolveRequestID), and echoes it in the
+// X-Request-ID response header
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ensureRequestID(c)
 		c.Next()
 	}
 }
@@ -31,13 +102,8 @@ func StructuredLoggingMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
 
-		// Get or generate request ID
-		requestID, _ := c.Get("request_id")
-		requestIDStr, _ := requestID.(string)
-		if requestIDStr == "" {
-			requestIDStr = uuid.New().String()
-			c.Set("request_id", requestIDStr)
-		}
+		// Get or assign request ID
+		requestIDStr := ensureRequestID(c)
 
 		// Get user ID if available
 		userID := ""
@@ -56,8 +122,10 @@ func StructuredLoggingMiddleware() gin.HandlerFunc {
 			c.ClientIP(),
 		)
 
-		// Store logger in context
+		// Store logger in context, and in the request context for services
+		// logging with logger.FromContext
 		c.Set("logger", reqLogger)
+		c.Request = c.Request.WithContext(logger.ContextWithLogger(c.Request.Context(), reqLogger))
 
 		// Log request start
 		reqLogger.Debug("request started",
@@ -105,7 +173,7 @@ func GetRequestLogger(c *gin.Context) *zap.Logger {
 			return l
 		}
 	}
-	return logger.GetLogger()
+	return logger.FromContext(c.Request.Context())
 }
 
 // GetRequestID retrieves the request ID from context
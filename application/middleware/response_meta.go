@@ -0,0 +1,203 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"os"
+	"time"
+
+	"github.com/aruncs31s/azf/application/dto"
+	"github.com/gin-gonic/gin"
+)
+
+// Context keys of the values reported in response meta
+const (
+	authModeContextKey     = "authorization_mode"
+	apiVersionContextKey   = "api_version"
+	requestStartContextKey = "request_start"
+	skipResponseMetaKey    = "skip_response_meta"
+)
+
+// SetAuthorizationMode records how the request was authorized, for its response meta
+func SetAuthorizationMode(c *gin.Context, mode string) {
+	c.Set(authModeContextKey, mode)
+}
+
+// SetAPIVersion records the API version of the route serving the request
+func SetAPIVersion(c *gin.Context, version string) {
+	if version != "" {
+		c.Set(apiVersionContextKey, version)
+	}
+}
+
+// BuildResponseMeta returns the meta of the request's response. The API
+// version falls back to API_VERSION for routes without metadata, and the
+// response time is measured from ResponseMetaMiddleware.
+func BuildResponseMeta(c *gin.Context) dto.ResponseMeta {
+	meta := dto.ResponseMeta{
+		APIVersion:        c.GetString(apiVersionContextKey),
+		AuthorizationMode: c.GetString(authModeContextKey),
+		RequestID:         GetRequestID(c),
+	}
+	if meta.APIVersion == "" {
+		meta.APIVersion = os.Getenv("API_VERSION")
+	}
+	if start := c.GetTime(requestStartContextKey); !start.IsZero() {
+		meta.ResponseTimeMs = float64(time.Since(start).Microseconds()) / 1000
+	}
+	return meta
+}
+
+// WithoutResponseMeta opts a route out of ResponseMetaMiddleware, e.g. when
+// its response must match an external schema
+func WithoutResponseMeta() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(skipResponseMetaKey, true)
+		c.Next()
+	}
+}
+
+// ResponseMetaMiddleware adds "meta" with the request ID, API version,
+// authorization mode and response time to every JSON object response. A
+// "meta" object the handler already wrote keeps its fields and gets the
+// missing ones. Other responses, and routes using WithoutResponseMeta, are
+// passed through unchanged.
+func ResponseMetaMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(requestStartContextKey, time.Now())
+		ensureRequestID(c)
+
+		writer := &metaResponseWriter{ResponseWriter: c.Writer, c: c}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+		writer.finish()
+	}
+}
+
+// metaResponseWriter holds back JSON bodies until the handler is done, so
+// meta can be added
+type metaResponseWriter struct {
+	gin.ResponseWriter
+	c         *gin.Context
+	decided   bool
+	buffering bool
+	body      bytes.Buffer
+}
+
+// buffer reports whether the body being written is held back; it decides
+// on the first write, once the handler has set the content type
+func (w *metaResponseWriter) buffer() bool {
+	if !w.decided {
+		w.decided = true
+		mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+		w.buffering = mediaType == "application/json" && !w.c.GetBool(skipResponseMetaKey)
+	}
+	return w.buffering
+}
+
+func (w *metaResponseWriter) Write(data []byte) (int, error) {
+	if w.buffer() {
+		return w.body.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *metaResponseWriter) WriteString(s string) (int, error) {
+	if w.buffer() {
+		return w.body.WriteString(s)
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *metaResponseWriter) Written() bool {
+	return w.body.Len() > 0 || w.ResponseWriter.Written()
+}
+
+func (w *metaResponseWriter) Size() int {
+	if w.buffering {
+		return w.body.Len()
+	}
+	return w.ResponseWriter.Size()
+}
+
+// Flush sends what was held back without meta: a handler streaming JSON
+// cannot have it added
+func (w *metaResponseWriter) Flush() {
+	if w.buffering {
+		w.buffering = false
+		w.ResponseWriter.Write(w.body.Bytes())
+		w.body.Reset()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// finish writes the held back body with meta added
+func (w *metaResponseWriter) finish() {
+	if !w.buffering {
+		return
+	}
+	body := w.body.Bytes()
+	if withMeta, ok := addResponseMeta(body, BuildResponseMeta(w.c)); ok {
+		body = withMeta
+		w.Header().Del("Content-Length")
+	}
+	w.ResponseWriter.Write(body)
+}
+
+// addResponseMeta returns the JSON object body with meta added, or false
+// when body is not a JSON object
+func addResponseMeta(body []byte, meta dto.ResponseMeta) ([]byte, bool) {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) < 2 || trimmed[0] != '{' {
+		return nil, false
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(trimmed, &fields); err != nil {
+		return nil, false
+	}
+	metaJSON, err := json.Marshal(meta)
+	if err != nil {
+		return nil, false
+	}
+
+	existing, exists := fields["meta"]
+	if !exists {
+		// Append, keeping the body as the handler wrote it
+		out := make([]byte, 0, len(trimmed)+len(metaJSON)+10)
+		out = append(out, trimmed[:len(trimmed)-1]...)
+		if len(fields) > 0 {
+			out = append(out, ',')
+		}
+		out = append(out, `"meta":`...)
+		out = append(out, metaJSON...)
+		out = append(out, '}')
+		return out, true
+	}
+
+	merged := make(map[string]json.RawMessage)
+	if string(existing) != "null" {
+		if err := json.Unmarshal(existing, &merged); err != nil {
+			// A meta value that is not an object is left alone
+			return nil, false
+		}
+	}
+	var defaults map[string]json.RawMessage
+	if err := json.Unmarshal(metaJSON, &defaults); err != nil {
+		return nil, false
+	}
+	for key, value := range defaults {
+		if _, set := merged[key]; !set {
+			merged[key] = value
+		}
+	}
+	if fields["meta"], err = json.Marshal(merged); err != nil {
+		return nil, false
+	}
+	out, err := json.Marshal(fields)
+	if err != nil {
+		return nil, false
+	}
+	return out, true
+}
@@ -1,7 +1,6 @@
 package service
 
 import (
-	"errors"
 	"fmt"
 
 	"time"
@@ -11,6 +10,13 @@ import (
 	"github.com/aruncs31s/azf/utils"
 )
 
+// AdminAuthenticator checks the username and password of an admin signing
+// in. Rejected credentials are an unsuccessful response; an error means they
+// could not be checked, with a response explaining why when there is one.
+type AdminAuthenticator interface {
+	Login(request *dto.LoginRequest) (*dto.AdminLoginResponse, error)
+}
+
 // TODO: Make it DDD Complaint
 // AdminAuthenticationService handles admin authentication operations
 type AdminAuthenticationService struct {
@@ -72,25 +78,3 @@ func (s *AdminAuthenticationService) Login(request *dto.LoginRequest) (*dto.Admi
 func (s *AdminAuthenticationService) generateSessionID() string {
 	return fmt.Sprintf("admin_session_%d", time.Now().UnixNano())
 }
-
-// ValidateSession validates an admin session
-// In a production system, this would validate against a session store
-func (s *AdminAuthenticationService) ValidateSession(sessionID string) (bool, error) {
-	if sessionID == "" {
-		return false, errors.New("session ID cannot be empty")
-	}
-
-	// TODO: Implement session validation with session storage
-	// For now, return true as sessions are handled by the handler
-	return true, nil
-}
-
-// Logout handles admin logout by invalidating the session
-func (s *AdminAuthenticationService) Logout(sessionID string) error {
-	if sessionID == "" {
-		return errors.New("session ID cannot be empty")
-	}
-
-	// TODO: Implement session cleanup and invalidation
-	return nil
-}
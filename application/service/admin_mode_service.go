@@ -0,0 +1,141 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aruncs31s/azf/shared/logger"
+	"go.uber.org/zap"
+)
+
+// ErrNotSuperadmin is returned when an admin without superadmin rights tries
+// to switch read-only mode
+var ErrNotSuperadmin = errors.New("only a superadmin can change read-only mode")
+
+// AdminModeService controls the read-only mode of the admin surface. While
+// read-only, mutating admin endpoints are rejected but dashboards stay available.
+type AdminModeService interface {
+	IsReadOnly() bool
+	GetReadOnlyStatus() ReadOnlyStatusDTO
+	SetReadOnly(adminUsername string, req SetReadOnlyRequest) (*ReadOnlyStatusDTO, error)
+	IsSuperadmin(adminUsername string) bool
+	// RecordBlockedWrite audits a write attempted while read-only mode is on
+	RecordBlockedWrite(attempt BlockedWriteAttempt)
+}
+
+// adminModeService implements AdminModeService
+type adminModeService struct {
+	superadmins map[string]bool
+	audit       func(attempt BlockedWriteAttempt)
+
+	mu     sync.RWMutex
+	status ReadOnlyStatusDTO
+}
+
+// NewAdminModeService creates a new admin mode service. readOnly is the mode
+// at startup; audit records blocked writes and may be nil.
+func NewAdminModeService(readOnly bool, superadmins []string, audit func(attempt BlockedWriteAttempt)) AdminModeService {
+	allowed := make(map[string]bool, len(superadmins))
+	for _, username := range superadmins {
+		allowed[username] = true
+	}
+
+	status := ReadOnlyStatusDTO{ReadOnly: readOnly}
+	if readOnly {
+		now := time.Now()
+		status.Reason = "Enabled by configuration"
+		status.ChangedBy = "config"
+		status.ChangedAt = &now
+	}
+
+	return &adminModeService{
+		superadmins: allowed,
+		audit:       audit,
+		status:      status,
+	}
+}
+
+func (s *adminModeService) IsReadOnly() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.status.ReadOnly
+}
+
+func (s *adminModeService) GetReadOnlyStatus() ReadOnlyStatusDTO {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.status
+}
+
+// SetReadOnly switches read-only mode on or off; only superadmins may do so
+func (s *adminModeService) SetReadOnly(adminUsername string, req SetReadOnlyRequest) (*ReadOnlyStatusDTO, error) {
+	if !s.IsSuperadmin(adminUsername) {
+		logger.Warn("Read-only mode change rejected",
+			zap.String("admin", adminUsername),
+			zap.Bool("read_only", req.ReadOnly))
+		return nil, ErrNotSuperadmin
+	}
+
+	reason := strings.TrimSpace(req.Reason)
+	if len(reason) > 500 {
+		reason = reason[:500]
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	s.status = ReadOnlyStatusDTO{
+		ReadOnly:  req.ReadOnly,
+		Reason:    reason,
+		ChangedBy: adminUsername,
+		ChangedAt: &now,
+	}
+	status := s.status
+	s.mu.Unlock()
+
+	logger.Info("Admin read-only mode changed",
+		zap.Bool("read_only", status.ReadOnly),
+		zap.String("admin", adminUsername),
+		zap.String("reason", reason))
+	return &status, nil
+}
+
+func (s *adminModeService) IsSuperadmin(adminUsername string) bool {
+	return adminUsername != "" && s.superadmins[adminUsername]
+}
+
+func (s *adminModeService) RecordBlockedWrite(attempt BlockedWriteAttempt) {
+	logger.Warn("Admin write blocked by read-only mode",
+		zap.String("admin", attempt.AdminUsername),
+		zap.String("method", attempt.Method),
+		zap.String("path", attempt.Path),
+		zap.String("ip", attempt.IPAddress))
+	if s.audit != nil {
+		s.audit(attempt)
+	}
+}
+
+// ReadOnlyStatusDTO describes the current read-only mode of the admin surface
+type ReadOnlyStatusDTO struct {
+	ReadOnly  bool       `json:"read_only"`
+	Reason    string     `json:"reason,omitempty"`
+	ChangedBy string     `json:"changed_by,omitempty"`
+	ChangedAt *time.Time `json:"changed_at,omitempty"`
+}
+
+// SetReadOnlyRequest switches read-only mode
+type SetReadOnlyRequest struct {
+	ReadOnly bool   `json:"read_only"`
+	Reason   string `json:"reason"`
+}
+
+// BlockedWriteAttempt is an admin write rejected because of read-only mode
+type BlockedWriteAttempt struct {
+	RequestID     string
+	AdminUsername string
+	Method        string
+	Path          string
+	IPAddress     string
+	UserAgent     string
+}
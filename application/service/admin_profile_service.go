@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"sort"
 	"time"
@@ -8,21 +9,33 @@ import (
 	"github.com/aruncs31s/azf/config"
 	"github.com/aruncs31s/azf/domain/model"
 	"github.com/aruncs31s/azf/initializer"
+	apperrors "github.com/aruncs31s/azf/shared/errors"
 )
 
 // AdminProfileService handles admin profile operations
 // Following DDD application service pattern
 type AdminProfileService struct {
 	configProvider *config.AdminConfigProvider
+	unitOfWork     UnitOfWork
+	approvals      ApprovalService
+	roleSync       RoleSyncService
 }
 
-// customDescriptions stores role descriptions that persist across requests
-var customDescriptions = make(map[string]string)
-
-// NewAdminProfileService creates a new AdminProfileService
-func NewAdminProfileService(configProvider *config.AdminConfigProvider) *AdminProfileService {
+// NewAdminProfileService creates a new AdminProfileService. Multi-step role
+// changes run in unitOfWork, or in a policy-only one when it is nil.
+// Changes that would lock the admin out can be overridden through approvals;
+// when it is nil they are always refused. Role assignments, renames and
+// deletions are mirrored on the user records through roleSync; when it is
+// nil only the grouping policies change.
+func NewAdminProfileService(configProvider *config.AdminConfigProvider, unitOfWork UnitOfWork, approvals ApprovalService, roleSync RoleSyncService) *AdminProfileService {
+	if unitOfWork == nil {
+		unitOfWork = NewUnitOfWork(nil)
+	}
 	return &AdminProfileService{
 		configProvider: configProvider,
+		unitOfWork:     unitOfWork,
+		approvals:      approvals,
+		roleSync:       roleSync,
 	}
 }
 
@@ -133,22 +146,27 @@ func (s *AdminProfileService) GetAllRolesFromCasbin() ([]string, error) {
 
 	roleSet := make(map[string]bool)
 
-	// Get all policies and extract roles (first column after 'p')
-	policies, err := enforcer.GetPolicy()
+	var policies, groupingPolicies [][]string
+	err := initializer.ReadPolicies(enforcer, func() error {
+		var err error
+		if policies, err = enforcer.GetPolicy(); err != nil {
+			return err
+		}
+		groupingPolicies, err = enforcer.GetGroupingPolicy()
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
+
+	// Extract roles from policies (first column after 'p')
 	for _, policy := range policies {
 		if len(policy) > 0 {
 			roleSet[policy[0]] = true
 		}
 	}
 
-	// Get all grouping policies and extract roles (second column after 'g')
-	groupingPolicies, err := enforcer.GetGroupingPolicy()
-	if err != nil {
-		return nil, err
-	}
+	// Extract roles from grouping policies (second column after 'g')
 	for _, groupPolicy := range groupingPolicies {
 		if len(groupPolicy) > 1 {
 			roleSet[groupPolicy[1]] = true
@@ -156,7 +174,7 @@ func (s *AdminProfileService) GetAllRolesFromCasbin() ([]string, error) {
 	}
 
 	// Include roles that have custom descriptions but haven't been used in policies yet
-	for role := range customDescriptions {
+	for role := range customDescriptions.All() {
 		roleSet[role] = true
 	}
 
@@ -189,20 +207,29 @@ func (s *AdminProfileService) GetRoleDescriptions() map[string]string {
 	}
 
 	// Override with custom descriptions
-	for role, desc := range customDescriptions {
+	for role, desc := range customDescriptions.All() {
 		descriptions[role] = desc
 	}
 
 	return descriptions
 }
 
-// CreateRole creates a new role with the given name and description
+// CreateRole creates a new role with the given name and description. The
+// name is normalized to lowercase and must not be a reserved role.
 func (s *AdminProfileService) CreateRole(name string, description string) error {
 	enforcer := initializer.CasbinEnforcer
 	if enforcer == nil {
 		return fmt.Errorf("casbin enforcer not available")
 	}
 
+	name, err := NormalizeRoleName(name)
+	if err != nil {
+		return err
+	}
+	if IsReservedRole(name) {
+		return apperrors.Newf(apperrors.ErrConflict, "role '%s' is reserved", name)
+	}
+
 	// Check if role already exists
 	roles, err := s.GetAllRolesFromCasbin()
 	if err != nil {
@@ -211,12 +238,14 @@ func (s *AdminProfileService) CreateRole(name string, description string) error
 
 	for _, existingRole := range roles {
 		if existingRole == name {
-			return fmt.Errorf("role '%s' already exists", name)
+			return apperrors.Newf(apperrors.ErrConflict, "role '%s' already exists", name)
 		}
 	}
 
 	// Store the description
-	customDescriptions[name] = description
+	if err := customDescriptions.Set(name, description); err != nil {
+		return err
+	}
 
 	// Note: In Casbin, roles are typically created implicitly when used in policies
 	// For now, we just store the description. Actual role creation happens when policies are added.
@@ -224,112 +253,159 @@ func (s *AdminProfileService) CreateRole(name string, description string) error
 	return nil
 }
 
-// UpdateRole updates an existing role's name and/or description
-func (s *AdminProfileService) UpdateRole(oldName string, newName string, description string) error {
+// UpdateRole updates an existing role's name and/or description. A rename
+// moves every policy and assignment of the role; if any step fails, none of
+// them are kept. Reserved roles cannot be renamed, nor can roles the session
+// depends on without an approved override.
+func (s *AdminProfileService) UpdateRole(oldName string, newName string, description string, session RoleSession) error {
 	enforcer := initializer.CasbinEnforcer
 	if enforcer == nil {
 		return fmt.Errorf("casbin enforcer not available")
 	}
 
+	// Names of existing roles are kept as they are when only the description changes
+	if newName != "" && newName != oldName {
+		normalized, err := NormalizeRoleName(newName)
+		if err != nil {
+			return err
+		}
+		newName = normalized
+	}
+
 	// If name is changing, we need to update all references
 	if oldName != newName && newName != "" {
-		// Update all policies that reference the old role name
-		policies, err := enforcer.GetPolicy()
+		if err := checkNotReserved(oldName, "renamed"); err != nil {
+			return err
+		}
+		if IsReservedRole(newName) {
+			return apperrors.Newf(apperrors.ErrConflict, "role '%s' is reserved", newName)
+		}
+		roles, err := s.GetAllRolesFromCasbin()
 		if err != nil {
-			return fmt.Errorf("failed to get policies: %w", err)
+			return err
 		}
-
-		for _, policy := range policies {
-			if len(policy) > 0 && policy[0] == oldName {
-				// Remove old policy
-				_, err := enforcer.RemovePolicy(policy)
-				if err != nil {
-					return fmt.Errorf("failed to remove old policy: %w", err)
-				}
-				// Add new policy with updated role name
-				policy[0] = newName
-				_, err = enforcer.AddPolicy(policy)
-				if err != nil {
-					return fmt.Errorf("failed to add updated policy: %w", err)
-				}
+		for _, existingRole := range roles {
+			if existingRole == newName {
+				return apperrors.Newf(apperrors.ErrConflict, "role '%s' already exists", newName)
 			}
 		}
 
-		// Update all grouping policies that reference the old role name
-		groupingPolicies, err := enforcer.GetGroupingPolicy()
-		if err != nil {
-			return fmt.Errorf("failed to get grouping policies: %w", err)
-		}
+		err = s.applySessionChange(session, sessionChange{
+			action:    ApprovalActionRenameRole,
+			target:    fmt.Sprintf("%s to %s", oldName, newName),
+			dependsOn: func(roles map[string]bool) bool { return roles[oldName] },
+			apply: func(ctx context.Context, policies *initializer.PolicyTransaction) error {
+				// Reads go through the transaction so no other change interleaves
+				enforcer := policies.Enforcer()
 
-		for _, gPolicy := range groupingPolicies {
-			if len(gPolicy) > 1 && gPolicy[1] == oldName {
-				// Remove old grouping policy
-				_, err := enforcer.RemoveGroupingPolicy(gPolicy)
+				// Update all policies that reference the old role name
+				rules, err := enforcer.GetFilteredPolicy(0, oldName)
 				if err != nil {
-					return fmt.Errorf("failed to remove old grouping policy: %w", err)
+					return fmt.Errorf("failed to get policies: %w", err)
+				}
+				for _, rule := range rules {
+					if _, err := policies.RemovePolicy(rule); err != nil {
+						return fmt.Errorf("failed to remove old policy: %w", err)
+					}
+					renamed := append([]string{newName}, rule[1:]...)
+					if _, err := policies.AddPolicy(renamed); err != nil {
+						return fmt.Errorf("failed to add updated policy: %w", err)
+					}
 				}
-				// Add new grouping policy with updated role name
-				gPolicy[1] = newName
-				_, err = enforcer.AddGroupingPolicy(gPolicy)
+
+				// Update all grouping policies that reference the old role name
+				groupingRules, err := enforcer.GetFilteredGroupingPolicy(1, oldName)
 				if err != nil {
-					return fmt.Errorf("failed to add updated grouping policy: %w", err)
+					return fmt.Errorf("failed to get grouping policies: %w", err)
 				}
-			}
+				for _, rule := range groupingRules {
+					if _, err := policies.RemoveGroupingPolicy(rule); err != nil {
+						return fmt.Errorf("failed to remove old grouping policy: %w", err)
+					}
+					renamed := append([]string{rule[0], newName}, rule[2:]...)
+					if _, err := policies.AddGroupingPolicy(renamed); err != nil {
+						return fmt.Errorf("failed to add updated grouping policy: %w", err)
+					}
+				}
+				if s.roleSync != nil {
+					return s.roleSync.RenameRole(ctx, oldName, newName)
+				}
+				return nil
+			},
+		})
+		if err != nil {
+			return err
 		}
 
-		// Update custom description for the new name
-		if description != "" {
-			customDescriptions[newName] = description
-		}
-		delete(customDescriptions, oldName)
-	} else {
-		// Only updating description
-		if description != "" {
-			customDescriptions[oldName] = description
-		}
+		// Move the custom description to the new name
+		return customDescriptions.Rename(oldName, newName, description)
 	}
 
+	// Only updating description
+	if description != "" {
+		return customDescriptions.Set(oldName, description)
+	}
 	return nil
 }
 
-// AssignRoleToUser assigns a role to a user in Casbin
+// AssignRoleToUser assigns a role to a user in Casbin and on their user record
 func (s *AdminProfileService) AssignRoleToUser(userID string, role string) error {
-	enforcer := initializer.CasbinEnforcer
-	if enforcer == nil {
+	if initializer.CasbinEnforcer == nil {
 		return fmt.Errorf("casbin enforcer not available")
 	}
 
-	// Add grouping policy: user -> role
-	added, err := enforcer.AddGroupingPolicy(userID, role)
+	var added bool
+	err := s.unitOfWork.Do(context.Background(), func(ctx context.Context, policies *initializer.PolicyTransaction) error {
+		var err error
+		if s.roleSync != nil {
+			added, err = s.roleSync.AssignRole(ctx, policies, userID, role)
+		} else {
+			// Add grouping policy: user -> role
+			added, err = policies.AddGroupingPolicy([]string{userID, role})
+		}
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to assign role: %w", err)
 	}
 
 	if !added {
-		return fmt.Errorf("role assignment already exists")
+		return apperrors.Newf(apperrors.ErrConflict, "role assignment already exists")
 	}
 
 	return nil
 }
 
-// RemoveRoleFromUser removes a role from a user in Casbin
-func (s *AdminProfileService) RemoveRoleFromUser(userID string, role string) error {
-	enforcer := initializer.CasbinEnforcer
-	if enforcer == nil {
+// RemoveRoleFromUser removes a role from a user in Casbin and on their user
+// record. An assignment the session depends on is only removed with an
+// approved override.
+func (s *AdminProfileService) RemoveRoleFromUser(userID string, role string, session RoleSession) error {
+	if initializer.CasbinEnforcer == nil {
 		return fmt.Errorf("casbin enforcer not available")
 	}
 
-	// Remove grouping policy: user -> role
-	removed, err := enforcer.RemoveGroupingPolicy(userID, role)
-	if err != nil {
-		return fmt.Errorf("failed to remove role: %w", err)
-	}
-
-	if !removed {
-		return fmt.Errorf("role assignment does not exist")
-	}
-
-	return nil
+	return s.applySessionChange(session, sessionChange{
+		action:    ApprovalActionRemoveRoleAssignment,
+		target:    fmt.Sprintf("%s from %s", role, userID),
+		dependsOn: func(roles map[string]bool) bool { return roles[userID] && roles[role] },
+		apply: func(ctx context.Context, policies *initializer.PolicyTransaction) error {
+			var removed bool
+			var err error
+			if s.roleSync != nil {
+				removed, err = s.roleSync.RemoveRole(ctx, policies, userID, role)
+			} else {
+				// Remove grouping policy: user -> role
+				removed, err = policies.RemoveGroupingPolicy([]string{userID, role})
+			}
+			if err != nil {
+				return fmt.Errorf("failed to remove role: %w", err)
+			}
+			if !removed {
+				return apperrors.Newf(apperrors.ErrNotFound, "role assignment does not exist")
+			}
+			return nil
+		},
+	})
 }
 
 // GetUsersForRole returns all users assigned to a specific role
@@ -340,7 +416,12 @@ func (s *AdminProfileService) GetUsersForRole(role string) ([]string, error) {
 	}
 
 	// Get all users with this role
-	users, err := enforcer.GetUsersForRole(role)
+	var users []string
+	err := initializer.ReadPolicies(enforcer, func() error {
+		var err error
+		users, err = enforcer.GetUsersForRole(role)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get users for role: %w", err)
 	}
@@ -348,31 +429,50 @@ func (s *AdminProfileService) GetUsersForRole(role string) ([]string, error) {
 	return users, nil
 }
 
-// DeleteRole removes a role and all its assignments
-func (s *AdminProfileService) DeleteRole(role string) error {
-	enforcer := initializer.CasbinEnforcer
-	if enforcer == nil {
+// DeleteRole removes a role and all its assignments. Either all of them are
+// removed or, if a step fails, none. Reserved roles cannot be deleted, nor
+// can roles the session depends on without an approved override.
+func (s *AdminProfileService) DeleteRole(role string, session RoleSession) error {
+	if initializer.CasbinEnforcer == nil {
 		return fmt.Errorf("casbin enforcer not available")
 	}
-
-	// Remove all grouping policies for this role
-	_, err := enforcer.RemoveFilteredGroupingPolicy(1, role)
-	if err != nil {
-		return fmt.Errorf("failed to remove role assignments: %w", err)
+	if err := checkNotReserved(role, "deleted"); err != nil {
+		return err
 	}
 
-	// Remove all policies that use this role
-	_, err = enforcer.RemoveFilteredPolicy(0, role)
+	err := s.applySessionChange(session, sessionChange{
+		action:    ApprovalActionDeleteRole,
+		target:    role,
+		dependsOn: func(roles map[string]bool) bool { return roles[role] },
+		apply: func(ctx context.Context, policies *initializer.PolicyTransaction) error {
+			// Remove all grouping policies for this role
+			if _, err := policies.RemoveFilteredGroupingPolicy(1, role); err != nil {
+				return fmt.Errorf("failed to remove role assignments: %w", err)
+			}
+
+			// Remove all policies that use this role
+			if _, err := policies.RemoveFilteredPolicy(0, role); err != nil {
+				return fmt.Errorf("failed to remove role policies: %w", err)
+			}
+			if s.roleSync != nil {
+				return s.roleSync.DeleteRole(ctx, role)
+			}
+			return nil
+		},
+	})
 	if err != nil {
-		return fmt.Errorf("failed to remove role policies: %w", err)
+		return err
 	}
 
 	// Remove custom description
-	delete(customDescriptions, role)
-
 	// Note: We don't return an error if nothing was removed, as the role might not have been used
+	return customDescriptions.Delete(role)
+}
 
-	return nil
+// SavePolicies writes the current roles and policies to the policy storage.
+// Changes are saved automatically unless CASBIN_AUTO_SAVE is disabled.
+func (s *AdminProfileService) SavePolicies() error {
+	return initializer.SavePolicies(initializer.CasbinEnforcer)
 }
 
 // GetUserRoles returns all roles assigned to a user
@@ -383,7 +483,12 @@ func (s *AdminProfileService) GetUserRoles(userID string) ([]string, error) {
 	}
 
 	// Get all roles for this user
-	roles, err := enforcer.GetRolesForUser(userID)
+	var roles []string
+	err := initializer.ReadPolicies(enforcer, func() error {
+		var err error
+		roles, err = enforcer.GetRolesForUser(userID)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get roles for user: %w", err)
 	}
@@ -398,16 +503,24 @@ func (s *AdminProfileService) GetRoleDetails(roleName string) (map[string]interf
 		return nil, fmt.Errorf("casbin enforcer not available")
 	}
 
-	// Get users for this role
-	users, err := enforcer.GetUsersForRole(roleName)
+	// Get users and permissions for this role
+	var users []string
+	var policies [][]string
+	var policiesErr error
+	err := initializer.ReadPolicies(enforcer, func() error {
+		var err error
+		if users, err = enforcer.GetUsersForRole(roleName); err != nil {
+			return err
+		}
+		policies, policiesErr = enforcer.GetFilteredPolicy(0, roleName)
+		return nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get users for role: %w", err)
 	}
 
-	// Get permissions for this role
 	permissions := make([]map[string]string, 0)
-	policies, err := enforcer.GetFilteredPolicy(0, roleName)
-	if err == nil {
+	if policiesErr == nil {
 		for _, policy := range policies {
 			if len(policy) >= 3 {
 				permissions = append(permissions, map[string]string{
@@ -442,7 +555,12 @@ func (s *AdminProfileService) GetRolePermissions(roleName string) ([]map[string]
 	}
 
 	permissions := make([]map[string]string, 0)
-	policies, err := enforcer.GetFilteredPolicy(0, roleName)
+	var policies [][]string
+	err := initializer.ReadPolicies(enforcer, func() error {
+		var err error
+		policies, err = enforcer.GetFilteredPolicy(0, roleName)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get permissions: %w", err)
 	}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	identity_access "github.com/aruncs31s/azf/domain/identity_access/model"
+	apperrors "github.com/aruncs31s/azf/shared/errors"
+	"github.com/aruncs31s/azf/shared/logger"
+	"go.uber.org/zap"
+)
+
+// adminSessionTouchInterval bounds how often the last use of a session is
+// written, and so how early before its idle expiry a session may end
+const adminSessionTouchInterval = time.Minute
+
+// AdminSessionService starts and checks admin dashboard sessions against a
+// session store, so they survive restarts and are shared between replicas.
+// Sessions are identified to clients by a random token; only its hash is
+// stored and shown.
+type AdminSessionService interface {
+	// Start stores a new session and returns its token for the session cookie
+	Start(ctx context.Context, req StartAdminSessionRequest) (string, error)
+	// Validate returns the session of token, failing when it is unknown or has ended
+	Validate(ctx context.Context, token string) (*identity_access.AdminSession, error)
+	// End ends the session of token, on sign-out
+	End(ctx context.Context, token string) error
+	ListSessions(ctx context.Context) (*[]AdminSessionDTO, error)
+	// RevokeSession ends the session with id from the admin UI
+	RevokeSession(ctx context.Context, id string) error
+	// AbsoluteTimeout is the longest a session may last
+	AbsoluteTimeout() time.Duration
+}
+
+type adminSessionService struct {
+	store           identity_access.SessionStore
+	idleTimeout     time.Duration
+	absoluteTimeout time.Duration
+}
+
+// NewAdminSessionService creates an admin session service on store. Sessions
+// end after idleTimeout without use, and after absoluteTimeout regardless.
+func NewAdminSessionService(store identity_access.SessionStore, idleTimeout time.Duration, absoluteTimeout time.Duration) AdminSessionService {
+	return &adminSessionService{
+		store:           store,
+		idleTimeout:     idleTimeout,
+		absoluteTimeout: absoluteTimeout,
+	}
+}
+
+func (s *adminSessionService) Start(ctx context.Context, req StartAdminSessionRequest) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate session token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	now := time.Now()
+	session := &identity_access.AdminSession{
+		ID:            HashAdminSessionToken(token),
+		Username:      req.Username,
+		UserID:        req.UserID,
+		ClientIP:      req.ClientIP,
+		UserAgent:     req.UserAgent,
+		CreatedAt:     now,
+		LastSeenAt:    now,
+		IdleExpiresAt: now.Add(s.idleTimeout),
+		ExpiresAt:     now.Add(s.absoluteTimeout),
+	}
+	if err := s.store.Create(ctx, session); err != nil {
+		return "", err
+	}
+	if err := s.store.DeleteExpired(ctx, now); err != nil {
+		logger.Warn("Failed to delete expired admin sessions", zap.Error(err))
+	}
+	return token, nil
+}
+
+func (s *adminSessionService) Validate(ctx context.Context, token string) (*identity_access.AdminSession, error) {
+	if token == "" {
+		return nil, apperrors.Newf(apperrors.ErrUnauthorized, "session token cannot be empty")
+	}
+
+	session, err := s.store.FindByID(ctx, HashAdminSessionToken(token))
+	if errors.Is(err, identity_access.ErrAdminSessionNotFound) {
+		return nil, apperrors.Newf(apperrors.ErrUnauthorized, "admin session not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if session.IsExpired(now) {
+		if err := s.store.Delete(ctx, session.ID); err != nil {
+			logger.Warn("Failed to delete expired admin session", zap.Error(err))
+		}
+		return nil, apperrors.Newf(apperrors.ErrUnauthorized, "admin session has expired")
+	}
+
+	if now.Sub(session.LastSeenAt) >= adminSessionTouchInterval {
+		session.LastSeenAt = now
+		session.IdleExpiresAt = now.Add(s.idleTimeout)
+		go func(id string, idleExpiresAt time.Time) {
+			if err := s.store.Touch(context.Background(), id, now, idleExpiresAt); err != nil {
+				logger.Warn("Failed to record admin session use", zap.Error(err))
+			}
+		}(session.ID, session.IdleExpiresAt)
+	}
+	return session, nil
+}
+
+func (s *adminSessionService) End(ctx context.Context, token string) error {
+	if token == "" {
+		return nil
+	}
+	return s.store.Delete(ctx, HashAdminSessionToken(token))
+}
+
+func (s *adminSessionService) ListSessions(ctx context.Context) (*[]AdminSessionDTO, error) {
+	sessions, err := s.store.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	result := make([]AdminSessionDTO, 0, len(sessions))
+	for _, session := range sessions {
+		if session.IsExpired(now) {
+			continue
+		}
+		result = append(result, toAdminSessionDTO(session))
+	}
+	return &result, nil
+}
+
+func (s *adminSessionService) RevokeSession(ctx context.Context, id string) error {
+	session, err := s.store.FindByID(ctx, id)
+	if errors.Is(err, identity_access.ErrAdminSessionNotFound) {
+		return apperrors.Newf(apperrors.ErrNotFound, "admin session not found: %s", id)
+	}
+	if err != nil {
+		return err
+	}
+	if err := s.store.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	logger.Info("Admin session revoked", zap.String("username", session.Username), zap.String("client_ip", session.ClientIP))
+	return nil
+}
+
+func (s *adminSessionService) AbsoluteTimeout() time.Duration {
+	return s.absoluteTimeout
+}
+
+// HashAdminSessionToken returns the stored ID of the session with token
+func HashAdminSessionToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func toAdminSessionDTO(session identity_access.AdminSession) AdminSessionDTO {
+	expiresAt := session.ExpiresAt
+	if session.IdleExpiresAt.Before(expiresAt) {
+		expiresAt = session.IdleExpiresAt
+	}
+	return AdminSessionDTO{
+		ID:         session.ID,
+		Username:   session.Username,
+		UserID:     session.UserID,
+		ClientIP:   session.ClientIP,
+		UserAgent:  session.UserAgent,
+		CreatedAt:  session.CreatedAt,
+		LastSeenAt: session.LastSeenAt,
+		ExpiresAt:  expiresAt,
+	}
+}
+
+// StartAdminSessionRequest describes the admin signing in
+type StartAdminSessionRequest struct {
+	Username  string
+	UserID    string
+	ClientIP  string
+	UserAgent string
+}
+
+// AdminSessionDTO is an active admin session
+type AdminSessionDTO struct {
+	ID         string    `json:"id"`
+	Username   string    `json:"username"`
+	UserID     string    `json:"user_id"`
+	ClientIP   string    `json:"client_ip"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	// ExpiresAt is when the session ends unless it is used again
+	ExpiresAt time.Time `json:"expires_at"`
+	// Current marks the session making the request
+	Current bool `json:"current"`
+}
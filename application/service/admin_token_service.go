@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aruncs31s/azf/application/dto"
+	"github.com/aruncs31s/azf/constants"
+	identity_access "github.com/aruncs31s/azf/domain/identity_access/model"
+	apperrors "github.com/aruncs31s/azf/shared/errors"
+	"github.com/aruncs31s/azf/shared/logger"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// AdminTokenService issues short-lived admin access tokens with rotating
+// refresh tokens. Each refresh replaces the refresh token; presenting a
+// replaced token again means it was stolen, so every token of that login
+// is revoked.
+type AdminTokenService interface {
+	// Issue starts a login and returns its first token pair
+	Issue(ctx context.Context, username, userID string) (*dto.AdminTokenPair, error)
+	// Refresh exchanges a refresh token for a new token pair
+	Refresh(ctx context.Context, refreshToken string) (*dto.AdminTokenPair, error)
+	// Revoke ends the login of a refresh token
+	Revoke(ctx context.Context, refreshToken string) error
+}
+
+type adminTokenService struct {
+	repo       identity_access.RefreshTokenRepository
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+// NewAdminTokenService creates an admin token service storing refresh tokens in repo
+func NewAdminTokenService(repo identity_access.RefreshTokenRepository, accessTTL, refreshTTL time.Duration) AdminTokenService {
+	return &adminTokenService{
+		repo:       repo,
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+	}
+}
+
+func (s *adminTokenService) Issue(ctx context.Context, username, userID string) (*dto.AdminTokenPair, error) {
+	refreshToken, stored, err := s.newRefreshToken(uuid.New().String(), username, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, stored); err != nil {
+		return nil, err
+	}
+	return s.tokenPair(stored, refreshToken)
+}
+
+func (s *adminTokenService) Refresh(ctx context.Context, refreshToken string) (*dto.AdminTokenPair, error) {
+	if refreshToken == "" {
+		return nil, apperrors.Newf(apperrors.ErrUnauthorized, "refresh token required")
+	}
+	current, err := s.repo.FindByHash(ctx, hashRefreshToken(refreshToken))
+	if errors.Is(err, identity_access.ErrRefreshTokenNotFound) {
+		return nil, apperrors.Newf(apperrors.ErrUnauthorized, "invalid refresh token")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if current.IsRevoked() {
+		s.revokeReused(ctx, current)
+		return nil, apperrors.Newf(apperrors.ErrUnauthorized, "refresh token has been revoked")
+	}
+	if current.IsExpired(time.Now()) {
+		return nil, apperrors.Newf(apperrors.ErrUnauthorized, "refresh token has expired")
+	}
+
+	nextToken, next, err := s.newRefreshToken(current.FamilyID, current.Username, current.UserID)
+	if err != nil {
+		return nil, err
+	}
+	err = s.repo.Rotate(ctx, current.TokenHash, next)
+	if errors.Is(err, identity_access.ErrRefreshTokenRevoked) {
+		// Rotated concurrently with this request
+		s.revokeReused(ctx, current)
+		return nil, apperrors.Newf(apperrors.ErrUnauthorized, "refresh token has been revoked")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.tokenPair(next, nextToken)
+}
+
+func (s *adminTokenService) Revoke(ctx context.Context, refreshToken string) error {
+	if refreshToken == "" {
+		return nil
+	}
+	current, err := s.repo.FindByHash(ctx, hashRefreshToken(refreshToken))
+	if errors.Is(err, identity_access.ErrRefreshTokenNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return s.repo.RevokeFamily(ctx, current.FamilyID)
+}
+
+// revokeReused revokes the login of a refresh token presented after it was
+// rotated or revoked
+func (s *adminTokenService) revokeReused(ctx context.Context, token *identity_access.RefreshToken) {
+	if token.ReplacedBy == "" {
+		return
+	}
+	logger.Warn("Rotated admin refresh token reused, revoking its login",
+		zap.String("username", token.Username),
+		zap.String("family_id", token.FamilyID))
+	if err := s.repo.RevokeFamily(ctx, token.FamilyID); err != nil {
+		logger.Error("Failed to revoke admin refresh tokens",
+			zap.String("family_id", token.FamilyID),
+			zap.Error(err))
+	}
+}
+
+// newRefreshToken returns a random refresh token and its stored form
+func (s *adminTokenService) newRefreshToken(familyID, username, userID string) (string, *identity_access.RefreshToken, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	now := time.Now()
+	return token, &identity_access.RefreshToken{
+		TokenHash: hashRefreshToken(token),
+		FamilyID:  familyID,
+		Username:  username,
+		UserID:    userID,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(s.refreshTTL),
+	}, nil
+}
+
+// tokenPair signs an access token for the login of stored
+func (s *adminTokenService) tokenPair(stored *identity_access.RefreshToken, refreshToken string) (*dto.AdminTokenPair, error) {
+	accessToken, err := GenerateTokenWithExpiry(map[string]any{
+		"username": stored.Username,
+		"role":     constants.ADMIN,
+		"user_id":  stored.UserID,
+		"sid":      stored.FamilyID,
+	}, s.accessTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign access token: %w", err)
+	}
+	return &dto.AdminTokenPair{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        int64(s.accessTTL / time.Second),
+		RefreshExpiresIn: int64(time.Until(stored.ExpiresAt) / time.Second),
+	}, nil
+}
+
+// hashRefreshToken returns the stored hash of a refresh token
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,334 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aruncs31s/azf/application/dto"
+	"github.com/aruncs31s/azf/config"
+	usermodel "github.com/aruncs31s/azf/domain/user_management/model"
+	"github.com/aruncs31s/azf/initializer"
+	"github.com/aruncs31s/azf/shared/logger"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// adminUserIDPrefix matches the user_id claim issued to admins before
+	// they had user records, so earlier usage stays attributed to them
+	adminUserIDPrefix = "admin_"
+	// adminUserSource marks user records created from the admin configuration
+	adminUserSource = "env"
+)
+
+var emailLocalPartInvalid = regexp.MustCompile(`[^a-zA-Z0-9._%+-]+`)
+
+// ErrAdminSignInDenied is returned when the user record of a directory
+// identity is blocked from signing in
+var ErrAdminSignInDenied = errors.New("user may not sign in to the admin UI")
+
+// AdminUserService keeps the admin configured through the environment in
+// lock-step with a user record in authz_users, so its traffic, roles and
+// display name resolve like those of any other user
+type AdminUserService interface {
+	// EnsureAdminUser returns the user record of a configured admin. An
+	// existing user with the same username is linked by promoting it to
+	// admin; otherwise a new record is created.
+	EnsureAdminUser(username string) (*usermodel.User, error)
+	// RecordAdminLogin ensures the admin's user record and records the login
+	RecordAdminLogin(ctx context.Context, username string) (*usermodel.User, error)
+	// RecordDirectoryLogin ensures the user record of an admin signed in
+	// through a directory such as LDAP or SAML, found by subject or else
+	// email, grants it the mapped roles it lacks, on the record and as
+	// grouping policies, and records the login. Blocked users fail with
+	// ErrAdminSignInDenied. Without a user repository it returns nil.
+	RecordDirectoryLogin(ctx context.Context, identity *dto.DirectoryIdentity) (*usermodel.User, error)
+}
+
+// adminUserService implements AdminUserService
+type adminUserService struct {
+	userRepo   usermodel.UserRepository
+	unitOfWork UnitOfWork
+}
+
+// NewAdminUserService creates a new admin user service. userRepo may be nil
+// when no database is configured; without a unitOfWork the lookup and the
+// write of a user record are not made atomic.
+func NewAdminUserService(userRepo usermodel.UserRepository, unitOfWork UnitOfWork) AdminUserService {
+	return &adminUserService{
+		userRepo:   userRepo,
+		unitOfWork: unitOfWork,
+	}
+}
+
+// AdminUserID returns the user ID given to the record of a configured admin
+func AdminUserID(username string) string {
+	id := adminUserIDPrefix + username
+	if len(id) > 36 {
+		// Long usernames get a stable ID that fits the user ID column
+		return uuid.NewSHA1(uuid.NameSpaceOID, []byte(id)).String()
+	}
+	return id
+}
+
+func (s *adminUserService) EnsureAdminUser(username string) (*usermodel.User, error) {
+	if username == "" {
+		return nil, fmt.Errorf("admin username cannot be empty")
+	}
+	if s.userRepo == nil {
+		return nil, fmt.Errorf("user repository is not configured")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var user *usermodel.User
+	err := s.inUnitOfWork(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = s.ensureAdminUser(ctx, username)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// ensureAdminUser implements EnsureAdminUser with the repository calls made on ctx
+func (s *adminUserService) ensureAdminUser(ctx context.Context, username string) (*usermodel.User, error) {
+	user, err := s.userRepo.GetByID(ctx, AdminUserID(username))
+	if errors.Is(err, usermodel.ErrUserNotFound) {
+		user, err = s.userRepo.GetByUsername(ctx, username)
+	}
+	if err != nil && !errors.Is(err, usermodel.ErrUserNotFound) {
+		return nil, fmt.Errorf("failed to look up user record for admin %s: %w", username, err)
+	}
+	if err == nil {
+		if user.IsAdmin() {
+			return user, nil
+		}
+		linked, err := s.userRepo.PromoteToAdmin(ctx, user.GetID())
+		if err != nil {
+			return nil, fmt.Errorf("failed to link admin %s to user %s: %w", username, user.GetID(), err)
+		}
+		logger.FromContext(ctx).Info("Linked configured admin to existing user",
+			zap.String("username", username),
+			zap.String("user_id", linked.GetID()))
+		return linked, nil
+	}
+
+	user, err = newAdminUser(username)
+	if err != nil {
+		return nil, err
+	}
+	created, err := s.userRepo.Create(ctx, user)
+	if errors.Is(err, usermodel.ErrDuplicateEmail) {
+		return nil, fmt.Errorf("admin email %s already belongs to another user: %w", user.GetEmail(), err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user record for admin %s: %w", username, err)
+	}
+	logger.FromContext(ctx).Info("Created user record for configured admin",
+		zap.String("username", username),
+		zap.String("user_id", created.GetID()))
+	return created, nil
+}
+
+func (s *adminUserService) RecordAdminLogin(ctx context.Context, username string) (*usermodel.User, error) {
+	if username == "" {
+		return nil, fmt.Errorf("admin username cannot be empty")
+	}
+	if s.userRepo == nil {
+		return nil, fmt.Errorf("user repository is not configured")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var updated *usermodel.User
+	err := s.inUnitOfWork(ctx, func(ctx context.Context) error {
+		user, err := s.ensureAdminUser(ctx, username)
+		if err != nil {
+			return err
+		}
+		if err := user.RecordLogin(); err != nil {
+			return fmt.Errorf("failed to record admin login: %w", err)
+		}
+		updated, err = s.userRepo.Update(ctx, user)
+		if err != nil {
+			return fmt.Errorf("failed to record admin login: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (s *adminUserService) RecordDirectoryLogin(ctx context.Context, identity *dto.DirectoryIdentity) (*usermodel.User, error) {
+	if s.userRepo == nil {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var saved *usermodel.User
+	err := s.inPolicyUnitOfWork(ctx, func(ctx context.Context, policies *initializer.PolicyTransaction) error {
+		user, created, err := s.findDirectoryUser(ctx, identity)
+		if err != nil {
+			return err
+		}
+		if !user.GetStatus().CanLogin() {
+			return fmt.Errorf("%w: user %s is %s", ErrAdminSignInDenied, user.GetID(), user.GetStatus())
+		}
+		if err := user.SetOAuthProvider(identity.Provider); err != nil {
+			return err
+		}
+		if err := user.SetOAuthID(identity.Subject); err != nil {
+			return err
+		}
+		if !user.IsAdmin() {
+			if err := user.PromoteToAdmin(); err != nil {
+				return err
+			}
+		}
+		for _, name := range identity.Roles {
+			if policies != nil {
+				if _, err := policies.AddGroupingPolicy([]string{user.GetID(), name}); err != nil {
+					return fmt.Errorf("failed to grant role %s: %w", name, err)
+				}
+			}
+			if user.HasRole(name) {
+				continue
+			}
+			role, err := usermodel.NewUserRole(name, nil)
+			if err != nil {
+				return err
+			}
+			if err := user.AssignRole(role); err != nil {
+				return fmt.Errorf("failed to assign role %s: %w", name, err)
+			}
+		}
+		if err := user.RecordLogin(); err != nil {
+			return fmt.Errorf("failed to record %s login: %w", identity.Provider, err)
+		}
+
+		if created {
+			saved, err = s.userRepo.Create(ctx, user)
+		} else {
+			saved, err = s.userRepo.Update(ctx, user)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to save user record of %s user %s: %w", identity.Provider, identity.Username, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return saved, nil
+}
+
+// findDirectoryUser returns the user record of a directory identity, by
+// subject or email, or a new record reporting true
+func (s *adminUserService) findDirectoryUser(ctx context.Context, identity *dto.DirectoryIdentity) (*usermodel.User, bool, error) {
+	user, err := s.userRepo.GetByOAuthID(ctx, identity.Provider, identity.Subject)
+	if errors.Is(err, usermodel.ErrUserNotFound) && identity.Email != "" {
+		user, err = s.userRepo.GetByEmail(ctx, identity.Email)
+	}
+	if err == nil {
+		return user, false, nil
+	}
+	if !errors.Is(err, usermodel.ErrUserNotFound) {
+		return nil, false, fmt.Errorf("failed to look up user record of %s user %s: %w", identity.Provider, identity.Username, err)
+	}
+
+	email := identity.Email
+	if email == "" {
+		email = placeholderEmail(identity.Username)
+	}
+	name := identity.Name
+	if name == "" {
+		name = identity.Username
+	}
+	user, err = usermodel.NewUser(AdminUserID(identity.Username), email, identity.Username, name)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid user record for %s user %s: %w", identity.Provider, identity.Username, err)
+	}
+	if err := user.SetMetadata("source", identity.Provider); err != nil {
+		return nil, false, err
+	}
+	return user, true, nil
+}
+
+// inUnitOfWork runs fn in the service's unit of work, or directly when none
+// is configured
+func (s *adminUserService) inUnitOfWork(ctx context.Context, fn func(ctx context.Context) error) error {
+	return s.inPolicyUnitOfWork(ctx, func(ctx context.Context, _ *initializer.PolicyTransaction) error {
+		return fn(ctx)
+	})
+}
+
+// inPolicyUnitOfWork runs fn in the service's unit of work with its policy
+// transaction, or directly without policies when none is configured
+func (s *adminUserService) inPolicyUnitOfWork(ctx context.Context, fn func(ctx context.Context, policies *initializer.PolicyTransaction) error) error {
+	if s.unitOfWork == nil {
+		return fn(ctx, nil)
+	}
+	return s.unitOfWork.Do(ctx, fn)
+}
+
+// newAdminUser builds the user record of a configured admin
+func newAdminUser(username string) (*usermodel.User, error) {
+	displayName := config.AdminDisplayName()
+	if displayName == "" {
+		displayName = username
+	}
+
+	user, err := usermodel.NewUser(AdminUserID(username), adminEmail(username), username, displayName)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user record for admin %s: %w", username, err)
+	}
+	if err := user.PromoteToAdmin(); err != nil {
+		return nil, err
+	}
+	role, err := usermodel.NewUserRole("admin", nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := user.AssignRole(role); err != nil {
+		return nil, err
+	}
+	if err := user.SetMetadata("source", adminUserSource); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// adminEmail returns the configured admin email, the username when it is an
+// address, or a placeholder address derived from the username
+func adminEmail(username string) string {
+	if email := config.AdminEmail(); email != "" {
+		return email
+	}
+	return placeholderEmail(username)
+}
+
+// placeholderEmail returns the username when it is an address, or a
+// placeholder address derived from it
+func placeholderEmail(username string) string {
+	if _, err := usermodel.NewUserEmail(username); err == nil {
+		return username
+	}
+	localPart := strings.Trim(emailLocalPartInvalid.ReplaceAllString(username, "-"), ".-")
+	if localPart == "" {
+		localPart = "admin"
+	}
+	return localPart + "@admin.azf.local"
+}
@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	identity_access "github.com/aruncs31s/azf/domain/identity_access/model"
+	apperrors "github.com/aruncs31s/azf/shared/errors"
+	"github.com/aruncs31s/azf/shared/logger"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// apiKeyPrefix starts every issued key, so leaked keys are easy to spot
+const apiKeyPrefix = "azf_"
+
+// apiKeyDisplayLength is how much of a key is kept to tell keys apart
+const apiKeyDisplayLength = len(apiKeyPrefix) + 8
+
+// apiKeyTouchInterval bounds how often the last use of a key is written
+const apiKeyTouchInterval = time.Minute
+
+// maxAPIKeyRequestsPerMinute caps per-key rate limits to catch typos
+const maxAPIKeyRequestsPerMinute = 100000
+
+// APIKeyService issues and revokes API keys and authenticates the requests
+// presenting them. A key is only shown when it is issued.
+type APIKeyService interface {
+	IssueKey(ctx context.Context, req IssueAPIKeyRequest, createdBy string) (*IssuedAPIKeyDTO, error)
+	ListKeys(ctx context.Context) (*[]APIKeyDTO, error)
+	RevokeKey(ctx context.Context, id string) error
+	// Authenticate returns the key, failing when it is unknown, expired or revoked
+	Authenticate(ctx context.Context, key string) (*identity_access.APIKey, error)
+}
+
+type apiKeyService struct {
+	repo identity_access.APIKeyRepository
+}
+
+// NewAPIKeyService creates an API key service storing keys in repo
+func NewAPIKeyService(repo identity_access.APIKeyRepository) APIKeyService {
+	return &apiKeyService{repo: repo}
+}
+
+func (s *apiKeyService) IssueKey(ctx context.Context, req IssueAPIKeyRequest, createdBy string) (*IssuedAPIKeyDTO, error) {
+	if s.repo == nil {
+		return nil, fmt.Errorf("API keys are not available: database is not initialized")
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > 100 {
+		return nil, apperrors.Newf(apperrors.ErrValidation, "name must be between 1 and 100 characters")
+	}
+	userID := strings.TrimSpace(req.UserID)
+	if userID == "" {
+		return nil, apperrors.Newf(apperrors.ErrValidation, "user_id cannot be empty")
+	}
+	role, err := NormalizeRoleName(req.Role)
+	if err != nil {
+		return nil, err
+	}
+	scopes, err := normalizeScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	if req.RateLimit < 0 || req.RateLimit > maxAPIKeyRequestsPerMinute {
+		return nil, apperrors.Newf(apperrors.ErrValidation, "rate_limit must be between 0 and %d", maxAPIKeyRequestsPerMinute)
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, apperrors.Newf(apperrors.ErrValidation, "expires_at must be in the future")
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, fmt.Errorf("failed to generate API key: %w", err)
+	}
+	key := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(buf)
+
+	apiKey := &identity_access.APIKey{
+		ID:        uuid.New().String(),
+		Name:      name,
+		Prefix:    key[:apiKeyDisplayLength],
+		KeyHash:   hashAPIKey(key),
+		UserID:    userID,
+		Role:      role,
+		Scopes:    scopes,
+		RateLimit: req.RateLimit,
+		ExpiresAt: req.ExpiresAt,
+		CreatedBy: createdBy,
+		CreatedAt: time.Now(),
+	}
+	if err := s.repo.Create(ctx, apiKey); err != nil {
+		return nil, err
+	}
+
+	logger.Info("API key issued",
+		zap.String("id", apiKey.ID),
+		zap.String("user_id", apiKey.UserID),
+		zap.String("role", apiKey.Role),
+		zap.Strings("scopes", apiKey.Scopes),
+		zap.String("created_by", createdBy))
+
+	return &IssuedAPIKeyDTO{
+		APIKeyDTO: toAPIKeyDTO(*apiKey, time.Now()),
+		Key:       key,
+	}, nil
+}
+
+func (s *apiKeyService) ListKeys(ctx context.Context) (*[]APIKeyDTO, error) {
+	result := make([]APIKeyDTO, 0)
+	if s.repo == nil {
+		return &result, nil
+	}
+
+	keys, err := s.repo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, key := range keys {
+		result = append(result, toAPIKeyDTO(key, now))
+	}
+	return &result, nil
+}
+
+func (s *apiKeyService) RevokeKey(ctx context.Context, id string) error {
+	if s.repo == nil {
+		return fmt.Errorf("API keys are not available: database is not initialized")
+	}
+
+	key, err := s.repo.FindByID(ctx, id)
+	if errors.Is(err, identity_access.ErrAPIKeyNotFound) {
+		return apperrors.Newf(apperrors.ErrNotFound, "API key not found: %s", id)
+	}
+	if err != nil {
+		return err
+	}
+	if key.IsRevoked() {
+		return nil
+	}
+	if err := s.repo.Revoke(ctx, id, time.Now()); err != nil {
+		return err
+	}
+
+	logger.Info("API key revoked", zap.String("id", id), zap.String("user_id", key.UserID))
+	return nil
+}
+
+func (s *apiKeyService) Authenticate(ctx context.Context, key string) (*identity_access.APIKey, error) {
+	if s.repo == nil {
+		return nil, apperrors.Newf(apperrors.ErrUnauthorized, "API keys are not available")
+	}
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return nil, apperrors.Newf(apperrors.ErrUnauthorized, "invalid API key")
+	}
+
+	apiKey, err := s.repo.FindByHash(ctx, hashAPIKey(key))
+	if errors.Is(err, identity_access.ErrAPIKeyNotFound) {
+		return nil, apperrors.Newf(apperrors.ErrUnauthorized, "invalid API key")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if apiKey.IsRevoked() {
+		return nil, apperrors.Newf(apperrors.ErrUnauthorized, "API key %s has been revoked", apiKey.Prefix)
+	}
+	now := time.Now()
+	if apiKey.IsExpired(now) {
+		return nil, apperrors.Newf(apperrors.ErrUnauthorized, "API key %s has expired", apiKey.Prefix)
+	}
+
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= apiKeyTouchInterval {
+		apiKey.LastUsedAt = &now
+		go func(id string) {
+			if err := s.repo.Touch(context.Background(), id, now); err != nil {
+				logger.Warn("Failed to record API key use", zap.String("id", id), zap.Error(err))
+			}
+		}(apiKey.ID)
+	}
+	return apiKey, nil
+}
+
+// normalizeScopes trims and deduplicates scopes, which cannot contain spaces
+func normalizeScopes(scopes []string) ([]string, error) {
+	result := make([]string, 0, len(scopes))
+	seen := make(map[string]bool, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if scope == "" || seen[scope] {
+			continue
+		}
+		if strings.ContainsAny(scope, " \t\r\n") {
+			return nil, apperrors.Newf(apperrors.ErrValidation, "scope %q cannot contain spaces", scope)
+		}
+		seen[scope] = true
+		result = append(result, scope)
+	}
+	return result, nil
+}
+
+// hashAPIKey returns the stored hash of an API key
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func toAPIKeyDTO(key identity_access.APIKey, now time.Time) APIKeyDTO {
+	status := "active"
+	switch {
+	case key.IsRevoked():
+		status = "revoked"
+	case key.IsExpired(now):
+		status = "expired"
+	}
+	return APIKeyDTO{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		UserID:     key.UserID,
+		Role:       key.Role,
+		Scopes:     key.Scopes,
+		RateLimit:  key.RateLimit,
+		Status:     status,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+		CreatedBy:  key.CreatedBy,
+		CreatedAt:  key.CreatedAt,
+	}
+}
+
+// IssueAPIKeyRequest is the body of an API key issuance
+type IssueAPIKeyRequest struct {
+	Name   string   `json:"name" binding:"required"`
+	UserID string   `json:"user_id" binding:"required"`
+	Role   string   `json:"role" binding:"required"`
+	Scopes []string `json:"scopes"`
+	// RateLimit is the key's requests per minute; zero applies the limits of its role
+	RateLimit int        `json:"rate_limit"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// APIKeyDTO is an issued API key without the key itself
+type APIKeyDTO struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	UserID     string     `json:"user_id"`
+	Role       string     `json:"role"`
+	Scopes     []string   `json:"scopes"`
+	RateLimit  int        `json:"rate_limit"`
+	Status     string     `json:"status"` // active, expired or revoked
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// IssuedAPIKeyDTO is a newly issued API key; Key is not shown again
+type IssuedAPIKeyDTO struct {
+	APIKeyDTO
+	Key string `json:"key"`
+}
@@ -5,21 +5,29 @@ import (
 	"sort"
 	"time"
 
+	"github.com/aruncs31s/azf/config"
 	"github.com/aruncs31s/azf/domain/api_usage"
 	"github.com/aruncs31s/azf/domain/repository"
 	"github.com/aruncs31s/azf/shared/logger"
 	"go.uber.org/zap"
 )
 
+// endpointErrorSamples is how many recent failed requests endpoint details show
+const endpointErrorSamples = 20
+
 // APIUsageAnalyticsService provides analytics and insights for API usage
 type APIUsageAnalyticsService interface {
 	GetTopEndpointsByUsage(limit int) (*[]api_usage.APIEndpointRanking, error)
 	GetEndpointsByErrorRate(limit int) (*[]api_usage.APIEndpointRanking, error)
 	GetEndpointsByResponseTime(limit int) (*[]api_usage.APIEndpointRanking, error)
+	GetEndpointRankings(query api_usage.EndpointRankingQuery) (*EndpointRankingsDTO, error)
 	GetEndpointDetails(endpoint string) (*EndpointDetailsDTO, error)
 	GetEndpointCallers(endpoint string, limit int) (*[]CallerDTO, error)
 	GetUsageSummary() (*UsageSummaryDTO, error)
 	GetUsageTrend(days int) (*[]UsageTrendDTO, error)
+	GetLatencyHeatmap(days int) (*LatencyHeatmapDTO, error)
+	GetTopConsumers(dimension string, days int, limit int) (*TopConsumersDTO, error)
+	GetMonthlyUsageReport(month time.Time) (*UsageReportDTO, error)
 	GetUserActivitySummary(userID string) (*UserActivityDTO, error)
 	RecalculateAllStats() error
 	ClearAllStatistics() error
@@ -27,8 +35,9 @@ type APIUsageAnalyticsService interface {
 
 // apiUsageAnalyticsService implements APIUsageAnalyticsService
 type apiUsageAnalyticsService struct {
-	logRepo   repository.APIUsageLogRepository
-	statsRepo repository.APIUsageStatsRepository
+	logRepo      repository.APIUsageLogRepository
+	statsRepo    repository.APIUsageStatsRepository
+	errorRanking config.ErrorRankingConfig
 }
 
 // NewAPIUsageAnalyticsService creates a new API usage analytics service
@@ -36,9 +45,19 @@ func NewAPIUsageAnalyticsService(
 	logRepo repository.APIUsageLogRepository,
 	statsRepo repository.APIUsageStatsRepository,
 ) APIUsageAnalyticsService {
+	errorRanking := config.GetErrorRankingConfig()
+	if errorRanking.SortBy != api_usage.RankingSortErrorRate && errorRanking.SortBy != api_usage.RankingSortErrorRateWilson {
+		logger.GetLogger().Warn("Unknown error ranking, ranking by error rate", zap.String("ranking", errorRanking.SortBy))
+		errorRanking.SortBy = api_usage.RankingSortErrorRate
+	}
+	if errorRanking.MinRequests < 0 {
+		errorRanking.MinRequests = 0
+	}
+
 	return &apiUsageAnalyticsService{
-		logRepo:   logRepo,
-		statsRepo: statsRepo,
+		logRepo:      logRepo,
+		statsRepo:    statsRepo,
+		errorRanking: errorRanking,
 	}
 }
 
@@ -52,9 +71,15 @@ func (s *apiUsageAnalyticsService) GetTopEndpointsByUsage(limit int) (*[]api_usa
 	return rankings, nil
 }
 
-// GetEndpointsByErrorRate returns endpoints sorted by error rate
+// GetEndpointsByErrorRate returns the most errored endpoints, ranked and
+// filtered as configured by ANALYTICS_ERROR_RANKING and
+// ANALYTICS_ERROR_MIN_REQUESTS
 func (s *apiUsageAnalyticsService) GetEndpointsByErrorRate(limit int) (*[]api_usage.APIEndpointRanking, error) {
-	rankings, err := s.statsRepo.GetEndpointsByErrorRate(limit)
+	rankings, _, err := s.statsRepo.GetEndpointRankings(api_usage.EndpointRankingQuery{
+		SortBy:      s.errorRanking.SortBy,
+		MinRequests: s.errorRanking.MinRequests,
+		Limit:       limit,
+	})
 	if err != nil {
 		logger.GetLogger().Error("Failed to get endpoints by error rate", zap.Error(err))
 		return nil, err
@@ -75,6 +100,35 @@ func (s *apiUsageAnalyticsService) GetEndpointsByResponseTime(limit int) (*[]api
 	return rankings, nil
 }
 
+// GetEndpointRankings returns a page of endpoints sorted by the requested column
+// Error rate rankings leave out endpoints below the configured minimum
+// number of requests unless the query sets its own.
+func (s *apiUsageAnalyticsService) GetEndpointRankings(query api_usage.EndpointRankingQuery) (*EndpointRankingsDTO, error) {
+	if query.MinRequests == 0 && (query.SortBy == api_usage.RankingSortErrorRate || query.SortBy == api_usage.RankingSortErrorRateWilson) {
+		query.MinRequests = s.errorRanking.MinRequests
+	}
+
+	rankings, total, err := s.statsRepo.GetEndpointRankings(query)
+	if err != nil {
+		logger.GetLogger().Error("Failed to get endpoint rankings", zap.Error(err))
+		return nil, err
+	}
+
+	order := "desc"
+	if query.Ascending {
+		order = "asc"
+	}
+	return &EndpointRankingsDTO{
+		Rankings:    *rankings,
+		Total:       total,
+		SortBy:      query.SortBy,
+		Order:       order,
+		MinRequests: query.MinRequests,
+		Limit:       query.Limit,
+		Offset:      query.Offset,
+	}, nil
+}
+
 // GetEndpointDetails returns detailed statistics for a specific endpoint
 func (s *apiUsageAnalyticsService) GetEndpointDetails(endpoint string) (*EndpointDetailsDTO, error) {
 	stats, err := s.statsRepo.FindByEndpoint(endpoint)
@@ -95,10 +149,22 @@ func (s *apiUsageAnalyticsService) GetEndpointDetails(endpoint string) (*Endpoin
 		successRate = (float64(stats.SuccessRequests) / float64(stats.TotalRequests)) * 100
 	}
 
-	// Get recent logs for this endpoint
-	logs, err := s.logRepo.FindByEndpoint(endpoint, 100, 0)
+	recentErrors := make([]ErrorSampleDTO, 0)
+	errorLogs, err := s.logRepo.FindErrorsByEndpoint(endpoint, endpointErrorSamples)
 	if err != nil {
-		logger.Warn("Failed to get endpoint logs", zap.Error(err), zap.String("endpoint", endpoint))
+		logger.Warn("Failed to get endpoint errors", zap.Error(err), zap.String("endpoint", endpoint))
+	} else {
+		for _, log := range *errorLogs {
+			recentErrors = append(recentErrors, toErrorSampleDTO(log))
+		}
+	}
+
+	statusCodes := make([]StatusCodeDTO, 0)
+	counts, err := s.logRepo.CountByStatusCode(endpoint)
+	if err != nil {
+		logger.Warn("Failed to count endpoint status codes", zap.Error(err), zap.String("endpoint", endpoint))
+	} else {
+		statusCodes = toStatusCodeDTOs(*counts)
 	}
 
 	return &EndpointDetailsDTO{
@@ -114,7 +180,10 @@ func (s *apiUsageAnalyticsService) GetEndpointDetails(endpoint string) (*Endpoin
 		MaxResponseTime: stats.MaxResponseTime,
 		Last24Hours:     stats.Last24Hours,
 		LastAccessedAt:  stats.LastAccessedAt,
-		RecentLogs:      logs,
+		RecentErrors:    recentErrors,
+		StatusCodes:     statusCodes,
+		Bandwidth:       toBandwidthDTO(stats),
+		Cacheability:    toCacheabilityDTO(stats),
 	}, nil
 }
 
@@ -180,64 +249,214 @@ func (s *apiUsageAnalyticsService) GetUsageSummary() (*UsageSummaryDTO, error) {
 	return summary, nil
 }
 
-// GetUsageTrend returns usage trend over specified days
+// GetUsageTrend returns usage trend over specified days, most recent day first.
+// Days use the server's local time zone.
 func (s *apiUsageAnalyticsService) GetUsageTrend(days int) (*[]UsageTrendDTO, error) {
 	trends := make([]UsageTrendDTO, 0, days)
+	if days <= 0 {
+		return &trends, nil
+	}
 
-	for i := 0; i < days; i++ {
-		date := time.Now().AddDate(0, 0, -i)
-		startOfDay := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
-		endOfDay := startOfDay.Add(24 * time.Hour)
-
-		logs, err := s.logRepo.FindByDateRange(
-			startOfDay.Format(time.RFC3339),
-			endOfDay.Format(time.RFC3339),
-			10000,
-			0,
-		)
-		if err != nil {
-			logger.Warn("Failed to get logs for trend", zap.Error(err), zap.Time("date", date))
-			continue
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	from := today.AddDate(0, 0, -(days - 1))
+	to := today.AddDate(0, 0, 1)
+
+	buckets, err := s.logRepo.AggregateHourly(from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hourly usage: %w", err)
+	}
+
+	type dayTotals struct {
+		requests, successes, responseTime int64
+	}
+	totals := make(map[time.Time]*dayTotals, days)
+	if buckets != nil {
+		for _, bucket := range *buckets {
+			t := bucket.Bucket.In(now.Location())
+			day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, now.Location())
+			if totals[day] == nil {
+				totals[day] = &dayTotals{}
+			}
+			totals[day].requests += bucket.RequestCount
+			totals[day].successes += bucket.SuccessCount
+			totals[day].responseTime += bucket.TotalResponseTime
 		}
+	}
 
-		if logs == nil || len(*logs) == 0 {
-			trends = append(trends, UsageTrendDTO{
-				Date:            startOfDay,
-				RequestCount:    0,
-				SuccessCount:    0,
-				ErrorCount:      0,
-				AvgResponseTime: 0,
-			})
-			continue
+	for i := 0; i < days; i++ {
+		startOfDay := today.AddDate(0, 0, -i)
+		trend := UsageTrendDTO{Date: startOfDay}
+		if day := totals[startOfDay]; day != nil && day.requests > 0 {
+			trend.RequestCount = day.requests
+			trend.SuccessCount = day.successes
+			trend.ErrorCount = day.requests - day.successes
+			trend.AvgResponseTime = day.responseTime / day.requests
 		}
+		trends = append(trends, trend)
+	}
 
-		var successCount, errorCount int64
-		var totalResponseTime int64
+	return &trends, nil
+}
+
+// GetLatencyHeatmap buckets requests and average latency by weekday and hour-of-day
+// over the specified number of days. Buckets use the server's local time zone.
+func (s *apiUsageAnalyticsService) GetLatencyHeatmap(days int) (*LatencyHeatmapDTO, error) {
+	now := time.Now()
+	since := now.AddDate(0, 0, -days)
+	buckets, err := s.logRepo.AggregateHourly(since.Truncate(time.Hour), now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hourly usage: %w", err)
+	}
+
+	var counts [7][24]int64
+	var totals [7][24]int64
+	if buckets != nil {
+		for _, bucket := range *buckets {
+			t := bucket.Bucket.Local()
+			counts[t.Weekday()][t.Hour()] += bucket.RequestCount
+			totals[t.Weekday()][t.Hour()] += bucket.TotalResponseTime
+		}
+	}
 
-		for _, log := range *logs {
-			if log.StatusCode >= 200 && log.StatusCode < 300 {
-				successCount++
-			} else {
-				errorCount++
+	heatmap := &LatencyHeatmapDTO{
+		Days:  days,
+		Since: since,
+		Cells: make([]HeatmapCellDTO, 0, 7*24),
+	}
+	for weekday := 0; weekday < 7; weekday++ {
+		for hour := 0; hour < 24; hour++ {
+			cell := HeatmapCellDTO{
+				Weekday:      weekday,
+				Hour:         hour,
+				RequestCount: counts[weekday][hour],
+			}
+			if cell.RequestCount > 0 {
+				cell.AvgResponseTime = totals[weekday][hour] / cell.RequestCount
+			}
+			if cell.RequestCount > heatmap.MaxRequestCount {
+				heatmap.MaxRequestCount = cell.RequestCount
+				heatmap.PeakWeekday = weekday
+				heatmap.PeakHour = hour
+			}
+			if cell.AvgResponseTime > heatmap.MaxAvgResponseTime {
+				heatmap.MaxAvgResponseTime = cell.AvgResponseTime
 			}
-			totalResponseTime += log.ResponseTime
+			heatmap.Cells = append(heatmap.Cells, cell)
 		}
+	}
+
+	return heatmap, nil
+}
+
+// GetTopConsumers returns the clients with the most requests in the last N days,
+// compared against the preceding window of the same length
+func (s *apiUsageAnalyticsService) GetTopConsumers(dimension string, days int, limit int) (*TopConsumersDTO, error) {
+	to := time.Now()
+	from := to.AddDate(0, 0, -days)
+	previousFrom := from.AddDate(0, 0, -days)
 
-		avgResponseTime := int64(0)
-		if len(*logs) > 0 {
-			avgResponseTime = totalResponseTime / int64(len(*logs))
+	current, err := s.logRepo.AggregateByClient(dimension, from, to, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate client usage: %w", err)
+	}
+
+	result := &TopConsumersDTO{
+		Dimension: dimension,
+		Days:      days,
+		From:      from,
+		To:        to,
+		Consumers: make([]ConsumerUsageDTO, 0),
+	}
+	if current == nil || len(*current) == 0 {
+		return result, nil
+	}
+
+	identities := make([]string, 0, len(*current))
+	for _, aggregate := range *current {
+		identities = append(identities, aggregate.Identity)
+	}
+
+	previousByIdentity := make(map[string]api_usage.ClientUsageAggregate)
+	previous, err := s.logRepo.AggregateForClients(dimension, identities, previousFrom, from)
+	if err != nil {
+		logger.Warn("Failed to aggregate previous window client usage", zap.Error(err))
+	} else if previous != nil {
+		for _, aggregate := range *previous {
+			previousByIdentity[aggregate.Identity] = aggregate
 		}
+	}
 
-		trends = append(trends, UsageTrendDTO{
-			Date:            startOfDay,
-			RequestCount:    int64(len(*logs)),
-			SuccessCount:    successCount,
-			ErrorCount:      errorCount,
-			AvgResponseTime: avgResponseTime,
-		})
+	for _, aggregate := range *current {
+		consumer := ConsumerUsageDTO{
+			Identity:            aggregate.Identity,
+			TotalRequests:       aggregate.TotalRequests,
+			ErrorRequests:       aggregate.ErrorRequests,
+			RateLimitedRequests: aggregate.RateLimitedRequests,
+			AvgResponseTime:     int64(aggregate.AvgResponseTime),
+			ErrorRate:           percentage(aggregate.ErrorRequests, aggregate.TotalRequests),
+		}
+
+		if prev, ok := previousByIdentity[aggregate.Identity]; ok && prev.TotalRequests > 0 {
+			consumer.PreviousRequests = prev.TotalRequests
+			change := float64(aggregate.TotalRequests-prev.TotalRequests) / float64(prev.TotalRequests) * 100
+			consumer.RequestChange = &change
+			errorRateChange := consumer.ErrorRate - percentage(prev.ErrorRequests, prev.TotalRequests)
+			consumer.ErrorRateChange = &errorRateChange
+		}
+
+		result.Consumers = append(result.Consumers, consumer)
 	}
 
-	return &trends, nil
+	return result, nil
+}
+
+// GetMonthlyUsageReport returns per-user consumption for the calendar month containing month
+func (s *apiUsageAnalyticsService) GetMonthlyUsageReport(month time.Time) (*UsageReportDTO, error) {
+	from := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, month.Location())
+	to := from.AddDate(0, 1, 0)
+
+	aggregates, err := s.logRepo.AggregateByClient(api_usage.ClientDimensionUser, from, to, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate monthly usage: %w", err)
+	}
+
+	report := &UsageReportDTO{
+		Month:     from.Format("2006-01"),
+		Dimension: api_usage.ClientDimensionUser,
+		From:      from,
+		To:        to,
+		Rows:      make([]UsageReportRowDTO, 0),
+	}
+	if aggregates == nil {
+		return report, nil
+	}
+
+	for _, aggregate := range *aggregates {
+		row := UsageReportRowDTO{
+			Identity:      aggregate.Identity,
+			TotalRequests: aggregate.TotalRequests,
+			ErrorRequests: aggregate.ErrorRequests,
+			RequestBytes:  aggregate.RequestBytes,
+			ResponseBytes: aggregate.ResponseBytes,
+		}
+		report.Rows = append(report.Rows, row)
+
+		report.Totals.TotalRequests += row.TotalRequests
+		report.Totals.ErrorRequests += row.ErrorRequests
+		report.Totals.RequestBytes += row.RequestBytes
+		report.Totals.ResponseBytes += row.ResponseBytes
+	}
+
+	return report, nil
+}
+
+// percentage returns part/total as a percentage, or 0 when total is 0
+func percentage(part int64, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total) * 100
 }
 
 // GetUserActivitySummary returns activity summary for a specific user
@@ -265,7 +484,7 @@ func (s *apiUsageAnalyticsService) GetUserActivitySummary(userID string) (*UserA
 	for _, log := range *logs {
 		endpointMap[log.Endpoint]++
 		totalResponseTime += log.ResponseTime
-		if log.StatusCode >= 200 && log.StatusCode < 300 {
+		if log.IsSuccess() {
 			successCount++
 		} else {
 			errorCount++
@@ -389,23 +608,159 @@ func getMostUsedEndpoint(endpointMap map[string]int64) string {
 	return mostUsed
 }
 
+func toErrorSampleDTO(log api_usage.APIUsageLog) ErrorSampleDTO {
+	sample := ErrorSampleDTO{
+		RequestID:    log.RequestID,
+		StatusCode:   log.StatusCode,
+		RequestedAt:  log.RequestedAt,
+		APIKeyID:     log.APIKeyID,
+		ClientIP:     log.ClientIP,
+		ResponseTime: log.ResponseTime,
+	}
+	if log.UserID != nil {
+		sample.UserID = *log.UserID
+	}
+	if log.ErrorMessage != nil {
+		sample.ErrorMessage = *log.ErrorMessage
+	}
+	return sample
+}
+
+// toStatusCodeDTOs computes each status code's share of the endpoint's requests
+func toStatusCodeDTOs(counts []api_usage.APIUsageStatusCount) []StatusCodeDTO {
+	var total int64
+	for _, count := range counts {
+		total += count.Requests
+	}
+
+	result := make([]StatusCodeDTO, 0, len(counts))
+	for _, count := range counts {
+		percentage := 0.0
+		if total > 0 {
+			percentage = float64(count.Requests) / float64(total) * 100
+		}
+		result = append(result, StatusCodeDTO{
+			StatusCode:      count.StatusCode,
+			Requests:        count.Requests,
+			Percentage:      percentage,
+			AvgResponseTime: int64(count.AvgResponseTime),
+			IsError:         !(api_usage.APIUsageLog{StatusCode: count.StatusCode}).IsSuccess(),
+		})
+	}
+	return result
+}
+
+func toBandwidthDTO(stats *api_usage.APIUsageStats) BandwidthDTO {
+	bandwidth := BandwidthDTO{
+		TotalRequestBytes:  stats.TotalRequestBytes,
+		TotalResponseBytes: stats.TotalResponseBytes,
+		MaxRequestBytes:    stats.MaxRequestBytes,
+	}
+	if stats.TotalRequests > 0 {
+		bandwidth.AvgRequestBytes = stats.TotalRequestBytes / stats.TotalRequests
+		bandwidth.AvgResponseBytes = stats.TotalResponseBytes / stats.TotalRequests
+	}
+	return bandwidth
+}
+
+// toCacheabilityDTO derives client caching metrics and a hint for an endpoint
+func toCacheabilityDTO(stats *api_usage.APIUsageStats) CacheabilityDTO {
+	cacheability := CacheabilityDTO{
+		ConditionalRequests:   stats.ConditionalRequests,
+		NotModifiedResponses:  stats.NotModifiedResponses,
+		UncachedResponseBytes: stats.UncachedResponseBytes,
+		ValidatorRate:         percentage(stats.ValidatorResponses, stats.TotalRequests),
+		ConditionalRate:       percentage(stats.ConditionalRequests, stats.TotalRequests),
+		NotModifiedRate:       percentage(stats.NotModifiedResponses, stats.ConditionalRequests),
+	}
+
+	switch {
+	case stats.TotalRequests == 0:
+	case stats.Method != "GET" && stats.Method != "HEAD":
+		cacheability.Hint = "Only GET and HEAD responses can be cached by clients"
+	case cacheability.ValidatorRate < 50:
+		cacheability.Hint = "Most responses carry no ETag or Last-Modified header, so clients cannot revalidate. Adding one is the first step."
+	case cacheability.ConditionalRate < 20:
+		cacheability.Hint = "Responses carry validators but clients rarely send If-None-Match. Check client HTTP caching."
+	case cacheability.NotModifiedRate >= 50:
+		cacheability.Hint = "Most revalidations return 304. A Cache-Control max-age would let clients skip the request entirely."
+	}
+	return cacheability
+}
+
 // DTOs for API responses
 
 // EndpointDetailsDTO contains detailed information about an endpoint
 type EndpointDetailsDTO struct {
-	Endpoint        string                   `json:"endpoint"`
-	Method          string                   `json:"method"`
-	TotalRequests   int64                    `json:"total_requests"`
-	SuccessRequests int64                    `json:"success_requests"`
-	ErrorRequests   int64                    `json:"error_requests"`
-	SuccessRate     float64                  `json:"success_rate"`
-	ErrorRate       float64                  `json:"error_rate"`
-	AvgResponseTime int64                    `json:"avg_response_time_ms"`
-	MinResponseTime int64                    `json:"min_response_time_ms"`
-	MaxResponseTime int64                    `json:"max_response_time_ms"`
-	Last24Hours     int64                    `json:"last_24_hours"`
-	LastAccessedAt  time.Time                `json:"last_accessed_at"`
-	RecentLogs      *[]api_usage.APIUsageLog `json:"recent_logs,omitempty"`
+	Endpoint        string           `json:"endpoint"`
+	Method          string           `json:"method"`
+	TotalRequests   int64            `json:"total_requests"`
+	SuccessRequests int64            `json:"success_requests"`
+	ErrorRequests   int64            `json:"error_requests"`
+	SuccessRate     float64          `json:"success_rate"`
+	ErrorRate       float64          `json:"error_rate"`
+	AvgResponseTime int64            `json:"avg_response_time_ms"`
+	MinResponseTime int64            `json:"min_response_time_ms"`
+	MaxResponseTime int64            `json:"max_response_time_ms"`
+	Last24Hours     int64            `json:"last_24_hours"`
+	LastAccessedAt  time.Time        `json:"last_accessed_at"`
+	RecentErrors    []ErrorSampleDTO `json:"recent_errors"`
+	StatusCodes     []StatusCodeDTO  `json:"status_codes"`
+	Bandwidth       BandwidthDTO     `json:"bandwidth"`
+	Cacheability    CacheabilityDTO  `json:"cacheability"`
+}
+
+// CacheabilityDTO contains conditional request (If-None-Match/304) metrics for an endpoint.
+// Rates are percentages; NotModifiedRate is relative to conditional requests.
+type CacheabilityDTO struct {
+	ConditionalRequests   int64   `json:"conditional_requests"`
+	NotModifiedResponses  int64   `json:"not_modified_responses"`
+	UncachedResponseBytes int64   `json:"uncached_response_bytes"`
+	ValidatorRate         float64 `json:"validator_rate"`
+	ConditionalRate       float64 `json:"conditional_rate"`
+	NotModifiedRate       float64 `json:"not_modified_rate"`
+	Hint                  string  `json:"hint,omitempty"`
+}
+
+// ErrorSampleDTO is one recent failed request to an endpoint
+type ErrorSampleDTO struct {
+	RequestID    string    `json:"request_id"`
+	StatusCode   int       `json:"status_code"`
+	RequestedAt  time.Time `json:"requested_at"`
+	UserID       string    `json:"user_id,omitempty"`
+	APIKeyID     string    `json:"api_key_id,omitempty"`
+	ClientIP     string    `json:"client_ip"`
+	ResponseTime int64     `json:"response_time_ms"`
+	ErrorMessage string    `json:"error_message,omitempty"`
+}
+
+// StatusCodeDTO is the share of an endpoint's requests answered with one status code
+type StatusCodeDTO struct {
+	StatusCode      int     `json:"status_code"`
+	Requests        int64   `json:"requests"`
+	Percentage      float64 `json:"percentage"`
+	AvgResponseTime int64   `json:"avg_response_time_ms"`
+	IsError         bool    `json:"is_error"`
+}
+
+// BandwidthDTO contains request/response byte totals for an endpoint
+type BandwidthDTO struct {
+	TotalRequestBytes  int64 `json:"total_request_bytes"`
+	TotalResponseBytes int64 `json:"total_response_bytes"`
+	AvgRequestBytes    int64 `json:"avg_request_bytes"`
+	AvgResponseBytes   int64 `json:"avg_response_bytes"`
+	MaxRequestBytes    int64 `json:"max_request_bytes"`
+}
+
+// EndpointRankingsDTO is a page of endpoint rankings
+type EndpointRankingsDTO struct {
+	Rankings    []api_usage.APIEndpointRanking `json:"rankings"`
+	Total       int64                          `json:"total"`
+	SortBy      string                         `json:"sort_by"`
+	Order       string                         `json:"order"`
+	MinRequests int64                          `json:"min_requests"`
+	Limit       int                            `json:"limit"`
+	Offset      int                            `json:"offset"`
 }
 
 // UsageSummaryDTO contains overall usage summary
@@ -431,6 +786,67 @@ type UsageTrendDTO struct {
 	AvgResponseTime int64     `json:"avg_response_time_ms"`
 }
 
+// LatencyHeatmapDTO contains request volume and latency bucketed by weekday and hour
+type LatencyHeatmapDTO struct {
+	Days               int              `json:"days"`
+	Since              time.Time        `json:"since"`
+	Cells              []HeatmapCellDTO `json:"cells"`
+	MaxRequestCount    int64            `json:"max_request_count"`
+	MaxAvgResponseTime int64            `json:"max_avg_response_time_ms"`
+	PeakWeekday        int              `json:"peak_weekday"`
+	PeakHour           int              `json:"peak_hour"`
+}
+
+// HeatmapCellDTO is a single weekday/hour bucket (weekday 0 = Sunday)
+type HeatmapCellDTO struct {
+	Weekday         int   `json:"weekday"`
+	Hour            int   `json:"hour"`
+	RequestCount    int64 `json:"request_count"`
+	AvgResponseTime int64 `json:"avg_response_time_ms"`
+}
+
+// TopConsumersDTO contains the top clients for a dimension over a window
+type TopConsumersDTO struct {
+	Dimension string             `json:"dimension"`
+	Days      int                `json:"days"`
+	From      time.Time          `json:"from"`
+	To        time.Time          `json:"to"`
+	Consumers []ConsumerUsageDTO `json:"consumers"`
+}
+
+// ConsumerUsageDTO contains usage for a single client and its change versus the previous window.
+// RequestChange and ErrorRateChange are nil when the client had no traffic in the previous window.
+type ConsumerUsageDTO struct {
+	Identity            string   `json:"identity"`
+	TotalRequests       int64    `json:"total_requests"`
+	ErrorRequests       int64    `json:"error_requests"`
+	ErrorRate           float64  `json:"error_rate"`
+	RateLimitedRequests int64    `json:"rate_limited_requests"`
+	AvgResponseTime     int64    `json:"avg_response_time_ms"`
+	PreviousRequests    int64    `json:"previous_requests"`
+	RequestChange       *float64 `json:"request_change_percent"`
+	ErrorRateChange     *float64 `json:"error_rate_change"`
+}
+
+// UsageReportDTO is a monthly consumption report used for billing/chargeback
+type UsageReportDTO struct {
+	Month     string              `json:"month"`
+	Dimension string              `json:"dimension"`
+	From      time.Time           `json:"from"`
+	To        time.Time           `json:"to"`
+	Rows      []UsageReportRowDTO `json:"rows"`
+	Totals    UsageReportRowDTO   `json:"totals"`
+}
+
+// UsageReportRowDTO is one client's consumption within a usage report
+type UsageReportRowDTO struct {
+	Identity      string `json:"identity,omitempty"`
+	TotalRequests int64  `json:"total_requests"`
+	ErrorRequests int64  `json:"error_requests"`
+	RequestBytes  int64  `json:"request_bytes"`
+	ResponseBytes int64  `json:"response_bytes"`
+}
+
 // UserActivityDTO contains user activity summary
 type UserActivityDTO struct {
 	UserID                  string `json:"user_id"`
@@ -447,4 +863,6 @@ type CallerDTO struct {
 	UserID     string    `json:"user_id"`
 	TotalCalls int64     `json:"total_calls"`
 	LastCall   time.Time `json:"last_call"`
+	// User is the resolved caller, when looked up
+	User *UserSummaryDTO `json:"user,omitempty"`
 }
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	apperrors "github.com/aruncs31s/azf/shared/errors"
+	"github.com/aruncs31s/azf/shared/logger"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// DefaultApprovalTTL is how long an approval request stays valid
+const DefaultApprovalTTL = 15 * time.Minute
+
+// Approval request statuses
+const (
+	ApprovalPending  = "PENDING"
+	ApprovalApproved = "APPROVED"
+	ApprovalRejected = "REJECTED"
+	ApprovalConsumed = "CONSUMED"
+)
+
+// ApprovalRequest asks a second superadmin to confirm an action of an admin
+type ApprovalRequest struct {
+	ID          string     `json:"id"`
+	Action      string     `json:"action"`
+	Target      string     `json:"target"`
+	Reason      string     `json:"reason"`
+	RequestedBy string     `json:"requested_by"`
+	Status      string     `json:"status"`
+	DecidedBy   string     `json:"decided_by,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	DecidedAt   *time.Time `json:"decided_at,omitempty"`
+}
+
+// ApprovalPendingError is returned when an action needs an approval that has
+// not been given yet
+type ApprovalPendingError struct {
+	Approval ApprovalRequest
+}
+
+func (e *ApprovalPendingError) Error() string {
+	return fmt.Sprintf("another superadmin must approve request %s before you can %s %s",
+		e.Approval.ID, e.Approval.Action, e.Approval.Target)
+}
+
+// ApprovalService is the two-person approval workflow for dangerous admin
+// actions. The admin requesting an action cannot approve it; another
+// superadmin has to, after which the requester may perform it once.
+type ApprovalService interface {
+	// Request returns the open approval request of requestedBy for the action
+	// on target, creating a pending one if there is none
+	Request(requestedBy, action, target, reason string) ApprovalRequest
+	// Approved returns the approved, unused request of requestedBy for the
+	// action on target
+	Approved(requestedBy, action, target string) (*ApprovalRequest, bool)
+	// Consume marks an approved request as used
+	Consume(id string) error
+	Approve(id, approver string) (*ApprovalRequest, error)
+	Reject(id, approver string) (*ApprovalRequest, error)
+	// ListOpen returns the pending and approved requests, newest first
+	ListOpen() []ApprovalRequest
+}
+
+// approvalService implements ApprovalService in memory
+type approvalService struct {
+	superadmins map[string]bool
+	ttl         time.Duration
+
+	mu       sync.Mutex
+	requests map[string]*ApprovalRequest
+}
+
+// NewApprovalService creates an approval workflow decided by superadmins.
+// Requests expire after ttl, DefaultApprovalTTL when ttl is not positive.
+func NewApprovalService(superadmins []string, ttl time.Duration) ApprovalService {
+	if ttl <= 0 {
+		ttl = DefaultApprovalTTL
+	}
+	allowed := make(map[string]bool, len(superadmins))
+	for _, username := range superadmins {
+		allowed[username] = true
+	}
+	return &approvalService{
+		superadmins: allowed,
+		ttl:         ttl,
+		requests:    make(map[string]*ApprovalRequest),
+	}
+}
+
+func (s *approvalService) Request(requestedBy, action, target, reason string) ApprovalRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pruneLocked()
+
+	if existing := s.findOpenLocked(requestedBy, action, target); existing != nil {
+		return *existing
+	}
+
+	now := time.Now()
+	request := &ApprovalRequest{
+		ID:          uuid.NewString(),
+		Action:      action,
+		Target:      target,
+		Reason:      reason,
+		RequestedBy: requestedBy,
+		Status:      ApprovalPending,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.ttl),
+	}
+	s.requests[request.ID] = request
+	logger.Warn("Approval requested",
+		zap.String("approval_id", request.ID),
+		zap.String("action", action),
+		zap.String("target", target),
+		zap.String("requested_by", requestedBy))
+	return *request
+}
+
+func (s *approvalService) Approved(requestedBy, action, target string) (*ApprovalRequest, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pruneLocked()
+
+	request := s.findOpenLocked(requestedBy, action, target)
+	if request == nil || request.Status != ApprovalApproved {
+		return nil, false
+	}
+	approved := *request
+	return &approved, true
+}
+
+func (s *approvalService) Consume(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	request, ok := s.requests[id]
+	if !ok || request.Status != ApprovalApproved {
+		return apperrors.Newf(apperrors.ErrNotFound, "approved request %s not found", id)
+	}
+	request.Status = ApprovalConsumed
+	return nil
+}
+
+func (s *approvalService) Approve(id, approver string) (*ApprovalRequest, error) {
+	return s.decide(id, approver, ApprovalApproved)
+}
+
+func (s *approvalService) Reject(id, approver string) (*ApprovalRequest, error) {
+	return s.decide(id, approver, ApprovalRejected)
+}
+
+// decide approves or rejects a pending request. Only a superadmin other than
+// the requester may do so.
+func (s *approvalService) decide(id, approver, status string) (*ApprovalRequest, error) {
+	if approver == "" || !s.superadmins[approver] {
+		return nil, apperrors.Newf(apperrors.ErrForbidden, "only a superadmin can decide approval requests")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pruneLocked()
+
+	request, ok := s.requests[id]
+	if !ok {
+		return nil, apperrors.Newf(apperrors.ErrNotFound, "approval request %s not found", id)
+	}
+	if request.Status != ApprovalPending {
+		return nil, apperrors.Newf(apperrors.ErrConflict, "approval request %s is already %s", id, request.Status)
+	}
+	if request.RequestedBy == approver {
+		return nil, apperrors.Newf(apperrors.ErrForbidden, "approval request %s must be decided by another superadmin", id)
+	}
+
+	now := time.Now()
+	request.Status = status
+	request.DecidedBy = approver
+	request.DecidedAt = &now
+	// The requester gets a full TTL to act on an approval
+	if status == ApprovalApproved {
+		request.ExpiresAt = now.Add(s.ttl)
+	}
+	logger.Warn("Approval request decided",
+		zap.String("approval_id", id),
+		zap.String("status", status),
+		zap.String("decided_by", approver))
+
+	decided := *request
+	return &decided, nil
+}
+
+func (s *approvalService) ListOpen() []ApprovalRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pruneLocked()
+
+	open := make([]ApprovalRequest, 0, len(s.requests))
+	for _, request := range s.requests {
+		if request.Status == ApprovalPending || request.Status == ApprovalApproved {
+			open = append(open, *request)
+		}
+	}
+	sort.Slice(open, func(i, j int) bool {
+		return open[i].CreatedAt.After(open[j].CreatedAt)
+	})
+	return open
+}
+
+// findOpenLocked returns the pending or approved request for the action
+func (s *approvalService) findOpenLocked(requestedBy, action, target string) *ApprovalRequest {
+	for _, request := range s.requests {
+		if request.RequestedBy == requestedBy && request.Action == action && request.Target == target &&
+			(request.Status == ApprovalPending || request.Status == ApprovalApproved) {
+			return request
+		}
+	}
+	return nil
+}
+
+// pruneLocked drops expired requests
+func (s *approvalService) pruneLocked() {
+	now := time.Now()
+	for id, request := range s.requests {
+		if now.After(request.ExpiresAt) {
+			delete(s.requests, id)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/aruncs31s/azf/infrastructure/token"
 	"github.com/golang-jwt/jwt/v5"
 )
 
@@ -20,8 +21,8 @@ const (
 
 // JWT-related errors
 var (
-	ErrJWTSecretNotSet   = errors.New("JWT_SECRET environment variable is not set")
-	ErrJWTSecretTooShort = errors.New("JWT_SECRET must be at least 32 characters")
+	ErrJWTSecretNotSet   = token.ErrSecretNotSet
+	ErrJWTSecretTooShort = token.ErrSecretTooShort
 	ErrInvalidToken      = errors.New("invalid token")
 	ErrTokenExpired      = errors.New("token has expired")
 )
@@ -41,12 +42,7 @@ func GetJWTSecret() (string, error) {
 // GenerateToken generates a JWT token with the given claims
 // Uses secure defaults for token expiry
 func GenerateToken(claims map[string]any) (string, error) {
-	secret, err := GetJWTSecret()
-	if err != nil {
-		return "", err
-	}
-	jwtClaims := MapToClaims(claims)
-	return GenerateJWT(secret, jwtClaims, DefaultAccessTokenExpiry)
+	return GenerateTokenWithExpiry(claims, DefaultAccessTokenExpiry)
 }
 
 // GenerateAccessToken generates a short-lived access token
@@ -59,14 +55,14 @@ func GenerateRefreshToken(claims map[string]any) (string, error) {
 	return GenerateTokenWithExpiry(claims, DefaultRefreshTokenExpiry)
 }
 
-// GenerateTokenWithExpiry generates a token with custom expiry
+// GenerateTokenWithExpiry generates a token with custom expiry, signed by
+// the configured token provider
 func GenerateTokenWithExpiry(claims map[string]any, expiry time.Duration) (string, error) {
-	secret, err := GetJWTSecret()
+	provider, err := token.Default()
 	if err != nil {
 		return "", err
 	}
-	jwtClaims := MapToClaims(claims)
-	return GenerateJWT(secret, jwtClaims, expiry)
+	return provider.Sign(withExpiry(MapToClaims(claims), expiry))
 }
 
 func GetEnv(key, fallback string) string {
@@ -82,36 +78,27 @@ func GenerateJWT(secret string, claims jwt.MapClaims, expiry time.Duration) (str
 	if claims == nil {
 		claims = jwt.MapClaims{}
 	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, withExpiry(claims, expiry))
+	return token.SignedString([]byte(secret))
+}
+
+// withExpiry sets the issue time of claims to now and their expiry after expiry
+func withExpiry(claims jwt.MapClaims, expiry time.Duration) jwt.MapClaims {
 	now := time.Now()
 	claims["exp"] = now.Add(expiry).Unix()
 	claims["iat"] = now.Unix()
 	claims["nbf"] = now.Unix()
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
+	return claims
 }
 
-// ValidateJWT validates the token string using the secret and returns the claims
+// ValidateJWT validates the token string with the configured token provider
+// and returns the claims
 func ValidateJWT(tokenString string) (jwt.MapClaims, error) {
-	secret, err := GetJWTSecret()
-	if err != nil {
-		return nil, err
-	}
-
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, ErrInvalidToken
-		}
-		return []byte(secret), nil
-	})
+	provider, err := token.Default()
 	if err != nil {
 		return nil, err
 	}
-
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		return claims, nil
-	}
-	return nil, ErrInvalidToken
+	return provider.Verify(tokenString)
 }
 
 // MapToClaims converts a map to jwt.MapClaims
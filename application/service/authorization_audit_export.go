@@ -0,0 +1,310 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aruncs31s/azf/infrastructure/enterprise"
+	"github.com/aruncs31s/azf/internal/parquet"
+	apperrors "github.com/aruncs31s/azf/shared/errors"
+)
+
+// AuditExportFormat is a file format audit logs can be exported in
+type AuditExportFormat string
+
+const (
+	AuditExportCSV     AuditExportFormat = "csv"
+	AuditExportJSONL   AuditExportFormat = "jsonl"
+	AuditExportParquet AuditExportFormat = "parquet"
+)
+
+// AuditExportBatchSize is the number of logs read and written per chunk
+const AuditExportBatchSize = 1000
+
+// ParseAuditExportFormat returns the export format named by value
+func ParseAuditExportFormat(value string) (AuditExportFormat, error) {
+	switch format := AuditExportFormat(strings.ToLower(value)); format {
+	case AuditExportCSV, AuditExportJSONL, AuditExportParquet:
+		return format, nil
+	default:
+		return "", apperrors.Newf(apperrors.ErrValidation, "format must be csv, jsonl or parquet")
+	}
+}
+
+// ContentType returns the MIME type of the format
+func (f AuditExportFormat) ContentType() string {
+	switch f {
+	case AuditExportJSONL:
+		return "application/x-ndjson"
+	case AuditExportParquet:
+		return "application/vnd.apache.parquet"
+	default:
+		return "text/csv"
+	}
+}
+
+// AuditLogFilter selects audit logs; empty fields and zero times match all.
+// Field and Value select logs whose custom audit field equals Value.
+type AuditLogFilter struct {
+	RequestID string
+	UserID    string
+	Result    string
+	Resource  string
+	Field     string
+	Value     string
+	Start     time.Time
+	End       time.Time
+}
+
+// AuditExportFilter selects the audit logs to export
+type AuditExportFilter = AuditLogFilter
+
+// validate checks the result and that the time range is not reversed
+func (f AuditLogFilter) validate() error {
+	if f.Result != "" {
+		validResults := map[string]bool{"ALLOWED": true, "DENIED": true, "WARNING": true}
+		if !validResults[f.Result] {
+			return apperrors.Newf(apperrors.ErrValidation, "invalid result: %s", f.Result)
+		}
+	}
+	if !f.Start.IsZero() && !f.End.IsZero() && f.Start.After(f.End) {
+		return apperrors.Newf(apperrors.ErrValidation, "start time cannot be after end time")
+	}
+	return nil
+}
+
+// repositoryFilter returns the filter as the audit repository takes it
+func (f AuditLogFilter) repositoryFilter() enterprise.AuditLogFilter {
+	return enterprise.AuditLogFilter{
+		RequestID:  f.RequestID,
+		UserID:     f.UserID,
+		Result:     f.Result,
+		Resource:   f.Resource,
+		Field:      f.Field,
+		FieldValue: f.Value,
+		Start:      f.Start,
+		End:        f.End,
+	}
+}
+
+// auditExportColumns are the exported fields, in order
+var auditExportColumns = []parquet.Column{
+	{Name: "id", Type: parquet.String},
+	{Name: "timestamp", Type: parquet.Timestamp},
+	{Name: "user_id", Type: parquet.String},
+	{Name: "role", Type: parquet.String},
+	{Name: "resource", Type: parquet.String},
+	{Name: "action", Type: parquet.String},
+	{Name: "result", Type: parquet.String},
+	{Name: "denial_reason", Type: parquet.String},
+	{Name: "ip_address", Type: parquet.String},
+	{Name: "user_agent", Type: parquet.String},
+	{Name: "api_version", Type: parquet.String},
+	{Name: "deprecated", Type: parquet.Boolean},
+	{Name: "environment", Type: parquet.String},
+	{Name: "rate_limit_status", Type: parquet.String},
+	{Name: "policy_version", Type: parquet.Int64},
+	{Name: "execution_time_ms", Type: parquet.Double},
+	{Name: "occurrence_count", Type: parquet.Int64},
+	{Name: "last_seen_at", Type: parquet.Timestamp},
+	{Name: "request_id", Type: parquet.String},
+	{Name: "error_message", Type: parquet.String},
+	{Name: "authorization_mode", Type: parquet.String},
+	{Name: "metadata", Type: parquet.String},
+}
+
+// ExportAuditLogs writes the logs matching filter to w, newest first, and
+// returns how many were written. Logs are read and written in chunks of
+// AuditExportBatchSize; w is flushed after each chunk when it supports it.
+func (s *authorizationAuditService) ExportAuditLogs(ctx context.Context, w io.Writer, format AuditExportFormat, filter AuditExportFilter) (int64, error) {
+	if err := filter.validate(); err != nil {
+		return 0, err
+	}
+
+	encoder, err := newAuditLogEncoder(w, format)
+	if err != nil {
+		return 0, err
+	}
+
+	var written int64
+	err = s.auditRepo.StreamLogs(ctx, filter.repositoryFilter(), AuditExportBatchSize, func(logs []*enterprise.AuthorizationAuditLogDB) error {
+		for _, log := range logs {
+			if err := encoder.encode(s.convertToDTO(log)); err != nil {
+				return err
+			}
+		}
+		written += int64(len(logs))
+		if err := encoder.flush(); err != nil {
+			return err
+		}
+		if flusher, ok := w.(interface{ Flush() }); ok {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return written, fmt.Errorf("failed to export audit logs: %w", err)
+	}
+	if err := encoder.close(); err != nil {
+		return written, fmt.Errorf("failed to export audit logs: %w", err)
+	}
+	return written, nil
+}
+
+// auditLogEncoder writes audit logs in an export format
+type auditLogEncoder interface {
+	encode(log AuditLogDTO) error
+	// flush writes out the logs encoded since the last flush
+	flush() error
+	// close completes the file
+	close() error
+}
+
+func newAuditLogEncoder(w io.Writer, format AuditExportFormat) (auditLogEncoder, error) {
+	switch format {
+	case AuditExportCSV:
+		encoder := &csvAuditLogEncoder{writer: csv.NewWriter(w)}
+		header := make([]string, len(auditExportColumns))
+		for i, column := range auditExportColumns {
+			header[i] = column.Name
+		}
+		if err := encoder.writer.Write(header); err != nil {
+			return nil, err
+		}
+		return encoder, nil
+	case AuditExportJSONL:
+		return &jsonlAuditLogEncoder{encoder: json.NewEncoder(w)}, nil
+	case AuditExportParquet:
+		return &parquetAuditLogEncoder{writer: parquet.NewWriter(w, auditExportColumns)}, nil
+	default:
+		return nil, apperrors.Newf(apperrors.ErrValidation, "unsupported export format: %s", format)
+	}
+}
+
+// csvAuditLogEncoder writes one CSV row per log; metadata is a JSON object
+type csvAuditLogEncoder struct {
+	writer *csv.Writer
+}
+
+func (e *csvAuditLogEncoder) encode(log AuditLogDTO) error {
+	lastSeenAt := ""
+	if log.LastSeenAt != nil {
+		lastSeenAt = log.LastSeenAt.UTC().Format(time.RFC3339Nano)
+	}
+	return e.writer.Write([]string{
+		log.ID,
+		log.Timestamp.UTC().Format(time.RFC3339Nano),
+		log.UserID,
+		log.Role,
+		log.Resource,
+		log.Action,
+		log.Result,
+		log.DenialReason,
+		log.IPAddress,
+		log.UserAgent,
+		log.APIVersion,
+		strconv.FormatBool(log.Deprecated),
+		log.Environment,
+		log.RateLimitStatus,
+		strconv.Itoa(log.PolicyVersion),
+		strconv.FormatFloat(log.ExecutionTimeMs, 'f', -1, 64),
+		strconv.FormatInt(log.OccurrenceCount, 10),
+		lastSeenAt,
+		log.RequestID,
+		log.ErrorMessage,
+		log.AuthMode,
+		auditMetadataJSON(log.Metadata),
+	})
+}
+
+func (e *csvAuditLogEncoder) flush() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+func (e *csvAuditLogEncoder) close() error {
+	return e.flush()
+}
+
+// jsonlAuditLogEncoder writes one JSON object per line
+type jsonlAuditLogEncoder struct {
+	encoder *json.Encoder
+}
+
+func (e *jsonlAuditLogEncoder) encode(log AuditLogDTO) error {
+	return e.encoder.Encode(log)
+}
+
+func (e *jsonlAuditLogEncoder) flush() error { return nil }
+
+func (e *jsonlAuditLogEncoder) close() error { return nil }
+
+// parquetAuditLogEncoder writes each flushed chunk as a row group
+type parquetAuditLogEncoder struct {
+	writer *parquet.Writer
+}
+
+func (e *parquetAuditLogEncoder) encode(log AuditLogDTO) error {
+	var lastSeenAt any
+	if log.LastSeenAt != nil {
+		lastSeenAt = *log.LastSeenAt
+	}
+	return e.writer.Write([]any{
+		log.ID,
+		log.Timestamp,
+		log.UserID,
+		log.Role,
+		log.Resource,
+		log.Action,
+		log.Result,
+		optionalString(log.DenialReason),
+		log.IPAddress,
+		log.UserAgent,
+		log.APIVersion,
+		log.Deprecated,
+		log.Environment,
+		log.RateLimitStatus,
+		int64(log.PolicyVersion),
+		log.ExecutionTimeMs,
+		log.OccurrenceCount,
+		lastSeenAt,
+		optionalString(log.RequestID),
+		optionalString(log.ErrorMessage),
+		optionalString(log.AuthMode),
+		optionalString(auditMetadataJSON(log.Metadata)),
+	})
+}
+
+func (e *parquetAuditLogEncoder) flush() error {
+	return e.writer.Flush()
+}
+
+func (e *parquetAuditLogEncoder) close() error {
+	return e.writer.Close()
+}
+
+// optionalString returns nil for an empty value, so it is exported as null
+func optionalString(value string) any {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+// auditMetadataJSON encodes custom audit fields, or "" when there are none
+func auditMetadataJSON(metadata map[string]interface{}) string {
+	if len(metadata) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
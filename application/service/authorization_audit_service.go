@@ -2,7 +2,9 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"time"
 
 	"github.com/aruncs31s/azf/infrastructure/enterprise"
@@ -12,15 +14,26 @@ import (
 
 // AuthorizationAuditService provides business logic for authorization audit logs
 type AuthorizationAuditService interface {
-	GetAuditLogs(limit int, offset int) (*[]AuditLogDTO, error)
-	GetAuditLogsByUser(userID string, limit int, offset int) (*[]AuditLogDTO, error)
-	GetAuditLogsByResult(result string, limit int, offset int) (*[]AuditLogDTO, error)
-	GetAuditLogsByTimeRange(startTime, endTime time.Time, limit int, offset int) (*[]AuditLogDTO, error)
-	GetAuditLogsByResource(resource string, limit int, offset int) (*[]AuditLogDTO, error)
-	GetDeniedAccessLogs(limit int, offset int) (*[]AuditLogDTO, error)
-	GetAuditSummary() (*AuditSummaryDTO, error)
-	GetCriticalEvents(limit int, offset int) (*[]AuditLogDTO, error)
-	CleanupOldLogs(olderThan time.Duration) (int64, error)
+	GetAuditLogs(ctx context.Context, limit int, offset int) (*[]AuditLogDTO, error)
+	GetAuditLogsByUser(ctx context.Context, userID string, limit int, offset int) (*[]AuditLogDTO, error)
+	GetAuditLogsByResult(ctx context.Context, result string, limit int, offset int) (*[]AuditLogDTO, error)
+	GetAuditLogsByTimeRange(ctx context.Context, startTime, endTime time.Time, limit int, offset int) (*[]AuditLogDTO, error)
+	GetAuditLogsByResource(ctx context.Context, resource string, limit int, offset int) (*[]AuditLogDTO, error)
+	// GetAuditLogsByRequestID returns the logs written for a request
+	GetAuditLogsByRequestID(ctx context.Context, requestID string, limit int, offset int) (*[]AuditLogDTO, error)
+	// GetAuditLogsByField returns logs whose custom audit field equals value
+	GetAuditLogsByField(ctx context.Context, field string, value string, limit int, offset int) (*[]AuditLogDTO, error)
+	// GetAuditFields returns the registered custom audit fields and their types
+	GetAuditFields() map[string]string
+	GetDeniedAccessLogs(ctx context.Context, limit int, offset int) (*[]AuditLogDTO, error)
+	// FindAuditLogs returns the logs matching every condition of filter
+	FindAuditLogs(ctx context.Context, filter AuditLogFilter, limit int, offset int) (*[]AuditLogDTO, error)
+	// GetAuditSummary returns statistics of the logs matching filter
+	GetAuditSummary(ctx context.Context, filter AuditLogFilter) (*AuditSummaryDTO, error)
+	GetCriticalEvents(ctx context.Context, limit int, offset int) (*[]AuditLogDTO, error)
+	CleanupOldLogs(ctx context.Context, olderThan time.Duration) (int64, error)
+	// ExportAuditLogs streams the logs matching filter to w in format
+	ExportAuditLogs(ctx context.Context, w io.Writer, format AuditExportFormat, filter AuditExportFilter) (int64, error)
 }
 
 // authorizationAuditService implements AuthorizationAuditService
@@ -36,7 +49,7 @@ func NewAuthorizationAuditService(auditRepo *enterprise.AuthorizationAuditReposi
 }
 
 // GetAuditLogs returns paginated audit logs
-func (s *authorizationAuditService) GetAuditLogs(limit int, offset int) (*[]AuditLogDTO, error) {
+func (s *authorizationAuditService) GetAuditLogs(ctx context.Context, limit int, offset int) (*[]AuditLogDTO, error) {
 	if limit <= 0 {
 		limit = 50
 	}
@@ -44,9 +57,9 @@ func (s *authorizationAuditService) GetAuditLogs(limit int, offset int) (*[]Audi
 		limit = 1000
 	}
 
-	logs, err := s.auditRepo.FindAll(context.Background(), limit, offset)
+	logs, err := s.auditRepo.FindAll(ctx, limit, offset)
 	if err != nil {
-		logger.Error("Failed to get audit logs", zap.Error(err))
+		logger.FromContext(ctx).Error("Failed to get audit logs", zap.Error(err))
 		return nil, fmt.Errorf("failed to retrieve audit logs: %w", err)
 	}
 
@@ -59,14 +72,14 @@ func (s *authorizationAuditService) GetAuditLogs(limit int, offset int) (*[]Audi
 }
 
 // GetAuditLogsByUser returns audit logs for a specific user
-func (s *authorizationAuditService) GetAuditLogsByUser(userID string, limit int, offset int) (*[]AuditLogDTO, error) {
+func (s *authorizationAuditService) GetAuditLogsByUser(ctx context.Context, userID string, limit int, offset int) (*[]AuditLogDTO, error) {
 	if userID == "" {
 		return nil, fmt.Errorf("user ID cannot be empty")
 	}
 
-	logs, err := s.auditRepo.FindByUserID(context.Background(), userID, limit, offset)
+	logs, err := s.auditRepo.FindByUserID(ctx, userID, limit, offset)
 	if err != nil {
-		logger.Error("Failed to get audit logs by user", zap.String("user_id", userID), zap.Error(err))
+		logger.FromContext(ctx).Error("Failed to get audit logs by user", zap.String("user_id", userID), zap.Error(err))
 		return nil, fmt.Errorf("failed to retrieve audit logs for user %s: %w", userID, err)
 	}
 
@@ -79,15 +92,15 @@ func (s *authorizationAuditService) GetAuditLogsByUser(userID string, limit int,
 }
 
 // GetAuditLogsByResult returns audit logs filtered by authorization result
-func (s *authorizationAuditService) GetAuditLogsByResult(result string, limit int, offset int) (*[]AuditLogDTO, error) {
+func (s *authorizationAuditService) GetAuditLogsByResult(ctx context.Context, result string, limit int, offset int) (*[]AuditLogDTO, error) {
 	validResults := map[string]bool{"ALLOWED": true, "DENIED": true, "WARNING": true}
 	if !validResults[result] {
 		return nil, fmt.Errorf("invalid result: %s", result)
 	}
 
-	logs, err := s.auditRepo.FindByResult(context.Background(), result, limit, offset)
+	logs, err := s.auditRepo.FindByResult(ctx, result, limit, offset)
 	if err != nil {
-		logger.Error("Failed to get audit logs by result", zap.String("result", result), zap.Error(err))
+		logger.FromContext(ctx).Error("Failed to get audit logs by result", zap.String("result", result), zap.Error(err))
 		return nil, fmt.Errorf("failed to retrieve audit logs by result %s: %w", result, err)
 	}
 
@@ -100,14 +113,14 @@ func (s *authorizationAuditService) GetAuditLogsByResult(result string, limit in
 }
 
 // GetAuditLogsByTimeRange returns audit logs within a time range
-func (s *authorizationAuditService) GetAuditLogsByTimeRange(startTime, endTime time.Time, limit int, offset int) (*[]AuditLogDTO, error) {
+func (s *authorizationAuditService) GetAuditLogsByTimeRange(ctx context.Context, startTime, endTime time.Time, limit int, offset int) (*[]AuditLogDTO, error) {
 	if startTime.After(endTime) {
 		return nil, fmt.Errorf("start time cannot be after end time")
 	}
 
-	logs, err := s.auditRepo.FindByTimeRange(context.Background(), startTime, endTime, limit, offset)
+	logs, err := s.auditRepo.FindByTimeRange(ctx, startTime, endTime, limit, offset)
 	if err != nil {
-		logger.Error("Failed to get audit logs by time range",
+		logger.FromContext(ctx).Error("Failed to get audit logs by time range",
 			zap.Time("start_time", startTime),
 			zap.Time("end_time", endTime),
 			zap.Error(err))
@@ -123,14 +136,14 @@ func (s *authorizationAuditService) GetAuditLogsByTimeRange(startTime, endTime t
 }
 
 // GetAuditLogsByResource returns audit logs for a specific resource
-func (s *authorizationAuditService) GetAuditLogsByResource(resource string, limit int, offset int) (*[]AuditLogDTO, error) {
+func (s *authorizationAuditService) GetAuditLogsByResource(ctx context.Context, resource string, limit int, offset int) (*[]AuditLogDTO, error) {
 	if resource == "" {
 		return nil, fmt.Errorf("resource cannot be empty")
 	}
 
-	logs, err := s.auditRepo.FindByResource(context.Background(), resource, limit, offset)
+	logs, err := s.auditRepo.FindByResource(ctx, resource, limit, offset)
 	if err != nil {
-		logger.Error("Failed to get audit logs by resource", zap.String("resource", resource), zap.Error(err))
+		logger.FromContext(ctx).Error("Failed to get audit logs by resource", zap.String("resource", resource), zap.Error(err))
 		return nil, fmt.Errorf("failed to retrieve audit logs for resource %s: %w", resource, err)
 	}
 
@@ -142,11 +155,68 @@ func (s *authorizationAuditService) GetAuditLogsByResource(resource string, limi
 	return &dtos, nil
 }
 
+// GetAuditLogsByRequestID returns audit logs for a specific request
+func (s *authorizationAuditService) GetAuditLogsByRequestID(ctx context.Context, requestID string, limit int, offset int) (*[]AuditLogDTO, error) {
+	if requestID == "" {
+		return nil, fmt.Errorf("request ID cannot be empty")
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > 1000 {
+		limit = 1000
+	}
+
+	logs, err := s.auditRepo.FindByRequestID(ctx, requestID, limit, offset)
+	if err != nil {
+		logger.FromContext(ctx).Error("Failed to get audit logs by request ID", zap.String("audit_request_id", requestID), zap.Error(err))
+		return nil, fmt.Errorf("failed to retrieve audit logs for request %s: %w", requestID, err)
+	}
+
+	dtos := make([]AuditLogDTO, len(logs))
+	for i, log := range logs {
+		dtos[i] = s.convertToDTO(log)
+	}
+
+	return &dtos, nil
+}
+
+// GetAuditLogsByField returns audit logs filtered by a custom audit field
+func (s *authorizationAuditService) GetAuditLogsByField(ctx context.Context, field string, value string, limit int, offset int) (*[]AuditLogDTO, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > 1000 {
+		limit = 1000
+	}
+
+	logs, err := s.auditRepo.FindByField(ctx, field, value, limit, offset)
+	if err != nil {
+		logger.FromContext(ctx).Error("Failed to get audit logs by field", zap.Error(err), zap.String("field", field))
+		return nil, fmt.Errorf("failed to retrieve audit logs: %w", err)
+	}
+
+	dtos := make([]AuditLogDTO, len(logs))
+	for i, log := range logs {
+		dtos[i] = s.convertToDTO(log)
+	}
+
+	return &dtos, nil
+}
+
+func (s *authorizationAuditService) GetAuditFields() map[string]string {
+	fields := make(map[string]string)
+	for name, fieldType := range enterprise.AuditFields() {
+		fields[name] = string(fieldType)
+	}
+	return fields
+}
+
 // GetDeniedAccessLogs returns logs where access was denied
-func (s *authorizationAuditService) GetDeniedAccessLogs(limit int, offset int) (*[]AuditLogDTO, error) {
-	logs, err := s.auditRepo.FindDeniedAccess(context.Background(), limit, offset)
+func (s *authorizationAuditService) GetDeniedAccessLogs(ctx context.Context, limit int, offset int) (*[]AuditLogDTO, error) {
+	logs, err := s.auditRepo.FindDeniedAccess(ctx, limit, offset)
 	if err != nil {
-		logger.Error("Failed to get denied access logs", zap.Error(err))
+		logger.FromContext(ctx).Error("Failed to get denied access logs", zap.Error(err))
 		return nil, fmt.Errorf("failed to retrieve denied access logs: %w", err)
 	}
 
@@ -158,83 +228,92 @@ func (s *authorizationAuditService) GetDeniedAccessLogs(limit int, offset int) (
 	return &dtos, nil
 }
 
-// GetAuditSummary returns summary statistics for audit logs
-func (s *authorizationAuditService) GetAuditSummary() (*AuditSummaryDTO, error) {
-	totalCount, err := s.auditRepo.Count(context.Background())
+// FindAuditLogs returns the audit logs matching every condition of filter,
+// newest first
+func (s *authorizationAuditService) FindAuditLogs(ctx context.Context, filter AuditLogFilter, limit int, offset int) (*[]AuditLogDTO, error) {
+	if err := filter.validate(); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > 1000 {
+		limit = 1000
+	}
+
+	logs, err := s.auditRepo.FindByFilter(ctx, filter.repositoryFilter(), limit, offset)
+	if err != nil {
+		logger.FromContext(ctx).Error("Failed to find audit logs", zap.Error(err))
+		return nil, fmt.Errorf("failed to retrieve audit logs: %w", err)
+	}
+
+	dtos := make([]AuditLogDTO, len(logs))
+	for i, log := range logs {
+		dtos[i] = s.convertToDTO(log)
+	}
+
+	return &dtos, nil
+}
+
+// GetAuditSummary returns summary statistics for the audit logs matching
+// filter, so they agree with the logs FindAuditLogs lists for it
+func (s *authorizationAuditService) GetAuditSummary(ctx context.Context, filter AuditLogFilter) (*AuditSummaryDTO, error) {
+	if err := filter.validate(); err != nil {
+		return nil, err
+	}
+
+	totalCount, err := s.auditRepo.Count(ctx)
 	if err != nil {
-		logger.Error("Failed to count audit logs", zap.Error(err))
+		logger.FromContext(ctx).Error("Failed to count audit logs", zap.Error(err))
 		return nil, fmt.Errorf("failed to get audit summary: %w", err)
 	}
 
-	// Get recent logs (last 24 hours)
-	yesterday := time.Now().Add(-24 * time.Hour)
-	recentLogs, err := s.auditRepo.FindByTimeRange(context.Background(), yesterday, time.Now(), 10000, 0)
+	summary, err := s.auditRepo.Summarize(ctx, filter.repositoryFilter())
 	if err != nil {
-		logger.Warn("Failed to get recent logs for summary", zap.Error(err))
-		recentLogs = []*enterprise.AuthorizationAuditLogDB{}
-	}
-
-	// Calculate statistics
-	var allowedCount, deniedCount, warningCount int64
-	var totalExecutionTime float64
-	denialReasons := make(map[string]int64)
-	resources := make(map[string]int64)
-
-	for _, log := range recentLogs {
-		switch log.Result {
-		case "ALLOWED":
-			allowedCount++
-		case "DENIED":
-			deniedCount++
-			if log.Reason != "" {
-				denialReasons[log.Reason]++
-			}
-		case "WARNING":
-			warningCount++
-		}
-		totalExecutionTime += log.ExecutionTimeMs
-		if log.Resource != "" {
-			resources[log.Resource]++
-		}
-	}
-
-	avgExecutionTime := 0.0
-	if len(recentLogs) > 0 {
-		avgExecutionTime = totalExecutionTime / float64(len(recentLogs))
-	}
-
-	return &AuditSummaryDTO{
+		logger.FromContext(ctx).Error("Failed to summarize audit logs", zap.Error(err))
+		return nil, fmt.Errorf("failed to get audit summary: %w", err)
+	}
+
+	dto := &AuditSummaryDTO{
 		TotalLogs:        totalCount,
-		RecentLogs24h:    int64(len(recentLogs)),
-		AllowedCount24h:  allowedCount,
-		DeniedCount24h:   deniedCount,
-		WarningCount24h:  warningCount,
-		AvgExecutionTime: avgExecutionTime,
-		TopDenialReasons: denialReasons,
-		TopResources:     resources,
+		MatchingLogs:     summary.Rows,
+		EventCount:       summary.Events,
+		AllowedCount:     summary.Allowed,
+		DeniedCount:      summary.Denied,
+		WarningCount:     summary.Warning,
+		AvgExecutionTime: summary.AvgExecutionTimeMs,
+		TopDenialReasons: summary.DenialReasons,
+		TopResources:     summary.Resources,
 		GeneratedAt:      time.Now(),
-	}, nil
+	}
+	if !filter.Start.IsZero() {
+		dto.Start = &filter.Start
+	}
+	if !filter.End.IsZero() {
+		dto.End = &filter.End
+	}
+	return dto, nil
 }
 
 // GetCriticalEvents returns critical audit events (denials, rate limits, deprecated routes)
-func (s *authorizationAuditService) GetCriticalEvents(limit int, offset int) (*[]AuditLogDTO, error) {
+func (s *authorizationAuditService) GetCriticalEvents(ctx context.Context, limit int, offset int) (*[]AuditLogDTO, error) {
 	// Get denied access logs
-	deniedLogs, err := s.auditRepo.FindDeniedAccess(context.Background(), limit/2, offset)
+	deniedLogs, err := s.auditRepo.FindDeniedAccess(ctx, limit/2, offset)
 	if err != nil {
-		logger.Error("Failed to get denied logs for critical events", zap.Error(err))
+		logger.FromContext(ctx).Error("Failed to get denied logs for critical events", zap.Error(err))
 		return nil, fmt.Errorf("failed to retrieve critical events: %w", err)
 	}
 
 	// Get rate limit exceeded logs
-	rateLimitLogs, err := s.auditRepo.FindRateLimitExceeded(context.Background(), limit/2, offset)
+	rateLimitLogs, err := s.auditRepo.FindRateLimitExceeded(ctx, limit/2, offset)
 	if err != nil {
-		logger.Warn("Failed to get rate limit logs for critical events", zap.Error(err))
+		logger.FromContext(ctx).Warn("Failed to get rate limit logs for critical events", zap.Error(err))
 	}
 
 	// Get deprecated route logs
-	deprecatedLogs, err := s.auditRepo.FindDeprecatedRouteAccess(context.Background(), limit/2, offset)
+	deprecatedLogs, err := s.auditRepo.FindDeprecatedRouteAccess(ctx, limit/2, offset)
 	if err != nil {
-		logger.Warn("Failed to get deprecated route logs for critical events", zap.Error(err))
+		logger.FromContext(ctx).Warn("Failed to get deprecated route logs for critical events", zap.Error(err))
 	}
 
 	// Combine and deduplicate
@@ -270,20 +349,20 @@ func (s *authorizationAuditService) GetCriticalEvents(limit int, offset int) (*[
 }
 
 // CleanupOldLogs removes audit logs older than the specified duration
-func (s *authorizationAuditService) CleanupOldLogs(olderThan time.Duration) (int64, error) {
+func (s *authorizationAuditService) CleanupOldLogs(ctx context.Context, olderThan time.Duration) (int64, error) {
 	if olderThan <= 0 {
 		return 0, fmt.Errorf("cleanup duration must be positive")
 	}
 
-	deletedCount, err := s.auditRepo.CleanupOldLogs(context.Background(), olderThan)
+	deletedCount, err := s.auditRepo.CleanupOldLogs(ctx, olderThan)
 	if err != nil {
-		logger.Error("Failed to cleanup old audit logs",
+		logger.FromContext(ctx).Error("Failed to cleanup old audit logs",
 			zap.Duration("older_than", olderThan),
 			zap.Error(err))
 		return 0, fmt.Errorf("failed to cleanup old logs: %w", err)
 	}
 
-	logger.Info("Cleaned up old audit logs",
+	logger.FromContext(ctx).Info("Cleaned up old audit logs",
 		zap.Int64("deleted_count", deletedCount),
 		zap.Duration("older_than", olderThan))
 
@@ -309,40 +388,78 @@ func (s *authorizationAuditService) convertToDTO(log *enterprise.AuthorizationAu
 		RateLimitStatus: log.RateLimitStatus,
 		PolicyVersion:   log.PolicyVersion,
 		ExecutionTimeMs: log.ExecutionTimeMs,
+		OccurrenceCount: log.Occurrences(),
+		LastSeenAt:      log.LastSeenAt,
+		RequestID:       stringValue(log.RequestID),
+		ErrorMessage:    stringValue(log.ErrorMsg),
+		AuthMode:        stringValue(log.AuthMode),
+		Metadata:        decodeAuditMetadata(log.Metadata),
+	}
+}
+
+// stringValue returns the value of a nullable column, or "" for NULL
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// decodeAuditMetadata parses the stored custom audit fields
+func decodeAuditMetadata(metadata *string) map[string]interface{} {
+	if metadata == nil || *metadata == "" {
+		return nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(*metadata), &fields); err != nil {
+		return nil
 	}
+	return fields
 }
 
 // DTOs for API responses
 
 // AuditLogDTO represents an audit log entry for API responses
 type AuditLogDTO struct {
-	ID              string    `json:"id"`
-	Timestamp       time.Time `json:"timestamp"`
-	UserID          string    `json:"user_id"`
-	Role            string    `json:"role"`
-	Resource        string    `json:"resource"`
-	Action          string    `json:"action"`
-	Result          string    `json:"result"`
-	DenialReason    string    `json:"denial_reason,omitempty"`
-	IPAddress       string    `json:"ip_address"`
-	UserAgent       string    `json:"user_agent"`
-	APIVersion      string    `json:"api_version"`
-	Deprecated      bool      `json:"deprecated"`
-	Environment     string    `json:"environment"`
-	RateLimitStatus string    `json:"rate_limit_status"`
-	PolicyVersion   int       `json:"policy_version"`
-	ExecutionTimeMs float64   `json:"execution_time_ms"`
+	ID              string     `json:"id"`
+	Timestamp       time.Time  `json:"timestamp"`
+	UserID          string     `json:"user_id"`
+	Role            string     `json:"role"`
+	Resource        string     `json:"resource"`
+	Action          string     `json:"action"`
+	Result          string     `json:"result"`
+	DenialReason    string     `json:"denial_reason,omitempty"`
+	IPAddress       string     `json:"ip_address"`
+	UserAgent       string     `json:"user_agent"`
+	APIVersion      string     `json:"api_version"`
+	Deprecated      bool       `json:"deprecated"`
+	Environment     string     `json:"environment"`
+	RateLimitStatus string     `json:"rate_limit_status"`
+	PolicyVersion   int        `json:"policy_version"`
+	ExecutionTimeMs float64    `json:"execution_time_ms"`
+	OccurrenceCount int64      `json:"occurrence_count"`
+	LastSeenAt      *time.Time `json:"last_seen_at,omitempty"`
+	RequestID       string     `json:"request_id,omitempty"`
+	ErrorMessage    string     `json:"error_message,omitempty"`
+	AuthMode        string     `json:"authorization_mode,omitempty"`
+	// Metadata holds the custom audit fields attached to the request
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
 }
 
-// AuditSummaryDTO contains summary statistics for audit logs
+// AuditSummaryDTO contains summary statistics for the audit logs matching a
+// filter; TotalLogs counts all stored logs. Event counts count rolled-up
+// rows once per collapsed event.
 type AuditSummaryDTO struct {
 	TotalLogs        int64            `json:"total_logs"`
-	RecentLogs24h    int64            `json:"recent_logs_24h"`
-	AllowedCount24h  int64            `json:"allowed_count_24h"`
-	DeniedCount24h   int64            `json:"denied_count_24h"`
-	WarningCount24h  int64            `json:"warning_count_24h"`
+	MatchingLogs     int64            `json:"matching_logs"`
+	EventCount       int64            `json:"event_count"`
+	AllowedCount     int64            `json:"allowed_count"`
+	DeniedCount      int64            `json:"denied_count"`
+	WarningCount     int64            `json:"warning_count"`
 	AvgExecutionTime float64          `json:"avg_execution_time_ms"`
 	TopDenialReasons map[string]int64 `json:"top_denial_reasons"`
 	TopResources     map[string]int64 `json:"top_resources"`
+	Start            *time.Time       `json:"start,omitempty"`
+	End              *time.Time       `json:"end,omitempty"`
 	GeneratedAt      time.Time        `json:"generated_at"`
 }
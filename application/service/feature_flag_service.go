@@ -0,0 +1,305 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aruncs31s/azf/domain/api_usage"
+	"github.com/aruncs31s/azf/domain/repository"
+	"github.com/aruncs31s/azf/shared/logger"
+	"go.uber.org/zap"
+)
+
+// featureFlagRefreshInterval bounds how long a flag switched on another
+// instance can take to be picked up
+const featureFlagRefreshInterval = 30 * time.Second
+
+// defaultFeatureFlagChangeLimit is the number of changes listed when no limit is given
+const defaultFeatureFlagChangeLimit = 50
+
+// featureFlagDescriptions lists the subsystems that can be switched at runtime
+var featureFlagDescriptions = []struct {
+	Name        string
+	Description string
+}{
+	{api_usage.FeatureAuditLogging, "Record authorization decisions in the audit log"},
+	{api_usage.FeatureRateLimiting, "Enforce per-role and per-client rate limits"},
+	{api_usage.FeatureWebhooks, "Deliver alerts to webhook and Slack endpoints"},
+	{api_usage.FeatureUsageTracking, "Record API usage for analytics"},
+	{api_usage.FeatureAnomalyDetection, "Raise alerts for denial storms"},
+}
+
+// FeatureFlagService switches AZF's own subsystems on and off per environment
+// without a restart. Flags are served from an in-memory cache so the request
+// path never hits the database.
+type FeatureFlagService interface {
+	// Environment returns the environment whose flags this instance applies
+	Environment() string
+	ListFlags(environment string) (*[]FeatureFlagDTO, error)
+	SetFlag(name string, req SetFeatureFlagRequest, changedBy string) (*FeatureFlagDTO, error)
+	ListChanges(limit int) (*[]FeatureFlagChangeDTO, error)
+	// IsEnabled reports whether the flag is on in this environment; fallback
+	// applies when the flag has never been switched
+	IsEnabled(name string, fallback bool) bool
+}
+
+// featureFlagService implements FeatureFlagService
+type featureFlagService struct {
+	repo        repository.FeatureFlagRepository
+	environment string
+	defaults    map[string]bool
+	mu          sync.RWMutex
+	cache       map[string]bool
+	loadedAt    time.Time
+}
+
+// NewFeatureFlagService creates a new feature flag service. defaults holds the
+// value each subsystem was set up with; repo may be nil when the database is
+// not available, in which case the defaults always apply.
+func NewFeatureFlagService(repo repository.FeatureFlagRepository, environment string, defaults map[string]bool) FeatureFlagService {
+	s := &featureFlagService{
+		repo:        repo,
+		environment: environment,
+		defaults:    defaults,
+		cache:       make(map[string]bool),
+	}
+	s.reload()
+	return s
+}
+
+func (s *featureFlagService) Environment() string {
+	return s.environment
+}
+
+// ListFlags returns every known flag for environment with its effective value
+func (s *featureFlagService) ListFlags(environment string) (*[]FeatureFlagDTO, error) {
+	if environment == "" {
+		environment = s.environment
+	}
+
+	stored := make(map[string]api_usage.FeatureFlag)
+	if s.repo != nil {
+		flags, err := s.repo.FindByEnvironment(environment)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list feature flags: %w", err)
+		}
+		if flags != nil {
+			for _, flag := range *flags {
+				stored[flag.Name] = flag
+			}
+		}
+	}
+
+	result := make([]FeatureFlagDTO, 0, len(featureFlagDescriptions))
+	for _, known := range featureFlagDescriptions {
+		dto := FeatureFlagDTO{
+			Name:        known.Name,
+			Description: known.Description,
+			Environment: environment,
+			Enabled:     s.defaults[known.Name],
+			Default:     s.defaults[known.Name],
+		}
+		if flag, exists := stored[known.Name]; exists {
+			dto.Enabled = flag.Enabled
+			dto.Overridden = true
+			dto.UpdatedBy = flag.UpdatedBy
+			dto.UpdatedAt = &flag.UpdatedAt
+		}
+		result = append(result, dto)
+	}
+	return &result, nil
+}
+
+// SetFlag switches a flag for the requested environment, or the current one
+// when none is given, and records the change
+func (s *featureFlagService) SetFlag(name string, req SetFeatureFlagRequest, changedBy string) (*FeatureFlagDTO, error) {
+	if s.repo == nil {
+		return nil, fmt.Errorf("feature flags are not available: database is not initialized")
+	}
+	if !isKnownFeatureFlag(name) {
+		return nil, fmt.Errorf("unknown feature flag: %s", name)
+	}
+	if req.Enabled == nil {
+		return nil, fmt.Errorf("enabled is required")
+	}
+
+	environment := strings.TrimSpace(req.Environment)
+	if environment == "" {
+		environment = s.environment
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if len(reason) > 500 {
+		return nil, fmt.Errorf("reason cannot be longer than 500 characters")
+	}
+
+	flag, err := s.repo.Save(
+		&api_usage.FeatureFlag{
+			Name:        name,
+			Environment: environment,
+			Enabled:     *req.Enabled,
+			UpdatedBy:   changedBy,
+		},
+		&api_usage.FeatureFlagChange{
+			Name:        name,
+			Environment: environment,
+			Enabled:     *req.Enabled,
+			ChangedBy:   changedBy,
+			Reason:      reason,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if environment == s.environment {
+		s.mu.Lock()
+		s.cache[name] = flag.Enabled
+		s.mu.Unlock()
+	}
+
+	logger.Info("Feature flag changed",
+		zap.String("flag", name),
+		zap.String("environment", environment),
+		zap.Bool("enabled", flag.Enabled),
+		zap.String("changed_by", changedBy),
+		zap.String("reason", reason))
+
+	return &FeatureFlagDTO{
+		Name:        name,
+		Description: featureFlagDescription(name),
+		Environment: environment,
+		Enabled:     flag.Enabled,
+		Default:     s.defaults[name],
+		Overridden:  true,
+		UpdatedBy:   flag.UpdatedBy,
+		UpdatedAt:   &flag.UpdatedAt,
+	}, nil
+}
+
+// ListChanges returns the latest flag changes across all environments
+func (s *featureFlagService) ListChanges(limit int) (*[]FeatureFlagChangeDTO, error) {
+	if limit <= 0 || limit > 1000 {
+		limit = defaultFeatureFlagChangeLimit
+	}
+
+	result := make([]FeatureFlagChangeDTO, 0)
+	if s.repo == nil {
+		return &result, nil
+	}
+
+	changes, err := s.repo.FindChanges(limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list feature flag changes: %w", err)
+	}
+	if changes == nil {
+		return &result, nil
+	}
+
+	for _, change := range *changes {
+		result = append(result, FeatureFlagChangeDTO{
+			Name:        change.Name,
+			Environment: change.Environment,
+			Enabled:     change.Enabled,
+			Previous:    change.Previous,
+			ChangedBy:   change.ChangedBy,
+			Reason:      change.Reason,
+			ChangedAt:   change.ChangedAt,
+		})
+	}
+	return &result, nil
+}
+
+func (s *featureFlagService) IsEnabled(name string, fallback bool) bool {
+	s.mu.RLock()
+	stale := time.Since(s.loadedAt) > featureFlagRefreshInterval
+	enabled, exists := s.cache[name]
+	s.mu.RUnlock()
+
+	if stale {
+		s.reload()
+		s.mu.RLock()
+		enabled, exists = s.cache[name]
+		s.mu.RUnlock()
+	}
+
+	if !exists {
+		return fallback
+	}
+	return enabled
+}
+
+// reload replaces the cache with the flags persisted for this environment
+func (s *featureFlagService) reload() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Another caller may have reloaded while we waited for the lock
+	if time.Since(s.loadedAt) <= featureFlagRefreshInterval {
+		return
+	}
+	s.loadedAt = time.Now()
+
+	if s.repo == nil {
+		return
+	}
+
+	flags, err := s.repo.FindByEnvironment(s.environment)
+	if err != nil {
+		logger.Warn("Failed to reload feature flags, keeping cached values", zap.Error(err))
+		return
+	}
+
+	cache := make(map[string]bool)
+	if flags != nil {
+		for _, flag := range *flags {
+			cache[flag.Name] = flag.Enabled
+		}
+	}
+	s.cache = cache
+}
+
+func isKnownFeatureFlag(name string) bool {
+	return featureFlagDescription(name) != ""
+}
+
+func featureFlagDescription(name string) string {
+	for _, known := range featureFlagDescriptions {
+		if known.Name == name {
+			return known.Description
+		}
+	}
+	return ""
+}
+
+// SetFeatureFlagRequest is the payload for switching a flag. Environment
+// defaults to the environment of the instance handling the request.
+type SetFeatureFlagRequest struct {
+	Enabled     *bool  `json:"enabled"`
+	Environment string `json:"environment"`
+	Reason      string `json:"reason"`
+}
+
+// FeatureFlagDTO is a subsystem flag and its effective value in one environment
+type FeatureFlagDTO struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Environment string     `json:"environment"`
+	Enabled     bool       `json:"enabled"`
+	Default     bool       `json:"default"`
+	Overridden  bool       `json:"overridden"`
+	UpdatedBy   string     `json:"updated_by,omitempty"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+}
+
+// FeatureFlagChangeDTO is an audited feature flag switch
+type FeatureFlagChangeDTO struct {
+	Name        string    `json:"name"`
+	Environment string    `json:"environment"`
+	Enabled     bool      `json:"enabled"`
+	Previous    *bool     `json:"previous,omitempty"`
+	ChangedBy   string    `json:"changed_by"`
+	Reason      string    `json:"reason,omitempty"`
+	ChangedAt   time.Time `json:"changed_at"`
+}
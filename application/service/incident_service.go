@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aruncs31s/azf/domain/api_usage"
+	"github.com/aruncs31s/azf/domain/repository"
+)
+
+// defaultIncidentLimit is the number of incidents listed when no limit is given
+const defaultIncidentLimit = 100
+
+// IncidentService backs the notification center, which lists the incidents
+// opened with PagerDuty or Opsgenie along with their acknowledgment status
+type IncidentService interface {
+	// Providers returns the names of the configured on-call providers
+	Providers() []string
+	ListIncidents(status string, limit int) (*[]IncidentDTO, error)
+	// SyncIncidents refreshes acknowledgment status from the providers
+	SyncIncidents() error
+}
+
+// incidentService implements IncidentService
+type incidentService struct {
+	repo      repository.AlertIncidentRepository
+	providers []string
+	sync      func(ctx context.Context) error
+}
+
+// NewIncidentService creates a new incident service. sync pulls the current
+// incident status from the providers; repo may be nil when no provider is configured.
+func NewIncidentService(
+	repo repository.AlertIncidentRepository,
+	providers []string,
+	sync func(ctx context.Context) error,
+) IncidentService {
+	return &incidentService{
+		repo:      repo,
+		providers: providers,
+		sync:      sync,
+	}
+}
+
+func (s *incidentService) Providers() []string {
+	return s.providers
+}
+
+// ListIncidents returns the most recent incidents, newest first, optionally
+// filtered by status
+func (s *incidentService) ListIncidents(status string, limit int) (*[]IncidentDTO, error) {
+	switch status {
+	case "", api_usage.IncidentTriggered, api_usage.IncidentAcknowledged, api_usage.IncidentResolved:
+	default:
+		return nil, fmt.Errorf("invalid incident status: %s", status)
+	}
+	if limit <= 0 || limit > 1000 {
+		limit = defaultIncidentLimit
+	}
+
+	result := make([]IncidentDTO, 0)
+	if s.repo == nil {
+		return &result, nil
+	}
+
+	incidents, err := s.repo.FindRecent(status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list incidents: %w", err)
+	}
+	if incidents == nil {
+		return &result, nil
+	}
+
+	for _, incident := range *incidents {
+		result = append(result, toIncidentDTO(incident))
+	}
+	return &result, nil
+}
+
+func (s *incidentService) SyncIncidents() error {
+	if s.repo == nil || s.sync == nil {
+		return fmt.Errorf("no incident provider is configured")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := s.sync(ctx); err != nil {
+		return fmt.Errorf("failed to sync incidents: %w", err)
+	}
+	return nil
+}
+
+func toIncidentDTO(incident api_usage.AlertIncident) IncidentDTO {
+	return IncidentDTO{
+		ID:              incident.ID,
+		Provider:        incident.Provider,
+		IncidentKey:     incident.IncidentKey,
+		ExternalID:      incident.ExternalID,
+		Rule:            incident.Rule,
+		Severity:        incident.Severity,
+		Title:           incident.Title,
+		Status:          incident.Status,
+		TriggerCount:    incident.TriggerCount,
+		LastTriggeredAt: incident.LastTriggeredAt,
+		AcknowledgedBy:  incident.AcknowledgedBy,
+		AcknowledgedAt:  incident.AcknowledgedAt,
+		ResolvedAt:      incident.ResolvedAt,
+		LastSyncedAt:    incident.LastSyncedAt,
+		CreatedAt:       incident.CreatedAt,
+	}
+}
+
+// IncidentDTO describes an incident opened with an on-call provider
+type IncidentDTO struct {
+	ID              string     `json:"id"`
+	Provider        string     `json:"provider"`
+	IncidentKey     string     `json:"incident_key"`
+	ExternalID      string     `json:"external_id,omitempty"`
+	Rule            string     `json:"rule"`
+	Severity        string     `json:"severity"`
+	Title           string     `json:"title"`
+	Status          string     `json:"status"`
+	TriggerCount    int        `json:"trigger_count"`
+	LastTriggeredAt time.Time  `json:"last_triggered_at"`
+	AcknowledgedBy  string     `json:"acknowledged_by,omitempty"`
+	AcknowledgedAt  *time.Time `json:"acknowledged_at,omitempty"`
+	ResolvedAt      *time.Time `json:"resolved_at,omitempty"`
+	LastSyncedAt    *time.Time `json:"last_synced_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
@@ -0,0 +1,259 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aruncs31s/azf/application/dto"
+	"github.com/aruncs31s/azf/config"
+	"github.com/aruncs31s/azf/constants"
+	"github.com/aruncs31s/azf/infrastructure/ldap"
+	"github.com/aruncs31s/azf/shared/clock"
+	"github.com/aruncs31s/azf/shared/ids"
+	"github.com/aruncs31s/azf/shared/logger"
+	"github.com/aruncs31s/azf/utils"
+	"go.uber.org/zap"
+)
+
+const (
+	// ldapOAuthProvider marks user records signed in through LDAP; their
+	// OAuth ID is the DN
+	ldapOAuthProvider = "ldap"
+	// ldapLoginTimeout bounds looking up and binding as a user signing in
+	ldapLoginTimeout = 15 * time.Second
+	// ldapHealthTTL is how long a connection check is reported before the
+	// server is checked again
+	ldapHealthTTL = 30 * time.Second
+)
+
+// Directory finds and authenticates users; *ldap.Directory implements it
+type Directory interface {
+	FindUser(ctx context.Context, username string) (*ldap.User, error)
+	Authenticate(ctx context.Context, dn, password string) error
+	Ping(ctx context.Context) error
+	URL() string
+}
+
+// DirectoryHealth is the state of the connection to a directory server
+type DirectoryHealth struct {
+	URL       string        `json:"url"`
+	Connected bool          `json:"connected"`
+	Latency   time.Duration `json:"latency_ns"`
+	Error     string        `json:"error,omitempty"`
+	CheckedAt time.Time     `json:"checked_at"`
+}
+
+// DirectoryHealthChecker reports the connection to the directory admins sign
+// in against
+type DirectoryHealthChecker interface {
+	Health(ctx context.Context) DirectoryHealth
+}
+
+// ldapCacheEntry is a looked up user and when it is looked up again
+type ldapCacheEntry struct {
+	user    *ldap.User
+	expires time.Time
+}
+
+// LDAPAuthenticator signs admins in against an LDAP or Active Directory
+// server: it looks the user up with the service account, binds as them with
+// the password and maps their groups to roles. Only users mapped to the admin
+// role may sign in.
+type LDAPAuthenticator struct {
+	directory Directory
+	config    config.LDAPConfig
+	clock     clock.Clock
+
+	mu    sync.Mutex
+	cache map[string]ldapCacheEntry
+
+	healthMu sync.Mutex
+	health   *DirectoryHealth
+}
+
+// NewLDAPAuthenticator creates an authenticator on the directory of cfg
+func NewLDAPAuthenticator(cfg config.LDAPConfig) (*LDAPAuthenticator, error) {
+	directory, err := ldap.NewDirectory(ldap.Config{
+		URL:                cfg.URL,
+		BindDN:             cfg.BindDN,
+		BindPassword:       cfg.BindPassword,
+		BaseDN:             cfg.BaseDN,
+		UserFilter:         cfg.UserFilter,
+		UsernameAttribute:  cfg.UsernameAttribute,
+		EmailAttribute:     cfg.EmailAttribute,
+		NameAttribute:      cfg.NameAttribute,
+		GroupAttribute:     cfg.GroupAttribute,
+		GroupBaseDN:        cfg.GroupBaseDN,
+		GroupFilter:        cfg.GroupFilter,
+		StartTLS:           cfg.StartTLS,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+		Timeout:            cfg.Timeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(mappedRoles(cfg.RoleMapping), constants.ADMIN) {
+		logger.Warn("LDAP_ROLE_MAPPING maps no group to the admin role; nobody can sign in through LDAP")
+	}
+	return NewLDAPAuthenticatorWithDirectory(directory, cfg, nil), nil
+}
+
+// NewLDAPAuthenticatorWithDirectory creates an authenticator on directory,
+// e.g. a fake in tests; c defaults to the process clock
+func NewLDAPAuthenticatorWithDirectory(directory Directory, cfg config.LDAPConfig, c clock.Clock) *LDAPAuthenticator {
+	return &LDAPAuthenticator{
+		directory: directory,
+		config:    cfg,
+		clock:     clock.Or(c),
+		cache:     make(map[string]ldapCacheEntry),
+	}
+}
+
+// Login authenticates an admin with their directory username and password
+func (a *LDAPAuthenticator) Login(request *dto.LoginRequest) (*dto.AdminLoginResponse, error) {
+	if request == nil {
+		return nil, utils.ErrInvalidData
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ldapLoginTimeout)
+	defer cancel()
+
+	user, err := a.lookup(ctx, request.Username)
+	if err == nil {
+		err = a.directory.Authenticate(ctx, user.DN, request.Password)
+	}
+	switch {
+	case errors.Is(err, ldap.ErrUserNotFound), errors.Is(err, ldap.ErrInvalidCredentials):
+		// The password may have failed because the entry moved or changed
+		a.forget(request.Username)
+		return a.failure("Authentication failed", utils.ErrInvalidUsernameOrPassword.Error()), nil
+	case err != nil:
+		return a.failure("Directory server unavailable, try again later", "server error"),
+			fmt.Errorf("failed to authenticate LDAP user %s: %w", request.Username, err)
+	}
+
+	roles := a.roles(user)
+	if !slices.Contains(roles, constants.ADMIN) {
+		logger.Warn("LDAP user without the admin role tried to sign in",
+			zap.String("username", user.Username),
+			zap.Strings("roles", roles))
+		return a.failure("Your account may not sign in to the admin UI", utils.ErrInvalidUsernameOrPassword.Error()), nil
+	}
+
+	return &dto.AdminLoginResponse{
+		Success:   true,
+		Message:   "LDAP login successful",
+		SessionID: "ldap_session_" + ids.New(),
+		Admin: dto.AdminInfo{
+			ID:       AdminUserID(user.Username),
+			Username: user.Username,
+		},
+		Identity: &dto.DirectoryIdentity{
+			Provider: ldapOAuthProvider,
+			Subject:  user.DN,
+			Username: user.Username,
+			Email:    user.Email,
+			Name:     user.Name,
+			Roles:    roles,
+		},
+		Timestamp: a.clock.Now().Format(time.RFC3339),
+	}, nil
+}
+
+// Health reports whether the service account can bind to the server, checking
+// it at most every ldapHealthTTL
+func (a *LDAPAuthenticator) Health(ctx context.Context) DirectoryHealth {
+	a.healthMu.Lock()
+	defer a.healthMu.Unlock()
+	now := a.clock.Now()
+	if a.health != nil && now.Sub(a.health.CheckedAt) < ldapHealthTTL {
+		return *a.health
+	}
+
+	started := time.Now()
+	err := a.directory.Ping(ctx)
+	health := DirectoryHealth{
+		URL:       a.directory.URL(),
+		Connected: err == nil,
+		Latency:   time.Since(started),
+		CheckedAt: now,
+	}
+	if err != nil {
+		health.Error = err.Error()
+		logger.Warn("LDAP server health check failed", zap.String("url", health.URL), zap.Error(err))
+	}
+	a.health = &health
+	return health
+}
+
+// lookup returns the directory entry of username, from the cache while it is
+// fresh
+func (a *LDAPAuthenticator) lookup(ctx context.Context, username string) (*ldap.User, error) {
+	key := strings.ToLower(username)
+	now := a.clock.Now()
+
+	a.mu.Lock()
+	entry, ok := a.cache[key]
+	a.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.user, nil
+	}
+
+	user, err := a.directory.FindUser(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if a.config.CacheTTL > 0 {
+		a.mu.Lock()
+		for cached, entry := range a.cache {
+			if !now.Before(entry.expires) {
+				delete(a.cache, cached)
+			}
+		}
+		a.cache[key] = ldapCacheEntry{user: user, expires: now.Add(a.config.CacheTTL)}
+		a.mu.Unlock()
+	}
+	return user, nil
+}
+
+// forget drops the cached entry of username
+func (a *LDAPAuthenticator) forget(username string) {
+	a.mu.Lock()
+	delete(a.cache, strings.ToLower(username))
+	a.mu.Unlock()
+}
+
+// roles returns the roles mapped to the groups of user, by DN or common name
+func (a *LDAPAuthenticator) roles(user *ldap.User) []string {
+	roles := []string{}
+	for _, group := range user.Groups {
+		keys := []string{strings.ToLower(group)}
+		if cn := ldap.CommonName(group); cn != "" {
+			keys = append(keys, strings.ToLower(cn))
+		}
+		for _, key := range keys {
+			for _, role := range a.config.RoleMapping[key] {
+				if !slices.Contains(roles, role) {
+					roles = append(roles, role)
+				}
+			}
+		}
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// failure returns an unsuccessful login response
+func (a *LDAPAuthenticator) failure(message, reason string) *dto.AdminLoginResponse {
+	return &dto.AdminLoginResponse{
+		Success:   false,
+		Message:   message,
+		Error:     reason,
+		Timestamp: a.clock.Now().Format(time.RFC3339),
+	}
+}
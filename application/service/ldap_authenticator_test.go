@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/aruncs31s/azf/application/dto"
+	"github.com/aruncs31s/azf/config"
+	"github.com/aruncs31s/azf/infrastructure/ldap"
+	"github.com/aruncs31s/azf/shared/clock"
+)
+
+// fakeDirectory holds one user with a password and counts lookups
+type fakeDirectory struct {
+	user     ldap.User
+	password string
+	lookups  int
+}
+
+func (d *fakeDirectory) FindUser(_ context.Context, username string) (*ldap.User, error) {
+	d.lookups++
+	if username != d.user.Username {
+		return nil, ldap.ErrUserNotFound
+	}
+	user := d.user
+	return &user, nil
+}
+
+func (d *fakeDirectory) Authenticate(_ context.Context, dn, password string) error {
+	if dn != d.user.DN || password != d.password {
+		return ldap.ErrInvalidCredentials
+	}
+	return nil
+}
+
+func (d *fakeDirectory) Ping(context.Context) error { return nil }
+
+func (d *fakeDirectory) URL() string { return "ldap://directory.test" }
+
+func TestLDAPAuthenticator_MapsGroupsAndCachesLookups(t *testing.T) {
+	directory := &fakeDirectory{
+		user: ldap.User{
+			DN:       "uid=jdoe,ou=people,dc=example,dc=com",
+			Username: "jdoe",
+			Groups: []string{
+				"cn=Admins,ou=groups,dc=example,dc=com",
+				"CN=Auditors,OU=Groups,DC=example,DC=com",
+			},
+		},
+		password: "secret",
+	}
+	c := clock.NewFake(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	auth := NewLDAPAuthenticatorWithDirectory(directory, config.LDAPConfig{
+		RoleMapping: map[string][]string{
+			"cn=admins,ou=groups,dc=example,dc=com": {"admin"},
+			"auditors":                              {"auditor"},
+		},
+		CacheTTL: time.Minute,
+	}, c)
+
+	response, err := auth.Login(&dto.LoginRequest{Username: "jdoe", Password: "secret"})
+	if err != nil || !response.Success {
+		t.Fatalf("Expected sign-in to succeed, got %+v, %v", response, err)
+	}
+	if response.Identity == nil || !slices.Equal(response.Identity.Roles, []string{"admin", "auditor"}) {
+		t.Errorf("Expected roles admin and auditor, got %+v", response.Identity)
+	}
+
+	response, err = auth.Login(&dto.LoginRequest{Username: "jdoe", Password: "wrong"})
+	if err != nil || response.Success {
+		t.Fatalf("Expected a wrong password to be rejected, got %+v, %v", response, err)
+	}
+	if directory.lookups != 1 {
+		t.Errorf("Expected the cached entry to be used, got %d lookups", directory.lookups)
+	}
+
+	// The rejected password dropped the entry, and it expires anyway
+	c.Advance(2 * time.Minute)
+	if _, err := auth.Login(&dto.LoginRequest{Username: "jdoe", Password: "secret"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if directory.lookups != 2 {
+		t.Errorf("Expected the user to be looked up again, got %d lookups", directory.lookups)
+	}
+}
+
+func TestLDAPAuthenticator_RequiresAdminRole(t *testing.T) {
+	directory := &fakeDirectory{
+		user: ldap.User{
+			DN:       "uid=jdoe,ou=people,dc=example,dc=com",
+			Username: "jdoe",
+			Groups:   []string{"cn=staff,ou=groups,dc=example,dc=com"},
+		},
+		password: "secret",
+	}
+	auth := NewLDAPAuthenticatorWithDirectory(directory, config.LDAPConfig{
+		RoleMapping: map[string][]string{"staff": {"user"}},
+	}, nil)
+
+	response, err := auth.Login(&dto.LoginRequest{Username: "jdoe", Password: "secret"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if response.Success {
+		t.Error("Expected a user without the admin role to be rejected")
+	}
+}
@@ -0,0 +1,346 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aruncs31s/azf/config"
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/github"
+	"golang.org/x/oauth2/google"
+)
+
+// oidcDiscoveryPath is appended to issuer URLs to find their discovery document
+const oidcDiscoveryPath = "/.well-known/openid-configuration"
+
+// oidcDiscoveryTimeout bounds the fetch of a discovery document
+const oidcDiscoveryTimeout = 10 * time.Second
+
+// oauthPreset builds a provider of one type from its configuration
+type oauthPreset func(settings config.OAuthProviderConfig, redirectURL string) (*oauthProvider, error)
+
+// oauthPresets are the provider types a configured provider can use
+var oauthPresets = map[string]oauthPreset{
+	"google":    newGoogleProvider,
+	"github":    newGitHubProvider,
+	"microsoft": newMicrosoftProvider,
+	"gitlab":    newGitLabProvider,
+	"oidc":      newOIDCProvider,
+}
+
+// oauthProvider is a configured provider. The endpoints of OpenID Connect
+// providers are read from their discovery document on first use.
+type oauthProvider struct {
+	config       *oauth2.Config
+	userInfoURL  string
+	discoveryURL string
+	issuer       string
+	parse        func(data []byte) (*OAuthUserInfo, error)
+	// emailsURL lists the email addresses of the user when the user info
+	// response may leave the email out; parseEmails picks the one to use
+	emailsURL   string
+	parseEmails func(data []byte) (string, error)
+
+	mu         sync.Mutex
+	discovered bool
+}
+
+// newOAuthProvider builds a provider from its configuration with the preset
+// of its type
+func newOAuthProvider(settings config.OAuthProviderConfig, baseURL string) (*oauthProvider, error) {
+	preset, ok := oauthPresets[settings.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown OAuth provider type %q", settings.Type)
+	}
+	redirectURL := fmt.Sprintf("%s/admin-ui/oauth/callback/%s", baseURL, settings.Name)
+	return preset(settings, redirectURL)
+}
+
+func newGoogleProvider(settings config.OAuthProviderConfig, redirectURL string) (*oauthProvider, error) {
+	return &oauthProvider{
+		config:      oauthConfig(settings, redirectURL, google.Endpoint, "openid", "profile", "email"),
+		userInfoURL: "https://www.googleapis.com/oauth2/v2/userinfo",
+		parse:       parseGoogleUserInfo,
+	}, nil
+}
+
+func newGitHubProvider(settings config.OAuthProviderConfig, redirectURL string) (*oauthProvider, error) {
+	return &oauthProvider{
+		config:      oauthConfig(settings, redirectURL, github.Endpoint, "user:email", "read:user"),
+		userInfoURL: "https://api.github.com/user",
+		parse:       parseGitHubUserInfo,
+		emailsURL:   "https://api.github.com/user/emails",
+		parseEmails: parseGitHubEmails,
+	}, nil
+}
+
+// newMicrosoftProvider signs in through Microsoft Entra ID; the tenant
+// defaults to common, which accepts work, school and personal accounts
+func newMicrosoftProvider(settings config.OAuthProviderConfig, redirectURL string) (*oauthProvider, error) {
+	tenant := settings.Tenant
+	if tenant == "" {
+		tenant = "common"
+	}
+	if settings.DiscoveryURL == "" {
+		settings.DiscoveryURL = fmt.Sprintf("https://login.microsoftonline.com/%s/v2.0", tenant)
+	}
+	return newOIDCProvider(settings, redirectURL)
+}
+
+// newGitLabProvider signs in through gitlab.com or the instance at BaseURL
+func newGitLabProvider(settings config.OAuthProviderConfig, redirectURL string) (*oauthProvider, error) {
+	baseURL := strings.TrimRight(settings.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = "https://gitlab.com"
+	}
+	if settings.DiscoveryURL == "" {
+		settings.DiscoveryURL = baseURL
+	}
+	return newOIDCProvider(settings, redirectURL)
+}
+
+// newOIDCProvider signs in through any OpenID Connect provider, with the
+// endpoints of its discovery document
+func newOIDCProvider(settings config.OAuthProviderConfig, redirectURL string) (*oauthProvider, error) {
+	discoveryURL := strings.TrimRight(settings.DiscoveryURL, "/")
+	if discoveryURL == "" {
+		return nil, fmt.Errorf("OAuth provider %s needs a discovery URL", settings.Name)
+	}
+	if !strings.HasSuffix(discoveryURL, oidcDiscoveryPath) {
+		discoveryURL += oidcDiscoveryPath
+	}
+	return &oauthProvider{
+		config:       oauthConfig(settings, redirectURL, oauth2.Endpoint{}, "openid", "profile", "email"),
+		discoveryURL: discoveryURL,
+		parse:        parseOIDCUserInfo,
+	}, nil
+}
+
+// oauthConfig builds the OAuth2 configuration of a provider, using the
+// default scopes unless the configuration names its own
+func oauthConfig(settings config.OAuthProviderConfig, redirectURL string, endpoint oauth2.Endpoint, scopes ...string) *oauth2.Config {
+	if len(settings.Scopes) > 0 {
+		scopes = settings.Scopes
+	}
+	return &oauth2.Config{
+		ClientID:     settings.ClientID,
+		ClientSecret: settings.ClientSecret,
+		RedirectURL:  redirectURL,
+		Scopes:       scopes,
+		Endpoint:     endpoint,
+	}
+}
+
+// resolve returns the OAuth2 configuration and user info endpoint of the
+// provider, fetching the discovery document the first time it is needed
+func (p *oauthProvider) resolve(ctx context.Context) (*oauth2.Config, string, error) {
+	if p.discoveryURL == "" {
+		return p.config, p.userInfoURL, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.discovered {
+		if err := p.discover(ctx); err != nil {
+			return nil, "", err
+		}
+		p.discovered = true
+	}
+	return p.config, p.userInfoURL, nil
+}
+
+// discover reads the endpoints of the provider from its discovery document
+func (p *oauthProvider) discover(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, oidcDiscoveryTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.discoveryURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch OpenID configuration: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("OpenID configuration %s returned status %d", p.discoveryURL, resp.StatusCode)
+	}
+
+	var document struct {
+		Issuer                string `json:"issuer"`
+		AuthorizationEndpoint string `json:"authorization_endpoint"`
+		TokenEndpoint         string `json:"token_endpoint"`
+		UserInfoEndpoint      string `json:"userinfo_endpoint"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&document); err != nil {
+		return fmt.Errorf("invalid OpenID configuration: %w", err)
+	}
+	if document.AuthorizationEndpoint == "" || document.TokenEndpoint == "" || document.UserInfoEndpoint == "" {
+		return fmt.Errorf("OpenID configuration %s lacks the authorization, token or userinfo endpoint", p.discoveryURL)
+	}
+
+	p.config.Endpoint = oauth2.Endpoint{
+		AuthURL:  document.AuthorizationEndpoint,
+		TokenURL: document.TokenEndpoint,
+	}
+	p.userInfoURL = document.UserInfoEndpoint
+	p.issuer = document.Issuer
+	return nil
+}
+
+// openIDConnect reports whether the provider is an OpenID Connect provider
+// returning ID tokens
+func (p *oauthProvider) openIDConnect() bool {
+	return p.discoveryURL != ""
+}
+
+// verifyIDToken checks the claims of the ID token returned with token and
+// returns its subject. The token comes straight from the token endpoint over
+// TLS, so its signature is not checked (OpenID Connect Core 3.1.3.7).
+func (p *oauthProvider) verifyIDToken(token *oauth2.Token, nonce string) (string, error) {
+	raw, _ := token.Extra("id_token").(string)
+	if raw == "" {
+		return "", fmt.Errorf("token response has no ID token")
+	}
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(raw, claims); err != nil {
+		return "", fmt.Errorf("invalid ID token: %w", err)
+	}
+
+	if p.issuer != "" {
+		// Multi-tenant Microsoft issuers hold the tenant of the user
+		issuer := p.issuer
+		if tenant, ok := claims["tid"].(string); ok {
+			issuer = strings.ReplaceAll(issuer, "{tenantid}", tenant)
+		}
+		if iss, _ := claims.GetIssuer(); iss != issuer {
+			return "", fmt.Errorf("ID token issuer %q is not %q", iss, issuer)
+		}
+	}
+	if audience, _ := claims.GetAudience(); !slices.Contains(audience, p.config.ClientID) {
+		return "", fmt.Errorf("ID token is not issued to this client")
+	}
+	if expiresAt, _ := claims.GetExpirationTime(); expiresAt == nil || expiresAt.Before(time.Now()) {
+		return "", fmt.Errorf("ID token has expired")
+	}
+	if got, _ := claims["nonce"].(string); nonce == "" || subtle.ConstantTimeCompare([]byte(got), []byte(nonce)) != 1 {
+		return "", fmt.Errorf("ID token nonce does not match")
+	}
+
+	subject, _ := claims.GetSubject()
+	if subject == "" {
+		return "", fmt.Errorf("ID token has no subject")
+	}
+	return subject, nil
+}
+
+func parseGoogleUserInfo(data []byte) (*OAuthUserInfo, error) {
+	var googleUser struct {
+		ID            string `json:"id"`
+		Email         string `json:"email"`
+		Name          string `json:"name"`
+		VerifiedEmail bool   `json:"verified_email"`
+		Picture       string `json:"picture"`
+	}
+	if err := json.Unmarshal(data, &googleUser); err != nil {
+		return nil, err
+	}
+	return &OAuthUserInfo{
+		ID:            googleUser.ID,
+		Email:         googleUser.Email,
+		Name:          googleUser.Name,
+		Username:      strings.Split(googleUser.Email, "@")[0], // Use email prefix as username
+		AvatarURL:     googleUser.Picture,
+		VerifiedEmail: googleUser.VerifiedEmail,
+	}, nil
+}
+
+func parseGitHubUserInfo(data []byte) (*OAuthUserInfo, error) {
+	var githubUser struct {
+		ID    int    `json:"id"`
+		Login string `json:"login"`
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	}
+	if err := json.Unmarshal(data, &githubUser); err != nil {
+		return nil, err
+	}
+
+	// The email is only set when the user made it public, and may not be
+	// verified; the primary verified address is read from /user/emails
+	return &OAuthUserInfo{
+		ID:        fmt.Sprintf("%d", githubUser.ID),
+		Email:     githubUser.Email,
+		Name:      githubUser.Name,
+		Username:  githubUser.Login,
+		AvatarURL: "",
+	}, nil
+}
+
+// parseGitHubEmails returns the primary verified address of a GitHub
+// /user/emails response
+func parseGitHubEmails(data []byte) (string, error) {
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := json.Unmarshal(data, &emails); err != nil {
+		return "", err
+	}
+	for _, email := range emails {
+		if email.Primary && email.Verified && email.Email != "" {
+			return email.Email, nil
+		}
+	}
+	return "", ErrOAuthEmailUnavailable
+}
+
+// parseOIDCUserInfo reads the standard claims of an OpenID Connect userinfo
+// response. The username is the first of preferred_username, nickname and
+// the email prefix that is set.
+func parseOIDCUserInfo(data []byte) (*OAuthUserInfo, error) {
+	var claims struct {
+		Subject           string      `json:"sub"`
+		Email             string      `json:"email"`
+		EmailVerified     interface{} `json:"email_verified"`
+		Name              string      `json:"name"`
+		PreferredUsername string      `json:"preferred_username"`
+		Nickname          string      `json:"nickname"`
+		Picture           string      `json:"picture"`
+	}
+	if err := json.Unmarshal(data, &claims); err != nil {
+		return nil, err
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("userinfo response has no subject")
+	}
+
+	username := claims.PreferredUsername
+	if username == "" {
+		username = claims.Nickname
+	}
+	if username == "" {
+		username = strings.Split(claims.Email, "@")[0]
+	}
+	return &OAuthUserInfo{
+		ID:       claims.Subject,
+		Email:    claims.Email,
+		Name:     claims.Name,
+		Username: username,
+		// Some providers send the claim as a string
+		VerifiedEmail: claims.EmailVerified == true || claims.EmailVerified == "true",
+		AvatarURL:     claims.Picture,
+	}, nil
+}
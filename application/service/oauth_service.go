@@ -3,39 +3,43 @@ package service
 import (
 	"context"
 	"crypto/rand"
+	"crypto/subtle"
 	"encoding/base64"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"os"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/aruncs31s/azf/application/dto"
+	"github.com/aruncs31s/azf/config"
 	usermodel "github.com/aruncs31s/azf/domain/user_management/model"
+	"github.com/aruncs31s/azf/infrastructure/token"
 	"github.com/aruncs31s/azf/shared/logger"
 	"github.com/golang-jwt/jwt/v5"
 	"go.uber.org/zap"
 	"golang.org/x/oauth2"
-	"golang.org/x/oauth2/github"
-	"golang.org/x/oauth2/google"
 )
 
-// OAuthProvider represents supported OAuth providers
+// OAuthProvider names a configured OAuth provider. The constants are the
+// presets; any other name set up in OAUTH_PROVIDERS works the same way.
 type OAuthProvider string
 
 const (
-	Google OAuthProvider = "google"
-	GitHub OAuthProvider = "github"
+	Google    OAuthProvider = "google"
+	GitHub    OAuthProvider = "github"
+	Microsoft OAuthProvider = "microsoft"
+	GitLab    OAuthProvider = "gitlab"
 )
 
 // OAuthService handles OAuth authentication operations
 type OAuthService struct {
-	userRepo     usermodel.UserRepository
-	oauthConfigs map[OAuthProvider]*oauth2.Config
-	baseURL      string
-	jwtSecret    string
+	userRepo  usermodel.UserRepository
+	providers map[OAuthProvider]*oauthProvider
+	baseURL   string
+	tokens    token.TokenProvider
 }
 
 // OAuthUserInfo represents user information from OAuth provider
@@ -48,102 +52,162 @@ type OAuthUserInfo struct {
 	VerifiedEmail bool
 }
 
-// NewOAuthService creates a new OAuth service
+// NewOAuthService creates a new OAuth service issuing tokens signed by tokens
 func NewOAuthService(
 	userRepo usermodel.UserRepository,
 	baseURL string,
-	jwtSecret string,
+	tokens token.TokenProvider,
 ) *OAuthService {
 	service := &OAuthService{
-		userRepo:     userRepo,
-		oauthConfigs: make(map[OAuthProvider]*oauth2.Config),
-		baseURL:      baseURL,
-		jwtSecret:    jwtSecret,
+		userRepo:  userRepo,
+		providers: make(map[OAuthProvider]*oauthProvider),
+		baseURL:   baseURL,
+		tokens:    tokens,
 	}
 
-	// Initialize OAuth configs
-	service.initOAuthConfigs()
+	// Register the configured providers
+	service.initProviders()
 
 	return service
 }
 
-// initOAuthConfigs initializes OAuth2 configurations for supported providers
-func (s *OAuthService) initOAuthConfigs() {
-	// Google OAuth
-	if clientID := s.getEnvVar("GOOGLE_OAUTH_CLIENT_ID"); clientID != "" {
-		if clientSecret := s.getEnvVar("GOOGLE_OAUTH_CLIENT_SECRET"); clientSecret != "" {
-			s.oauthConfigs[Google] = &oauth2.Config{
-				ClientID:     clientID,
-				ClientSecret: clientSecret,
-				RedirectURL:  fmt.Sprintf("%s/admin-ui/oauth/callback/google", s.baseURL),
-				Scopes:       []string{"openid", "profile", "email"},
-				Endpoint:     google.Endpoint,
-			}
+// initProviders registers the providers configured in the environment,
+// logging and skipping those whose configuration is invalid
+func (s *OAuthService) initProviders() {
+	for _, settings := range config.GetOAuthProviders() {
+		provider, err := newOAuthProvider(settings, s.baseURL)
+		if err != nil {
+			logger.GetLogger().Warn("Skipping OAuth provider",
+				zap.String("provider", settings.Name),
+				zap.Error(err))
+			continue
 		}
+		s.providers[OAuthProvider(settings.Name)] = provider
 	}
+}
 
-	// GitHub OAuth
-	if clientID := s.getEnvVar("GITHUB_OAUTH_CLIENT_ID"); clientID != "" {
-		if clientSecret := s.getEnvVar("GITHUB_OAUTH_CLIENT_SECRET"); clientSecret != "" {
-			s.oauthConfigs[GitHub] = &oauth2.Config{
-				ClientID:     clientID,
-				ClientSecret: clientSecret,
-				RedirectURL:  fmt.Sprintf("%s/admin-ui/oauth/callback/github", s.baseURL),
-				Scopes:       []string{"user:email", "read:user"},
-				Endpoint:     github.Endpoint,
-			}
-		}
-	}
+// OAuthLoginTTL bounds the time between starting a sign-in and its callback
+const OAuthLoginTTL = 10 * time.Minute
+
+// ErrInvalidOAuthState is returned when a callback does not belong to the
+// sign-in it claims to complete
+var ErrInvalidOAuthState = errors.New("invalid or expired OAuth state")
+
+// ErrOAuthEmailUnavailable is returned when the provider does not share a
+// verified email address of the user
+var ErrOAuthEmailUnavailable = errors.New("OAuth provider returned no verified email address")
+
+// OAuthLogin is a started sign-in. The caller keeps it, e.g. in a cookie,
+// and hands it back with the callback, which must carry its state.
+type OAuthLogin struct {
+	Provider OAuthProvider `json:"provider"`
+	State    string        `json:"state"`
+	// CodeVerifier is the PKCE secret whose challenge is in the authorization URL
+	CodeVerifier string `json:"code_verifier"`
+	// Nonce must come back in the ID token of OpenID Connect providers
+	Nonce     string    `json:"nonce,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+	// URL is the authorization URL to send the browser to
+	URL string `json:"-"`
 }
 
-// getEnvVar retrieves environment variable
-func (s *OAuthService) getEnvVar(key string) string {
-	return os.Getenv(key)
+// verify checks that a callback for provider with state completes the login
+func (l *OAuthLogin) verify(provider OAuthProvider, state string) error {
+	if l == nil || l.State == "" || l.Provider != provider ||
+		subtle.ConstantTimeCompare([]byte(l.State), []byte(state)) != 1 ||
+		time.Since(l.StartedAt) > OAuthLoginTTL {
+		return ErrInvalidOAuthState
+	}
+	return nil
 }
 
-// GetAuthURL generates OAuth authorization URL for the specified provider
-func (s *OAuthService) GetAuthURL(provider OAuthProvider, state string) (string, error) {
-	config, exists := s.oauthConfigs[provider]
+// BeginLogin starts a sign-in with the provider, generating its state, PKCE
+// verifier and, for OpenID Connect providers, nonce
+func (s *OAuthService) BeginLogin(ctx context.Context, provider OAuthProvider) (*OAuthLogin, error) {
+	registered, exists := s.providers[provider]
 	if !exists {
-		return "", fmt.Errorf("OAuth provider %s not configured", provider)
+		return nil, fmt.Errorf("OAuth provider %s not configured", provider)
 	}
-
-	if state == "" {
-		state = s.generateState()
+	config, _, err := registered.resolve(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	return config.AuthCodeURL(state, oauth2.AccessTypeOffline), nil
+	login := &OAuthLogin{
+		Provider:     provider,
+		State:        s.generateState(),
+		CodeVerifier: oauth2.GenerateVerifier(),
+		StartedAt:    time.Now(),
+	}
+	options := []oauth2.AuthCodeOption{
+		oauth2.AccessTypeOffline,
+		oauth2.S256ChallengeOption(login.CodeVerifier),
+	}
+	if registered.openIDConnect() {
+		login.Nonce = s.generateState()
+		options = append(options, oauth2.SetAuthURLParam("nonce", login.Nonce))
+	}
+	login.URL = config.AuthCodeURL(login.State, options...)
+	return login, nil
 }
 
-// HandleCallback processes OAuth callback and returns user authentication result
-func (s *OAuthService) HandleCallback(provider OAuthProvider, code, state string) (*dto.AdminLoginResponse, error) {
-	config, exists := s.oauthConfigs[provider]
+// HandleCallback completes the sign-in login with the code and state of the
+// provider callback and returns user authentication result
+func (s *OAuthService) HandleCallback(ctx context.Context, provider OAuthProvider, code, state string, login *OAuthLogin) (*dto.AdminLoginResponse, error) {
+	registered, exists := s.providers[provider]
 	if !exists {
 		return nil, fmt.Errorf("OAuth provider %s not configured", provider)
 	}
+	if err := login.verify(provider, state); err != nil {
+		return nil, err
+	}
+	config, userInfoURL, err := registered.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	// Exchange code for token
-	token, err := config.Exchange(context.Background(), code)
+	token, err := config.Exchange(ctx, code, oauth2.VerifierOption(login.CodeVerifier))
 	if err != nil {
-		logger.GetLogger().Error("OAuth token exchange failed",
+		logger.FromContext(ctx).Error("OAuth token exchange failed",
 			zap.String("provider", string(provider)),
 			zap.Error(err))
 		return nil, fmt.Errorf("failed to exchange OAuth code: %w", err)
 	}
 
+	// OpenID Connect providers vouch for the user in the ID token
+	var subject string
+	if registered.openIDConnect() {
+		subject, err = registered.verifyIDToken(token, login.Nonce)
+		if err != nil {
+			logger.FromContext(ctx).Warn("OAuth ID token rejected",
+				zap.String("provider", string(provider)),
+				zap.Error(err))
+			return nil, fmt.Errorf("%w: %v", ErrInvalidOAuthState, err)
+		}
+	}
+
 	// Get user info from provider
-	userInfo, err := s.getUserInfo(provider, token)
+	userInfo, err := s.getUserInfo(ctx, registered, userInfoURL, token)
+	if errors.Is(err, ErrOAuthEmailUnavailable) {
+		logger.FromContext(ctx).Warn("OAuth user has no verified email address",
+			zap.String("provider", string(provider)))
+		return nil, err
+	}
 	if err != nil {
-		logger.GetLogger().Error("Failed to get OAuth user info",
+		logger.FromContext(ctx).Error("Failed to get OAuth user info",
 			zap.String("provider", string(provider)),
 			zap.Error(err))
 		return nil, fmt.Errorf("failed to get user info: %w", err)
 	}
+	if subject != "" && userInfo.ID != subject {
+		return nil, fmt.Errorf("%w: userinfo subject does not match the ID token", ErrInvalidOAuthState)
+	}
 
 	// Find or create user
-	user, err := s.findOrCreateUser(provider, userInfo)
+	user, err := s.findOrCreateUser(ctx, provider, userInfo)
 	if err != nil {
-		logger.GetLogger().Error("Failed to find or create OAuth user",
+		logger.FromContext(ctx).Error("Failed to find or create OAuth user",
 			zap.String("provider", string(provider)),
 			zap.String("oauthID", userInfo.ID),
 			zap.Error(err))
@@ -152,17 +216,16 @@ func (s *OAuthService) HandleCallback(provider OAuthProvider, code, state string
 
 	// Record login
 	if err := user.RecordLogin(); err != nil {
-		logger.GetLogger().Warn("Failed to record login",
+		logger.FromContext(ctx).Warn("Failed to record login",
 			zap.String("userID", user.GetID()),
 			zap.Error(err))
 	}
 
 	// Save user (Create or Update based on whether it's new)
-	ctx := context.Background()
 	if strings.HasPrefix(user.GetID(), "user_") {
 		// New user
 		if _, err := s.userRepo.Create(ctx, user); err != nil {
-			logger.GetLogger().Error("Failed to create user after OAuth login",
+			logger.FromContext(ctx).Error("Failed to create user after OAuth login",
 				zap.String("userID", user.GetID()),
 				zap.Error(err))
 			return nil, fmt.Errorf("failed to create user: %w", err)
@@ -170,7 +233,7 @@ func (s *OAuthService) HandleCallback(provider OAuthProvider, code, state string
 	} else {
 		// Existing user
 		if _, err := s.userRepo.Update(ctx, user); err != nil {
-			logger.GetLogger().Error("Failed to update user after OAuth login",
+			logger.FromContext(ctx).Error("Failed to update user after OAuth login",
 				zap.String("userID", user.GetID()),
 				zap.Error(err))
 			return nil, fmt.Errorf("failed to update user: %w", err)
@@ -180,7 +243,7 @@ func (s *OAuthService) HandleCallback(provider OAuthProvider, code, state string
 	// Generate JWT
 	jwtToken, err := s.generateJWT(user)
 	if err != nil {
-		logger.GetLogger().Error("Failed to generate JWT for OAuth user",
+		logger.FromContext(ctx).Error("Failed to generate JWT for OAuth user",
 			zap.String("userID", user.GetID()),
 			zap.Error(err))
 		return nil, fmt.Errorf("failed to generate JWT: %w", err)
@@ -199,111 +262,78 @@ func (s *OAuthService) HandleCallback(provider OAuthProvider, code, state string
 	}, nil
 }
 
-// getUserInfo retrieves user information from OAuth provider
-func (s *OAuthService) getUserInfo(provider OAuthProvider, token *oauth2.Token) (*OAuthUserInfo, error) {
-	client := &http.Client{}
-	req, err := http.NewRequest("GET", s.getUserInfoURL(provider), nil)
+// getUserInfo retrieves user information from OAuth provider. Users must
+// come with an email address, which providers with an emails endpoint fill in
+// with the primary verified address.
+func (s *OAuthService) getUserInfo(ctx context.Context, provider *oauthProvider, userInfoURL string, token *oauth2.Token) (*OAuthUserInfo, error) {
+	body, err := fetchOAuthResource(ctx, userInfoURL, token)
 	if err != nil {
 		return nil, err
 	}
-
-	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
-	req.Header.Set("Accept", "application/json")
-
-	resp, err := client.Do(req)
+	userInfo, err := provider.parse(body)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("OAuth provider returned status %d", resp.StatusCode)
+	if provider.emailsURL != "" {
+		body, err := fetchOAuthResource(ctx, provider.emailsURL, token)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get email addresses: %w", err)
+		}
+		email, err := provider.parseEmails(body)
+		if err != nil {
+			return nil, err
+		}
+		userInfo.Email = email
+		userInfo.VerifiedEmail = true
+	}
+	if userInfo.Email == "" {
+		return nil, ErrOAuthEmailUnavailable
 	}
+	return userInfo, nil
+}
 
-	body, err := io.ReadAll(resp.Body)
+// fetchOAuthResource reads a JSON resource of the provider on behalf of the
+// user holding token
+func fetchOAuthResource(ctx context.Context, url string, token *oauth2.Token) ([]byte, error) {
+	client := &http.Client{}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	return s.parseUserInfo(provider, body)
-}
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	req.Header.Set("Accept", "application/json")
 
-// getUserInfoURL returns the user info endpoint for the provider
-func (s *OAuthService) getUserInfoURL(provider OAuthProvider) string {
-	switch provider {
-	case Google:
-		return "https://www.googleapis.com/oauth2/v2/userinfo"
-	case GitHub:
-		return "https://api.github.com/user"
-	default:
-		return ""
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
 	}
-}
-
-// parseUserInfo parses user information from provider response
-func (s *OAuthService) parseUserInfo(provider OAuthProvider, data []byte) (*OAuthUserInfo, error) {
-	var userInfo OAuthUserInfo
-
-	switch provider {
-	case Google:
-		var googleUser struct {
-			ID            string `json:"id"`
-			Email         string `json:"email"`
-			Name          string `json:"name"`
-			VerifiedEmail bool   `json:"verified_email"`
-			Picture       string `json:"picture"`
-		}
-		if err := json.Unmarshal(data, &googleUser); err != nil {
-			return nil, err
-		}
-		userInfo = OAuthUserInfo{
-			ID:            googleUser.ID,
-			Email:         googleUser.Email,
-			Name:          googleUser.Name,
-			Username:      strings.Split(googleUser.Email, "@")[0], // Use email prefix as username
-			AvatarURL:     googleUser.Picture,
-			VerifiedEmail: googleUser.VerifiedEmail,
-		}
-
-	case GitHub:
-		var githubUser struct {
-			ID    int    `json:"id"`
-			Login string `json:"login"`
-			Name  string `json:"name"`
-			Email string `json:"email"`
-		}
-		if err := json.Unmarshal(data, &githubUser); err != nil {
-			return nil, err
-		}
-
-		// GitHub may not return email in user endpoint, handle separately if needed
-		userInfo = OAuthUserInfo{
-			ID:        fmt.Sprintf("%d", githubUser.ID),
-			Email:     githubUser.Email,
-			Name:      githubUser.Name,
-			Username:  githubUser.Login,
-			AvatarURL: "",
-		}
+	defer resp.Body.Close()
 
-	default:
-		return nil, fmt.Errorf("unsupported OAuth provider: %s", provider)
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("OAuth provider returned status %d", resp.StatusCode)
 	}
 
-	return &userInfo, nil
+	return io.ReadAll(resp.Body)
 }
 
 // findOrCreateUser finds existing user or creates new one
-func (s *OAuthService) findOrCreateUser(provider OAuthProvider, userInfo *OAuthUserInfo) (*usermodel.User, error) {
-	ctx := context.Background()
-
+func (s *OAuthService) findOrCreateUser(ctx context.Context, provider OAuthProvider, userInfo *OAuthUserInfo) (*usermodel.User, error) {
 	// Try to find existing user by OAuth ID
 	existingUser, err := s.userRepo.GetByOAuthID(ctx, string(provider), userInfo.ID)
 	if err == nil && existingUser != nil {
 		return existingUser, nil
 	}
+	if err != nil && !errors.Is(err, usermodel.ErrUserNotFound) {
+		return nil, fmt.Errorf("failed to look up user by OAuth ID: %w", err)
+	}
 
 	// Try to find by email
 	existingUser, err = s.userRepo.GetByEmail(ctx, userInfo.Email)
+	if err != nil && !errors.Is(err, usermodel.ErrUserNotFound) {
+		return nil, fmt.Errorf("failed to look up user by email: %w", err)
+	}
 	if err == nil && existingUser != nil {
 		// Link OAuth account to existing user
 		if err := existingUser.SetOAuthProvider(string(provider)); err != nil {
@@ -366,11 +396,8 @@ func (s *OAuthService) generateJWT(user *usermodel.User) (string, error) {
 		"iat":      time.Now().Unix(),
 	}
 
-	// Create token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	// Sign token with secret
-	tokenString, err := token.SignedString([]byte(s.jwtSecret))
+	// Sign token with the configured provider
+	tokenString, err := s.tokens.Sign(claims)
 	if err != nil {
 		return "", fmt.Errorf("failed to sign JWT: %w", err)
 	}
@@ -380,6 +407,16 @@ func (s *OAuthService) generateJWT(user *usermodel.User) (string, error) {
 
 // IsProviderConfigured checks if OAuth provider is configured
 func (s *OAuthService) IsProviderConfigured(provider OAuthProvider) bool {
-	_, exists := s.oauthConfigs[provider]
+	_, exists := s.providers[provider]
 	return exists
 }
+
+// Providers returns the configured OAuth providers sorted by name
+func (s *OAuthService) Providers() []OAuthProvider {
+	providers := make([]OAuthProvider, 0, len(s.providers))
+	for provider := range s.providers {
+		providers = append(providers, provider)
+	}
+	slices.Sort(providers)
+	return providers
+}
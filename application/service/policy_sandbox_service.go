@@ -0,0 +1,491 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aruncs31s/azf/infrastructure/abac"
+	"github.com/aruncs31s/azf/initializer"
+	apperrors "github.com/aruncs31s/azf/shared/errors"
+	"github.com/aruncs31s/azf/shared/logger"
+	"github.com/aruncs31s/azf/utils"
+	"github.com/casbin/casbin/v2"
+	"go.uber.org/zap"
+)
+
+// DefaultPolicySandboxTTL is how long an untouched sandbox is kept
+const DefaultPolicySandboxTTL = time.Hour
+
+// Operations of staged sandbox changes
+const (
+	SandboxAddPolicy            = "add_policy"
+	SandboxRemovePolicy         = "remove_policy"
+	SandboxAddGroupingPolicy    = "add_grouping_policy"
+	SandboxRemoveGroupingPolicy = "remove_grouping_policy"
+)
+
+// PolicySandboxChange is a policy change staged in a sandbox
+type PolicySandboxChange struct {
+	Op   string   `json:"op"`
+	Rule []string `json:"rule"`
+}
+
+// PolicySandbox is the view of an admin's sandbox. Token must accompany every
+// request changing the sandbox, so a forged cross-site request cannot stage
+// or apply changes with the admin's cookies alone.
+type PolicySandbox struct {
+	Owner     string                `json:"owner"`
+	Token     string                `json:"token"`
+	Changes   []PolicySandboxChange `json:"changes"`
+	CreatedAt time.Time             `json:"created_at"`
+	ExpiresAt time.Time             `json:"expires_at"`
+}
+
+// PolicySandboxCheck is the decision for a request in the sandbox and in the
+// live policies
+type PolicySandboxCheck struct {
+	Subject string `json:"subject"`
+	Object  string `json:"object"`
+	Action  string `json:"action"`
+	Sandbox bool   `json:"sandbox"`
+	Live    bool   `json:"live"`
+	Changed bool   `json:"changed"`
+}
+
+// PolicySimulationRequest is a what-if request: would user or role be
+// allowed to call method on path, with Changes made to the live policies
+type PolicySimulationRequest struct {
+	User   string `json:"user"`
+	Role   string `json:"role"`
+	Path   string `json:"path"`
+	Method string `json:"method"`
+	// Changes are proposed policy changes, evaluated on a copy of the live
+	// policies and never saved
+	Changes []PolicySandboxChange `json:"changes"`
+}
+
+// PolicySimulation is the decision for a simulated request
+type PolicySimulation struct {
+	Subject string `json:"subject"`
+	Object  string `json:"object"`
+	Action  string `json:"action"`
+	Allowed bool   `json:"allowed"`
+	// MatchedRule is the policy rule that allowed the request; empty when
+	// it is denied
+	MatchedRule []string `json:"matched_rule,omitempty"`
+	// Roles are the roles the subject holds, directly or inherited
+	Roles []string `json:"roles"`
+	// Live is the decision without the proposed changes
+	Live    bool     `json:"live"`
+	Changed bool     `json:"changed"`
+	Trace   []string `json:"trace"`
+}
+
+// PolicySandboxService stages policy changes of each admin on a copy of the
+// live policies. The changes can be tested there and are applied to the live
+// enforcer together, in one policy transaction.
+type PolicySandboxService interface {
+	// Open returns the sandbox of owner, creating one from the live policies
+	// if there is none
+	Open(owner string) (*PolicySandbox, error)
+	Get(owner, token string) (*PolicySandbox, error)
+	Stage(owner, token string, change PolicySandboxChange) (*PolicySandbox, error)
+	Check(owner, token, subject, object, action string) (*PolicySandboxCheck, error)
+	// Apply makes the staged changes on the live enforcer and closes the
+	// sandbox. Either every change is applied or none is.
+	Apply(owner, token string) (int, error)
+	Discard(owner, token string) error
+	// Simulate decides a request against the live policies with proposed
+	// changes, without a sandbox and without changing anything
+	Simulate(request PolicySimulationRequest) (*PolicySimulation, error)
+}
+
+// policySandbox is the sandbox of one admin
+type policySandbox struct {
+	info     PolicySandbox
+	enforcer *casbin.Enforcer
+}
+
+// policySandboxService implements PolicySandboxService in memory
+type policySandboxService struct {
+	ttl time.Duration
+
+	mu        sync.Mutex
+	sandboxes map[string]*policySandbox
+}
+
+// NewPolicySandboxService creates a sandbox service. Sandboxes untouched for
+// ttl are dropped, DefaultPolicySandboxTTL when ttl is not positive.
+func NewPolicySandboxService(ttl time.Duration) PolicySandboxService {
+	if ttl <= 0 {
+		ttl = DefaultPolicySandboxTTL
+	}
+	return &policySandboxService{
+		ttl:       ttl,
+		sandboxes: make(map[string]*policySandbox),
+	}
+}
+
+func (s *policySandboxService) Open(owner string) (*PolicySandbox, error) {
+	if owner == "" {
+		return nil, apperrors.Newf(apperrors.ErrForbidden, "a signed-in admin is required to use a policy sandbox")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pruneLocked()
+
+	if sandbox, ok := s.sandboxes[owner]; ok {
+		s.touchLocked(sandbox)
+		return sandbox.view(), nil
+	}
+
+	enforcer, err := cloneEnforcer(initializer.CasbinEnforcer)
+	if err != nil {
+		return nil, err
+	}
+	token, err := newSandboxToken()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	sandbox := &policySandbox{
+		info: PolicySandbox{
+			Owner:     owner,
+			Token:     token,
+			CreatedAt: now,
+			ExpiresAt: now.Add(s.ttl),
+		},
+		enforcer: enforcer,
+	}
+	s.sandboxes[owner] = sandbox
+	return sandbox.view(), nil
+}
+
+func (s *policySandboxService) Get(owner, token string) (*PolicySandbox, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sandbox, err := s.sandboxLocked(owner, token)
+	if err != nil {
+		return nil, err
+	}
+	return sandbox.view(), nil
+}
+
+func (s *policySandboxService) Stage(owner, token string, change PolicySandboxChange) (*PolicySandbox, error) {
+	change.Rule = trimRule(change.Rule)
+	if len(change.Rule) < 2 {
+		return nil, apperrors.Newf(apperrors.ErrValidation, "a rule needs at least a subject and one more value")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sandbox, err := s.sandboxLocked(owner, token)
+	if err != nil {
+		return nil, err
+	}
+	changed, err := applySandboxChange(sandbox.enforcer, change)
+	if err != nil {
+		return nil, err
+	}
+	if !changed {
+		return nil, apperrors.Newf(apperrors.ErrConflict, "%s %v changes nothing in the sandbox", change.Op, change.Rule)
+	}
+	sandbox.info.Changes = append(sandbox.info.Changes, change)
+	return sandbox.view(), nil
+}
+
+func (s *policySandboxService) Check(owner, token, subject, object, action string) (*PolicySandboxCheck, error) {
+	if subject == "" || object == "" || action == "" {
+		return nil, apperrors.Newf(apperrors.ErrValidation, "subject, object and action are required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sandbox, err := s.sandboxLocked(owner, token)
+	if err != nil {
+		return nil, err
+	}
+
+	object = utils.NormalizePathForLookup(object)
+	inSandbox, err := sandbox.enforcer.Enforce(abac.RequestValues(sandbox.enforcer, subject, object, action, "", nil)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate sandbox policies: %w", err)
+	}
+	live := initializer.CasbinEnforcer
+	var inLive bool
+	if live != nil {
+		inLive, err = initializer.Enforce(live, abac.RequestValues(live, subject, object, action, "", nil)...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate live policies: %w", err)
+		}
+	}
+	return &PolicySandboxCheck{
+		Subject: subject,
+		Object:  object,
+		Action:  action,
+		Sandbox: inSandbox,
+		Live:    inLive,
+		Changed: inSandbox != inLive,
+	}, nil
+}
+
+func (s *policySandboxService) Apply(owner, token string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sandbox, err := s.sandboxLocked(owner, token)
+	if err != nil {
+		return 0, err
+	}
+	if len(sandbox.info.Changes) == 0 {
+		return 0, apperrors.Newf(apperrors.ErrValidation, "the sandbox has no changes to apply")
+	}
+
+	applied := 0
+	err = initializer.WithPolicyTransaction(initializer.CasbinEnforcer, func(tx *initializer.PolicyTransaction) error {
+		for _, change := range sandbox.info.Changes {
+			changed, err := applyTransactionChange(tx, change)
+			if err != nil {
+				return err
+			}
+			if changed {
+				applied++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	delete(s.sandboxes, owner)
+	logger.Info("Applied policy sandbox",
+		zap.String("owner", owner),
+		zap.Int("staged", len(sandbox.info.Changes)),
+		zap.Int("applied", applied))
+	return applied, nil
+}
+
+func (s *policySandboxService) Discard(owner, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.sandboxLocked(owner, token); err != nil {
+		return err
+	}
+	delete(s.sandboxes, owner)
+	return nil
+}
+
+func (s *policySandboxService) Simulate(request PolicySimulationRequest) (*PolicySimulation, error) {
+	request.User = strings.TrimSpace(request.User)
+	request.Role = strings.TrimSpace(request.Role)
+	if request.User == "" && request.Role == "" {
+		return nil, apperrors.Newf(apperrors.ErrValidation, "a user or role is required")
+	}
+	if strings.TrimSpace(request.Path) == "" || strings.TrimSpace(request.Method) == "" {
+		return nil, apperrors.Newf(apperrors.ErrValidation, "path and method are required")
+	}
+	live := initializer.CasbinEnforcer
+	if live == nil {
+		return nil, fmt.Errorf("casbin enforcer not available")
+	}
+
+	// Requests are authorized as the role of the token; the user's role
+	// assignments stand in for it when no role is given
+	simulation := &PolicySimulation{
+		Subject: request.Role,
+		Object:  utils.NormalizePathForLookup(request.Path),
+		Action:  strings.ToUpper(strings.TrimSpace(request.Method)),
+	}
+	if simulation.Subject == "" {
+		simulation.Subject = request.User
+		simulation.Trace = append(simulation.Trace, fmt.Sprintf("No role given, evaluating user %s through its role assignments", request.User))
+	} else {
+		simulation.Trace = append(simulation.Trace, fmt.Sprintf("Evaluating role %s, as requests are authorized with the role of their token", request.Role))
+	}
+	if simulation.Object != request.Path {
+		simulation.Trace = append(simulation.Trace, fmt.Sprintf("Path %s normalized to %s", request.Path, simulation.Object))
+	}
+
+	err := initializer.ReadPolicies(live, func() error {
+		allowed, _, err := live.EnforceEx(abac.RequestValues(live, simulation.Subject, simulation.Object, simulation.Action, "", nil)...)
+		simulation.Live = allowed
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate live policies: %w", err)
+	}
+
+	enforcer := live
+	if len(request.Changes) > 0 {
+		if enforcer, err = cloneEnforcer(live); err != nil {
+			return nil, err
+		}
+		for _, change := range request.Changes {
+			change.Rule = trimRule(change.Rule)
+			if len(change.Rule) < 2 {
+				return nil, apperrors.Newf(apperrors.ErrValidation, "a rule needs at least a subject and one more value")
+			}
+			changed, err := applySandboxChange(enforcer, change)
+			if err != nil {
+				return nil, err
+			}
+			if changed {
+				simulation.Trace = append(simulation.Trace, fmt.Sprintf("Applied %s %s", change.Op, strings.Join(change.Rule, ", ")))
+			} else {
+				simulation.Trace = append(simulation.Trace, fmt.Sprintf("Skipped %s %s: it changes nothing", change.Op, strings.Join(change.Rule, ", ")))
+			}
+		}
+	}
+
+	evaluate := func() error {
+		roles, err := enforcer.GetImplicitRolesForUser(simulation.Subject)
+		if err != nil {
+			return err
+		}
+		simulation.Roles = append([]string{}, roles...)
+		allowed, explain, err := enforcer.EnforceEx(abac.RequestValues(enforcer, simulation.Subject, simulation.Object, simulation.Action, "", nil)...)
+		simulation.Allowed, simulation.MatchedRule = allowed, explain
+		return err
+	}
+	// A copy with proposed changes is only used here and needs no lock
+	if enforcer == live {
+		err = initializer.ReadPolicies(live, evaluate)
+	} else {
+		err = evaluate()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate policies: %w", err)
+	}
+
+	if len(simulation.Roles) > 0 {
+		simulation.Trace = append(simulation.Trace, fmt.Sprintf("%s holds roles %s", simulation.Subject, strings.Join(simulation.Roles, ", ")))
+	} else {
+		simulation.Trace = append(simulation.Trace, fmt.Sprintf("%s holds no roles", simulation.Subject))
+	}
+	if simulation.Allowed {
+		simulation.Trace = append(simulation.Trace, fmt.Sprintf("Allowed by p, %s", strings.Join(simulation.MatchedRule, ", ")))
+	} else {
+		simulation.Trace = append(simulation.Trace, fmt.Sprintf("Denied: no policy allows %s to %s %s", simulation.Subject, simulation.Action, simulation.Object))
+	}
+	simulation.Changed = simulation.Allowed != simulation.Live
+	return simulation, nil
+}
+
+// sandboxLocked returns the sandbox of owner if token is its token
+func (s *policySandboxService) sandboxLocked(owner, token string) (*policySandbox, error) {
+	s.pruneLocked()
+	sandbox, ok := s.sandboxes[owner]
+	if !ok || owner == "" {
+		return nil, apperrors.Newf(apperrors.ErrNotFound, "no open policy sandbox")
+	}
+	if subtle.ConstantTimeCompare([]byte(sandbox.info.Token), []byte(token)) != 1 {
+		return nil, apperrors.Newf(apperrors.ErrForbidden, "invalid policy sandbox token")
+	}
+	s.touchLocked(sandbox)
+	return sandbox, nil
+}
+
+func (s *policySandboxService) touchLocked(sandbox *policySandbox) {
+	sandbox.info.ExpiresAt = time.Now().Add(s.ttl)
+}
+
+// pruneLocked drops expired sandboxes
+func (s *policySandboxService) pruneLocked() {
+	now := time.Now()
+	for owner, sandbox := range s.sandboxes {
+		if now.After(sandbox.info.ExpiresAt) {
+			delete(s.sandboxes, owner)
+		}
+	}
+}
+
+// view returns a copy of the sandbox info
+func (sb *policySandbox) view() *PolicySandbox {
+	info := sb.info
+	info.Changes = append([]PolicySandboxChange{}, sb.info.Changes...)
+	return &info
+}
+
+// cloneEnforcer returns an enforcer without storage holding a copy of the
+// model and policies of live
+func cloneEnforcer(live *casbin.Enforcer) (*casbin.Enforcer, error) {
+	if live == nil {
+		return nil, fmt.Errorf("casbin enforcer not available")
+	}
+	var clone *casbin.Enforcer
+	err := initializer.ReadPolicies(live, func() error {
+		var err error
+		clone, err = casbin.NewEnforcer(live.GetModel().Copy())
+		if err != nil {
+			return fmt.Errorf("failed to create sandbox enforcer: %w", err)
+		}
+		return clone.BuildRoleLinks()
+	})
+	if err != nil {
+		return nil, err
+	}
+	abac.Register(clone)
+	return clone, nil
+}
+
+// applySandboxChange makes change on a sandbox enforcer
+func applySandboxChange(enforcer *casbin.Enforcer, change PolicySandboxChange) (bool, error) {
+	switch change.Op {
+	case SandboxAddPolicy:
+		return enforcer.AddPolicy(change.Rule)
+	case SandboxRemovePolicy:
+		return enforcer.RemovePolicy(change.Rule)
+	case SandboxAddGroupingPolicy:
+		return enforcer.AddGroupingPolicy(change.Rule)
+	case SandboxRemoveGroupingPolicy:
+		return enforcer.RemoveGroupingPolicy(change.Rule)
+	}
+	return false, apperrors.Newf(apperrors.ErrValidation, "unknown sandbox operation %q", change.Op)
+}
+
+// applyTransactionChange makes change on the live enforcer in tx
+func applyTransactionChange(tx *initializer.PolicyTransaction, change PolicySandboxChange) (bool, error) {
+	switch change.Op {
+	case SandboxAddPolicy:
+		return tx.AddPolicy(change.Rule)
+	case SandboxRemovePolicy:
+		return tx.RemovePolicy(change.Rule)
+	case SandboxAddGroupingPolicy:
+		return tx.AddGroupingPolicy(change.Rule)
+	case SandboxRemoveGroupingPolicy:
+		return tx.RemoveGroupingPolicy(change.Rule)
+	}
+	return false, apperrors.Newf(apperrors.ErrValidation, "unknown sandbox operation %q", change.Op)
+}
+
+// trimRule trims the rule values and drops trailing empty ones
+func trimRule(rule []string) []string {
+	trimmed := make([]string, 0, len(rule))
+	for _, value := range rule {
+		trimmed = append(trimmed, strings.TrimSpace(value))
+	}
+	for len(trimmed) > 0 && trimmed[len(trimmed)-1] == "" {
+		trimmed = trimmed[:len(trimmed)-1]
+	}
+	return trimmed
+}
+
+// newSandboxToken returns a random sandbox token
+func newSandboxToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate sandbox token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aruncs31s/azf/infrastructure/abac"
+	"github.com/aruncs31s/azf/initializer"
+	apperrors "github.com/aruncs31s/azf/shared/errors"
+)
+
+// Rule types managed by the policy API
+const (
+	PolicyTypePolicy   = "p"
+	PolicyTypeGrouping = "g"
+)
+
+const (
+	// DefaultPolicyPageSize is the number of rules listed when no limit is given
+	DefaultPolicyPageSize = 100
+	// MaxPolicyPageSize is the largest number of rules listed at once
+	MaxPolicyPageSize = 1000
+	// MaxPolicyImportSize is the largest number of rules imported at once
+	MaxPolicyImportSize = 5000
+)
+
+// PolicyRule is a Casbin rule: a policy ("p": subject, object, action and
+// optional condition) or a role assignment ("g": user or role, role)
+type PolicyRule struct {
+	PType string   `json:"ptype"`
+	Rule  []string `json:"rule"`
+}
+
+// PolicyFilter selects the rules to list. Role matches the subject of
+// policies and the role of assignments; Resource matches policy objects.
+type PolicyFilter struct {
+	PType    string
+	Role     string
+	Resource string
+	Limit    int
+	Offset   int
+}
+
+// PolicyPage is a page of listed rules
+type PolicyPage struct {
+	Rules  []PolicyRule `json:"rules"`
+	Total  int          `json:"total"`
+	Limit  int          `json:"limit"`
+	Offset int          `json:"offset"`
+}
+
+// PolicyImportResult counts the rules of an import
+type PolicyImportResult struct {
+	Added   int `json:"added"`
+	Skipped int `json:"skipped"`
+}
+
+// PolicyService manages Casbin policies and role assignments for the admin API
+type PolicyService interface {
+	List(filter PolicyFilter) (*PolicyPage, error)
+	// Add adds a rule and reports whether it was not present yet
+	Add(rule PolicyRule) (bool, error)
+	// Remove removes a rule and reports whether it was present
+	Remove(rule PolicyRule) (bool, error)
+	// Import adds rules in one policy transaction; rules already present are
+	// skipped, and an invalid rule rejects the whole import
+	Import(rules []PolicyRule) (*PolicyImportResult, error)
+}
+
+// policyService implements PolicyService on the shared Casbin enforcer
+type policyService struct{}
+
+// NewPolicyService creates a new policy service
+func NewPolicyService() PolicyService {
+	return &policyService{}
+}
+
+func (s *policyService) List(filter PolicyFilter) (*PolicyPage, error) {
+	if filter.PType != "" && filter.PType != PolicyTypePolicy && filter.PType != PolicyTypeGrouping {
+		return nil, apperrors.Newf(apperrors.ErrValidation, "ptype must be %q or %q", PolicyTypePolicy, PolicyTypeGrouping)
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultPolicyPageSize
+	} else if filter.Limit > MaxPolicyPageSize {
+		filter.Limit = MaxPolicyPageSize
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	enforcer := initializer.CasbinEnforcer
+	var matched []PolicyRule
+	err := initializer.ReadPolicies(enforcer, func() error {
+		if filter.PType != PolicyTypeGrouping {
+			policies, err := enforcer.GetPolicy()
+			if err != nil {
+				return fmt.Errorf("failed to get policies: %w", err)
+			}
+			for _, rule := range policies {
+				if matchesPolicyFilter(PolicyTypePolicy, rule, filter) {
+					matched = append(matched, PolicyRule{PType: PolicyTypePolicy, Rule: rule})
+				}
+			}
+		}
+		if filter.PType != PolicyTypePolicy {
+			assignments, err := enforcer.GetGroupingPolicy()
+			if err != nil {
+				return fmt.Errorf("failed to get role assignments: %w", err)
+			}
+			for _, rule := range assignments {
+				if matchesPolicyFilter(PolicyTypeGrouping, rule, filter) {
+					matched = append(matched, PolicyRule{PType: PolicyTypeGrouping, Rule: rule})
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	page := &PolicyPage{
+		Rules:  []PolicyRule{},
+		Total:  len(matched),
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	}
+	if filter.Offset < len(matched) {
+		end := min(filter.Offset+filter.Limit, len(matched))
+		page.Rules = matched[filter.Offset:end]
+	}
+	return page, nil
+}
+
+func (s *policyService) Add(rule PolicyRule) (bool, error) {
+	rule, err := normalizePolicyRule(rule, abac.IsABACModel(initializer.CasbinEnforcer))
+	if err != nil {
+		return false, err
+	}
+	return initializer.ApplyPolicyChange(initializer.CasbinEnforcer, func(tx *initializer.PolicyTransaction) (bool, error) {
+		return addPolicyRule(tx, rule)
+	})
+}
+
+func (s *policyService) Remove(rule PolicyRule) (bool, error) {
+	rule, err := normalizePolicyRule(rule, abac.IsABACModel(initializer.CasbinEnforcer))
+	if err != nil {
+		return false, err
+	}
+	return initializer.ApplyPolicyChange(initializer.CasbinEnforcer, func(tx *initializer.PolicyTransaction) (bool, error) {
+		if rule.PType == PolicyTypeGrouping {
+			return tx.RemoveGroupingPolicy(rule.Rule)
+		}
+		return tx.RemovePolicy(rule.Rule)
+	})
+}
+
+func (s *policyService) Import(rules []PolicyRule) (*PolicyImportResult, error) {
+	if len(rules) == 0 {
+		return nil, apperrors.Newf(apperrors.ErrValidation, "no rules to import")
+	}
+	if len(rules) > MaxPolicyImportSize {
+		return nil, apperrors.Newf(apperrors.ErrValidation, "at most %d rules can be imported at once", MaxPolicyImportSize)
+	}
+	conditions := abac.IsABACModel(initializer.CasbinEnforcer)
+	normalized := make([]PolicyRule, 0, len(rules))
+	for i, rule := range rules {
+		rule, err := normalizePolicyRule(rule, conditions)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i, err)
+		}
+		normalized = append(normalized, rule)
+	}
+
+	result := &PolicyImportResult{}
+	err := initializer.WithPolicyTransaction(initializer.CasbinEnforcer, func(tx *initializer.PolicyTransaction) error {
+		for _, rule := range normalized {
+			added, err := addPolicyRule(tx, rule)
+			if err != nil {
+				return err
+			}
+			if added {
+				result.Added++
+			} else {
+				result.Skipped++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// addPolicyRule adds rule in tx
+func addPolicyRule(tx *initializer.PolicyTransaction, rule PolicyRule) (bool, error) {
+	if rule.PType == PolicyTypeGrouping {
+		return tx.AddGroupingPolicy(rule.Rule)
+	}
+	return tx.AddPolicy(rule.Rule)
+}
+
+// normalizePolicyRule trims rule and checks it has the values its type
+// needs; policies may carry a condition when conditions are enforced
+func normalizePolicyRule(rule PolicyRule, conditions bool) (PolicyRule, error) {
+	rule.PType = strings.TrimSpace(rule.PType)
+	rule.Rule = trimRule(rule.Rule)
+	for _, value := range rule.Rule {
+		if value == "" {
+			return rule, apperrors.Newf(apperrors.ErrValidation, "rule %v has an empty value", rule.Rule)
+		}
+	}
+
+	switch rule.PType {
+	case PolicyTypePolicy:
+		if len(rule.Rule) == 4 && !conditions {
+			return rule, apperrors.Newf(apperrors.ErrValidation, "policy conditions need the abac model")
+		}
+		if len(rule.Rule) < 3 || len(rule.Rule) > 4 {
+			return rule, apperrors.Newf(apperrors.ErrValidation,
+				"policy rules need subject, object, action and an optional condition")
+		}
+	case PolicyTypeGrouping:
+		if len(rule.Rule) != 2 {
+			return rule, apperrors.Newf(apperrors.ErrValidation, "role assignments need a user or role and a role")
+		}
+	default:
+		return rule, apperrors.Newf(apperrors.ErrValidation, "ptype must be %q or %q", PolicyTypePolicy, PolicyTypeGrouping)
+	}
+	return rule, nil
+}
+
+// matchesPolicyFilter reports whether a rule of ptype passes the role and
+// resource filters
+func matchesPolicyFilter(ptype string, rule []string, filter PolicyFilter) bool {
+	if ptype == PolicyTypeGrouping {
+		if filter.Resource != "" {
+			return false
+		}
+		return filter.Role == "" || (len(rule) > 1 && rule[1] == filter.Role)
+	}
+	if filter.Role != "" && (len(rule) == 0 || rule[0] != filter.Role) {
+		return false
+	}
+	return filter.Resource == "" || (len(rule) > 1 && rule[1] == filter.Resource)
+}
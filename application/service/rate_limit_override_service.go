@@ -0,0 +1,228 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aruncs31s/azf/domain/api_usage"
+	"github.com/aruncs31s/azf/domain/repository"
+	apperrors "github.com/aruncs31s/azf/shared/errors"
+	"github.com/aruncs31s/azf/shared/logger"
+	"go.uber.org/zap"
+)
+
+// rateLimitOverrideRefreshInterval bounds how long overrides written by
+// another instance can take to be picked up
+const rateLimitOverrideRefreshInterval = time.Minute
+
+// maxOverrideRequestsPerMinute caps override limits to catch typos
+const maxOverrideRequestsPerMinute = 100000
+
+// RateLimitOverrideService manages per-client rate limit overrides.
+// It also serves active overrides to the rate limiter from an in-memory
+// cache so the request path never hits the database.
+type RateLimitOverrideService interface {
+	ListOverrides() (*[]RateLimitOverrideDTO, error)
+	SetOverride(identity string, req SetRateLimitOverrideRequest, createdBy string) (*RateLimitOverrideDTO, error)
+	DeleteOverride(identity string) error
+	GetOverride(identity string) (requestsPerMinute int, burstAllowance int, ok bool)
+}
+
+// rateLimitOverrideService implements RateLimitOverrideService
+type rateLimitOverrideService struct {
+	repo     repository.RateLimitOverrideRepository
+	mu       sync.RWMutex
+	cache    map[string]api_usage.RateLimitOverride
+	loadedAt time.Time
+}
+
+// NewRateLimitOverrideService creates a new rate limit override service
+func NewRateLimitOverrideService(repo repository.RateLimitOverrideRepository) RateLimitOverrideService {
+	s := &rateLimitOverrideService{
+		repo:  repo,
+		cache: make(map[string]api_usage.RateLimitOverride),
+	}
+	s.reload()
+	return s
+}
+
+// ListOverrides returns all overrides, including expired ones
+func (s *rateLimitOverrideService) ListOverrides() (*[]RateLimitOverrideDTO, error) {
+	result := make([]RateLimitOverrideDTO, 0)
+	if s.repo == nil {
+		return &result, nil
+	}
+
+	overrides, err := s.repo.FindAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rate limit overrides: %w", err)
+	}
+	if overrides == nil {
+		return &result, nil
+	}
+
+	now := time.Now()
+	for _, override := range *overrides {
+		result = append(result, toRateLimitOverrideDTO(override, now))
+	}
+	return &result, nil
+}
+
+// SetOverride creates or replaces the override for identity
+func (s *rateLimitOverrideService) SetOverride(identity string, req SetRateLimitOverrideRequest, createdBy string) (*RateLimitOverrideDTO, error) {
+	if s.repo == nil {
+		return nil, fmt.Errorf("rate limit overrides are not available: database is not initialized")
+	}
+
+	identity = strings.TrimSpace(identity)
+	if identity == "" {
+		return nil, apperrors.Newf(apperrors.ErrValidation, "identity cannot be empty")
+	}
+	if req.RequestsPerMinute < 1 || req.RequestsPerMinute > maxOverrideRequestsPerMinute {
+		return nil, apperrors.Newf(apperrors.ErrValidation, "requests_per_minute must be between 1 and %d", maxOverrideRequestsPerMinute)
+	}
+	if req.BurstAllowance < 0 {
+		return nil, apperrors.Newf(apperrors.ErrValidation, "burst_allowance cannot be negative")
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, apperrors.Newf(apperrors.ErrValidation, "expires_at must be in the future")
+	}
+
+	override, err := s.repo.Save(&api_usage.RateLimitOverride{
+		Identity:          identity,
+		RequestsPerMinute: req.RequestsPerMinute,
+		BurstAllowance:    req.BurstAllowance,
+		Reason:            strings.TrimSpace(req.Reason),
+		ExpiresAt:         req.ExpiresAt,
+		CreatedBy:         createdBy,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.cache[override.Identity] = *override
+	s.mu.Unlock()
+
+	logger.Info("Rate limit override set",
+		zap.String("identity", override.Identity),
+		zap.Int("requests_per_minute", override.RequestsPerMinute),
+		zap.Int("burst_allowance", override.BurstAllowance),
+		zap.Bool("temporary", override.ExpiresAt != nil))
+
+	dto := toRateLimitOverrideDTO(*override, time.Now())
+	return &dto, nil
+}
+
+// DeleteOverride removes the override for identity so role limits apply again
+func (s *rateLimitOverrideService) DeleteOverride(identity string) error {
+	if s.repo == nil {
+		return fmt.Errorf("rate limit overrides are not available: database is not initialized")
+	}
+
+	override, err := s.repo.FindByIdentity(identity)
+	if err != nil {
+		return fmt.Errorf("failed to find rate limit override: %w", err)
+	}
+	if override == nil {
+		return apperrors.Newf(apperrors.ErrNotFound, "rate limit override not found: %s", identity)
+	}
+	if err := s.repo.Delete(identity); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	delete(s.cache, identity)
+	s.mu.Unlock()
+
+	logger.Info("Rate limit override removed", zap.String("identity", identity))
+	return nil
+}
+
+// GetOverride returns the active override limits for identity
+func (s *rateLimitOverrideService) GetOverride(identity string) (int, int, bool) {
+	s.mu.RLock()
+	stale := time.Since(s.loadedAt) > rateLimitOverrideRefreshInterval
+	override, exists := s.cache[identity]
+	s.mu.RUnlock()
+
+	if stale {
+		s.reload()
+		s.mu.RLock()
+		override, exists = s.cache[identity]
+		s.mu.RUnlock()
+	}
+
+	if !exists || !override.IsActive(time.Now()) {
+		return 0, 0, false
+	}
+	return override.RequestsPerMinute, override.BurstAllowance, true
+}
+
+// reload replaces the cache with the overrides currently persisted
+func (s *rateLimitOverrideService) reload() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Another caller may have reloaded while we waited for the lock
+	if time.Since(s.loadedAt) <= rateLimitOverrideRefreshInterval {
+		return
+	}
+	s.loadedAt = time.Now()
+
+	if s.repo == nil {
+		return
+	}
+
+	overrides, err := s.repo.FindAll()
+	if err != nil {
+		logger.Warn("Failed to reload rate limit overrides, keeping cached values", zap.Error(err))
+		return
+	}
+
+	cache := make(map[string]api_usage.RateLimitOverride)
+	if overrides != nil {
+		for _, override := range *overrides {
+			cache[override.Identity] = override
+		}
+	}
+	s.cache = cache
+}
+
+func toRateLimitOverrideDTO(override api_usage.RateLimitOverride, now time.Time) RateLimitOverrideDTO {
+	return RateLimitOverrideDTO{
+		Identity:          override.Identity,
+		RequestsPerMinute: override.RequestsPerMinute,
+		BurstAllowance:    override.BurstAllowance,
+		Reason:            override.Reason,
+		ExpiresAt:         override.ExpiresAt,
+		Active:            override.IsActive(now),
+		CreatedBy:         override.CreatedBy,
+		CreatedAt:         override.CreatedAt,
+		UpdatedAt:         override.UpdatedAt,
+	}
+}
+
+// SetRateLimitOverrideRequest is the payload for creating or replacing an override.
+// Leave ExpiresAt empty for a permanent override.
+type SetRateLimitOverrideRequest struct {
+	RequestsPerMinute int        `json:"requests_per_minute" binding:"required"`
+	BurstAllowance    int        `json:"burst_allowance"`
+	Reason            string     `json:"reason"`
+	ExpiresAt         *time.Time `json:"expires_at"`
+}
+
+// RateLimitOverrideDTO is a per-client rate limit override
+type RateLimitOverrideDTO struct {
+	Identity          string     `json:"identity"`
+	RequestsPerMinute int        `json:"requests_per_minute"`
+	BurstAllowance    int        `json:"burst_allowance"`
+	Reason            string     `json:"reason,omitempty"`
+	ExpiresAt         *time.Time `json:"expires_at,omitempty"`
+	Active            bool       `json:"active"`
+	CreatedBy         string     `json:"created_by,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
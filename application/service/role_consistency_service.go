@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	usermodel "github.com/aruncs31s/azf/domain/user_management/model"
+	"github.com/aruncs31s/azf/initializer"
+)
+
+// RoleConsistencyService compares the roles recorded on users with the
+// grouping policies Casbin enforces
+type RoleConsistencyService interface {
+	// Check reports the role assignments that are present on only one side
+	Check() (*RoleConsistencyReport, error)
+}
+
+// roleConsistencyService implements RoleConsistencyService
+type roleConsistencyService struct {
+	userRepo usermodel.UserRepository
+}
+
+// NewRoleConsistencyService creates a new role consistency service
+func NewRoleConsistencyService(userRepo usermodel.UserRepository) RoleConsistencyService {
+	return &roleConsistencyService{
+		userRepo: userRepo,
+	}
+}
+
+func (s *roleConsistencyService) Check() (*RoleConsistencyReport, error) {
+	if s.userRepo == nil {
+		return nil, fmt.Errorf("user repository is not configured")
+	}
+	enforcer := initializer.CasbinEnforcer
+	if enforcer == nil {
+		return nil, fmt.Errorf("casbin enforcer is not initialized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	assignments, err := s.userRepo.ListRoleAssignments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var groupingPolicies, policies [][]string
+	err = initializer.ReadPolicies(enforcer, func() error {
+		var err error
+		if groupingPolicies, err = enforcer.GetGroupingPolicy(); err != nil {
+			return fmt.Errorf("failed to load grouping policies: %w", err)
+		}
+		if policies, err = enforcer.GetPolicy(); err != nil {
+			return fmt.Errorf("failed to load policies: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	inUsers := make(map[RoleAssignmentDTO]bool, len(assignments))
+	usersWithRoles := make(map[string]bool)
+	for _, assignment := range assignments {
+		inUsers[RoleAssignmentDTO{UserID: assignment.UserID, Role: assignment.RoleName}] = true
+		usersWithRoles[assignment.UserID] = true
+	}
+
+	// Grouping subjects that are roles themselves express role inheritance,
+	// not user membership
+	roles := make(map[string]bool)
+	for _, policy := range policies {
+		if len(policy) > 0 {
+			roles[policy[0]] = true
+		}
+	}
+	inCasbin := make(map[RoleAssignmentDTO]bool, len(groupingPolicies))
+	for _, groupPolicy := range groupingPolicies {
+		if len(groupPolicy) < 2 {
+			continue
+		}
+		roles[groupPolicy[1]] = true
+		inCasbin[RoleAssignmentDTO{UserID: groupPolicy[0], Role: groupPolicy[1]}] = true
+	}
+
+	report := &RoleConsistencyReport{
+		CheckedAt:           time.Now(),
+		UserRoleCount:       len(inUsers),
+		GroupingPolicyCount: len(inCasbin),
+		MissingInCasbin:     make([]RoleAssignmentDTO, 0),
+		MissingInUsers:      make([]RoleAssignmentDTO, 0),
+		UnknownSubjects:     make([]RoleAssignmentDTO, 0),
+	}
+	for assignment := range inUsers {
+		if !inCasbin[assignment] {
+			report.MissingInCasbin = append(report.MissingInCasbin, assignment)
+		}
+	}
+
+	candidates := make([]RoleAssignmentDTO, 0)
+	lookupIDs := make([]string, 0)
+	for assignment := range inCasbin {
+		if inUsers[assignment] || (roles[assignment.UserID] && !usersWithRoles[assignment.UserID]) {
+			continue
+		}
+		candidates = append(candidates, assignment)
+		if !usersWithRoles[assignment.UserID] {
+			lookupIDs = append(lookupIDs, assignment.UserID)
+		}
+	}
+	users, err := s.userRepo.GetByIDs(ctx, lookupIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, assignment := range candidates {
+		if _, ok := users[assignment.UserID]; ok || usersWithRoles[assignment.UserID] {
+			report.MissingInUsers = append(report.MissingInUsers, assignment)
+		} else {
+			report.UnknownSubjects = append(report.UnknownSubjects, assignment)
+		}
+	}
+
+	sortRoleAssignments(report.MissingInCasbin)
+	sortRoleAssignments(report.MissingInUsers)
+	sortRoleAssignments(report.UnknownSubjects)
+	report.Consistent = len(report.MissingInCasbin) == 0 && len(report.MissingInUsers) == 0
+	return report, nil
+}
+
+func sortRoleAssignments(assignments []RoleAssignmentDTO) {
+	sort.Slice(assignments, func(i, j int) bool {
+		if assignments[i].UserID != assignments[j].UserID {
+			return assignments[i].UserID < assignments[j].UserID
+		}
+		return assignments[i].Role < assignments[j].Role
+	})
+}
+
+// RoleAssignmentDTO is a user-role pair
+type RoleAssignmentDTO struct {
+	UserID string `json:"user_id"`
+	Role   string `json:"role"`
+}
+
+// RoleConsistencyReport lists the differences between user roles and Casbin
+// grouping policies
+type RoleConsistencyReport struct {
+	Consistent          bool      `json:"consistent"`
+	CheckedAt           time.Time `json:"checked_at"`
+	UserRoleCount       int       `json:"user_role_count"`
+	GroupingPolicyCount int       `json:"grouping_policy_count"`
+	// MissingInCasbin holds roles recorded on users without a grouping policy
+	MissingInCasbin []RoleAssignmentDTO `json:"missing_in_casbin"`
+	// MissingInUsers holds grouping policies of known users whose user record lacks the role
+	MissingInUsers []RoleAssignmentDTO `json:"missing_in_users"`
+	// UnknownSubjects holds grouping policies whose subject has no user record
+	UnknownSubjects []RoleAssignmentDTO `json:"unknown_subjects"`
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/aruncs31s/azf/config"
+	"github.com/aruncs31s/azf/shared/logger"
+	"github.com/aruncs31s/azf/utils"
+	"go.uber.org/zap"
+)
+
+// customDescriptions stores role descriptions that persist across requests
+// and restarts
+var customDescriptions = newRoleDescriptionStore(config.ROLE_DESCRIPTIONS_FILE)
+
+// roleDescriptionStore keeps custom role descriptions in a JSON file. It is
+// safe for concurrent use; a change is only kept once it has been saved.
+type roleDescriptionStore struct {
+	mu           sync.RWMutex
+	path         string
+	loaded       bool
+	descriptions map[string]string
+}
+
+// newRoleDescriptionStore creates a store backed by the file at path, which
+// is read on first use
+func newRoleDescriptionStore(path string) *roleDescriptionStore {
+	return &roleDescriptionStore{
+		path:         path,
+		descriptions: make(map[string]string),
+	}
+}
+
+// All returns a copy of the stored descriptions
+func (s *roleDescriptionStore) All() map[string]string {
+	s.mu.RLock()
+	if s.loaded {
+		defer s.mu.RUnlock()
+		return copyDescriptions(s.descriptions)
+	}
+	s.mu.RUnlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	return copyDescriptions(s.descriptions)
+}
+
+// Set stores the description of a role
+func (s *roleDescriptionStore) Set(role, description string) error {
+	return s.update(func(descriptions map[string]string) {
+		descriptions[role] = description
+	})
+}
+
+// Rename moves the description of a role to its new name, replacing it with
+// description when that is not empty
+func (s *roleDescriptionStore) Rename(oldRole, newRole, description string) error {
+	return s.update(func(descriptions map[string]string) {
+		if description == "" {
+			description = descriptions[oldRole]
+		}
+		delete(descriptions, oldRole)
+		if description != "" {
+			descriptions[newRole] = description
+		}
+	})
+}
+
+// Delete removes the description of a role
+func (s *roleDescriptionStore) Delete(role string) error {
+	return s.update(func(descriptions map[string]string) {
+		delete(descriptions, role)
+	})
+}
+
+// update applies change to a copy of the descriptions and keeps the copy
+// once it is saved
+func (s *roleDescriptionStore) update(change func(descriptions map[string]string)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+
+	updated := copyDescriptions(s.descriptions)
+	change(updated)
+	if err := s.save(updated); err != nil {
+		return err
+	}
+	s.descriptions = updated
+	return nil
+}
+
+// load reads the descriptions file once. The caller must hold the write lock.
+func (s *roleDescriptionStore) load() {
+	if s.loaded {
+		return
+	}
+	s.loaded = true
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	if err == nil {
+		err = json.Unmarshal(data, &s.descriptions)
+	}
+	if err != nil {
+		logger.Warn("Failed to load role descriptions", zap.String("path", s.path), zap.Error(err))
+		s.descriptions = make(map[string]string)
+	}
+}
+
+func (s *roleDescriptionStore) save(descriptions map[string]string) error {
+	data, err := json.MarshalIndent(descriptions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode role descriptions: %w", err)
+	}
+	if err := utils.WriteFileAtomic(s.path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to save role descriptions: %w", err)
+	}
+	return nil
+}
+
+func copyDescriptions(descriptions map[string]string) map[string]string {
+	copied := make(map[string]string, len(descriptions))
+	for role, description := range descriptions {
+		copied[role] = description
+	}
+	return copied
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/aruncs31s/azf/constants"
+	apperrors "github.com/aruncs31s/azf/shared/errors"
+)
+
+const (
+	// MinRoleNameLength is the shortest accepted role name
+	MinRoleNameLength = 2
+	// MaxRoleNameLength is the longest accepted role name
+	MaxRoleNameLength = 50
+)
+
+// roleNamePattern allows lowercase letters, digits, '_' and '-', starting
+// with a letter. Spaces and commas would break CSV policy files.
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// reservedRoles are built-in roles that cannot be created, renamed or deleted
+var reservedRoles = map[string]bool{
+	constants.ADMIN: true,
+	constants.USER:  true,
+}
+
+// IsReservedRole reports whether role is a built-in role
+func IsReservedRole(role string) bool {
+	return reservedRoles[strings.ToLower(strings.TrimSpace(role))]
+}
+
+// NormalizeRoleName trims and lowercases name and checks it is a valid role name
+func NormalizeRoleName(name string) (string, error) {
+	normalized := strings.ToLower(strings.TrimSpace(name))
+	if len(normalized) < MinRoleNameLength || len(normalized) > MaxRoleNameLength {
+		return "", apperrors.Newf(apperrors.ErrValidation,
+			"role name must be between %d and %d characters", MinRoleNameLength, MaxRoleNameLength)
+	}
+	if !roleNamePattern.MatchString(normalized) {
+		return "", apperrors.Newf(apperrors.ErrValidation,
+			"role name %q may only contain lowercase letters, digits, '_' and '-', and must start with a letter", name)
+	}
+	return normalized, nil
+}
+
+// checkNotReserved returns an error if role is reserved; verb says what was
+// attempted, e.g. "deleted"
+func checkNotReserved(role, verb string) error {
+	if IsReservedRole(role) {
+		return apperrors.Newf(apperrors.ErrValidation, "role '%s' is reserved and cannot be %s", role, verb)
+	}
+	return nil
+}
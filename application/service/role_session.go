@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/aruncs31s/azf/infrastructure/abac"
+	"github.com/aruncs31s/azf/initializer"
+	apperrors "github.com/aruncs31s/azf/shared/errors"
+	"github.com/aruncs31s/azf/utils"
+	"github.com/casbin/casbin/v2"
+)
+
+// ErrSelfLockout is matched by errors for changes that would take away the
+// access of the admin session making them
+var ErrSelfLockout = &apperrors.AppError{
+	Code:       "SELF_LOCKOUT",
+	Message:    "change would lock your session out",
+	HTTPStatus: http.StatusForbidden,
+}
+
+// Actions of role changes that need approval to override a self-lockout
+const (
+	ApprovalActionDeleteRole           = "delete role"
+	ApprovalActionRenameRole           = "rename role"
+	ApprovalActionRemoveRoleAssignment = "remove role assignment"
+)
+
+// RoleSession identifies the admin session changing roles, so the roles and
+// rules it depends on can be protected
+type RoleSession struct {
+	Username string
+	UserID   string
+	Role     string
+	// Resource and Action are the request being made; a change that would
+	// deny it to the session is a self-lockout
+	Resource string
+	Action   string
+	// OverrideLockout asks to make a self-locking change anyway, once another
+	// superadmin approves it
+	OverrideLockout bool
+}
+
+// subjects returns the Casbin subjects the session is enforced as
+func (s RoleSession) subjects() []string {
+	var subjects []string
+	for _, subject := range []string{s.Role, s.UserID} {
+		if subject != "" {
+			subjects = append(subjects, subject)
+		}
+	}
+	return subjects
+}
+
+// roles returns the session subjects with every role they inherit
+func (s RoleSession) roles(enforcer *casbin.Enforcer) (map[string]bool, error) {
+	roles := make(map[string]bool)
+	for _, subject := range s.subjects() {
+		roles[subject] = true
+		inherited, err := enforcer.GetImplicitRolesForUser(subject)
+		if err != nil {
+			return nil, err
+		}
+		for _, role := range inherited {
+			roles[role] = true
+		}
+	}
+	return roles, nil
+}
+
+// canAccess reports whether the session is allowed to make its request
+func (s RoleSession) canAccess(enforcer *casbin.Enforcer) (bool, error) {
+	if s.Resource == "" || s.Action == "" {
+		return false, nil
+	}
+	resource := utils.NormalizePathForLookup(s.Resource)
+	for _, subject := range s.subjects() {
+		allowed, err := enforcer.Enforce(abac.RequestValues(enforcer, subject, resource, s.Action, "", nil)...)
+		if err != nil {
+			return false, err
+		}
+		if allowed {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// sessionChange is a role change made on behalf of a session. dependsOn
+// reports, given the session roles, whether the session relies on what the
+// change removes.
+type sessionChange struct {
+	action    string
+	target    string
+	dependsOn func(roles map[string]bool) bool
+	apply     func(ctx context.Context, policies *initializer.PolicyTransaction) error
+}
+
+// applySessionChange applies change in a unit of work unless it would lock
+// the session out: remove a role or assignment the session relies on, or
+// deny it the request it is making. Such a change is rolled back. With
+// OverrideLockout set it is made once another superadmin approves it.
+func (s *AdminProfileService) applySessionChange(session RoleSession, change sessionChange) error {
+	// Overrides are tied to the admin who asked for them
+	canOverride := session.OverrideLockout && session.Username != "" && s.approvals != nil
+	var approval *ApprovalRequest
+	if canOverride {
+		approval, _ = s.approvals.Approved(session.Username, change.action, change.target)
+	}
+
+	err := s.unitOfWork.Do(context.Background(), func(ctx context.Context, policies *initializer.PolicyTransaction) error {
+		if approval != nil {
+			return change.apply(ctx, policies)
+		}
+
+		enforcer := policies.Enforcer()
+		roles, err := session.roles(enforcer)
+		if err != nil {
+			return fmt.Errorf("failed to resolve session roles: %w", err)
+		}
+		if change.dependsOn != nil && change.dependsOn(roles) {
+			return apperrors.Newf(ErrSelfLockout, "cannot %s %s because your session depends on it", change.action, change.target)
+		}
+
+		allowed, err := session.canAccess(enforcer)
+		if err != nil {
+			return fmt.Errorf("failed to check session access: %w", err)
+		}
+		if err := change.apply(ctx, policies); err != nil {
+			return err
+		}
+		if allowed {
+			stillAllowed, err := session.canAccess(enforcer)
+			if err != nil {
+				return fmt.Errorf("failed to check session access: %w", err)
+			}
+			if !stillAllowed {
+				return apperrors.Newf(ErrSelfLockout, "cannot %s %s because your session would lose access to %s %s",
+					change.action, change.target, session.Action, session.Resource)
+			}
+		}
+		return nil
+	})
+
+	switch {
+	case err == nil && approval != nil:
+		return s.approvals.Consume(approval.ID)
+	case errors.Is(err, ErrSelfLockout) && canOverride:
+		request := s.approvals.Request(session.Username, change.action, change.target, err.Error())
+		return &ApprovalPendingError{Approval: request}
+	}
+	return err
+}
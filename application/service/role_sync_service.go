@@ -0,0 +1,294 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aruncs31s/azf/config"
+	usermodel "github.com/aruncs31s/azf/domain/user_management/model"
+	"github.com/aruncs31s/azf/initializer"
+	apperrors "github.com/aruncs31s/azf/shared/errors"
+	"github.com/aruncs31s/azf/shared/logger"
+	"go.uber.org/zap"
+)
+
+// RoleSyncService keeps the roles recorded on users and the Casbin grouping
+// policies in step. Role changes made through it are applied to both sides
+// in the caller's unit of work; Reconcile repairs drift from changes made
+// elsewhere, such as edits of the policy file.
+type RoleSyncService interface {
+	// AssignRole gives userID role as a grouping policy and, when userID has
+	// a user record, on the record. It reports whether either side changed.
+	AssignRole(ctx context.Context, policies *initializer.PolicyTransaction, userID string, role string) (bool, error)
+	// RemoveRole takes role from userID on both sides. It reports whether
+	// either side changed.
+	RemoveRole(ctx context.Context, policies *initializer.PolicyTransaction, userID string, role string) (bool, error)
+	// RenameRole renames role on the user records holding it. Grouping
+	// policies are renamed by the caller together with the role's policies.
+	RenameRole(ctx context.Context, oldName string, newName string) error
+	// DeleteRole removes role from the user records holding it. Grouping
+	// policies are removed by the caller together with the role's policies.
+	DeleteRole(ctx context.Context, role string) error
+	// Report returns the assignments present on only one side
+	Report() (*RoleConsistencyReport, error)
+	// Reconcile resolves the drift in mode, one of the config.RoleSyncMode
+	// values, and returns what it changed
+	Reconcile(ctx context.Context, mode string) (*RoleSyncResult, error)
+}
+
+// roleSyncService implements RoleSyncService
+type roleSyncService struct {
+	userRepo    usermodel.UserRepository
+	unitOfWork  UnitOfWork
+	consistency RoleConsistencyService
+}
+
+// NewRoleSyncService creates a role sync service. userRepo may be nil when
+// no database is configured; only grouping policies are changed then.
+func NewRoleSyncService(userRepo usermodel.UserRepository, unitOfWork UnitOfWork) RoleSyncService {
+	if unitOfWork == nil {
+		unitOfWork = NewUnitOfWork(nil)
+	}
+	return &roleSyncService{
+		userRepo:    userRepo,
+		unitOfWork:  unitOfWork,
+		consistency: NewRoleConsistencyService(userRepo),
+	}
+}
+
+func (s *roleSyncService) AssignRole(ctx context.Context, policies *initializer.PolicyTransaction, userID string, role string) (bool, error) {
+	added, err := policies.AddGroupingPolicy([]string{userID, role})
+	if err != nil {
+		return false, err
+	}
+	recorded, err := s.changeUserRole(ctx, userID, role, true)
+	if err != nil {
+		return false, err
+	}
+	return added || recorded, nil
+}
+
+func (s *roleSyncService) RemoveRole(ctx context.Context, policies *initializer.PolicyTransaction, userID string, role string) (bool, error) {
+	removed, err := policies.RemoveGroupingPolicy([]string{userID, role})
+	if err != nil {
+		return false, err
+	}
+	unrecorded, err := s.changeUserRole(ctx, userID, role, false)
+	if err != nil {
+		return false, err
+	}
+	return removed || unrecorded, nil
+}
+
+func (s *roleSyncService) RenameRole(ctx context.Context, oldName string, newName string) error {
+	if s.userRepo == nil {
+		return nil
+	}
+	users, err := s.userRepo.GetByRole(ctx, oldName)
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		if _, err := s.changeUserRole(ctx, user.GetID(), oldName, false); err != nil {
+			return err
+		}
+		if _, err := s.changeUserRole(ctx, user.GetID(), newName, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *roleSyncService) DeleteRole(ctx context.Context, role string) error {
+	if s.userRepo == nil {
+		return nil
+	}
+	users, err := s.userRepo.GetByRole(ctx, role)
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		if _, err := s.changeUserRole(ctx, user.GetID(), role, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *roleSyncService) Report() (*RoleConsistencyReport, error) {
+	return s.consistency.Check()
+}
+
+func (s *roleSyncService) Reconcile(ctx context.Context, mode string) (*RoleSyncResult, error) {
+	switch mode {
+	case config.RoleSyncModeMerge, config.RoleSyncModeUsers, config.RoleSyncModeCasbin:
+	default:
+		return nil, apperrors.Newf(apperrors.ErrValidation, "unknown role sync mode %q, use %s, %s or %s",
+			mode, config.RoleSyncModeMerge, config.RoleSyncModeUsers, config.RoleSyncModeCasbin)
+	}
+	// The report is read before the unit of work, which cannot read policies
+	// under its lock; every change below is a no-op when already applied
+	report, err := s.Report()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RoleSyncResult{
+		Mode:                mode,
+		StartedAt:           time.Now(),
+		AddedToCasbin:       make([]RoleAssignmentDTO, 0),
+		RemovedFromCasbin:   make([]RoleAssignmentDTO, 0),
+		AddedToUsers:        make([]RoleAssignmentDTO, 0),
+		RemovedFromUsers:    make([]RoleAssignmentDTO, 0),
+		UnknownSubjectsKept: len(report.UnknownSubjects),
+	}
+	err = s.unitOfWork.Do(ctx, func(ctx context.Context, policies *initializer.PolicyTransaction) error {
+		for _, assignment := range report.MissingInCasbin {
+			if mode == config.RoleSyncModeCasbin {
+				if _, err := s.changeUserRole(ctx, assignment.UserID, assignment.Role, false); err != nil {
+					return err
+				}
+				result.RemovedFromUsers = append(result.RemovedFromUsers, assignment)
+				continue
+			}
+			if _, err := policies.AddGroupingPolicy([]string{assignment.UserID, assignment.Role}); err != nil {
+				return err
+			}
+			result.AddedToCasbin = append(result.AddedToCasbin, assignment)
+		}
+		for _, assignment := range report.MissingInUsers {
+			if mode == config.RoleSyncModeUsers {
+				if _, err := policies.RemoveGroupingPolicy([]string{assignment.UserID, assignment.Role}); err != nil {
+					return err
+				}
+				result.RemovedFromCasbin = append(result.RemovedFromCasbin, assignment)
+				continue
+			}
+			if _, err := s.changeUserRole(ctx, assignment.UserID, assignment.Role, true); err != nil {
+				return err
+			}
+			result.AddedToUsers = append(result.AddedToUsers, assignment)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result.FinishedAt = time.Now()
+	if result.Changes() > 0 {
+		logger.Info("Reconciled user roles with grouping policies",
+			zap.String("mode", mode),
+			zap.Int("added_to_casbin", len(result.AddedToCasbin)),
+			zap.Int("removed_from_casbin", len(result.RemovedFromCasbin)),
+			zap.Int("added_to_users", len(result.AddedToUsers)),
+			zap.Int("removed_from_users", len(result.RemovedFromUsers)))
+	}
+	return result, nil
+}
+
+// changeUserRole records or removes role on the user record of userID. It
+// reports whether the record changed; subjects without a record, such as
+// roles inheriting others, are left alone.
+func (s *roleSyncService) changeUserRole(ctx context.Context, userID string, role string, assign bool) (bool, error) {
+	if s.userRepo == nil {
+		return false, nil
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if errors.Is(err, usermodel.ErrUserNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if user.HasRole(role) == assign {
+		return false, nil
+	}
+
+	userRole, err := usermodel.NewUserRole(role, nil)
+	if err != nil {
+		return false, apperrors.Newf(apperrors.ErrValidation, "%v", err)
+	}
+	if assign {
+		_, err = s.userRepo.AssignRole(ctx, userID, userRole)
+	} else {
+		_, err = s.userRepo.RemoveRole(ctx, userID, userRole)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to update roles of user %s: %w", userID, err)
+	}
+	return true, nil
+}
+
+// RoleSyncResult is what a reconciliation changed
+type RoleSyncResult struct {
+	Mode                string              `json:"mode"`
+	StartedAt           time.Time           `json:"started_at"`
+	FinishedAt          time.Time           `json:"finished_at"`
+	AddedToCasbin       []RoleAssignmentDTO `json:"added_to_casbin"`
+	RemovedFromCasbin   []RoleAssignmentDTO `json:"removed_from_casbin"`
+	AddedToUsers        []RoleAssignmentDTO `json:"added_to_users"`
+	RemovedFromUsers    []RoleAssignmentDTO `json:"removed_from_users"`
+	UnknownSubjectsKept int                 `json:"unknown_subjects_kept"`
+}
+
+// Changes returns how many assignments the reconciliation changed
+func (r *RoleSyncResult) Changes() int {
+	return len(r.AddedToCasbin) + len(r.RemovedFromCasbin) + len(r.AddedToUsers) + len(r.RemovedFromUsers)
+}
+
+// RoleSyncScheduler reconciles user roles with grouping policies in the background
+type RoleSyncScheduler struct {
+	service  RoleSyncService
+	mode     string
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// StartRoleSyncScheduler reconciles service in mode every interval until the
+// scheduler is stopped
+func StartRoleSyncScheduler(service RoleSyncService, mode string, interval time.Duration) *RoleSyncScheduler {
+	s := &RoleSyncScheduler{
+		service: service,
+		mode:    mode,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go s.loop(interval)
+	return s
+}
+
+// Stop stops the scheduler
+func (s *RoleSyncScheduler) Stop() {
+	s.stopOnce.Do(func() { close(s.stop) })
+	<-s.done
+}
+
+func (s *RoleSyncScheduler) loop(interval time.Duration) {
+	defer close(s.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.reconcile()
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+func (s *RoleSyncScheduler) reconcile() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if _, err := s.service.Reconcile(ctx, s.mode); err != nil {
+		logger.Warn("Failed to reconcile user roles with grouping policies", zap.Error(err))
+	}
+}
@@ -0,0 +1,270 @@
+package service
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aruncs31s/azf/application/dto"
+	"github.com/aruncs31s/azf/config"
+	"github.com/aruncs31s/azf/constants"
+	usermodel "github.com/aruncs31s/azf/domain/user_management/model"
+	"github.com/aruncs31s/azf/infrastructure/saml"
+	"github.com/aruncs31s/azf/shared/clock"
+	"github.com/aruncs31s/azf/shared/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	// SAMLLoginTTL bounds the time between starting a SAML sign-in and the
+	// response of the identity provider
+	SAMLLoginTTL = 10 * time.Minute
+	// samlMetadataRefresh is how long identity provider metadata is used
+	// before it is loaded again, picking up rotated certificates
+	samlMetadataRefresh = 24 * time.Hour
+	// samlOAuthProvider marks user records signed in through SAML; their
+	// OAuth ID is the NameID
+	samlOAuthProvider = "saml"
+)
+
+var (
+	// ErrInvalidSAMLResponse is returned when a response does not verify or
+	// does not answer the sign-in it claims to complete
+	ErrInvalidSAMLResponse = errors.New("invalid or expired SAML sign-in")
+	// ErrSAMLAccessDenied is returned when a verified user may not sign in to
+	// the admin UI because the admin role is not mapped to them
+	ErrSAMLAccessDenied = errors.New("SAML user may not sign in to the admin UI")
+)
+
+// SAMLLogin is a started SAML sign-in. The caller keeps it, e.g. in a
+// cookie, and hands it back with the response, which must answer its request.
+type SAMLLogin struct {
+	RequestID string    `json:"request_id"`
+	StartedAt time.Time `json:"started_at"`
+	// URL sends the browser to the identity provider
+	URL string `json:"-"`
+}
+
+// SAMLIdentity is the user a verified assertion signs in
+type SAMLIdentity struct {
+	NameID   string
+	Username string
+	Email    string
+	Name     string
+	// Roles are the azf roles mapped from the role attribute
+	Roles []string
+}
+
+// DirectoryIdentity returns the identity as recorded on the user record,
+// with the NameID as subject
+func (i *SAMLIdentity) DirectoryIdentity() *dto.DirectoryIdentity {
+	return &dto.DirectoryIdentity{
+		Provider: samlOAuthProvider,
+		Subject:  i.NameID,
+		Username: i.Username,
+		Email:    i.Email,
+		Name:     i.Name,
+		Roles:    i.Roles,
+	}
+}
+
+// SAMLService signs admins in through a SAML 2.0 identity provider. Sign-in
+// is started by azf (SP-initiated); the identity provider's metadata is
+// loaded on first use and again once a day.
+type SAMLService struct {
+	config config.SAMLConfig
+	sp     saml.ServiceProvider
+
+	mu       sync.Mutex
+	idp      *saml.IdentityProvider
+	loadedAt time.Time
+	// used holds the IDs of consumed assertions until they expire, so a
+	// response cannot be replayed
+	used map[string]time.Time
+}
+
+// NewSAMLService creates the SAML service of cfg, loading the key pair
+// signing its requests when one is configured
+func NewSAMLService(cfg config.SAMLConfig) (*SAMLService, error) {
+	if cfg.IDPMetadata == "" {
+		return nil, fmt.Errorf("SAML_IDP_METADATA is not set")
+	}
+	s := &SAMLService{
+		config: cfg,
+		sp: saml.ServiceProvider{
+			EntityID:  cfg.EntityID,
+			ACSURL:    cfg.BaseURL + "/admin-ui/saml/acs",
+			ClockSkew: cfg.ClockSkew,
+		},
+		used: make(map[string]time.Time),
+	}
+
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		pair, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load SAML key pair: %w", err)
+		}
+		key, ok := pair.PrivateKey.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("SAML signing key must be an RSA key")
+		}
+		cert, err := x509.ParseCertificate(pair.Certificate[0])
+		if err != nil {
+			return nil, fmt.Errorf("failed to load SAML certificate: %w", err)
+		}
+		s.sp.Key, s.sp.Certificate = key, cert
+	}
+
+	if !slices.Contains(mappedRoles(cfg.RoleMapping), constants.ADMIN) {
+		logger.Warn("SAML_ROLE_MAPPING maps no value to the admin role; nobody can sign in with SAML",
+			zap.String("role_attribute", cfg.RoleAttribute))
+	}
+	return s, nil
+}
+
+// Metadata returns the service provider metadata the identity provider is
+// set up with
+func (s *SAMLService) Metadata() []byte {
+	return s.sp.Metadata()
+}
+
+// Secure reports whether azf is served over HTTPS, which the cookie of a
+// sign-in needs to come back with the identity provider's cross-site POST
+func (s *SAMLService) Secure() bool {
+	return strings.HasPrefix(s.sp.ACSURL, "https://")
+}
+
+// BeginLogin starts a sign-in, returning the request to send the browser to
+func (s *SAMLService) BeginLogin(ctx context.Context) (*SAMLLogin, error) {
+	sp, err := s.serviceProvider(ctx)
+	if err != nil {
+		return nil, err
+	}
+	url, requestID, err := sp.AuthnRequestURL("")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SAML request: %w", err)
+	}
+	return &SAMLLogin{RequestID: requestID, StartedAt: clock.Now(), URL: url}, nil
+}
+
+// HandleResponse verifies the base64 encoded response posted for login and
+// returns the identity it signs in, which must be mapped to the admin role
+func (s *SAMLService) HandleResponse(ctx context.Context, encoded string, login *SAMLLogin) (*SAMLIdentity, error) {
+	if login == nil || login.RequestID == "" || clock.Since(login.StartedAt) > SAMLLoginTTL {
+		return nil, ErrInvalidSAMLResponse
+	}
+	sp, err := s.serviceProvider(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	assertion, err := sp.ParseResponse(encoded, login.RequestID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSAMLResponse, err)
+	}
+	if !s.consume(assertion) {
+		return nil, fmt.Errorf("%w: assertion %s was already used", ErrInvalidSAMLResponse, assertion.ID)
+	}
+
+	identity := &SAMLIdentity{
+		NameID:   assertion.NameID,
+		Username: firstAttribute(assertion, s.config.UsernameAttribute),
+		Email:    firstAttribute(assertion, s.config.EmailAttribute),
+		Name:     firstAttribute(assertion, s.config.NameAttribute),
+		Roles:    s.roles(assertion),
+	}
+	if identity.Username == "" {
+		identity.Username = assertion.NameID
+	}
+	if _, err := usermodel.NewUserEmail(identity.Email); err != nil {
+		identity.Email = ""
+		if _, err := usermodel.NewUserEmail(assertion.NameID); err == nil {
+			identity.Email = assertion.NameID
+		}
+	}
+	if !slices.Contains(identity.Roles, constants.ADMIN) {
+		return nil, fmt.Errorf("%w: %s has roles %v", ErrSAMLAccessDenied, identity.Username, identity.Roles)
+	}
+	return identity, nil
+}
+
+// serviceProvider returns the service provider with the identity provider
+// of the metadata, loading it when it is missing or stale. Stale metadata
+// that cannot be reloaded is used until it can.
+func (s *SAMLService) serviceProvider(ctx context.Context) (*saml.ServiceProvider, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.idp == nil || clock.Since(s.loadedAt) > samlMetadataRefresh {
+		idp, err := saml.LoadIdentityProviderMetadata(ctx, s.config.IDPMetadata)
+		switch {
+		case err == nil:
+			s.idp, s.loadedAt = idp, clock.Now()
+		case s.idp == nil:
+			return nil, err
+		default:
+			logger.FromContext(ctx).Warn("Failed to reload SAML identity provider metadata", zap.Error(err))
+		}
+	}
+	sp := s.sp
+	sp.IDP = s.idp
+	return &sp, nil
+}
+
+// consume records the use of an assertion, reporting false when it was
+// already used
+func (s *SAMLService) consume(assertion *saml.Assertion) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := clock.Now()
+	for id, expiresAt := range s.used {
+		if now.After(expiresAt) {
+			delete(s.used, id)
+		}
+	}
+	if _, used := s.used[assertion.ID]; used {
+		return false
+	}
+	s.used[assertion.ID] = assertion.ExpiresAt
+	return true
+}
+
+// roles maps the values of the role attribute to azf roles
+func (s *SAMLService) roles(assertion *saml.Assertion) []string {
+	roles := []string{}
+	for _, value := range assertion.Attributes[s.config.RoleAttribute] {
+		for _, role := range s.config.RoleMapping[value] {
+			if !slices.Contains(roles, role) {
+				roles = append(roles, role)
+			}
+		}
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// firstAttribute returns the first value of an attribute of the assertion
+func firstAttribute(assertion *saml.Assertion, name string) string {
+	if values := assertion.Attributes[name]; name != "" && len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// mappedRoles returns the roles of a role mapping
+func mappedRoles(mapping map[string][]string) []string {
+	var roles []string
+	for _, mapped := range mapping {
+		roles = append(roles, mapped...)
+	}
+	return roles
+}
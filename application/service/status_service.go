@@ -0,0 +1,220 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aruncs31s/azf/domain/repository"
+	"github.com/aruncs31s/azf/shared/logger"
+	"go.uber.org/zap"
+)
+
+// Coarse health states exposed on the public status page
+const (
+	StatusOperational = "operational"
+	StatusDegraded    = "degraded"
+	StatusOutage      = "outage"
+)
+
+// Request rate tiers exposed on the public status page.
+// Exact request counts are never published, only the tier.
+const (
+	RateTierIdle     = "idle"
+	RateTierLow      = "low"
+	RateTierModerate = "moderate"
+	RateTierHigh     = "high"
+)
+
+// Incident severities that can be set by admins
+const (
+	IncidentSeverityInfo     = "info"
+	IncidentSeverityMinor    = "minor"
+	IncidentSeverityMajor    = "major"
+	IncidentSeverityCritical = "critical"
+)
+
+// rateTierWindow is the window used to compute the request rate tier
+const rateTierWindow = 5 * time.Minute
+
+// processStartedAt is used to compute service uptime
+var processStartedAt = time.Now()
+
+// StatusService provides the redacted, unauthenticated service status
+type StatusService interface {
+	GetPublicStatus() (*PublicStatusDTO, error)
+	GetIncident() *IncidentBannerDTO
+	SetIncident(title, message, severity string) (*IncidentBannerDTO, error)
+	ClearIncident()
+}
+
+// statusService implements StatusService
+type statusService struct {
+	logRepo     repository.APIUsageLogReader
+	healthCheck func() error
+	mu          sync.RWMutex
+	incident    *IncidentBannerDTO
+}
+
+// NewStatusService creates a new status service.
+// logRepo and healthCheck are optional; when missing the corresponding
+// information is reported as unknown rather than failing the page.
+func NewStatusService(logRepo repository.APIUsageLogReader, healthCheck func() error) StatusService {
+	return &statusService{
+		logRepo:     logRepo,
+		healthCheck: healthCheck,
+	}
+}
+
+// GetPublicStatus returns the coarse service status.
+// It intentionally contains no endpoints, users, IPs or exact counts.
+func (s *statusService) GetPublicStatus() (*PublicStatusDTO, error) {
+	uptime := time.Since(processStartedAt)
+
+	status := StatusOperational
+	if s.healthCheck != nil {
+		if err := s.healthCheck(); err != nil {
+			logger.GetLogger().Warn("Status health check failed", zap.Error(err))
+			status = StatusOutage
+		}
+	}
+
+	incident := s.GetIncident()
+	if incident != nil && status == StatusOperational {
+		switch incident.Severity {
+		case IncidentSeverityMajor, IncidentSeverityCritical:
+			status = StatusDegraded
+		}
+	}
+
+	rateTier := "unknown"
+	if s.logRepo != nil && status != StatusOutage {
+		count, err := s.logRepo.CountSince(time.Now().Add(-rateTierWindow))
+		if err != nil {
+			logger.GetLogger().Warn("Failed to compute request rate tier", zap.Error(err))
+		} else {
+			rateTier = requestRateTier(float64(count) / rateTierWindow.Minutes())
+		}
+	}
+
+	return &PublicStatusDTO{
+		Status:          status,
+		UptimeSeconds:   int64(uptime.Seconds()),
+		UptimeDisplay:   formatUptime(uptime),
+		RequestRateTier: rateTier,
+		Incident:        incident,
+		CheckedAt:       time.Now(),
+	}, nil
+}
+
+// GetIncident returns a copy of the current incident banner, or nil
+func (s *statusService) GetIncident() *IncidentBannerDTO {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.incident == nil {
+		return nil
+	}
+	incident := *s.incident
+	return &incident
+}
+
+// SetIncident sets the incident banner shown on the status page
+func (s *statusService) SetIncident(title, message, severity string) (*IncidentBannerDTO, error) {
+	title = strings.TrimSpace(title)
+	message = strings.TrimSpace(message)
+	if title == "" {
+		return nil, fmt.Errorf("incident title cannot be empty")
+	}
+	if len(title) > 120 {
+		return nil, fmt.Errorf("incident title cannot exceed 120 characters")
+	}
+	if len(message) > 1000 {
+		return nil, fmt.Errorf("incident message cannot exceed 1000 characters")
+	}
+	if severity == "" {
+		severity = IncidentSeverityInfo
+	}
+	switch severity {
+	case IncidentSeverityInfo, IncidentSeverityMinor, IncidentSeverityMajor, IncidentSeverityCritical:
+	default:
+		return nil, fmt.Errorf("invalid incident severity: %s", severity)
+	}
+
+	incident := &IncidentBannerDTO{
+		Title:     title,
+		Message:   message,
+		Severity:  severity,
+		StartedAt: time.Now(),
+	}
+
+	s.mu.Lock()
+	s.incident = incident
+	s.mu.Unlock()
+
+	logger.Info("Status page incident banner set",
+		zap.String("title", title),
+		zap.String("severity", severity))
+
+	copied := *incident
+	return &copied, nil
+}
+
+// ClearIncident removes the incident banner
+func (s *statusService) ClearIncident() {
+	s.mu.Lock()
+	s.incident = nil
+	s.mu.Unlock()
+
+	logger.Info("Status page incident banner cleared")
+}
+
+// requestRateTier maps requests per minute to a coarse tier
+func requestRateTier(requestsPerMinute float64) string {
+	switch {
+	case requestsPerMinute < 1:
+		return RateTierIdle
+	case requestsPerMinute < 60:
+		return RateTierLow
+	case requestsPerMinute < 600:
+		return RateTierModerate
+	default:
+		return RateTierHigh
+	}
+}
+
+// formatUptime renders uptime as days, hours and minutes
+func formatUptime(d time.Duration) string {
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	minutes := int(d.Minutes()) % 60
+
+	if days > 0 {
+		return fmt.Sprintf("%dd %dh %dm", days, hours, minutes)
+	}
+	if hours > 0 {
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	}
+	return fmt.Sprintf("%dm", minutes)
+}
+
+// DTOs for API responses
+
+// PublicStatusDTO is the redacted status shown to unauthenticated users
+type PublicStatusDTO struct {
+	Status          string             `json:"status"`
+	UptimeSeconds   int64              `json:"uptime_seconds"`
+	UptimeDisplay   string             `json:"uptime"`
+	RequestRateTier string             `json:"request_rate_tier"`
+	Incident        *IncidentBannerDTO `json:"incident,omitempty"`
+	CheckedAt       time.Time          `json:"checked_at"`
+}
+
+// IncidentBannerDTO is the admin-controlled incident banner
+type IncidentBannerDTO struct {
+	Title     string    `json:"title"`
+	Message   string    `json:"message,omitempty"`
+	Severity  string    `json:"severity"`
+	StartedAt time.Time `json:"started_at"`
+}
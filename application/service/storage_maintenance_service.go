@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aruncs31s/azf/domain/repository"
+	"github.com/aruncs31s/azf/shared/logger"
+	"go.uber.org/zap"
+)
+
+// textBackfillBatchSize is the number of rows encoded per query by the backfill job
+const textBackfillBatchSize = 500
+
+// StorageMaintenanceService runs background maintenance jobs on the usage and audit tables
+type StorageMaintenanceService interface {
+	StartTextBackfill() (*TextBackfillStatusDTO, error)
+	GetTextBackfillStatus() TextBackfillStatusDTO
+}
+
+// storageMaintenanceService implements StorageMaintenanceService
+type storageMaintenanceService struct {
+	backfiller repository.EncodedTextBackfiller
+
+	mu     sync.Mutex
+	status TextBackfillStatusDTO
+}
+
+// NewStorageMaintenanceService creates a new storage maintenance service
+func NewStorageMaintenanceService(backfiller repository.EncodedTextBackfiller) StorageMaintenanceService {
+	return &storageMaintenanceService{
+		backfiller: backfiller,
+	}
+}
+
+// StartTextBackfill starts encoding the text columns of existing rows in the
+// background. Only one backfill runs at a time.
+func (s *storageMaintenanceService) StartTextBackfill() (*TextBackfillStatusDTO, error) {
+	if s.backfiller == nil {
+		return nil, fmt.Errorf("storage is not available")
+	}
+
+	s.mu.Lock()
+	if s.status.Running {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("text column backfill is already running")
+	}
+	s.status = TextBackfillStatusDTO{
+		Running:   true,
+		StartedAt: time.Now(),
+	}
+	status := s.status
+	s.mu.Unlock()
+
+	go s.runTextBackfill()
+	return &status, nil
+}
+
+func (s *storageMaintenanceService) runTextBackfill() {
+	updated, err := s.backfiller.BackfillEncodedText(context.Background(), textBackfillBatchSize)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.status.Running = false
+	s.status.FinishedAt = &now
+	s.status.RowsUpdated = updated
+	if err != nil {
+		s.status.Error = err.Error()
+		logger.Error("Text column backfill failed", zap.Int64("rows_updated", updated), zap.Error(err))
+		return
+	}
+	logger.Info("Text column backfill completed", zap.Int64("rows_updated", updated))
+}
+
+// GetTextBackfillStatus returns the state of the current or last backfill
+func (s *storageMaintenanceService) GetTextBackfillStatus() TextBackfillStatusDTO {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
+
+// TextBackfillStatusDTO reports the progress of the text column backfill job
+type TextBackfillStatusDTO struct {
+	Running     bool       `json:"running"`
+	StartedAt   time.Time  `json:"started_at,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	RowsUpdated int64      `json:"rows_updated"`
+	Error       string     `json:"error,omitempty"`
+}
@@ -0,0 +1,325 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aruncs31s/azf/application/dto"
+	identity_access "github.com/aruncs31s/azf/domain/identity_access/model"
+	apperrors "github.com/aruncs31s/azf/shared/errors"
+	"github.com/aruncs31s/azf/shared/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	// totpPeriod is the TOTP time step, as used by authenticator apps
+	totpPeriod = 30 * time.Second
+	// totpDigits is the length of a TOTP code
+	totpDigits = 6
+	// totpSkew is how many time steps before and after now are accepted,
+	// for clocks that drift apart
+	totpSkew = 1
+	// totpSecretBytes is the length of a TOTP secret, as recommended by RFC 4226
+	totpSecretBytes = 20
+	// backupCodeCount is how many backup codes are issued at a time
+	backupCodeCount = 10
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TwoFactorService manages the TOTP second factor of admins, following
+// RFC 6238. Backup codes sign in once each when the authenticator is lost.
+type TwoFactorService interface {
+	// Status returns the two-factor authentication of the admin
+	Status(ctx context.Context, username string) (*dto.TwoFactorStatus, error)
+	// IsEnabled reports whether the admin signs in with a second factor
+	IsEnabled(ctx context.Context, username string) (bool, error)
+	// Required reports whether every admin must sign in with a second factor
+	Required() bool
+	// BeginEnrollment creates a pending secret for the admin to scan
+	BeginEnrollment(ctx context.Context, username string) (*dto.TwoFactorEnrollment, error)
+	// ConfirmEnrollment enables the pending secret when code matches it, and
+	// returns new backup codes
+	ConfirmEnrollment(ctx context.Context, username string, code string) ([]string, error)
+	// Verify checks a TOTP code or an unused backup code of the admin
+	Verify(ctx context.Context, username string, code string) error
+	// RegenerateBackupCodes replaces the backup codes after checking a TOTP code
+	RegenerateBackupCodes(ctx context.Context, username string, code string) ([]string, error)
+	// Disable removes the second factor after checking code; refused while
+	// two-factor authentication is required
+	Disable(ctx context.Context, username string, code string) error
+}
+
+type twoFactorService struct {
+	repo     identity_access.TwoFactorRepository
+	issuer   string
+	required bool
+}
+
+// NewTwoFactorService creates a two-factor service on repo. issuer names azf
+// in authenticator apps; with required, admins cannot sign in without it.
+func NewTwoFactorService(repo identity_access.TwoFactorRepository, issuer string, required bool) TwoFactorService {
+	return &twoFactorService{
+		repo:     repo,
+		issuer:   issuer,
+		required: required,
+	}
+}
+
+func (s *twoFactorService) Status(ctx context.Context, username string) (*dto.TwoFactorStatus, error) {
+	status := &dto.TwoFactorStatus{Required: s.required}
+	factor, err := s.repo.FindByUsername(ctx, username)
+	if errors.Is(err, identity_access.ErrTwoFactorNotFound) {
+		return status, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if factor.Enabled {
+		status.Enabled = true
+		status.BackupCodesRemaining = len(factor.BackupCodeHashes)
+		status.EnabledAt = factor.EnabledAt
+	}
+	return status, nil
+}
+
+func (s *twoFactorService) IsEnabled(ctx context.Context, username string) (bool, error) {
+	factor, err := s.repo.FindByUsername(ctx, username)
+	if errors.Is(err, identity_access.ErrTwoFactorNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return factor.Enabled, nil
+}
+
+func (s *twoFactorService) Required() bool {
+	return s.required
+}
+
+func (s *twoFactorService) BeginEnrollment(ctx context.Context, username string) (*dto.TwoFactorEnrollment, error) {
+	enabled, err := s.IsEnabled(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, apperrors.Newf(apperrors.ErrConflict, "two-factor authentication is already enabled")
+	}
+
+	buf := make([]byte, totpSecretBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, fmt.Errorf("failed to generate TOTP secret: %w", err)
+	}
+	secret := totpEncoding.EncodeToString(buf)
+	if err := s.repo.Save(ctx, &identity_access.AdminTwoFactor{
+		Username:  username,
+		Secret:    secret,
+		UpdatedAt: time.Now(),
+	}); err != nil {
+		return nil, err
+	}
+
+	return &dto.TwoFactorEnrollment{
+		Secret:     secret,
+		OTPAuthURL: s.otpAuthURL(username, secret),
+	}, nil
+}
+
+func (s *twoFactorService) ConfirmEnrollment(ctx context.Context, username string, code string) ([]string, error) {
+	factor, err := s.repo.FindByUsername(ctx, username)
+	if errors.Is(err, identity_access.ErrTwoFactorNotFound) {
+		return nil, apperrors.Newf(apperrors.ErrValidation, "two-factor enrollment has not been started")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if factor.Enabled {
+		return nil, apperrors.Newf(apperrors.ErrConflict, "two-factor authentication is already enabled")
+	}
+	step, ok := matchTOTP(factor.Secret, code, time.Now(), 0)
+	if !ok {
+		return nil, apperrors.Newf(apperrors.ErrValidation, "invalid two-factor code")
+	}
+
+	codes, hashes, err := generateBackupCodes()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	factor.Enabled = true
+	factor.EnabledAt = &now
+	factor.BackupCodeHashes = hashes
+	factor.LastUsedStep = step
+	factor.UpdatedAt = now
+	if err := s.repo.Save(ctx, factor); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Admin two-factor authentication enabled", zap.String("username", username))
+	return codes, nil
+}
+
+func (s *twoFactorService) Verify(ctx context.Context, username string, code string) error {
+	factor, err := s.enabledFactor(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	code = normalizeTwoFactorCode(code)
+	if len(code) == totpDigits {
+		step, ok := matchTOTP(factor.Secret, code, time.Now(), factor.LastUsedStep)
+		if !ok {
+			return apperrors.Newf(apperrors.ErrUnauthorized, "invalid two-factor code")
+		}
+		factor.LastUsedStep = step
+	} else {
+		if !factor.UseBackupCode(hashBackupCode(code)) {
+			return apperrors.Newf(apperrors.ErrUnauthorized, "invalid two-factor code")
+		}
+		logger.Info("Admin signed in with a backup code",
+			zap.String("username", username),
+			zap.Int("backup_codes_remaining", len(factor.BackupCodeHashes)))
+	}
+	factor.UpdatedAt = time.Now()
+	return s.repo.Save(ctx, factor)
+}
+
+func (s *twoFactorService) RegenerateBackupCodes(ctx context.Context, username string, code string) ([]string, error) {
+	factor, err := s.enabledFactor(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	step, ok := matchTOTP(factor.Secret, code, time.Now(), factor.LastUsedStep)
+	if !ok {
+		return nil, apperrors.Newf(apperrors.ErrValidation, "invalid two-factor code")
+	}
+
+	codes, hashes, err := generateBackupCodes()
+	if err != nil {
+		return nil, err
+	}
+	factor.BackupCodeHashes = hashes
+	factor.LastUsedStep = step
+	factor.UpdatedAt = time.Now()
+	if err := s.repo.Save(ctx, factor); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s *twoFactorService) Disable(ctx context.Context, username string, code string) error {
+	if s.required {
+		return apperrors.Newf(apperrors.ErrForbidden, "two-factor authentication is required for admins")
+	}
+	if err := s.Verify(ctx, username, code); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, username); err != nil {
+		return err
+	}
+
+	logger.Info("Admin two-factor authentication disabled", zap.String("username", username))
+	return nil
+}
+
+// enabledFactor returns the enabled factor of the admin
+func (s *twoFactorService) enabledFactor(ctx context.Context, username string) (*identity_access.AdminTwoFactor, error) {
+	factor, err := s.repo.FindByUsername(ctx, username)
+	if errors.Is(err, identity_access.ErrTwoFactorNotFound) || (err == nil && !factor.Enabled) {
+		return nil, apperrors.Newf(apperrors.ErrValidation, "two-factor authentication is not enabled")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return factor, nil
+}
+
+// otpAuthURL returns the key URI authenticator apps import the secret from
+func (s *twoFactorService) otpAuthURL(username string, secret string) string {
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", s.issuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(totpDigits))
+	params.Set("period", fmt.Sprint(int(totpPeriod/time.Second)))
+	label := url.PathEscape(s.issuer + ":" + username)
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// matchTOTP returns the time step code is valid for around now, rejecting
+// steps at or before lastUsedStep so a code cannot be replayed
+func matchTOTP(secret string, code string, now time.Time, lastUsedStep int64) (int64, bool) {
+	code = normalizeTwoFactorCode(code)
+	if len(code) != totpDigits {
+		return 0, false
+	}
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		return 0, false
+	}
+
+	current := now.Unix() / int64(totpPeriod/time.Second)
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if step <= lastUsedStep {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// totpCode returns the HOTP code of key at counter step (RFC 4226)
+func totpCode(key []byte, step int64) string {
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000)
+}
+
+// generateBackupCodes returns new backup codes with their hashes
+func generateBackupCodes() ([]string, []string, error) {
+	codes := make([]string, 0, backupCodeCount)
+	hashes := make([]string, 0, backupCodeCount)
+	for i := 0; i < backupCodeCount; i++ {
+		buf := make([]byte, 5)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate backup codes: %w", err)
+		}
+		raw := strings.ToLower(totpEncoding.EncodeToString(buf))
+		codes = append(codes, raw[:4]+"-"+raw[4:])
+		hashes = append(hashes, hashBackupCode(raw))
+	}
+	return codes, hashes, nil
+}
+
+// hashBackupCode returns the stored hash of a normalized backup code
+func hashBackupCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeTwoFactorCode drops the spaces and dashes admins type in codes
+func normalizeTwoFactorCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer(" ", "", "-", "").Replace(code)
+}
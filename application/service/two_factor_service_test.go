@@ -0,0 +1,49 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+// RFC 6238 appendix B test vectors, truncated to six digits
+func TestTOTPCode_RFC6238(t *testing.T) {
+	key := []byte("12345678901234567890")
+	cases := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, expected := range cases {
+		if code := totpCode(key, unix/30); code != expected {
+			t.Errorf("Expected code %s at %d, got %s", expected, unix, code)
+		}
+	}
+}
+
+func TestMatchTOTP_RejectsReplay(t *testing.T) {
+	secret := totpEncoding.EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(1111111109, 0)
+	code := totpCode([]byte("12345678901234567890"), now.Unix()/30)
+
+	step, ok := matchTOTP(secret, code, now, 0)
+	if !ok {
+		t.Fatal("Expected the current code to match")
+	}
+	if _, ok := matchTOTP(secret, code, now, step); ok {
+		t.Error("Expected a used code to be rejected")
+	}
+}
+
+func TestBackupCodes_MatchTheirHashes(t *testing.T) {
+	codes, hashes, err := generateBackupCodes()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(codes) != backupCodeCount || len(hashes) != backupCodeCount {
+		t.Fatalf("Expected %d codes, got %d", backupCodeCount, len(codes))
+	}
+	if hashBackupCode(normalizeTwoFactorCode(codes[0])) != hashes[0] {
+		t.Error("Expected a typed backup code to match its hash")
+	}
+}
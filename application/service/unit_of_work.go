@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+
+	"github.com/aruncs31s/azf/domain/repository"
+	"github.com/aruncs31s/azf/initializer"
+)
+
+// UnitOfWork runs a multi-step admin operation so its database writes and
+// Casbin policy changes are applied together or not at all
+type UnitOfWork interface {
+	// Do runs fn in a database transaction, when one is configured. Repository
+	// calls must use the context passed to fn to join it. Policy changes go
+	// through policies; all of them are undone when fn fails or the database
+	// transaction cannot be committed.
+	Do(ctx context.Context, fn func(ctx context.Context, policies *initializer.PolicyTransaction) error) error
+}
+
+// unitOfWork implements UnitOfWork
+type unitOfWork struct {
+	transactions repository.TransactionManager
+}
+
+// NewUnitOfWork creates a unit of work. transactions may be nil when no
+// database is configured, in which case only policy changes are covered.
+func NewUnitOfWork(transactions repository.TransactionManager) UnitOfWork {
+	return &unitOfWork{
+		transactions: transactions,
+	}
+}
+
+func (u *unitOfWork) Do(ctx context.Context, fn func(ctx context.Context, policies *initializer.PolicyTransaction) error) error {
+	return initializer.WithPolicyTransaction(initializer.CasbinEnforcer, func(policies *initializer.PolicyTransaction) error {
+		if u.transactions == nil {
+			return fn(ctx, policies)
+		}
+		// Policies are saved before the database commits, so a failed save
+		// aborts it; a failed commit is returned, so the policies are undone
+		return u.transactions.WithTransaction(ctx, func(ctx context.Context) error {
+			if err := fn(ctx, policies); err != nil {
+				return err
+			}
+			return policies.Save()
+		})
+	})
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aruncs31s/azf/domain/api_usage"
+	"github.com/aruncs31s/azf/domain/repository"
+	apperrors "github.com/aruncs31s/azf/shared/errors"
+	"github.com/aruncs31s/azf/shared/logger"
+	"go.uber.org/zap"
+)
+
+// UsageAnnotationService manages incident/deployment markers shown on analytics charts
+type UsageAnnotationService interface {
+	ListAnnotations(from time.Time, to time.Time) (*[]UsageAnnotationDTO, error)
+	CreateAnnotation(req CreateUsageAnnotationRequest, createdBy string) (*UsageAnnotationDTO, error)
+	DeleteAnnotation(id string) error
+}
+
+// usageAnnotationService implements UsageAnnotationService
+type usageAnnotationService struct {
+	repo repository.UsageAnnotationRepository
+}
+
+// NewUsageAnnotationService creates a new usage annotation service
+func NewUsageAnnotationService(repo repository.UsageAnnotationRepository) UsageAnnotationService {
+	return &usageAnnotationService{
+		repo: repo,
+	}
+}
+
+// ListAnnotations returns annotations that occurred within [from, to)
+func (s *usageAnnotationService) ListAnnotations(from time.Time, to time.Time) (*[]UsageAnnotationDTO, error) {
+	annotations, err := s.repo.FindByTimeRange(from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list annotations: %w", err)
+	}
+
+	result := make([]UsageAnnotationDTO, 0)
+	if annotations == nil {
+		return &result, nil
+	}
+	for _, annotation := range *annotations {
+		result = append(result, toUsageAnnotationDTO(annotation))
+	}
+	return &result, nil
+}
+
+// CreateAnnotation validates and records a new annotation
+func (s *usageAnnotationService) CreateAnnotation(req CreateUsageAnnotationRequest, createdBy string) (*UsageAnnotationDTO, error) {
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		return nil, apperrors.Newf(apperrors.ErrValidation, "annotation title cannot be empty")
+	}
+	if len(title) > 200 {
+		return nil, apperrors.Newf(apperrors.ErrValidation, "annotation title cannot exceed 200 characters")
+	}
+
+	kind := req.Kind
+	if kind == "" {
+		kind = api_usage.AnnotationKindIncident
+	}
+	if kind != api_usage.AnnotationKindIncident && kind != api_usage.AnnotationKindDeployment {
+		return nil, apperrors.Newf(apperrors.ErrValidation, "invalid annotation kind: %s", kind)
+	}
+
+	occurredAt := req.OccurredAt
+	if occurredAt.IsZero() {
+		occurredAt = time.Now()
+	}
+
+	annotation, err := s.repo.Create(&api_usage.UsageAnnotation{
+		Kind:        kind,
+		Title:       title,
+		Description: strings.TrimSpace(req.Description),
+		OccurredAt:  occurredAt,
+		CreatedBy:   createdBy,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create annotation: %w", err)
+	}
+
+	logger.Info("Usage annotation created",
+		zap.String("id", annotation.ID),
+		zap.String("kind", annotation.Kind),
+		zap.Time("occurred_at", annotation.OccurredAt))
+
+	dto := toUsageAnnotationDTO(*annotation)
+	return &dto, nil
+}
+
+// DeleteAnnotation removes an annotation by ID
+func (s *usageAnnotationService) DeleteAnnotation(id string) error {
+	annotation, err := s.repo.FindByID(id)
+	if err != nil {
+		return fmt.Errorf("failed to find annotation: %w", err)
+	}
+	if annotation == nil {
+		return apperrors.Newf(apperrors.ErrNotFound, "annotation not found: %s", id)
+	}
+	return s.repo.Delete(id)
+}
+
+func toUsageAnnotationDTO(annotation api_usage.UsageAnnotation) UsageAnnotationDTO {
+	return UsageAnnotationDTO{
+		ID:          annotation.ID,
+		Kind:        annotation.Kind,
+		Title:       annotation.Title,
+		Description: annotation.Description,
+		OccurredAt:  annotation.OccurredAt,
+		CreatedBy:   annotation.CreatedBy,
+	}
+}
+
+// CreateUsageAnnotationRequest is the payload for recording an annotation
+type CreateUsageAnnotationRequest struct {
+	Kind        string    `json:"kind"`
+	Title       string    `json:"title" binding:"required"`
+	Description string    `json:"description"`
+	OccurredAt  time.Time `json:"occurred_at"`
+}
+
+// UsageAnnotationDTO is an annotation as rendered on analytics charts
+type UsageAnnotationDTO struct {
+	ID          string    `json:"id"`
+	Kind        string    `json:"kind"`
+	Title       string    `json:"title"`
+	Description string    `json:"description,omitempty"`
+	OccurredAt  time.Time `json:"occurred_at"`
+	CreatedBy   string    `json:"created_by,omitempty"`
+}
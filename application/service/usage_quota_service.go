@@ -0,0 +1,485 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aruncs31s/azf/domain/api_usage"
+	"github.com/aruncs31s/azf/domain/repository"
+	"github.com/aruncs31s/azf/infrastructure/enterprise"
+	apperrors "github.com/aruncs31s/azf/shared/errors"
+	"github.com/aruncs31s/azf/shared/logger"
+	"go.uber.org/zap"
+)
+
+// DefaultUsageQuotaSyncInterval is how often usage counted by an instance is
+// written, and usage counted by the others picked up. Instances can let
+// through up to an interval's worth of requests over a quota together.
+const DefaultUsageQuotaSyncInterval = 30 * time.Second
+
+// maxUsageQuotaLimit caps quota limits to catch typos
+const maxUsageQuotaLimit = 1000000000000
+
+// UsageQuotaService manages daily and monthly usage quotas and counts
+// requests against them. Requests are counted in memory and written to the
+// database by Sync, so the request path never waits for it.
+type UsageQuotaService interface {
+	enterprise.QuotaChecker
+	ListQuotas() (*[]UsageQuotaDTO, error)
+	SetQuota(req SetUsageQuotaRequest, createdBy string) (*UsageQuotaDTO, error)
+	DeleteQuota(id string) error
+	// Sync starts new periods for the quotas whose period ended, writes the
+	// usage counted since the last sync and reloads the quotas
+	Sync(now time.Time) error
+}
+
+// quotaState is a cached quota; its Used includes pending
+type quotaState struct {
+	quota api_usage.UsageQuota
+	// pending is the usage counted since the last sync
+	pending int64
+	// persistedStart is the period start stored in the database
+	persistedStart time.Time
+}
+
+// rollover starts the period containing now if the quota's period ended,
+// dropping the usage of the ended one
+func (st *quotaState) rollover(now time.Time) {
+	start := api_usage.QuotaPeriodStart(st.quota.Period, now)
+	if start.After(st.quota.PeriodStart) {
+		st.quota.PeriodStart = start
+		st.quota.Used = 0
+		st.pending = 0
+	}
+}
+
+// usageQuotaService implements UsageQuotaService
+type usageQuotaService struct {
+	repo repository.UsageQuotaRepository
+	mu   sync.Mutex
+	// quotas holds the daily and monthly quota of each subject
+	quotas map[string][]*quotaState
+}
+
+// NewUsageQuotaService creates a new usage quota service
+func NewUsageQuotaService(repo repository.UsageQuotaRepository) UsageQuotaService {
+	s := &usageQuotaService{
+		repo:   repo,
+		quotas: make(map[string][]*quotaState),
+	}
+	if err := s.reload(time.Now()); err != nil {
+		logger.Warn("Failed to load usage quotas", zap.Error(err))
+	}
+	return s
+}
+
+func quotaSubjectKey(subjectType, subject string) string {
+	return subjectType + "\x1f" + subject
+}
+
+// ConsumeQuota counts the request against the quotas of its user, role and
+// API key, unless one of them is used up
+func (s *usageQuotaService) ConsumeQuota(ctx context.Context, req enterprise.QuotaRequest) (*enterprise.QuotaResult, error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var applicable []*quotaState
+	for _, subject := range []struct{ subjectType, id string }{
+		{api_usage.QuotaSubjectUser, req.UserID},
+		{api_usage.QuotaSubjectRole, req.Role},
+		{api_usage.QuotaSubjectAPIKey, req.APIKeyID},
+	} {
+		if subject.id != "" {
+			applicable = append(applicable, s.quotas[quotaSubjectKey(subject.subjectType, subject.id)]...)
+		}
+	}
+	if len(applicable) == 0 {
+		return nil, nil
+	}
+
+	var result *enterprise.QuotaResult
+	for _, st := range applicable {
+		st.rollover(now)
+		remaining := st.quota.Limit - st.quota.Used
+		if remaining < 1 {
+			return toQuotaResult(st.quota, false, 0), nil
+		}
+		if result == nil || remaining-1 < result.Remaining {
+			result = toQuotaResult(st.quota, true, remaining-1)
+		}
+	}
+	for _, st := range applicable {
+		st.quota.Used++
+		st.pending++
+	}
+	return result, nil
+}
+
+func toQuotaResult(quota api_usage.UsageQuota, allowed bool, remaining int64) *enterprise.QuotaResult {
+	return &enterprise.QuotaResult{
+		Allowed:     allowed,
+		SubjectType: quota.SubjectType,
+		Subject:     quota.Subject,
+		Period:      quota.Period,
+		Limit:       quota.Limit,
+		Remaining:   remaining,
+		ResetAt:     quota.PeriodEnd(),
+	}
+}
+
+// ListQuotas returns all quotas with their usage in the current period
+func (s *usageQuotaService) ListQuotas() (*[]UsageQuotaDTO, error) {
+	result := make([]UsageQuotaDTO, 0)
+	now := time.Now()
+
+	s.mu.Lock()
+	for _, states := range s.quotas {
+		for _, st := range states {
+			st.rollover(now)
+			result = append(result, toUsageQuotaDTO(st.quota))
+		}
+	}
+	s.mu.Unlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.SubjectType != b.SubjectType {
+			return a.SubjectType < b.SubjectType
+		}
+		if a.Subject != b.Subject {
+			return a.Subject < b.Subject
+		}
+		return a.Period < b.Period
+	})
+	return &result, nil
+}
+
+// SetQuota creates or replaces the quota of a subject for a period, keeping
+// the usage counted so far
+func (s *usageQuotaService) SetQuota(req SetUsageQuotaRequest, createdBy string) (*UsageQuotaDTO, error) {
+	if s.repo == nil {
+		return nil, fmt.Errorf("usage quotas are not available: database is not initialized")
+	}
+
+	req.SubjectType = strings.TrimSpace(req.SubjectType)
+	req.Subject = strings.TrimSpace(req.Subject)
+	req.Period = strings.TrimSpace(req.Period)
+	if !api_usage.IsValidQuotaSubject(req.SubjectType) {
+		return nil, apperrors.Newf(apperrors.ErrValidation, "subject_type must be one of %s, %s or %s",
+			api_usage.QuotaSubjectUser, api_usage.QuotaSubjectRole, api_usage.QuotaSubjectAPIKey)
+	}
+	if req.Subject == "" {
+		return nil, apperrors.Newf(apperrors.ErrValidation, "subject cannot be empty")
+	}
+	if !api_usage.IsValidQuotaPeriod(req.Period) {
+		return nil, apperrors.Newf(apperrors.ErrValidation, "period must be %s or %s",
+			api_usage.QuotaPeriodDaily, api_usage.QuotaPeriodMonthly)
+	}
+	if req.Limit < 1 || req.Limit > maxUsageQuotaLimit {
+		return nil, apperrors.Newf(apperrors.ErrValidation, "limit must be between 1 and %d", int64(maxUsageQuotaLimit))
+	}
+
+	quota, err := s.repo.Save(&api_usage.UsageQuota{
+		SubjectType: req.SubjectType,
+		Subject:     req.Subject,
+		Period:      req.Period,
+		Limit:       req.Limit,
+		Reason:      strings.TrimSpace(req.Reason),
+		CreatedBy:   createdBy,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	key := quotaSubjectKey(quota.SubjectType, quota.Subject)
+	st := s.find(key, quota.ID)
+	if st == nil {
+		st = &quotaState{quota: *quota, persistedStart: quota.PeriodStart}
+		s.quotas[key] = append(s.quotas[key], st)
+	} else {
+		// Usage counted since the last sync is not saved yet
+		used, start := st.quota.Used, st.quota.PeriodStart
+		st.quota = *quota
+		st.quota.Used, st.quota.PeriodStart = used, start
+	}
+	st.rollover(time.Now())
+	dto := toUsageQuotaDTO(st.quota)
+	s.mu.Unlock()
+
+	logger.Info("Usage quota set",
+		zap.String("subject_type", quota.SubjectType),
+		zap.String("subject", quota.Subject),
+		zap.String("period", quota.Period),
+		zap.Int64("limit", quota.Limit))
+
+	return &dto, nil
+}
+
+// DeleteQuota removes a quota
+func (s *usageQuotaService) DeleteQuota(id string) error {
+	if s.repo == nil {
+		return fmt.Errorf("usage quotas are not available: database is not initialized")
+	}
+
+	quota, err := s.repo.FindByID(id)
+	if err != nil {
+		return fmt.Errorf("failed to find usage quota: %w", err)
+	}
+	if quota == nil {
+		return apperrors.Newf(apperrors.ErrNotFound, "usage quota not found: %s", id)
+	}
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	key := quotaSubjectKey(quota.SubjectType, quota.Subject)
+	states := s.quotas[key][:0]
+	for _, st := range s.quotas[key] {
+		if st.quota.ID != id {
+			states = append(states, st)
+		}
+	}
+	if len(states) == 0 {
+		delete(s.quotas, key)
+	} else {
+		s.quotas[key] = states
+	}
+	s.mu.Unlock()
+
+	logger.Info("Usage quota removed",
+		zap.String("subject_type", quota.SubjectType),
+		zap.String("subject", quota.Subject),
+		zap.String("period", quota.Period))
+	return nil
+}
+
+// find returns the cached quota with id under key
+func (s *usageQuotaService) find(key, id string) *quotaState {
+	for _, st := range s.quotas[key] {
+		if st.quota.ID == id {
+			return st
+		}
+	}
+	return nil
+}
+
+// quotaFlush is the usage of a quota to write
+type quotaFlush struct {
+	id             string
+	persistedStart time.Time
+	start          time.Time
+	requests       int64
+}
+
+func (s *usageQuotaService) Sync(now time.Time) error {
+	if s.repo == nil {
+		return nil
+	}
+
+	// Take the pending usage, so requests are not held up by the writes
+	var flushes []quotaFlush
+	s.mu.Lock()
+	for _, states := range s.quotas {
+		for _, st := range states {
+			st.rollover(now)
+			if st.pending == 0 && st.persistedStart.Equal(st.quota.PeriodStart) {
+				continue
+			}
+			flushes = append(flushes, quotaFlush{
+				id:             st.quota.ID,
+				persistedStart: st.persistedStart,
+				start:          st.quota.PeriodStart,
+				requests:       st.pending,
+			})
+			st.pending = 0
+		}
+	}
+	s.mu.Unlock()
+
+	var firstErr error
+	for _, f := range flushes {
+		if err := s.flush(f); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			s.restore(f)
+		}
+	}
+	if err := s.reload(now); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	return firstErr
+}
+
+// flush starts the quota's new period in the database if it has one, then
+// adds its usage
+func (s *usageQuotaService) flush(f quotaFlush) error {
+	if !f.persistedStart.Equal(f.start) {
+		// Another instance may have started the period already
+		if _, err := s.repo.ResetPeriod(f.id, f.persistedStart, f.start); err != nil {
+			return err
+		}
+	}
+	if f.requests == 0 {
+		return nil
+	}
+	return s.repo.AddUsage(f.id, f.start, f.requests)
+}
+
+// restore puts back usage that could not be written, for the next sync
+func (s *usageQuotaService) restore(f quotaFlush) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, states := range s.quotas {
+		for _, st := range states {
+			if st.quota.ID == f.id && st.quota.PeriodStart.Equal(f.start) {
+				st.pending += f.requests
+			}
+		}
+	}
+}
+
+// reload replaces the cache with the persisted quotas, adding the usage
+// counted since they were read
+func (s *usageQuotaService) reload(now time.Time) error {
+	if s.repo == nil {
+		return nil
+	}
+	quotas, err := s.repo.FindAll()
+	if err != nil {
+		return fmt.Errorf("failed to load usage quotas: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cache := make(map[string][]*quotaState)
+	if quotas != nil {
+		for _, quota := range *quotas {
+			key := quotaSubjectKey(quota.SubjectType, quota.Subject)
+			st := &quotaState{quota: quota, persistedStart: quota.PeriodStart}
+			st.rollover(now)
+			if previous := s.find(key, quota.ID); previous != nil && previous.quota.PeriodStart.Equal(st.quota.PeriodStart) {
+				st.pending = previous.pending
+				st.quota.Used += previous.pending
+			}
+			cache[key] = append(cache[key], st)
+		}
+	}
+	s.quotas = cache
+	return nil
+}
+
+func toUsageQuotaDTO(quota api_usage.UsageQuota) UsageQuotaDTO {
+	remaining := quota.Limit - quota.Used
+	if remaining < 0 {
+		remaining = 0
+	}
+	var percent float64
+	if quota.Limit > 0 {
+		percent = float64(quota.Used) / float64(quota.Limit) * 100
+	}
+	return UsageQuotaDTO{
+		ID:           quota.ID,
+		SubjectType:  quota.SubjectType,
+		Subject:      quota.Subject,
+		Period:       quota.Period,
+		Limit:        quota.Limit,
+		Used:         quota.Used,
+		Remaining:    remaining,
+		UsagePercent: percent,
+		PeriodStart:  quota.PeriodStart,
+		ResetsAt:     quota.PeriodEnd(),
+		Reason:       quota.Reason,
+		CreatedBy:    quota.CreatedBy,
+		CreatedAt:    quota.CreatedAt,
+		UpdatedAt:    quota.UpdatedAt,
+	}
+}
+
+// UsageQuotaScheduler syncs usage quotas in the background, which also
+// resets them when their period ends
+type UsageQuotaScheduler struct {
+	service  UsageQuotaService
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// StartUsageQuotaScheduler syncs service every interval until the scheduler is stopped
+func StartUsageQuotaScheduler(service UsageQuotaService, interval time.Duration) *UsageQuotaScheduler {
+	if interval <= 0 {
+		interval = DefaultUsageQuotaSyncInterval
+	}
+	s := &UsageQuotaScheduler{
+		service: service,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go s.loop(interval)
+	return s
+}
+
+// Stop stops the scheduler and writes the usage counted since the last sync
+func (s *UsageQuotaScheduler) Stop() {
+	s.stopOnce.Do(func() { close(s.stop) })
+	<-s.done
+}
+
+func (s *UsageQuotaScheduler) loop(interval time.Duration) {
+	defer close(s.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			s.sync(now)
+		case <-s.stop:
+			s.sync(time.Now())
+			return
+		}
+	}
+}
+
+func (s *UsageQuotaScheduler) sync(now time.Time) {
+	if err := s.service.Sync(now); err != nil {
+		logger.Warn("Failed to sync usage quotas", zap.Error(err))
+	}
+}
+
+// SetUsageQuotaRequest is the payload for creating or replacing a quota.
+// SubjectType is user, role or api_key, and Period daily or monthly.
+type SetUsageQuotaRequest struct {
+	SubjectType string `json:"subject_type" binding:"required"`
+	Subject     string `json:"subject" binding:"required"`
+	Period      string `json:"period" binding:"required"`
+	Limit       int64  `json:"limit" binding:"required"`
+	Reason      string `json:"reason"`
+}
+
+// UsageQuotaDTO is a usage quota with its consumption in the current period
+type UsageQuotaDTO struct {
+	ID           string    `json:"id"`
+	SubjectType  string    `json:"subject_type"`
+	Subject      string    `json:"subject"`
+	Period       string    `json:"period"`
+	Limit        int64     `json:"limit"`
+	Used         int64     `json:"used"`
+	Remaining    int64     `json:"remaining"`
+	UsagePercent float64   `json:"usage_percent"`
+	PeriodStart  time.Time `json:"period_start"`
+	ResetsAt     time.Time `json:"resets_at"`
+	Reason       string    `json:"reason,omitempty"`
+	CreatedBy    string    `json:"created_by,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	usermodel "github.com/aruncs31s/azf/domain/user_management/model"
+	"github.com/aruncs31s/azf/shared/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	// userLookupTTL is how long resolved users, and IDs without a user, are cached
+	userLookupTTL = 5 * time.Minute
+	// maxUserLookupEntries bounds the cache; it is cleared when full
+	maxUserLookupEntries = 10000
+)
+
+// UserLookupService resolves user IDs, as recorded in policies and usage
+// logs, to the users behind them for display
+type UserLookupService interface {
+	// Resolve returns a summary for every given ID. IDs without a user record
+	// get a summary with Known set to false that shows the ID itself.
+	Resolve(userIDs []string) map[string]UserSummaryDTO
+}
+
+// userLookupService implements UserLookupService
+type userLookupService struct {
+	userRepo usermodel.UserRepository
+	mu       sync.RWMutex
+	cache    map[string]cachedUserSummary
+}
+
+type cachedUserSummary struct {
+	summary   UserSummaryDTO
+	expiresAt time.Time
+}
+
+// NewUserLookupService creates a new user lookup service. userRepo may be nil
+// when no database is configured, in which case IDs resolve to themselves.
+func NewUserLookupService(userRepo usermodel.UserRepository) UserLookupService {
+	return &userLookupService{
+		userRepo: userRepo,
+		cache:    make(map[string]cachedUserSummary),
+	}
+}
+
+func (s *userLookupService) Resolve(userIDs []string) map[string]UserSummaryDTO {
+	summaries := make(map[string]UserSummaryDTO, len(userIDs))
+	missing := make([]string, 0)
+	now := time.Now()
+
+	s.mu.RLock()
+	for _, userID := range userIDs {
+		if _, seen := summaries[userID]; seen {
+			continue
+		}
+		if cached, ok := s.cache[userID]; ok && now.Before(cached.expiresAt) {
+			summaries[userID] = cached.summary
+			continue
+		}
+		summaries[userID] = unknownUserSummary(userID)
+		missing = append(missing, userID)
+	}
+	s.mu.RUnlock()
+
+	if len(missing) == 0 || s.userRepo == nil {
+		return summaries
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	users, err := s.userRepo.GetByIDs(ctx, missing)
+	if err != nil {
+		// Show the raw IDs rather than failing the page; retry on the next lookup
+		logger.Warn("Failed to resolve user IDs", zap.Int("count", len(missing)), zap.Error(err))
+		return summaries
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.cache)+len(missing) > maxUserLookupEntries {
+		s.cache = make(map[string]cachedUserSummary)
+	}
+	expiresAt := now.Add(userLookupTTL)
+	for _, userID := range missing {
+		summary := unknownUserSummary(userID)
+		if user, ok := users[userID]; ok {
+			summary = toUserSummaryDTO(user)
+		}
+		summaries[userID] = summary
+		s.cache[userID] = cachedUserSummary{summary: summary, expiresAt: expiresAt}
+	}
+	return summaries
+}
+
+func unknownUserSummary(userID string) UserSummaryDTO {
+	return UserSummaryDTO{
+		ID:          userID,
+		DisplayName: userID,
+	}
+}
+
+func toUserSummaryDTO(user *usermodel.User) UserSummaryDTO {
+	displayName := user.GetDisplayName()
+	if displayName == "" {
+		displayName = user.GetUsername()
+	}
+	return UserSummaryDTO{
+		ID:          user.GetID(),
+		Username:    user.GetUsername(),
+		DisplayName: displayName,
+		Email:       user.GetEmail(),
+		Known:       true,
+	}
+}
+
+// UserSummaryDTO identifies a user for display
+type UserSummaryDTO struct {
+	ID          string `json:"id"`
+	Username    string `json:"username,omitempty"`
+	DisplayName string `json:"display_name"`
+	Email       string `json:"email,omitempty"`
+	// Known is false when no user record exists for the ID
+	Known bool `json:"known"`
+}
@@ -0,0 +1,531 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	usermodel "github.com/aruncs31s/azf/domain/user_management/model"
+	"github.com/aruncs31s/azf/initializer"
+	"github.com/aruncs31s/azf/shared/clock"
+	apperrors "github.com/aruncs31s/azf/shared/errors"
+	"github.com/aruncs31s/azf/shared/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultUserPageSize is the page size of user searches without a limit
+	defaultUserPageSize = 20
+	// maxUserPageSize bounds the page size of user searches
+	maxUserPageSize = 100
+)
+
+// UserManagementService lets admins find users, block, delete and restore
+// them and manage their roles. Role changes go through the RoleSyncService,
+// so they are recorded on the user and as Casbin grouping policies in one
+// unit of work.
+type UserManagementService interface {
+	// Search returns a page of the users matching query and filter
+	Search(ctx context.Context, query string, filter usermodel.UserSearchFilter) (*UserPageDTO, error)
+	// Get returns a user
+	Get(ctx context.Context, userID string) (*UserDTO, error)
+	// Block blocks a user; admins cannot block their own user
+	Block(ctx context.Context, userID string, reason string, session RoleSession) (*UserDTO, error)
+	// Unblock makes a blocked user active again
+	Unblock(ctx context.Context, userID string) (*UserDTO, error)
+	// AssignRole gives a user a role
+	AssignRole(ctx context.Context, userID string, role string) (*UserDTO, error)
+	// RemoveRole takes a role from a user. A role the session depends on is
+	// only removed with an approved override.
+	RemoveRole(ctx context.Context, userID string, role string, session RoleSession) (*UserDTO, error)
+	// PromoteToAdmin marks a user as admin
+	PromoteToAdmin(ctx context.Context, userID string) (*UserDTO, error)
+	// DemoteFromAdmin removes the admin mark of a user; admins cannot demote
+	// their own user
+	DemoteFromAdmin(ctx context.Context, userID string, session RoleSession) (*UserDTO, error)
+	// Delete soft-deletes a user and removes its grouping policies; admins
+	// cannot delete their own user. The user can be restored until it is
+	// purged.
+	Delete(ctx context.Context, userID string, reason string, session RoleSession) error
+	// Restore brings back a deleted user with its roles
+	Restore(ctx context.Context, userID string, session RoleSession) (*UserDTO, error)
+	// History returns the audit trail of a user, newest first
+	History(ctx context.Context, userID string) ([]UserAuditEntryDTO, error)
+	// RecentAudit returns the latest audit trail entries of every user
+	RecentAudit(ctx context.Context, limit int) ([]UserAuditEntryDTO, error)
+	// PurgeDeleted permanently removes the users deleted longer than
+	// retention ago and returns how many were purged
+	PurgeDeleted(ctx context.Context, retention time.Duration) (int, error)
+}
+
+// userManagementService implements UserManagementService
+type userManagementService struct {
+	userRepo   usermodel.UserRepository
+	unitOfWork UnitOfWork
+	profile    *AdminProfileService
+	roleSync   RoleSyncService
+	audit      usermodel.UserAuditRepository
+}
+
+// NewUserManagementService creates a user management service. userRepo may be
+// nil when no database is configured; every call then fails. Role removals
+// go through profile, so they share its self-lockout protection. Deletions
+// and restores are recorded in audit when it is set.
+func NewUserManagementService(userRepo usermodel.UserRepository, unitOfWork UnitOfWork, profile *AdminProfileService, roleSync RoleSyncService, audit usermodel.UserAuditRepository) UserManagementService {
+	if unitOfWork == nil {
+		unitOfWork = NewUnitOfWork(nil)
+	}
+	if roleSync == nil {
+		roleSync = NewRoleSyncService(userRepo, unitOfWork)
+	}
+	return &userManagementService{
+		userRepo:   userRepo,
+		unitOfWork: unitOfWork,
+		profile:    profile,
+		roleSync:   roleSync,
+		audit:      audit,
+	}
+}
+
+func (s *userManagementService) Search(ctx context.Context, query string, filter usermodel.UserSearchFilter) (*UserPageDTO, error) {
+	if err := s.ready(); err != nil {
+		return nil, err
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultUserPageSize
+	}
+	if filter.Limit > maxUserPageSize {
+		filter.Limit = maxUserPageSize
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	result, err := s.userRepo.Search(ctx, strings.TrimSpace(query), &filter)
+	if err != nil {
+		return nil, err
+	}
+	page := &UserPageDTO{
+		Users:   make([]UserDTO, 0, len(result.Users)),
+		Total:   result.Total,
+		HasMore: result.HasMore,
+		Limit:   result.Limit,
+		Offset:  result.Offset,
+	}
+	for _, user := range result.Users {
+		page.Users = append(page.Users, *NewUserDTO(user))
+	}
+	return page, nil
+}
+
+func (s *userManagementService) Get(ctx context.Context, userID string) (*UserDTO, error) {
+	if err := s.ready(); err != nil {
+		return nil, err
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return NewUserDTO(user), nil
+}
+
+func (s *userManagementService) Block(ctx context.Context, userID string, reason string, session RoleSession) (*UserDTO, error) {
+	if err := s.ready(); err != nil {
+		return nil, err
+	}
+	if session.UserID != "" && session.UserID == userID {
+		return nil, apperrors.Newf(apperrors.ErrValidation, "you cannot block your own user")
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, apperrors.Newf(apperrors.ErrValidation, "block reason is required")
+	}
+	if len(reason) > 500 {
+		return nil, apperrors.Newf(apperrors.ErrValidation, "block reason cannot exceed 500 characters")
+	}
+
+	user, err := s.userRepo.Block(ctx, userID, reason)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("User blocked", zap.String("user_id", userID), zap.String("by", session.Username))
+	return NewUserDTO(user), nil
+}
+
+func (s *userManagementService) Unblock(ctx context.Context, userID string) (*UserDTO, error) {
+	if err := s.ready(); err != nil {
+		return nil, err
+	}
+	user, err := s.userRepo.Unblock(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return NewUserDTO(user), nil
+}
+
+func (s *userManagementService) AssignRole(ctx context.Context, userID string, role string) (*UserDTO, error) {
+	if err := s.ready(); err != nil {
+		return nil, err
+	}
+	role, err := NormalizeRoleName(role)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		return nil, err
+	}
+	err = s.unitOfWork.Do(ctx, func(ctx context.Context, policies *initializer.PolicyTransaction) error {
+		changed, err := s.roleSync.AssignRole(ctx, policies, userID, role)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			return apperrors.Newf(apperrors.ErrConflict, "user already has role %s", role)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, userID)
+}
+
+func (s *userManagementService) RemoveRole(ctx context.Context, userID string, role string, session RoleSession) (*UserDTO, error) {
+	if err := s.ready(); err != nil {
+		return nil, err
+	}
+	if s.profile == nil {
+		return nil, fmt.Errorf("admin profile service is not configured")
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.HasRole(role) {
+		return nil, apperrors.Newf(apperrors.ErrNotFound, "user does not have role %s", role)
+	}
+
+	err = s.profile.applySessionChange(session, sessionChange{
+		action:    ApprovalActionRemoveRoleAssignment,
+		target:    fmt.Sprintf("%s from %s", role, userID),
+		dependsOn: func(roles map[string]bool) bool { return roles[userID] && roles[role] },
+		apply: func(ctx context.Context, policies *initializer.PolicyTransaction) error {
+			_, err := s.roleSync.RemoveRole(ctx, policies, userID, role)
+			return err
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, userID)
+}
+
+func (s *userManagementService) PromoteToAdmin(ctx context.Context, userID string) (*UserDTO, error) {
+	if err := s.ready(); err != nil {
+		return nil, err
+	}
+	user, err := s.userRepo.PromoteToAdmin(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return NewUserDTO(user), nil
+}
+
+func (s *userManagementService) DemoteFromAdmin(ctx context.Context, userID string, session RoleSession) (*UserDTO, error) {
+	if err := s.ready(); err != nil {
+		return nil, err
+	}
+	if session.UserID != "" && session.UserID == userID {
+		return nil, apperrors.Newf(apperrors.ErrValidation, "you cannot demote your own user")
+	}
+	user, err := s.userRepo.DemoteFromAdmin(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return NewUserDTO(user), nil
+}
+
+func (s *userManagementService) Delete(ctx context.Context, userID string, reason string, session RoleSession) error {
+	if err := s.ready(); err != nil {
+		return err
+	}
+	if session.UserID != "" && session.UserID == userID {
+		return apperrors.Newf(apperrors.ErrValidation, "you cannot delete your own user")
+	}
+	reason = strings.TrimSpace(reason)
+	if len(reason) > 500 {
+		return apperrors.Newf(apperrors.ErrValidation, "delete reason cannot exceed 500 characters")
+	}
+
+	err := s.unitOfWork.Do(ctx, func(ctx context.Context, policies *initializer.PolicyTransaction) error {
+		user, err := s.userRepo.GetByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		// The roles stay on the user for a restore, but grant nothing meanwhile
+		for _, role := range user.GetRoles() {
+			if _, err := policies.RemoveGroupingPolicy([]string{userID, role.Name()}); err != nil {
+				return err
+			}
+		}
+		if err := s.userRepo.Delete(ctx, userID); err != nil {
+			return err
+		}
+		return s.record(ctx, user, usermodel.UserAuditDeleted, session, reason)
+	})
+	if err != nil {
+		return err
+	}
+	logger.Info("User deleted", zap.String("user_id", userID), zap.String("by", session.Username))
+	return nil
+}
+
+func (s *userManagementService) Restore(ctx context.Context, userID string, session RoleSession) (*UserDTO, error) {
+	if err := s.ready(); err != nil {
+		return nil, err
+	}
+
+	var restored *usermodel.User
+	err := s.unitOfWork.Do(ctx, func(ctx context.Context, policies *initializer.PolicyTransaction) error {
+		user, err := s.userRepo.Restore(ctx, userID)
+		if err != nil {
+			return err
+		}
+		for _, role := range user.GetRoles() {
+			if _, err := policies.AddGroupingPolicy([]string{userID, role.Name()}); err != nil {
+				return err
+			}
+		}
+		restored = user
+		return s.record(ctx, user, usermodel.UserAuditRestored, session, "")
+	})
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("User restored", zap.String("user_id", userID), zap.String("by", session.Username))
+	return NewUserDTO(restored), nil
+}
+
+func (s *userManagementService) History(ctx context.Context, userID string) ([]UserAuditEntryDTO, error) {
+	if s.audit == nil {
+		return []UserAuditEntryDTO{}, nil
+	}
+	entries, err := s.audit.FindByUserID(ctx, userID, 0)
+	if err != nil {
+		return nil, err
+	}
+	return newUserAuditEntryDTOs(entries), nil
+}
+
+func (s *userManagementService) RecentAudit(ctx context.Context, limit int) ([]UserAuditEntryDTO, error) {
+	if s.audit == nil {
+		return []UserAuditEntryDTO{}, nil
+	}
+	if limit <= 0 || limit > maxUserPageSize {
+		limit = defaultUserPageSize
+	}
+	entries, err := s.audit.FindRecent(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+	return newUserAuditEntryDTOs(entries), nil
+}
+
+func (s *userManagementService) PurgeDeleted(ctx context.Context, retention time.Duration) (int, error) {
+	if err := s.ready(); err != nil {
+		return 0, err
+	}
+	if retention <= 0 {
+		return 0, nil
+	}
+
+	var purged []*usermodel.User
+	err := s.unitOfWork.Do(ctx, func(ctx context.Context, _ *initializer.PolicyTransaction) error {
+		var err error
+		purged, err = s.userRepo.PurgeDeletedBefore(ctx, clock.Now().Add(-retention))
+		if err != nil {
+			return err
+		}
+		for _, user := range purged {
+			if err := s.record(ctx, user, usermodel.UserAuditPurged, RoleSession{Username: userAuditSystemActor}, ""); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if len(purged) > 0 {
+		logger.Info("Purged deleted users", zap.Int("count", len(purged)), zap.Duration("retention", retention))
+	}
+	return len(purged), nil
+}
+
+// userAuditSystemActor is the actor of changes made by background jobs
+const userAuditSystemActor = "system"
+
+// record adds an audit trail entry for a change of user made by session
+func (s *userManagementService) record(ctx context.Context, user *usermodel.User, action usermodel.UserAuditAction, session RoleSession, reason string) error {
+	if s.audit == nil {
+		return nil
+	}
+	return s.audit.Record(ctx, &usermodel.UserAuditEntry{
+		UserID:    user.GetID(),
+		Username:  user.GetUsername(),
+		Action:    action,
+		ActorID:   session.UserID,
+		ActorName: session.Username,
+		Reason:    reason,
+	})
+}
+
+// ready returns an error when the user repository is not available
+func (s *userManagementService) ready() error {
+	if s.userRepo == nil {
+		return fmt.Errorf("user repository is not configured")
+	}
+	return nil
+}
+
+// UserDTO is a user as shown to admins
+type UserDTO struct {
+	ID            string     `json:"id"`
+	Email         string     `json:"email"`
+	Username      string     `json:"username"`
+	DisplayName   string     `json:"display_name"`
+	Status        string     `json:"status"`
+	Roles         []string   `json:"roles"`
+	IsAdmin       bool       `json:"is_admin"`
+	BlockedReason string     `json:"blocked_reason,omitempty"`
+	OAuthProvider string     `json:"oauth_provider,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	LastLoginAt   *time.Time `json:"last_login_at,omitempty"`
+	DeletedAt     *time.Time `json:"deleted_at,omitempty"`
+}
+
+// NewUserDTO converts a user to its DTO
+func NewUserDTO(user *usermodel.User) *UserDTO {
+	roles := user.GetRoles()
+	names := make([]string, 0, len(roles))
+	for _, role := range roles {
+		names = append(names, role.Name())
+	}
+	// Deleted users keep their status for a restore, but show as deleted
+	status := user.GetStatus()
+	if user.IsDeleted() {
+		status = usermodel.StatusDeleted
+	}
+	return &UserDTO{
+		ID:            user.GetID(),
+		Email:         user.GetEmail(),
+		Username:      user.GetUsername(),
+		DisplayName:   user.GetDisplayName(),
+		Status:        status.String(),
+		Roles:         names,
+		IsAdmin:       user.IsAdmin(),
+		BlockedReason: user.GetBlockedReason(),
+		OAuthProvider: user.GetOAuthProvider(),
+		CreatedAt:     user.GetCreatedAt(),
+		UpdatedAt:     user.GetUpdatedAt(),
+		LastLoginAt:   user.GetLastLoginAt(),
+		DeletedAt:     user.GetDeletedAt(),
+	}
+}
+
+// UserAuditEntryDTO is an entry of the user audit trail
+type UserAuditEntryDTO struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Username  string    `json:"username"`
+	Action    string    `json:"action"`
+	ActorID   string    `json:"actor_id,omitempty"`
+	ActorName string    `json:"actor_name"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// newUserAuditEntryDTOs converts audit trail entries to their DTOs
+func newUserAuditEntryDTOs(entries []*usermodel.UserAuditEntry) []UserAuditEntryDTO {
+	dtos := make([]UserAuditEntryDTO, 0, len(entries))
+	for _, entry := range entries {
+		dtos = append(dtos, UserAuditEntryDTO{
+			ID:        entry.ID,
+			UserID:    entry.UserID,
+			Username:  entry.Username,
+			Action:    string(entry.Action),
+			ActorID:   entry.ActorID,
+			ActorName: entry.ActorName,
+			Reason:    entry.Reason,
+			CreatedAt: entry.CreatedAt,
+		})
+	}
+	return dtos
+}
+
+// UserPageDTO is a page of user search results
+type UserPageDTO struct {
+	Users   []UserDTO `json:"users"`
+	Total   int64     `json:"total"`
+	HasMore bool      `json:"has_more"`
+	Limit   int       `json:"limit"`
+	Offset  int       `json:"offset"`
+}
+
+// UserPurgeScheduler purges the users deleted longer than the retention
+// period ago in the background
+type UserPurgeScheduler struct {
+	service   UserManagementService
+	retention time.Duration
+	stop      chan struct{}
+	done      chan struct{}
+	stopOnce  sync.Once
+}
+
+// StartUserPurgeScheduler purges the users of service deleted longer than
+// retention ago every interval until the scheduler is stopped
+func StartUserPurgeScheduler(service UserManagementService, retention time.Duration, interval time.Duration) *UserPurgeScheduler {
+	s := &UserPurgeScheduler{
+		service:   service,
+		retention: retention,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go s.loop(interval)
+	return s
+}
+
+// Stop stops the scheduler
+func (s *UserPurgeScheduler) Stop() {
+	s.stopOnce.Do(func() { close(s.stop) })
+	<-s.done
+}
+
+func (s *UserPurgeScheduler) loop(interval time.Duration) {
+	defer close(s.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.purge()
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+func (s *UserPurgeScheduler) purge() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if _, err := s.service.PurgeDeleted(ctx, s.retention); err != nil {
+		logger.Warn("Failed to purge deleted users", zap.Error(err))
+	}
+}
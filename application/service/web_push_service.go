@@ -0,0 +1,245 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aruncs31s/azf/domain/api_usage"
+	"github.com/aruncs31s/azf/domain/model"
+	"github.com/aruncs31s/azf/domain/repository"
+	apperrors "github.com/aruncs31s/azf/shared/errors"
+	"github.com/aruncs31s/azf/shared/logger"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// WebPushService manages the browser push subscriptions admins use to
+// receive alerts while the dashboard is closed
+type WebPushService interface {
+	// PublicKey returns the VAPID key browsers subscribe with, or "" if web push is disabled
+	PublicKey() string
+	Subscribe(adminUsername string, req WebPushSubscribeRequest, userAgent string) (*WebPushSubscriptionDTO, error)
+	ListSubscriptions(adminUsername string) (*[]WebPushSubscriptionDTO, error)
+	UpdateMinSeverity(adminUsername string, id string, minSeverity string) (*WebPushSubscriptionDTO, error)
+	Unsubscribe(adminUsername string, id string) error
+	SendTest(adminUsername string) (int, error)
+}
+
+// webPushService implements WebPushService
+type webPushService struct {
+	repo            repository.WebPushSubscriptionRepository
+	publicKey       string
+	defaultSeverity string
+	sendTest        func(subscription *api_usage.WebPushSubscription) error
+}
+
+// NewWebPushService creates a new web push service. sendTest delivers a test
+// message to one subscription; repo may be nil when web push is disabled.
+func NewWebPushService(
+	repo repository.WebPushSubscriptionRepository,
+	publicKey string,
+	defaultSeverity string,
+	sendTest func(subscription *api_usage.WebPushSubscription) error,
+) WebPushService {
+	if _, err := model.NewAlertSeverity(defaultSeverity); err != nil {
+		defaultSeverity = model.AlertCritical.Value()
+	}
+	return &webPushService{
+		repo:            repo,
+		publicKey:       publicKey,
+		defaultSeverity: defaultSeverity,
+		sendTest:        sendTest,
+	}
+}
+
+func (s *webPushService) PublicKey() string {
+	if s.repo == nil {
+		return ""
+	}
+	return s.publicKey
+}
+
+// Subscribe registers a browser push subscription for the admin. Subscribing
+// again from the same browser replaces the previous subscription.
+func (s *webPushService) Subscribe(adminUsername string, req WebPushSubscribeRequest, userAgent string) (*WebPushSubscriptionDTO, error) {
+	if s.repo == nil {
+		return nil, fmt.Errorf("web push is not enabled")
+	}
+
+	endpoint, err := url.Parse(req.Endpoint)
+	if err != nil || endpoint.Scheme != "https" || endpoint.Host == "" {
+		return nil, apperrors.Newf(apperrors.ErrValidation, "endpoint must be an https URL")
+	}
+	if req.Keys.P256dh == "" || req.Keys.Auth == "" {
+		return nil, apperrors.Newf(apperrors.ErrValidation, "keys.p256dh and keys.auth are required")
+	}
+
+	minSeverity := s.defaultSeverity
+	if req.MinSeverity != "" {
+		severity, err := model.NewAlertSeverity(strings.ToUpper(req.MinSeverity))
+		if err != nil {
+			return nil, apperrors.Newf(apperrors.ErrValidation, "invalid min_severity: %w", err)
+		}
+		minSeverity = severity.Value()
+	}
+
+	if len(userAgent) > 500 {
+		userAgent = userAgent[:500]
+	}
+
+	subscription, err := s.repo.Save(&api_usage.WebPushSubscription{
+		ID:            uuid.New().String(),
+		AdminUsername: adminUsername,
+		Endpoint:      req.Endpoint,
+		P256dh:        req.Keys.P256dh,
+		Auth:          req.Keys.Auth,
+		MinSeverity:   minSeverity,
+		UserAgent:     userAgent,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("Web push subscription registered",
+		zap.String("admin", adminUsername),
+		zap.String("subscription_id", subscription.ID),
+		zap.String("min_severity", subscription.MinSeverity))
+
+	dto := toWebPushSubscriptionDTO(*subscription)
+	return &dto, nil
+}
+
+// ListSubscriptions returns the admin's push subscriptions
+func (s *webPushService) ListSubscriptions(adminUsername string) (*[]WebPushSubscriptionDTO, error) {
+	result := make([]WebPushSubscriptionDTO, 0)
+	if s.repo == nil {
+		return &result, nil
+	}
+
+	subscriptions, err := s.repo.FindByAdmin(adminUsername)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list web push subscriptions: %w", err)
+	}
+	if subscriptions == nil {
+		return &result, nil
+	}
+
+	for _, subscription := range *subscriptions {
+		result = append(result, toWebPushSubscriptionDTO(subscription))
+	}
+	return &result, nil
+}
+
+// UpdateMinSeverity changes the lowest alert severity pushed to a subscription
+func (s *webPushService) UpdateMinSeverity(adminUsername string, id string, minSeverity string) (*WebPushSubscriptionDTO, error) {
+	severity, err := model.NewAlertSeverity(strings.ToUpper(minSeverity))
+	if err != nil {
+		return nil, apperrors.Newf(apperrors.ErrValidation, "invalid min_severity: %w", err)
+	}
+
+	subscription, err := s.findOwned(adminUsername, id)
+	if err != nil {
+		return nil, err
+	}
+
+	subscription.MinSeverity = severity.Value()
+	subscription, err = s.repo.Save(subscription)
+	if err != nil {
+		return nil, err
+	}
+
+	dto := toWebPushSubscriptionDTO(*subscription)
+	return &dto, nil
+}
+
+// Unsubscribe removes one of the admin's push subscriptions
+func (s *webPushService) Unsubscribe(adminUsername string, id string) error {
+	if _, err := s.findOwned(adminUsername, id); err != nil {
+		return err
+	}
+	return s.repo.Delete(id)
+}
+
+// SendTest pushes a test message to all of the admin's subscriptions and
+// returns how many were delivered
+func (s *webPushService) SendTest(adminUsername string) (int, error) {
+	if s.repo == nil || s.sendTest == nil {
+		return 0, fmt.Errorf("web push is not enabled")
+	}
+
+	subscriptions, err := s.repo.FindByAdmin(adminUsername)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list web push subscriptions: %w", err)
+	}
+	if subscriptions == nil || len(*subscriptions) == 0 {
+		return 0, fmt.Errorf("no web push subscriptions registered")
+	}
+
+	delivered := 0
+	var lastErr error
+	for i := range *subscriptions {
+		if err := s.sendTest(&(*subscriptions)[i]); err != nil {
+			lastErr = err
+			continue
+		}
+		delivered++
+	}
+	if delivered == 0 && lastErr != nil {
+		return 0, fmt.Errorf("failed to send test notification: %w", lastErr)
+	}
+	return delivered, nil
+}
+
+// findOwned returns the subscription if it belongs to the admin
+func (s *webPushService) findOwned(adminUsername string, id string) (*api_usage.WebPushSubscription, error) {
+	if s.repo == nil {
+		return nil, fmt.Errorf("web push is not enabled")
+	}
+	subscription, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load web push subscription: %w", err)
+	}
+	if subscription == nil || subscription.AdminUsername != adminUsername {
+		return nil, apperrors.Newf(apperrors.ErrNotFound, "web push subscription not found: %s", id)
+	}
+	return subscription, nil
+}
+
+func toWebPushSubscriptionDTO(subscription api_usage.WebPushSubscription) WebPushSubscriptionDTO {
+	return WebPushSubscriptionDTO{
+		ID:           subscription.ID,
+		Endpoint:     subscription.Endpoint,
+		MinSeverity:  subscription.MinSeverity,
+		UserAgent:    subscription.UserAgent,
+		LastPushedAt: subscription.LastPushedAt,
+		CreatedAt:    subscription.CreatedAt,
+	}
+}
+
+// WebPushSubscribeRequest is the PushSubscription JSON produced by the
+// browser, plus the lowest alert severity the admin wants pushed
+type WebPushSubscribeRequest struct {
+	Endpoint string `json:"endpoint" binding:"required"`
+	Keys     struct {
+		P256dh string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+	MinSeverity string `json:"min_severity"`
+}
+
+// UpdateWebPushSubscriptionRequest changes the severity filter of a subscription
+type UpdateWebPushSubscriptionRequest struct {
+	MinSeverity string `json:"min_severity" binding:"required"`
+}
+
+// WebPushSubscriptionDTO describes a registered push subscription
+type WebPushSubscriptionDTO struct {
+	ID           string     `json:"id"`
+	Endpoint     string     `json:"endpoint"`
+	MinSeverity  string     `json:"min_severity"`
+	UserAgent    string     `json:"user_agent,omitempty"`
+	LastPushedAt *time.Time `json:"last_pushed_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+
+	authorization_audit "github.com/aruncs31s/azf/domain/authorization_audit/model"
+	"github.com/aruncs31s/azf/shared/clock"
+	apperrors "github.com/aruncs31s/azf/shared/errors"
+	"github.com/aruncs31s/azf/shared/ids"
+)
+
+// webhookManager implements authorization_audit.WebhookManager over the
+// subscription repository, delivering test events through the dispatcher
+type webhookManager struct {
+	subscriptions authorization_audit.WebhookSubscriptionRepository
+	dispatcher    authorization_audit.WebhookDispatcher
+}
+
+// NewWebhookManager creates a webhook subscription manager
+func NewWebhookManager(
+	subscriptions authorization_audit.WebhookSubscriptionRepository,
+	dispatcher authorization_audit.WebhookDispatcher,
+) authorization_audit.WebhookManager {
+	return &webhookManager{
+		subscriptions: subscriptions,
+		dispatcher:    dispatcher,
+	}
+}
+
+func (m *webhookManager) RegisterSubscription(ctx context.Context, subscription *authorization_audit.WebhookSubscription) (*authorization_audit.WebhookSubscription, error) {
+	return m.subscriptions.Create(ctx, subscription)
+}
+
+func (m *webhookManager) UnregisterSubscription(ctx context.Context, subscriptionID string) error {
+	return m.subscriptions.Delete(ctx, subscriptionID)
+}
+
+func (m *webhookManager) UpdateSubscription(ctx context.Context, subscription *authorization_audit.WebhookSubscription) (*authorization_audit.WebhookSubscription, error) {
+	return m.subscriptions.Update(ctx, subscription)
+}
+
+func (m *webhookManager) GetSubscription(ctx context.Context, subscriptionID string) (*authorization_audit.WebhookSubscription, error) {
+	return m.subscriptions.FindByID(ctx, subscriptionID)
+}
+
+func (m *webhookManager) ListSubscriptions(ctx context.Context) ([]*authorization_audit.WebhookSubscription, error) {
+	return m.subscriptions.FindAll(ctx)
+}
+
+func (m *webhookManager) ListActiveSubscriptions(ctx context.Context) ([]*authorization_audit.WebhookSubscription, error) {
+	return m.subscriptions.FindActive(ctx)
+}
+
+// VerifySubscription reactivates the subscription, clearing its failure
+// count, and checks that its endpoint accepts a test event
+func (m *webhookManager) VerifySubscription(ctx context.Context, subscriptionID string) error {
+	subscription, err := m.subscriptions.FindByID(ctx, subscriptionID)
+	if err != nil {
+		return err
+	}
+	if err := subscription.Activate(); err != nil {
+		return err
+	}
+	if _, err := m.subscriptions.Update(ctx, subscription); err != nil {
+		return err
+	}
+	return m.TestSubscription(ctx, subscriptionID)
+}
+
+// TestSubscription delivers a test event of the first event type the
+// subscription listens to
+func (m *webhookManager) TestSubscription(ctx context.Context, subscriptionID string) error {
+	subscription, err := m.subscriptions.FindByID(ctx, subscriptionID)
+	if err != nil {
+		return err
+	}
+	if !subscription.CanDeliver() {
+		return apperrors.Newf(apperrors.ErrValidation, "webhook subscription %s is %s", subscriptionID, subscription.Status().Value())
+	}
+
+	// The example of the schema makes the test event look like a real one
+	eventType := subscription.EventTypes()[0]
+	schema, err := authorization_audit.ParseWebhookSchema(eventType.Value())
+	if err != nil {
+		return err
+	}
+	data := map[string]interface{}{}
+	if len(schema.Examples) > 0 {
+		for key, value := range schema.Examples[0] {
+			data[key] = value
+		}
+	}
+	data["test"] = true
+	data["subscription_id"] = subscription.ID()
+	data["message"] = "Test event sent from the AZF admin UI"
+
+	event, err := authorization_audit.NewWebhookEvent(
+		ids.New(),
+		eventType,
+		authorization_audit.WebhookTestAuditLogID,
+		data,
+		clock.Now(),
+		subscription.Endpoint().Value(),
+	)
+	if err != nil {
+		return err
+	}
+	return m.dispatcher.DispatchEvent(ctx, event)
+}
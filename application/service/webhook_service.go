@@ -0,0 +1,772 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	authorization_audit "github.com/aruncs31s/azf/domain/authorization_audit/model"
+	"github.com/aruncs31s/azf/infrastructure/webhook"
+	apperrors "github.com/aruncs31s/azf/shared/errors"
+	"github.com/aruncs31s/azf/shared/logger"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// defaultWebhookDeliveryLimit is the number of delivery attempts listed when no limit is given
+const defaultWebhookDeliveryLimit = 100
+
+// maxFailedWebhookEvents bounds the failed events listed for replay
+const maxFailedWebhookEvents = 100
+
+const (
+	// defaultWebhookReplayLimit is the page size of subscriber replays without a limit
+	defaultWebhookReplayLimit = 100
+	// maxWebhookReplayLimit bounds the page size of subscriber replays
+	maxWebhookReplayLimit = 500
+)
+
+// WebhookService manages the webhook subscriptions that receive authorization
+// events and the delivery of those events
+type WebhookService interface {
+	// Enabled reports whether webhook storage is available
+	Enabled() bool
+	// EventTypes returns the event types subscriptions can listen to
+	EventTypes() []string
+	ListSubscriptions(ctx context.Context) (*[]WebhookSubscriptionDTO, error)
+	GetSubscription(ctx context.Context, id string) (*WebhookSubscriptionDTO, error)
+	// CreateSubscription registers a subscription. The signing secret is
+	// generated unless given and is only returned here.
+	CreateSubscription(ctx context.Context, req CreateWebhookSubscriptionRequest) (*WebhookSubscriptionDTO, error)
+	// TestSubscription delivers a test event and returns an error if it was not accepted
+	TestSubscription(ctx context.Context, id string) error
+	SuspendSubscription(ctx context.Context, id string) (*WebhookSubscriptionDTO, error)
+	// ActivateSubscription resumes delivery and clears the failure count
+	ActivateSubscription(ctx context.Context, id string) (*WebhookSubscriptionDTO, error)
+	// UpdateFilters replaces the filters of a subscription; an empty map
+	// removes them all
+	UpdateFilters(ctx context.Context, id string, filters map[string]interface{}) (*WebhookSubscriptionDTO, error)
+	// UpdateTransport replaces the transport security options of a
+	// subscription and verifies the endpoint with them, keeping the previous
+	// options when verification fails
+	UpdateTransport(ctx context.Context, id string, req WebhookTransportRequest) (*WebhookSubscriptionDTO, error)
+	DeleteSubscription(ctx context.Context, id string) error
+	// ListDeliveries returns the most recent delivery attempts of a subscription
+	ListDeliveries(ctx context.Context, id string, limit int) (*[]WebhookDeliveryDTO, error)
+	// ListEventDeliveries returns every delivery attempt of an event, oldest first
+	ListEventDeliveries(ctx context.Context, eventID string) (*[]WebhookDeliveryDTO, error)
+	// RedeliverEvent sends an event to one of its subscriptions again and
+	// returns the attempt
+	RedeliverEvent(ctx context.Context, id string, eventID string) (*WebhookDeliveryDTO, error)
+	// ListFailedEvents returns the failed and abandoned events, newest first
+	ListFailedEvents(ctx context.Context) (*[]WebhookEventDTO, error)
+	// ReplayEvent delivers a failed or abandoned event again
+	ReplayEvent(ctx context.Context, eventID string) (*WebhookEventDTO, error)
+	// AuthenticateSubscriber checks that a subscriber request was signed with
+	// the secret of the subscription at timestamp, a Unix time within
+	// webhook.MaxSignatureAge
+	AuthenticateSubscriber(ctx context.Context, id string, timestamp string, signature string, body []byte) error
+	// ReplayEvents returns a page of the stored events of a subscription, as
+	// they were delivered, for its subscriber to catch up after an outage
+	ReplayEvents(ctx context.Context, id string, req WebhookReplayRequest) (*WebhookReplayPage, error)
+}
+
+// webhookService implements WebhookService
+type webhookService struct {
+	manager    authorization_audit.WebhookManager
+	dispatcher authorization_audit.WebhookDispatcher
+	events     authorization_audit.WebhookEventRepository
+	deliveries authorization_audit.WebhookDeliveryRepository
+}
+
+// NewWebhookService creates a new webhook service. All arguments may be nil
+// when the database is not available, in which case webhooks are disabled.
+func NewWebhookService(
+	manager authorization_audit.WebhookManager,
+	dispatcher authorization_audit.WebhookDispatcher,
+	events authorization_audit.WebhookEventRepository,
+	deliveries authorization_audit.WebhookDeliveryRepository,
+) WebhookService {
+	return &webhookService{
+		manager:    manager,
+		dispatcher: dispatcher,
+		events:     events,
+		deliveries: deliveries,
+	}
+}
+
+func (s *webhookService) Enabled() bool {
+	return s.manager != nil
+}
+
+func (s *webhookService) EventTypes() []string {
+	types := authorization_audit.WebhookEventTypes()
+	names := make([]string, 0, len(types))
+	for _, eventType := range types {
+		names = append(names, eventType.Value())
+	}
+	return names
+}
+
+func (s *webhookService) ListSubscriptions(ctx context.Context) (*[]WebhookSubscriptionDTO, error) {
+	result := make([]WebhookSubscriptionDTO, 0)
+	if !s.Enabled() {
+		return &result, nil
+	}
+
+	subscriptions, err := s.manager.ListSubscriptions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook subscriptions: %w", err)
+	}
+	for _, subscription := range subscriptions {
+		result = append(result, toWebhookSubscriptionDTO(subscription))
+	}
+	return &result, nil
+}
+
+func (s *webhookService) GetSubscription(ctx context.Context, id string) (*WebhookSubscriptionDTO, error) {
+	if err := s.checkEnabled(); err != nil {
+		return nil, err
+	}
+	subscription, err := s.manager.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	dto := toWebhookSubscriptionDTO(subscription)
+	return &dto, nil
+}
+
+func (s *webhookService) CreateSubscription(ctx context.Context, req CreateWebhookSubscriptionRequest) (*WebhookSubscriptionDTO, error) {
+	if err := s.checkEnabled(); err != nil {
+		return nil, err
+	}
+
+	target, err := url.Parse(req.Endpoint)
+	if err != nil || (target.Scheme != "https" && target.Scheme != "http") || target.Host == "" {
+		return nil, apperrors.Newf(apperrors.ErrValidation, "endpoint must be an http or https URL")
+	}
+	endpoint, err := authorization_audit.NewWebhookEndpoint(req.Endpoint)
+	if err != nil {
+		return nil, apperrors.Newf(apperrors.ErrValidation, "%w", err)
+	}
+	eventTypes := make([]*authorization_audit.WebhookEventType, 0, len(req.EventTypes))
+	for _, value := range req.EventTypes {
+		eventType, err := authorization_audit.NewWebhookEventType(value)
+		if err != nil {
+			return nil, apperrors.Newf(apperrors.ErrValidation, "%w", err)
+		}
+		eventTypes = append(eventTypes, eventType)
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		if secret, err = generateWebhookSecret(); err != nil {
+			return nil, err
+		}
+	}
+
+	subscription, err := authorization_audit.NewWebhookSubscription(uuid.NewString(), endpoint, eventTypes, secret, strings.TrimSpace(req.Description))
+	if err != nil {
+		return nil, apperrors.Newf(apperrors.ErrValidation, "%w", err)
+	}
+	for name, value := range req.Headers {
+		if err := subscription.SetHeader(strings.TrimSpace(name), value); err != nil {
+			return nil, apperrors.Newf(apperrors.ErrValidation, "%w", err)
+		}
+	}
+	if err := setWebhookFilters(subscription, req.Filters); err != nil {
+		return nil, err
+	}
+	if req.Transport != nil {
+		if err := subscription.SetTransport(req.Transport.toDomain()); err != nil {
+			return nil, apperrors.Newf(apperrors.ErrValidation, "%w", err)
+		}
+	}
+
+	subscription, err = s.manager.RegisterSubscription(ctx, subscription)
+	if err != nil {
+		return nil, err
+	}
+	// An endpoint behind custom transport options is only kept once it is
+	// known to be reachable with them
+	if !subscription.Transport().IsZero() {
+		if err := s.manager.VerifySubscription(ctx, subscription.ID()); err != nil {
+			if deleteErr := s.manager.UnregisterSubscription(ctx, subscription.ID()); deleteErr != nil {
+				logger.Warn("Failed to remove unverified webhook subscription",
+					zap.String("subscription_id", subscription.ID()), zap.Error(deleteErr))
+			}
+			return nil, apperrors.Newf(apperrors.ErrValidation, "webhook endpoint verification failed: %w", err)
+		}
+		if subscription, err = s.manager.GetSubscription(ctx, subscription.ID()); err != nil {
+			return nil, err
+		}
+	}
+
+	logger.Info("Webhook subscription registered",
+		zap.String("subscription_id", subscription.ID()),
+		zap.String("endpoint", subscription.Endpoint().Value()))
+
+	dto := toWebhookSubscriptionDTO(subscription)
+	dto.Secret = subscription.Secret()
+	return &dto, nil
+}
+
+func (s *webhookService) TestSubscription(ctx context.Context, id string) error {
+	if err := s.checkEnabled(); err != nil {
+		return err
+	}
+	return s.manager.TestSubscription(ctx, id)
+}
+
+func (s *webhookService) SuspendSubscription(ctx context.Context, id string) (*WebhookSubscriptionDTO, error) {
+	return s.changeSubscription(ctx, id, (*authorization_audit.WebhookSubscription).Suspend)
+}
+
+func (s *webhookService) ActivateSubscription(ctx context.Context, id string) (*WebhookSubscriptionDTO, error) {
+	return s.changeSubscription(ctx, id, (*authorization_audit.WebhookSubscription).Activate)
+}
+
+func (s *webhookService) UpdateFilters(ctx context.Context, id string, filters map[string]interface{}) (*WebhookSubscriptionDTO, error) {
+	return s.changeSubscription(ctx, id, func(subscription *authorization_audit.WebhookSubscription) error {
+		return setWebhookFilters(subscription, filters)
+	})
+}
+
+// UpdateTransport verifies the endpoint with a test event, which also
+// reactivates a suspended subscription. Clearing the options needs no
+// verification.
+func (s *webhookService) UpdateTransport(ctx context.Context, id string, req WebhookTransportRequest) (*WebhookSubscriptionDTO, error) {
+	if err := s.checkEnabled(); err != nil {
+		return nil, err
+	}
+	subscription, err := s.manager.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	previous := subscription.Transport()
+	transport := req.toDomain()
+	// The key is never returned, so it is kept when the certificate is unchanged
+	if transport.ClientKey == "" && transport.ClientCertificate == previous.ClientCertificate {
+		transport.ClientKey = previous.ClientKey
+	}
+	if err := subscription.SetTransport(transport); err != nil {
+		return nil, apperrors.Newf(apperrors.ErrValidation, "%w", err)
+	}
+	if _, err := s.manager.UpdateSubscription(ctx, subscription); err != nil {
+		return nil, err
+	}
+
+	if transport.IsZero() {
+		return s.GetSubscription(ctx, id)
+	}
+	if err := s.manager.VerifySubscription(ctx, id); err != nil {
+		if restoreErr := s.restoreTransport(ctx, id, previous); restoreErr != nil {
+			return nil, restoreErr
+		}
+		return nil, apperrors.Newf(apperrors.ErrValidation, "webhook endpoint verification failed: %w", err)
+	}
+	logger.Info("Webhook subscription transport updated",
+		zap.String("subscription_id", id),
+		zap.Bool("mutual_tls", transport.MutualTLS()),
+		zap.Int("pinned_ips", len(transport.PinnedIPs)))
+	return s.GetSubscription(ctx, id)
+}
+
+// restoreTransport puts back the transport options a failed update replaced
+func (s *webhookService) restoreTransport(ctx context.Context, id string, transport authorization_audit.WebhookTransport) error {
+	subscription, err := s.manager.GetSubscription(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := subscription.SetTransport(transport); err != nil {
+		return err
+	}
+	_, err = s.manager.UpdateSubscription(ctx, subscription)
+	return err
+}
+
+func (s *webhookService) DeleteSubscription(ctx context.Context, id string) error {
+	if err := s.checkEnabled(); err != nil {
+		return err
+	}
+	return s.manager.UnregisterSubscription(ctx, id)
+}
+
+func (s *webhookService) ListDeliveries(ctx context.Context, id string, limit int) (*[]WebhookDeliveryDTO, error) {
+	if err := s.checkEnabled(); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultWebhookDeliveryLimit
+	}
+
+	attempts, err := s.deliveries.FindBySubscriptionID(ctx, id, limit)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]WebhookDeliveryDTO, 0, len(attempts))
+	for _, attempt := range attempts {
+		result = append(result, toWebhookDeliveryDTO(attempt))
+	}
+	return &result, nil
+}
+
+func (s *webhookService) ListEventDeliveries(ctx context.Context, eventID string) (*[]WebhookDeliveryDTO, error) {
+	if err := s.checkEnabled(); err != nil {
+		return nil, err
+	}
+	if _, err := s.events.FindByID(ctx, eventID); err != nil {
+		return nil, err
+	}
+
+	attempts, err := s.deliveries.FindByEventID(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]WebhookDeliveryDTO, 0, len(attempts))
+	for _, attempt := range attempts {
+		result = append(result, toWebhookDeliveryDTO(attempt))
+	}
+	return &result, nil
+}
+
+// RedeliverEvent sends the event to the subscription whatever its delivery
+// state. A failed delivery is not an error: it is reported through the
+// returned attempt.
+func (s *webhookService) RedeliverEvent(ctx context.Context, id string, eventID string) (*WebhookDeliveryDTO, error) {
+	if err := s.checkEnabled(); err != nil {
+		return nil, err
+	}
+
+	attempt, err := s.dispatcher.RedeliverEvent(ctx, eventID, id)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("Webhook event redelivered",
+		zap.String("event_id", eventID),
+		zap.String("subscription_id", id),
+		zap.Bool("success", attempt.Success))
+
+	dto := toWebhookDeliveryDTO(attempt)
+	return &dto, nil
+}
+
+func (s *webhookService) ListFailedEvents(ctx context.Context) (*[]WebhookEventDTO, error) {
+	result := make([]WebhookEventDTO, 0)
+	if !s.Enabled() {
+		return &result, nil
+	}
+
+	var events []*authorization_audit.WebhookEvent
+	for _, status := range []*authorization_audit.WebhookEventStatus{authorization_audit.WebhookStatusFailed, authorization_audit.WebhookStatusAbandoned} {
+		found, err := s.events.FindByStatus(ctx, status.Value())
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, found...)
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Timestamp().After(events[j].Timestamp()) })
+	if len(events) > maxFailedWebhookEvents {
+		events = events[:maxFailedWebhookEvents]
+	}
+
+	for _, event := range events {
+		result = append(result, toWebhookEventDTO(event))
+	}
+	return &result, nil
+}
+
+// ReplayEvent requeues the event and delivers it right away. A failed
+// delivery is not an error: it is reported through the returned event's status.
+func (s *webhookService) ReplayEvent(ctx context.Context, eventID string) (*WebhookEventDTO, error) {
+	if err := s.checkEnabled(); err != nil {
+		return nil, err
+	}
+
+	event, err := s.events.FindByID(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	// The requeued event is only stored with the delivery outcome, so the
+	// background worker does not pick it up as pending in the meantime
+	if err := event.Requeue(); err != nil {
+		return nil, apperrors.Newf(apperrors.ErrValidation, "%w", err)
+	}
+	if err := s.dispatcher.DispatchEvent(ctx, event); err != nil {
+		logger.Warn("Replayed webhook event was not delivered", zap.String("event_id", eventID), zap.Error(err))
+	}
+	event, err = s.dispatcher.GetEventDeliveryStatus(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	dto := toWebhookEventDTO(event)
+	return &dto, nil
+}
+
+func (s *webhookService) AuthenticateSubscriber(ctx context.Context, id string, timestamp string, signature string, body []byte) error {
+	if err := s.checkEnabled(); err != nil {
+		return err
+	}
+	// Unknown subscriptions fail like bad signatures, so IDs cannot be probed
+	subscription, err := s.manager.GetSubscription(ctx, id)
+	if errors.Is(err, authorization_audit.ErrWebhookSubscriptionNotFound) {
+		return apperrors.Newf(apperrors.ErrUnauthorized, "invalid webhook signature")
+	}
+	if err != nil {
+		return err
+	}
+
+	sentAt, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return apperrors.Newf(apperrors.ErrUnauthorized, "%s must be a Unix timestamp", webhook.HeaderTimestamp)
+	}
+	if age := time.Since(time.Unix(sentAt, 0)); age > webhook.MaxSignatureAge || age < -webhook.MaxSignatureAge {
+		return apperrors.Newf(apperrors.ErrUnauthorized, "%s is more than %s away from the current time", webhook.HeaderTimestamp, webhook.MaxSignatureAge)
+	}
+	if !webhook.VerifySignature(subscription.Secret(), sentAt, body, signature) {
+		return apperrors.Newf(apperrors.ErrUnauthorized, "invalid webhook signature")
+	}
+	return nil
+}
+
+// ReplayEvents reads the events addressed to the subscription endpoint since
+// the subscription was created, leaving out test events and events its
+// filters reject. Pages may hold fewer events than the limit once filtered;
+// has_more tells whether to continue from the cursor.
+func (s *webhookService) ReplayEvents(ctx context.Context, id string, req WebhookReplayRequest) (*WebhookReplayPage, error) {
+	if err := s.checkEnabled(); err != nil {
+		return nil, err
+	}
+	subscription, err := s.manager.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultWebhookReplayLimit
+	}
+	if limit > maxWebhookReplayLimit {
+		limit = maxWebhookReplayLimit
+	}
+	query := authorization_audit.WebhookEventRange{
+		DeliveryURL: subscription.Endpoint().Value(),
+		Since:       subscription.CreatedAt(),
+		Limit:       limit,
+	}
+	for _, eventType := range subscription.EventTypes() {
+		query.EventTypes = append(query.EventTypes, eventType.Value())
+	}
+	if req.Since != nil && req.Since.After(query.Since) {
+		query.Since = *req.Since
+	}
+	if req.Until != nil {
+		if !req.Until.After(query.Since) {
+			return nil, apperrors.Newf(apperrors.ErrValidation, "until must be after since and the creation of the subscription")
+		}
+		query.Until = *req.Until
+	}
+	if req.After != "" {
+		cursor, err := s.events.FindByID(ctx, req.After)
+		if errors.Is(err, authorization_audit.ErrWebhookEventNotFound) || (err == nil && cursor.DeliveryURL() != query.DeliveryURL) {
+			return nil, apperrors.Newf(apperrors.ErrValidation, "unknown replay cursor %s", req.After)
+		}
+		if err != nil {
+			return nil, err
+		}
+		query.AfterTimestamp = cursor.Timestamp()
+		query.AfterID = cursor.ID()
+	}
+
+	events, err := s.events.FindRange(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	page := &WebhookReplayPage{
+		Events:     make([]webhook.Payload, 0, len(events)),
+		NextCursor: req.After,
+		HasMore:    len(events) == limit,
+	}
+	for _, event := range events {
+		page.NextCursor = event.ID()
+		if event.IsTest() || !subscription.Matches(event) {
+			continue
+		}
+		page.Events = append(page.Events, webhook.NewPayload(event))
+	}
+	return page, nil
+}
+
+// changeSubscription applies change to a subscription and stores it
+func (s *webhookService) changeSubscription(ctx context.Context, id string, change func(*authorization_audit.WebhookSubscription) error) (*WebhookSubscriptionDTO, error) {
+	if err := s.checkEnabled(); err != nil {
+		return nil, err
+	}
+	subscription, err := s.manager.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := change(subscription); err != nil {
+		return nil, err
+	}
+	subscription, err = s.manager.UpdateSubscription(ctx, subscription)
+	if err != nil {
+		return nil, err
+	}
+	dto := toWebhookSubscriptionDTO(subscription)
+	return &dto, nil
+}
+
+func (s *webhookService) checkEnabled() error {
+	if !s.Enabled() {
+		return fmt.Errorf("webhooks are not available: database is not configured")
+	}
+	return nil
+}
+
+// setWebhookFilters replaces the filters of subscription with filters
+func setWebhookFilters(subscription *authorization_audit.WebhookSubscription, filters map[string]interface{}) error {
+	for key := range subscription.Filters() {
+		subscription.RemoveFilter(key)
+	}
+	for key, value := range filters {
+		if err := subscription.SetFilter(strings.TrimSpace(key), value); err != nil {
+			return apperrors.Newf(apperrors.ErrValidation, "%w", err)
+		}
+	}
+	return nil
+}
+
+// generateWebhookSecret returns a random 64 character signing secret
+func generateWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+func toWebhookSubscriptionDTO(subscription *authorization_audit.WebhookSubscription) WebhookSubscriptionDTO {
+	eventTypes := make([]string, 0, len(subscription.EventTypes()))
+	for _, eventType := range subscription.EventTypes() {
+		eventTypes = append(eventTypes, eventType.Value())
+	}
+	return WebhookSubscriptionDTO{
+		ID:           subscription.ID(),
+		Endpoint:     subscription.Endpoint().Value(),
+		EventTypes:   eventTypes,
+		Status:       subscription.Status().Value(),
+		Description:  subscription.Description(),
+		Headers:      subscription.Headers(),
+		Filters:      webhookFilterValues(subscription),
+		Transport:    toWebhookTransportDTO(subscription.Transport()),
+		FailureCount: subscription.FailureCount(),
+		MaxFailures:  subscription.MaxFailures(),
+		Healthy:      subscription.IsHealthy(),
+		LastDelivery: subscription.LastDelivery(),
+		CreatedAt:    subscription.CreatedAt(),
+		UpdatedAt:    subscription.UpdatedAt(),
+	}
+}
+
+// webhookFilterValues returns the filters of subscription by key
+func webhookFilterValues(subscription *authorization_audit.WebhookSubscription) map[string][]string {
+	filters := make(map[string][]string)
+	for key, value := range subscription.Filters() {
+		if values, err := authorization_audit.ParseWebhookFilter(key, value); err == nil {
+			filters[key] = values
+		}
+	}
+	return filters
+}
+
+// toWebhookTransportDTO describes the transport options without the client key
+func toWebhookTransportDTO(transport authorization_audit.WebhookTransport) *WebhookTransportDTO {
+	if transport.IsZero() {
+		return nil
+	}
+	return &WebhookTransportDTO{
+		CABundle:          transport.CABundle,
+		ClientCertificate: transport.ClientCertificate,
+		MutualTLS:         transport.MutualTLS(),
+		MinTLSVersion:     transport.MinTLSVersion,
+		PinnedIPs:         transport.PinnedIPs,
+	}
+}
+
+func toWebhookDeliveryDTO(attempt *authorization_audit.WebhookDeliveryAttempt) WebhookDeliveryDTO {
+	return WebhookDeliveryDTO{
+		ID:             attempt.ID,
+		EventID:        attempt.EventID,
+		SubscriptionID: attempt.SubscriptionID,
+		Attempt:        attempt.Attempt,
+		URL:            attempt.URL,
+		StatusCode:     attempt.StatusCode,
+		Success:        attempt.Success,
+		Error:          attempt.Error,
+		ResponseBody:   attempt.ResponseBody,
+		DurationMs:     attempt.Duration.Milliseconds(),
+		AttemptedAt:    attempt.AttemptedAt,
+		Redelivery:     attempt.Redelivery,
+	}
+}
+
+func toWebhookEventDTO(event *authorization_audit.WebhookEvent) WebhookEventDTO {
+	return WebhookEventDTO{
+		ID:          event.ID(),
+		EventType:   event.EventType().Value(),
+		AuditLogID:  event.AuditLogID(),
+		Status:      event.Status().Value(),
+		DeliveryURL: event.DeliveryURL(),
+		RetryCount:  event.RetryCount(),
+		MaxRetries:  event.MaxRetries(),
+		LastError:   event.LastError(),
+		LastAttempt: event.LastAttempt(),
+		NextRetry:   event.NextRetry(),
+		Timestamp:   event.Timestamp(),
+	}
+}
+
+// CreateWebhookSubscriptionRequest registers a webhook subscription
+type CreateWebhookSubscriptionRequest struct {
+	Endpoint   string   `json:"endpoint" binding:"required"`
+	EventTypes []string `json:"event_types" binding:"required"`
+	// Secret signs the payloads; one is generated when empty
+	Secret      string            `json:"secret"`
+	Description string            `json:"description"`
+	Headers     map[string]string `json:"headers"`
+	// Filters limit the events delivered; see UpdateWebhookFiltersRequest
+	Filters map[string]interface{} `json:"filters"`
+	// Transport sets transport security options. The endpoint is verified
+	// with them and the subscription is not created when that fails.
+	Transport *WebhookTransportRequest `json:"transport"`
+}
+
+// WebhookTransportRequest sets the transport security options of a
+// subscription. Certificates and keys are PEM encoded; min_tls_version is
+// "1.2" or "1.3". Empty fields leave the defaults.
+type WebhookTransportRequest struct {
+	CABundle          string `json:"ca_bundle"`
+	ClientCertificate string `json:"client_certificate"`
+	// ClientKey may be omitted on update to keep the key of an unchanged certificate
+	ClientKey     string   `json:"client_key"`
+	MinTLSVersion string   `json:"min_tls_version"`
+	PinnedIPs     []string `json:"pinned_ips"`
+}
+
+func (r WebhookTransportRequest) toDomain() authorization_audit.WebhookTransport {
+	pinnedIPs := make([]string, 0, len(r.PinnedIPs))
+	for _, ip := range r.PinnedIPs {
+		if ip = strings.TrimSpace(ip); ip != "" {
+			pinnedIPs = append(pinnedIPs, ip)
+		}
+	}
+	if len(pinnedIPs) == 0 {
+		pinnedIPs = nil
+	}
+	return authorization_audit.WebhookTransport{
+		CABundle:          strings.TrimSpace(r.CABundle),
+		ClientCertificate: strings.TrimSpace(r.ClientCertificate),
+		ClientKey:         strings.TrimSpace(r.ClientKey),
+		MinTLSVersion:     strings.TrimSpace(r.MinTLSVersion),
+		PinnedIPs:         pinnedIPs,
+	}
+}
+
+// UpdateWebhookFiltersRequest replaces the filters of a subscription. Keys are
+// resource_prefix, role, result (granted or denied), environment and tenant;
+// values are a string or a list of strings, any of which may match.
+type UpdateWebhookFiltersRequest struct {
+	Filters map[string]interface{} `json:"filters"`
+}
+
+// WebhookSubscriptionDTO describes a webhook subscription and its health
+type WebhookSubscriptionDTO struct {
+	ID           string               `json:"id"`
+	Endpoint     string               `json:"endpoint"`
+	EventTypes   []string             `json:"event_types"`
+	Status       string               `json:"status"`
+	Description  string               `json:"description,omitempty"`
+	Headers      map[string]string    `json:"headers,omitempty"`
+	Filters      map[string][]string  `json:"filters,omitempty"`
+	Transport    *WebhookTransportDTO `json:"transport,omitempty"`
+	FailureCount int                  `json:"failure_count"`
+	MaxFailures  int                  `json:"max_failures"`
+	Healthy      bool                 `json:"healthy"`
+	LastDelivery *time.Time           `json:"last_delivery,omitempty"`
+	CreatedAt    time.Time            `json:"created_at"`
+	UpdatedAt    time.Time            `json:"updated_at"`
+	// Secret is only set in the response to creating the subscription
+	Secret string `json:"secret,omitempty"`
+}
+
+// WebhookTransportDTO describes the transport security options of a
+// subscription. The client key is never returned.
+type WebhookTransportDTO struct {
+	CABundle          string   `json:"ca_bundle,omitempty"`
+	ClientCertificate string   `json:"client_certificate,omitempty"`
+	MutualTLS         bool     `json:"mutual_tls"`
+	MinTLSVersion     string   `json:"min_tls_version,omitempty"`
+	PinnedIPs         []string `json:"pinned_ips,omitempty"`
+}
+
+// WebhookDeliveryDTO describes one attempt to deliver an event
+type WebhookDeliveryDTO struct {
+	ID             string `json:"id"`
+	EventID        string `json:"event_id"`
+	SubscriptionID string `json:"subscription_id"`
+	Attempt        int    `json:"attempt"`
+	URL            string `json:"url"`
+	StatusCode     int    `json:"status_code,omitempty"`
+	Success        bool   `json:"success"`
+	Error          string `json:"error,omitempty"`
+	// ResponseBody is the start of the response body, up to 1 KiB
+	ResponseBody string    `json:"response_body,omitempty"`
+	DurationMs   int64     `json:"duration_ms"`
+	AttemptedAt  time.Time `json:"attempted_at"`
+	// Redelivery is set for attempts triggered by hand
+	Redelivery bool `json:"redelivery"`
+}
+
+// WebhookReplayRequest selects the events a subscriber fetches again. Events
+// are returned oldest first.
+type WebhookReplayRequest struct {
+	// Since (inclusive) and Until (exclusive) bound the event timestamps
+	Since *time.Time `json:"since"`
+	Until *time.Time `json:"until"`
+	// After continues from the next_cursor of the previous page
+	After string `json:"after"`
+	Limit int    `json:"limit"`
+}
+
+// WebhookReplayPage is a page of replayed events, in the format they are
+// delivered in
+type WebhookReplayPage struct {
+	Events []webhook.Payload `json:"events"`
+	// NextCursor is the After of the next page. It is kept when the page is
+	// empty, so subscribers can poll with it for newer events.
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// WebhookEventDTO describes a webhook event and its delivery state
+type WebhookEventDTO struct {
+	ID          string     `json:"id"`
+	EventType   string     `json:"event_type"`
+	AuditLogID  string     `json:"audit_log_id"`
+	Status      string     `json:"status"`
+	DeliveryURL string     `json:"delivery_url"`
+	RetryCount  int        `json:"retry_count"`
+	MaxRetries  int        `json:"max_retries"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastAttempt *time.Time `json:"last_attempt,omitempty"`
+	NextRetry   *time.Time `json:"next_retry,omitempty"`
+	Timestamp   time.Time  `json:"timestamp"`
+}
@@ -29,7 +29,7 @@ func LoginPage(theError string) templ.Component {
 			templ_7745c5c3_Var1 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<!doctype html><html lang=\"en\"><head><meta charset=\"UTF-8\"><meta name=\"viewport\" content=\"width=device-width, initial-scale=1.0\"><title>Admin Login - Permission Management</title><script src=\"https://cdn.tailwindcss.com\"></script><script>\n\t\t\t\ttailwind.config = {\n\t\t\t\t\tdarkMode: 'class',\n\t\t\t\t}\n\t\t\t</script><style>\n\t\t\t\tbody {\n\t\t\t\t\tbackground: linear-gradient(135deg, #667eea 0%, #764ba2 100%);\n\t\t\t\t\tmin-height: 100vh;\n\t\t\t\t}\n\t\t\t\t.dark body {\n\t\t\t\t\tbackground: linear-gradient(135deg, #1e1b4b 0%, #2e1065 100%);\n\t\t\t\t}\n\t\t\t\t.login-card {\n\t\t\t\t\tbackground: rgba(255, 255, 255, 0.95);\n\t\t\t\t\tbackdrop-filter: blur(10px);\n\t\t\t\t}\n\t\t\t\t.dark .login-card {\n\t\t\t\t\tbackground: rgba(31, 41, 55, 0.95);\n\t\t\t\t\tbackdrop-filter: blur(10px);\n\t\t\t\t}\n\t\t\t\t.login-btn:hover {\n\t\t\t\t\ttransform: translateY(-2px);\n\t\t\t\t\tbox-shadow: 0 10px 25px rgba(0, 0, 0, 0.2);\n\t\t\t\t}\n\t\t\t\t.input-focus:focus {\n\t\t\t\t\tborder-color: #667eea;\n\t\t\t\t\tbox-shadow: 0 0 0 3px rgba(102, 126, 234, 0.1);\n\t\t\t\t}\n\t\t\t\t.dark .input-focus:focus {\n\t\t\t\t\tborder-color: #818cf8;\n\t\t\t\t\tbox-shadow: 0 0 0 3px rgba(129, 140, 248, 0.1);\n\t\t\t\t}\n\t\t\t\t.dark .text-white {\n\t\t\t\t\tcolor: #f3f4f6;\n\t\t\t\t}\n\t\t\t\t.dark .text-gray-200 {\n\t\t\t\t\tcolor: #e5e7eb;\n\t\t\t\t}\n\t\t\t\t.dark .text-gray-600 {\n\t\t\t\t\tcolor: #9ca3af;\n\t\t\t\t}\n\t\t\t\t.dark .text-gray-700 {\n\t\t\t\t\tcolor: #d1d5db;\n\t\t\t\t}\n\t\t\t\t.dark .text-gray-800 {\n\t\t\t\t\tcolor: #f3f4f6;\n\t\t\t\t}\n\t\t\t\t.dark .text-red-700 {\n\t\t\t\t\tcolor: #fca5a5;\n\t\t\t\t}\n\t\t\t\t.dark .text-blue-600 {\n\t\t\t\t\tcolor: #60a5fa;\n\t\t\t\t}\n\t\t\t\t.dark .text-green-700 {\n\t\t\t\t\tcolor: #86efac;\n\t\t\t\t}\n\t\t\t\t.dark .bg-red-50 {\n\t\t\t\t\tbackground-color: #7f1d1d;\n\t\t\t\t}\n\t\t\t\t.dark .bg-green-50 {\n\t\t\t\t\tbackground-color: #166534;\n\t\t\t\t}\n\t\t\t\t.dark .border-red-500 {\n\t\t\t\t\tborder-color: #f87171;\n\t\t\t\t}\n\t\t\t\t.dark .border-green-500 {\n\t\t\t\t\tborder-color: #4ade80;\n\t\t\t\t}\n\t\t\t\t.dark input,\n\t\t\t\t.dark select,\n\t\t\t\t.dark textarea {\n\t\t\t\t\tbackground-color: #1f2937;\n\t\t\t\t\tcolor: #f3f4f6;\n\t\t\t\t\tborder-color: #4b5563;\n\t\t\t\t}\n\t\t\t\t.dark input:focus,\n\t\t\t\t.dark select:focus,\n\t\t\t\t.dark textarea:focus {\n\t\t\t\t\tborder-color: #818cf8;\n\t\t\t\t\tbox-shadow: 0 0 0 3px rgba(129, 140, 248, 0.1);\n\t\t\t\t}\n\t\t\t\t.dark .bg-blue-600 {\n\t\t\t\t\tbackground-color: #2563eb;\n\t\t\t\t}\n\t\t\t\t.dark .hover\\:bg-blue-700:hover {\n\t\t\t\t\tbackground-color: #1d4ed8;\n\t\t\t\t}\n\t\t\t\t.dark .hover\\:text-blue-700:hover {\n\t\t\t\t\tcolor: #60a5fa;\n\t\t\t\t}\n\t\t\t\t.dark .focus\\:ring-blue-500:focus {\n\t\t\t\t\tbox-shadow: 0 0 0 3px rgba(59, 130, 246, 0.1);\n\t\t\t\t}\n\t\t\t</style><script>\n\t\t\t\t// Store JWT token from login response\n\t\t\t\tfunction storeJWTToken(token) {\n\t\t\t\t\tlocalStorage.setItem('jwt_token', token);\n\t\t\t\t}\n\n\t\t\t\t// Retrieve JWT token from localStorage\n\t\t\t\tfunction getJWTToken() {\n\t\t\t\t\treturn localStorage.getItem('jwt_token');\n\t\t\t\t}\n\n\t\t\t\t// Clear JWT token from localStorage\n\t\t\t\tfunction clearJWTToken() {\n\t\t\t\t\tlocalStorage.removeItem('jwt_token');\n\t\t\t\t}\n\n\t\t\t\t// Make API request with JWT token\n\t\t\t\tasync function apiRequest(url, method = 'GET', body = null) {\n\t\t\t\t\tconst token = getJWTToken();\n\t\t\t\t\tconst headers = {\n\t\t\t\t\t\t'Content-Type': 'application/json',\n\t\t\t\t\t};\n\n\t\t\t\t\tif (token) {\n\t\t\t\t\t\theaders['Authorization'] = 'Bearer ' + token;\n\t\t\t\t\t}\n\n\t\t\t\t\tconst options = {\n\t\t\t\t\t\tmethod,\n\t\t\t\t\t\theaders,\n\t\t\t\t\t};\n\n\t\t\t\t\tif (body) {\n\t\t\t\t\t\toptions.body = JSON.stringify(body);\n\t\t\t\t\t}\n\n\t\t\t\t\treturn fetch(url, options);\n\t\t\t\t}\n\n\t\t\t\t// Validate JWT token is actually valid by testing it\n\t\t\t\tasync function isTokenValid() {\n\t\t\t\t\tconst token = getJWTToken();\n\t\t\t\t\tif (!token) {\n\t\t\t\t\t\treturn false;\n\t\t\t\t\t}\n\n\t\t\t\t\ttry {\n\t\t\t\t\t\t// Attempt to use the token by making a test request\n\t\t\t\t\t\tconst response = await fetch('/admin-ui', {\n\t\t\t\t\t\t\tmethod: 'GET',\n\t\t\t\t\t\t\theaders: {\n\t\t\t\t\t\t\t\t'Authorization': 'Bearer ' + token\n\t\t\t\t\t\t\t}\n\t\t\t\t\t\t});\n\n\t\t\t\t\t\t// If we get a 401, token is invalid\n\t\t\t\t\t\tif (response.status === 401) {\n\t\t\t\t\t\t\tclearJWTToken();\n\t\t\t\t\t\t\treturn false;\n\t\t\t\t\t\t}\n\n\t\t\t\t\t\treturn response.ok;\n\t\t\t\t\t} catch (error) {\n\t\t\t\t\t\tconsole.error('Token validation error:', error);\n\t\t\t\t\t\treturn false;\n\t\t\t\t\t}\n\t\t\t\t}\n\n\t\t\t\t// Handle login form submission with JSON\n\t\t\t\tasync function handleLoginJSON(event) {\n\t\t\t\t\tevent.preventDefault();\n\t\t\t\t\tconst username = document.getElementById('username').value;\n\t\t\t\t\tconst password = document.getElementById('password').value;\n\n\t\t\t\t\tif (!username || !password) {\n\t\t\t\t\t\talert('Please enter both username and password');\n\t\t\t\t\t\treturn;\n\t\t\t\t\t}\n\n\t\t\t\t\ttry {\n\t\t\t\t\t\tconst response = await fetch('/admin-ui/login/json', {\n\t\t\t\t\t\t\tmethod: 'POST',\n\t\t\t\t\t\t\theaders: {\n\t\t\t\t\t\t\t\t'Content-Type': 'application/json',\n\t\t\t\t\t\t\t},\n\t\t\t\t\t\t\tbody: JSON.stringify({ username, password }),\n\t\t\t\t\t\t});\n\n\t\t\t\t\t\tconst data = await response.json();\n\n\t\t\t\t\t\t// Check both response status and response data success flag\n\t\t\t\t\t\tif (response.ok && data.success && data.jwt) {\n\t\t\t\t\t\t\tstoreJWTToken(data.jwt);\n\t\t\t\t\t\t\t// Redirect to dashboard\n\t\t\t\t\t\t\twindow.location.href = '/admin-ui';\n\t\t\t\t\t\t} else {\n\t\t\t\t\t\t\t// Clear any invalid token on failed login\n\t\t\t\t\t\t\tclearJWTToken();\n\t\t\t\t\t\t\talert('Login failed: ' + (data.message || 'Unknown error'));\n\t\t\t\t\t\t}\n\t\t\t\t\t} catch (error) {\n\t\t\t\t\t\tconsole.error('Login error:', error);\n\t\t\t\t\t\tclearJWTToken();\n\t\t\t\t\t\talert('An error occurred during login');\n\t\t\t\t\t}\n\t\t\t\t}\n</script><script>\n\t\t\t\t\t// Initialize dark mode from localStorage\n\t\t\t\t\tfunction initializeDarkMode() {\n\t\t\t\t\t\tconst isDarkMode = localStorage.getItem('darkMode') === 'true';\n\t\t\t\t\t\tconst htmlElement = document.documentElement;\n\n\t\t\t\t\t\tif (isDarkMode) {\n\t\t\t\t\t\t\thtmlElement.classList.add('dark');\n\t\t\t\t\t\t} else {\n\t\t\t\t\t\t\thtmlElement.classList.remove('dark');\n\t\t\t\t\t\t}\n\t\t\t\t\t}\n\n\t\t\t\t\t// Check if user is already logged in (has valid JWT)\n\t\t\t\t\twindow.addEventListener('load', async function() {\n\t\t\t\t\t\tconst token = getJWTToken();\n\t\t\t\t\t\tconst currentPath = window.location.pathname;\n\t\t\t\t\t\t// Check for both /admin-ui/login and /login paths\n\t\t\t\t\t\tif (token && (currentPath === '/admin-ui/login' || currentPath === '/login')) {\n\t\t\t\t\t\t\t// Validate token is actually valid before redirecting\n\t\t\t\t\t\t\tconst valid = await isTokenValid();\n\t\t\t\t\t\t\tif (valid) {\n\t\t\t\t\t\t\t\t// Redirect to dashboard if already logged in with valid token\n\t\t\t\t\t\t\t\twindow.location.href = '/admin-ui';\n\t\t\t\t\t\t\t} else {\n\t\t\t\t\t\t\t\t// Token is invalid or expired, clear it\n\t\t\t\t\t\t\t\tclearJWTToken();\n\t\t\t\t\t\t\t}\n\t\t\t\t\t\t}\n\t\t\t\t\t});\n\n\t\t\t\t\t// Initialize immediately for faster response\n\t\t\t\t\tinitializeDarkMode();\n\t\t\t\t\tdocument.addEventListener('DOMContentLoaded', initializeDarkMode);\n\t\t\t\t</script></head><body class=\"flex items-center justify-center dark:bg-gray-950\"><div class=\"w-full max-w-md\"><!-- Header --><div class=\"text-center mb-8\"><h1 class=\"text-4xl font-bold text-white dark:text-gray-100 mb-2\">Admin Panel</h1><p class=\"text-gray-200 dark:text-gray-400\">Permission Management System</p></div><!-- Login Card --><div class=\"login-card rounded-2xl shadow-2xl p-8\"><!-- Title --><div class=\"mb-8\"><h2 class=\"text-2xl font-bold text-gray-800 dark:text-gray-100 mb-2\">Welcome Back</h2><p class=\"text-gray-600 dark:text-gray-400\">Sign in to your admin account</p></div><!-- Error Message -->")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<!doctype html><html lang=\"en\"><head><meta charset=\"UTF-8\"><meta name=\"viewport\" content=\"width=device-width, initial-scale=1.0\"><title>Admin Login - Permission Management</title><script src=\"https://cdn.tailwindcss.com\"></script><script src=\"https://cdnjs.cloudflare.com/ajax/libs/qrcodejs/1.0.0/qrcode.min.js\"></script><script>\n\t\t\t\ttailwind.config = {\n\t\t\t\t\tdarkMode: 'class',\n\t\t\t\t}\n\t\t\t</script><style>\n\t\t\t\tbody {\n\t\t\t\t\tbackground: linear-gradient(135deg, #667eea 0%, #764ba2 100%);\n\t\t\t\t\tmin-height: 100vh;\n\t\t\t\t}\n\t\t\t\t.dark body {\n\t\t\t\t\tbackground: linear-gradient(135deg, #1e1b4b 0%, #2e1065 100%);\n\t\t\t\t}\n\t\t\t\t.login-card {\n\t\t\t\t\tbackground: rgba(255, 255, 255, 0.95);\n\t\t\t\t\tbackdrop-filter: blur(10px);\n\t\t\t\t}\n\t\t\t\t.dark .login-card {\n\t\t\t\t\tbackground: rgba(31, 41, 55, 0.95);\n\t\t\t\t\tbackdrop-filter: blur(10px);\n\t\t\t\t}\n\t\t\t\t.login-btn:hover {\n\t\t\t\t\ttransform: translateY(-2px);\n\t\t\t\t\tbox-shadow: 0 10px 25px rgba(0, 0, 0, 0.2);\n\t\t\t\t}\n\t\t\t\t.input-focus:focus {\n\t\t\t\t\tborder-color: #667eea;\n\t\t\t\t\tbox-shadow: 0 0 0 3px rgba(102, 126, 234, 0.1);\n\t\t\t\t}\n\t\t\t\t.dark .input-focus:focus {\n\t\t\t\t\tborder-color: #818cf8;\n\t\t\t\t\tbox-shadow: 0 0 0 3px rgba(129, 140, 248, 0.1);\n\t\t\t\t}\n\t\t\t\t.dark .text-white {\n\t\t\t\t\tcolor: #f3f4f6;\n\t\t\t\t}\n\t\t\t\t.dark .text-gray-200 {\n\t\t\t\t\tcolor: #e5e7eb;\n\t\t\t\t}\n\t\t\t\t.dark .text-gray-600 {\n\t\t\t\t\tcolor: #9ca3af;\n\t\t\t\t}\n\t\t\t\t.dark .text-gray-700 {\n\t\t\t\t\tcolor: #d1d5db;\n\t\t\t\t}\n\t\t\t\t.dark .text-gray-800 {\n\t\t\t\t\tcolor: #f3f4f6;\n\t\t\t\t}\n\t\t\t\t.dark .text-red-700 {\n\t\t\t\t\tcolor: #fca5a5;\n\t\t\t\t}\n\t\t\t\t.dark .text-blue-600 {\n\t\t\t\t\tcolor: #60a5fa;\n\t\t\t\t}\n\t\t\t\t.dark .text-green-700 {\n\t\t\t\t\tcolor: #86efac;\n\t\t\t\t}\n\t\t\t\t.dark .bg-red-50 {\n\t\t\t\t\tbackground-color: #7f1d1d;\n\t\t\t\t}\n\t\t\t\t.dark .bg-green-50 {\n\t\t\t\t\tbackground-color: #166534;\n\t\t\t\t}\n\t\t\t\t.dark .border-red-500 {\n\t\t\t\t\tborder-color: #f87171;\n\t\t\t\t}\n\t\t\t\t.dark .border-green-500 {\n\t\t\t\t\tborder-color: #4ade80;\n\t\t\t\t}\n\t\t\t\t.dark input,\n\t\t\t\t.dark select,\n\t\t\t\t.dark textarea {\n\t\t\t\t\tbackground-color: #1f2937;\n\t\t\t\t\tcolor: #f3f4f6;\n\t\t\t\t\tborder-color: #4b5563;\n\t\t\t\t}\n\t\t\t\t.dark input:focus,\n\t\t\t\t.dark select:focus,\n\t\t\t\t.dark textarea:focus {\n\t\t\t\t\tborder-color: #818cf8;\n\t\t\t\t\tbox-shadow: 0 0 0 3px rgba(129, 140, 248, 0.1);\n\t\t\t\t}\n\t\t\t\t.dark .bg-blue-600 {\n\t\t\t\t\tbackground-color: #2563eb;\n\t\t\t\t}\n\t\t\t\t.dark .hover\\:bg-blue-700:hover {\n\t\t\t\t\tbackground-color: #1d4ed8;\n\t\t\t\t}\n\t\t\t\t.dark .hover\\:text-blue-700:hover {\n\t\t\t\t\tcolor: #60a5fa;\n\t\t\t\t}\n\t\t\t\t.dark .focus\\:ring-blue-500:focus {\n\t\t\t\t\tbox-shadow: 0 0 0 3px rgba(59, 130, 246, 0.1);\n\t\t\t\t}\n\t\t\t</style><script>\n\t\t\t\t// Store JWT token from login response\n\t\t\t\tfunction storeJWTToken(token) {\n\t\t\t\t\tlocalStorage.setItem('jwt_token', token);\n\t\t\t\t}\n\n\t\t\t\t// Retrieve JWT token from localStorage\n\t\t\t\tfunction getJWTToken() {\n\t\t\t\t\treturn localStorage.getItem('jwt_token');\n\t\t\t\t}\n\n\t\t\t\t// Clear JWT token from localStorage\n\t\t\t\tfunction clearJWTToken() {\n\t\t\t\t\tlocalStorage.removeItem('jwt_token');\n\t\t\t\t}\n\n\t\t\t\t// Make API request with JWT token\n\t\t\t\tasync function apiRequest(url, method = 'GET', body = null) {\n\t\t\t\t\tconst token = getJWTToken();\n\t\t\t\t\tconst headers = {\n\t\t\t\t\t\t'Content-Type': 'application/json',\n\t\t\t\t\t};\n\n\t\t\t\t\tif (token) {\n\t\t\t\t\t\theaders['Authorization'] = 'Bearer ' + token;\n\t\t\t\t\t}\n\n\t\t\t\t\tconst options = {\n\t\t\t\t\t\tmethod,\n\t\t\t\t\t\theaders,\n\t\t\t\t\t};\n\n\t\t\t\t\tif (body) {\n\t\t\t\t\t\toptions.body = JSON.stringify(body);\n\t\t\t\t\t}\n\n\t\t\t\t\tlet response = await fetch(url, options);\n\t\t\t\t\t// An expired access token is renewed once with the refresh token cookie\n\t\t\t\t\tif (response.status === 401 && token && await refreshJWTToken()) {\n\t\t\t\t\t\theaders['Authorization'] = 'Bearer ' + getJWTToken();\n\t\t\t\t\t\tresponse = await fetch(url, options);\n\t\t\t\t\t}\n\t\t\t\t\treturn response;\n\t\t\t\t}\n\n\t\t\t\t// Exchange the refresh token cookie for a new access token\n\t\t\t\tasync function refreshJWTToken() {\n\t\t\t\t\ttry {\n\t\t\t\t\t\tconst response = await fetch('/admin-ui/token/refresh', { method: 'POST' });\n\t\t\t\t\t\tif (!response.ok) {\n\t\t\t\t\t\t\tclearJWTToken();\n\t\t\t\t\t\t\treturn false;\n\t\t\t\t\t\t}\n\t\t\t\t\t\tconst data = await response.json();\n\t\t\t\t\t\tstoreJWTToken(data.access_token);\n\t\t\t\t\t\treturn true;\n\t\t\t\t\t} catch (error) {\n\t\t\t\t\t\treturn false;\n\t\t\t\t\t}\n\t\t\t\t}\n\n\t\t\t\t// Validate JWT token is actually valid by testing it\n\t\t\t\tasync function isTokenValid() {\n\t\t\t\t\tconst token = getJWTToken();\n\t\t\t\t\tif (!token) {\n\t\t\t\t\t\treturn false;\n\t\t\t\t\t}\n\n\t\t\t\t\ttry {\n\t\t\t\t\t\t// Attempt to use the token by making a test request\n\t\t\t\t\t\tconst response = await fetch('/admin-ui', {\n\t\t\t\t\t\t\tmethod: 'GET',\n\t\t\t\t\t\t\theaders: {\n\t\t\t\t\t\t\t\t'Authorization': 'Bearer ' + token\n\t\t\t\t\t\t\t}\n\t\t\t\t\t\t});\n\n\t\t\t\t\t\t// If we get a 401, token is invalid\n\t\t\t\t\t\tif (response.status === 401) {\n\t\t\t\t\t\t\tclearJWTToken();\n\t\t\t\t\t\t\treturn false;\n\t\t\t\t\t\t}\n\n\t\t\t\t\t\treturn response.ok;\n\t\t\t\t\t} catch (error) {\n\t\t\t\t\t\tconsole.error('Token validation error:', error);\n\t\t\t\t\t\treturn false;\n\t\t\t\t\t}\n\t\t\t\t}\n\n\t\t\t\t// Ask for the two-factor code, showing the secret to scan when\n\t\t\t\t// the account must enroll first\n\t\t\t\tfunction showTwoFactor(message, enrollment) {\n\t\t\t\t\tdocument.getElementById('twoFactor').classList.remove('hidden');\n\t\t\t\t\tdocument.getElementById('twoFactorMessage').textContent = message || '';\n\t\t\t\t\tconst codeInput = document.getElementById('otp_code');\n\t\t\t\t\tcodeInput.value = '';\n\t\t\t\t\tcodeInput.required = true;\n\t\t\t\t\tcodeInput.focus();\n\t\t\t\t\tif (enrollment) {\n\t\t\t\t\t\tdocument.getElementById('twoFactorEnrollment').classList.remove('hidden');\n\t\t\t\t\t\tdocument.getElementById('twoFactorSecret').textContent = enrollment.secret;\n\t\t\t\t\t\tconst qrCode = document.getElementById('twoFactorQRCode');\n\t\t\t\t\t\tqrCode.innerHTML = '';\n\t\t\t\t\t\tnew QRCode(qrCode, { text: enrollment.otpauth_url, width: 160, height: 160 });\n\t\t\t\t\t}\n\t\t\t\t}\n\n\t\t\t\t// Handle login form submission with JSON\n\t\t\t\tasync function handleLoginJSON(event) {\n\t\t\t\t\tevent.preventDefault();\n\t\t\t\t\tconst username = document.getElementById('username').value;\n\t\t\t\t\tconst password = document.getElementById('password').value;\n\t\t\t\t\tconst otpCode = document.getElementById('otp_code').value.trim();\n\n\t\t\t\t\tif (!username || !password) {\n\t\t\t\t\t\talert('Please enter both username and password');\n\t\t\t\t\t\treturn;\n\t\t\t\t\t}\n\n\t\t\t\t\ttry {\n\t\t\t\t\t\tconst response = await fetch('/admin-ui/login/json', {\n\t\t\t\t\t\t\tmethod: 'POST',\n\t\t\t\t\t\t\theaders: {\n\t\t\t\t\t\t\t\t'Content-Type': 'application/json',\n\t\t\t\t\t\t\t},\n\t\t\t\t\t\t\tbody: JSON.stringify({ username, password, otp_code: otpCode }),\n\t\t\t\t\t\t});\n\n\t\t\t\t\t\tconst data = await response.json();\n\n\t\t\t\t\t\t// Check both response status and response data success flag\n\t\t\t\t\t\tif (response.ok && data.success && data.jwt) {\n\t\t\t\t\t\t\tstoreJWTToken(data.jwt);\n\t\t\t\t\t\t\tif (data.backup_codes && data.backup_codes.length) {\n\t\t\t\t\t\t\t\talert('Two-factor authentication enabled. Store these backup codes now, they will not be shown again:\\n\\n' + data.backup_codes.join('\\n'));\n\t\t\t\t\t\t\t}\n\t\t\t\t\t\t\t// Redirect to dashboard\n\t\t\t\t\t\t\twindow.location.href = '/admin-ui';\n\t\t\t\t\t\t} else if (data.two_factor_required) {\n\t\t\t\t\t\t\tshowTwoFactor(data.message, data.two_factor_enrollment);\n\t\t\t\t\t\t} else {\n\t\t\t\t\t\t\t// Clear any invalid token on failed login\n\t\t\t\t\t\t\tclearJWTToken();\n\t\t\t\t\t\t\talert('Login failed: ' + (data.message || 'Unknown error'));\n\t\t\t\t\t\t}\n\t\t\t\t\t} catch (error) {\n\t\t\t\t\t\tconsole.error('Login error:', error);\n\t\t\t\t\t\tclearJWTToken();\n\t\t\t\t\t\talert('An error occurred during login');\n\t\t\t\t\t}\n\t\t\t\t}\n</script><script>\n\t\t\t\t\t// Initialize dark mode from localStorage\n\t\t\t\t\tfunction initializeDarkMode() {\n\t\t\t\t\t\tconst isDarkMode = localStorage.getItem('darkMode') === 'true';\n\t\t\t\t\t\tconst htmlElement = document.documentElement;\n\n\t\t\t\t\t\tif (isDarkMode) {\n\t\t\t\t\t\t\thtmlElement.classList.add('dark');\n\t\t\t\t\t\t} else {\n\t\t\t\t\t\t\thtmlElement.classList.remove('dark');\n\t\t\t\t\t\t}\n\t\t\t\t\t}\n\n\t\t\t\t\t// Check if user is already logged in (has valid JWT)\n\t\t\t\t\twindow.addEventListener('load', async function() {\n\t\t\t\t\t\tconst token = getJWTToken();\n\t\t\t\t\t\tconst currentPath = window.location.pathname;\n\t\t\t\t\t\t// Check for both /admin-ui/login and /login paths\n\t\t\t\t\t\tif (token && (currentPath === '/admin-ui/login' || currentPath === '/login')) {\n\t\t\t\t\t\t\t// Validate token is actually valid before redirecting\n\t\t\t\t\t\t\tconst valid = await isTokenValid();\n\t\t\t\t\t\t\tif (valid) {\n\t\t\t\t\t\t\t\t// Redirect to dashboard if already logged in with valid token\n\t\t\t\t\t\t\t\twindow.location.href = '/admin-ui';\n\t\t\t\t\t\t\t} else {\n\t\t\t\t\t\t\t\t// Token is invalid or expired, clear it\n\t\t\t\t\t\t\t\tclearJWTToken();\n\t\t\t\t\t\t\t}\n\t\t\t\t\t\t}\n\t\t\t\t\t});\n\n\t\t\t\t\t// Initialize immediately for faster response\n\t\t\t\t\tinitializeDarkMode();\n\t\t\t\t\tdocument.addEventListener('DOMContentLoaded', initializeDarkMode);\n\t\t\t\t</script></head><body class=\"flex items-center justify-center dark:bg-gray-950\"><div class=\"w-full max-w-md\"><!-- Header --><div class=\"text-center mb-8\"><h1 class=\"text-4xl font-bold text-white dark:text-gray-100 mb-2\">Admin Panel</h1><p class=\"text-gray-200 dark:text-gray-400\">Permission Management System</p></div><!-- Login Card --><div class=\"login-card rounded-2xl shadow-2xl p-8\"><!-- Title --><div class=\"mb-8\"><h2 class=\"text-2xl font-bold text-gray-800 dark:text-gray-100 mb-2\">Welcome Back</h2><p class=\"text-gray-600 dark:text-gray-400\">Sign in to your admin account</p></div><!-- Error Message -->")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -41,7 +41,7 @@ func LoginPage(theError string) templ.Component {
 			var templ_7745c5c3_Var2 string
 			templ_7745c5c3_Var2, templ_7745c5c3_Err = templ.JoinStringErrs(theError)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/login.templ`, Line: 266, Col: 67}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `login.templ`, Line: 313, Col: 67}
 			}
 			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var2))
 			if templ_7745c5c3_Err != nil {
@@ -52,7 +52,7 @@ func LoginPage(theError string) templ.Component {
 				return templ_7745c5c3_Err
 			}
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 4, "<!-- Login Form --><form onsubmit=\"handleLoginJSON(event)\" class=\"space-y-5\"><!-- Username Input --><div><label for=\"username\" class=\"block text-sm font-medium text-gray-700 dark:text-gray-300 mb-2\">Username</label> <input type=\"text\" id=\"username\" name=\"username\" placeholder=\"Enter your username\" required class=\"input-focus w-full px-4 py-3 border border-gray-300 dark:border-gray-600 rounded-lg focus:outline-none transition duration-300 dark:bg-gray-800 dark:text-gray-100\"></div><!-- Password Input --><div><label for=\"password\" class=\"block text-sm font-medium text-gray-700 dark:text-gray-300 mb-2\">Password</label> <input type=\"password\" id=\"password\" name=\"password\" placeholder=\"Enter your password\" required class=\"input-focus w-full px-4 py-3 border border-gray-300 dark:border-gray-600 rounded-lg focus:outline-none transition duration-300 dark:bg-gray-800 dark:text-gray-100\"></div><!-- Remember Me --><div class=\"flex items-center\"><input type=\"checkbox\" id=\"remember\" name=\"remember\" class=\"w-4 h-4 text-blue-600 border-gray-300 dark:border-gray-600 rounded focus:ring-blue-500\"> <label for=\"remember\" class=\"ml-2 text-sm text-gray-600 dark:text-gray-400\">Keep me logged in</label></div><!-- Login Button --><button type=\"submit\" class=\"login-btn w-full bg-gradient-to-r from-blue-600 to-purple-600 hover:from-blue-700 hover:to-purple-700 text-white font-bold py-3 px-4 rounded-lg transition duration-300 ease-in-out mt-6\">Sign In</button></form><!-- Divider --><div class=\"my-6 flex items-center\"><div class=\"flex-1 border-t border-gray-300 dark:border-gray-600\"></div><span class=\"px-2 text-sm text-gray-500 dark:text-gray-400\">or</span><div class=\"flex-1 border-t border-gray-300 dark:border-gray-600\"></div></div><!-- OAuth Login Buttons --><div class=\"space-y-3\"><button type=\"button\" onclick=\"window.location.href='/admin-ui/oauth/google'\" class=\"w-full flex items-center justify-center bg-white dark:bg-gray-800 border border-gray-300 dark:border-gray-600 text-gray-700 dark:text-gray-300 font-medium py-3 px-4 rounded-lg hover:bg-gray-50 dark:hover:bg-gray-700 transition duration-300\"><svg class=\"w-5 h-5 mr-3\" viewBox=\"0 0 24 24\"><path fill=\"#4285F4\" d=\"M22.56 12.25c0-.78-.07-1.53-.2-2.25H12v4.26h5.92c-.26 1.37-1.04 2.53-2.21 3.31v2.77h3.57c2.08-1.92 3.28-4.74 3.28-8.09z\"></path> <path fill=\"#34A853\" d=\"M12 23c2.97 0 5.46-.98 7.28-2.66l-3.57-2.77c-.98.66-2.23 1.06-3.71 1.06-2.86 0-5.29-1.93-6.16-4.53H2.18v2.84C3.99 20.53 7.7 23 12 23z\"></path> <path fill=\"#FBBC05\" d=\"M5.84 14.09c-.22-.66-.35-1.36-.35-2.09s.13-1.43.35-2.09V7.07H2.18C1.43 8.55 1 10.22 1 12s.43 3.45 1.18 4.93l2.85-2.22.81-.62z\"></path> <path fill=\"#EA4335\" d=\"M12 5.38c1.62 0 3.06.56 4.21 1.64l3.15-3.15C17.45 2.09 14.97 1 12 1 7.7 1 3.99 3.47 2.18 7.07l3.66 2.84c.87-2.6 3.3-4.53 6.16-4.53z\"></path></svg> Continue with Google</button> <button type=\"button\" onclick=\"window.location.href='/admin-ui/oauth/github'\" class=\"w-full flex items-center justify-center bg-gray-900 dark:bg-gray-700 text-white font-medium py-3 px-4 rounded-lg hover:bg-gray-800 dark:hover:bg-gray-600 transition duration-300\"><svg class=\"w-5 h-5 mr-3\" fill=\"currentColor\" viewBox=\"0 0 24 24\"><path d=\"M12 0c-6.626 0-12 5.373-12 12 0 5.302 3.438 9.8 8.207 11.387.599.111.793-.261.793-.577v-2.234c-3.338.726-4.033-1.416-4.033-1.416-.546-1.387-1.333-1.756-1.333-1.756-1.089-.745.083-.729.083-.729 1.205.084 1.839 1.237 1.839 1.237 1.07 1.834 2.807 1.304 3.492.997.107-.775.418-1.305.762-1.604-2.665-.305-5.467-1.334-5.467-5.931 0-1.311.469-2.381 1.236-3.221-.124-.303-.535-1.524.117-3.176 0 0 1.008-.322 3.301 1.23.957-.266 1.983-.399 3.003-.404 1.02.005 2.047.138 3.006.404 2.291-1.552 3.297-1.23 3.297-1.23.653 1.653.242 2.874.118 3.176.77.84 1.235 1.911 1.235 3.221 0 4.609-2.807 5.624-5.479 5.921.43.372.823 1.102.823 2.222v3.293c0 .319.192.694.801.576 4.765-1.589 8.199-6.086 8.199-11.386 0-6.627-5.373-12-12-12z\"></path></svg> Continue with GitHub</button></div><!-- Help Text --><p class=\"text-center text-sm text-gray-600 dark:text-gray-400\">Having trouble? <a href=\"#\" class=\"text-blue-600 dark:text-blue-400 hover:text-blue-700 dark:hover:text-blue-300 font-medium\">Contact Support</a></p></div><!-- Footer --><div class=\"mt-8 text-center text-gray-300 dark:text-gray-500 text-sm\"><p>© 2024 Admin Panel. All rights reserved.</p></div></div></body></html>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 4, "<!-- Login Form --><form onsubmit=\"handleLoginJSON(event)\" class=\"space-y-5\"><!-- Username Input --><div><label for=\"username\" class=\"block text-sm font-medium text-gray-700 dark:text-gray-300 mb-2\">Username</label> <input type=\"text\" id=\"username\" name=\"username\" placeholder=\"Enter your username\" required class=\"input-focus w-full px-4 py-3 border border-gray-300 dark:border-gray-600 rounded-lg focus:outline-none transition duration-300 dark:bg-gray-800 dark:text-gray-100\"></div><!-- Password Input --><div><label for=\"password\" class=\"block text-sm font-medium text-gray-700 dark:text-gray-300 mb-2\">Password</label> <input type=\"password\" id=\"password\" name=\"password\" placeholder=\"Enter your password\" required class=\"input-focus w-full px-4 py-3 border border-gray-300 dark:border-gray-600 rounded-lg focus:outline-none transition duration-300 dark:bg-gray-800 dark:text-gray-100\"></div><!-- Two-Factor Code, shown when the account asks for one --><div id=\"twoFactor\" class=\"hidden\"><p id=\"twoFactorMessage\" class=\"text-sm text-gray-600 dark:text-gray-400 mb-2\"></p><div id=\"twoFactorEnrollment\" class=\"hidden mb-3\"><div id=\"twoFactorQRCode\" class=\"bg-white p-2 inline-block mb-2\"></div><code id=\"twoFactorSecret\" class=\"block font-mono text-xs break-all text-gray-700 dark:text-gray-300\"></code></div><label for=\"otp_code\" class=\"block text-sm font-medium text-gray-700 dark:text-gray-300 mb-2\">Authentication Code</label> <input type=\"text\" id=\"otp_code\" name=\"otp_code\" autocomplete=\"one-time-code\" placeholder=\"6-digit code or backup code\" class=\"input-focus w-full px-4 py-3 border border-gray-300 dark:border-gray-600 rounded-lg focus:outline-none transition duration-300 dark:bg-gray-800 dark:text-gray-100\"></div><!-- Remember Me --><div class=\"flex items-center\"><input type=\"checkbox\" id=\"remember\" name=\"remember\" class=\"w-4 h-4 text-blue-600 border-gray-300 dark:border-gray-600 rounded focus:ring-blue-500\"> <label for=\"remember\" class=\"ml-2 text-sm text-gray-600 dark:text-gray-400\">Keep me logged in</label></div><!-- Login Button --><button type=\"submit\" class=\"login-btn w-full bg-gradient-to-r from-blue-600 to-purple-600 hover:from-blue-700 hover:to-purple-700 text-white font-bold py-3 px-4 rounded-lg transition duration-300 ease-in-out mt-6\">Sign In</button></form><!-- Divider --><div class=\"my-6 flex items-center\"><div class=\"flex-1 border-t border-gray-300 dark:border-gray-600\"></div><span class=\"px-2 text-sm text-gray-500 dark:text-gray-400\">or</span><div class=\"flex-1 border-t border-gray-300 dark:border-gray-600\"></div></div><!-- OAuth Login Buttons --><div class=\"space-y-3\"><button type=\"button\" onclick=\"window.location.href='/admin-ui/oauth/google'\" class=\"w-full flex items-center justify-center bg-white dark:bg-gray-800 border border-gray-300 dark:border-gray-600 text-gray-700 dark:text-gray-300 font-medium py-3 px-4 rounded-lg hover:bg-gray-50 dark:hover:bg-gray-700 transition duration-300\"><svg class=\"w-5 h-5 mr-3\" viewBox=\"0 0 24 24\"><path fill=\"#4285F4\" d=\"M22.56 12.25c0-.78-.07-1.53-.2-2.25H12v4.26h5.92c-.26 1.37-1.04 2.53-2.21 3.31v2.77h3.57c2.08-1.92 3.28-4.74 3.28-8.09z\"></path> <path fill=\"#34A853\" d=\"M12 23c2.97 0 5.46-.98 7.28-2.66l-3.57-2.77c-.98.66-2.23 1.06-3.71 1.06-2.86 0-5.29-1.93-6.16-4.53H2.18v2.84C3.99 20.53 7.7 23 12 23z\"></path> <path fill=\"#FBBC05\" d=\"M5.84 14.09c-.22-.66-.35-1.36-.35-2.09s.13-1.43.35-2.09V7.07H2.18C1.43 8.55 1 10.22 1 12s.43 3.45 1.18 4.93l2.85-2.22.81-.62z\"></path> <path fill=\"#EA4335\" d=\"M12 5.38c1.62 0 3.06.56 4.21 1.64l3.15-3.15C17.45 2.09 14.97 1 12 1 7.7 1 3.99 3.47 2.18 7.07l3.66 2.84c.87-2.6 3.3-4.53 6.16-4.53z\"></path></svg> Continue with Google</button> <button type=\"button\" onclick=\"window.location.href='/admin-ui/oauth/github'\" class=\"w-full flex items-center justify-center bg-gray-900 dark:bg-gray-700 text-white font-medium py-3 px-4 rounded-lg hover:bg-gray-800 dark:hover:bg-gray-600 transition duration-300\"><svg class=\"w-5 h-5 mr-3\" fill=\"currentColor\" viewBox=\"0 0 24 24\"><path d=\"M12 0c-6.626 0-12 5.373-12 12 0 5.302 3.438 9.8 8.207 11.387.599.111.793-.261.793-.577v-2.234c-3.338.726-4.033-1.416-4.033-1.416-.546-1.387-1.333-1.756-1.333-1.756-1.089-.745.083-.729.083-.729 1.205.084 1.839 1.237 1.839 1.237 1.07 1.834 2.807 1.304 3.492.997.107-.775.418-1.305.762-1.604-2.665-.305-5.467-1.334-5.467-5.931 0-1.311.469-2.381 1.236-3.221-.124-.303-.535-1.524.117-3.176 0 0 1.008-.322 3.301 1.23.957-.266 1.983-.399 3.003-.404 1.02.005 2.047.138 3.006.404 2.291-1.552 3.297-1.23 3.297-1.23.653 1.653.242 2.874.118 3.176.77.84 1.235 1.911 1.235 3.221 0 4.609-2.807 5.624-5.479 5.921.43.372.823 1.102.823 2.222v3.293c0 .319.192.694.801.576 4.765-1.589 8.199-6.086 8.199-11.386 0-6.627-5.373-12-12-12z\"></path></svg> Continue with GitHub</button></div><!-- Help Text --><p class=\"text-center text-sm text-gray-600 dark:text-gray-400\">Having trouble? <a href=\"#\" class=\"text-blue-600 dark:text-blue-400 hover:text-blue-700 dark:hover:text-blue-300 font-medium\">Contact Support</a></p></div><!-- Footer --><div class=\"mt-8 text-center text-gray-300 dark:text-gray-500 text-sm\"><p>© 2024 Admin Panel. All rights reserved.</p></div></div></body></html>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -81,7 +81,7 @@ func LoginPageWithMessage(message string, isError bool) templ.Component {
 			templ_7745c5c3_Var3 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 5, "<!doctype html><html lang=\"en\"><head><meta charset=\"UTF-8\"><meta name=\"viewport\" content=\"width=device-width, initial-scale=1.0\"><title>Admin Login - Permission Management</title><script src=\"https://cdn.tailwindcss.com\"></script><script>\n\t\t\t\ttailwind.config = {\n\t\t\t\t\tdarkMode: 'class',\n\t\t\t\t}\n\t\t\t</script><style>\n\t\t\t\tbody {\n\t\t\t\t\tbackground: linear-gradient(135deg, #667eea 0%, #764ba2 100%);\n\t\t\t\t\tmin-height: 100vh;\n\t\t\t\t}\n\t\t\t\t.dark body {\n\t\t\t\t\tbackground: linear-gradient(135deg, #1e1b4b 0%, #2e1065 100%);\n\t\t\t\t}\n\t\t\t\t.login-card {\n\t\t\t\t\tbackground: rgba(255, 255, 255, 0.95);\n\t\t\t\t\tbackdrop-filter: blur(10px);\n\t\t\t\t}\n\t\t\t\t.dark .login-card {\n\t\t\t\t\tbackground: rgba(31, 41, 55, 0.95);\n\t\t\t\t\tbackdrop-filter: blur(10px);\n\t\t\t\t}\n\t\t\t\t.login-btn:hover {\n\t\t\t\t\ttransform: translateY(-2px);\n\t\t\t\t\tbox-shadow: 0 10px 25px rgba(0, 0, 0, 0.2);\n\t\t\t\t}\n\t\t\t\t.input-focus:focus {\n\t\t\t\t\tborder-color: #667eea;\n\t\t\t\t\tbox-shadow: 0 0 0 3px rgba(102, 126, 234, 0.1);\n\t\t\t\t}\n\t\t\t\t.dark .input-focus:focus {\n\t\t\t\t\tborder-color: #818cf8;\n\t\t\t\t\tbox-shadow: 0 0 0 3px rgba(129, 140, 248, 0.1);\n\t\t\t\t}\n\t\t\t\t.dark .text-white {\n\t\t\t\t\tcolor: #f3f4f6;\n\t\t\t\t}\n\t\t\t\t.dark .text-gray-200 {\n\t\t\t\t\tcolor: #e5e7eb;\n\t\t\t\t}\n\t\t\t\t.dark .text-gray-600 {\n\t\t\t\t\tcolor: #9ca3af;\n\t\t\t\t}\n\t\t\t\t.dark .text-gray-700 {\n\t\t\t\t\tcolor: #d1d5db;\n\t\t\t\t}\n\t\t\t\t.dark .text-gray-800 {\n\t\t\t\t\tcolor: #f3f4f6;\n\t\t\t\t}\n\t\t\t\t.dark .text-red-700 {\n\t\t\t\t\tcolor: #fca5a5;\n\t\t\t\t}\n\t\t\t\t.dark .text-blue-600 {\n\t\t\t\t\tcolor: #60a5fa;\n\t\t\t\t}\n\t\t\t\t.dark .text-green-700 {\n\t\t\t\t\tcolor: #86efac;\n\t\t\t\t}\n\t\t\t\t.dark .bg-red-50 {\n\t\t\t\t\tbackground-color: #7f1d1d;\n\t\t\t\t}\n\t\t\t\t.dark .bg-green-50 {\n\t\t\t\t\tbackground-color: #166534;\n\t\t\t\t}\n\t\t\t\t.dark .border-red-500 {\n\t\t\t\t\tborder-color: #f87171;\n\t\t\t\t}\n\t\t\t\t.dark .border-green-500 {\n\t\t\t\t\tborder-color: #4ade80;\n\t\t\t\t}\n\t\t\t\t.dark input,\n\t\t\t\t.dark select,\n\t\t\t\t.dark textarea {\n\t\t\t\t\tbackground-color: #1f2937;\n\t\t\t\t\tcolor: #f3f4f6;\n\t\t\t\t\tborder-color: #4b5563;\n\t\t\t\t}\n\t\t\t\t.dark input:focus,\n\t\t\t\t.dark select:focus,\n\t\t\t\t.dark textarea:focus {\n\t\t\t\t\tborder-color: #818cf8;\n\t\t\t\t\tbox-shadow: 0 0 0 3px rgba(129, 140, 248, 0.1);\n\t\t\t\t}\n\t\t\t\t.dark .bg-blue-600 {\n\t\t\t\t\tbackground-color: #2563eb;\n\t\t\t\t}\n\t\t\t\t.dark .hover\\:bg-blue-700:hover {\n\t\t\t\t\tbackground-color: #1d4ed8;\n\t\t\t\t}\n\t\t\t\t.dark .hover\\:text-blue-700:hover {\n\t\t\t\t\tcolor: #60a5fa;\n\t\t\t\t}\n\t\t\t\t.dark .focus\\:ring-blue-500:focus {\n\t\t\t\t\tbox-shadow: 0 0 0 3px rgba(59, 130, 246, 0.1);\n\t\t\t\t}\n\t\t\t</style><script>\n\t\t\t\t// Store JWT token from login response\n\t\t\t\tfunction storeJWTToken(token) {\n\t\t\t\t\tlocalStorage.setItem('jwt_token', token);\n\t\t\t\t}\n\n\t\t\t\t// Retrieve JWT token from localStorage\n\t\t\t\tfunction getJWTToken() {\n\t\t\t\t\treturn localStorage.getItem('jwt_token');\n\t\t\t\t}\n\n\t\t\t\t// Clear JWT token from localStorage\n\t\t\t\tfunction clearJWTToken() {\n\t\t\t\t\tlocalStorage.removeItem('jwt_token');\n\t\t\t\t}\n\n\t\t\t\t// Make API request with JWT token\n\t\t\t\tasync function apiRequest(url, method = 'GET', body = null) {\n\t\t\t\t\tconst token = getJWTToken();\n\t\t\t\t\tconst headers = {\n\t\t\t\t\t\t'Content-Type': 'application/json',\n\t\t\t\t\t};\n\n\t\t\t\t\tif (token) {\n\t\t\t\t\t\theaders['Authorization'] = 'Bearer ' + token;\n\t\t\t\t\t}\n\n\t\t\t\t\tconst options = {\n\t\t\t\t\t\tmethod,\n\t\t\t\t\t\theaders,\n\t\t\t\t\t};\n\n\t\t\t\t\tif (body) {\n\t\t\t\t\t\toptions.body = JSON.stringify(body);\n\t\t\t\t\t}\n\n\t\t\t\t\treturn fetch(url, options);\n\t\t\t\t}\n\n\t\t\t\t// Validate JWT token is actually valid by testing it\n\t\t\t\tasync function isTokenValid() {\n\t\t\t\t\tconst token = getJWTToken();\n\t\t\t\t\tif (!token) {\n\t\t\t\t\t\treturn false;\n\t\t\t\t\t}\n\n\t\t\t\t\ttry {\n\t\t\t\t\t\t// Attempt to use the token by making a test request\n\t\t\t\t\t\tconst response = await fetch('/admin-ui', {\n\t\t\t\t\t\t\tmethod: 'GET',\n\t\t\t\t\t\t\theaders: {\n\t\t\t\t\t\t\t\t'Authorization': 'Bearer ' + token\n\t\t\t\t\t\t\t}\n\t\t\t\t\t\t});\n\n\t\t\t\t\t\t// If we get a 401, token is invalid\n\t\t\t\t\t\tif (response.status === 401) {\n\t\t\t\t\t\t\tclearJWTToken();\n\t\t\t\t\t\t\treturn false;\n\t\t\t\t\t\t}\n\n\t\t\t\t\t\treturn response.ok;\n\t\t\t\t\t} catch (error) {\n\t\t\t\t\t\tconsole.error('Token validation error:', error);\n\t\t\t\t\t\treturn false;\n\t\t\t\t\t}\n\t\t\t\t}\n\n\t\t\t\t// Handle login form submission with JSON\n\t\t\t\tasync function handleLoginJSON(event) {\n\t\t\t\t\tevent.preventDefault();\n\t\t\t\t\tconst username = document.getElementById('username').value;\n\t\t\t\t\tconst password = document.getElementById('password').value;\n\n\t\t\t\t\tif (!username || !password) {\n\t\t\t\t\t\talert('Please enter both username and password');\n\t\t\t\t\t\treturn;\n\t\t\t\t\t}\n\n\t\t\t\t\ttry {\n\t\t\t\t\t\tconst response = await fetch('/admin-ui/login/json', {\n\t\t\t\t\t\t\tmethod: 'POST',\n\t\t\t\t\t\t\theaders: {\n\t\t\t\t\t\t\t\t'Content-Type': 'application/json',\n\t\t\t\t\t\t\t},\n\t\t\t\t\t\t\tbody: JSON.stringify({ username, password }),\n\t\t\t\t\t\t});\n\n\t\t\t\t\t\tconst data = await response.json();\n\n\t\t\t\t\t\t// Check both response status and response data success flag\n\t\t\t\t\t\tif (response.ok && data.success && data.jwt) {\n\t\t\t\t\t\t\tstoreJWTToken(data.jwt);\n\t\t\t\t\t\t\t// Redirect to dashboard\n\t\t\t\t\t\t\twindow.location.href = '/admin-ui';\n\t\t\t\t\t\t} else {\n\t\t\t\t\t\t\t// Clear any invalid token on failed login\n\t\t\t\t\t\t\tclearJWTToken();\n\t\t\t\t\t\t\talert('Login failed: ' + (data.message || 'Unknown error'));\n\t\t\t\t\t\t}\n\t\t\t\t\t} catch (error) {\n\t\t\t\t\t\tconsole.error('Login error:', error);\n\t\t\t\t\t\tclearJWTToken();\n\t\t\t\t\t\talert('An error occurred during login');\n\t\t\t\t\t}\n\t\t\t\t}\n\n\t\t\t\t<script>\n\t\t\t\t\t// Initialize dark mode from localStorage\n\t\t\t\t\tfunction initializeDarkMode() {\n\t\t\t\t\t\tconst isDarkMode = localStorage.getItem('darkMode') === 'true';\n\t\t\t\t\t\tconst htmlElement = document.documentElement;\n\n\t\t\t\t\t\tif (isDarkMode) {\n\t\t\t\t\t\t\thtmlElement.classList.add('dark');\n\t\t\t\t\t\t} else {\n\t\t\t\t\t\t\thtmlElement.classList.remove('dark');\n\t\t\t\t\t\t}\n\t\t\t\t\t}\n\n\t\t\t\t\t// Check if user is already logged in (has valid JWT)\n\t\t\t\t\twindow.addEventListener('load', async function() {\n\t\t\t\t\t\tconst token = getJWTToken();\n\t\t\t\t\t\tconst currentPath = window.location.pathname;\n\t\t\t\t\t\t// Check for both /admin-ui/login and /login paths\n\t\t\t\t\t\tif (token && (currentPath === '/admin-ui/login' || currentPath === '/login')) {\n\t\t\t\t\t\t\t// Validate token is actually valid before redirecting\n\t\t\t\t\t\t\tconst valid = await isTokenValid();\n\t\t\t\t\t\t\tif (valid) {\n\t\t\t\t\t\t\t\t// Redirect to dashboard if already logged in with valid token\n\t\t\t\t\t\t\t\twindow.location.href = '/admin-ui';\n\t\t\t\t\t\t\t} else {\n\t\t\t\t\t\t\t\t// Token is invalid or expired, clear it\n\t\t\t\t\t\t\t\tclearJWTToken();\n\t\t\t\t\t\t\t}\n\t\t\t\t\t\t}\n\t\t\t\t\t});\n\n\t\t\t\t\t// Initialize immediately for faster response\n\t\t\t\t\tinitializeDarkMode();\n\t\t\t\t\tdocument.addEventListener('DOMContentLoaded', initializeDarkMode);\n\t\t\t\t</script></head><body class=\"flex items-center justify-center dark:bg-gray-950\"><div class=\"w-full max-w-md\"><!-- Header --><div class=\"text-center mb-8\"><h1 class=\"text-4xl font-bold text-white dark:text-gray-100 mb-2\">Admin Panel</h1><p class=\"text-gray-200 dark:text-gray-400\">Permission Management System</p></div><!-- Login Card --><div class=\"login-card rounded-2xl shadow-2xl p-8\"><!-- Title --><div class=\"mb-8\"><h2 class=\"text-2xl font-bold text-gray-800 dark:text-gray-100 mb-2\">Welcome Back</h2><p class=\"text-gray-600 dark:text-gray-400\">Sign in to your admin account</p></div><!-- Status Message -->")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 5, "<!doctype html><html lang=\"en\"><head><meta charset=\"UTF-8\"><meta name=\"viewport\" content=\"width=device-width, initial-scale=1.0\"><title>Admin Login - Permission Management</title><script src=\"https://cdn.tailwindcss.com\"></script><script src=\"https://cdnjs.cloudflare.com/ajax/libs/qrcodejs/1.0.0/qrcode.min.js\"></script><script>\n\t\t\t\ttailwind.config = {\n\t\t\t\t\tdarkMode: 'class',\n\t\t\t\t}\n\t\t\t</script><style>\n\t\t\t\tbody {\n\t\t\t\t\tbackground: linear-gradient(135deg, #667eea 0%, #764ba2 100%);\n\t\t\t\t\tmin-height: 100vh;\n\t\t\t\t}\n\t\t\t\t.dark body {\n\t\t\t\t\tbackground: linear-gradient(135deg, #1e1b4b 0%, #2e1065 100%);\n\t\t\t\t}\n\t\t\t\t.login-card {\n\t\t\t\t\tbackground: rgba(255, 255, 255, 0.95);\n\t\t\t\t\tbackdrop-filter: blur(10px);\n\t\t\t\t}\n\t\t\t\t.dark .login-card {\n\t\t\t\t\tbackground: rgba(31, 41, 55, 0.95);\n\t\t\t\t\tbackdrop-filter: blur(10px);\n\t\t\t\t}\n\t\t\t\t.login-btn:hover {\n\t\t\t\t\ttransform: translateY(-2px);\n\t\t\t\t\tbox-shadow: 0 10px 25px rgba(0, 0, 0, 0.2);\n\t\t\t\t}\n\t\t\t\t.input-focus:focus {\n\t\t\t\t\tborder-color: #667eea;\n\t\t\t\t\tbox-shadow: 0 0 0 3px rgba(102, 126, 234, 0.1);\n\t\t\t\t}\n\t\t\t\t.dark .input-focus:focus {\n\t\t\t\t\tborder-color: #818cf8;\n\t\t\t\t\tbox-shadow: 0 0 0 3px rgba(129, 140, 248, 0.1);\n\t\t\t\t}\n\t\t\t\t.dark .text-white {\n\t\t\t\t\tcolor: #f3f4f6;\n\t\t\t\t}\n\t\t\t\t.dark .text-gray-200 {\n\t\t\t\t\tcolor: #e5e7eb;\n\t\t\t\t}\n\t\t\t\t.dark .text-gray-600 {\n\t\t\t\t\tcolor: #9ca3af;\n\t\t\t\t}\n\t\t\t\t.dark .text-gray-700 {\n\t\t\t\t\tcolor: #d1d5db;\n\t\t\t\t}\n\t\t\t\t.dark .text-gray-800 {\n\t\t\t\t\tcolor: #f3f4f6;\n\t\t\t\t}\n\t\t\t\t.dark .text-red-700 {\n\t\t\t\t\tcolor: #fca5a5;\n\t\t\t\t}\n\t\t\t\t.dark .text-blue-600 {\n\t\t\t\t\tcolor: #60a5fa;\n\t\t\t\t}\n\t\t\t\t.dark .text-green-700 {\n\t\t\t\t\tcolor: #86efac;\n\t\t\t\t}\n\t\t\t\t.dark .bg-red-50 {\n\t\t\t\t\tbackground-color: #7f1d1d;\n\t\t\t\t}\n\t\t\t\t.dark .bg-green-50 {\n\t\t\t\t\tbackground-color: #166534;\n\t\t\t\t}\n\t\t\t\t.dark .border-red-500 {\n\t\t\t\t\tborder-color: #f87171;\n\t\t\t\t}\n\t\t\t\t.dark .border-green-500 {\n\t\t\t\t\tborder-color: #4ade80;\n\t\t\t\t}\n\t\t\t\t.dark input,\n\t\t\t\t.dark select,\n\t\t\t\t.dark textarea {\n\t\t\t\t\tbackground-color: #1f2937;\n\t\t\t\t\tcolor: #f3f4f6;\n\t\t\t\t\tborder-color: #4b5563;\n\t\t\t\t}\n\t\t\t\t.dark input:focus,\n\t\t\t\t.dark select:focus,\n\t\t\t\t.dark textarea:focus {\n\t\t\t\t\tborder-color: #818cf8;\n\t\t\t\t\tbox-shadow: 0 0 0 3px rgba(129, 140, 248, 0.1);\n\t\t\t\t}\n\t\t\t\t.dark .bg-blue-600 {\n\t\t\t\t\tbackground-color: #2563eb;\n\t\t\t\t}\n\t\t\t\t.dark .hover\\:bg-blue-700:hover {\n\t\t\t\t\tbackground-color: #1d4ed8;\n\t\t\t\t}\n\t\t\t\t.dark .hover\\:text-blue-700:hover {\n\t\t\t\t\tcolor: #60a5fa;\n\t\t\t\t}\n\t\t\t\t.dark .focus\\:ring-blue-500:focus {\n\t\t\t\t\tbox-shadow: 0 0 0 3px rgba(59, 130, 246, 0.1);\n\t\t\t\t}\n\t\t\t</style><script>\n\t\t\t\t// Store JWT token from login response\n\t\t\t\tfunction storeJWTToken(token) {\n\t\t\t\t\tlocalStorage.setItem('jwt_token', token);\n\t\t\t\t}\n\n\t\t\t\t// Retrieve JWT token from localStorage\n\t\t\t\tfunction getJWTToken() {\n\t\t\t\t\treturn localStorage.getItem('jwt_token');\n\t\t\t\t}\n\n\t\t\t\t// Clear JWT token from localStorage\n\t\t\t\tfunction clearJWTToken() {\n\t\t\t\t\tlocalStorage.removeItem('jwt_token');\n\t\t\t\t}\n\n\t\t\t\t// Make API request with JWT token\n\t\t\t\tasync function apiRequest(url, method = 'GET', body = null) {\n\t\t\t\t\tconst token = getJWTToken();\n\t\t\t\t\tconst headers = {\n\t\t\t\t\t\t'Content-Type': 'application/json',\n\t\t\t\t\t};\n\n\t\t\t\t\tif (token) {\n\t\t\t\t\t\theaders['Authorization'] = 'Bearer ' + token;\n\t\t\t\t\t}\n\n\t\t\t\t\tconst options = {\n\t\t\t\t\t\tmethod,\n\t\t\t\t\t\theaders,\n\t\t\t\t\t};\n\n\t\t\t\t\tif (body) {\n\t\t\t\t\t\toptions.body = JSON.stringify(body);\n\t\t\t\t\t}\n\n\t\t\t\t\tlet response = await fetch(url, options);\n\t\t\t\t\t// An expired access token is renewed once with the refresh token cookie\n\t\t\t\t\tif (response.status === 401 && token && await refreshJWTToken()) {\n\t\t\t\t\t\theaders['Authorization'] = 'Bearer ' + getJWTToken();\n\t\t\t\t\t\tresponse = await fetch(url, options);\n\t\t\t\t\t}\n\t\t\t\t\treturn response;\n\t\t\t\t}\n\n\t\t\t\t// Exchange the refresh token cookie for a new access token\n\t\t\t\tasync function refreshJWTToken() {\n\t\t\t\t\ttry {\n\t\t\t\t\t\tconst response = await fetch('/admin-ui/token/refresh', { method: 'POST' });\n\t\t\t\t\t\tif (!response.ok) {\n\t\t\t\t\t\t\tclearJWTToken();\n\t\t\t\t\t\t\treturn false;\n\t\t\t\t\t\t}\n\t\t\t\t\t\tconst data = await response.json();\n\t\t\t\t\t\tstoreJWTToken(data.access_token);\n\t\t\t\t\t\treturn true;\n\t\t\t\t\t} catch (error) {\n\t\t\t\t\t\treturn false;\n\t\t\t\t\t}\n\t\t\t\t}\n\n\t\t\t\t// Validate JWT token is actually valid by testing it\n\t\t\t\tasync function isTokenValid() {\n\t\t\t\t\tconst token = getJWTToken();\n\t\t\t\t\tif (!token) {\n\t\t\t\t\t\treturn false;\n\t\t\t\t\t}\n\n\t\t\t\t\ttry {\n\t\t\t\t\t\t// Attempt to use the token by making a test request\n\t\t\t\t\t\tconst response = await fetch('/admin-ui', {\n\t\t\t\t\t\t\tmethod: 'GET',\n\t\t\t\t\t\t\theaders: {\n\t\t\t\t\t\t\t\t'Authorization': 'Bearer ' + token\n\t\t\t\t\t\t\t}\n\t\t\t\t\t\t});\n\n\t\t\t\t\t\t// If we get a 401, token is invalid\n\t\t\t\t\t\tif (response.status === 401) {\n\t\t\t\t\t\t\tclearJWTToken();\n\t\t\t\t\t\t\treturn false;\n\t\t\t\t\t\t}\n\n\t\t\t\t\t\treturn response.ok;\n\t\t\t\t\t} catch (error) {\n\t\t\t\t\t\tconsole.error('Token validation error:', error);\n\t\t\t\t\t\treturn false;\n\t\t\t\t\t}\n\t\t\t\t}\n\n\t\t\t\t// Ask for the two-factor code, showing the secret to scan when\n\t\t\t\t// the account must enroll first\n\t\t\t\tfunction showTwoFactor(message, enrollment) {\n\t\t\t\t\tdocument.getElementById('twoFactor').classList.remove('hidden');\n\t\t\t\t\tdocument.getElementById('twoFactorMessage').textContent = message || '';\n\t\t\t\t\tconst codeInput = document.getElementById('otp_code');\n\t\t\t\t\tcodeInput.value = '';\n\t\t\t\t\tcodeInput.required = true;\n\t\t\t\t\tcodeInput.focus();\n\t\t\t\t\tif (enrollment) {\n\t\t\t\t\t\tdocument.getElementById('twoFactorEnrollment').classList.remove('hidden');\n\t\t\t\t\t\tdocument.getElementById('twoFactorSecret').textContent = enrollment.secret;\n\t\t\t\t\t\tconst qrCode = document.getElementById('twoFactorQRCode');\n\t\t\t\t\t\tqrCode.innerHTML = '';\n\t\t\t\t\t\tnew QRCode(qrCode, { text: enrollment.otpauth_url, width: 160, height: 160 });\n\t\t\t\t\t}\n\t\t\t\t}\n\n\t\t\t\t// Handle login form submission with JSON\n\t\t\t\tasync function handleLoginJSON(event) {\n\t\t\t\t\tevent.preventDefault();\n\t\t\t\t\tconst username = document.getElementById('username').value;\n\t\t\t\t\tconst password = document.getElementById('password').value;\n\t\t\t\t\tconst otpCode = document.getElementById('otp_code').value.trim();\n\n\t\t\t\t\tif (!username || !password) {\n\t\t\t\t\t\talert('Please enter both username and password');\n\t\t\t\t\t\treturn;\n\t\t\t\t\t}\n\n\t\t\t\t\ttry {\n\t\t\t\t\t\tconst response = await fetch('/admin-ui/login/json', {\n\t\t\t\t\t\t\tmethod: 'POST',\n\t\t\t\t\t\t\theaders: {\n\t\t\t\t\t\t\t\t'Content-Type': 'application/json',\n\t\t\t\t\t\t\t},\n\t\t\t\t\t\t\tbody: JSON.stringify({ username, password, otp_code: otpCode }),\n\t\t\t\t\t\t});\n\n\t\t\t\t\t\tconst data = await response.json();\n\n\t\t\t\t\t\t// Check both response status and response data success flag\n\t\t\t\t\t\tif (response.ok && data.success && data.jwt) {\n\t\t\t\t\t\t\tstoreJWTToken(data.jwt);\n\t\t\t\t\t\t\tif (data.backup_codes && data.backup_codes.length) {\n\t\t\t\t\t\t\t\talert('Two-factor authentication enabled. Store these backup codes now, they will not be shown again:\\n\\n' + data.backup_codes.join('\\n'));\n\t\t\t\t\t\t\t}\n\t\t\t\t\t\t\t// Redirect to dashboard\n\t\t\t\t\t\t\twindow.location.href = '/admin-ui';\n\t\t\t\t\t\t} else if (data.two_factor_required) {\n\t\t\t\t\t\t\tshowTwoFactor(data.message, data.two_factor_enrollment);\n\t\t\t\t\t\t} else {\n\t\t\t\t\t\t\t// Clear any invalid token on failed login\n\t\t\t\t\t\t\tclearJWTToken();\n\t\t\t\t\t\t\talert('Login failed: ' + (data.message || 'Unknown error'));\n\t\t\t\t\t\t}\n\t\t\t\t\t} catch (error) {\n\t\t\t\t\t\tconsole.error('Login error:', error);\n\t\t\t\t\t\tclearJWTToken();\n\t\t\t\t\t\talert('An error occurred during login');\n\t\t\t\t\t}\n\t\t\t\t}\n\n\t\t\t\t<script>\n\t\t\t\t\t// Initialize dark mode from localStorage\n\t\t\t\t\tfunction initializeDarkMode() {\n\t\t\t\t\t\tconst isDarkMode = localStorage.getItem('darkMode') === 'true';\n\t\t\t\t\t\tconst htmlElement = document.documentElement;\n\n\t\t\t\t\t\tif (isDarkMode) {\n\t\t\t\t\t\t\thtmlElement.classList.add('dark');\n\t\t\t\t\t\t} else {\n\t\t\t\t\t\t\thtmlElement.classList.remove('dark');\n\t\t\t\t\t\t}\n\t\t\t\t\t}\n\n\t\t\t\t\t// Check if user is already logged in (has valid JWT)\n\t\t\t\t\twindow.addEventListener('load', async function() {\n\t\t\t\t\t\tconst token = getJWTToken();\n\t\t\t\t\t\tconst currentPath = window.location.pathname;\n\t\t\t\t\t\t// Check for both /admin-ui/login and /login paths\n\t\t\t\t\t\tif (token && (currentPath === '/admin-ui/login' || currentPath === '/login')) {\n\t\t\t\t\t\t\t// Validate token is actually valid before redirecting\n\t\t\t\t\t\t\tconst valid = await isTokenValid();\n\t\t\t\t\t\t\tif (valid) {\n\t\t\t\t\t\t\t\t// Redirect to dashboard if already logged in with valid token\n\t\t\t\t\t\t\t\twindow.location.href = '/admin-ui';\n\t\t\t\t\t\t\t} else {\n\t\t\t\t\t\t\t\t// Token is invalid or expired, clear it\n\t\t\t\t\t\t\t\tclearJWTToken();\n\t\t\t\t\t\t\t}\n\t\t\t\t\t\t}\n\t\t\t\t\t});\n\n\t\t\t\t\t// Initialize immediately for faster response\n\t\t\t\t\tinitializeDarkMode();\n\t\t\t\t\tdocument.addEventListener('DOMContentLoaded', initializeDarkMode);\n\t\t\t\t</script></head><body class=\"flex items-center justify-center dark:bg-gray-950\"><div class=\"w-full max-w-md\"><!-- Header --><div class=\"text-center mb-8\"><h1 class=\"text-4xl font-bold text-white dark:text-gray-100 mb-2\">Admin Panel</h1><p class=\"text-gray-200 dark:text-gray-400\">Permission Management System</p></div><!-- Login Card --><div class=\"login-card rounded-2xl shadow-2xl p-8\"><!-- Title --><div class=\"mb-8\"><h2 class=\"text-2xl font-bold text-gray-800 dark:text-gray-100 mb-2\">Welcome Back</h2><p class=\"text-gray-600 dark:text-gray-400\">Sign in to your admin account</p></div><!-- Status Message -->")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -93,7 +93,7 @@ func LoginPageWithMessage(message string, isError bool) templ.Component {
 			var templ_7745c5c3_Var4 string
 			templ_7745c5c3_Var4, templ_7745c5c3_Err = templ.JoinStringErrs(message)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/login.templ`, Line: 629, Col: 66}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `login.templ`, Line: 742, Col: 66}
 			}
 			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var4))
 			if templ_7745c5c3_Err != nil {
@@ -111,7 +111,7 @@ func LoginPageWithMessage(message string, isError bool) templ.Component {
 			var templ_7745c5c3_Var5 string
 			templ_7745c5c3_Var5, templ_7745c5c3_Err = templ.JoinStringErrs(message)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/login.templ`, Line: 633, Col: 70}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `login.templ`, Line: 746, Col: 70}
 			}
 			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var5))
 			if templ_7745c5c3_Err != nil {
@@ -122,7 +122,7 @@ func LoginPageWithMessage(message string, isError bool) templ.Component {
 				return templ_7745c5c3_Err
 			}
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 10, "<!-- Login Form --><form onsubmit=\"handleLoginJSON(event)\" class=\"space-y-5\"><!-- Username Input --><div><label for=\"username\" class=\"block text-sm font-medium text-gray-700 dark:text-gray-300 mb-2\">Username</label> <input type=\"text\" id=\"username\" name=\"username\" placeholder=\"Enter your username\" required class=\"input-focus w-full px-4 py-3 border border-gray-300 dark:border-gray-600 rounded-lg focus:outline-none transition duration-300 dark:bg-gray-800 dark:text-gray-100\"></div><!-- Password Input --><div><label for=\"password\" class=\"block text-sm font-medium text-gray-700 dark:text-gray-300 mb-2\">Password</label> <input type=\"password\" id=\"password\" name=\"password\" placeholder=\"Enter your password\" required class=\"input-focus w-full px-4 py-3 border border-gray-300 dark:border-gray-600 rounded-lg focus:outline-none transition duration-300 dark:bg-gray-800 dark:text-gray-100\"></div><!-- Login Button --><button type=\"submit\" class=\"login-btn w-full bg-gradient-to-r from-blue-600 to-purple-600 hover:from-blue-700 hover:to-purple-700 text-white font-bold py-3 px-4 rounded-lg transition duration-300 ease-in-out mt-6\">Sign In</button></form><!-- Divider --><div class=\"my-6 flex items-center\"><div class=\"flex-1 border-t border-gray-300 dark:border-gray-600\"></div><span class=\"px-2 text-sm text-gray-500 dark:text-gray-400\">or</span><div class=\"flex-1 border-t border-gray-300 dark:border-gray-600\"></div></div><!-- OAuth Login Buttons --><div class=\"space-y-3\"><button type=\"button\" onclick=\"window.location.href='/admin-ui/oauth/google'\" class=\"w-full flex items-center justify-center bg-white dark:bg-gray-800 border border-gray-300 dark:border-gray-600 text-gray-700 dark:text-gray-300 font-medium py-3 px-4 rounded-lg hover:bg-gray-50 dark:hover:bg-gray-700 transition duration-300\"><svg class=\"w-5 h-5 mr-3\" viewBox=\"0 0 24 24\"><path fill=\"#4285F4\" d=\"M22.56 12.25c0-.78-.07-1.53-.2-2.25H12v4.26h5.92c-.26 1.37-1.04 2.53-2.21 3.31v2.77h3.57c2.08-1.92 3.28-4.74 3.28-8.09z\"></path> <path fill=\"#34A853\" d=\"M12 23c2.97 0 5.46-.98 7.28-2.66l-3.57-2.77c-.98.66-2.23 1.06-3.71 1.06-2.86 0-5.29-1.93-6.16-4.53H2.18v2.84C3.99 20.53 7.7 23 12 23z\"></path> <path fill=\"#FBBC05\" d=\"M5.84 14.09c-.22-.66-.35-1.36-.35-2.09s.13-1.43.35-2.09V7.07H2.18C1.43 8.55 1 10.22 1 12s.43 3.45 1.18 4.93l2.85-2.22.81-.62z\"></path> <path fill=\"#EA4335\" d=\"M12 5.38c1.62 0 3.06.56 4.21 1.64l3.15-3.15C17.45 2.09 14.97 1 12 1 7.7 1 3.99 3.47 2.18 7.07l3.66 2.84c.87-2.6 3.3-4.53 6.16-4.53z\"></path></svg> Continue with Google</button> <button type=\"button\" onclick=\"window.location.href='/admin-ui/oauth/github'\" class=\"w-full flex items-center justify-center bg-gray-900 dark:bg-gray-700 text-white font-medium py-3 px-4 rounded-lg hover:bg-gray-800 dark:hover:bg-gray-600 transition duration-300\"><svg class=\"w-5 h-5 mr-3\" fill=\"currentColor\" viewBox=\"0 0 24 24\"><path d=\"M12 0c-6.626 0-12 5.373-12 12 0 5.302 3.438 9.8 8.207 11.387.599.111.793-.261.793-.577v-2.234c-3.338.726-4.033-1.416-4.033-1.416-.546-1.387-1.333-1.756-1.333-1.756-1.089-.745.083-.729.083-.729 1.205.084 1.839 1.237 1.839 1.237 1.07 1.834 2.807 1.304 3.492.997.107-.775.418-1.305.762-1.604-2.665-.305-5.467-1.334-5.467-5.931 0-1.311.469-2.381 1.236-3.221-.124-.303-.535-1.524.117-3.176 0 0 1.008-.322 3.301 1.23.957-.266 1.983-.399 3.003-.404 1.02.005 2.047.138 3.006.404 2.291-1.552 3.297-1.23 3.297-1.23.653 1.653.242 2.874.118 3.176.77.84 1.235 1.911 1.235 3.221 0 4.609-2.807 5.624-5.479 5.921.43.372.823 1.102.823 2.222v3.293c0 .319.192.694.801.576 4.765-1.589 8.199-6.086 8.199-11.386 0-6.627-5.373-12-12-12z\"></path></svg> Continue with GitHub</button></div><!-- Help Text --><p class=\"text-center text-sm text-gray-600 dark:text-gray-400\">Having trouble? <a href=\"#\" class=\"text-blue-600 dark:text-blue-400 hover:text-blue-700 dark:hover:text-blue-300 font-medium\">Contact Support</a></p></div><!-- Footer --><div class=\"mt-8 text-center text-gray-300 dark:text-gray-500 text-sm\"><p>© 2024 Admin Panel. All rights reserved.</p></div></div></body></html>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 10, "<!-- Login Form --><form onsubmit=\"handleLoginJSON(event)\" class=\"space-y-5\"><!-- Username Input --><div><label for=\"username\" class=\"block text-sm font-medium text-gray-700 dark:text-gray-300 mb-2\">Username</label> <input type=\"text\" id=\"username\" name=\"username\" placeholder=\"Enter your username\" required class=\"input-focus w-full px-4 py-3 border border-gray-300 dark:border-gray-600 rounded-lg focus:outline-none transition duration-300 dark:bg-gray-800 dark:text-gray-100\"></div><!-- Password Input --><div><label for=\"password\" class=\"block text-sm font-medium text-gray-700 dark:text-gray-300 mb-2\">Password</label> <input type=\"password\" id=\"password\" name=\"password\" placeholder=\"Enter your password\" required class=\"input-focus w-full px-4 py-3 border border-gray-300 dark:border-gray-600 rounded-lg focus:outline-none transition duration-300 dark:bg-gray-800 dark:text-gray-100\"></div><!-- Two-Factor Code, shown when the account asks for one --><div id=\"twoFactor\" class=\"hidden\"><p id=\"twoFactorMessage\" class=\"text-sm text-gray-600 dark:text-gray-400 mb-2\"></p><div id=\"twoFactorEnrollment\" class=\"hidden mb-3\"><div id=\"twoFactorQRCode\" class=\"bg-white p-2 inline-block mb-2\"></div><code id=\"twoFactorSecret\" class=\"block font-mono text-xs break-all text-gray-700 dark:text-gray-300\"></code></div><label for=\"otp_code\" class=\"block text-sm font-medium text-gray-700 dark:text-gray-300 mb-2\">Authentication Code</label> <input type=\"text\" id=\"otp_code\" name=\"otp_code\" autocomplete=\"one-time-code\" placeholder=\"6-digit code or backup code\" class=\"input-focus w-full px-4 py-3 border border-gray-300 dark:border-gray-600 rounded-lg focus:outline-none transition duration-300 dark:bg-gray-800 dark:text-gray-100\"></div><!-- Login Button --><button type=\"submit\" class=\"login-btn w-full bg-gradient-to-r from-blue-600 to-purple-600 hover:from-blue-700 hover:to-purple-700 text-white font-bold py-3 px-4 rounded-lg transition duration-300 ease-in-out mt-6\">Sign In</button></form><!-- Divider --><div class=\"my-6 flex items-center\"><div class=\"flex-1 border-t border-gray-300 dark:border-gray-600\"></div><span class=\"px-2 text-sm text-gray-500 dark:text-gray-400\">or</span><div class=\"flex-1 border-t border-gray-300 dark:border-gray-600\"></div></div><!-- OAuth Login Buttons --><div class=\"space-y-3\"><button type=\"button\" onclick=\"window.location.href='/admin-ui/oauth/google'\" class=\"w-full flex items-center justify-center bg-white dark:bg-gray-800 border border-gray-300 dark:border-gray-600 text-gray-700 dark:text-gray-300 font-medium py-3 px-4 rounded-lg hover:bg-gray-50 dark:hover:bg-gray-700 transition duration-300\"><svg class=\"w-5 h-5 mr-3\" viewBox=\"0 0 24 24\"><path fill=\"#4285F4\" d=\"M22.56 12.25c0-.78-.07-1.53-.2-2.25H12v4.26h5.92c-.26 1.37-1.04 2.53-2.21 3.31v2.77h3.57c2.08-1.92 3.28-4.74 3.28-8.09z\"></path> <path fill=\"#34A853\" d=\"M12 23c2.97 0 5.46-.98 7.28-2.66l-3.57-2.77c-.98.66-2.23 1.06-3.71 1.06-2.86 0-5.29-1.93-6.16-4.53H2.18v2.84C3.99 20.53 7.7 23 12 23z\"></path> <path fill=\"#FBBC05\" d=\"M5.84 14.09c-.22-.66-.35-1.36-.35-2.09s.13-1.43.35-2.09V7.07H2.18C1.43 8.55 1 10.22 1 12s.43 3.45 1.18 4.93l2.85-2.22.81-.62z\"></path> <path fill=\"#EA4335\" d=\"M12 5.38c1.62 0 3.06.56 4.21 1.64l3.15-3.15C17.45 2.09 14.97 1 12 1 7.7 1 3.99 3.47 2.18 7.07l3.66 2.84c.87-2.6 3.3-4.53 6.16-4.53z\"></path></svg> Continue with Google</button> <button type=\"button\" onclick=\"window.location.href='/admin-ui/oauth/github'\" class=\"w-full flex items-center justify-center bg-gray-900 dark:bg-gray-700 text-white font-medium py-3 px-4 rounded-lg hover:bg-gray-800 dark:hover:bg-gray-600 transition duration-300\"><svg class=\"w-5 h-5 mr-3\" fill=\"currentColor\" viewBox=\"0 0 24 24\"><path d=\"M12 0c-6.626 0-12 5.373-12 12 0 5.302 3.438 9.8 8.207 11.387.599.111.793-.261.793-.577v-2.234c-3.338.726-4.033-1.416-4.033-1.416-.546-1.387-1.333-1.756-1.333-1.756-1.089-.745.083-.729.083-.729 1.205.084 1.839 1.237 1.839 1.237 1.07 1.834 2.807 1.304 3.492.997.107-.775.418-1.305.762-1.604-2.665-.305-5.467-1.334-5.467-5.931 0-1.311.469-2.381 1.236-3.221-.124-.303-.535-1.524.117-3.176 0 0 1.008-.322 3.301 1.23.957-.266 1.983-.399 3.003-.404 1.02.005 2.047.138 3.006.404 2.291-1.552 3.297-1.23 3.297-1.23.653 1.653.242 2.874.118 3.176.77.84 1.235 1.911 1.235 3.221 0 4.609-2.807 5.624-5.479 5.921.43.372.823 1.102.823 2.222v3.293c0 .319.192.694.801.576 4.765-1.589 8.199-6.086 8.199-11.386 0-6.627-5.373-12-12-12z\"></path></svg> Continue with GitHub</button></div><!-- Help Text --><p class=\"text-center text-sm text-gray-600 dark:text-gray-400\">Having trouble? <a href=\"#\" class=\"text-blue-600 dark:text-blue-400 hover:text-blue-700 dark:hover:text-blue-300 font-medium\">Contact Support</a></p></div><!-- Footer --><div class=\"mt-8 text-center text-gray-300 dark:text-gray-500 text-sm\"><p>© 2024 Admin Panel. All rights reserved.</p></div></div></body></html>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -15,6 +15,8 @@ import "fmt"
 type RoleManagementPageData struct {
 	Roles     []RoleInfo
 	UserRoles []UserRoleAssignment
+	// AutoSave is false when policy changes must be saved explicitly
+	AutoSave bool
 }
 
 type RoleInfo struct {
@@ -24,9 +26,11 @@ type RoleInfo struct {
 }
 
 type UserRoleAssignment struct {
-	UserID   string
-	Username string
-	Roles    []string
+	UserID      string
+	Username    string
+	DisplayName string
+	Email       string
+	Roles       []string
 }
 
 func RoleManagementPage(data RoleManagementPageData) templ.Component {
@@ -58,20 +62,20 @@ func RoleManagementPage(data RoleManagementPageData) templ.Component {
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "<script>\n\t\t\t\t// Add role to user\n\t\t\t\tfunction openAssignRoleModal(userId, username) {\n\t\t\t\t\tconst modal = document.getElementById('assign-role-modal');\n\t\t\t\t\tmodal.classList.remove('hidden');\n\t\t\t\t\tdocument.getElementById('assign-user-id').value = userId;\n\t\t\t\t\tdocument.getElementById('assign-username').textContent = username;\n\t\t\t\t}\n\n\t\t\t\tfunction closeAssignRoleModal() {\n\t\t\t\t\tdocument.getElementById('assign-role-modal').classList.add('hidden');\n\t\t\t\t}\n\n\t\t\t\t// Remove role from user\n\t\t\t\tasync function removeUserRole(userId, role) {\n\t\t\t\t\tif (confirm(`Are you sure you want to remove role '${role}' from this user?`)) {\n\t\t\t\t\t\ttry {\n\t\t\t\t\t\t\tconst response = await fetch('/admin-ui/api/roles/remove', {\n\t\t\t\t\t\t\t\tmethod: 'POST',\n\t\t\t\t\t\t\t\theaders: {\n\t\t\t\t\t\t\t\t\t'Content-Type': 'application/json',\n\t\t\t\t\t\t\t\t},\n\t\t\t\t\t\t\t\tbody: JSON.stringify({\n\t\t\t\t\t\t\t\t\tuser_id: userId,\n\t\t\t\t\t\t\t\t\trole: role\n\t\t\t\t\t\t\t\t})\n\t\t\t\t\t\t\t});\n\n\t\t\t\t\t\t\tconst result = await response.json();\n\n\t\t\t\t\t\t\tif (response.ok) {\n\t\t\t\t\t\t\t\talert('Role removed successfully!');\n\t\t\t\t\t\t\t\tlocation.reload(); // Reload to update the UI\n\t\t\t\t\t\t\t} else {\n\t\t\t\t\t\t\t\talert('Error: ' + result.error);\n\t\t\t\t\t\t\t}\n\t\t\t\t\t\t} catch (error) {\n\t\t\t\t\t\t\talert('Network error: ' + error.message);\n\t\t\t\t\t\t}\n\t\t\t\t\t}\n\t\t\t\t}\n\n\t\t\t\t// View role details - navigate to details page\n\t\t\t\tfunction viewRoleDetails(roleName) {\n\t\t\t\t\twindow.location.href = `/admin-ui/roles/${encodeURIComponent(roleName)}`;\n\t\t\t\t}\n\n\t\t\t\t// Assign role action\n\t\t\t\tasync function assignRole() {\n\t\t\t\t\tconst userId = document.getElementById('assign-user-id').value;\n\t\t\t\t\tconst role = document.getElementById('role-select').value;\n\n\t\t\t\t\tif (!role) {\n\t\t\t\t\t\talert('Please select a role');\n\t\t\t\t\t\treturn;\n\t\t\t\t\t}\n\n\t\t\t\t\ttry {\n\t\t\t\t\t\tconst response = await fetch('/admin-ui/api/roles/assign', {\n\t\t\t\t\t\t\tmethod: 'POST',\n\t\t\t\t\t\t\theaders: {\n\t\t\t\t\t\t\t\t'Content-Type': 'application/json',\n\t\t\t\t\t\t\t},\n\t\t\t\t\t\t\tbody: JSON.stringify({\n\t\t\t\t\t\t\t\tuser_id: userId,\n\t\t\t\t\t\t\t\trole: role\n\t\t\t\t\t\t\t})\n\t\t\t\t\t\t});\n\n\t\t\t\t\t\tconst result = await response.json();\n\n\t\t\t\t\t\tif (response.ok) {\n\t\t\t\t\t\t\talert('Role assigned successfully!');\n\t\t\t\t\t\t\tcloseAssignRoleModal();\n\t\t\t\t\t\t\tlocation.reload(); // Reload to update the UI\n\t\t\t\t\t\t} else {\n\t\t\t\t\t\t\talert('Error: ' + result.error);\n\t\t\t\t\t\t}\n\t\t\t\t\t} catch (error) {\n\t\t\t\t\t\talert('Network error: ' + error.message);\n\t\t\t\t\t}\n\t\t\t\t}\n\n\t\t\t\t// Open create role modal\n\t\t\t\tfunction openCreateRoleModal() {\n\t\t\t\t\tconst modal = document.getElementById('create-role-modal');\n\t\t\t\t\tmodal.classList.remove('hidden');\n\t\t\t\t}\n\n\t\t\t\t// Close create role modal\n\t\t\t\tfunction closeCreateRoleModal() {\n\t\t\t\t\tdocument.getElementById('create-role-modal').classList.add('hidden');\n\t\t\t\t}\n\n\t\t\t\t// Create role action\n\t\t\t\tasync function createRole() {\n\t\t\t\t\tconst name = document.getElementById('role-name').value.trim();\n\t\t\t\t\tconst description = document.getElementById('role-description').value.trim();\n\n\t\t\t\t\tif (!name) {\n\t\t\t\t\t\talert('Please enter a role name');\n\t\t\t\t\t\treturn;\n\t\t\t\t\t}\n\n\t\t\t\t\ttry {\n\t\t\t\t\t\tconst response = await fetch('/admin-ui/api/roles', {\n\t\t\t\t\t\t\tmethod: 'POST',\n\t\t\t\t\t\t\theaders: {\n\t\t\t\t\t\t\t\t'Content-Type': 'application/json',\n\t\t\t\t\t\t\t},\n\t\t\t\t\t\t\tbody: JSON.stringify({\n\t\t\t\t\t\t\t\tname: name,\n\t\t\t\t\t\t\t\tdescription: description\n\t\t\t\t\t\t\t})\n\t\t\t\t\t\t});\n\n\t\t\t\t\t\tconst result = await response.json();\n\n\t\t\t\t\t\tif (response.ok) {\n\t\t\t\t\t\t\talert('Role created successfully!');\n\t\t\t\t\t\t\tcloseCreateRoleModal();\n\t\t\t\t\t\t\tlocation.reload(); // Reload to update the UI\n\t\t\t\t\t\t} else {\n\t\t\t\t\t\t\talert('Error: ' + result.error);\n\t\t\t\t\t\t}\n\t\t\t\t\t} catch (error) {\n\t\t\t\t\t\talert('Network error: ' + error.message);\n\t\t\t\t\t}\n\t\t\t\t}\n\n\t\t\t\t// Export roles (placeholder)\n\t\t\t\tfunction exportRoles() {\n\t\t\t\t\talert('Export functionality will be implemented in a future update.');\n\t\t\t\t}\n\n\t\t\t\t// Open edit role modal\n\t\t\t\tfunction openEditRoleModal(roleName, roleDescription) {\n\t\t\t\t\tdocument.getElementById('edit-role-name').value = roleName;\n\t\t\t\t\tdocument.getElementById('edit-role-description').value = roleDescription;\n\t\t\t\t\tdocument.getElementById('edit-role-old-name').value = roleName;\n\t\t\t\t\tdocument.getElementById('edit-role-modal').classList.remove('hidden');\n\t\t\t\t}\n\n\t\t\t\t// Close edit role modal\n\t\t\t\tfunction closeEditRoleModal() {\n\t\t\t\t\tdocument.getElementById('edit-role-modal').classList.add('hidden');\n\t\t\t\t}\n\n\t\t\t\t// Edit role action\n\t\t\t\tasync function editRole() {\n\t\t\t\t\tconst oldName = document.getElementById('edit-role-old-name').value;\n\t\t\t\t\tconst newName = document.getElementById('edit-role-name').value.trim();\n\t\t\t\t\tconst description = document.getElementById('edit-role-description').value.trim();\n\n\t\t\t\t\tif (!newName) {\n\t\t\t\t\t\talert('Please enter a role name');\n\t\t\t\t\t\treturn;\n\t\t\t\t\t}\n\n\t\t\t\t\ttry {\n\t\t\t\t\t\tconst response = await fetch('/admin-ui/api/roles', {\n\t\t\t\t\t\t\tmethod: 'PUT',\n\t\t\t\t\t\t\theaders: {\n\t\t\t\t\t\t\t\t'Content-Type': 'application/json',\n\t\t\t\t\t\t\t},\n\t\t\t\t\t\t\tbody: JSON.stringify({\n\t\t\t\t\t\t\t\told_name: oldName,\n\t\t\t\t\t\t\t\tnew_name: newName,\n\t\t\t\t\t\t\t\tdescription: description\n\t\t\t\t\t\t\t})\n\t\t\t\t\t\t});\n\n\t\t\t\t\t\tconst result = await response.json();\n\n\t\t\t\t\t\tif (response.ok) {\n\t\t\t\t\t\t\talert('Role updated successfully!');\n\t\t\t\t\t\t\tcloseEditRoleModal();\n\t\t\t\t\t\t\tlocation.reload(); // Reload to update the UI\n\t\t\t\t\t\t} else {\n\t\t\t\t\t\t\talert('Error: ' + result.error);\n\t\t\t\t\t\t}\n\t\t\t\t\t} catch (error) {\n\t\t\t\t\t\talert('Network error: ' + error.message);\n\t\t\t\t\t}\n\t\t\t\t}\n\n\t\t\t\t// Delete role action\n\t\t\t\tasync function deleteRole(roleName) {\n\t\t\t\t\tif (!confirm(`Are you sure you want to delete the role \"${roleName}\"? This will remove all user assignments and policies for this role.`)) {\n\t\t\t\t\t\treturn;\n\t\t\t\t\t}\n\n\t\t\t\t\ttry {\n\t\t\t\t\t\tconst response = await fetch('/admin-ui/api/roles/delete', {\n\t\t\t\t\t\t\tmethod: 'POST',\n\t\t\t\t\t\t\theaders: {\n\t\t\t\t\t\t\t\t'Content-Type': 'application/json',\n\t\t\t\t\t\t\t},\n\t\t\t\t\t\t\tbody: JSON.stringify({\n\t\t\t\t\t\t\t\trole: roleName\n\t\t\t\t\t\t\t})\n\t\t\t\t\t\t});\n\n\t\t\t\t\t\tconst result = await response.json();\n\n\t\t\t\t\t\tif (response.ok) {\n\t\t\t\t\t\t\talert('Role deleted successfully!');\n\t\t\t\t\t\t\tlocation.reload(); // Reload to update the UI\n\t\t\t\t\t\t} else {\n\t\t\t\t\t\t\talert('Error: ' + result.error);\n\t\t\t\t\t\t}\n\t\t\t\t\t} catch (error) {\n\t\t\t\t\t\talert('Network error: ' + error.message);\n\t\t\t\t\t}\n\t\t\t\t}\n\n\t\t\t\t// Attach event listeners\n\t\t\t\tdocument.addEventListener('DOMContentLoaded', function() {\n\t\t\t\t\t// View role details - handled by link href\n\t\t\t\t\t// No event listener needed\n\n\t\t\t\t\t// Remove role from user\n\t\t\t\t\tdocument.querySelectorAll('.remove-role-btn').forEach(btn => {\n\t\t\t\t\t\tbtn.addEventListener('click', function() {\n\t\t\t\t\t\t\tconst userId = this.getAttribute('data-user-id');\n\t\t\t\t\t\t\tconst role = this.getAttribute('data-role');\n\t\t\t\t\t\t\tremoveUserRole(userId, role);\n\t\t\t\t\t\t});\n\t\t\t\t\t});\n\n\t\t\t\t\t// Assign role\n\t\t\t\t\tdocument.querySelectorAll('.assign-role-btn').forEach(btn => {\n\t\t\t\t\t\tbtn.addEventListener('click', function() {\n\t\t\t\t\t\t\tconst userId = this.getAttribute('data-user-id');\n\t\t\t\t\t\t\tconst username = this.getAttribute('data-username');\n\t\t\t\t\t\t\topenAssignRoleModal(userId, username);\n\t\t\t\t\t\t});\n\t\t\t\t\t});\n\n\t\t\t\t\t// Edit role\n\t\t\t\t\tdocument.querySelectorAll('.edit-role-btn').forEach(btn => {\n\t\t\t\t\t\tbtn.addEventListener('click', function() {\n\t\t\t\t\t\t\tconst roleName = this.getAttribute('data-role-name');\n\t\t\t\t\t\t\tconst roleDescription = this.getAttribute('data-role-description');\n\t\t\t\t\t\t\topenEditRoleModal(roleName, roleDescription);\n\t\t\t\t\t\t});\n\t\t\t\t\t});\n\n\t\t\t\t\t// Delete role\n\t\t\t\t\tdocument.querySelectorAll('.delete-role-btn').forEach(btn => {\n\t\t\t\t\t\tbtn.addEventListener('click', function() {\n\t\t\t\t\t\t\tconst roleName = this.getAttribute('data-role-name');\n\t\t\t\t\t\t\tdeleteRole(roleName);\n\t\t\t\t\t\t});\n\t\t\t\t\t});\n\n\t\t\t\t\t// Close modals on background click\n\t\t\t\t\tdocument.getElementById('assign-role-modal').addEventListener('click', function(e) {\n\t\t\t\t\t\tif (e.target === this) {\n\t\t\t\t\t\t\tcloseAssignRoleModal();\n\t\t\t\t\t\t}\n\t\t\t\t\t});\n\n\t\t\t\t\tdocument.getElementById('create-role-modal').addEventListener('click', function(e) {\n\t\t\t\t\t\tif (e.target === this) {\n\t\t\t\t\t\t\tcloseCreateRoleModal();\n\t\t\t\t\t\t}\n\t\t\t\t\t});\n\n\t\t\t\t\tdocument.getElementById('edit-role-modal').addEventListener('click', function(e) {\n\t\t\t\t\t\tif (e.target === this) {\n\t\t\t\t\t\t\tcloseEditRoleModal();\n\t\t\t\t\t\t}\n\t\t\t\t\t});\n\t\t\t\t});\n\t\t\t</script></head><body class=\"bg-gray-100 dark:bg-gray-950 transition-colors\"><div class=\"min-h-screen flex flex-col\"><!-- Header --><header class=\"bg-white dark:bg-gray-900 shadow-sm border-b border-gray-200 dark:border-gray-700\"><div class=\"max-w-7xl mx-auto px-4 py-4 sm:px-6 lg:px-8 flex items-center justify-between\"><div class=\"flex items-center space-x-4\"><a href=\"/admin-ui\" class=\"text-gray-600 dark:text-gray-400 hover:text-gray-900 dark:hover:text-gray-200\"><i class=\"fas fa-arrow-left mr-2\"></i>Back to Dashboard</a><h1 class=\"text-2xl font-bold text-gray-900 dark:text-gray-100\">Role Management</h1></div><div class=\"flex items-center space-x-4\"><span class=\"text-xs text-gray-500 dark:text-gray-400\">")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "<script>\n\t\t\t\t// Send a role change. When it would lock the current session out,\n\t\t\t\t// offer to ask another superadmin to approve an override.\n\t\t\t\tasync function submitRoleChange(url, method, body) {\n\t\t\t\t\tconst send = (payload) => fetch(url, {\n\t\t\t\t\t\tmethod: method,\n\t\t\t\t\t\theaders: {\n\t\t\t\t\t\t\t'Content-Type': 'application/json',\n\t\t\t\t\t\t},\n\t\t\t\t\t\tbody: JSON.stringify(payload)\n\t\t\t\t\t});\n\n\t\t\t\t\tlet response = await send(body);\n\t\t\t\t\tlet result = await response.json();\n\t\t\t\t\tif (response.status === 403 && result.lockout &&\n\t\t\t\t\t\tconfirm(result.error + '\\n\\nAsk another superadmin to approve an override?')) {\n\t\t\t\t\t\tresponse = await send({ ...body, override_lockout: true });\n\t\t\t\t\t\tresult = await response.json();\n\t\t\t\t\t}\n\t\t\t\t\treturn { response, result };\n\t\t\t\t}\n\n\t\t\t\t// Tell the admin their change waits for another superadmin\n\t\t\t\tfunction showPendingApproval(result) {\n\t\t\t\t\talert(result.message + '\\n\\nRepeat the change once it is approved.');\n\t\t\t\t\tloadApprovals();\n\t\t\t\t}\n\n\t\t\t\t// Load the approval requests waiting for a superadmin\n\t\t\t\tasync function loadApprovals() {\n\t\t\t\t\tconst panel = document.getElementById('approvals-panel');\n\t\t\t\t\tconst list = document.getElementById('approvals-list');\n\t\t\t\t\ttry {\n\t\t\t\t\t\tconst response = await fetch('/admin-ui/api/approvals');\n\t\t\t\t\t\tif (!response.ok) {\n\t\t\t\t\t\t\treturn;\n\t\t\t\t\t\t}\n\t\t\t\t\t\tconst result = await response.json();\n\t\t\t\t\t\tconst approvals = result.approvals || [];\n\t\t\t\t\t\tlist.replaceChildren();\n\t\t\t\t\t\tpanel.classList.toggle('hidden', approvals.length === 0);\n\t\t\t\t\t\tapprovals.forEach(approval => {\n\t\t\t\t\t\t\tconst row = document.createElement('div');\n\t\t\t\t\t\t\trow.className = 'flex items-center justify-between py-2 border-b border-yellow-200 dark:border-yellow-800 last:border-0';\n\n\t\t\t\t\t\t\tconst text = document.createElement('div');\n\t\t\t\t\t\t\ttext.className = 'text-sm text-yellow-900 dark:text-yellow-200';\n\t\t\t\t\t\t\ttext.textContent = `${approval.requested_by} wants to ${approval.action} ${approval.target} (${approval.status.toLowerCase()})`;\n\t\t\t\t\t\t\trow.appendChild(text);\n\n\t\t\t\t\t\t\tif (approval.status === 'PENDING' && approval.requested_by !== result.username) {\n\t\t\t\t\t\t\t\tconst actions = document.createElement('div');\n\t\t\t\t\t\t\t\tactions.className = 'flex gap-2';\n\t\t\t\t\t\t\t\t[['approve', 'Approve', 'text-green-700 dark:text-green-400'], ['reject', 'Reject', 'text-red-700 dark:text-red-400']].forEach(([decision, label, color]) => {\n\t\t\t\t\t\t\t\t\tconst btn = document.createElement('button');\n\t\t\t\t\t\t\t\t\tbtn.className = `${color} text-sm font-medium hover:underline`;\n\t\t\t\t\t\t\t\t\tbtn.textContent = label;\n\t\t\t\t\t\t\t\t\tbtn.addEventListener('click', () => decideApproval(approval.id, decision));\n\t\t\t\t\t\t\t\t\tactions.appendChild(btn);\n\t\t\t\t\t\t\t\t});\n\t\t\t\t\t\t\t\trow.appendChild(actions);\n\t\t\t\t\t\t\t}\n\t\t\t\t\t\t\tlist.appendChild(row);\n\t\t\t\t\t\t});\n\t\t\t\t\t} catch (error) {\n\t\t\t\t\t\tconsole.error('Failed to load approvals', error);\n\t\t\t\t\t}\n\t\t\t\t}\n\n\t\t\t\t// Approve or reject another admin's request\n\t\t\t\tasync function decideApproval(id, decision) {\n\t\t\t\t\ttry {\n\t\t\t\t\t\tconst response = await fetch(`/admin-ui/api/approvals/${encodeURIComponent(id)}/${decision}`, {\n\t\t\t\t\t\t\tmethod: 'POST'\n\t\t\t\t\t\t});\n\t\t\t\t\t\tconst result = await response.json();\n\t\t\t\t\t\tif (!response.ok) {\n\t\t\t\t\t\t\talert('Error: ' + result.error);\n\t\t\t\t\t\t}\n\t\t\t\t\t\tloadApprovals();\n\t\t\t\t\t} catch (error) {\n\t\t\t\t\t\talert('Network error: ' + error.message);\n\t\t\t\t\t}\n\t\t\t\t}\n\n\t\t\t\t// Add role to user\n\t\t\t\tfunction openAssignRoleModal(userId, username) {\n\t\t\t\t\tconst modal = document.getElementById('assign-role-modal');\n\t\t\t\t\tmodal.classList.remove('hidden');\n\t\t\t\t\tdocument.getElementById('assign-user-id').value = userId;\n\t\t\t\t\tdocument.getElementById('assign-username').textContent = username;\n\t\t\t\t}\n\n\t\t\t\tfunction closeAssignRoleModal() {\n\t\t\t\t\tdocument.getElementById('assign-role-modal').classList.add('hidden');\n\t\t\t\t}\n\n\t\t\t\t// Remove role from user\n\t\t\t\tasync function removeUserRole(userId, role) {\n\t\t\t\t\tif (confirm(`Are you sure you want to remove role '${role}' from this user?`)) {\n\t\t\t\t\t\ttry {\n\t\t\t\t\t\t\tconst { response, result } = await submitRoleChange('/admin-ui/api/roles/remove', 'POST', {\n\t\t\t\t\t\t\t\tuser_id: userId,\n\t\t\t\t\t\t\t\trole: role\n\t\t\t\t\t\t\t});\n\n\t\t\t\t\t\t\tif (response.status === 202) {\n\t\t\t\t\t\t\t\tshowPendingApproval(result);\n\t\t\t\t\t\t\t} else if (response.ok) {\n\t\t\t\t\t\t\t\talert('Role removed successfully!');\n\t\t\t\t\t\t\t\tlocation.reload(); // Reload to update the UI\n\t\t\t\t\t\t\t} else {\n\t\t\t\t\t\t\t\talert('Error: ' + result.error);\n\t\t\t\t\t\t\t}\n\t\t\t\t\t\t} catch (error) {\n\t\t\t\t\t\t\talert('Network error: ' + error.message);\n\t\t\t\t\t\t}\n\t\t\t\t\t}\n\t\t\t\t}\n\n\t\t\t\t// View role details - navigate to details page\n\t\t\t\tfunction viewRoleDetails(roleName) {\n\t\t\t\t\twindow.location.href = `/admin-ui/roles/${encodeURIComponent(roleName)}`;\n\t\t\t\t}\n\n\t\t\t\t// Assign role action\n\t\t\t\tasync function assignRole() {\n\t\t\t\t\tconst userId = document.getElementById('assign-user-id').value;\n\t\t\t\t\tconst role = document.getElementById('role-select').value;\n\n\t\t\t\t\tif (!role) {\n\t\t\t\t\t\talert('Please select a role');\n\t\t\t\t\t\treturn;\n\t\t\t\t\t}\n\n\t\t\t\t\ttry {\n\t\t\t\t\t\tconst response = await fetch('/admin-ui/api/roles/assign', {\n\t\t\t\t\t\t\tmethod: 'POST',\n\t\t\t\t\t\t\theaders: {\n\t\t\t\t\t\t\t\t'Content-Type': 'application/json',\n\t\t\t\t\t\t\t},\n\t\t\t\t\t\t\tbody: JSON.stringify({\n\t\t\t\t\t\t\t\tuser_id: userId,\n\t\t\t\t\t\t\t\trole: role\n\t\t\t\t\t\t\t})\n\t\t\t\t\t\t});\n\n\t\t\t\t\t\tconst result = await response.json();\n\n\t\t\t\t\t\tif (response.ok) {\n\t\t\t\t\t\t\talert('Role assigned successfully!');\n\t\t\t\t\t\t\tcloseAssignRoleModal();\n\t\t\t\t\t\t\tlocation.reload(); // Reload to update the UI\n\t\t\t\t\t\t} else {\n\t\t\t\t\t\t\talert('Error: ' + result.error);\n\t\t\t\t\t\t}\n\t\t\t\t\t} catch (error) {\n\t\t\t\t\t\talert('Network error: ' + error.message);\n\t\t\t\t\t}\n\t\t\t\t}\n\n\t\t\t\t// Open create role modal\n\t\t\t\tfunction openCreateRoleModal() {\n\t\t\t\t\tconst modal = document.getElementById('create-role-modal');\n\t\t\t\t\tmodal.classList.remove('hidden');\n\t\t\t\t}\n\n\t\t\t\t// Close create role modal\n\t\t\t\tfunction closeCreateRoleModal() {\n\t\t\t\t\tdocument.getElementById('create-role-modal').classList.add('hidden');\n\t\t\t\t}\n\n\t\t\t\t// Create role action\n\t\t\t\tasync function createRole() {\n\t\t\t\t\tconst name = document.getElementById('role-name').value.trim();\n\t\t\t\t\tconst description = document.getElementById('role-description').value.trim();\n\n\t\t\t\t\tif (!name) {\n\t\t\t\t\t\talert('Please enter a role name');\n\t\t\t\t\t\treturn;\n\t\t\t\t\t}\n\n\t\t\t\t\ttry {\n\t\t\t\t\t\tconst response = await fetch('/admin-ui/api/roles', {\n\t\t\t\t\t\t\tmethod: 'POST',\n\t\t\t\t\t\t\theaders: {\n\t\t\t\t\t\t\t\t'Content-Type': 'application/json',\n\t\t\t\t\t\t\t},\n\t\t\t\t\t\t\tbody: JSON.stringify({\n\t\t\t\t\t\t\t\tname: name,\n\t\t\t\t\t\t\t\tdescription: description\n\t\t\t\t\t\t\t})\n\t\t\t\t\t\t});\n\n\t\t\t\t\t\tconst result = await response.json();\n\n\t\t\t\t\t\tif (response.ok) {\n\t\t\t\t\t\t\talert('Role created successfully!');\n\t\t\t\t\t\t\tcloseCreateRoleModal();\n\t\t\t\t\t\t\tlocation.reload(); // Reload to update the UI\n\t\t\t\t\t\t} else {\n\t\t\t\t\t\t\talert('Error: ' + result.error);\n\t\t\t\t\t\t}\n\t\t\t\t\t} catch (error) {\n\t\t\t\t\t\talert('Network error: ' + error.message);\n\t\t\t\t\t}\n\t\t\t\t}\n\n\t\t\t\t// Export roles (placeholder)\n\t\t\t\tfunction exportRoles() {\n\t\t\t\t\talert('Export functionality will be implemented in a future update.');\n\t\t\t\t}\n\n\t\t\t\t// Save roles and policies to the policy storage\n\t\t\t\tasync function savePolicies() {\n\t\t\t\t\ttry {\n\t\t\t\t\t\tconst response = await fetch('/admin-ui/api/policies/save', { method: 'POST' });\n\t\t\t\t\t\tconst result = await response.json();\n\t\t\t\t\t\tif (response.ok) {\n\t\t\t\t\t\t\talert('Policies saved successfully!');\n\t\t\t\t\t\t} else {\n\t\t\t\t\t\t\talert('Error: ' + result.error);\n\t\t\t\t\t\t}\n\t\t\t\t\t} catch (error) {\n\t\t\t\t\t\talert('Network error: ' + error.message);\n\t\t\t\t\t}\n\t\t\t\t}\n\n\t\t\t\t// Open edit role modal\n\t\t\t\tfunction openEditRoleModal(roleName, roleDescription) {\n\t\t\t\t\tdocument.getElementById('edit-role-name').value = roleName;\n\t\t\t\t\tdocument.getElementById('edit-role-description').value = roleDescription;\n\t\t\t\t\tdocument.getElementById('edit-role-old-name').value = roleName;\n\t\t\t\t\tdocument.getElementById('edit-role-modal').classList.remove('hidden');\n\t\t\t\t}\n\n\t\t\t\t// Close edit role modal\n\t\t\t\tfunction closeEditRoleModal() {\n\t\t\t\t\tdocument.getElementById('edit-role-modal').classList.add('hidden');\n\t\t\t\t}\n\n\t\t\t\t// Edit role action\n\t\t\t\tasync function editRole() {\n\t\t\t\t\tconst oldName = document.getElementById('edit-role-old-name').value;\n\t\t\t\t\tconst newName = document.getElementById('edit-role-name').value.trim();\n\t\t\t\t\tconst description = document.getElementById('edit-role-description').value.trim();\n\n\t\t\t\t\tif (!newName) {\n\t\t\t\t\t\talert('Please enter a role name');\n\t\t\t\t\t\treturn;\n\t\t\t\t\t}\n\n\t\t\t\t\ttry {\n\t\t\t\t\t\tconst { response, result } = await submitRoleChange('/admin-ui/api/roles', 'PUT', {\n\t\t\t\t\t\t\told_name: oldName,\n\t\t\t\t\t\t\tnew_name: newName,\n\t\t\t\t\t\t\tdescription: description\n\t\t\t\t\t\t});\n\n\t\t\t\t\t\tif (response.status === 202) {\n\t\t\t\t\t\t\tcloseEditRoleModal();\n\t\t\t\t\t\t\tshowPendingApproval(result);\n\t\t\t\t\t\t} else if (response.ok) {\n\t\t\t\t\t\t\talert('Role updated successfully!');\n\t\t\t\t\t\t\tcloseEditRoleModal();\n\t\t\t\t\t\t\tlocation.reload(); // Reload to update the UI\n\t\t\t\t\t\t} else {\n\t\t\t\t\t\t\talert('Error: ' + result.error);\n\t\t\t\t\t\t}\n\t\t\t\t\t} catch (error) {\n\t\t\t\t\t\talert('Network error: ' + error.message);\n\t\t\t\t\t}\n\t\t\t\t}\n\n\t\t\t\t// Delete role action\n\t\t\t\tasync function deleteRole(roleName) {\n\t\t\t\t\tif (!confirm(`Are you sure you want to delete the role \"${roleName}\"? This will remove all user assignments and policies for this role.`)) {\n\t\t\t\t\t\treturn;\n\t\t\t\t\t}\n\n\t\t\t\t\ttry {\n\t\t\t\t\t\tconst { response, result } = await submitRoleChange('/admin-ui/api/roles/delete', 'POST', {\n\t\t\t\t\t\t\trole: roleName\n\t\t\t\t\t\t});\n\n\t\t\t\t\t\tif (response.status === 202) {\n\t\t\t\t\t\t\tshowPendingApproval(result);\n\t\t\t\t\t\t} else if (response.ok) {\n\t\t\t\t\t\t\talert('Role deleted successfully!');\n\t\t\t\t\t\t\tlocation.reload(); // Reload to update the UI\n\t\t\t\t\t\t} else {\n\t\t\t\t\t\t\talert('Error: ' + result.error);\n\t\t\t\t\t\t}\n\t\t\t\t\t} catch (error) {\n\t\t\t\t\t\talert('Network error: ' + error.message);\n\t\t\t\t\t}\n\t\t\t\t}\n\n\t\t\t\t// Attach event listeners\n\t\t\t\tdocument.addEventListener('DOMContentLoaded', function() {\n\t\t\t\t\tloadApprovals();\n\n\t\t\t\t\t// View role details - handled by link href\n\t\t\t\t\t// No event listener needed\n\n\t\t\t\t\t// Remove role from user\n\t\t\t\t\tdocument.querySelectorAll('.remove-role-btn').forEach(btn => {\n\t\t\t\t\t\tbtn.addEventListener('click', function() {\n\t\t\t\t\t\t\tconst userId = this.getAttribute('data-user-id');\n\t\t\t\t\t\t\tconst role = this.getAttribute('data-role');\n\t\t\t\t\t\t\tremoveUserRole(userId, role);\n\t\t\t\t\t\t});\n\t\t\t\t\t});\n\n\t\t\t\t\t// Assign role\n\t\t\t\t\tdocument.querySelectorAll('.assign-role-btn').forEach(btn => {\n\t\t\t\t\t\tbtn.addEventListener('click', function() {\n\t\t\t\t\t\t\tconst userId = this.getAttribute('data-user-id');\n\t\t\t\t\t\t\tconst username = this.getAttribute('data-username');\n\t\t\t\t\t\t\topenAssignRoleModal(userId, username);\n\t\t\t\t\t\t});\n\t\t\t\t\t});\n\n\t\t\t\t\t// Edit role\n\t\t\t\t\tdocument.querySelectorAll('.edit-role-btn').forEach(btn => {\n\t\t\t\t\t\tbtn.addEventListener('click', function() {\n\t\t\t\t\t\t\tconst roleName = this.getAttribute('data-role-name');\n\t\t\t\t\t\t\tconst roleDescription = this.getAttribute('data-role-description');\n\t\t\t\t\t\t\topenEditRoleModal(roleName, roleDescription);\n\t\t\t\t\t\t});\n\t\t\t\t\t});\n\n\t\t\t\t\t// Delete role\n\t\t\t\t\tdocument.querySelectorAll('.delete-role-btn').forEach(btn => {\n\t\t\t\t\t\tbtn.addEventListener('click', function() {\n\t\t\t\t\t\t\tconst roleName = this.getAttribute('data-role-name');\n\t\t\t\t\t\t\tdeleteRole(roleName);\n\t\t\t\t\t\t});\n\t\t\t\t\t});\n\n\t\t\t\t\t// Close modals on background click\n\t\t\t\t\tdocument.getElementById('assign-role-modal').addEventListener('click', function(e) {\n\t\t\t\t\t\tif (e.target === this) {\n\t\t\t\t\t\t\tcloseAssignRoleModal();\n\t\t\t\t\t\t}\n\t\t\t\t\t});\n\n\t\t\t\t\tdocument.getElementById('create-role-modal').addEventListener('click', function(e) {\n\t\t\t\t\t\tif (e.target === this) {\n\t\t\t\t\t\t\tcloseCreateRoleModal();\n\t\t\t\t\t\t}\n\t\t\t\t\t});\n\n\t\t\t\t\tdocument.getElementById('edit-role-modal').addEventListener('click', function(e) {\n\t\t\t\t\t\tif (e.target === this) {\n\t\t\t\t\t\t\tcloseEditRoleModal();\n\t\t\t\t\t\t}\n\t\t\t\t\t});\n\t\t\t\t});\n\t\t\t</script></head><body class=\"bg-gray-100 dark:bg-gray-950 transition-colors\"><div class=\"min-h-screen flex flex-col\"><!-- Header --><header class=\"bg-white dark:bg-gray-900 shadow-sm border-b border-gray-200 dark:border-gray-700\"><div class=\"max-w-7xl mx-auto px-4 py-4 sm:px-6 lg:px-8 flex items-center justify-between\"><div class=\"flex items-center space-x-4\"><a href=\"/admin-ui\" class=\"text-gray-600 dark:text-gray-400 hover:text-gray-900 dark:hover:text-gray-200\"><i class=\"fas fa-arrow-left mr-2\"></i>Back to Dashboard</a><h1 class=\"text-2xl font-bold text-gray-900 dark:text-gray-100\">Role Management</h1></div><div class=\"flex items-center space-x-4\"><span class=\"text-xs text-gray-500 dark:text-gray-400\">")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
 		var templ_7745c5c3_Var2 string
 		templ_7745c5c3_Var2, templ_7745c5c3_Err = templ.JoinStringErrs(fmt.Sprintf("%d roles, %d users", len(data.Roles), len(data.UserRoles)))
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/role_management.templ`, Line: 324, Col: 81}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `role_management.templ`, Line: 412, Col: 81}
 		}
 		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var2))
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 3, "</span>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 3, "</span> <a href=\"/admin-ui/role-sync\" class=\"text-sm text-blue-600 hover:text-blue-800 dark:text-blue-400\" title=\"Compare user roles with grouping policies\"><i class=\"fas fa-sync-alt mr-1\"></i>Role Sync</a>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -79,7 +83,7 @@ func RoleManagementPage(data RoleManagementPageData) templ.Component {
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 4, "<a href=\"/admin-ui/logout\" class=\"text-gray-600 dark:text-gray-400 hover:text-gray-900 dark:hover:text-gray-200\"><i class=\"fas fa-sign-out-alt\"></i></a></div></div></header><!-- Main Content --><main class=\"flex-1 max-w-7xl w-full mx-auto px-4 py-8 sm:px-6 lg:px-8\"><!-- Info Banner --><div class=\"mb-6 bg-green-50 dark:bg-green-900/20 border border-green-200 dark:border-green-800 rounded-lg p-4\"><div class=\"flex items-start\"><i class=\"fas fa-check-circle text-green-600 dark:text-green-400 mt-1 mr-3\"></i><div><h3 class=\"text-sm font-semibold text-green-900 dark:text-green-200\">Full CRUD Support Available</h3><p class=\"text-xs text-green-800 dark:text-green-300 mt-1\">This interface provides complete CRUD operations for roles and user assignments based on your Casbin policies. You can create, read, update, and delete roles, as well as assign and remove roles from users directly through the UI.</p></div></div></div><!-- Roles Overview --><div class=\"mb-8\"><div class=\"flex items-center justify-between mb-4\"><h2 class=\"text-xl font-bold text-gray-900 dark:text-gray-100\">Available Roles</h2><button onclick=\"openCreateRoleModal()\" class=\"bg-blue-600 hover:bg-blue-700 text-white px-4 py-2 rounded-lg text-sm font-medium transition\"><i class=\"fas fa-plus mr-2\"></i>Add New Role</button></div><div class=\"grid grid-cols-1 md:grid-cols-2 lg:grid-cols-3 gap-4\">")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 4, "<a href=\"/admin-ui/logout\" class=\"text-gray-600 dark:text-gray-400 hover:text-gray-900 dark:hover:text-gray-200\"><i class=\"fas fa-sign-out-alt\"></i></a></div></div></header><!-- Main Content --><main class=\"flex-1 max-w-7xl w-full mx-auto px-4 py-8 sm:px-6 lg:px-8\"><!-- Info Banner --><div class=\"mb-6 bg-green-50 dark:bg-green-900/20 border border-green-200 dark:border-green-800 rounded-lg p-4\"><div class=\"flex items-start\"><i class=\"fas fa-check-circle text-green-600 dark:text-green-400 mt-1 mr-3\"></i><div><h3 class=\"text-sm font-semibold text-green-900 dark:text-green-200\">Full CRUD Support Available</h3><p class=\"text-xs text-green-800 dark:text-green-300 mt-1\">This interface provides complete CRUD operations for roles and user assignments based on your Casbin policies. You can create, read, update, and delete roles, as well as assign and remove roles from users directly through the UI.</p></div></div></div><!-- Approval requests of role changes that would lock an admin out --><div id=\"approvals-panel\" class=\"hidden mb-6 bg-yellow-50 dark:bg-yellow-900/20 border border-yellow-200 dark:border-yellow-800 rounded-lg p-4\"><h3 class=\"text-sm font-semibold text-yellow-900 dark:text-yellow-200 mb-2\"><i class=\"fas fa-user-shield mr-2\"></i>Lockout Overrides Awaiting Approval</h3><p class=\"text-xs text-yellow-800 dark:text-yellow-300 mb-2\">These changes would remove access the requesting admin relies on. Another superadmin has to approve them.</p><div id=\"approvals-list\"></div></div><!-- Roles Overview --><div class=\"mb-8\"><div class=\"flex items-center justify-between mb-4\"><h2 class=\"text-xl font-bold text-gray-900 dark:text-gray-100\">Available Roles</h2><button onclick=\"openCreateRoleModal()\" class=\"bg-blue-600 hover:bg-blue-700 text-white px-4 py-2 rounded-lg text-sm font-medium transition\"><i class=\"fas fa-plus mr-2\"></i>Add New Role</button></div><div class=\"grid grid-cols-1 md:grid-cols-2 lg:grid-cols-3 gap-4\">")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -91,7 +95,7 @@ func RoleManagementPage(data RoleManagementPageData) templ.Component {
 			var templ_7745c5c3_Var3 string
 			templ_7745c5c3_Var3, templ_7745c5c3_Err = templ.JoinStringErrs(role.Name)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/role_management.templ`, Line: 365, Col: 82}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `role_management.templ`, Line: 466, Col: 82}
 			}
 			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var3))
 			if templ_7745c5c3_Err != nil {
@@ -104,7 +108,7 @@ func RoleManagementPage(data RoleManagementPageData) templ.Component {
 			var templ_7745c5c3_Var4 string
 			templ_7745c5c3_Var4, templ_7745c5c3_Err = templ.JoinStringErrs(role.Description)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/role_management.templ`, Line: 366, Col: 82}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `role_management.templ`, Line: 467, Col: 82}
 			}
 			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var4))
 			if templ_7745c5c3_Err != nil {
@@ -117,7 +121,7 @@ func RoleManagementPage(data RoleManagementPageData) templ.Component {
 			var templ_7745c5c3_Var5 string
 			templ_7745c5c3_Var5, templ_7745c5c3_Err = templ.JoinStringErrs(fmt.Sprintf("%d users", role.UserCount))
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/role_management.templ`, Line: 373, Col: 52}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `role_management.templ`, Line: 474, Col: 52}
 			}
 			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var5))
 			if templ_7745c5c3_Err != nil {
@@ -130,7 +134,7 @@ func RoleManagementPage(data RoleManagementPageData) templ.Component {
 			var templ_7745c5c3_Var6 string
 			templ_7745c5c3_Var6, templ_7745c5c3_Err = templ.JoinStringErrs(role.Name)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/role_management.templ`, Line: 376, Col: 45}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `role_management.templ`, Line: 477, Col: 45}
 			}
 			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var6))
 			if templ_7745c5c3_Err != nil {
@@ -143,7 +147,7 @@ func RoleManagementPage(data RoleManagementPageData) templ.Component {
 			var templ_7745c5c3_Var7 string
 			templ_7745c5c3_Var7, templ_7745c5c3_Err = templ.JoinStringErrs(role.Description)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/role_management.templ`, Line: 376, Col: 88}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `role_management.templ`, Line: 477, Col: 88}
 			}
 			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var7))
 			if templ_7745c5c3_Err != nil {
@@ -156,7 +160,7 @@ func RoleManagementPage(data RoleManagementPageData) templ.Component {
 			var templ_7745c5c3_Var8 string
 			templ_7745c5c3_Var8, templ_7745c5c3_Err = templ.JoinStringErrs(role.Name)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/role_management.templ`, Line: 379, Col: 45}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `role_management.templ`, Line: 480, Col: 45}
 			}
 			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var8))
 			if templ_7745c5c3_Err != nil {
@@ -169,7 +173,7 @@ func RoleManagementPage(data RoleManagementPageData) templ.Component {
 			var templ_7745c5c3_Var9 templ.SafeURL
 			templ_7745c5c3_Var9, templ_7745c5c3_Err = templ.JoinURLErrs(templ.URL("/admin-ui/roles/" + role.Name))
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/role_management.templ`, Line: 382, Col: 62}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `role_management.templ`, Line: 483, Col: 62}
 			}
 			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var9))
 			if templ_7745c5c3_Err != nil {
@@ -180,192 +184,244 @@ func RoleManagementPage(data RoleManagementPageData) templ.Component {
 				return templ_7745c5c3_Err
 			}
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 13, "</div></div><!-- User Role Assignments --><div><div class=\"flex items-center justify-between mb-4\"><h2 class=\"text-xl font-bold text-gray-900 dark:text-gray-100\">User Role Assignments</h2><div class=\"flex gap-2\"><button onclick=\"exportRoles()\" class=\"bg-green-600 hover:bg-green-700 text-white px-4 py-2 rounded-lg text-sm font-medium transition\"><i class=\"fas fa-file-export mr-2\"></i>Export</button> <button onclick=\"location.reload()\" class=\"bg-purple-600 hover:bg-purple-700 text-white px-4 py-2 rounded-lg text-sm font-medium transition\"><i class=\"fas fa-sync mr-2\"></i>Reload from Casbin</button></div></div><div class=\"bg-white dark:bg-gray-800 rounded-lg shadow-sm border border-gray-200 dark:border-gray-700 overflow-hidden\"><div class=\"overflow-x-auto\"><table class=\"w-full text-sm\"><thead><tr class=\"text-left text-xs font-medium text-gray-600 dark:text-gray-400 uppercase bg-gray-50 dark:bg-gray-700/50 border-b border-gray-200 dark:border-gray-700\"><th class=\"px-4 py-3\">User ID</th><th class=\"px-4 py-3\">Username</th><th class=\"px-4 py-3\">Assigned Roles</th><th class=\"px-4 py-3 text-right\">Actions</th></tr></thead> <tbody class=\"divide-y divide-gray-200 dark:divide-gray-700\">")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 13, "</div></div><!-- User Role Assignments --><div><div class=\"flex items-center justify-between mb-4\"><h2 class=\"text-xl font-bold text-gray-900 dark:text-gray-100\">User Role Assignments</h2><div class=\"flex gap-2\"><button onclick=\"exportRoles()\" class=\"bg-green-600 hover:bg-green-700 text-white px-4 py-2 rounded-lg text-sm font-medium transition\"><i class=\"fas fa-file-export mr-2\"></i>Export</button> ")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		if !data.AutoSave {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 14, "<button onclick=\"savePolicies()\" title=\"Auto-save is disabled; changes are lost on restart until saved\" class=\"bg-orange-600 hover:bg-orange-700 text-white px-4 py-2 rounded-lg text-sm font-medium transition\"><i class=\"fas fa-save mr-2\"></i>Save Policies</button> ")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 15, "<button onclick=\"location.reload()\" class=\"bg-purple-600 hover:bg-purple-700 text-white px-4 py-2 rounded-lg text-sm font-medium transition\"><i class=\"fas fa-sync mr-2\"></i>Reload from Casbin</button></div></div><div class=\"bg-white dark:bg-gray-800 rounded-lg shadow-sm border border-gray-200 dark:border-gray-700 overflow-hidden\"><div class=\"overflow-x-auto\"><table class=\"w-full text-sm\"><thead><tr class=\"text-left text-xs font-medium text-gray-600 dark:text-gray-400 uppercase bg-gray-50 dark:bg-gray-700/50 border-b border-gray-200 dark:border-gray-700\"><th class=\"px-4 py-3\">User ID</th><th class=\"px-4 py-3\">Username</th><th class=\"px-4 py-3\">Assigned Roles</th><th class=\"px-4 py-3 text-right\">Actions</th></tr></thead> <tbody class=\"divide-y divide-gray-200 dark:divide-gray-700\">")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
 		for _, userRole := range data.UserRoles {
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 14, "<tr class=\"hover:bg-gray-50 dark:hover:bg-gray-700/50 transition\"><td class=\"px-4 py-3 text-gray-900 dark:text-gray-100 font-mono text-xs\">")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 16, "<tr class=\"hover:bg-gray-50 dark:hover:bg-gray-700/50 transition\"><td class=\"px-4 py-3 text-gray-900 dark:text-gray-100 font-mono text-xs\">")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 			var templ_7745c5c3_Var10 string
 			templ_7745c5c3_Var10, templ_7745c5c3_Err = templ.JoinStringErrs(userRole.UserID)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/role_management.templ`, Line: 419, Col: 30}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `role_management.templ`, Line: 525, Col: 30}
 			}
 			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var10))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 15, "</td><td class=\"px-4 py-3 text-gray-900 dark:text-gray-100\">")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 17, "</td><td class=\"px-4 py-3 text-gray-900 dark:text-gray-100\">")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 			var templ_7745c5c3_Var11 string
-			templ_7745c5c3_Var11, templ_7745c5c3_Err = templ.JoinStringErrs(userRole.Username)
+			templ_7745c5c3_Var11, templ_7745c5c3_Err = templ.JoinStringErrs(userRole.DisplayName)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/role_management.templ`, Line: 422, Col: 32}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `role_management.templ`, Line: 528, Col: 35}
 			}
 			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var11))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 16, "</td><td class=\"px-4 py-3\">")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 18, " ")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if userRole.DisplayName != userRole.Username {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 19, "<span class=\"block text-xs text-gray-500 dark:text-gray-400\">")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				var templ_7745c5c3_Var12 string
+				templ_7745c5c3_Var12, templ_7745c5c3_Err = templ.JoinStringErrs(userRole.Username)
+				if templ_7745c5c3_Err != nil {
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `role_management.templ`, Line: 530, Col: 94}
+				}
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var12))
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 20, "</span> ")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			if userRole.Email != "" {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 21, "<span class=\"block text-xs text-gray-500 dark:text-gray-400\">")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				var templ_7745c5c3_Var13 string
+				templ_7745c5c3_Var13, templ_7745c5c3_Err = templ.JoinStringErrs(userRole.Email)
+				if templ_7745c5c3_Err != nil {
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `role_management.templ`, Line: 533, Col: 91}
+				}
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var13))
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 22, "</span>")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 23, "</td><td class=\"px-4 py-3\">")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 			if len(userRole.Roles) == 0 {
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 17, "<span class=\"text-gray-400 dark:text-gray-500 italic\">No roles assigned</span>")
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 24, "<span class=\"text-gray-400 dark:text-gray-500 italic\">No roles assigned</span>")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
 			} else {
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 18, "<div class=\"flex flex-wrap gap-1\">")
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 25, "<div class=\"flex flex-wrap gap-1\">")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
 				for _, role := range userRole.Roles {
-					templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 19, "<span class=\"px-2 py-1 bg-indigo-100 dark:bg-indigo-900 text-indigo-800 dark:text-indigo-200 text-xs rounded inline-flex items-center\">")
+					templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 26, "<span class=\"px-2 py-1 bg-indigo-100 dark:bg-indigo-900 text-indigo-800 dark:text-indigo-200 text-xs rounded inline-flex items-center\">")
 					if templ_7745c5c3_Err != nil {
 						return templ_7745c5c3_Err
 					}
-					var templ_7745c5c3_Var12 string
-					templ_7745c5c3_Var12, templ_7745c5c3_Err = templ.JoinStringErrs(role)
+					var templ_7745c5c3_Var14 string
+					templ_7745c5c3_Var14, templ_7745c5c3_Err = templ.JoinStringErrs(role)
 					if templ_7745c5c3_Err != nil {
-						return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/role_management.templ`, Line: 431, Col: 23}
+						return templ.Error{Err: templ_7745c5c3_Err, FileName: `role_management.templ`, Line: 543, Col: 23}
 					}
-					_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var12))
+					_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var14))
 					if templ_7745c5c3_Err != nil {
 						return templ_7745c5c3_Err
 					}
-					templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 20, " <button data-user-id=\"")
+					templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 27, " <button data-user-id=\"")
 					if templ_7745c5c3_Err != nil {
 						return templ_7745c5c3_Err
 					}
-					var templ_7745c5c3_Var13 string
-					templ_7745c5c3_Var13, templ_7745c5c3_Err = templ.JoinStringErrs(userRole.UserID)
+					var templ_7745c5c3_Var15 string
+					templ_7745c5c3_Var15, templ_7745c5c3_Err = templ.JoinStringErrs(userRole.UserID)
 					if templ_7745c5c3_Err != nil {
-						return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/role_management.templ`, Line: 432, Col: 55}
+						return templ.Error{Err: templ_7745c5c3_Err, FileName: `role_management.templ`, Line: 544, Col: 55}
 					}
-					_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var13))
+					_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var15))
 					if templ_7745c5c3_Err != nil {
 						return templ_7745c5c3_Err
 					}
-					templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 21, "\" data-role=\"")
+					templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 28, "\" data-role=\"")
 					if templ_7745c5c3_Err != nil {
 						return templ_7745c5c3_Err
 					}
-					var templ_7745c5c3_Var14 string
-					templ_7745c5c3_Var14, templ_7745c5c3_Err = templ.JoinStringErrs(role)
+					var templ_7745c5c3_Var16 string
+					templ_7745c5c3_Var16, templ_7745c5c3_Err = templ.JoinStringErrs(role)
 					if templ_7745c5c3_Err != nil {
-						return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/role_management.templ`, Line: 432, Col: 74}
+						return templ.Error{Err: templ_7745c5c3_Err, FileName: `role_management.templ`, Line: 544, Col: 74}
 					}
-					_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var14))
+					_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var16))
 					if templ_7745c5c3_Err != nil {
 						return templ_7745c5c3_Err
 					}
-					templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 22, "\" class=\"remove-role-btn ml-1 hover:text-red-600 dark:hover:text-red-400\"><i class=\"fas fa-times text-xs\"></i></button></span>")
+					templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 29, "\" class=\"remove-role-btn ml-1 hover:text-red-600 dark:hover:text-red-400\"><i class=\"fas fa-times text-xs\"></i></button></span>")
 					if templ_7745c5c3_Err != nil {
 						return templ_7745c5c3_Err
 					}
 				}
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 23, "</div>")
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 30, "</div>")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 24, "</td><td class=\"px-4 py-3 text-right\"><button data-user-id=\"")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 31, "</td><td class=\"px-4 py-3 text-right\"><button data-user-id=\"")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			var templ_7745c5c3_Var15 string
-			templ_7745c5c3_Var15, templ_7745c5c3_Err = templ.JoinStringErrs(userRole.UserID)
+			var templ_7745c5c3_Var17 string
+			templ_7745c5c3_Var17, templ_7745c5c3_Err = templ.JoinStringErrs(userRole.UserID)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/role_management.templ`, Line: 441, Col: 51}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `role_management.templ`, Line: 553, Col: 51}
 			}
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var15))
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var17))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 25, "\" data-username=\"")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 32, "\" data-username=\"")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			var templ_7745c5c3_Var16 string
-			templ_7745c5c3_Var16, templ_7745c5c3_Err = templ.JoinStringErrs(userRole.Username)
+			var templ_7745c5c3_Var18 string
+			templ_7745c5c3_Var18, templ_7745c5c3_Err = templ.JoinStringErrs(userRole.Username)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/role_management.templ`, Line: 441, Col: 87}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `role_management.templ`, Line: 553, Col: 87}
 			}
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var16))
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var18))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 26, "\" class=\"assign-role-btn text-blue-600 dark:text-blue-400 hover:text-blue-800 dark:hover:text-blue-300\"><i class=\"fas fa-plus-circle mr-1\"></i>Assign Role</button></td></tr>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 33, "\" class=\"assign-role-btn text-blue-600 dark:text-blue-400 hover:text-blue-800 dark:hover:text-blue-300\"><i class=\"fas fa-plus-circle mr-1\"></i>Assign Role</button></td></tr>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 27, "</tbody></table>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 34, "</tbody></table>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
 		if len(data.UserRoles) == 0 {
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 28, "<div class=\"text-center py-12\"><i class=\"fas fa-user-slash text-gray-400 dark:text-gray-600 text-4xl mb-3\"></i><p class=\"text-gray-600 dark:text-gray-400\">No users found</p><p class=\"text-sm text-gray-500 dark:text-gray-500 mt-1\">Users will appear here once they are registered in the system</p></div>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 35, "<div class=\"text-center py-12\"><i class=\"fas fa-user-slash text-gray-400 dark:text-gray-600 text-4xl mb-3\"></i><p class=\"text-gray-600 dark:text-gray-400\">No users found</p><p class=\"text-sm text-gray-500 dark:text-gray-500 mt-1\">Users will appear here once they are registered in the system</p></div>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 29, "</div></div></div></main></div><!-- Assign Role Modal --><div id=\"assign-role-modal\" class=\"hidden fixed inset-0 bg-black bg-opacity-50 flex items-center justify-center z-50\"><div class=\"bg-white dark:bg-gray-800 rounded-lg shadow-xl max-w-md w-full mx-4\"><div class=\"p-6\"><div class=\"flex items-center justify-between mb-4\"><h3 class=\"text-lg font-semibold text-gray-900 dark:text-gray-100\">Assign Role</h3><button onclick=\"closeAssignRoleModal()\" class=\"text-gray-400 hover:text-gray-600 dark:hover:text-gray-300\"><i class=\"fas fa-times\"></i></button></div><div class=\"mb-4\"><label class=\"block text-sm font-medium text-gray-700 dark:text-gray-300 mb-2\">User: <span id=\"assign-username\" class=\"font-semibold\"></span></label> <input type=\"hidden\" id=\"assign-user-id\"></div><div class=\"mb-4\"><label for=\"role-select\" class=\"block text-sm font-medium text-gray-700 dark:text-gray-300 mb-2\">Select Role</label> <select id=\"role-select\" class=\"w-full px-3 py-2 border border-gray-300 dark:border-gray-600 rounded-lg bg-white dark:bg-gray-700 text-gray-900 dark:text-gray-100\"><option value=\"\">Choose a role...</option> ")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 36, "</div></div></div></main></div><!-- Assign Role Modal --><div id=\"assign-role-modal\" class=\"hidden fixed inset-0 bg-black bg-opacity-50 flex items-center justify-center z-50\"><div class=\"bg-white dark:bg-gray-800 rounded-lg shadow-xl max-w-md w-full mx-4\"><div class=\"p-6\"><div class=\"flex items-center justify-between mb-4\"><h3 class=\"text-lg font-semibold text-gray-900 dark:text-gray-100\">Assign Role</h3><button onclick=\"closeAssignRoleModal()\" class=\"text-gray-400 hover:text-gray-600 dark:hover:text-gray-300\"><i class=\"fas fa-times\"></i></button></div><div class=\"mb-4\"><label class=\"block text-sm font-medium text-gray-700 dark:text-gray-300 mb-2\">User: <span id=\"assign-username\" class=\"font-semibold\"></span></label> <input type=\"hidden\" id=\"assign-user-id\"></div><div class=\"mb-4\"><label for=\"role-select\" class=\"block text-sm font-medium text-gray-700 dark:text-gray-300 mb-2\">Select Role</label> <select id=\"role-select\" class=\"w-full px-3 py-2 border border-gray-300 dark:border-gray-600 rounded-lg bg-white dark:bg-gray-700 text-gray-900 dark:text-gray-100\"><option value=\"\">Choose a role...</option> ")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
 		for _, role := range data.Roles {
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 30, "<option value=\"")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 37, "<option value=\"")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			var templ_7745c5c3_Var17 string
-			templ_7745c5c3_Var17, templ_7745c5c3_Err = templ.JoinStringErrs(role.Name)
+			var templ_7745c5c3_Var19 string
+			templ_7745c5c3_Var19, templ_7745c5c3_Err = templ.JoinStringErrs(role.Name)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/role_management.templ`, Line: 484, Col: 34}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `role_management.templ`, Line: 596, Col: 34}
 			}
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var17))
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var19))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 31, "\">")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 38, "\">")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			var templ_7745c5c3_Var18 string
-			templ_7745c5c3_Var18, templ_7745c5c3_Err = templ.JoinStringErrs(role.Name)
+			var templ_7745c5c3_Var20 string
+			templ_7745c5c3_Var20, templ_7745c5c3_Err = templ.JoinStringErrs(role.Name)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/role_management.templ`, Line: 484, Col: 48}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `role_management.templ`, Line: 596, Col: 48}
 			}
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var18))
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var20))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 32, " - ")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 39, " - ")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			var templ_7745c5c3_Var19 string
-			templ_7745c5c3_Var19, templ_7745c5c3_Err = templ.JoinStringErrs(role.Description)
+			var templ_7745c5c3_Var21 string
+			templ_7745c5c3_Var21, templ_7745c5c3_Err = templ.JoinStringErrs(role.Description)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/role_management.templ`, Line: 484, Col: 71}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `role_management.templ`, Line: 596, Col: 71}
 			}
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var19))
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var21))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 33, "</option>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 40, "</option>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 34, "</select></div><div class=\"flex gap-2 justify-end\"><button onclick=\"closeAssignRoleModal()\" class=\"px-4 py-2 text-gray-700 dark:text-gray-300 hover:bg-gray-100 dark:hover:bg-gray-700 rounded-lg transition\">Cancel</button> <button onclick=\"assignRole()\" class=\"px-4 py-2 bg-blue-600 hover:bg-blue-700 text-white rounded-lg transition\"><i class=\"fas fa-check mr-2\"></i>Assign</button></div></div></div></div><!-- Create Role Modal --><div id=\"create-role-modal\" class=\"hidden fixed inset-0 bg-black bg-opacity-50 flex items-center justify-center z-50\"><div class=\"bg-white dark:bg-gray-800 rounded-lg shadow-xl max-w-md w-full mx-4\"><div class=\"p-6\"><div class=\"flex items-center justify-between mb-4\"><h3 class=\"text-lg font-semibold text-gray-900 dark:text-gray-100\">Create New Role</h3><button onclick=\"closeCreateRoleModal()\" class=\"text-gray-400 hover:text-gray-600 dark:hover:text-gray-300\"><i class=\"fas fa-times\"></i></button></div><div class=\"mb-4\"><label for=\"role-name\" class=\"block text-sm font-medium text-gray-700 dark:text-gray-300 mb-2\">Role Name *</label> <input type=\"text\" id=\"role-name\" placeholder=\"e.g., manager, editor\" class=\"w-full px-3 py-2 border border-gray-300 dark:border-gray-600 rounded-lg bg-white dark:bg-gray-700 text-gray-900 dark:text-gray-100\"></div><div class=\"mb-4\"><label for=\"role-description\" class=\"block text-sm font-medium text-gray-700 dark:text-gray-300 mb-2\">Description</label> <textarea id=\"role-description\" placeholder=\"Describe the role's purpose and permissions\" rows=\"3\" class=\"w-full px-3 py-2 border border-gray-300 dark:border-gray-600 rounded-lg bg-white dark:bg-gray-700 text-gray-900 dark:text-gray-100\"></textarea></div><div class=\"flex gap-2 justify-end\"><button onclick=\"closeCreateRoleModal()\" class=\"px-4 py-2 text-gray-700 dark:text-gray-300 hover:bg-gray-100 dark:hover:bg-gray-700 rounded-lg transition\">Cancel</button> <button onclick=\"createRole()\" class=\"px-4 py-2 bg-blue-600 hover:bg-blue-700 text-white rounded-lg transition\"><i class=\"fas fa-plus mr-2\"></i>Create Role</button></div></div></div></div><!-- Edit Role Modal --><div id=\"edit-role-modal\" class=\"hidden fixed inset-0 bg-black bg-opacity-50 flex items-center justify-center z-50\"><div class=\"bg-white dark:bg-gray-800 rounded-lg shadow-xl max-w-md w-full mx-4\"><div class=\"p-6\"><div class=\"flex items-center justify-between mb-4\"><h3 class=\"text-lg font-semibold text-gray-900 dark:text-gray-100\">Edit Role</h3><button onclick=\"closeEditRoleModal()\" class=\"text-gray-400 hover:text-gray-600 dark:hover:text-gray-300\"><i class=\"fas fa-times\"></i></button></div><div class=\"mb-4\"><label for=\"edit-role-name\" class=\"block text-sm font-medium text-gray-700 dark:text-gray-300 mb-2\">Role Name *</label> <input type=\"text\" id=\"edit-role-name\" placeholder=\"e.g., manager, editor\" class=\"w-full px-3 py-2 border border-gray-300 dark:border-gray-600 rounded-lg bg-white dark:bg-gray-700 text-gray-900 dark:text-gray-100\"> <input type=\"hidden\" id=\"edit-role-old-name\"></div><div class=\"mb-4\"><label for=\"edit-role-description\" class=\"block text-sm font-medium text-gray-700 dark:text-gray-300 mb-2\">Description</label> <textarea id=\"edit-role-description\" placeholder=\"Describe the role's purpose and permissions\" rows=\"3\" class=\"w-full px-3 py-2 border border-gray-300 dark:border-gray-600 rounded-lg bg-white dark:bg-gray-700 text-gray-900 dark:text-gray-100\"></textarea></div><div class=\"flex gap-2 justify-end\"><button onclick=\"closeEditRoleModal()\" class=\"px-4 py-2 text-gray-700 dark:text-gray-300 hover:bg-gray-100 dark:hover:bg-gray-700 rounded-lg transition\">Cancel</button> <button onclick=\"editRole()\" class=\"px-4 py-2 bg-green-600 hover:bg-green-700 text-white rounded-lg transition\"><i class=\"fas fa-save mr-2\"></i>Update Role</button></div></div></div></div></body></html>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 41, "</select></div><div class=\"flex gap-2 justify-end\"><button onclick=\"closeAssignRoleModal()\" class=\"px-4 py-2 text-gray-700 dark:text-gray-300 hover:bg-gray-100 dark:hover:bg-gray-700 rounded-lg transition\">Cancel</button> <button onclick=\"assignRole()\" class=\"px-4 py-2 bg-blue-600 hover:bg-blue-700 text-white rounded-lg transition\"><i class=\"fas fa-check mr-2\"></i>Assign</button></div></div></div></div><!-- Create Role Modal --><div id=\"create-role-modal\" class=\"hidden fixed inset-0 bg-black bg-opacity-50 flex items-center justify-center z-50\"><div class=\"bg-white dark:bg-gray-800 rounded-lg shadow-xl max-w-md w-full mx-4\"><div class=\"p-6\"><div class=\"flex items-center justify-between mb-4\"><h3 class=\"text-lg font-semibold text-gray-900 dark:text-gray-100\">Create New Role</h3><button onclick=\"closeCreateRoleModal()\" class=\"text-gray-400 hover:text-gray-600 dark:hover:text-gray-300\"><i class=\"fas fa-times\"></i></button></div><div class=\"mb-4\"><label for=\"role-name\" class=\"block text-sm font-medium text-gray-700 dark:text-gray-300 mb-2\">Role Name *</label> <input type=\"text\" id=\"role-name\" placeholder=\"e.g., manager, editor\" class=\"w-full px-3 py-2 border border-gray-300 dark:border-gray-600 rounded-lg bg-white dark:bg-gray-700 text-gray-900 dark:text-gray-100\"></div><div class=\"mb-4\"><label for=\"role-description\" class=\"block text-sm font-medium text-gray-700 dark:text-gray-300 mb-2\">Description</label> <textarea id=\"role-description\" placeholder=\"Describe the role's purpose and permissions\" rows=\"3\" class=\"w-full px-3 py-2 border border-gray-300 dark:border-gray-600 rounded-lg bg-white dark:bg-gray-700 text-gray-900 dark:text-gray-100\"></textarea></div><div class=\"flex gap-2 justify-end\"><button onclick=\"closeCreateRoleModal()\" class=\"px-4 py-2 text-gray-700 dark:text-gray-300 hover:bg-gray-100 dark:hover:bg-gray-700 rounded-lg transition\">Cancel</button> <button onclick=\"createRole()\" class=\"px-4 py-2 bg-blue-600 hover:bg-blue-700 text-white rounded-lg transition\"><i class=\"fas fa-plus mr-2\"></i>Create Role</button></div></div></div></div><!-- Edit Role Modal --><div id=\"edit-role-modal\" class=\"hidden fixed inset-0 bg-black bg-opacity-50 flex items-center justify-center z-50\"><div class=\"bg-white dark:bg-gray-800 rounded-lg shadow-xl max-w-md w-full mx-4\"><div class=\"p-6\"><div class=\"flex items-center justify-between mb-4\"><h3 class=\"text-lg font-semibold text-gray-900 dark:text-gray-100\">Edit Role</h3><button onclick=\"closeEditRoleModal()\" class=\"text-gray-400 hover:text-gray-600 dark:hover:text-gray-300\"><i class=\"fas fa-times\"></i></button></div><div class=\"mb-4\"><label for=\"edit-role-name\" class=\"block text-sm font-medium text-gray-700 dark:text-gray-300 mb-2\">Role Name *</label> <input type=\"text\" id=\"edit-role-name\" placeholder=\"e.g., manager, editor\" class=\"w-full px-3 py-2 border border-gray-300 dark:border-gray-600 rounded-lg bg-white dark:bg-gray-700 text-gray-900 dark:text-gray-100\"> <input type=\"hidden\" id=\"edit-role-old-name\"></div><div class=\"mb-4\"><label for=\"edit-role-description\" class=\"block text-sm font-medium text-gray-700 dark:text-gray-300 mb-2\">Description</label> <textarea id=\"edit-role-description\" placeholder=\"Describe the role's purpose and permissions\" rows=\"3\" class=\"w-full px-3 py-2 border border-gray-300 dark:border-gray-600 rounded-lg bg-white dark:bg-gray-700 text-gray-900 dark:text-gray-100\"></textarea></div><div class=\"flex gap-2 justify-end\"><button onclick=\"closeEditRoleModal()\" class=\"px-4 py-2 text-gray-700 dark:text-gray-300 hover:bg-gray-100 dark:hover:bg-gray-700 rounded-lg transition\">Cancel</button> <button onclick=\"editRole()\" class=\"px-4 py-2 bg-green-600 hover:bg-green-700 text-white rounded-lg transition\"><i class=\"fas fa-save mr-2\"></i>Update Role</button></div></div></div></div></body></html>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
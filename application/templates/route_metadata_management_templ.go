@@ -12,12 +12,17 @@ import templruntime "github.com/a-h/templ/runtime"
 
 import (
 	"fmt"
+	"github.com/aruncs31s/azf/infrastructure/abac"
 	"github.com/aruncs31s/azf/infrastructure/enterprise"
 	"strings"
 )
 
 type RouteMetadataManagementPageData struct {
 	Routes []*enterprise.RouteMetadata
+	// ConditionAttributes documents the attributes route conditions can use
+	ConditionAttributes []abac.AttributeDescription
+	// ConditionsEnforced is true when the Casbin enforcer uses the ABAC model
+	ConditionsEnforced bool
 }
 
 func RouteMetadataManagementPage(data RouteMetadataManagementPageData) templ.Component {
@@ -49,14 +54,14 @@ func RouteMetadataManagementPage(data RouteMetadataManagementPageData) templ.Com
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "<script>\n\t\t\t\t// Toggle route details\n\t\t\t\tfunction toggleRouteDetails(routeId) {\n\t\t\t\t\tconst details = document.getElementById('details-' + routeId);\n\t\t\t\t\tconst icon = document.getElementById('icon-' + routeId);\n\t\t\t\t\tif (details.classList.contains('hidden')) {\n\t\t\t\t\t\tdetails.classList.remove('hidden');\n\t\t\t\t\t\ticon.classList.add('rotate-90');\n\t\t\t\t\t} else {\n\t\t\t\t\t\tdetails.classList.add('hidden');\n\t\t\t\t\t\ticon.classList.remove('rotate-90');\n\t\t\t\t\t}\n\t\t\t\t}\n\n\t\t\t\t// Edit route modal\n\t\t\t\tlet editingRouteIndex = -1; // Track which route is being edited\n\n\t\t\t\tfunction openEditModal(routeIndex) {\n\t\t\t\t\teditingRouteIndex = routeIndex;\n\t\t\t\t\tconst modal = document.getElementById('edit-modal');\n\t\t\t\t\tmodal.classList.remove('hidden');\n\t\t\t\t\t// Populate form with route data\n\t\t\t\t\tif (routeIndex >= 0) {\n\t\t\t\t\t\tconst route = @data.Routes[routeIndex];\n\t\t\t\t\t\tdocument.getElementById('edit-path').value = route.Path;\n\t\t\t\t\t\tdocument.getElementById('edit-method').value = route.Method;\n\t\t\t\t\t\tdocument.getElementById('edit-description').value = route.Description;\n\t\t\t\t\t\tdocument.getElementById('edit-roles').value = route.AllowedRoles.join(', ');\n\t\t\t\t\t\tdocument.getElementById('edit-api-version').value = route.APIVersion;\n\t\t\t\t\t\tdocument.getElementById('edit-tags').value = route.Tags.join(', ');\n\t\t\t\t\t\tdocument.getElementById('edit-public').checked = route.IsPublic;\n\t\t\t\t\t\tdocument.getElementById('edit-ownership-check').checked = route.OwnershipCheck;\n\t\t\t\t\t\tdocument.getElementById('edit-audit-required').checked = route.AuditRequired;\n\t\t\t\t\t\tdocument.getElementById('edit-deprecated').checked = route.Deprecated;\n\t\t\t\t\t\tdocument.getElementById('modal-title').textContent = 'Edit Route';\n\t\t\t\t\t} else {\n\t\t\t\t\t\t// New route - clear form\n\t\t\t\t\t\tdocument.getElementById('edit-path').value = '';\n\t\t\t\t\t\tdocument.getElementById('edit-method').value = 'GET';\n\t\t\t\t\t\tdocument.getElementById('edit-description').value = '';\n\t\t\t\t\t\tdocument.getElementById('edit-roles').value = '';\n\t\t\t\t\t\tdocument.getElementById('edit-api-version').value = 'v1';\n\t\t\t\t\t\tdocument.getElementById('edit-tags').value = '';\n\t\t\t\t\t\tdocument.getElementById('edit-public').checked = false;\n\t\t\t\t\t\tdocument.getElementById('edit-ownership-check').checked = false;\n\t\t\t\t\t\tdocument.getElementById('edit-audit-required').checked = false;\n\t\t\t\t\t\tdocument.getElementById('edit-deprecated').checked = false;\n\t\t\t\t\t\tdocument.getElementById('modal-title').textContent = 'Add New Route';\n\t\t\t\t\t}\n\t\t\t\t}\n\n\t\t\t\tfunction closeEditModal() {\n\t\t\t\t\tdocument.getElementById('edit-modal').classList.add('hidden');\n\t\t\t\t\teditingRouteIndex = -1;\n\t\t\t\t}\n\n\t\t\t\t// Save route changes\n\t\t\t\tasync function saveRoute() {\n\t\t\t\t\tconst formData = new FormData(document.getElementById('edit-form'));\n\t\t\t\t\tconst routeData = {\n\t\t\t\t\t\tpath: formData.get('path'),\n\t\t\t\t\t\tmethod: formData.get('method'),\n\t\t\t\t\t\tdescription: formData.get('description'),\n\t\t\t\t\t\tallowed_roles: formData.get('roles').split(',').map(r => r.trim()).filter(r => r),\n\t\t\t\t\t\tapi_version: formData.get('api_version'),\n\t\t\t\t\t\ttags: formData.get('tags').split(',').map(t => t.trim()).filter(t => t),\n\t\t\t\t\t\tis_public: formData.get('public') === 'on',\n\t\t\t\t\t\townership_check: formData.get('ownership_check') === 'on',\n\t\t\t\t\t\taudit_required: formData.get('audit_required') === 'on',\n\t\t\t\t\t\tdeprecated: formData.get('deprecated') === 'on'\n\t\t\t\t\t};\n\n\t\t\t\t\t// Validate required fields\n\t\t\t\t\tif (!routeData.path) {\n\t\t\t\t\t\talert('Path is required');\n\t\t\t\t\t\treturn;\n\t\t\t\t\t}\n\t\t\t\t\tif (!routeData.method) {\n\t\t\t\t\t\talert('Method is required');\n\t\t\t\t\t\treturn;\n\t\t\t\t\t}\n\t\t\t\t\tif (!routeData.api_version) {\n\t\t\t\t\t\talert('API Version is required');\n\t\t\t\t\t\treturn;\n\t\t\t\t\t}\n\n\t\t\t\t\ttry {\n\t\t\t\t\t\tconst response = await fetch('/admin-ui/route_metadata', {\n\t\t\t\t\t\t\tmethod: 'POST',\n\t\t\t\t\t\t\theaders: {\n\t\t\t\t\t\t\t\t'Content-Type': 'application/json',\n\t\t\t\t\t\t\t},\n\t\t\t\t\t\t\tbody: JSON.stringify({ routes: editingRouteIndex >= 0 ? \n\t\t\t\t\t\t\t\t@data.Routes.map((r, i) => i === editingRouteIndex ? routeData : {\n\t\t\t\t\t\t\t\t\tpath: r.Path,\n\t\t\t\t\t\t\t\t\tmethod: r.Method,\n\t\t\t\t\t\t\t\t\tdescription: r.Description,\n\t\t\t\t\t\t\t\t\tallowed_roles: r.AllowedRoles,\n\t\t\t\t\t\t\t\t\tapi_version: r.APIVersion,\n\t\t\t\t\t\t\t\t\ttags: r.Tags,\n\t\t\t\t\t\t\t\t\tis_public: r.IsPublic,\n\t\t\t\t\t\t\t\t\townership_check: r.OwnershipCheck,\n\t\t\t\t\t\t\t\t\taudit_required: r.AuditRequired,\n\t\t\t\t\t\t\t\t\tdeprecated: r.Deprecated\n\t\t\t\t\t\t\t\t}) : [...@data.Routes.map(r => ({\n\t\t\t\t\t\t\t\t\tpath: r.Path,\n\t\t\t\t\t\t\t\t\tmethod: r.Method,\n\t\t\t\t\t\t\t\t\tdescription: r.Description,\n\t\t\t\t\t\t\t\t\tallowed_roles: r.AllowedRoles,\n\t\t\t\t\t\t\t\t\tapi_version: r.APIVersion,\n\t\t\t\t\t\t\t\t\ttags: r.Tags,\n\t\t\t\t\t\t\t\t\tis_public: r.IsPublic,\n\t\t\t\t\t\t\t\t\townership_check: r.OwnershipCheck,\n\t\t\t\t\t\t\t\t\taudit_required: r.AuditRequired,\n\t\t\t\t\t\t\t\t\tdeprecated: r.Deprecated\n\t\t\t\t\t\t\t\t})), routeData]\n\t\t\t\t\t\t\t})\n\t\t\t\t\t\t});\n\n\t\t\t\t\t\tif (response.ok) {\n\t\t\t\t\t\t\talert('Route saved successfully! Refreshing page...');\n\t\t\t\t\t\t\tcloseEditModal();\n\t\t\t\t\t\t\twindow.location.reload();\n\t\t\t\t\t\t} else {\n\t\t\t\t\t\t\tconst error = await response.json();\n\t\t\t\t\t\t\talert('Error saving route: ' + (error.error || 'Unknown error'));\n\t\t\t\t\t\t}\n\t\t\t\t\t} catch (error) {\n\t\t\t\t\t\talert('Error saving route: ' + error.message);\n\t\t\t\t\t}\n\t\t\t\t}\n\n\t\t\t\t// Delete route\n\t\t\t\tasync function deleteRoute(methodText, pathText) {\n\t\t\t\t\tif (!confirm(`Are you sure you want to delete the route: ${methodText} ${pathText}?`)) {\n\t\t\t\t\t\treturn;\n\t\t\t\t\t}\n\n\t\t\t\t\ttry {\n\t\t\t\t\t\tconst response = await fetch('/admin-ui/route_metadata/delete', {\n\t\t\t\t\t\t\tmethod: 'POST',\n\t\t\t\t\t\t\theaders: {\n\t\t\t\t\t\t\t\t'Content-Type': 'application/json',\n\t\t\t\t\t\t\t},\n\t\t\t\t\t\t\tbody: JSON.stringify({\n\t\t\t\t\t\t\t\tmethod: methodText,\n\t\t\t\t\t\t\t\tpath: pathText\n\t\t\t\t\t\t\t})\n\t\t\t\t\t\t});\n\n\t\t\t\t\t\tif (response.ok) {\n\t\t\t\t\t\t\talert('Route deleted successfully! Refreshing page...');\n\t\t\t\t\t\t\twindow.location.reload();\n\t\t\t\t\t\t} else {\n\t\t\t\t\t\t\tconst error = await response.json();\n\t\t\t\t\t\t\talert('Error deleting route: ' + (error.error || 'Unknown error'));\n\t\t\t\t\t\t}\n\t\t\t\t\t} catch (error) {\n\t\t\t\t\t\talert('Error deleting route: ' + error.message);\n\t\t\t\t\t}\n\t\t\t\t}\n\n\t\t\t\t// Export routes as JSON\n\t\t\t\tfunction exportRoutes() {\n\t\t\t\t\tconst routes = @data.Routes;\n\t\t\t\t\tconst dataStr = JSON.stringify({routes: routes}, null, 2);\n\t\t\t\t\tconst dataUri = 'data:application/json;charset=utf-8,'+ encodeURIComponent(dataStr);\n\n\t\t\t\t\tconst exportFileDefaultName = 'enterprise_route_metadata.json';\n\n\t\t\t\t\tconst linkElement = document.createElement('a');\n\t\t\t\t\tlinkElement.setAttribute('href', dataUri);\n\t\t\t\t\tlinkElement.setAttribute('download', exportFileDefaultName);\n\t\t\t\t\tlinkElement.click();\n\t\t\t\t}\n\n\t\t\t\t// Import routes from JSON file\n\t\t\t\tfunction importRoutes(event) {\n\t\t\t\t\tconst file = event.target.files[0];\n\t\t\t\t\tif (file) {\n\t\t\t\t\t\tconst reader = new FileReader();\n\t\t\t\t\t\treader.onload = function(e) {\n\t\t\t\t\t\t\ttry {\n\t\t\t\t\t\t\t\tconst data = JSON.parse(e.target.result);\n\t\t\t\t\t\t\t\tif (data.routes && Array.isArray(data.routes)) {\n\t\t\t\t\t\t\t\t\timportRoutesData(data.routes);\n\t\t\t\t\t\t\t\t} else {\n\t\t\t\t\t\t\t\t\talert('Invalid file format. Expected {routes: [...]} structure.');\n\t\t\t\t\t\t\t\t}\n\t\t\t\t\t\t\t} catch (error) {\n\t\t\t\t\t\t\t\talert('Error parsing JSON file: ' + error.message);\n\t\t\t\t\t\t\t}\n\t\t\t\t\t\t};\n\t\t\t\t\t\treader.readAsText(file);\n\t\t\t\t\t}\n\t\t\t\t}\n\n\t\t\t\t// Send imported routes to server\n\t\t\t\tasync function importRoutesData(routes) {\n\t\t\t\t\ttry {\n\t\t\t\t\t\tconst response = await fetch('/admin-ui/route_metadata/import', {\n\t\t\t\t\t\t\tmethod: 'POST',\n\t\t\t\t\t\t\theaders: {\n\t\t\t\t\t\t\t\t'Content-Type': 'application/json',\n\t\t\t\t\t\t\t},\n\t\t\t\t\t\t\tbody: JSON.stringify({ routes: routes })\n\t\t\t\t\t\t});\n\n\t\t\t\t\t\tif (response.ok) {\n\t\t\t\t\t\t\tconst result = await response.json();\n\t\t\t\t\t\t\talert(`Routes imported successfully! Imported: ${result.imported}, Total: ${result.total}. Refreshing page...`);\n\t\t\t\t\t\t\twindow.location.reload();\n\t\t\t\t\t\t} else {\n\t\t\t\t\t\t\tconst error = await response.json();\n\t\t\t\t\t\t\talert('Error importing routes: ' + (error.error || 'Unknown error'));\n\t\t\t\t\t\t}\n\t\t\t\t\t} catch (error) {\n\t\t\t\t\t\talert('Error importing routes: ' + error.message);\n\t\t\t\t\t}\n\t\t\t\t}\n\n\t\t\t\t// Attach event listeners on page load\n\t\t\t\tdocument.addEventListener('DOMContentLoaded', function() {\n\t\t\t\t\t// Toggle route details\n\t\t\t\t\tdocument.querySelectorAll('.toggle-route-btn').forEach(btn => {\n\t\t\t\t\t\tbtn.addEventListener('click', function() {\n\t\t\t\t\t\t\tconst routeId = this.getAttribute('data-toggle-route');\n\t\t\t\t\t\t\ttoggleRouteDetails(routeId);\n\t\t\t\t\t\t});\n\t\t\t\t\t});\n\n\t\t\t\t\t// Edit route\n\t\t\t\t\tdocument.querySelectorAll('.edit-route-btn').forEach(btn => {\n\t\t\t\t\t\tbtn.addEventListener('click', function() {\n\t\t\t\t\t\t\tconst routeIndex = this.getAttribute('data-edit-route');\n\t\t\t\t\t\t\topenEditModal(parseInt(routeIndex));\n\t\t\t\t\t\t});\n\t\t\t\t\t});\n\n\t\t\t\t\t// Delete route\n\t\t\t\t\tdocument.querySelectorAll('.delete-route-btn').forEach(btn => {\n\t\t\t\t\t\tbtn.addEventListener('click', function() {\n\t\t\t\t\t\t\tconst method = this.getAttribute('data-delete-method');\n\t\t\t\t\t\t\tconst path = this.getAttribute('data-delete-path');\n\t\t\t\t\t\t\tdeleteRoute(method, path);\n\t\t\t\t\t\t});\n\t\t\t\t\t});\n\t\t\t\t});\n\t\t\t</script></head><body class=\"bg-gray-100 dark:bg-gray-950 transition-colors\"><div class=\"min-h-screen flex flex-col\"><!-- Header --><header class=\"bg-white dark:bg-gray-900 shadow-sm border-b border-gray-200 dark:border-gray-700\"><div class=\"max-w-7xl mx-auto px-4 py-4 sm:px-6 lg:px-8 flex items-center justify-between\"><div class=\"flex items-center space-x-4\"><a href=\"/admin-ui/api_analytics\" class=\"text-gray-600 dark:text-gray-400 hover:text-gray-900 dark:hover:text-gray-200\"><i class=\"fas fa-arrow-left mr-2\"></i>Back to Analytics</a><h1 class=\"text-2xl font-bold text-gray-900 dark:text-gray-100\">Route Metadata Management</h1></div><div class=\"flex items-center space-x-4\"><span class=\"text-xs text-gray-500 dark:text-gray-400\">")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "<script>\n\t\t\t\t// Toggle route details\n\t\t\t\tfunction toggleRouteDetails(routeId) {\n\t\t\t\t\tconst details = document.getElementById('details-' + routeId);\n\t\t\t\t\tconst icon = document.getElementById('icon-' + routeId);\n\t\t\t\t\tif (details.classList.contains('hidden')) {\n\t\t\t\t\t\tdetails.classList.remove('hidden');\n\t\t\t\t\t\ticon.classList.add('rotate-90');\n\t\t\t\t\t} else {\n\t\t\t\t\t\tdetails.classList.add('hidden');\n\t\t\t\t\t\ticon.classList.remove('rotate-90');\n\t\t\t\t\t}\n\t\t\t\t}\n\n\t\t\t\t// Edit route modal\n\t\t\t\tlet editingRouteIndex = -1; // Track which route is being edited\n\n\t\t\t\tfunction openEditModal(routeIndex) {\n\t\t\t\t\teditingRouteIndex = routeIndex;\n\t\t\t\t\tconst modal = document.getElementById('edit-modal');\n\t\t\t\t\tmodal.classList.remove('hidden');\n\t\t\t\t\t// Populate form with route data\n\t\t\t\t\tif (routeIndex >= 0) {\n\t\t\t\t\t\tconst route = @data.Routes[routeIndex];\n\t\t\t\t\t\tdocument.getElementById('edit-path').value = route.Path;\n\t\t\t\t\t\tdocument.getElementById('edit-method').value = route.Method;\n\t\t\t\t\t\tdocument.getElementById('edit-description').value = route.Description;\n\t\t\t\t\t\tdocument.getElementById('edit-roles').value = route.AllowedRoles.join(', ');\n\t\t\t\t\t\tdocument.getElementById('edit-api-version').value = route.APIVersion;\n\t\t\t\t\t\tdocument.getElementById('edit-tags').value = route.Tags.join(', ');\n\t\t\t\t\t\tdocument.getElementById('edit-public').checked = route.IsPublic;\n\t\t\t\t\t\tdocument.getElementById('edit-ownership-check').checked = route.OwnershipCheck;\n\t\t\t\t\t\tdocument.getElementById('edit-audit-required').checked = route.AuditRequired;\n\t\t\t\t\t\tdocument.getElementById('edit-deprecated').checked = route.Deprecated;\n\t\t\t\t\t\tdocument.getElementById('edit-max-body-bytes').value = route.MaxBodyBytes || '';\n\t\t\t\t\t\tdocument.getElementById('edit-condition').value = route.Condition || '';\n\t\t\t\t\t\tdocument.getElementById('modal-title').textContent = 'Edit Route';\n\t\t\t\t\t} else {\n\t\t\t\t\t\t// New route - clear form\n\t\t\t\t\t\tdocument.getElementById('edit-path').value = '';\n\t\t\t\t\t\tdocument.getElementById('edit-method').value = 'GET';\n\t\t\t\t\t\tdocument.getElementById('edit-description').value = '';\n\t\t\t\t\t\tdocument.getElementById('edit-roles').value = '';\n\t\t\t\t\t\tdocument.getElementById('edit-api-version').value = 'v1';\n\t\t\t\t\t\tdocument.getElementById('edit-tags').value = '';\n\t\t\t\t\t\tdocument.getElementById('edit-public').checked = false;\n\t\t\t\t\t\tdocument.getElementById('edit-ownership-check').checked = false;\n\t\t\t\t\t\tdocument.getElementById('edit-audit-required').checked = false;\n\t\t\t\t\t\tdocument.getElementById('edit-deprecated').checked = false;\n\t\t\t\t\t\tdocument.getElementById('edit-max-body-bytes').value = '';\n\t\t\t\t\t\tdocument.getElementById('edit-condition').value = '';\n\t\t\t\t\t\tdocument.getElementById('modal-title').textContent = 'Add New Route';\n\t\t\t\t\t}\n\t\t\t\t}\n\n\t\t\t\tfunction closeEditModal() {\n\t\t\t\t\tdocument.getElementById('edit-modal').classList.add('hidden');\n\t\t\t\t\teditingRouteIndex = -1;\n\t\t\t\t}\n\n\t\t\t\t// Save route changes\n\t\t\t\tasync function saveRoute() {\n\t\t\t\t\tconst formData = new FormData(document.getElementById('edit-form'));\n\t\t\t\t\tconst routeData = {\n\t\t\t\t\t\tpath: formData.get('path'),\n\t\t\t\t\t\tmethod: formData.get('method'),\n\t\t\t\t\t\tdescription: formData.get('description'),\n\t\t\t\t\t\tallowed_roles: formData.get('roles').split(',').map(r => r.trim()).filter(r => r),\n\t\t\t\t\t\tapi_version: formData.get('api_version'),\n\t\t\t\t\t\ttags: formData.get('tags').split(',').map(t => t.trim()).filter(t => t),\n\t\t\t\t\t\tis_public: formData.get('public') === 'on',\n\t\t\t\t\t\townership_check: formData.get('ownership_check') === 'on',\n\t\t\t\t\t\taudit_required: formData.get('audit_required') === 'on',\n\t\t\t\t\t\tdeprecated: formData.get('deprecated') === 'on',\n\t\t\t\t\t\tmax_body_bytes: parseInt(formData.get('max_body_bytes') || '0', 10),\n\t\t\t\t\t\tcondition: (formData.get('condition') || '').trim()\n\t\t\t\t\t};\n\n\t\t\t\t\t// Validate required fields\n\t\t\t\t\tif (!routeData.path) {\n\t\t\t\t\t\talert('Path is required');\n\t\t\t\t\t\treturn;\n\t\t\t\t\t}\n\t\t\t\t\tif (!routeData.method) {\n\t\t\t\t\t\talert('Method is required');\n\t\t\t\t\t\treturn;\n\t\t\t\t\t}\n\t\t\t\t\tif (!routeData.api_version) {\n\t\t\t\t\t\talert('API Version is required');\n\t\t\t\t\t\treturn;\n\t\t\t\t\t}\n\n\t\t\t\t\ttry {\n\t\t\t\t\t\tconst response = await fetch('/admin-ui/route_metadata', {\n\t\t\t\t\t\t\tmethod: 'POST',\n\t\t\t\t\t\t\theaders: {\n\t\t\t\t\t\t\t\t'Content-Type': 'application/json',\n\t\t\t\t\t\t\t},\n\t\t\t\t\t\t\tbody: JSON.stringify({ routes: editingRouteIndex >= 0 ? \n\t\t\t\t\t\t\t\t@data.Routes.map((r, i) => i === editingRouteIndex ? routeData : {\n\t\t\t\t\t\t\t\t\tpath: r.Path,\n\t\t\t\t\t\t\t\t\tmethod: r.Method,\n\t\t\t\t\t\t\t\t\tdescription: r.Description,\n\t\t\t\t\t\t\t\t\tallowed_roles: r.AllowedRoles,\n\t\t\t\t\t\t\t\t\tapi_version: r.APIVersion,\n\t\t\t\t\t\t\t\t\ttags: r.Tags,\n\t\t\t\t\t\t\t\t\tis_public: r.IsPublic,\n\t\t\t\t\t\t\t\t\townership_check: r.OwnershipCheck,\n\t\t\t\t\t\t\t\t\taudit_required: r.AuditRequired,\n\t\t\t\t\t\t\t\t\tdeprecated: r.Deprecated,\n\t\t\t\t\t\t\t\t\tmax_body_bytes: r.MaxBodyBytes,\n\t\t\t\t\t\t\t\t\tcondition: r.Condition\n\t\t\t\t\t\t\t\t}) : [...@data.Routes.map(r => ({\n\t\t\t\t\t\t\t\t\tpath: r.Path,\n\t\t\t\t\t\t\t\t\tmethod: r.Method,\n\t\t\t\t\t\t\t\t\tdescription: r.Description,\n\t\t\t\t\t\t\t\t\tallowed_roles: r.AllowedRoles,\n\t\t\t\t\t\t\t\t\tapi_version: r.APIVersion,\n\t\t\t\t\t\t\t\t\ttags: r.Tags,\n\t\t\t\t\t\t\t\t\tis_public: r.IsPublic,\n\t\t\t\t\t\t\t\t\townership_check: r.OwnershipCheck,\n\t\t\t\t\t\t\t\t\taudit_required: r.AuditRequired,\n\t\t\t\t\t\t\t\t\tdeprecated: r.Deprecated,\n\t\t\t\t\t\t\t\t\tmax_body_bytes: r.MaxBodyBytes,\n\t\t\t\t\t\t\t\t\tcondition: r.Condition\n\t\t\t\t\t\t\t\t})), routeData]\n\t\t\t\t\t\t\t})\n\t\t\t\t\t\t});\n\n\t\t\t\t\t\tif (response.ok) {\n\t\t\t\t\t\t\talert('Route saved successfully! Refreshing page...');\n\t\t\t\t\t\t\tcloseEditModal();\n\t\t\t\t\t\t\twindow.location.reload();\n\t\t\t\t\t\t} else {\n\t\t\t\t\t\t\tconst error = await response.json();\n\t\t\t\t\t\t\talert('Error saving route: ' + (error.error || 'Unknown error'));\n\t\t\t\t\t\t}\n\t\t\t\t\t} catch (error) {\n\t\t\t\t\t\talert('Error saving route: ' + error.message);\n\t\t\t\t\t}\n\t\t\t\t}\n\n\t\t\t\t// Delete route\n\t\t\t\tasync function deleteRoute(methodText, pathText) {\n\t\t\t\t\tif (!confirm(`Are you sure you want to delete the route: ${methodText} ${pathText}?`)) {\n\t\t\t\t\t\treturn;\n\t\t\t\t\t}\n\n\t\t\t\t\ttry {\n\t\t\t\t\t\tconst response = await fetch('/admin-ui/route_metadata/delete', {\n\t\t\t\t\t\t\tmethod: 'POST',\n\t\t\t\t\t\t\theaders: {\n\t\t\t\t\t\t\t\t'Content-Type': 'application/json',\n\t\t\t\t\t\t\t},\n\t\t\t\t\t\t\tbody: JSON.stringify({\n\t\t\t\t\t\t\t\tmethod: methodText,\n\t\t\t\t\t\t\t\tpath: pathText\n\t\t\t\t\t\t\t})\n\t\t\t\t\t\t});\n\n\t\t\t\t\t\tif (response.ok) {\n\t\t\t\t\t\t\talert('Route deleted successfully! Refreshing page...');\n\t\t\t\t\t\t\twindow.location.reload();\n\t\t\t\t\t\t} else {\n\t\t\t\t\t\t\tconst error = await response.json();\n\t\t\t\t\t\t\talert('Error deleting route: ' + (error.error || 'Unknown error'));\n\t\t\t\t\t\t}\n\t\t\t\t\t} catch (error) {\n\t\t\t\t\t\talert('Error deleting route: ' + error.message);\n\t\t\t\t\t}\n\t\t\t\t}\n\n\t\t\t\t// Export routes as JSON\n\t\t\t\tfunction exportRoutes() {\n\t\t\t\t\tconst routes = @data.Routes;\n\t\t\t\t\tconst dataStr = JSON.stringify({routes: routes}, null, 2);\n\t\t\t\t\tconst dataUri = 'data:application/json;charset=utf-8,'+ encodeURIComponent(dataStr);\n\n\t\t\t\t\tconst exportFileDefaultName = 'enterprise_route_metadata.json';\n\n\t\t\t\t\tconst linkElement = document.createElement('a');\n\t\t\t\t\tlinkElement.setAttribute('href', dataUri);\n\t\t\t\t\tlinkElement.setAttribute('download', exportFileDefaultName);\n\t\t\t\t\tlinkElement.click();\n\t\t\t\t}\n\n\t\t\t\t// Import routes from JSON file\n\t\t\t\tfunction importRoutes(event) {\n\t\t\t\t\tconst file = event.target.files[0];\n\t\t\t\t\tif (file) {\n\t\t\t\t\t\tconst reader = new FileReader();\n\t\t\t\t\t\treader.onload = function(e) {\n\t\t\t\t\t\t\ttry {\n\t\t\t\t\t\t\t\tconst data = JSON.parse(e.target.result);\n\t\t\t\t\t\t\t\tif (data.routes && Array.isArray(data.routes)) {\n\t\t\t\t\t\t\t\t\timportRoutesData(data.routes);\n\t\t\t\t\t\t\t\t} else {\n\t\t\t\t\t\t\t\t\talert('Invalid file format. Expected {routes: [...]} structure.');\n\t\t\t\t\t\t\t\t}\n\t\t\t\t\t\t\t} catch (error) {\n\t\t\t\t\t\t\t\talert('Error parsing JSON file: ' + error.message);\n\t\t\t\t\t\t\t}\n\t\t\t\t\t\t};\n\t\t\t\t\t\treader.readAsText(file);\n\t\t\t\t\t}\n\t\t\t\t}\n\n\t\t\t\t// Send imported routes to server\n\t\t\t\tasync function importRoutesData(routes) {\n\t\t\t\t\ttry {\n\t\t\t\t\t\tconst response = await fetch('/admin-ui/route_metadata/import', {\n\t\t\t\t\t\t\tmethod: 'POST',\n\t\t\t\t\t\t\theaders: {\n\t\t\t\t\t\t\t\t'Content-Type': 'application/json',\n\t\t\t\t\t\t\t},\n\t\t\t\t\t\t\tbody: JSON.stringify({ routes: routes })\n\t\t\t\t\t\t});\n\n\t\t\t\t\t\tif (response.ok) {\n\t\t\t\t\t\t\tconst result = await response.json();\n\t\t\t\t\t\t\talert(`Routes imported successfully! Imported: ${result.imported}, Total: ${result.total}. Refreshing page...`);\n\t\t\t\t\t\t\twindow.location.reload();\n\t\t\t\t\t\t} else {\n\t\t\t\t\t\t\tconst error = await response.json();\n\t\t\t\t\t\t\talert('Error importing routes: ' + (error.error || 'Unknown error'));\n\t\t\t\t\t\t}\n\t\t\t\t\t} catch (error) {\n\t\t\t\t\t\talert('Error importing routes: ' + error.message);\n\t\t\t\t\t}\n\t\t\t\t}\n\n\t\t\t\t// Attach event listeners on page load\n\t\t\t\tdocument.addEventListener('DOMContentLoaded', function() {\n\t\t\t\t\t// Toggle route details\n\t\t\t\t\tdocument.querySelectorAll('.toggle-route-btn').forEach(btn => {\n\t\t\t\t\t\tbtn.addEventListener('click', function() {\n\t\t\t\t\t\t\tconst routeId = this.getAttribute('data-toggle-route');\n\t\t\t\t\t\t\ttoggleRouteDetails(routeId);\n\t\t\t\t\t\t});\n\t\t\t\t\t});\n\n\t\t\t\t\t// Edit route\n\t\t\t\t\tdocument.querySelectorAll('.edit-route-btn').forEach(btn => {\n\t\t\t\t\t\tbtn.addEventListener('click', function() {\n\t\t\t\t\t\t\tconst routeIndex = this.getAttribute('data-edit-route');\n\t\t\t\t\t\t\topenEditModal(parseInt(routeIndex));\n\t\t\t\t\t\t});\n\t\t\t\t\t});\n\n\t\t\t\t\t// Delete route\n\t\t\t\t\tdocument.querySelectorAll('.delete-route-btn').forEach(btn => {\n\t\t\t\t\t\tbtn.addEventListener('click', function() {\n\t\t\t\t\t\t\tconst method = this.getAttribute('data-delete-method');\n\t\t\t\t\t\t\tconst path = this.getAttribute('data-delete-path');\n\t\t\t\t\t\t\tdeleteRoute(method, path);\n\t\t\t\t\t\t});\n\t\t\t\t\t});\n\t\t\t\t});\n\t\t\t</script></head><body class=\"bg-gray-100 dark:bg-gray-950 transition-colors\"><div class=\"min-h-screen flex flex-col\"><!-- Header --><header class=\"bg-white dark:bg-gray-900 shadow-sm border-b border-gray-200 dark:border-gray-700\"><div class=\"max-w-7xl mx-auto px-4 py-4 sm:px-6 lg:px-8 flex items-center justify-between\"><div class=\"flex items-center space-x-4\"><a href=\"/admin-ui/api_analytics\" class=\"text-gray-600 dark:text-gray-400 hover:text-gray-900 dark:hover:text-gray-200\"><i class=\"fas fa-arrow-left mr-2\"></i>Back to Analytics</a><h1 class=\"text-2xl font-bold text-gray-900 dark:text-gray-100\">Route Metadata Management</h1></div><div class=\"flex items-center space-x-4\"><span class=\"text-xs text-gray-500 dark:text-gray-400\">")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
 		var templ_7745c5c3_Var2 string
 		templ_7745c5c3_Var2, templ_7745c5c3_Err = templ.JoinStringErrs(fmt.Sprintf("%d routes configured", len(data.Routes)))
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/route_metadata_management.templ`, Line: 288, Col: 63}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/route_metadata_management.templ`, Line: 303, Col: 63}
 		}
 		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var2))
 		if templ_7745c5c3_Err != nil {
@@ -82,7 +87,7 @@ func RouteMetadataManagementPage(data RouteMetadataManagementPageData) templ.Com
 			var templ_7745c5c3_Var3 string
 			templ_7745c5c3_Var3, templ_7745c5c3_Err = templ.JoinStringErrs(fmt.Sprintf("%d", i))
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/route_metadata_management.templ`, Line: 337, Col: 60}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/route_metadata_management.templ`, Line: 352, Col: 60}
 			}
 			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var3))
 			if templ_7745c5c3_Err != nil {
@@ -95,7 +100,7 @@ func RouteMetadataManagementPage(data RouteMetadataManagementPageData) templ.Com
 			var templ_7745c5c3_Var4 string
 			templ_7745c5c3_Var4, templ_7745c5c3_Err = templ.JoinStringErrs(fmt.Sprintf("icon-%d", i))
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/route_metadata_management.templ`, Line: 338, Col: 46}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/route_metadata_management.templ`, Line: 353, Col: 46}
 			}
 			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var4))
 			if templ_7745c5c3_Err != nil {
@@ -137,7 +142,7 @@ func RouteMetadataManagementPage(data RouteMetadataManagementPageData) templ.Com
 			var templ_7745c5c3_Var7 string
 			templ_7745c5c3_Var7, templ_7745c5c3_Err = templ.JoinStringErrs(route.Method)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/route_metadata_management.templ`, Line: 353, Col: 27}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/route_metadata_management.templ`, Line: 368, Col: 27}
 			}
 			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var7))
 			if templ_7745c5c3_Err != nil {
@@ -150,7 +155,7 @@ func RouteMetadataManagementPage(data RouteMetadataManagementPageData) templ.Com
 			var templ_7745c5c3_Var8 string
 			templ_7745c5c3_Var8, templ_7745c5c3_Err = templ.JoinStringErrs(route.Path)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/route_metadata_management.templ`, Line: 356, Col: 120}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/route_metadata_management.templ`, Line: 371, Col: 120}
 			}
 			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var8))
 			if templ_7745c5c3_Err != nil {
@@ -163,7 +168,7 @@ func RouteMetadataManagementPage(data RouteMetadataManagementPageData) templ.Com
 			var templ_7745c5c3_Var9 string
 			templ_7745c5c3_Var9, templ_7745c5c3_Err = templ.JoinStringErrs(route.Path)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/route_metadata_management.templ`, Line: 357, Col: 24}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/route_metadata_management.templ`, Line: 372, Col: 24}
 			}
 			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var9))
 			if templ_7745c5c3_Err != nil {
@@ -176,7 +181,7 @@ func RouteMetadataManagementPage(data RouteMetadataManagementPageData) templ.Com
 			var templ_7745c5c3_Var10 string
 			templ_7745c5c3_Var10, templ_7745c5c3_Err = templ.JoinStringErrs(route.Description)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/route_metadata_management.templ`, Line: 359, Col: 109}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/route_metadata_management.templ`, Line: 374, Col: 109}
 			}
 			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var10))
 			if templ_7745c5c3_Err != nil {
@@ -189,7 +194,7 @@ func RouteMetadataManagementPage(data RouteMetadataManagementPageData) templ.Com
 			var templ_7745c5c3_Var11 string
 			templ_7745c5c3_Var11, templ_7745c5c3_Err = templ.JoinStringErrs(route.Description)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/route_metadata_management.templ`, Line: 360, Col: 31}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/route_metadata_management.templ`, Line: 375, Col: 31}
 			}
 			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var11))
 			if templ_7745c5c3_Err != nil {
@@ -217,7 +222,7 @@ func RouteMetadataManagementPage(data RouteMetadataManagementPageData) templ.Com
 					var templ_7745c5c3_Var12 string
 					templ_7745c5c3_Var12, templ_7745c5c3_Err = templ.JoinStringErrs(role)
 					if templ_7745c5c3_Err != nil {
-						return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/route_metadata_management.templ`, Line: 369, Col: 22}
+						return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/route_metadata_management.templ`, Line: 384, Col: 22}
 					}
 					_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var12))
 					if templ_7745c5c3_Err != nil {
@@ -240,7 +245,7 @@ func RouteMetadataManagementPage(data RouteMetadataManagementPageData) templ.Com
 			var templ_7745c5c3_Var13 string
 			templ_7745c5c3_Var13, templ_7745c5c3_Err = templ.JoinStringErrs(route.APIVersion)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/route_metadata_management.templ`, Line: 376, Col: 30}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/route_metadata_management.templ`, Line: 391, Col: 30}
 			}
 			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var13))
 			if templ_7745c5c3_Err != nil {
@@ -268,7 +273,7 @@ func RouteMetadataManagementPage(data RouteMetadataManagementPageData) templ.Com
 			var templ_7745c5c3_Var14 string
 			templ_7745c5c3_Var14, templ_7745c5c3_Err = templ.JoinStringErrs(fmt.Sprintf("%d", i))
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/route_metadata_management.templ`, Line: 391, Col: 59}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/route_metadata_management.templ`, Line: 406, Col: 59}
 			}
 			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var14))
 			if templ_7745c5c3_Err != nil {
@@ -281,7 +286,7 @@ func RouteMetadataManagementPage(data RouteMetadataManagementPageData) templ.Com
 			var templ_7745c5c3_Var15 string
 			templ_7745c5c3_Var15, templ_7745c5c3_Err = templ.JoinStringErrs(route.Method)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/route_metadata_management.templ`, Line: 394, Col: 54}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/route_metadata_management.templ`, Line: 409, Col: 54}
 			}
 			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var15))
 			if templ_7745c5c3_Err != nil {
@@ -294,7 +299,7 @@ func RouteMetadataManagementPage(data RouteMetadataManagementPageData) templ.Com
 			var templ_7745c5c3_Var16 string
 			templ_7745c5c3_Var16, templ_7745c5c3_Err = templ.JoinStringErrs(route.Path)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/route_metadata_management.templ`, Line: 394, Col: 86}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/route_metadata_management.templ`, Line: 409, Col: 86}
 			}
 			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var16))
 			if templ_7745c5c3_Err != nil {
@@ -317,7 +322,7 @@ func RouteMetadataManagementPage(data RouteMetadataManagementPageData) templ.Com
 			var templ_7745c5c3_Var17 string
 			templ_7745c5c3_Var17, templ_7745c5c3_Err = templ.JoinStringErrs(fmt.Sprintf("details-%d", i))
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/route_metadata_management.templ`, Line: 405, Col: 47}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/route_metadata_management.templ`, Line: 420, Col: 47}
 			}
 			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var17))
 			if templ_7745c5c3_Err != nil {
@@ -345,7 +350,7 @@ func RouteMetadataManagementPage(data RouteMetadataManagementPageData) templ.Com
 					var templ_7745c5c3_Var18 string
 					templ_7745c5c3_Var18, templ_7745c5c3_Err = templ.JoinStringErrs(tag)
 					if templ_7745c5c3_Err != nil {
-						return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/route_metadata_management.templ`, Line: 416, Col: 23}
+						return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/route_metadata_management.templ`, Line: 431, Col: 23}
 					}
 					_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var18))
 					if templ_7745c5c3_Err != nil {
@@ -381,70 +386,168 @@ func RouteMetadataManagementPage(data RouteMetadataManagementPageData) templ.Com
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
+			if route.Condition != "" {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 40, "<div class=\"md:col-span-2 lg:col-span-3\"><strong class=\"text-gray-700 dark:text-gray-300\">Condition:</strong> <code class=\"block mt-1 px-2 py-1 bg-gray-200 dark:bg-gray-600 text-gray-800 dark:text-gray-200 text-xs rounded\">")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				var templ_7745c5c3_Var19 string
+				templ_7745c5c3_Var19, templ_7745c5c3_Err = templ.JoinStringErrs(route.Condition)
+				if templ_7745c5c3_Err != nil {
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/route_metadata_management.templ`, Line: 455, Col: 145}
+				}
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var19))
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 41, "</code> ")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				if !data.ConditionsEnforced {
+					templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 42, "<p class=\"text-xs text-yellow-600 dark:text-yellow-400 mt-1\">Not enforced: set CASBIN_MODEL_TYPE=abac to evaluate conditions</p>")
+					if templ_7745c5c3_Err != nil {
+						return templ_7745c5c3_Err
+					}
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 43, "</div>")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
 			if route.Deprecated {
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 40, "<div class=\"md:col-span-2 lg:col-span-1\"><strong class=\"text-gray-700 dark:text-gray-300\">Deprecation:</strong><div class=\"mt-1 text-red-600 dark:text-red-400\">")
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 44, "<div class=\"md:col-span-2 lg:col-span-1\"><strong class=\"text-gray-700 dark:text-gray-300\">Deprecation:</strong><div class=\"mt-1 text-red-600 dark:text-red-400\">")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
 				if route.DeprecatedReason != "" {
-					templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 41, "<p class=\"text-xs\">")
+					templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 45, "<p class=\"text-xs\">")
 					if templ_7745c5c3_Err != nil {
 						return templ_7745c5c3_Err
 					}
-					var templ_7745c5c3_Var19 string
-					templ_7745c5c3_Var19, templ_7745c5c3_Err = templ.JoinStringErrs(route.DeprecatedReason)
+					var templ_7745c5c3_Var20 string
+					templ_7745c5c3_Var20, templ_7745c5c3_Err = templ.JoinStringErrs(route.DeprecatedReason)
 					if templ_7745c5c3_Err != nil {
-						return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/route_metadata_management.templ`, Line: 442, Col: 60}
+						return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/route_metadata_management.templ`, Line: 466, Col: 60}
 					}
-					_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var19))
+					_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var20))
 					if templ_7745c5c3_Err != nil {
 						return templ_7745c5c3_Err
 					}
-					templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 42, "</p>")
+					templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 46, "</p>")
 					if templ_7745c5c3_Err != nil {
 						return templ_7745c5c3_Err
 					}
 				}
 				if route.ReplacedBy != "" {
-					templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 43, "<p class=\"text-xs mt-1\"><strong>Replaced by:</strong> ")
+					templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 47, "<p class=\"text-xs mt-1\"><strong>Replaced by:</strong> ")
 					if templ_7745c5c3_Err != nil {
 						return templ_7745c5c3_Err
 					}
-					var templ_7745c5c3_Var20 string
-					templ_7745c5c3_Var20, templ_7745c5c3_Err = templ.JoinStringErrs(route.ReplacedBy)
+					var templ_7745c5c3_Var21 string
+					templ_7745c5c3_Var21, templ_7745c5c3_Err = templ.JoinStringErrs(route.ReplacedBy)
 					if templ_7745c5c3_Err != nil {
-						return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/route_metadata_management.templ`, Line: 446, Col: 66}
+						return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/route_metadata_management.templ`, Line: 470, Col: 66}
 					}
-					_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var20))
+					_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var21))
 					if templ_7745c5c3_Err != nil {
 						return templ_7745c5c3_Err
 					}
-					templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 44, "</p>")
+					templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 48, "</p>")
 					if templ_7745c5c3_Err != nil {
 						return templ_7745c5c3_Err
 					}
 				}
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 45, "</div></div>")
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 49, "</div></div>")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 46, "</div></td></tr>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 50, "</div></td></tr>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 47, "</tbody></table>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 51, "</tbody></table>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
 		if len(data.Routes) == 0 {
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 48, "<div class=\"px-6 py-12 text-center text-gray-500 dark:text-gray-400\"><i class=\"fas fa-route text-4xl mb-4\"></i><p class=\"text-lg mb-2\">No routes configured</p><p class=\"text-sm\">Add your first route to get started with authorization management</p></div>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 52, "<div class=\"px-6 py-12 text-center text-gray-500 dark:text-gray-400\"><i class=\"fas fa-route text-4xl mb-4\"></i><p class=\"text-lg mb-2\">No routes configured</p><p class=\"text-sm\">Add your first route to get started with authorization management</p></div>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 53, "</div></div><!-- Footer --><div class=\"text-center text-xs text-gray-500 dark:text-gray-400 mt-8 pb-4\"><p>Route Metadata Management • Enterprise Authorization Framework</p><p class=\"mt-1\">Changes are saved to <code class=\"bg-gray-200 dark:bg-gray-700 px-1\">enterprise_route_metadata.json</code></p></div></main></div><!-- Edit Modal --><div id=\"edit-modal\" class=\"fixed inset-0 bg-black bg-opacity-50 hidden flex items-center justify-center z-50\"><div class=\"bg-white dark:bg-gray-800 rounded-lg shadow-xl max-w-2xl w-full mx-4 max-h-[90vh] overflow-y-auto\"><div class=\"p-6\"><div class=\"flex items-center justify-between mb-6\"><h3 id=\"modal-title\" class=\"text-lg font-semibold text-gray-900 dark:text-gray-100\">Edit Route</h3><button onclick=\"closeEditModal()\" class=\"text-gray-400 hover:text-gray-600 dark:hover:text-gray-300\"><i class=\"fas fa-times\"></i></button></div><form id=\"edit-form\" class=\"space-y-4\"><div class=\"grid grid-cols-1 md:grid-cols-2 gap-4\"><div><label class=\"block text-sm font-medium text-gray-700 dark:text-gray-300 mb-2\">HTTP Method</label> <select id=\"edit-method\" name=\"method\" class=\"w-full px-3 py-2 border border-gray-300 dark:border-gray-600 rounded-lg focus:ring-2 focus:ring-blue-500 dark:bg-gray-700 dark:text-gray-100\"><option value=\"GET\">GET</option> <option value=\"POST\">POST</option> <option value=\"PUT\">PUT</option> <option value=\"DELETE\">DELETE</option> <option value=\"PATCH\">PATCH</option> <option value=\"OPTIONS\">OPTIONS</option> <option value=\"HEAD\">HEAD</option></select></div><div><label class=\"block text-sm font-medium text-gray-700 dark:text-gray-300 mb-2\">API Version</label> <input type=\"text\" id=\"edit-api-version\" name=\"api_version\" placeholder=\"v1\" class=\"w-full px-3 py-2 border border-gray-300 dark:border-gray-600 rounded-lg focus:ring-2 focus:ring-blue-500 dark:bg-gray-700 dark:text-gray-100\"></div></div><div><label class=\"block text-sm font-medium text-gray-700 dark:text-gray-300 mb-2\">Path</label> <input type=\"text\" id=\"edit-path\" name=\"path\" placeholder=\"/api/v1/example\" class=\"w-full px-3 py-2 border border-gray-300 dark:border-gray-600 rounded-lg focus:ring-2 focus:ring-blue-500 dark:bg-gray-700 dark:text-gray-100\"></div><div><label class=\"block text-sm font-medium text-gray-700 dark:text-gray-300 mb-2\">Description</label> <input type=\"text\" id=\"edit-description\" name=\"description\" placeholder=\"Brief description of the endpoint\" class=\"w-full px-3 py-2 border border-gray-300 dark:border-gray-600 rounded-lg focus:ring-2 focus:ring-blue-500 dark:bg-gray-700 dark:text-gray-100\"></div><div><label class=\"block text-sm font-medium text-gray-700 dark:text-gray-300 mb-2\">Max Body Size (bytes)</label> <input type=\"number\" id=\"edit-max-body-bytes\" name=\"max_body_bytes\" min=\"0\" placeholder=\"No limit\" class=\"w-full px-3 py-2 border border-gray-300 dark:border-gray-600 rounded-lg focus:ring-2 focus:ring-blue-500 dark:bg-gray-700 dark:text-gray-100\"><p class=\"text-xs text-gray-500 dark:text-gray-400 mt-1\">Larger requests are rejected with 413 and audited</p></div><div><label class=\"block text-sm font-medium text-gray-700 dark:text-gray-300 mb-2\">Condition (ABAC)</label> <textarea id=\"edit-condition\" name=\"condition\" rows=\"2\" maxlength=\"")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		var templ_7745c5c3_Var22 string
+		templ_7745c5c3_Var22, templ_7745c5c3_Err = templ.JoinStringErrs(fmt.Sprint(abac.MaxConditionLength))
+		if templ_7745c5c3_Err != nil {
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/route_metadata_management.templ`, Line: 542, Col: 111}
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var22))
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 54, "\" placeholder=\"owner_id == user_id\" class=\"w-full px-3 py-2 border border-gray-300 dark:border-gray-600 rounded-lg focus:ring-2 focus:ring-blue-500 dark:bg-gray-700 dark:text-gray-100 font-mono text-sm\"></textarea><p class=\"text-xs text-gray-500 dark:text-gray-400 mt-1\">Optional expression that must hold in addition to the role check. ")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		if !data.ConditionsEnforced {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 55, "Only evaluated when CASBIN_MODEL_TYPE=abac.")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 56, "</p>")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		if len(data.ConditionAttributes) > 0 {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 57, "<details class=\"mt-1 text-xs text-gray-500 dark:text-gray-400\"><summary class=\"cursor-pointer\">Available attributes</summary><ul class=\"mt-1 space-y-1\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			for _, attr := range data.ConditionAttributes {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 58, "<li><code class=\"bg-gray-200 dark:bg-gray-700 px-1\">")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				var templ_7745c5c3_Var23 string
+				templ_7745c5c3_Var23, templ_7745c5c3_Err = templ.JoinStringErrs(attr.Name)
+				if templ_7745c5c3_Err != nil {
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/route_metadata_management.templ`, Line: 554, Col: 75}
+				}
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var23))
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 59, "</code> ")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				var templ_7745c5c3_Var24 string
+				templ_7745c5c3_Var24, templ_7745c5c3_Err = templ.JoinStringErrs(attr.Description)
+				if templ_7745c5c3_Err != nil {
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `application/templates/route_metadata_management.templ`, Line: 554, Col: 103}
+				}
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var24))
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 60, "</li>")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 61, "</ul></details>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 49, "</div></div><!-- Footer --><div class=\"text-center text-xs text-gray-500 dark:text-gray-400 mt-8 pb-4\"><p>Route Metadata Management • Enterprise Authorization Framework</p><p class=\"mt-1\">Changes are saved to <code class=\"bg-gray-200 dark:bg-gray-700 px-1\">enterprise_route_metadata.json</code></p></div></main></div><!-- Edit Modal --><div id=\"edit-modal\" class=\"fixed inset-0 bg-black bg-opacity-50 hidden flex items-center justify-center z-50\"><div class=\"bg-white dark:bg-gray-800 rounded-lg shadow-xl max-w-2xl w-full mx-4 max-h-[90vh] overflow-y-auto\"><div class=\"p-6\"><div class=\"flex items-center justify-between mb-6\"><h3 id=\"modal-title\" class=\"text-lg font-semibold text-gray-900 dark:text-gray-100\">Edit Route</h3><button onclick=\"closeEditModal()\" class=\"text-gray-400 hover:text-gray-600 dark:hover:text-gray-300\"><i class=\"fas fa-times\"></i></button></div><form id=\"edit-form\" class=\"space-y-4\"><div class=\"grid grid-cols-1 md:grid-cols-2 gap-4\"><div><label class=\"block text-sm font-medium text-gray-700 dark:text-gray-300 mb-2\">HTTP Method</label> <select id=\"edit-method\" name=\"method\" class=\"w-full px-3 py-2 border border-gray-300 dark:border-gray-600 rounded-lg focus:ring-2 focus:ring-blue-500 dark:bg-gray-700 dark:text-gray-100\"><option value=\"GET\">GET</option> <option value=\"POST\">POST</option> <option value=\"PUT\">PUT</option> <option value=\"DELETE\">DELETE</option> <option value=\"PATCH\">PATCH</option> <option value=\"OPTIONS\">OPTIONS</option> <option value=\"HEAD\">HEAD</option></select></div><div><label class=\"block text-sm font-medium text-gray-700 dark:text-gray-300 mb-2\">API Version</label> <input type=\"text\" id=\"edit-api-version\" name=\"api_version\" placeholder=\"v1\" class=\"w-full px-3 py-2 border border-gray-300 dark:border-gray-600 rounded-lg focus:ring-2 focus:ring-blue-500 dark:bg-gray-700 dark:text-gray-100\"></div></div><div><label class=\"block text-sm font-medium text-gray-700 dark:text-gray-300 mb-2\">Path</label> <input type=\"text\" id=\"edit-path\" name=\"path\" placeholder=\"/api/v1/example\" class=\"w-full px-3 py-2 border border-gray-300 dark:border-gray-600 rounded-lg focus:ring-2 focus:ring-blue-500 dark:bg-gray-700 dark:text-gray-100\"></div><div><label class=\"block text-sm font-medium text-gray-700 dark:text-gray-300 mb-2\">Description</label> <input type=\"text\" id=\"edit-description\" name=\"description\" placeholder=\"Brief description of the endpoint\" class=\"w-full px-3 py-2 border border-gray-300 dark:border-gray-600 rounded-lg focus:ring-2 focus:ring-blue-500 dark:bg-gray-700 dark:text-gray-100\"></div><div class=\"grid grid-cols-1 md:grid-cols-2 gap-4\"><div><label class=\"block text-sm font-medium text-gray-700 dark:text-gray-300 mb-2\">Allowed Roles</label> <input type=\"text\" id=\"edit-roles\" name=\"roles\" placeholder=\"admin, staff, user\" class=\"w-full px-3 py-2 border border-gray-300 dark:border-gray-600 rounded-lg focus:ring-2 focus:ring-blue-500 dark:bg-gray-700 dark:text-gray-100\"><p class=\"text-xs text-gray-500 dark:text-gray-400 mt-1\">Comma-separated list</p></div><div><label class=\"block text-sm font-medium text-gray-700 dark:text-gray-300 mb-2\">Tags</label> <input type=\"text\" id=\"edit-tags\" name=\"tags\" placeholder=\"admin, authentication, api\" class=\"w-full px-3 py-2 border border-gray-300 dark:border-gray-600 rounded-lg focus:ring-2 focus:ring-blue-500 dark:bg-gray-700 dark:text-gray-100\"><p class=\"text-xs text-gray-500 dark:text-gray-400 mt-1\">Comma-separated list</p></div></div><div class=\"flex flex-wrap gap-4\"><label class=\"flex items-center\"><input type=\"checkbox\" id=\"edit-public\" name=\"public\" class=\"rounded border-gray-300 dark:border-gray-600 text-blue-600 focus:ring-blue-500\"> <span class=\"ml-2 text-sm text-gray-700 dark:text-gray-300\">Public Route</span></label> <label class=\"flex items-center\"><input type=\"checkbox\" id=\"edit-ownership-check\" name=\"ownership_check\" class=\"rounded border-gray-300 dark:border-gray-600 text-blue-600 focus:ring-blue-500\"> <span class=\"ml-2 text-sm text-gray-700 dark:text-gray-300\">Ownership Check</span></label> <label class=\"flex items-center\"><input type=\"checkbox\" id=\"edit-audit-required\" name=\"audit_required\" class=\"rounded border-gray-300 dark:border-gray-600 text-blue-600 focus:ring-blue-500\"> <span class=\"ml-2 text-sm text-gray-700 dark:text-gray-300\">Audit Required</span></label> <label class=\"flex items-center\"><input type=\"checkbox\" id=\"edit-deprecated\" name=\"deprecated\" class=\"rounded border-gray-300 dark:border-gray-600 text-blue-600 focus:ring-blue-500\"> <span class=\"ml-2 text-sm text-gray-700 dark:text-gray-300\">Deprecated</span></label></div><div class=\"flex justify-end gap-3 pt-4\"><button type=\"button\" onclick=\"closeEditModal()\" class=\"px-4 py-2 text-gray-600 dark:text-gray-400 hover:text-gray-800 dark:hover:text-gray-200\">Cancel</button> <button type=\"button\" onclick=\"saveRoute()\" class=\"bg-blue-600 hover:bg-blue-700 text-white px-4 py-2 rounded-lg\">Save Route</button></div></form></div></div></div></body></html>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 62, "</div><div class=\"grid grid-cols-1 md:grid-cols-2 gap-4\"><div><label class=\"block text-sm font-medium text-gray-700 dark:text-gray-300 mb-2\">Allowed Roles</label> <input type=\"text\" id=\"edit-roles\" name=\"roles\" placeholder=\"admin, staff, user\" class=\"w-full px-3 py-2 border border-gray-300 dark:border-gray-600 rounded-lg focus:ring-2 focus:ring-blue-500 dark:bg-gray-700 dark:text-gray-100\"><p class=\"text-xs text-gray-500 dark:text-gray-400 mt-1\">Comma-separated list</p></div><div><label class=\"block text-sm font-medium text-gray-700 dark:text-gray-300 mb-2\">Tags</label> <input type=\"text\" id=\"edit-tags\" name=\"tags\" placeholder=\"admin, authentication, api\" class=\"w-full px-3 py-2 border border-gray-300 dark:border-gray-600 rounded-lg focus:ring-2 focus:ring-blue-500 dark:bg-gray-700 dark:text-gray-100\"><p class=\"text-xs text-gray-500 dark:text-gray-400 mt-1\">Comma-separated list</p></div></div><div class=\"flex flex-wrap gap-4\"><label class=\"flex items-center\"><input type=\"checkbox\" id=\"edit-public\" name=\"public\" class=\"rounded border-gray-300 dark:border-gray-600 text-blue-600 focus:ring-blue-500\"> <span class=\"ml-2 text-sm text-gray-700 dark:text-gray-300\">Public Route</span></label> <label class=\"flex items-center\"><input type=\"checkbox\" id=\"edit-ownership-check\" name=\"ownership_check\" class=\"rounded border-gray-300 dark:border-gray-600 text-blue-600 focus:ring-blue-500\"> <span class=\"ml-2 text-sm text-gray-700 dark:text-gray-300\">Ownership Check</span></label> <label class=\"flex items-center\"><input type=\"checkbox\" id=\"edit-audit-required\" name=\"audit_required\" class=\"rounded border-gray-300 dark:border-gray-600 text-blue-600 focus:ring-blue-500\"> <span class=\"ml-2 text-sm text-gray-700 dark:text-gray-300\">Audit Required</span></label> <label class=\"flex items-center\"><input type=\"checkbox\" id=\"edit-deprecated\" name=\"deprecated\" class=\"rounded border-gray-300 dark:border-gray-600 text-blue-600 focus:ring-blue-500\"> <span class=\"ml-2 text-sm text-gray-700 dark:text-gray-300\">Deprecated</span></label></div><div class=\"flex justify-end gap-3 pt-4\"><button type=\"button\" onclick=\"closeEditModal()\" class=\"px-4 py-2 text-gray-600 dark:text-gray-400 hover:text-gray-800 dark:hover:text-gray-200\">Cancel</button> <button type=\"button\" onclick=\"saveRoute()\" class=\"bg-blue-600 hover:bg-blue-700 text-white px-4 py-2 rounded-lg\">Save Route</button></div></form></div></div></div></body></html>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -1,19 +1,34 @@
 package azf
 
 import (
-	"os"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
 
 	"github.com/aruncs31s/azf/application/handler"
 	"github.com/aruncs31s/azf/application/middleware"
 	"github.com/aruncs31s/azf/application/service"
 	"github.com/aruncs31s/azf/config"
+	"github.com/aruncs31s/azf/domain/api_usage"
+	"github.com/aruncs31s/azf/domain/model"
+	"github.com/aruncs31s/azf/domain/repository"
+	usermodel "github.com/aruncs31s/azf/domain/user_management/model"
+	"github.com/aruncs31s/azf/infrastructure/analytics"
 	"github.com/aruncs31s/azf/infrastructure/enterprise"
-	"github.com/aruncs31s/azf/infrastructure/persistence"
+	"github.com/aruncs31s/azf/infrastructure/metrics"
+	"github.com/aruncs31s/azf/infrastructure/notification"
+	"github.com/aruncs31s/azf/infrastructure/token"
+	"github.com/aruncs31s/azf/infrastructure/tracing"
+	"github.com/aruncs31s/azf/infrastructure/webhook"
 	"github.com/aruncs31s/azf/initializer"
+	"github.com/aruncs31s/azf/internal/persistence"
 	"github.com/aruncs31s/azf/shared/logger"
 	"github.com/aruncs31s/azf/utils"
 	"github.com/casbin/casbin/v2"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 	"golang.org/x/time/rate"
 	"gorm.io/gorm"
@@ -24,6 +39,89 @@ import (
 // It is used to centralize DB/enforcer access while still providing compatibility.
 var mgr *initializer.Manager
 
+// policyRedis carries policy change announcements between instances; nil
+// unless the policy watcher is enabled
+var policyRedis *redis.Client
+
+// statusService backs both the public status page and the admin incident API,
+// so an incident set from the admin UI shows up on /status.
+var statusService service.StatusService
+
+// rateLimitOverrideService is shared by the enterprise rate limiter and the
+// admin UI so overrides saved from the UI apply immediately.
+var rateLimitOverrideService service.RateLimitOverrideService
+
+// usageQuotaService is shared by the enterprise middleware and the admin UI
+// so quotas saved from the UI apply immediately
+var usageQuotaService service.UsageQuotaService
+
+// usageQuotaScheduler writes counted quota usage and resets quotas whose
+// period ended; nil when the database is not available
+var usageQuotaScheduler *service.UsageQuotaScheduler
+
+// roleSyncScheduler reconciles user roles with the grouping policies every
+// ROLE_SYNC_INTERVAL; nil when disabled or the database is not available
+var roleSyncScheduler *service.RoleSyncScheduler
+
+// userPurgeScheduler purges users deleted longer than USER_DELETION_RETENTION
+// ago; nil when disabled or the database is not available
+var userPurgeScheduler *service.UserPurgeScheduler
+
+// apiKeyService authenticates X-API-Key requests and backs the admin UI,
+// so revoked keys are rejected immediately
+var apiKeyService service.APIKeyService
+
+// webPushSender delivers alerts to admin browsers; nil when web push is disabled
+var webPushSender *notification.WebPushSender
+
+// webPushService manages the admins' browser push subscriptions
+var webPushService service.WebPushService
+
+// incidentManager opens PagerDuty/Opsgenie incidents for critical alerts; nil
+// when no on-call provider is configured
+var incidentManager *notification.IncidentManager
+
+// incidentService backs the notification center
+var incidentService service.IncidentService
+
+// featureFlagService switches AZF's own subsystems at runtime
+var featureFlagService service.FeatureFlagService
+
+// adminModeService holds the read-only switch of the admin surface
+var adminModeService service.AdminModeService
+
+// approvalService holds the superadmin approvals of role changes that would
+// lock an admin out
+var approvalService service.ApprovalService
+
+// policySandboxService holds the staged policy changes of each admin
+var policySandboxService service.PolicySandboxService
+
+// webhookService manages webhook subscriptions for authorization events
+var webhookService service.WebhookService
+
+// webhookWorker delivers pending webhook events and retries failed ones; nil
+// when the database is not available
+var webhookWorker *webhook.Worker
+
+// webhookOutbox stages the webhook events of authorization audit logs in the
+// transaction that saves them; nil when the database is not available
+var webhookOutbox *webhook.Outbox
+
+// readOnlyExemptPaths stay writable in read-only mode: signing in, refreshing
+// tokens, turning the mode off, incident response tools (status banner,
+// incident sync, push alerts) and the policy simulator, which saves nothing
+var readOnlyExemptPaths = []string{
+	"/admin-ui/login",
+	"/admin-ui/saml/acs",
+	"/admin-ui/token/refresh",
+	"/admin-ui/api/read-only",
+	"/admin-ui/api/status/incident",
+	"/admin-ui/api/incidents/sync",
+	"/admin-ui/api/push/",
+	"/admin-ui/api/policies/simulate",
+}
+
 // InitAuthZModule Initializes new Authorization Instance , of the AZF AuthZ Framework
 //
 // Params:
@@ -54,9 +152,14 @@ func InitAuthZModule(
 		initializer.CasbinEnforcer = mgr.Enforcer
 	}
 
+	initNotifications(mgr.DB)
+	initWebhooks(mgr.DB)
+	middleware.SetAPIKeyAuthenticator(getAPIKeyService().Authenticate)
+
+	policyRedis = newPolicyRedis()
 	err = enterprise.IniAuthorization(
 		mgr.DB,
-		nil,
+		policyRedis,
 		mgr.Enforcer,
 		logger.GetLogger(),
 	)
@@ -66,11 +169,90 @@ func InitAuthZModule(
 
 	if enterprise.EnterpriseAuth != nil {
 		enterprise.RegisterEnterpriseRouteMetadata(enterprise.EnterpriseAuth)
+		enterprise.EnterpriseAuth.SetRateLimitOverrides(getRateLimitOverrideService())
+		enterprise.EnterpriseAuth.SetUsageQuotas(getUsageQuotaService())
+		enterprise.EnterpriseAuth.SetFeatureFlags(getFeatureFlagService())
+		if webhookOutbox != nil {
+			enterprise.EnterpriseAuth.SetWebhookOutbox(webhookOutbox)
+		}
 	} else {
 		logger.Warn("Enterprise authorization setup not available, running in compatibility mode")
 	}
 }
 
+// newPolicyRedis connects to REDIS_URL when the policy watcher is enabled
+func newPolicyRedis() *redis.Client {
+	if !config.CasbinPolicyWatcher() {
+		return nil
+	}
+	opts, err := redis.ParseURL(config.RedisURL())
+	if err != nil {
+		logger.Warn("Policy watcher needs a valid REDIS_URL", zap.Error(err))
+		return nil
+	}
+	return redis.NewClient(opts)
+}
+
+// initNotifications sets up the shared alert notifier. Browser push to admins
+// is added when web push is enabled and the database is available, and
+// incidents are opened when PagerDuty or Opsgenie is configured.
+func initNotifications(db *gorm.DB) {
+	cfg := config.GetNotificationConfig()
+	channels := notification.NewChannels(cfg)
+	for i, channel := range channels {
+		if channel.Name() == "webhook" || channel.Name() == "slack" {
+			channels[i] = notification.NewSwitchedChannel(channel, webhooksEnabled)
+		}
+	}
+
+	if cfg.WebPush.Enabled && db != nil {
+		repo := persistence.NewWebPushSubscriptionRepository(db)
+		sender, err := notification.LoadWebPushSender(cfg.WebPush, repo, &http.Client{Timeout: cfg.Timeout})
+		if err != nil {
+			logger.Warn("Web push notifications disabled", zap.Error(err))
+		} else {
+			webPushSender = sender
+			channels = append(channels, notification.NewWebPushChannel(sender, repo))
+		}
+	}
+
+	providers := notification.NewIncidentProviders(cfg.Incidents, &http.Client{Timeout: cfg.Timeout})
+	if len(providers) > 0 {
+		if db == nil {
+			logger.Warn("Incident integrations disabled: database is not available")
+		} else {
+			incidentManager = notification.NewIncidentManager(
+				cfg.Incidents, providers, persistence.NewAlertIncidentRepository(db), cfg.Timeout,
+			)
+			channels = append(channels, incidentManager)
+		}
+	}
+
+	notification.SetDefault(notification.NewNotifier(cfg, channels))
+}
+
+// initWebhooks sets up webhook delivery of authorization events and starts
+// the worker that retries failed deliveries
+func initWebhooks(db *gorm.DB) {
+	if db == nil {
+		webhookService = service.NewWebhookService(nil, nil, nil, nil)
+		return
+	}
+
+	events := persistence.NewWebhookEventRepository(db)
+	subscriptions := persistence.NewWebhookSubscriptionRepository(db)
+	deliveries := persistence.NewWebhookDeliveryRepository(db)
+	dispatcher := webhook.NewDispatcher(events, subscriptions, deliveries, nil)
+	webhookService = service.NewWebhookService(
+		service.NewWebhookManager(subscriptions, dispatcher),
+		dispatcher,
+		events,
+		deliveries,
+	)
+	webhookOutbox = webhook.NewOutbox(events, subscriptions)
+	webhookWorker = webhook.StartWorker(dispatcher, webhook.DefaultWorkerInterval)
+}
+
 func InitUsageTracking() {
 	// Create API Usage Tracking Repo; prefer manager DB if available
 	var db *gorm.DB
@@ -85,10 +267,14 @@ func InitUsageTracking() {
 		return
 	}
 
-	apiUsageRepo := persistence.NewAPIUsageRepository(db)
+	// The storage backend (GORM or ClickHouse) is selected by USAGE_ANALYTICS_BACKEND
+	apiUsageRepo := analytics.Default(db).Logs()
 
 	// Initialize the UsageTracking Middleware
 	middleware.InitAPIUsageTracking(apiUsageRepo)
+	middleware.SetUsageTrackingSwitch(func() bool {
+		return getFeatureFlagService().IsEnabled(api_usage.FeatureUsageTracking, true)
+	})
 
 }
 func SetApiTrackingMiddleware(r *gin.Engine) *gin.Engine {
@@ -106,6 +292,31 @@ func SetAuthZMiddleware(r *gin.Engine) *gin.Engine {
 	return r
 }
 
+// SetCORSMiddleware applies the CORS policy configured by the CORS_*
+// settings, with the overrides in the route metadata. Use it before the
+// other middleware so preflight requests are answered first.
+func SetCORSMiddleware(r *gin.Engine) *gin.Engine {
+	policy := middleware.NewCORSConfig(config.GetCORSConfig())
+	if len(policy.AllowedOrigins) == 0 {
+		logger.Warn("CORS_ALLOWED_ORIGINS is not set, cross-origin requests are only allowed on routes that allow their origin")
+	}
+	r.Use(middleware.RouteCORSMiddleware(policy, func(c *gin.Context, method string) *middleware.CORSConfig {
+		if enterprise.EnterpriseAuth == nil {
+			return nil
+		}
+		return enterprise.EnterpriseAuth.GetRouteRegistry().CORSResolver(policy)(c, method)
+	}))
+	return r
+}
+
+// SetResponseMetaMiddleware adds "meta" with the request ID, API version,
+// authorization mode and response time to every JSON object response. Use
+// it before the other middleware so the responses they abort with get meta too.
+func SetResponseMetaMiddleware(r *gin.Engine) *gin.Engine {
+	r.Use(middleware.ResponseMetaMiddleware())
+	return r
+}
+
 func SetRateLimitMiddleware(r *gin.Engine, requestsPerSecond float64, burst int) *gin.Engine {
 	limiter := middleware.NewIPRateLimiter(rate.Limit(requestsPerSecond), burst)
 	r.Use(middleware.RateLimitMiddleware(limiter))
@@ -119,6 +330,35 @@ func StopAuthZModule() {
 	if enterprise.EnterpriseAuth != nil {
 		enterprise.EnterpriseAuth.Stop()
 	}
+	if webhookWorker != nil {
+		webhookWorker.Stop()
+		webhookWorker = nil
+	}
+	// Write the quota usage counted since the last sync
+	if usageQuotaScheduler != nil {
+		usageQuotaScheduler.Stop()
+		usageQuotaScheduler = nil
+	}
+	if roleSyncScheduler != nil {
+		roleSyncScheduler.Stop()
+		roleSyncScheduler = nil
+	}
+	if userPurgeScheduler != nil {
+		userPurgeScheduler.Stop()
+		userPurgeScheduler = nil
+	}
+	if policyRedis != nil {
+		_ = policyRedis.Close()
+		policyRedis = nil
+	}
+	// Send any pending alert digest
+	if err := notification.Close(); err != nil {
+		logger.Warn("Failed to close alert notifier", zap.Error(err))
+	}
+	// Flush buffered usage logs before the database goes away
+	if err := analytics.Close(); err != nil {
+		logger.Warn("Failed to close usage analytics backend", zap.Error(err))
+	}
 	// Close manager resources (DB) if created
 	if mgr != nil {
 		_ = mgr.Close()
@@ -137,33 +377,67 @@ func GetLogger() *zap.Logger {
 }
 func SetupUI(r *gin.Engine) *gin.Engine {
 	configProvider, _ := config.NewAdminConfigProvider()
-	apiPerfHandler := handler.NewPerformanceHandler(configProvider)
+	adminHandlers := mustHandler(handler.NewAdminHandlers(configProvider, getApprovalService()))
+	// Admin session cookies are checked against the session store, so revoked
+	// and expired sessions are signed out on every instance
+	if adminHandlers.SessionService != nil {
+		middleware.SetAdminSessionValidator(adminHandlers.SessionService.Validate)
+	}
+	// Repair drift from policy changes made outside the admin UI
+	if adminHandlers.RoleSync != nil && config.RoleSyncInterval() > 0 && roleSyncScheduler == nil {
+		roleSyncScheduler = service.StartRoleSyncScheduler(adminHandlers.RoleSync, config.RoleSyncMode(), config.RoleSyncInterval())
+	}
+	// Deleted users can be restored until their retention ends
+	if adminHandlers.UserManagement != nil && config.UserDeletionRetention() > 0 && userPurgeScheduler == nil {
+		userPurgeScheduler = service.StartUserPurgeScheduler(adminHandlers.UserManagement, config.UserDeletionRetention(), time.Hour)
+	}
 
 	// Initialize rate limiting manager
 	rateLimitManager := handler.NewRateLimitManager(10, 20) // 10 requests/second, burst 20
-	rateLimitHandler := handler.NewRateLimitHandler(rateLimitManager)
+	rateLimitHandler := mustHandler(handler.NewRateLimitHandler(rateLimitManager))
+	if enterprise.EnterpriseAuth != nil {
+		rateLimitHandler.SetBucketSource(enterprise.EnterpriseAuth)
+		if limiter := enterprise.EnterpriseAuth.GetRateLimiter(); limiter != nil {
+			rateLimitHandler.SetBlocker(limiter)
+		}
+	}
 
 	// Initialize OAuth service and handler if user repository is available
 	var oauthHandler *handler.OAuthHandler
 	if mgr != nil && mgr.DB != nil {
 		userRepo := persistence.NewUserRepository(mgr.DB)
+		if configProvider != nil {
+			bootstrapAdminUser(configProvider, userRepo, service.NewUnitOfWork(persistence.NewTransactionManager(mgr.DB)))
+		}
 		baseURL := "http://localhost:8080" // default
 		if envURL, err := utils.GetEnv("BASE_URL"); err == nil {
 			baseURL = envURL
 		}
-		// Get JWT secret from environment - fail gracefully if not set
-		jwtSecret := os.Getenv("JWT_SECRET")
-		if jwtSecret == "" {
-			logger.Warn("JWT_SECRET not set, OAuth will not be available")
+		// OAuth sign-in issues azf tokens - fail gracefully if they cannot be signed
+		tokens, err := token.Default()
+		if err != nil {
+			logger.Warn("JWT signing not configured, OAuth will not be available", zap.Error(err))
 		} else {
-			oauthService := service.NewOAuthService(userRepo, baseURL, jwtSecret)
-			oauthHandler = handler.NewOAuthHandler(oauthService)
+			oauthService := service.NewOAuthService(userRepo, baseURL, tokens)
+			oauthHandler = mustHandler(handler.NewOAuthHandler(oauthService))
 		}
 	}
 
-	r.GET("/admin-ui/login", apiPerfHandler.GetLoginPage)
+	// Admin requests are limited separately from application traffic
+	adminLimits := config.GetAdminRateLimitConfig()
+	if adminLimits.Enabled {
+		r.Use(middleware.AdminRateLimitMiddleware(
+			middleware.NewAdminRateLimiter(rate.Limit(adminLimits.RequestsPerSecond), adminLimits.Burst),
+			middleware.NewAdminRateLimiter(rate.Limit(adminLimits.LoginPerMinute/60), adminLimits.LoginBurst),
+			adminIdentity,
+		))
+	}
+
+	// Read-only mode rejects admin writes during incidents and change freezes
+	r.Use(middleware.AdminReadOnlyMiddleware(getAdminModeService().IsReadOnly, readOnlyExemptPaths, recordBlockedAdminWrite))
 
-	r.POST("/admin-ui/login/json", apiPerfHandler.LoginJSON)
+	// Sign-in, dashboard pages, analytics, route metadata, roles and policies, audit logs
+	adminHandlers.RegisterRoutes(r, middleware.CheckAdminAuth())
 
 	// OAuth routes
 	if oauthHandler != nil {
@@ -172,27 +446,32 @@ func SetupUI(r *gin.Engine) *gin.Engine {
 		r.GET("/admin-ui/oauth/providers", oauthHandler.GetProviders)
 	}
 
-	r.GET("", middleware.CheckAdminAuth(), apiPerfHandler.GetHomePage)
-	r.GET("/admin-ui", middleware.CheckAdminAuth(), apiPerfHandler.GetHomePage)
-	r.GET("/admin-ui/api_analytics", middleware.CheckAdminAuth(), apiPerfHandler.GetAPIAnalyticsPage)
-	r.GET("/admin-ui/api_analytics/endpoint", middleware.CheckAdminAuth(), apiPerfHandler.GetEndpointDetailsPage)
-	r.GET("/admin-ui/route_metadata", middleware.CheckAdminAuth(), apiPerfHandler.GetRouteMetadataManagementPage)
-	r.POST("/admin-ui/route_metadata", middleware.CheckAdminAuth(), apiPerfHandler.SaveRouteMetadata)
-	r.POST("/admin-ui/route_metadata/import", middleware.CheckAdminAuth(), apiPerfHandler.ImportRouteMetadata)
-	r.POST("/admin-ui/route_metadata/delete", middleware.CheckAdminAuth(), apiPerfHandler.DeleteRouteMetadata)
-	r.GET("/admin-ui/roles", middleware.CheckAdminAuth(), apiPerfHandler.GetRoleManagementPage)
-	r.GET("/admin-ui/roles/:role", middleware.CheckAdminAuth(), apiPerfHandler.GetRoleDetailsPage)
-	r.GET("/admin-ui/policies", middleware.CheckAdminAuth(), apiPerfHandler.GetPolicyManagementPage)
-	r.GET("/admin-ui/audit_logs", middleware.CheckAdminAuth(), apiPerfHandler.GetAuditLogsPage)
-	r.GET("/admin-ui/features", middleware.CheckAdminAuth(), apiPerfHandler.GetFeaturesDocumentationPage)
-
-	// Role management API endpoints
-	r.POST("/admin-ui/api/roles", middleware.CheckAdminAuth(), apiPerfHandler.CreateRole)
-	r.PUT("/admin-ui/api/roles", middleware.CheckAdminAuth(), apiPerfHandler.UpdateRole)
-	r.POST("/admin-ui/api/roles/assign", middleware.CheckAdminAuth(), apiPerfHandler.AssignRoleToUser)
-	r.POST("/admin-ui/api/roles/remove", middleware.CheckAdminAuth(), apiPerfHandler.RemoveRoleFromUser)
-	r.GET("/admin-ui/api/roles/users", middleware.CheckAdminAuth(), apiPerfHandler.GetUsersForRole)
-	r.POST("/admin-ui/api/roles/delete", middleware.CheckAdminAuth(), apiPerfHandler.DeleteRole)
+	// Audit trail API, filterable by custom audit fields, and streamed exports
+	auditLogHandler := handler.NewAuditLogHandler(getAuthorizationAuditService())
+	r.GET("/admin-ui/api/audit/logs", middleware.CheckAdminAuth(), auditLogHandler.ListAuditLogs)
+	r.GET("/admin-ui/api/audit/summary", middleware.CheckAdminAuth(), auditLogHandler.GetAuditSummary)
+	r.GET("/admin-ui/api/audit/fields", middleware.CheckAdminAuth(), auditLogHandler.ListAuditFields)
+	r.GET("/admin-ui/api/audit/export", middleware.CheckAdminAuth(), auditLogHandler.ExportAuditLogs)
+
+	// Per-admin policy sandbox: stage, test and apply policy changes together
+	sandboxHandler := mustHandler(handler.NewPolicySandboxHandler(getPolicySandboxService()))
+	r.POST("/admin-ui/api/policies/sandbox", middleware.CheckAdminAuth(), sandboxHandler.OpenSandbox)
+	r.GET("/admin-ui/api/policies/sandbox", middleware.CheckAdminAuth(), sandboxHandler.GetSandbox)
+	r.POST("/admin-ui/api/policies/sandbox/changes", middleware.CheckAdminAuth(), sandboxHandler.StageChange)
+	r.POST("/admin-ui/api/policies/sandbox/check", middleware.CheckAdminAuth(), sandboxHandler.CheckSandbox)
+	r.POST("/admin-ui/api/policies/sandbox/apply", middleware.CheckAdminAuth(), sandboxHandler.ApplySandbox)
+	r.DELETE("/admin-ui/api/policies/sandbox", middleware.CheckAdminAuth(), sandboxHandler.DiscardSandbox)
+	r.POST("/admin-ui/api/policies/simulate", middleware.CheckAdminAuth(), sandboxHandler.SimulatePolicy)
+
+	// Versioned policy API for automation, authenticated with an admin bearer token
+	policyAPIHandler := mustHandler(handler.NewPolicyAPIHandler(service.NewPolicyService()))
+	policyAPIHandler.RegisterRoutes(r, middleware.AdminAPIAuth())
+
+	// Approvals of role changes that would lock the requesting admin out
+	approvalHandler := mustHandler(handler.NewApprovalHandler(getApprovalService()))
+	r.GET("/admin-ui/api/approvals", middleware.CheckAdminAuth(), approvalHandler.ListApprovals)
+	r.POST("/admin-ui/api/approvals/:id/approve", middleware.CheckAdminAuth(), approvalHandler.ApproveRequest)
+	r.POST("/admin-ui/api/approvals/:id/reject", middleware.CheckAdminAuth(), approvalHandler.RejectRequest)
 
 	// Rate limiting routes
 	r.GET("/admin-ui/rate-limits", middleware.CheckAdminAuth(), rateLimitHandler.GetRateLimitPage)
@@ -205,7 +484,501 @@ func SetupUI(r *gin.Engine) *gin.Engine {
 	r.DELETE("/admin-ui/api/rate-limits/reset-all", middleware.CheckAdminAuth(), rateLimitHandler.ResetAllLimits)
 	r.GET("/admin-ui/api/rate-limits/search", middleware.CheckAdminAuth(), rateLimitHandler.SearchRateLimitStats)
 	r.GET("/admin-ui/api/rate-limits/export", middleware.CheckAdminAuth(), rateLimitHandler.ExportRateLimitStats)
+	r.GET("/admin-ui/api/rate-limits/blocks/:identifier", middleware.CheckAdminAuth(), rateLimitHandler.GetIdentifierBlock)
+	r.PUT("/admin-ui/api/rate-limits/blocks/:identifier", middleware.CheckAdminAuth(), rateLimitHandler.BlockIdentifier)
+	r.DELETE("/admin-ui/api/rate-limits/blocks/:identifier", middleware.CheckAdminAuth(), rateLimitHandler.UnblockIdentifier)
+
+	// Analytics chart annotation routes
+	annotationHandler := mustHandler(handler.NewAnnotationHandler(
+		service.NewUsageAnnotationService(persistence.NewUsageAnnotationRepository(initializer.DB)),
+	))
+	r.GET("/admin-ui/api/analytics/annotations", middleware.CheckAdminAuth(), annotationHandler.ListAnnotations)
+	r.POST("/admin-ui/api/analytics/annotations", middleware.CheckAdminAuth(), annotationHandler.CreateAnnotation)
+	r.DELETE("/admin-ui/api/analytics/annotations/:id", middleware.CheckAdminAuth(), annotationHandler.DeleteAnnotation)
+
+	// Status page incident banner routes
+	statusHandler := mustHandler(handler.NewStatusHandler(getStatusService()))
+	r.GET("/admin-ui/api/status/incident", middleware.CheckAdminAuth(), statusHandler.GetIncident)
+	r.POST("/admin-ui/api/status/incident", middleware.CheckAdminAuth(), statusHandler.SetIncident)
+	r.DELETE("/admin-ui/api/status/incident", middleware.CheckAdminAuth(), statusHandler.ClearIncident)
+
+	// Per-user rate limit overrides
+	overrideHandler := mustHandler(handler.NewRateLimitOverrideHandler(getRateLimitOverrideService()))
+	r.GET("/admin-ui/rate-limit-overrides", middleware.CheckAdminAuth(), overrideHandler.GetOverridesPage)
+	r.GET("/admin-ui/api/rate-limits/overrides", middleware.CheckAdminAuth(), overrideHandler.ListOverrides)
+	r.PUT("/admin-ui/api/rate-limits/overrides/:identity", middleware.CheckAdminAuth(), overrideHandler.SetOverride)
+	r.DELETE("/admin-ui/api/rate-limits/overrides/:identity", middleware.CheckAdminAuth(), overrideHandler.DeleteOverride)
+
+	// Daily and monthly usage quotas
+	quotaHandler := mustHandler(handler.NewUsageQuotaHandler(getUsageQuotaService()))
+	r.GET("/admin-ui/quotas", middleware.CheckAdminAuth(), quotaHandler.GetQuotasPage)
+	r.GET("/admin-ui/api/quotas", middleware.CheckAdminAuth(), quotaHandler.ListQuotas)
+	r.PUT("/admin-ui/api/quotas", middleware.CheckAdminAuth(), quotaHandler.SetQuota)
+	r.DELETE("/admin-ui/api/quotas/:id", middleware.CheckAdminAuth(), quotaHandler.DeleteQuota)
+
+	// API keys authenticating clients without a JWT
+	apiKeyHandler := mustHandler(handler.NewAPIKeyHandler(getAPIKeyService()))
+	r.GET("/admin-ui/api-keys", middleware.CheckAdminAuth(), apiKeyHandler.GetAPIKeysPage)
+	r.GET("/admin-ui/api/api-keys", middleware.CheckAdminAuth(), apiKeyHandler.ListKeys)
+	r.POST("/admin-ui/api/api-keys", middleware.CheckAdminAuth(), apiKeyHandler.IssueKey)
+	r.DELETE("/admin-ui/api/api-keys/:id", middleware.CheckAdminAuth(), apiKeyHandler.RevokeKey)
+
+	// Feature flags for AZF's own subsystems
+	featureFlagHandler := mustHandler(handler.NewFeatureFlagHandler(getFeatureFlagService()))
+	r.GET("/admin-ui/feature-flags", middleware.CheckAdminAuth(), featureFlagHandler.GetFeatureFlagsPage)
+	r.GET("/admin-ui/api/feature-flags", middleware.CheckAdminAuth(), featureFlagHandler.ListFlags)
+	r.PUT("/admin-ui/api/feature-flags/:name", middleware.CheckAdminAuth(), featureFlagHandler.SetFlag)
+	r.GET("/admin-ui/api/feature-flags/changes", middleware.CheckAdminAuth(), featureFlagHandler.ListChanges)
+
+	// Storage maintenance jobs
+	var textBackfiller repository.EncodedTextBackfiller
+	if initializer.DB != nil {
+		textBackfiller = persistence.NewEncodedTextBackfiller(initializer.DB)
+	}
+	storageHandler := mustHandler(handler.NewStorageHandler(service.NewStorageMaintenanceService(textBackfiller)))
+	r.POST("/admin-ui/api/storage/text-backfill", middleware.CheckAdminAuth(), storageHandler.StartTextBackfill)
+	r.GET("/admin-ui/api/storage/text-backfill", middleware.CheckAdminAuth(), storageHandler.GetTextBackfillStatus)
+
+	// Browser push notifications for admins
+	webPushHandler := mustHandler(handler.NewWebPushHandler(getWebPushService()))
+	r.GET("/admin-ui/push-sw.js", webPushHandler.GetServiceWorker)
+	r.GET("/admin-ui/push-client.js", middleware.CheckAdminAuth(), webPushHandler.GetClientScript)
+	r.GET("/admin-ui/api/push/public-key", middleware.CheckAdminAuth(), webPushHandler.GetPublicKey)
+	r.GET("/admin-ui/api/push/subscriptions", middleware.CheckAdminAuth(), webPushHandler.ListSubscriptions)
+	r.POST("/admin-ui/api/push/subscriptions", middleware.CheckAdminAuth(), webPushHandler.Subscribe)
+	r.PUT("/admin-ui/api/push/subscriptions/:id", middleware.CheckAdminAuth(), webPushHandler.UpdateSubscription)
+	r.DELETE("/admin-ui/api/push/subscriptions/:id", middleware.CheckAdminAuth(), webPushHandler.DeleteSubscription)
+	r.POST("/admin-ui/api/push/test", middleware.CheckAdminAuth(), webPushHandler.SendTest)
+
+	// Notification center: PagerDuty/Opsgenie incidents and their acknowledgments
+	incidentHandler := mustHandler(handler.NewIncidentHandler(getIncidentService()))
+	r.GET("/admin-ui/notifications", middleware.CheckAdminAuth(), incidentHandler.GetNotificationCenterPage)
+	r.GET("/admin-ui/api/incidents", middleware.CheckAdminAuth(), incidentHandler.ListIncidents)
+	r.POST("/admin-ui/api/incidents/sync", middleware.CheckAdminAuth(), incidentHandler.SyncIncidents)
+
+	// Webhook subscriptions for authorization events
+	webhookHandler := mustHandler(handler.NewWebhookHandler(getWebhookService()))
+	r.GET("/admin-ui/webhooks", middleware.CheckAdminAuth(), webhookHandler.GetWebhooksPage)
+	r.GET("/admin-ui/webhooks/:id", middleware.CheckAdminAuth(), webhookHandler.GetWebhookDetailsPage)
+	r.GET("/admin-ui/api/webhooks", middleware.CheckAdminAuth(), webhookHandler.ListSubscriptions)
+	r.POST("/admin-ui/api/webhooks", middleware.CheckAdminAuth(), webhookHandler.CreateSubscription)
+	r.DELETE("/admin-ui/api/webhooks/:id", middleware.CheckAdminAuth(), webhookHandler.DeleteSubscription)
+	r.POST("/admin-ui/api/webhooks/:id/test", middleware.CheckAdminAuth(), webhookHandler.TestSubscription)
+	r.POST("/admin-ui/api/webhooks/:id/suspend", middleware.CheckAdminAuth(), webhookHandler.SuspendSubscription)
+	r.POST("/admin-ui/api/webhooks/:id/activate", middleware.CheckAdminAuth(), webhookHandler.ActivateSubscription)
+	r.PUT("/admin-ui/api/webhooks/:id/filters", middleware.CheckAdminAuth(), webhookHandler.UpdateFilters)
+	r.PUT("/admin-ui/api/webhooks/:id/transport", middleware.CheckAdminAuth(), webhookHandler.UpdateTransport)
+	r.GET("/admin-ui/api/webhooks/:id/deliveries", middleware.CheckAdminAuth(), webhookHandler.ListDeliveries)
+	r.POST("/admin-ui/api/webhooks/:id/events/:eventId/redeliver", middleware.CheckAdminAuth(), webhookHandler.RedeliverEvent)
+	r.GET("/admin-ui/api/webhook-events/failed", middleware.CheckAdminAuth(), webhookHandler.ListFailedEvents)
+	r.POST("/admin-ui/api/webhook-events/:id/replay", middleware.CheckAdminAuth(), webhookHandler.ReplayEvent)
+	r.GET("/admin-ui/api/webhook-events/:id/deliveries", middleware.CheckAdminAuth(), webhookHandler.ListEventDeliveries)
+
+	// Read-only mode switch
+	adminModeHandler := mustHandler(handler.NewAdminModeHandler(getAdminModeService()))
+	r.GET("/admin-ui/api/read-only", middleware.CheckAdminAuth(), adminModeHandler.GetReadOnlyStatus)
+	r.PUT("/admin-ui/api/read-only", middleware.CheckAdminAuth(), adminModeHandler.SetReadOnly)
+	return r
+}
+
+type (
+	// RouteBuilder declares routes together with their authorization metadata
+	RouteBuilder = enterprise.RouteBuilder
+	// RouteDefinition is a route declared on a RouteBuilder
+	RouteDefinition = enterprise.RouteDefinition
+	// RouteMetadata is the authorization metadata of a route
+	RouteMetadata = enterprise.RouteMetadata
+	// RateLimitConfig is the rate limit of a route
+	RateLimitConfig = enterprise.RateLimitConfig
+	// RouteCORSConfig overrides the CORS policy for a route
+	RouteCORSConfig = enterprise.RouteCORSConfig
+)
+
+// Route returns a builder declaring routes on r together with their
+// authorization metadata. Metadata goes to the enterprise route registry
+// when enterprise authorization is initialized; call WithPolicies to also
+// grant the declared roles in the Casbin policies.
+func Route(r gin.IRouter) *RouteBuilder {
+	var registry *enterprise.RouteRegistry
+	if enterprise.EnterpriseAuth != nil {
+		registry = enterprise.EnterpriseAuth.GetRouteRegistry()
+	}
+	return enterprise.NewRouteBuilder(r, registry, initializer.CasbinEnforcer)
+}
+
+// SetTracerProvider makes azf record spans for authorization checks,
+// database calls and audit flushes with tp; see the tracing package for
+// plugging in OpenTelemetry
+func SetTracerProvider(tp tracing.TracerProvider) {
+	tracing.SetTracerProvider(tp)
+	if tp != nil && initializer.DB != nil {
+		if err := initializer.DB.Use(tracing.GormPlugin()); err != nil && !errors.Is(err, gorm.ErrRegistered) {
+			logger.Warn("Failed to trace database calls", zap.Error(err))
+		}
+	}
+}
+
+// SetupMetrics serves the Prometheus metrics on METRICS_PATH (/metrics)
+// when METRICS_ENABLED is set. Set METRICS_TOKEN to require scrapers to
+// present it as a bearer token.
+func SetupMetrics(r *gin.Engine) *gin.Engine {
+	cfg := config.GetMetricsConfig()
+	if !cfg.Enabled {
+		return r
+	}
+	routes := Route(r)
+	routes.GET(cfg.Path, mustHandler(handler.NewMetricsHandler(metrics.Default(), cfg.Token)).GetMetrics).
+		Public().Describe("Prometheus metrics").Tags("monitoring").WithoutResponseMeta()
+	if err := routes.Register(); err != nil {
+		logger.Error("Failed to register metrics route", zap.Error(err))
+	}
+	return r
+}
+
+// SetupStatusPage registers the optional unauthenticated status page.
+// It exposes only coarse health (status, uptime, request rate tier and the
+// admin-controlled incident banner) and is safe to serve publicly.
+func SetupStatusPage(r *gin.Engine) *gin.Engine {
+	statusHandler := mustHandler(handler.NewStatusHandler(getStatusService()))
+
+	routes := Route(r)
+	routes.GET("/status", statusHandler.GetStatusPage).
+		Public().Describe("Public service status page").Tags("status")
+	routes.GET("/status.json", statusHandler.GetStatusJSON).
+		Public().Describe("Public service status as JSON").Tags("status")
+	if err := routes.Register(); err != nil {
+		logger.Error("Failed to register status page routes", zap.Error(err))
+	}
+	return r
+}
+
+// SetupJWKS publishes the public keys azf signs tokens with at
+// /.well-known/jwks.json, so other services can verify them. It serves keys
+// only when JWT_ALGORITHM selects RS or ES keys.
+func SetupJWKS(r *gin.Engine) *gin.Engine {
+	routes := Route(r)
+	routes.GET("/.well-known/jwks.json", handler.NewJWKSHandler().GetJWKS).
+		Public().Describe("Public keys of the tokens azf issues").Tags("auth")
+	if err := routes.Register(); err != nil {
+		logger.Error("Failed to register JWKS route", zap.Error(err))
+	}
+	return r
+}
 
-	r.GET("/admin-ui/logout", apiPerfHandler.Logout)
+// SetupWebhookSchemas publishes the JSON schemas of the webhook payloads at
+// /webhooks/schemas, one per event type and one for the payload envelope.
+func SetupWebhookSchemas(r *gin.Engine) *gin.Engine {
+	schemas := handler.NewWebhookSchemaHandler()
+	routes := Route(r)
+	routes.GET("/webhooks/schemas", schemas.ListSchemas).
+		Public().Describe("Webhook payload schemas").Tags("webhooks")
+	routes.GET("/webhooks/schemas/:type", schemas.GetSchema).
+		Public().Describe("JSON schema of a webhook event type").Tags("webhooks")
+	if err := routes.Register(); err != nil {
+		logger.Error("Failed to register webhook schema routes", zap.Error(err))
+	}
+	return r
+}
+
+// SetupWebhookReplay lets subscribers fetch the stored events of their
+// subscription again at /webhooks/subscriptions/:id/replay, to recover from
+// their own outages. Requests are signed with the subscription secret.
+func SetupWebhookReplay(r *gin.Engine) *gin.Engine {
+	webhookHandler := mustHandler(handler.NewWebhookHandler(getWebhookService()))
+	routes := Route(r)
+	routes.POST("/webhooks/subscriptions/:id/replay", webhookHandler.ReplaySubscriberEvents).
+		Public().Describe("Stored events of a webhook subscription, for its subscriber").Tags("webhooks")
+	if err := routes.Register(); err != nil {
+		logger.Error("Failed to register webhook replay route", zap.Error(err))
+	}
 	return r
 }
+
+// getStatusService lazily creates the shared status service
+func getStatusService() service.StatusService {
+	if statusService != nil {
+		return statusService
+	}
+
+	var db *gorm.DB
+	if mgr != nil && mgr.DB != nil {
+		db = mgr.DB
+	} else {
+		db = initializer.DB
+	}
+
+	if db == nil {
+		statusService = service.NewStatusService(nil, nil)
+		return statusService
+	}
+
+	statusService = service.NewStatusService(
+		analytics.Default(db).Logs(),
+		func() error { return persistence.HealthCheck(db) },
+	)
+	return statusService
+}
+
+// getWebPushService lazily creates the shared web push subscription service
+func getWebPushService() service.WebPushService {
+	if webPushService != nil {
+		return webPushService
+	}
+
+	var db *gorm.DB
+	if mgr != nil && mgr.DB != nil {
+		db = mgr.DB
+	} else {
+		db = initializer.DB
+	}
+
+	defaultSeverity := config.GetNotificationConfig().WebPush.DefaultSeverity
+	if db == nil || webPushSender == nil {
+		webPushService = service.NewWebPushService(nil, "", defaultSeverity, nil)
+		return webPushService
+	}
+
+	webPushService = service.NewWebPushService(
+		persistence.NewWebPushSubscriptionRepository(db),
+		webPushSender.PublicKey(),
+		defaultSeverity,
+		webPushSender.SendTestMessage,
+	)
+	return webPushService
+}
+
+// mustHandler returns h, panicking when its constructor reported a missing
+// dependency, so incomplete wiring fails at startup rather than on a request
+func mustHandler[T any](h T, err error) T {
+	if err != nil {
+		panic(fmt.Sprintf("azf: %v", err))
+	}
+	return h
+}
+
+// adminIdentity keys the admin rate limiter by the logged-in admin's username
+func adminIdentity(c *gin.Context) string {
+	if username := handler.AdminUsername(c); username != "" {
+		return "admin:" + username
+	}
+	return ""
+}
+
+// getAuthorizationAuditService returns a service over the enterprise audit
+// repository, or nil when enterprise authorization is not set up
+func getAuthorizationAuditService() service.AuthorizationAuditService {
+	if enterprise.EnterpriseAuth == nil || enterprise.EnterpriseAuth.GetAuditRepository() == nil {
+		return nil
+	}
+	return service.NewAuthorizationAuditService(enterprise.EnterpriseAuth.GetAuditRepository())
+}
+
+// getFeatureFlagService lazily creates the shared feature flag service. Flags
+// that were never switched keep the values the subsystems were set up with.
+func getFeatureFlagService() service.FeatureFlagService {
+	if featureFlagService != nil {
+		return featureFlagService
+	}
+
+	var db *gorm.DB
+	if mgr != nil && mgr.DB != nil {
+		db = mgr.DB
+	} else {
+		db = initializer.DB
+	}
+
+	defaults := map[string]bool{
+		api_usage.FeatureAuditLogging:     config.AUDIT_LOGING,
+		api_usage.FeatureRateLimiting:     config.RATE_LIMITING,
+		api_usage.FeatureWebhooks:         true,
+		api_usage.FeatureUsageTracking:    true,
+		api_usage.FeatureAnomalyDetection: true,
+	}
+
+	if db == nil {
+		featureFlagService = service.NewFeatureFlagService(nil, config.GetEnvironment(), defaults)
+		return featureFlagService
+	}
+
+	featureFlagService = service.NewFeatureFlagService(persistence.NewFeatureFlagRepository(db), config.GetEnvironment(), defaults)
+	return featureFlagService
+}
+
+// webhooksEnabled reports whether alerts go out to webhook and Slack endpoints
+func webhooksEnabled() bool {
+	return getFeatureFlagService().IsEnabled(api_usage.FeatureWebhooks, true)
+}
+
+// getWebhookService returns the shared webhook service, which is disabled
+// until InitAuthZModule sets up webhooks
+func getWebhookService() service.WebhookService {
+	if webhookService == nil {
+		webhookService = service.NewWebhookService(nil, nil, nil, nil)
+	}
+	return webhookService
+}
+
+// getApprovalService lazily creates the shared approval service, decided by
+// the configured superadmins
+func getApprovalService() service.ApprovalService {
+	if approvalService == nil {
+		approvalService = service.NewApprovalService(config.AdminSuperadmins(), service.DefaultApprovalTTL)
+	}
+	return approvalService
+}
+
+// getPolicySandboxService lazily creates the shared policy sandbox service
+func getPolicySandboxService() service.PolicySandboxService {
+	if policySandboxService == nil {
+		policySandboxService = service.NewPolicySandboxService(service.DefaultPolicySandboxTTL)
+	}
+	return policySandboxService
+}
+
+// getAdminModeService lazily creates the shared admin mode service
+func getAdminModeService() service.AdminModeService {
+	if adminModeService == nil {
+		adminModeService = service.NewAdminModeService(config.AdminReadOnly(), config.AdminSuperadmins(), auditBlockedAdminWrite)
+	}
+	return adminModeService
+}
+
+// bootstrapAdminUser creates or links the user record of the configured admin
+func bootstrapAdminUser(configProvider *config.AdminConfigProvider, userRepo usermodel.UserRepository, unitOfWork service.UnitOfWork) {
+	credentials, err := configProvider.GetAdminCredentials()
+	if err != nil {
+		logger.Warn("Skipping admin user bootstrap", zap.Error(err))
+		return
+	}
+	username := credentials.Username().Value()
+	if _, err := service.NewAdminUserService(userRepo, unitOfWork).EnsureAdminUser(username); err != nil {
+		logger.Warn("Failed to bootstrap admin user record", zap.String("username", username), zap.Error(err))
+	}
+}
+
+// recordBlockedAdminWrite reports an admin write rejected by read-only mode
+func recordBlockedAdminWrite(c *gin.Context) {
+	getAdminModeService().RecordBlockedWrite(service.BlockedWriteAttempt{
+		RequestID:     middleware.GetRequestID(c),
+		AdminUsername: handler.AdminUsername(c),
+		Method:        c.Request.Method,
+		Path:          c.Request.URL.Path,
+		IPAddress:     c.ClientIP(),
+		UserAgent:     c.Request.UserAgent(),
+	})
+}
+
+// auditBlockedAdminWrite adds a blocked admin write to the authorization audit trail
+func auditBlockedAdminWrite(attempt service.BlockedWriteAttempt) {
+	if enterprise.EnterpriseAuth == nil {
+		return
+	}
+	userID := attempt.AdminUsername
+	if userID == "" {
+		userID = "anonymous"
+	}
+	requestID := attempt.RequestID
+	if requestID == "" {
+		requestID = uuid.New().String()
+	}
+	enterprise.EnterpriseAuth.GetMiddleware().AuditDenied(
+		requestID, userID, "admin", attempt.Path, attempt.Method,
+		model.ReasonReadOnlyMode,
+		"admin surface is in read-only mode",
+		attempt.IPAddress, attempt.UserAgent,
+	)
+}
+
+// getIncidentService lazily creates the notification center's incident service
+func getIncidentService() service.IncidentService {
+	if incidentService != nil {
+		return incidentService
+	}
+
+	var db *gorm.DB
+	if mgr != nil && mgr.DB != nil {
+		db = mgr.DB
+	} else {
+		db = initializer.DB
+	}
+
+	if db == nil || incidentManager == nil {
+		incidentService = service.NewIncidentService(nil, nil, nil)
+		return incidentService
+	}
+
+	incidentService = service.NewIncidentService(
+		persistence.NewAlertIncidentRepository(db),
+		incidentManager.Providers(),
+		incidentManager.Sync,
+	)
+	return incidentService
+}
+
+// getRateLimitOverrideService lazily creates the shared rate limit override service
+func getRateLimitOverrideService() service.RateLimitOverrideService {
+	if rateLimitOverrideService != nil {
+		return rateLimitOverrideService
+	}
+
+	var db *gorm.DB
+	if mgr != nil && mgr.DB != nil {
+		db = mgr.DB
+	} else {
+		db = initializer.DB
+	}
+
+	if db == nil {
+		rateLimitOverrideService = service.NewRateLimitOverrideService(nil)
+		return rateLimitOverrideService
+	}
+
+	rateLimitOverrideService = service.NewRateLimitOverrideService(persistence.NewRateLimitOverrideRepository(db))
+	return rateLimitOverrideService
+}
+
+// getUsageQuotaService lazily creates the shared usage quota service and
+// starts its scheduler
+func getUsageQuotaService() service.UsageQuotaService {
+	if usageQuotaService != nil {
+		return usageQuotaService
+	}
+
+	var db *gorm.DB
+	if mgr != nil && mgr.DB != nil {
+		db = mgr.DB
+	} else {
+		db = initializer.DB
+	}
+
+	if db == nil {
+		usageQuotaService = service.NewUsageQuotaService(nil)
+		return usageQuotaService
+	}
+
+	usageQuotaService = service.NewUsageQuotaService(persistence.NewUsageQuotaRepository(db))
+	usageQuotaScheduler = service.StartUsageQuotaScheduler(usageQuotaService, service.DefaultUsageQuotaSyncInterval)
+	return usageQuotaService
+}
+
+// getAPIKeyService lazily creates the shared API key service
+func getAPIKeyService() service.APIKeyService {
+	if apiKeyService != nil {
+		return apiKeyService
+	}
+
+	var db *gorm.DB
+	if mgr != nil && mgr.DB != nil {
+		db = mgr.DB
+	} else {
+		db = initializer.DB
+	}
+
+	if db == nil {
+		apiKeyService = service.NewAPIKeyService(nil)
+		return apiKeyService
+	}
+
+	apiKeyService = service.NewAPIKeyService(persistence.NewAPIKeyRepository(db))
+	return apiKeyService
+}
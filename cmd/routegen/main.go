@@ -0,0 +1,73 @@
+// Command routegen writes the route metadata declared with @azf: annotations
+// on handler functions to the enterprise route metadata JSON file, so the
+// authorization metadata lives next to the handler it protects. Run it from
+// go:generate, e.g.
+//
+//	//go:generate go run github.com/aruncs31s/azf/cmd/routegen -dir . -out ../routes/enterprise_route_metadata.json
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/aruncs31s/azf/infrastructure/enterprise"
+	"github.com/aruncs31s/azf/utils"
+)
+
+func main() {
+	dir := flag.String("dir", ".", "directory to scan for annotated handlers, recursively")
+	out := flag.String("out", "application/routes/enterprise_route_metadata.json", "route metadata JSON file to write")
+	merge := flag.Bool("merge", true, "keep routes of the existing file that are not annotated")
+	flag.Parse()
+
+	if err := run(*dir, *out, *merge); err != nil {
+		fmt.Fprintln(os.Stderr, "routegen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(dir, out string, merge bool) error {
+	declared, err := enterprise.ExtractRouteAnnotations(dir)
+	if err != nil {
+		return err
+	}
+
+	routes := declared
+	if merge {
+		existing, err := readRoutes(out)
+		if err != nil {
+			return err
+		}
+		routes = enterprise.MergeRouteMetadata(existing, declared)
+	}
+
+	data, err := json.MarshalIndent(enterprise.EnterpriseRouteMetadataConfig{Routes: routes}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode route metadata: %w", err)
+	}
+	if err := utils.WriteFileAtomic(out, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", out, err)
+	}
+	fmt.Printf("routegen: %d annotated routes, %d routes written to %s\n", len(declared), len(routes), out)
+	return nil
+}
+
+// readRoutes reads the routes of an existing metadata file; a missing file has none
+func readRoutes(path string) ([]*enterprise.RouteMetadata, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var config enterprise.EnterpriseRouteMetadataConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return config.Routes, nil
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/aruncs31s/azf/domain/model"
 )
@@ -77,6 +78,83 @@ func (ac *AdminConfig) GetPasswordString() string {
 	return ac.password.Value()
 }
 
+// AdminReadOnly reports whether the admin surface starts in read-only mode
+func AdminReadOnly() bool {
+	return getBoolOrDefault("ADMIN_READ_ONLY", false)
+}
+
+// AdminSuperadmins returns the admins allowed to switch read-only mode at
+// runtime. It defaults to the configured admin user.
+func AdminSuperadmins() []string {
+	var defaults []string
+	if username := os.Getenv(adminUsernameVar); username != "" {
+		defaults = []string{username}
+	}
+	return getSliceOrDefault("ADMIN_SUPERADMINS", defaults)
+}
+
+// AdminEmail returns the email stored on the configured admin's user record
+func AdminEmail() string {
+	return getEnvOrDefault("ADMIN_EMAIL", "")
+}
+
+// AdminDisplayName returns the display name of the configured admin's user record
+func AdminDisplayName() string {
+	return getEnvOrDefault("ADMIN_DISPLAY_NAME", "System Administrator")
+}
+
+// AdminTokenStoreDatabase and AdminTokenStoreRedis select where admin
+// refresh tokens and sessions are stored
+const (
+	AdminTokenStoreDatabase = "database"
+	AdminTokenStoreRedis    = "redis"
+)
+
+// AdminAccessTokenTTL returns how long an admin access token is valid
+func AdminAccessTokenTTL() time.Duration {
+	return getDurationOrDefault("ADMIN_ACCESS_TOKEN_TTL", 15*time.Minute)
+}
+
+// AdminRefreshTokenTTL returns how long an admin refresh token is valid.
+// Refreshing rotates the token, so an admin stays signed in while active.
+func AdminRefreshTokenTTL() time.Duration {
+	return getDurationOrDefault("ADMIN_REFRESH_TOKEN_TTL", 7*24*time.Hour)
+}
+
+// AdminRefreshTokenStore returns where admin refresh tokens are stored:
+// AdminTokenStoreDatabase (the default) or AdminTokenStoreRedis on REDIS_URL
+func AdminRefreshTokenStore() string {
+	return getEnvOrDefault("ADMIN_REFRESH_TOKEN_STORE", AdminTokenStoreDatabase)
+}
+
+// AdminSessionStore returns where admin dashboard sessions are stored:
+// AdminTokenStoreDatabase (the default) or AdminTokenStoreRedis on REDIS_URL
+func AdminSessionStore() string {
+	return getEnvOrDefault("ADMIN_SESSION_STORE", AdminTokenStoreDatabase)
+}
+
+// AdminSessionIdleTimeout returns how long an unused admin session stays valid
+func AdminSessionIdleTimeout() time.Duration {
+	return getDurationOrDefault("ADMIN_SESSION_IDLE_TIMEOUT", 2*time.Hour)
+}
+
+// AdminSessionAbsoluteTimeout returns how long an admin session stays valid
+// however often it is used
+func AdminSessionAbsoluteTimeout() time.Duration {
+	return getDurationOrDefault("ADMIN_SESSION_ABSOLUTE_TIMEOUT", 24*time.Hour)
+}
+
+// AdminRequireTwoFactor reports whether admins must sign in with a TOTP
+// code. Admins without two-factor authentication enroll on their next sign-in.
+func AdminRequireTwoFactor() bool {
+	return getBoolOrDefault("ADMIN_REQUIRE_2FA", false)
+}
+
+// AdminTwoFactorIssuer returns the issuer shown for azf in authenticator apps
+func AdminTwoFactorIssuer() string {
+	return getEnvOrDefault("ADMIN_2FA_ISSUER", "AZF")
+}
+
 // AdminConfigProvider provides access to admin configuration
 // Following DDD: this is an application service that provides domain configuration
 type AdminConfigProvider struct {
@@ -0,0 +1,23 @@
+package config
+
+// AdminRateLimitConfig holds the limits for the admin UI and admin API. They
+// are enforced separately from application traffic: dashboard requests are
+// limited per admin identity and login attempts per client IP.
+type AdminRateLimitConfig struct {
+	Enabled           bool
+	RequestsPerSecond float64
+	Burst             int
+	LoginPerMinute    float64
+	LoginBurst        int
+}
+
+// GetAdminRateLimitConfig loads the admin rate limits from the environment
+func GetAdminRateLimitConfig() AdminRateLimitConfig {
+	return AdminRateLimitConfig{
+		Enabled:           getBoolOrDefault("ADMIN_RATE_LIMIT_ENABLED", true),
+		RequestsPerSecond: getFloatOrDefault("ADMIN_RATE_LIMIT_RPS", 2),
+		Burst:             getIntOrDefault("ADMIN_RATE_LIMIT_BURST", 30),
+		LoginPerMinute:    getFloatOrDefault("ADMIN_LOGIN_RATE_LIMIT_PER_MINUTE", 5),
+		LoginBurst:        getIntOrDefault("ADMIN_LOGIN_RATE_LIMIT_BURST", 5),
+	}
+}
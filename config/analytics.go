@@ -0,0 +1,64 @@
+package config
+
+import (
+	"strings"
+	"time"
+)
+
+// Usage analytics storage backends
+const (
+	UsageAnalyticsBackendGorm       = "gorm"
+	UsageAnalyticsBackendClickHouse = "clickhouse"
+)
+
+// UsageAnalyticsConfig selects where raw API usage logs are stored
+type UsageAnalyticsConfig struct {
+	Backend    string
+	ClickHouse ClickHouseConfig
+}
+
+// ClickHouseConfig holds the connection and batching settings for the ClickHouse backend
+type ClickHouseConfig struct {
+	URL           string
+	Database      string
+	Username      string
+	Password      string
+	BatchSize     int
+	FlushInterval time.Duration
+	Timeout       time.Duration
+}
+
+// GetUsageAnalyticsConfig loads the usage analytics backend configuration from
+// the environment. The GORM backend (the application database) is the default.
+func GetUsageAnalyticsConfig() UsageAnalyticsConfig {
+	return UsageAnalyticsConfig{
+		Backend: strings.ToLower(getEnvOrDefault("USAGE_ANALYTICS_BACKEND", UsageAnalyticsBackendGorm)),
+		ClickHouse: ClickHouseConfig{
+			URL:           getEnvOrDefault("CLICKHOUSE_URL", "http://localhost:8123"),
+			Database:      getEnvOrDefault("CLICKHOUSE_DATABASE", "default"),
+			Username:      getEnvOrDefault("CLICKHOUSE_USER", "default"),
+			Password:      getEnvOrDefault("CLICKHOUSE_PASSWORD", ""),
+			BatchSize:     getIntOrDefault("CLICKHOUSE_BATCH_SIZE", 500),
+			FlushInterval: getDurationOrDefault("CLICKHOUSE_FLUSH_INTERVAL", 5*time.Second),
+			Timeout:       getDurationOrDefault("CLICKHOUSE_TIMEOUT", 10*time.Second),
+		},
+	}
+}
+
+// ErrorRankingConfig controls how the "most errored endpoints" list is ranked
+type ErrorRankingConfig struct {
+	// MinRequests leaves out endpoints with fewer requests, whose error
+	// rates are mostly noise
+	MinRequests int64
+	// SortBy is error_rate or error_rate_wilson, which ranks by the lower
+	// bound of the Wilson score interval of the error rate
+	SortBy string
+}
+
+// GetErrorRankingConfig loads the error ranking configuration from the environment
+func GetErrorRankingConfig() ErrorRankingConfig {
+	return ErrorRankingConfig{
+		MinRequests: int64(getIntOrDefault("ANALYTICS_ERROR_MIN_REQUESTS", 10)),
+		SortBy:      strings.ToLower(getEnvOrDefault("ANALYTICS_ERROR_RANKING", "error_rate_wilson")),
+	}
+}
@@ -106,14 +106,7 @@ func LoadConfig() (*Config, error) {
 	}
 
 	// Load database config
-	cfg.Database = DBConfig{
-		Driver:          getEnvOrDefault("DB_DRIVER", "sqlite"),
-		DSN:             getEnvOrDefault("DB_DSN", "tmp/AZF_auth_z.db"),
-		MaxIdleConns:    getIntOrDefault("DB_MAX_IDLE_CONNS", 10),
-		MaxOpenConns:    getIntOrDefault("DB_MAX_OPEN_CONNS", 100),
-		ConnMaxLifetime: getDurationOrDefault("DB_CONN_MAX_LIFETIME", time.Hour),
-		ConnMaxIdleTime: getDurationOrDefault("DB_CONN_MAX_IDLE_TIME", 30*time.Minute),
-	}
+	cfg.Database = GetDBConfig()
 
 	// Load rate limit config
 	cfg.RateLimit = RateLimitCfg{
@@ -121,7 +114,7 @@ func LoadConfig() (*Config, error) {
 		RequestsPerSecond: getFloatOrDefault("RATE_LIMIT_RPS", 10.0),
 		Burst:             getIntOrDefault("RATE_LIMIT_BURST", 20),
 		UseRedis:          getBoolOrDefault("RATE_LIMIT_USE_REDIS", false),
-		RedisURL:          getEnvOrDefault("REDIS_URL", ""),
+		RedisURL:          RedisURL(),
 	}
 
 	// Load server config
@@ -137,7 +130,7 @@ func LoadConfig() (*Config, error) {
 
 	// Load Casbin config
 	cfg.Casbin = CasbinConfig{
-		ModelPath:  getEnvOrDefault("CASBIN_MODEL", CASBIN_MODEL_FILE),
+		ModelPath:  getEnvOrDefault("CASBIN_MODEL", CasbinModelFile()),
 		PolicyPath: getEnvOrDefault("CASBIN_POLICY", CASBIN_POLICY_FILE),
 	}
 
@@ -165,8 +158,8 @@ func LoadConfigWithDefaults() *Config {
 			Issuer:             "azf-dev",
 		},
 		Database: DBConfig{
-			Driver:          "sqlite",
-			DSN:             "tmp/AZF_auth_z.db",
+			Driver:          DBDriverSQLite,
+			DSN:             DefaultSQLiteDSN,
 			MaxIdleConns:    10,
 			MaxOpenConns:    100,
 			ConnMaxLifetime: time.Hour,
@@ -0,0 +1,27 @@
+package config
+
+import "time"
+
+// CORSConfig holds the CORS policy applied to cross-origin browser requests.
+// Routes can override the origins, headers and credentials in their metadata.
+type CORSConfig struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// GetCORSConfig loads the CORS policy from the environment; empty methods
+// and headers use the middleware defaults
+func GetCORSConfig() CORSConfig {
+	return CORSConfig{
+		AllowedOrigins:   getSliceOrDefault("CORS_ALLOWED_ORIGINS", nil),
+		AllowedMethods:   getSliceOrDefault("CORS_ALLOWED_METHODS", nil),
+		AllowedHeaders:   getSliceOrDefault("CORS_ALLOWED_HEADERS", nil),
+		ExposedHeaders:   getSliceOrDefault("CORS_EXPOSED_HEADERS", nil),
+		AllowCredentials: getBoolOrDefault("CORS_ALLOW_CREDENTIALS", false),
+		MaxAge:           getDurationOrDefault("CORS_MAX_AGE", 24*time.Hour),
+	}
+}
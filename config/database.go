@@ -3,8 +3,22 @@ package config
 import (
 	"fmt"
 	"os"
+	"strings"
+	"time"
 )
 
+// Database drivers selectable with DB_DRIVER
+const (
+	DBDriverSQLite   = "sqlite"
+	DBDriverPostgres = "postgres"
+	DBDriverMySQL    = "mysql"
+)
+
+// DefaultSQLiteDSN is the database file used when no DSN is configured
+const DefaultSQLiteDSN = "tmp/AZF_auth_z.db"
+
+// DatabaseConfig holds the connection parts a DSN is built from when DB_DSN
+// is not set
 type DatabaseConfig struct {
 	User     string
 	Password string
@@ -13,17 +27,57 @@ type DatabaseConfig struct {
 	Name     string
 }
 
+// GetLocalDBDSN returns the MySQL DSN built from DB_USER, DB_PASS, DB_HOST,
+// DB_PORT and DB_NAME
 func GetLocalDBDSN() string {
-	db := DatabaseConfig{
+	db := databaseConfigFromEnv()
+	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true",
+		db.User, db.Password, db.Host, db.Port, db.Name)
+}
+
+// GetPostgresDSN returns the Postgres DSN built from DB_USER, DB_PASS,
+// DB_HOST, DB_PORT, DB_NAME and DB_SSLMODE
+func GetPostgresDSN() string {
+	db := databaseConfigFromEnv()
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+		db.Host, getEnvOrDefault("DB_PORT", "5432"), db.User, db.Password, db.Name,
+		getEnvOrDefault("DB_SSLMODE", "disable"))
+}
+
+func databaseConfigFromEnv() DatabaseConfig {
+	return DatabaseConfig{
 		User:     os.Getenv("DB_USER"),
 		Password: os.Getenv("DB_PASS"),
 		Host:     os.Getenv("DB_HOST"),
 		Port:     os.Getenv("DB_PORT"),
 		Name:     os.Getenv("DB_NAME"),
 	}
+}
 
-	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true",
-		db.User, db.Password, db.Host, db.Port, db.Name)
+// GetDBConfig loads the application database configuration from the
+// environment. SQLite at DefaultSQLiteDSN is the default; for MySQL and
+// Postgres the DSN is built from the DB_* parts when DB_DSN is not set.
+func GetDBConfig() DBConfig {
+	driver := strings.ToLower(getEnvOrDefault("DB_DRIVER", DBDriverSQLite))
+	dsn := os.Getenv("DB_DSN")
+	if dsn == "" {
+		switch driver {
+		case DBDriverMySQL:
+			dsn = GetLocalDBDSN()
+		case DBDriverPostgres:
+			dsn = GetPostgresDSN()
+		default:
+			dsn = DefaultSQLiteDSN
+		}
+	}
+	return DBConfig{
+		Driver:          driver,
+		DSN:             dsn,
+		MaxIdleConns:    getIntOrDefault("DB_MAX_IDLE_CONNS", 10),
+		MaxOpenConns:    getIntOrDefault("DB_MAX_OPEN_CONNS", 100),
+		ConnMaxLifetime: getDurationOrDefault("DB_CONN_MAX_LIFETIME", time.Hour),
+		ConnMaxIdleTime: getDurationOrDefault("DB_CONN_MAX_IDLE_TIME", 30*time.Minute),
+	}
 }
 
 // GetEnvironment returns the current environment (development, staging, production)
@@ -37,3 +91,15 @@ func GetEnvironmentVal() string {
 	}
 	return env
 }
+
+// TimescaleEnabled reports whether hypertables and continuous aggregates are
+// set up on Postgres deployments that have the timescaledb extension
+func TimescaleEnabled() bool {
+	return getBoolOrDefault("TIMESCALE_ENABLED", true)
+}
+
+// TextEncodingEnabled reports whether large text columns in the usage and
+// audit tables are dictionary-encoded or compressed on write
+func TextEncodingEnabled() bool {
+	return getBoolOrDefault("TEXT_COLUMN_ENCODING_ENABLED", true)
+}
@@ -3,6 +3,8 @@ package config
 import (
 	// Because the , cwd changes when using the module outside
 	_ "embed" // Import for embedding
+	"strings"
+	"time"
 )
 
 // Embed the default policy and model files
@@ -13,8 +15,115 @@ var DefaultPolicy []byte
 //go:embed casbin_rbac_model.conf
 var DefaultModel []byte
 
+// DefaultABACModel extends DefaultModel with route conditions evaluated
+// against request attributes
+//
+//go:embed casbin_abac_model.conf
+var DefaultABACModel []byte
+
 const (
 	CASBIN_MODEL_FILE          = "config/casbin_rbac_model.conf" // Keep for backward compatibility, but we'll use embedded for defaults
+	CASBIN_ABAC_MODEL_FILE     = "config/casbin_abac_model.conf"
 	CASBIN_POLICY_FILE         = "config/casbin_rbac_policy.csv"
 	CASBIN_POLICY_DEFAULT_PATH = "config/casbin_rbac_policy.csv"
+	ROLE_DESCRIPTIONS_FILE     = "config/role_descriptions.json"
+)
+
+// Casbin model types selectable with CASBIN_MODEL_TYPE
+const (
+	CASBIN_MODEL_TYPE_RBAC = "rbac"
+	CASBIN_MODEL_TYPE_ABAC = "abac"
+)
+
+// CasbinModelType returns the configured Casbin model type, rbac by default
+func CasbinModelType() string {
+	if strings.EqualFold(getEnvOrDefault("CASBIN_MODEL_TYPE", ""), CASBIN_MODEL_TYPE_ABAC) {
+		return CASBIN_MODEL_TYPE_ABAC
+	}
+	return CASBIN_MODEL_TYPE_RBAC
+}
+
+// Casbin policy storages selectable with CASBIN_POLICY_STORAGE
+const (
+	CASBIN_POLICY_STORAGE_FILE     = "file"
+	CASBIN_POLICY_STORAGE_DATABASE = "database"
+)
+
+// CasbinPolicyStorage returns where Casbin policies are stored: the policy
+// file (default) or the application database
+func CasbinPolicyStorage() string {
+	if strings.EqualFold(getEnvOrDefault("CASBIN_POLICY_STORAGE", ""), CASBIN_POLICY_STORAGE_DATABASE) {
+		return CASBIN_POLICY_STORAGE_DATABASE
+	}
+	return CASBIN_POLICY_STORAGE_FILE
+}
+
+// CasbinPolicyWatcher reports whether policy changes are announced to other
+// instances over Redis (REDIS_URL) so they reload their policies
+func CasbinPolicyWatcher() bool {
+	return getBoolOrDefault("CASBIN_POLICY_WATCHER", false)
+}
+
+// CasbinPolicyWatcherChannel returns the Redis channel policy changes are
+// announced on; empty selects the default channel
+func CasbinPolicyWatcherChannel() string {
+	return getEnvOrDefault("CASBIN_POLICY_WATCHER_CHANNEL", "")
+}
+
+// RedisURL returns the URL of the Redis server shared by the instances
+func RedisURL() string {
+	return getEnvOrDefault("REDIS_URL", "")
+}
+
+// CasbinAutoSave reports whether Casbin policy changes are written to the
+// policy storage as soon as they are applied. It is on by default; when off,
+// changes only persist once the policies are saved explicitly.
+func CasbinAutoSave() bool {
+	return getBoolOrDefault("CASBIN_AUTO_SAVE", true)
+}
+
+// Role sync modes, deciding which side wins when the roles recorded on users
+// and the Casbin grouping policies differ
+const (
+	// RoleSyncModeMerge adds assignments missing on either side, removing none
+	RoleSyncModeMerge = "merge"
+	// RoleSyncModeUsers makes the grouping policies follow the user records
+	RoleSyncModeUsers = "users"
+	// RoleSyncModeCasbin makes the user records follow the grouping policies
+	RoleSyncModeCasbin = "casbin"
 )
+
+// RoleSyncMode returns how the role reconciliation job resolves differences
+// between user roles and grouping policies; merge by default
+func RoleSyncMode() string {
+	return getEnvOrDefault("ROLE_SYNC_MODE", RoleSyncModeMerge)
+}
+
+// RoleSyncInterval returns how often user roles and grouping policies are
+// reconciled in the background; zero disables the job
+func RoleSyncInterval() time.Duration {
+	return getDurationOrDefault("ROLE_SYNC_INTERVAL", time.Hour)
+}
+
+// UserDeletionRetention returns how long soft-deleted users can be restored
+// before they are purged; zero keeps them until restored. 30 days by default.
+func UserDeletionRetention() time.Duration {
+	return getDurationOrDefault("USER_DELETION_RETENTION", 30*24*time.Hour)
+}
+
+// CasbinModelFile returns the default model file of the configured model type
+func CasbinModelFile() string {
+	if CasbinModelType() == CASBIN_MODEL_TYPE_ABAC {
+		return CASBIN_ABAC_MODEL_FILE
+	}
+	return CASBIN_MODEL_FILE
+}
+
+// DefaultModelFor returns the embedded model used to create a missing model
+// file at modelPath
+func DefaultModelFor(modelPath string) []byte {
+	if modelPath == CASBIN_ABAC_MODEL_FILE || (modelPath != CASBIN_MODEL_FILE && CasbinModelType() == CASBIN_MODEL_TYPE_ABAC) {
+		return DefaultABACModel
+	}
+	return DefaultModel
+}
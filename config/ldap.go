@@ -0,0 +1,87 @@
+package config
+
+import (
+	"strings"
+	"time"
+)
+
+// LDAPConfig holds the settings of admin sign-in against an LDAP or Active
+// Directory server
+type LDAPConfig struct {
+	// URL is the ldap:// or ldaps:// address of the server. Admins sign in
+	// with the configured password without it.
+	URL string
+	// BindDN and BindPassword are the service account users are looked up
+	// with; an anonymous bind is used when BindDN is empty
+	BindDN       string
+	BindPassword string
+	// BaseDN is where users are searched
+	BaseDN string
+	// UserFilter finds the user signing in; {username} is replaced by the
+	// escaped username
+	UserFilter        string
+	UsernameAttribute string
+	EmailAttribute    string
+	NameAttribute     string
+	// GroupAttribute holds the DNs of the user's groups, as memberOf does on
+	// Active Directory and OpenLDAP with the memberof overlay
+	GroupAttribute string
+	// GroupBaseDN and GroupFilter find groups listing the user instead, e.g.
+	// groupOfNames with member={dn}; groups are searched when GroupBaseDN is set
+	GroupBaseDN string
+	GroupFilter string
+	// RoleMapping maps group DNs or common names, in lower case, to azf roles.
+	// LDAP_ROLE_MAPPING separates entries with ";" since DNs hold commas, and
+	// the role follows the last "=", e.g. "Admins=admin;cn=ops,dc=example,dc=com=editor".
+	RoleMapping map[string][]string
+	// StartTLS upgrades ldap:// connections to TLS
+	StartTLS bool
+	// InsecureSkipVerify accepts any server certificate (testing only)
+	InsecureSkipVerify bool
+	// Timeout bounds connecting to the server and each request
+	Timeout time.Duration
+	// CacheTTL is how long a user's entry and groups are reused between
+	// sign-ins; the password is checked against the server every time
+	CacheTTL time.Duration
+}
+
+// GetLDAPConfig loads the LDAP_* settings
+func GetLDAPConfig() LDAPConfig {
+	return LDAPConfig{
+		URL:                getEnvOrDefault("LDAP_URL", ""),
+		BindDN:             getEnvOrDefault("LDAP_BIND_DN", ""),
+		BindPassword:       getEnvOrDefault("LDAP_BIND_PASSWORD", ""),
+		BaseDN:             getEnvOrDefault("LDAP_BASE_DN", ""),
+		UserFilter:         getEnvOrDefault("LDAP_USER_FILTER", "(|(uid={username})(sAMAccountName={username}))"),
+		UsernameAttribute:  getEnvOrDefault("LDAP_USERNAME_ATTRIBUTE", ""),
+		EmailAttribute:     getEnvOrDefault("LDAP_EMAIL_ATTRIBUTE", "mail"),
+		NameAttribute:      getEnvOrDefault("LDAP_NAME_ATTRIBUTE", "displayName"),
+		GroupAttribute:     getEnvOrDefault("LDAP_GROUP_ATTRIBUTE", "memberOf"),
+		GroupBaseDN:        getEnvOrDefault("LDAP_GROUP_BASE_DN", ""),
+		GroupFilter:        getEnvOrDefault("LDAP_GROUP_FILTER", "(|(member={dn})(uniqueMember={dn}))"),
+		RoleMapping:        parseLDAPRoleMapping(getEnvOrDefault("LDAP_ROLE_MAPPING", "")),
+		StartTLS:           getBoolOrDefault("LDAP_START_TLS", false),
+		InsecureSkipVerify: getBoolOrDefault("LDAP_INSECURE_SKIP_VERIFY", false),
+		Timeout:            getDurationOrDefault("LDAP_TIMEOUT", 5*time.Second),
+		CacheTTL:           getDurationOrDefault("LDAP_CACHE_TTL", 5*time.Minute),
+	}
+}
+
+// parseLDAPRoleMapping parses "group=role;..." where group is a DN or common
+// name, keyed in lower case
+func parseLDAPRoleMapping(value string) map[string][]string {
+	mapping := make(map[string][]string)
+	for _, entry := range splitAndTrim(value, ";") {
+		i := strings.LastIndex(entry, "=")
+		if i < 0 {
+			continue
+		}
+		group := strings.ToLower(strings.TrimSpace(entry[:i]))
+		role := strings.TrimSpace(entry[i+1:])
+		if group == "" || role == "" {
+			continue
+		}
+		mapping[group] = append(mapping[group], role)
+	}
+	return mapping
+}
@@ -1,5 +1,7 @@
 package config
 
+import "time"
+
 var (
 	ADMIN_LIMIT                 = 300
 	STAFF_LIMIT                 = 120
@@ -9,3 +11,27 @@ var (
 	BURST_ALLOWANCE             = 20
 	DEFAULT_REQUESTS_PER_MINUTE = 60
 )
+
+// RateLimitAlgorithm returns the counting algorithm of the Redis rate
+// limiter: fixed_window, sliding_window_log, sliding_window_counter or
+// leaky_bucket
+func RateLimitAlgorithm() string {
+	return getEnvOrDefault("RATE_LIMIT_ALGORITHM", "fixed_window")
+}
+
+// RateLimitMaxBuckets returns the number of buckets the in-memory rate
+// limiter keeps before evicting the least recently used
+func RateLimitMaxBuckets() int {
+	return getIntOrDefault("RATE_LIMIT_MAX_BUCKETS", 100000)
+}
+
+// RateLimitAutoBanThreshold returns the rejections within a window after
+// which the rate limiter blocks an identifier; 0 turns auto-ban off
+func RateLimitAutoBanThreshold() int {
+	return getIntOrDefault("RATE_LIMIT_AUTO_BAN_THRESHOLD", 0)
+}
+
+// RateLimitAutoBanDuration returns how long an automatic block lasts
+func RateLimitAutoBanDuration() time.Duration {
+	return getDurationOrDefault("RATE_LIMIT_AUTO_BAN_DURATION", 15*time.Minute)
+}
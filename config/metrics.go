@@ -0,0 +1,18 @@
+package config
+
+// MetricsConfig controls the Prometheus metrics endpoint
+type MetricsConfig struct {
+	Enabled bool
+	Path    string
+	// Token, when set, must be sent by scrapers as a bearer token
+	Token string
+}
+
+// GetMetricsConfig loads the metrics settings from the environment
+func GetMetricsConfig() MetricsConfig {
+	return MetricsConfig{
+		Enabled: getBoolOrDefault("METRICS_ENABLED", false),
+		Path:    getEnvOrDefault("METRICS_PATH", "/metrics"),
+		Token:   getEnvOrDefault("METRICS_TOKEN", ""),
+	}
+}
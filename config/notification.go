@@ -0,0 +1,123 @@
+package config
+
+import (
+	"strings"
+	"time"
+)
+
+// NotificationConfig holds the alert channels and flood control settings
+type NotificationConfig struct {
+	WebhookURL      string
+	SlackWebhookURL string
+	Email           EmailNotificationConfig
+	Timeout         time.Duration
+
+	// ThrottleWindow is the minimum time between two notifications for the
+	// same rule and dedup key; RuleThrottleWindows overrides it per rule
+	ThrottleWindow      time.Duration
+	RuleThrottleWindows map[string]time.Duration
+
+	// Alerts at or below DigestSeverity are batched into one summary every
+	// DigestInterval instead of being sent one by one
+	DigestEnabled  bool
+	DigestInterval time.Duration
+	DigestSeverity string
+
+	WebPush   WebPushConfig
+	Incidents IncidentConfig
+}
+
+// WebPushConfig holds the settings for browser push notifications to admins.
+// When no VAPID key pair is configured one is generated and stored in the database.
+type WebPushConfig struct {
+	Enabled         bool
+	VAPIDPublicKey  string
+	VAPIDPrivateKey string
+	Subject         string // mailto: or https: contact sent to push services
+	TTL             time.Duration
+	DefaultSeverity string // minimum severity for new subscriptions
+}
+
+// IncidentConfig holds the on-call integrations that open an incident for
+// alerts at or above MinSeverity and resolve it when the alert clears.
+// Acknowledgments are synced back every SyncInterval; PagerDuty needs a REST
+// API token for that in addition to the Events API routing key.
+type IncidentConfig struct {
+	PagerDutyRoutingKey string
+	PagerDutyAPIToken   string
+	PagerDutyEventsURL  string
+	PagerDutyAPIURL     string
+	OpsgenieAPIKey      string
+	OpsgenieAPIURL      string
+	Source              string // reported as the origin of the incident
+	MinSeverity         string
+	SyncInterval        time.Duration
+}
+
+// EmailNotificationConfig holds the SMTP settings for the email alert channel
+type EmailNotificationConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+// GetNotificationConfig loads the alert notification configuration from the environment
+func GetNotificationConfig() NotificationConfig {
+	return NotificationConfig{
+		WebhookURL:      getEnvOrDefault("NOTIFY_WEBHOOK_URL", ""),
+		SlackWebhookURL: getEnvOrDefault("NOTIFY_SLACK_WEBHOOK_URL", ""),
+		Email: EmailNotificationConfig{
+			Host:     getEnvOrDefault("NOTIFY_SMTP_HOST", ""),
+			Port:     getIntOrDefault("NOTIFY_SMTP_PORT", 587),
+			Username: getEnvOrDefault("NOTIFY_SMTP_USER", ""),
+			Password: getEnvOrDefault("NOTIFY_SMTP_PASSWORD", ""),
+			From:     getEnvOrDefault("NOTIFY_EMAIL_FROM", ""),
+			To:       getSliceOrDefault("NOTIFY_EMAIL_TO", nil),
+		},
+		Timeout:             getDurationOrDefault("NOTIFY_TIMEOUT", 10*time.Second),
+		ThrottleWindow:      getDurationOrDefault("NOTIFY_THROTTLE_WINDOW", 5*time.Minute),
+		RuleThrottleWindows: parseDurationMap(getEnvOrDefault("NOTIFY_RULE_THROTTLE_WINDOWS", "")),
+		DigestEnabled:       getBoolOrDefault("NOTIFY_DIGEST_ENABLED", true),
+		DigestInterval:      getDurationOrDefault("NOTIFY_DIGEST_INTERVAL", time.Hour),
+		DigestSeverity:      strings.ToUpper(getEnvOrDefault("NOTIFY_DIGEST_SEVERITY", "INFO")),
+		WebPush: WebPushConfig{
+			Enabled:         getBoolOrDefault("WEBPUSH_ENABLED", true),
+			VAPIDPublicKey:  getEnvOrDefault("WEBPUSH_VAPID_PUBLIC_KEY", ""),
+			VAPIDPrivateKey: getEnvOrDefault("WEBPUSH_VAPID_PRIVATE_KEY", ""),
+			Subject:         getEnvOrDefault("WEBPUSH_SUBJECT", "mailto:admin@localhost"),
+			TTL:             getDurationOrDefault("WEBPUSH_TTL", 24*time.Hour),
+			DefaultSeverity: strings.ToUpper(getEnvOrDefault("WEBPUSH_DEFAULT_SEVERITY", "CRITICAL")),
+		},
+		Incidents: IncidentConfig{
+			PagerDutyRoutingKey: getEnvOrDefault("PAGERDUTY_ROUTING_KEY", ""),
+			PagerDutyAPIToken:   getEnvOrDefault("PAGERDUTY_API_TOKEN", ""),
+			PagerDutyEventsURL:  getEnvOrDefault("PAGERDUTY_EVENTS_URL", "https://events.pagerduty.com/v2/enqueue"),
+			PagerDutyAPIURL:     getEnvOrDefault("PAGERDUTY_API_URL", "https://api.pagerduty.com"),
+			OpsgenieAPIKey:      getEnvOrDefault("OPSGENIE_API_KEY", ""),
+			OpsgenieAPIURL:      getEnvOrDefault("OPSGENIE_API_URL", "https://api.opsgenie.com"),
+			Source:              getEnvOrDefault("INCIDENT_SOURCE", "azf"),
+			MinSeverity:         strings.ToUpper(getEnvOrDefault("INCIDENT_MIN_SEVERITY", "CRITICAL")),
+			SyncInterval:        getDurationOrDefault("INCIDENT_SYNC_INTERVAL", time.Minute),
+		},
+	}
+}
+
+// parseDurationMap parses "key=duration,key=duration"; invalid entries are skipped
+func parseDurationMap(value string) map[string]time.Duration {
+	result := make(map[string]time.Duration)
+	for _, entry := range splitAndTrim(value, ",") {
+		key, raw, ok := strings.Cut(entry, "=")
+		if !ok {
+			continue
+		}
+		duration, err := time.ParseDuration(strings.TrimSpace(raw))
+		if err != nil {
+			continue
+		}
+		result[strings.TrimSpace(key)] = duration
+	}
+	return result
+}
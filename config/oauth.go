@@ -0,0 +1,68 @@
+package config
+
+import "strings"
+
+// defaultOAuthProviders are the providers looked up when OAUTH_PROVIDERS is unset
+var defaultOAuthProviders = []string{"google", "github", "microsoft", "gitlab"}
+
+// OAuthProviderConfig holds the settings of an OAuth sign-in provider, read
+// from the <NAME>_OAUTH_* variables of its name
+type OAuthProviderConfig struct {
+	// Name identifies the provider in the login and callback URLs
+	Name string
+	// Type is the preset the provider is built from: google, github,
+	// microsoft, gitlab or oidc. It defaults to the name.
+	Type         string
+	ClientID     string
+	ClientSecret string
+	// Scopes replace the default scopes of the preset
+	Scopes []string
+	// DiscoveryURL is the OpenID Connect issuer or discovery document of an
+	// oidc provider
+	DiscoveryURL string
+	// Tenant is the Microsoft Entra tenant, "common" by default
+	Tenant string
+	// BaseURL is the address of a self-managed GitLab instance
+	BaseURL string
+}
+
+// GetOAuthProviders loads the providers named in OAUTH_PROVIDERS that have a
+// client ID and secret
+func GetOAuthProviders() []OAuthProviderConfig {
+	var providers []OAuthProviderConfig
+	for _, name := range getSliceOrDefault("OAUTH_PROVIDERS", defaultOAuthProviders) {
+		name = strings.ToLower(name)
+		prefix := oauthEnvPrefix(name)
+		provider := OAuthProviderConfig{
+			Name:         name,
+			Type:         strings.ToLower(getEnvOrDefault(prefix+"TYPE", name)),
+			ClientID:     getEnvOrDefault(prefix+"CLIENT_ID", ""),
+			ClientSecret: getEnvOrDefault(prefix+"CLIENT_SECRET", ""),
+			Scopes:       getSliceOrDefault(prefix+"SCOPES", nil),
+			DiscoveryURL: getEnvOrDefault(prefix+"DISCOVERY_URL", ""),
+			Tenant:       getEnvOrDefault(prefix+"TENANT", ""),
+			BaseURL:      getEnvOrDefault(prefix+"BASE_URL", ""),
+		}
+		if provider.ClientID == "" || provider.ClientSecret == "" {
+			continue
+		}
+		providers = append(providers, provider)
+	}
+	return providers
+}
+
+// oauthEnvPrefix returns the variable prefix of a provider, e.g.
+// GOOGLE_OAUTH_ for google and MY_IDP_OAUTH_ for my-idp
+func oauthEnvPrefix(name string) string {
+	prefix := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+	return prefix + "_OAUTH_"
+}
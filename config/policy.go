@@ -1,5 +1,7 @@
 package config
 
+import "time"
+
 const (
 	POLICY_VERSION = 1
 )
@@ -16,3 +18,20 @@ const (
 	AUTH_MODE_GRADUAL_ROLLOUT = "GRADUAL_ROLLOUT"
 	AUTH_MODE_CASBIN          = "CASBIN_V2"
 )
+
+// AuditDedupWindow returns how long identical non-allowed authorization events
+// are collapsed into a single audit row. Zero disables deduplication.
+func AuditDedupWindow() time.Duration {
+	return getDurationOrDefault("AUDIT_DEDUP_WINDOW", 0)
+}
+
+// DenialStormThreshold returns how many non-allowed authorization events
+// within DenialStormWindow raise a denial storm alert. Zero disables it.
+func DenialStormThreshold() int {
+	return getIntOrDefault("DENIAL_STORM_THRESHOLD", 0)
+}
+
+// DenialStormWindow returns the window denial storm detection counts over
+func DenialStormWindow() time.Duration {
+	return getDurationOrDefault("DENIAL_STORM_WINDOW", time.Minute)
+}
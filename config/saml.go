@@ -0,0 +1,71 @@
+package config
+
+import (
+	"strings"
+	"time"
+)
+
+// SAMLConfig holds the settings of SAML single sign-on to the admin UI
+type SAMLConfig struct {
+	// IDPMetadata is the URL or file of the identity provider's metadata.
+	// SAML sign-in is off without it.
+	IDPMetadata string
+	// BaseURL is the public address of azf, which the identity provider
+	// returns users to
+	BaseURL string
+	// EntityID names azf to the identity provider; it defaults to the URL of
+	// the metadata endpoint
+	EntityID string
+	// CertFile and KeyFile hold a PEM certificate and RSA key signing the
+	// sign-in requests (optional)
+	CertFile string
+	KeyFile  string
+	// UsernameAttribute names the attribute holding the username; the NameID
+	// is used when it is empty or missing
+	UsernameAttribute string
+	EmailAttribute    string
+	NameAttribute     string
+	// RoleAttribute names the attribute whose values are mapped to roles
+	RoleAttribute string
+	// RoleMapping maps values of the role attribute to azf roles
+	RoleMapping map[string][]string
+	// ClockSkew is the clock difference with the identity provider that is
+	// tolerated
+	ClockSkew time.Duration
+}
+
+// GetSAMLConfig loads the SAML_* settings
+func GetSAMLConfig() SAMLConfig {
+	baseURL := strings.TrimRight(getEnvOrDefault("BASE_URL", "http://localhost:8080"), "/")
+	return SAMLConfig{
+		IDPMetadata:       getEnvOrDefault("SAML_IDP_METADATA", ""),
+		BaseURL:           baseURL,
+		EntityID:          getEnvOrDefault("SAML_ENTITY_ID", baseURL+"/admin-ui/saml/metadata"),
+		CertFile:          getEnvOrDefault("SAML_SP_CERT_FILE", ""),
+		KeyFile:           getEnvOrDefault("SAML_SP_KEY_FILE", ""),
+		UsernameAttribute: getEnvOrDefault("SAML_USERNAME_ATTRIBUTE", ""),
+		EmailAttribute:    getEnvOrDefault("SAML_EMAIL_ATTRIBUTE", "email"),
+		NameAttribute:     getEnvOrDefault("SAML_NAME_ATTRIBUTE", "displayName"),
+		RoleAttribute:     getEnvOrDefault("SAML_ROLE_ATTRIBUTE", "groups"),
+		RoleMapping:       parseRoleMapping(getEnvOrDefault("SAML_ROLE_MAPPING", "")),
+		ClockSkew:         getDurationOrDefault("SAML_CLOCK_SKEW", 90*time.Second),
+	}
+}
+
+// parseRoleMapping parses "value=role,value=role"; a value listed several
+// times maps to each of its roles. Invalid entries are skipped.
+func parseRoleMapping(value string) map[string][]string {
+	mapping := make(map[string][]string)
+	if value == "" {
+		return mapping
+	}
+	for _, entry := range splitAndTrim(value, ",") {
+		key, role, ok := strings.Cut(entry, "=")
+		key, role = strings.TrimSpace(key), strings.TrimSpace(role)
+		if !ok || key == "" || role == "" {
+			continue
+		}
+		mapping[key] = append(mapping[key], role)
+	}
+	return mapping
+}
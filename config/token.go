@@ -0,0 +1,48 @@
+package config
+
+import "time"
+
+// TokenConfig selects how azf signs and verifies JWTs
+type TokenConfig struct {
+	// Algorithm signs the tokens azf issues: HS256 (the default) with
+	// Secret, or RS256/ES256 (and their 384/512 variants) with PrivateKeyFile
+	Algorithm string
+	Secret    string
+	// PrivateKeyFile is the PEM signing key and KeyID its kid; an empty kid
+	// is the key's thumbprint
+	PrivateKeyFile string
+	KeyID          string
+	// PublicKeyFiles are PEM keys still accepted after a key rotation
+	PublicKeyFiles []string
+	// Issuer is set on the tokens azf issues; Issuer and Audience are
+	// required of them when set
+	Issuer   string
+	Audience string
+	// JWKSURL makes azf also accept tokens of an external identity
+	// provider, verified against the keys it publishes and required to
+	// have JWKSIssuer and JWKSAudience when set
+	JWKSURL      string
+	JWKSRefresh  time.Duration
+	JWKSIssuer   string
+	JWKSAudience string
+	// Leeway tolerates clock skew when checking expiry
+	Leeway time.Duration
+}
+
+// GetTokenConfig loads the JWT settings from the environment
+func GetTokenConfig() TokenConfig {
+	return TokenConfig{
+		Algorithm:      getEnvOrDefault("JWT_ALGORITHM", "HS256"),
+		Secret:         getEnvOrDefault("JWT_SECRET", ""),
+		PrivateKeyFile: getEnvOrDefault("JWT_PRIVATE_KEY_FILE", ""),
+		KeyID:          getEnvOrDefault("JWT_KEY_ID", ""),
+		PublicKeyFiles: getSliceOrDefault("JWT_PUBLIC_KEY_FILES", nil),
+		Issuer:         getEnvOrDefault("JWT_ISSUER", ""),
+		Audience:       getEnvOrDefault("JWT_AUDIENCE", ""),
+		JWKSURL:        getEnvOrDefault("JWT_JWKS_URL", ""),
+		JWKSRefresh:    getDurationOrDefault("JWT_JWKS_REFRESH", time.Hour),
+		JWKSIssuer:     getEnvOrDefault("JWT_JWKS_ISSUER", ""),
+		JWKSAudience:   getEnvOrDefault("JWT_JWKS_AUDIENCE", ""),
+		Leeway:         getDurationOrDefault("JWT_LEEWAY", 0),
+	}
+}
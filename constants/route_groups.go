@@ -16,4 +16,7 @@ const (
 	COMMON_PATH   = "/api/v1/common"
 	GENERIC_PATH  = "/api/v1"
 	PUBLIC_PATH   = "/api/v1/public"
+
+	// ADMIN_POLICY_API_PATH is the JSON API managing policies for automation
+	ADMIN_POLICY_API_PATH = ADMIN_PATH + "/policies"
 )
@@ -0,0 +1,12 @@
+// Package azf is the entry point of the authorization framework: it
+// initializes the Casbin enforcer and database, mounts the admin dashboard
+// and declares routes with their authorization metadata.
+//
+// The stable v1 API is made of this package, its subpackages middleware
+// (the Gin middleware to put in front of routes) and analytics (API usage
+// analytics), and the cmd/routegen command. Packages under internal/ cannot
+// be imported, and application/, domain/, infrastructure/ and initializer/
+// are implementation packages that may change between minor versions.
+// infrastructure/persistence is kept only as a deprecated alias of the
+// internal persistence package.
+package azf
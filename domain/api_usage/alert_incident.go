@@ -0,0 +1,44 @@
+package api_usage
+
+import "time"
+
+// Alert incident statuses, mirroring the incident lifecycle of PagerDuty and Opsgenie
+const (
+	IncidentTriggered    = "triggered"
+	IncidentAcknowledged = "acknowledged"
+	IncidentResolved     = "resolved"
+)
+
+// AlertIncident is an incident opened with an on-call provider for a
+// high-severity alert. It is kept open while the alert keeps firing and is
+// resolved when the alert rule clears; acknowledgments made in the provider
+// are synced back so the notification center shows who is handling it.
+type AlertIncident struct {
+	ID              string     `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	Provider        string     `gorm:"index:idx_alert_incident_key;type:varchar(20)" json:"provider"`
+	IncidentKey     string     `gorm:"index:idx_alert_incident_key;type:varchar(255)" json:"incident_key"`
+	ExternalID      string     `gorm:"type:varchar(100)" json:"external_id,omitempty"`
+	Rule            string     `gorm:"index;type:varchar(100)" json:"rule"`
+	DedupKey        string     `gorm:"type:varchar(255)" json:"dedup_key"`
+	Severity        string     `gorm:"type:varchar(20)" json:"severity"`
+	Title           string     `gorm:"type:varchar(255)" json:"title"`
+	Status          string     `gorm:"index;type:varchar(20)" json:"status"`
+	TriggerCount    int        `gorm:"not null;default:1" json:"trigger_count"`
+	LastTriggeredAt time.Time  `json:"last_triggered_at"`
+	AcknowledgedBy  string     `gorm:"type:varchar(255)" json:"acknowledged_by,omitempty"`
+	AcknowledgedAt  *time.Time `json:"acknowledged_at,omitempty"`
+	ResolvedAt      *time.Time `json:"resolved_at,omitempty"`
+	LastSyncedAt    *time.Time `json:"last_synced_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// TableName specifies the table name for AlertIncident
+func (AlertIncident) TableName() string {
+	return "alert_incidents"
+}
+
+// IsOpen reports whether the incident has not been resolved yet
+func (i *AlertIncident) IsOpen() bool {
+	return i.Status != IncidentResolved
+}
@@ -1,20 +1,29 @@
 package api_usage
 
-import "time"
+import (
+	"math"
+	"net/http"
+	"sort"
+	"time"
+)
 
 // APIUsageLog represents a record of API endpoint usage
 type APIUsageLog struct {
 	ID             string    `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	RequestID      string    `gorm:"index;type:varchar(64)" json:"request_id"` // matches X-Request-ID and the authorization audit log
 	Endpoint       string    `gorm:"index;type:varchar(255)" json:"endpoint"`
 	Method         string    `gorm:"index;type:varchar(10)" json:"method"`
 	StatusCode     int       `gorm:"index" json:"status_code"`
 	ResponseTime   int64     `gorm:"type:bigint" json:"response_time_ms"`
-	RequestSize    int       `gorm:"type:int" json:"request_size"`
-	ResponseSize   int       `gorm:"type:int" json:"response_size"`
+	RequestSize    int64     `gorm:"type:bigint" json:"request_size"`
+	ResponseSize   int64     `gorm:"type:bigint" json:"response_size"`
 	UserID         *string   `gorm:"index;type:varchar(36)" json:"user_id"`
+	APIKeyID       string    `gorm:"index;type:varchar(36)" json:"api_key_id,omitempty"` // set when the request authenticated with an API key
 	ClientIP       string    `gorm:"type:varchar(45)" json:"client_ip"`
 	UserAgent      string    `gorm:"type:text" json:"user_agent"`
 	ErrorMessage   *string   `gorm:"type:text" json:"error_message"`
+	Conditional    bool      `json:"conditional"`   // request sent If-None-Match or If-Modified-Since
+	HasValidator   bool      `json:"has_validator"` // response carried ETag or Last-Modified
 	RequestedAt    time.Time `gorm:"index" json:"requested_at"`
 	LastAccessedAt time.Time `gorm:"index" json:"last_accessed_at"`
 	CreatedAt      time.Time `json:"created_at"`
@@ -22,31 +31,178 @@ type APIUsageLog struct {
 
 // APIUsageStats represents aggregated statistics for API endpoints
 type APIUsageStats struct {
-	ID              string    `gorm:"primaryKey;type:varchar(36)" json:"id"`
-	Endpoint        string    `gorm:"index;type:varchar(255)" json:"endpoint"`
-	Method          string    `gorm:"type:varchar(10)" json:"method"`
-	TotalRequests   int64     `json:"total_requests"`
-	SuccessRequests int64     `json:"success_requests"`
-	ErrorRequests   int64     `json:"error_requests"`
-	AvgResponseTime int64     `json:"avg_response_time_ms"`
-	MaxResponseTime int64     `json:"max_response_time_ms"`
-	MinResponseTime int64     `json:"min_response_time_ms"`
-	Last24Hours     int64     `json:"last_24_hours"`
-	LastAccessedAt  time.Time `json:"last_accessed_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
-	CreatedAt       time.Time `json:"created_at"`
+	ID                    string    `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	Endpoint              string    `gorm:"index;type:varchar(255)" json:"endpoint"`
+	Method                string    `gorm:"type:varchar(10)" json:"method"`
+	TotalRequests         int64     `json:"total_requests"`
+	SuccessRequests       int64     `json:"success_requests"`
+	ErrorRequests         int64     `json:"error_requests"`
+	AvgResponseTime       int64     `json:"avg_response_time_ms"`
+	P95ResponseTime       int64     `json:"p95_response_time_ms"`
+	MaxResponseTime       int64     `json:"max_response_time_ms"`
+	MinResponseTime       int64     `json:"min_response_time_ms"`
+	Last24Hours           int64     `json:"last_24_hours"`
+	TotalRequestBytes     int64     `json:"total_request_bytes"`  // request bandwidth, in bytes
+	TotalResponseBytes    int64     `json:"total_response_bytes"` // response bandwidth, in bytes
+	MaxRequestBytes       int64     `json:"max_request_bytes"`
+	ConditionalRequests   int64     `json:"conditional_requests"`
+	NotModifiedResponses  int64     `json:"not_modified_responses"`
+	ValidatorResponses    int64     `json:"validator_responses"`
+	UncachedResponseBytes int64     `json:"uncached_response_bytes"` // 2xx bytes served without a conditional request
+	LastAccessedAt        time.Time `json:"last_accessed_at"`
+	UpdatedAt             time.Time `json:"updated_at"`
+	CreatedAt             time.Time `json:"created_at"`
 }
 
 // APIEndpointRanking represents endpoint usage ranking
 type APIEndpointRanking struct {
-	Endpoint        string `json:"endpoint"`
-	Method          string `json:"method"`
-	TotalRequests   int64  `json:"total_requests"`
-	SuccessRequests int64  `json:"success_requests"`
-	ErrorRequests   int64  `json:"error_requests"`
-	AvgResponseTime int64  `json:"avg_response_time_ms"`
-	Last24Hours     int64  `json:"last_24_hours"`
-	Rank            int    `json:"rank"`
+	Endpoint        string  `json:"endpoint"`
+	Method          string  `json:"method"`
+	TotalRequests   int64   `json:"total_requests"`
+	SuccessRequests int64   `json:"success_requests"`
+	ErrorRequests   int64   `json:"error_requests"`
+	AvgResponseTime int64   `json:"avg_response_time_ms"`
+	P95ResponseTime int64   `json:"p95_response_time_ms"`
+	ErrorRate       float64 `json:"error_rate"` // percentage of requests that failed
+	// ErrorRateLowerBound is the lower bound of the 95% Wilson score interval
+	// of the error rate, as a percentage. Few requests give a wide interval,
+	// so one failed request does not outrank many requests with some errors.
+	ErrorRateLowerBound float64 `json:"error_rate_lower_bound"`
+	Last24Hours         int64   `json:"last_24_hours"`
+	Rank                int     `json:"rank"`
+}
+
+// Columns endpoint rankings can be sorted by
+const (
+	RankingSortRequests        = "requests"
+	RankingSortErrorRate       = "error_rate"
+	RankingSortErrorRateWilson = "error_rate_wilson"
+	RankingSortP95             = "p95"
+	RankingSortAvgResponseTime = "avg_response_time"
+	RankingSortLast24Hours     = "last_24h"
+)
+
+// IsValidRankingSort reports whether sortBy is a column rankings can be sorted by
+func IsValidRankingSort(sortBy string) bool {
+	switch sortBy {
+	case RankingSortRequests, RankingSortErrorRate, RankingSortErrorRateWilson, RankingSortP95, RankingSortAvgResponseTime, RankingSortLast24Hours:
+		return true
+	}
+	return false
+}
+
+// EndpointRankingQuery selects a page of endpoint rankings. Ties are broken
+// by endpoint and method, so pages are stable.
+type EndpointRankingQuery struct {
+	SortBy    string
+	Ascending bool
+	// MinRequests leaves out endpoints with fewer requests, so an endpoint
+	// called once does not top the error rate ranking
+	MinRequests int64
+	Limit       int
+	Offset      int
+}
+
+// WithErrorRate sets the error rate and its lower bound from the request counts
+func (r APIEndpointRanking) WithErrorRate() APIEndpointRanking {
+	if r.TotalRequests > 0 {
+		r.ErrorRate = float64(r.ErrorRequests) / float64(r.TotalRequests) * 100
+	}
+	r.ErrorRateLowerBound = WilsonLowerBound(r.ErrorRequests, r.TotalRequests) * 100
+	return r
+}
+
+// wilsonZ is the normal quantile of a 95% confidence interval
+const wilsonZ = 1.96
+
+// WilsonLowerBound returns the lower bound of the 95% Wilson score interval
+// of the proportion of failures in total, or 0 without any
+func WilsonLowerBound(failures int64, total int64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	n := float64(total)
+	p := float64(failures) / n
+	z2 := wilsonZ * wilsonZ
+	center := p + z2/(2*n)
+	margin := wilsonZ * math.Sqrt(p*(1-p)/n+z2/(4*n*n))
+	return math.Max(0, (center-margin)/(1+z2/n))
+}
+
+// RankByErrorRateLowerBound sorts rankings with their error rates set by
+// the lower bound of the error rate, and returns the page query selects
+func RankByErrorRateLowerBound(rankings []APIEndpointRanking, query EndpointRankingQuery) []APIEndpointRanking {
+	sort.SliceStable(rankings, func(i, j int) bool {
+		a, b := rankings[i], rankings[j]
+		if a.ErrorRateLowerBound != b.ErrorRateLowerBound {
+			if query.Ascending {
+				return a.ErrorRateLowerBound < b.ErrorRateLowerBound
+			}
+			return a.ErrorRateLowerBound > b.ErrorRateLowerBound
+		}
+		if a.Endpoint != b.Endpoint {
+			return a.Endpoint < b.Endpoint
+		}
+		return a.Method < b.Method
+	})
+
+	offset := min(max(query.Offset, 0), len(rankings))
+	end := len(rankings)
+	if query.Limit > 0 {
+		end = min(offset+query.Limit, end)
+	}
+	page := rankings[offset:end]
+	for i := range page {
+		page[i].Rank = offset + i + 1
+	}
+	return page
+}
+
+// Client dimensions used to aggregate usage per consumer
+const (
+	ClientDimensionUser   = "user"
+	ClientDimensionIP     = "ip"
+	ClientDimensionAPIKey = "api_key"
+)
+
+// ClientUsageAggregate represents aggregated usage for a single client identity
+type ClientUsageAggregate struct {
+	Identity            string  `json:"identity"`
+	TotalRequests       int64   `json:"total_requests"`
+	ErrorRequests       int64   `json:"error_requests"`
+	RateLimitedRequests int64   `json:"rate_limited_requests"`
+	AvgResponseTime     float64 `json:"avg_response_time_ms"`
+	RequestBytes        int64   `json:"request_bytes"`
+	ResponseBytes       int64   `json:"response_bytes"`
+}
+
+// APIUsageTiming is a lightweight projection of an APIUsageLog used for time bucketing
+type APIUsageTiming struct {
+	RequestedAt  time.Time `json:"requested_at"`
+	ResponseTime int64     `json:"response_time_ms"`
+}
+
+// APIUsageHourlyBucket is the request volume and latency for one hour,
+// used by the usage trend and latency heatmap
+type APIUsageHourlyBucket struct {
+	Bucket            time.Time `json:"bucket"`
+	RequestCount      int64     `json:"request_count"`
+	SuccessCount      int64     `json:"success_count"`
+	TotalResponseTime int64     `json:"total_response_time_ms"`
+}
+
+// APIUsageStatusCount is the number of requests to an endpoint answered
+// with one status code
+type APIUsageStatusCount struct {
+	StatusCode      int     `json:"status_code"`
+	Requests        int64   `json:"requests"`
+	AvgResponseTime float64 `json:"avg_response_time_ms"`
+}
+
+// IsSuccess reports whether the request succeeded. A 304 Not Modified is a
+// successful cache revalidation and is not counted as an error.
+func (l APIUsageLog) IsSuccess() bool {
+	return (l.StatusCode >= 200 && l.StatusCode < 300) || l.StatusCode == http.StatusNotModified
 }
 
 // TableName specifies the table name for APIUsageLog
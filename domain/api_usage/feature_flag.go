@@ -0,0 +1,45 @@
+package api_usage
+
+import "time"
+
+// Feature flags for AZF's own subsystems
+const (
+	FeatureAuditLogging     = "audit_logging"
+	FeatureRateLimiting     = "rate_limiting"
+	FeatureWebhooks         = "webhooks"
+	FeatureUsageTracking    = "usage_tracking"
+	FeatureAnomalyDetection = "anomaly_detection"
+)
+
+// FeatureFlag switches a subsystem on or off at runtime for one environment.
+// Subsystems without a flag row use the value they were set up with.
+type FeatureFlag struct {
+	Name        string    `gorm:"primaryKey;type:varchar(100)" json:"name"`
+	Environment string    `gorm:"primaryKey;type:varchar(50)" json:"environment"`
+	Enabled     bool      `json:"enabled"`
+	UpdatedBy   string    `gorm:"type:varchar(100)" json:"updated_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for FeatureFlag
+func (FeatureFlag) TableName() string {
+	return "feature_flags"
+}
+
+// FeatureFlagChange records who switched a flag and why
+type FeatureFlagChange struct {
+	ID          string    `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	Name        string    `gorm:"index;type:varchar(100)" json:"name"`
+	Environment string    `gorm:"type:varchar(50)" json:"environment"`
+	Enabled     bool      `json:"enabled"`
+	Previous    *bool     `json:"previous,omitempty"`
+	ChangedBy   string    `gorm:"type:varchar(100)" json:"changed_by"`
+	Reason      string    `gorm:"type:varchar(500)" json:"reason"`
+	ChangedAt   time.Time `gorm:"index" json:"changed_at"`
+}
+
+// TableName specifies the table name for FeatureFlagChange
+func (FeatureFlagChange) TableName() string {
+	return "feature_flag_changes"
+}
@@ -0,0 +1,27 @@
+package api_usage
+
+import "time"
+
+// RateLimitOverride replaces the role-based rate limit for a single client.
+// Overrides with an ExpiresAt in the past are kept for reference but no
+// longer applied, which allows temporary increases to lapse on their own.
+type RateLimitOverride struct {
+	Identity          string     `gorm:"primaryKey;type:varchar(100)" json:"identity"`
+	RequestsPerMinute int        `json:"requests_per_minute"`
+	BurstAllowance    int        `json:"burst_allowance"`
+	Reason            string     `gorm:"type:varchar(500)" json:"reason"`
+	ExpiresAt         *time.Time `gorm:"index" json:"expires_at,omitempty"`
+	CreatedBy         string     `gorm:"type:varchar(100)" json:"created_by"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// TableName specifies the table name for RateLimitOverride
+func (RateLimitOverride) TableName() string {
+	return "rate_limit_overrides"
+}
+
+// IsActive reports whether the override applies at the given time
+func (o RateLimitOverride) IsActive(now time.Time) bool {
+	return o.ExpiresAt == nil || now.Before(*o.ExpiresAt)
+}
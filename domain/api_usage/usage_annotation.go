@@ -0,0 +1,26 @@
+package api_usage
+
+import "time"
+
+// Annotation kinds that can be shown on analytics charts
+const (
+	AnnotationKindIncident   = "incident"
+	AnnotationKindDeployment = "deployment"
+)
+
+// UsageAnnotation marks a known event (incident, deployment) on the
+// usage and latency trend charts so anomalies can be correlated with it
+type UsageAnnotation struct {
+	ID          string    `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	Kind        string    `gorm:"index;type:varchar(20)" json:"kind"`
+	Title       string    `gorm:"type:varchar(200)" json:"title"`
+	Description string    `gorm:"type:text" json:"description"`
+	OccurredAt  time.Time `gorm:"index" json:"occurred_at"`
+	CreatedBy   string    `gorm:"type:varchar(100)" json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for UsageAnnotation
+func (UsageAnnotation) TableName() string {
+	return "api_usage_annotations"
+}
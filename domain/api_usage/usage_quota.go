@@ -0,0 +1,75 @@
+package api_usage
+
+import "time"
+
+// Subjects a usage quota can apply to
+const (
+	QuotaSubjectUser   = "user"
+	QuotaSubjectRole   = "role"
+	QuotaSubjectAPIKey = "api_key"
+)
+
+// Periods a usage quota is counted over, starting at midnight UTC
+const (
+	QuotaPeriodDaily   = "daily"
+	QuotaPeriodMonthly = "monthly"
+)
+
+// UsageQuota caps the requests a user, role or API key can make in a day or
+// a month, on top of the per-minute rate limits. Used counts the requests
+// of the period starting at PeriodStart; a role quota is shared by every
+// user with the role.
+type UsageQuota struct {
+	ID          string    `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	SubjectType string    `gorm:"uniqueIndex:idx_usage_quotas_subject;type:varchar(20)" json:"subject_type"`
+	Subject     string    `gorm:"uniqueIndex:idx_usage_quotas_subject;type:varchar(100)" json:"subject"`
+	Period      string    `gorm:"uniqueIndex:idx_usage_quotas_subject;type:varchar(20)" json:"period"`
+	Limit       int64     `gorm:"column:request_limit" json:"limit"`
+	Used        int64     `json:"used"`
+	PeriodStart time.Time `gorm:"index" json:"period_start"`
+	Reason      string    `gorm:"type:varchar(500)" json:"reason"`
+	CreatedBy   string    `gorm:"type:varchar(100)" json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for UsageQuota
+func (UsageQuota) TableName() string {
+	return "usage_quotas"
+}
+
+// PeriodEnd returns when the quota's current period ends
+func (q UsageQuota) PeriodEnd() time.Time {
+	return QuotaPeriodEnd(q.Period, q.PeriodStart)
+}
+
+// IsValidQuotaSubject reports whether subjectType is a known quota subject
+func IsValidQuotaSubject(subjectType string) bool {
+	switch subjectType {
+	case QuotaSubjectUser, QuotaSubjectRole, QuotaSubjectAPIKey:
+		return true
+	}
+	return false
+}
+
+// IsValidQuotaPeriod reports whether period is a known quota period
+func IsValidQuotaPeriod(period string) bool {
+	return period == QuotaPeriodDaily || period == QuotaPeriodMonthly
+}
+
+// QuotaPeriodStart returns the start of the period containing t
+func QuotaPeriodStart(period string, t time.Time) time.Time {
+	t = t.UTC()
+	if period == QuotaPeriodMonthly {
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// QuotaPeriodEnd returns the end of the period starting at start
+func QuotaPeriodEnd(period string, start time.Time) time.Time {
+	if period == QuotaPeriodMonthly {
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
@@ -0,0 +1,39 @@
+package api_usage
+
+import "time"
+
+// WebPushSubscription is a browser push subscription registered by an admin
+// so alerts reach them even when the dashboard tab is closed. Only alerts at
+// or above MinSeverity are pushed to it.
+type WebPushSubscription struct {
+	ID            string     `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	AdminUsername string     `gorm:"index;type:varchar(100)" json:"admin_username"`
+	Endpoint      string     `gorm:"uniqueIndex;type:varchar(768)" json:"endpoint"`
+	P256dh        string     `gorm:"type:varchar(200)" json:"-"`
+	Auth          string     `gorm:"type:varchar(100)" json:"-"`
+	MinSeverity   string     `gorm:"type:varchar(20)" json:"min_severity"`
+	UserAgent     string     `gorm:"type:varchar(500)" json:"user_agent"`
+	LastPushedAt  *time.Time `json:"last_pushed_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// TableName specifies the table name for WebPushSubscription
+func (WebPushSubscription) TableName() string {
+	return "web_push_subscriptions"
+}
+
+// WebPushVAPIDKeys is the application server key pair used to sign push
+// requests when no keys are configured in the environment. Browsers bind
+// subscriptions to the public key, so it must not change between restarts.
+type WebPushVAPIDKeys struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	PublicKey  string    `gorm:"type:varchar(100)" json:"public_key"`
+	PrivateKey string    `gorm:"type:varchar(100)" json:"-"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for WebPushVAPIDKeys
+func (WebPushVAPIDKeys) TableName() string {
+	return "web_push_vapid_keys"
+}
@@ -0,0 +1,12 @@
+package authorization_audit
+
+import apperrors "github.com/aruncs31s/azf/shared/errors"
+
+// Errors returned by webhook repositories. They also match the shared
+// apperrors.ErrNotFound.
+var (
+	// ErrWebhookEventNotFound is returned when no webhook event matches the lookup
+	ErrWebhookEventNotFound = apperrors.Newf(apperrors.ErrNotFound, "webhook event not found")
+	// ErrWebhookSubscriptionNotFound is returned when no webhook subscription matches the lookup
+	ErrWebhookSubscriptionNotFound = apperrors.Newf(apperrors.ErrNotFound, "webhook subscription not found")
+)
@@ -1,6 +1,9 @@
 package authorization_audit
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // AuditLogRepository defines the interface for audit log persistence operations
 type AuditLogRepository interface {
@@ -44,6 +47,23 @@ type WebhookEventRepository interface {
 	WebhookEventWriter
 }
 
+// WebhookEventRange selects the events sent to a delivery URL. Events are
+// returned in timestamp order, ties broken by ID.
+type WebhookEventRange struct {
+	DeliveryURL string
+	// EventTypes limits the range to these types; empty matches every type
+	EventTypes []string
+	// Since (inclusive) and Until (exclusive) bound the event timestamps;
+	// zero times leave the range open
+	Since time.Time
+	Until time.Time
+	// AfterTimestamp and AfterID continue the range after that event
+	AfterTimestamp time.Time
+	AfterID        string
+	// Limit bounds the number of events returned
+	Limit int
+}
+
 // WebhookEventReader defines the interface for webhook event read operations
 type WebhookEventReader interface {
 	// FindByID retrieves a webhook event by ID
@@ -66,6 +86,9 @@ type WebhookEventReader interface {
 
 	// FindAll retrieves all webhook events
 	FindAll(ctx context.Context) ([]*WebhookEvent, error)
+
+	// FindRange retrieves the webhook events in a range, for replay
+	FindRange(ctx context.Context, r WebhookEventRange) ([]*WebhookEvent, error)
 }
 
 // WebhookEventWriter defines the interface for webhook event write operations
@@ -121,3 +144,16 @@ type WebhookSubscriptionWriter interface {
 	// Delete removes a webhook subscription
 	Delete(ctx context.Context, id string) error
 }
+
+// WebhookDeliveryRepository defines the interface for persisting webhook delivery attempts
+type WebhookDeliveryRepository interface {
+	// Record stores a delivery attempt
+	Record(ctx context.Context, attempt *WebhookDeliveryAttempt) error
+
+	// FindByEventID retrieves the delivery attempts of an event, oldest first
+	FindByEventID(ctx context.Context, eventID string) ([]*WebhookDeliveryAttempt, error)
+
+	// FindBySubscriptionID retrieves the most recent delivery attempts of a
+	// subscription, newest first
+	FindBySubscriptionID(ctx context.Context, subscriptionID string, limit int) ([]*WebhookDeliveryAttempt, error)
+}
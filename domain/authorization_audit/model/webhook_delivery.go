@@ -0,0 +1,23 @@
+package authorization_audit
+
+import "time"
+
+// WebhookDeliveryAttempt records one attempt to deliver a webhook event to a
+// subscription
+type WebhookDeliveryAttempt struct {
+	ID             string
+	EventID        string
+	SubscriptionID string
+	// Attempt counts the attempts for the event, starting at 1
+	Attempt    int
+	URL        string
+	StatusCode int
+	Success    bool
+	Error      string
+	// ResponseBody holds the start of the response body, as far as it was read
+	ResponseBody string
+	Duration     time.Duration
+	AttemptedAt  time.Time
+	// Redelivery is set for attempts an administrator triggered by hand
+	Redelivery bool
+}
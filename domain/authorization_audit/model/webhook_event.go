@@ -3,6 +3,8 @@ package authorization_audit
 import (
 	"fmt"
 	"time"
+
+	"github.com/aruncs31s/azf/shared/clock"
 )
 
 // WebhookEventType is a value object representing types of webhook events
@@ -35,6 +37,21 @@ var validEventTypes = map[string]bool{
 	"policy.violation":      true,
 }
 
+// WebhookEventTypes returns the predefined webhook event types
+func WebhookEventTypes() []*WebhookEventType {
+	return []*WebhookEventType{
+		EventTypeAuthorizationGranted,
+		EventTypeAuthorizationDenied,
+		EventTypeAuditLogCreated,
+		EventTypeAdminLogin,
+		EventTypeAdminLogout,
+		EventTypeResourceAccessed,
+		EventTypeResourceModified,
+		EventTypeResourceDeleted,
+		EventTypePolicyViolation,
+	}
+}
+
 // NewWebhookEventType creates a new WebhookEventType with validation
 func NewWebhookEventType(eventType string) (*WebhookEventType, error) {
 	if eventType == "" {
@@ -201,6 +218,52 @@ func NewWebhookEvent(
 	}, nil
 }
 
+// WebhookEventState is the persisted state of a webhook event
+type WebhookEventState struct {
+	ID          string
+	EventType   string
+	AuditLogID  string
+	Payload     map[string]interface{}
+	Timestamp   time.Time
+	Status      string
+	DeliveryURL string
+	RetryCount  int
+	MaxRetries  int
+	LastError   string
+	LastAttempt *time.Time
+	NextRetry   *time.Time
+	Metadata    map[string]interface{}
+}
+
+// RestoreWebhookEvent rebuilds a webhook event from its persisted state
+func RestoreWebhookEvent(state WebhookEventState) (*WebhookEvent, error) {
+	eventType, err := NewWebhookEventType(state.EventType)
+	if err != nil {
+		return nil, err
+	}
+	status, err := NewWebhookEventStatus(state.Status)
+	if err != nil {
+		return nil, err
+	}
+	event, err := NewWebhookEvent(state.ID, eventType, state.AuditLogID, state.Payload, state.Timestamp, state.DeliveryURL)
+	if err != nil {
+		return nil, err
+	}
+
+	event.status = status
+	event.retryCount = state.RetryCount
+	if state.MaxRetries > 0 {
+		event.maxRetries = state.MaxRetries
+	}
+	event.lastError = state.LastError
+	event.lastAttempt = state.LastAttempt
+	event.nextRetry = state.NextRetry
+	if state.Metadata != nil {
+		event.metadata = state.Metadata
+	}
+	return event, nil
+}
+
 // Getters
 func (w *WebhookEvent) ID() string {
 	return w.id
@@ -270,6 +333,11 @@ func (w *WebhookEvent) Metadata() map[string]interface{} {
 
 // Business logic methods
 
+// IsTest reports whether the event was sent to test a subscription
+func (w *WebhookEvent) IsTest() bool {
+	return w.auditLogID == WebhookTestAuditLogID
+}
+
 // CanRetry checks if the event can be retried
 func (w *WebhookEvent) CanRetry() bool {
 	if w.retryCount >= w.maxRetries {
@@ -284,7 +352,7 @@ func (w *WebhookEvent) MarkAsDelivered() error {
 		return fmt.Errorf("invalid webhook event status")
 	}
 	w.status = WebhookStatusDelivered
-	now := time.Now()
+	now := clock.Now()
 	w.lastAttempt = &now
 	w.lastError = ""
 	return nil
@@ -300,7 +368,7 @@ func (w *WebhookEvent) MarkAsFailed(errorMsg string) error {
 	}
 	w.status = WebhookStatusFailed
 	w.lastError = errorMsg
-	now := time.Now()
+	now := clock.Now()
 	w.lastAttempt = &now
 	return nil
 }
@@ -316,7 +384,7 @@ func (w *WebhookEvent) MarkForRetry(errorMsg string) error {
 	w.retryCount++
 	w.status = WebhookStatusRetrying
 	w.lastError = errorMsg
-	now := time.Now()
+	now := clock.Now()
 	w.lastAttempt = &now
 
 	// Exponential backoff: 2^retryCount minutes
@@ -327,9 +395,21 @@ func (w *WebhookEvent) MarkForRetry(errorMsg string) error {
 	return nil
 }
 
+// Requeue resets a failed or abandoned event so it is delivered again with a
+// fresh retry budget
+func (w *WebhookEvent) Requeue() error {
+	if !w.status.IsFailed() && !w.status.Equals(WebhookStatusAbandoned) {
+		return fmt.Errorf("only failed or abandoned webhook events can be requeued")
+	}
+	w.status = WebhookStatusPending
+	w.retryCount = 0
+	w.nextRetry = nil
+	return nil
+}
+
 // IsRetryable checks if the event should be retried
 func (w *WebhookEvent) IsRetryable() bool {
-	return w.CanRetry() && w.nextRetry != nil && time.Now().After(*w.nextRetry)
+	return w.CanRetry() && w.nextRetry != nil && clock.Now().After(*w.nextRetry)
 }
 
 // GetDeliveryAttempt returns the formatted attempt information
@@ -0,0 +1,157 @@
+package authorization_audit
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Keys of the filters a webhook subscription can set. Each filter takes one
+// value or a list of values; an event passes a filter when it matches any of
+// them, and a subscription receives an event only when it passes every filter.
+const (
+	// WebhookFilterResourcePrefix matches the start of the event's resource
+	WebhookFilterResourcePrefix = "resource_prefix"
+	// WebhookFilterRole matches the event's role
+	WebhookFilterRole = "role"
+	// WebhookFilterResult matches "granted" or "denied"
+	WebhookFilterResult = "result"
+	// WebhookFilterEnvironment matches the event's environment
+	WebhookFilterEnvironment = "environment"
+	// WebhookFilterTenant matches the event's tenant_id
+	WebhookFilterTenant = "tenant"
+)
+
+// Authorization results the result filter matches
+const (
+	WebhookResultGranted = "granted"
+	WebhookResultDenied  = "denied"
+)
+
+// WebhookTestAuditLogID is the audit log ID of test events. They are sent to
+// the subscription under test whatever its filters.
+const WebhookTestAuditLogID = "webhook-test"
+
+// webhookFilterFields maps the filter keys to the event data field they match
+var webhookFilterFields = map[string]string{
+	WebhookFilterResourcePrefix: "resource",
+	WebhookFilterRole:           "role",
+	WebhookFilterResult:         "result",
+	WebhookFilterEnvironment:    "environment",
+	WebhookFilterTenant:         "tenant_id",
+}
+
+// WebhookFilterKeys returns the filter keys subscriptions can set
+func WebhookFilterKeys() []string {
+	return []string{
+		WebhookFilterResourcePrefix,
+		WebhookFilterRole,
+		WebhookFilterResult,
+		WebhookFilterEnvironment,
+		WebhookFilterTenant,
+	}
+}
+
+// ParseWebhookFilter checks a filter and returns its values. value is a
+// string or a list of strings, as decoded from JSON.
+func ParseWebhookFilter(key string, value interface{}) ([]string, error) {
+	if _, ok := webhookFilterFields[key]; !ok {
+		return nil, fmt.Errorf("unknown webhook filter %q, use one of %s", key, strings.Join(WebhookFilterKeys(), ", "))
+	}
+
+	var values []string
+	switch v := value.(type) {
+	case string:
+		values = []string{v}
+	case []string:
+		values = v
+	case []interface{}:
+		for _, item := range v {
+			str, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("webhook filter %s must be a string or a list of strings", key)
+			}
+			values = append(values, str)
+		}
+	default:
+		return nil, fmt.Errorf("webhook filter %s must be a string or a list of strings", key)
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("webhook filter %s needs at least one value", key)
+	}
+
+	parsed := make([]string, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return nil, fmt.Errorf("webhook filter %s cannot have empty values", key)
+		}
+		if key == WebhookFilterResult {
+			value = strings.ToLower(value)
+			if value != WebhookResultGranted && value != WebhookResultDenied {
+				return nil, fmt.Errorf("webhook filter %s must be %s or %s", key, WebhookResultGranted, WebhookResultDenied)
+			}
+		}
+		if key == WebhookFilterResourcePrefix && !strings.HasPrefix(value, "/") {
+			return nil, fmt.Errorf("webhook filter %s must start with /", key)
+		}
+		parsed = append(parsed, value)
+	}
+	return parsed, nil
+}
+
+// Matches reports whether the event passes the subscription's filters. Events
+// without the field a filter matches do not pass it.
+func (w *WebhookSubscription) Matches(event *WebhookEvent) bool {
+	if event == nil {
+		return false
+	}
+	if event.IsTest() {
+		return true
+	}
+	for key, value := range w.filters {
+		values, err := ParseWebhookFilter(key, value)
+		if err != nil {
+			// Filters are checked when set; one that no longer parses
+			// matches nothing rather than everything
+			return false
+		}
+		if !matchesWebhookFilter(key, values, webhookEventField(event, key)) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesWebhookFilter reports whether field matches any of values
+func matchesWebhookFilter(key string, values []string, field string) bool {
+	if field == "" {
+		return false
+	}
+	for _, value := range values {
+		if key == WebhookFilterResourcePrefix {
+			if strings.HasPrefix(field, value) {
+				return true
+			}
+			continue
+		}
+		if strings.EqualFold(field, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// webhookEventField returns the event data field the filter key matches. The
+// result of authorization events follows from their type.
+func webhookEventField(event *WebhookEvent, key string) string {
+	if key == WebhookFilterResult {
+		switch {
+		case event.EventType().Equals(EventTypeAuthorizationGranted):
+			return WebhookResultGranted
+		case event.EventType().Equals(EventTypeAuthorizationDenied), event.EventType().Equals(EventTypePolicyViolation):
+			return WebhookResultDenied
+		}
+	}
+	value, _ := event.payload[webhookFilterFields[key]].(string)
+	return value
+}
@@ -3,6 +3,8 @@ package authorization_audit
 import (
 	"testing"
 	"time"
+
+	"github.com/aruncs31s/azf/shared/clock"
 )
 
 // TestWebhookEventTypeCreation tests creating webhook event types
@@ -85,6 +87,29 @@ func TestWebhookEventRetry(t *testing.T) {
 	}
 }
 
+// TestWebhookEventRetryBackoff tests that events become retryable once their
+// backoff has passed
+func TestWebhookEventRetryBackoff(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	clock.SetDefault(fake)
+	defer clock.SetDefault(nil)
+
+	event, _ := NewWebhookEvent("webhook-1", EventTypeAuthorizationGranted, "audit-123",
+		map[string]interface{}{}, fake.Now(), "https://example.com")
+	if err := event.MarkForRetry("connection timeout"); err != nil {
+		t.Fatalf("MarkForRetry() error = %v", err)
+	}
+
+	fake.Advance(time.Minute)
+	if event.IsRetryable() {
+		t.Error("expected event to wait for its backoff")
+	}
+	fake.Advance(time.Minute + time.Second)
+	if !event.IsRetryable() {
+		t.Error("expected event to be retryable after its backoff")
+	}
+}
+
 // TestWebhookEventDelivery tests webhook delivery marking
 func TestWebhookEventDelivery(t *testing.T) {
 	event, _ := NewWebhookEvent("webhook-1", EventTypeAuthorizationGranted, "audit-123",
@@ -240,6 +265,68 @@ func TestWebhookSubscriptionHeaders(t *testing.T) {
 	}
 }
 
+// TestWebhookSubscriptionFilters tests filter validation and matching
+func TestWebhookSubscriptionFilters(t *testing.T) {
+	endpoint, _ := NewWebhookEndpoint("https://example.com/webhooks")
+	eventTypes := []*WebhookEventType{EventTypeAuthorizationGranted, EventTypeAuthorizationDenied}
+
+	sub, _ := NewWebhookSubscription("sub-1", endpoint, eventTypes,
+		"super-secret-32-character-minimum-key-here", "Test")
+
+	invalid := map[string]interface{}{
+		"unknown":         "value",
+		"result":          "maybe",
+		"resource_prefix": "api/",
+		"role":            []interface{}{},
+		"environment":     42,
+	}
+	for key, value := range invalid {
+		if err := sub.SetFilter(key, value); err == nil {
+			t.Errorf("SetFilter(%s, %v) expected error", key, value)
+		}
+	}
+
+	if err := sub.SetFilter("resource_prefix", []interface{}{"/api/admin", "/api/billing"}); err != nil {
+		t.Fatalf("SetFilter() error = %v", err)
+	}
+	if err := sub.SetFilter("result", "Denied"); err != nil {
+		t.Fatalf("SetFilter() error = %v", err)
+	}
+
+	newEvent := func(eventType *WebhookEventType, auditLogID string, resource string) *WebhookEvent {
+		event, err := NewWebhookEvent("event-1", eventType, auditLogID,
+			map[string]interface{}{"resource": resource}, time.Now(), endpoint.Value())
+		if err != nil {
+			t.Fatalf("NewWebhookEvent() error = %v", err)
+		}
+		return event
+	}
+
+	tests := []struct {
+		name  string
+		event *WebhookEvent
+		want  bool
+	}{
+		{"matching resource and result", newEvent(EventTypeAuthorizationDenied, "audit-1", "/api/admin/users"), true},
+		{"other resource", newEvent(EventTypeAuthorizationDenied, "audit-1", "/api/posts"), false},
+		{"other result", newEvent(EventTypeAuthorizationGranted, "audit-1", "/api/billing/invoices"), false},
+		{"test event", newEvent(EventTypeAuthorizationGranted, WebhookTestAuditLogID, "/api/posts"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sub.Matches(tt.event); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	sub.RemoveFilter("resource_prefix")
+	sub.RemoveFilter("result")
+	if !sub.Matches(newEvent(EventTypeAuthorizationGranted, "audit-1", "/api/posts")) {
+		t.Error("expected a subscription without filters to match every event")
+	}
+}
+
 // TestWebhookSubscriptionActivation tests subscription activation/deactivation
 func TestWebhookSubscriptionActivation(t *testing.T) {
 	endpoint, _ := NewWebhookEndpoint("https://example.com/webhooks")
@@ -306,3 +393,44 @@ func TestWebhookSubscriptionHealth(t *testing.T) {
 		t.Error("expected subscription to be unhealthy at failure count >= 2")
 	}
 }
+
+// TestWebhookSubscriptionTransport tests validation of transport security options
+func TestWebhookSubscriptionTransport(t *testing.T) {
+	httpsEndpoint, _ := NewWebhookEndpoint("https://example.com/webhooks")
+	httpEndpoint, _ := NewWebhookEndpoint("http://example.com/webhooks")
+	eventTypes := []*WebhookEventType{EventTypeAuthorizationGranted}
+
+	sub, _ := NewWebhookSubscription("sub-1", httpsEndpoint, eventTypes,
+		"super-secret-32-character-minimum-key-here", "Test")
+	if !sub.Transport().IsZero() {
+		t.Error("expected no transport options initially")
+	}
+
+	invalid := []WebhookTransport{
+		{MinTLSVersion: "1.1"},
+		{CABundle: "not a certificate"},
+		{ClientCertificate: "-----BEGIN CERTIFICATE-----"},
+		{PinnedIPs: []string{"example.com"}},
+	}
+	for _, transport := range invalid {
+		if err := sub.SetTransport(transport); err == nil {
+			t.Errorf("expected error for transport %+v", transport)
+		}
+	}
+
+	if err := sub.SetTransport(WebhookTransport{MinTLSVersion: WebhookTLSVersion13, PinnedIPs: []string{"10.0.0.5", "::1"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sub.Transport().TLSVersion() == 0 || len(sub.Transport().PinnedIPs) != 2 {
+		t.Errorf("expected transport options to be set, got %+v", sub.Transport())
+	}
+
+	plain, _ := NewWebhookSubscription("sub-2", httpEndpoint, eventTypes,
+		"super-secret-32-character-minimum-key-here", "Test")
+	if err := plain.SetTransport(WebhookTransport{MinTLSVersion: WebhookTLSVersion12}); err == nil {
+		t.Error("expected TLS options to be rejected for an http endpoint")
+	}
+	if err := plain.SetTransport(WebhookTransport{PinnedIPs: []string{"127.0.0.1"}}); err != nil {
+		t.Errorf("expected IP pinning to be allowed for an http endpoint: %v", err)
+	}
+}
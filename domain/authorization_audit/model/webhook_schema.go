@@ -0,0 +1,204 @@
+package authorization_audit
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WebhookSchemaVersion is the version of the webhook payload schemas, sent as
+// schema_version in every payload. Within a version fields are only added, so
+// receivers must ignore fields they do not know; removing or changing a field
+// needs a new version.
+const WebhookSchemaVersion = "1"
+
+// webhookEnvelopeSchema names the schema of the payload around the event data
+const webhookEnvelopeSchema = "envelope"
+
+//go:embed schemas/v1/*.json
+var webhookSchemaFiles embed.FS
+
+// webhookSchemas holds the raw schemas of WebhookSchemaVersion by name: the
+// event types and webhookEnvelopeSchema
+var webhookSchemas = loadWebhookSchemas()
+
+// WebhookSchema is the subset of JSON Schema the webhook payloads are
+// described and validated with
+type WebhookSchema struct {
+	Type                 string                   `json:"type,omitempty"`
+	Required             []string                 `json:"required,omitempty"`
+	Properties           map[string]WebhookSchema `json:"properties,omitempty"`
+	Items                *WebhookSchema           `json:"items,omitempty"`
+	Enum                 []string                 `json:"enum,omitempty"`
+	Format               string                   `json:"format,omitempty"`
+	AdditionalProperties *bool                    `json:"additionalProperties,omitempty"`
+	Examples             []map[string]interface{} `json:"examples,omitempty"`
+}
+
+func loadWebhookSchemas() map[string]json.RawMessage {
+	entries, err := webhookSchemaFiles.ReadDir("schemas/v1")
+	if err != nil {
+		panic(fmt.Sprintf("webhook schemas: %v", err))
+	}
+	schemas := make(map[string]json.RawMessage, len(entries))
+	for _, entry := range entries {
+		raw, err := webhookSchemaFiles.ReadFile("schemas/v1/" + entry.Name())
+		if err != nil {
+			panic(fmt.Sprintf("webhook schemas: %v", err))
+		}
+		schemas[strings.TrimSuffix(entry.Name(), ".json")] = raw
+	}
+	return schemas
+}
+
+// WebhookSchemaJSON returns the JSON schema of the data of an event type, or
+// of the payload envelope for "envelope"
+func WebhookSchemaJSON(name string) (json.RawMessage, bool) {
+	raw, ok := webhookSchemas[name]
+	return raw, ok
+}
+
+// WebhookSchemaNames returns the names of the published schemas, sorted
+func WebhookSchemaNames() []string {
+	names := make([]string, 0, len(webhookSchemas))
+	for name := range webhookSchemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseWebhookSchema returns the parsed schema of name
+func ParseWebhookSchema(name string) (*WebhookSchema, error) {
+	raw, ok := webhookSchemas[name]
+	if !ok {
+		return nil, fmt.Errorf("no webhook schema for %s", name)
+	}
+	var schema WebhookSchema
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		return nil, fmt.Errorf("invalid webhook schema %s: %w", name, err)
+	}
+	return &schema, nil
+}
+
+// ValidateWebhookData checks the data of an event against the schema of its type
+func ValidateWebhookData(eventType string, data map[string]interface{}) error {
+	schema, err := ParseWebhookSchema(eventType)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("invalid %s data: %w", eventType, err)
+	}
+	return schema.validate(body, "data")
+}
+
+// ValidateWebhookPayload checks an encoded webhook payload against the
+// envelope schema and its data against the schema of its event type
+func ValidateWebhookPayload(body []byte) error {
+	envelope, err := ParseWebhookSchema(webhookEnvelopeSchema)
+	if err != nil {
+		return err
+	}
+	if err := envelope.validate(body, "payload"); err != nil {
+		return err
+	}
+
+	var payload struct {
+		Type string          `json:"type"`
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return fmt.Errorf("invalid webhook payload: %w", err)
+	}
+	schema, err := ParseWebhookSchema(payload.Type)
+	if err != nil {
+		return err
+	}
+	return schema.validate(payload.Data, "data")
+}
+
+// validate checks the JSON value in body against the schema; path names the
+// value in errors
+func (s *WebhookSchema) validate(body []byte, path string) error {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("%s: invalid JSON: %w", path, err)
+	}
+	return s.check(value, path)
+}
+
+func (s *WebhookSchema) check(value interface{}, path string) error {
+	switch s.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected an object", path)
+		}
+		for _, name := range s.Required {
+			if _, ok := object[name]; !ok {
+				return fmt.Errorf("%s: missing required field %s", path, name)
+			}
+		}
+		for name, field := range object {
+			property, ok := s.Properties[name]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					return fmt.Errorf("%s: unknown field %s", path, name)
+				}
+				continue
+			}
+			if err := property.check(field, path+"."+name); err != nil {
+				return err
+			}
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected an array", path)
+		}
+		if s.Items != nil {
+			for i, item := range items {
+				if err := s.Items.check(item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s: expected a string", path)
+		}
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, str); err != nil {
+				return fmt.Errorf("%s: expected an RFC 3339 date-time", path)
+			}
+		}
+		if len(s.Enum) > 0 && !slices.Contains(s.Enum, str) {
+			return fmt.Errorf("%s: %q is not one of %s", path, str, strings.Join(s.Enum, ", "))
+		}
+	case "integer", "number":
+		number, ok := value.(json.Number)
+		if !ok {
+			return fmt.Errorf("%s: expected a %s", path, s.Type)
+		}
+		if s.Type == "integer" {
+			if _, err := number.Int64(); err != nil {
+				return fmt.Errorf("%s: expected an integer", path)
+			}
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: expected a boolean", path)
+		}
+	}
+	return nil
+}
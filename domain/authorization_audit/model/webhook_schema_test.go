@@ -0,0 +1,66 @@
+package authorization_audit
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// TestWebhookSchemas_CoverEventTypes checks every event type has a schema
+// with an example that matches it
+func TestWebhookSchemas_CoverEventTypes(t *testing.T) {
+	for _, eventType := range WebhookEventTypes() {
+		schema, err := ParseWebhookSchema(eventType.Value())
+		if err != nil {
+			t.Fatalf("Expected a schema for %s, got %v", eventType, err)
+		}
+		if len(schema.Examples) == 0 {
+			t.Fatalf("Expected an example in the %s schema", eventType)
+		}
+		if err := ValidateWebhookData(eventType.Value(), schema.Examples[0]); err != nil {
+			t.Errorf("Expected the %s example to match its schema, got %v", eventType, err)
+		}
+	}
+}
+
+// TestValidateWebhookPayload checks payloads against the envelope and data schemas
+func TestValidateWebhookPayload(t *testing.T) {
+	valid := func() map[string]interface{} {
+		return map[string]interface{}{
+			"id":             "evt-1",
+			"type":           "admin.login",
+			"schema_version": WebhookSchemaVersion,
+			"audit_log_id":   "audit-1",
+			"timestamp":      time.Now().UTC().Format(time.RFC3339Nano),
+			"data":           map[string]interface{}{"username": "admin", "extra": 1},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		change  func(payload map[string]interface{})
+		wantErr bool
+	}{
+		{"valid with unknown data fields", func(map[string]interface{}) {}, false},
+		{"missing schema version", func(p map[string]interface{}) { delete(p, "schema_version") }, true},
+		{"other schema version", func(p map[string]interface{}) { p["schema_version"] = "0" }, true},
+		{"unknown event type", func(p map[string]interface{}) { p["type"] = "invalid.event" }, true},
+		{"invalid timestamp", func(p map[string]interface{}) { p["timestamp"] = "yesterday" }, true},
+		{"missing required data field", func(p map[string]interface{}) { p["data"] = map[string]interface{}{} }, true},
+		{"wrong data field type", func(p map[string]interface{}) { p["data"] = map[string]interface{}{"username": 7} }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := valid()
+			tt.change(payload)
+			body, err := json.Marshal(payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := ValidateWebhookPayload(body); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateWebhookPayload() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
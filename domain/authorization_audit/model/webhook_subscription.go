@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"time"
+
+	"github.com/aruncs31s/azf/shared/clock"
 )
 
 // WebhookEndpoint is a value object representing a webhook endpoint URL
@@ -120,6 +122,7 @@ type WebhookSubscription struct {
 	filters      map[string]interface{}
 	headers      map[string]string
 	metadata     map[string]interface{}
+	transport    WebhookTransport
 }
 
 // NewWebhookSubscription creates a new WebhookSubscription with business rule validation
@@ -156,8 +159,8 @@ func NewWebhookSubscription(
 		status:      SubscriptionStatusActive,
 		secret:      secret,
 		description: description,
-		createdAt:   time.Now(),
-		updatedAt:   time.Now(),
+		createdAt:   clock.Now(),
+		updatedAt:   clock.Now(),
 		maxFailures: 5,
 		filters:     make(map[string]interface{}),
 		headers:     make(map[string]string),
@@ -165,6 +168,69 @@ func NewWebhookSubscription(
 	}, nil
 }
 
+// WebhookSubscriptionState is the persisted state of a webhook subscription
+type WebhookSubscriptionState struct {
+	ID           string
+	Endpoint     string
+	EventTypes   []string
+	Status       string
+	Secret       string
+	Description  string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	LastDelivery *time.Time
+	FailureCount int
+	MaxFailures  int
+	Filters      map[string]interface{}
+	Headers      map[string]string
+	Metadata     map[string]interface{}
+	Transport    WebhookTransport
+}
+
+// RestoreWebhookSubscription rebuilds a webhook subscription from its persisted state
+func RestoreWebhookSubscription(state WebhookSubscriptionState) (*WebhookSubscription, error) {
+	endpoint, err := NewWebhookEndpoint(state.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	eventTypes := make([]*WebhookEventType, 0, len(state.EventTypes))
+	for _, value := range state.EventTypes {
+		eventType, err := NewWebhookEventType(value)
+		if err != nil {
+			return nil, err
+		}
+		eventTypes = append(eventTypes, eventType)
+	}
+	status, err := NewSubscriptionStatus(state.Status)
+	if err != nil {
+		return nil, err
+	}
+	subscription, err := NewWebhookSubscription(state.ID, endpoint, eventTypes, state.Secret, state.Description)
+	if err != nil {
+		return nil, err
+	}
+
+	subscription.status = status
+	subscription.createdAt = state.CreatedAt
+	subscription.updatedAt = state.UpdatedAt
+	subscription.lastDelivery = state.LastDelivery
+	subscription.failureCount = state.FailureCount
+	if state.MaxFailures > 0 {
+		subscription.maxFailures = state.MaxFailures
+	}
+	if state.Filters != nil {
+		subscription.filters = state.Filters
+	}
+	if state.Headers != nil {
+		subscription.headers = state.Headers
+	}
+	if state.Metadata != nil {
+		subscription.metadata = state.Metadata
+	}
+	subscription.transport = state.Transport
+	return subscription, nil
+}
+
 // Getters
 func (w *WebhookSubscription) ID() string {
 	return w.id
@@ -248,6 +314,12 @@ func (w *WebhookSubscription) Metadata() map[string]interface{} {
 	return metadata
 }
 
+func (w *WebhookSubscription) Transport() WebhookTransport {
+	transport := w.transport
+	transport.PinnedIPs = append([]string(nil), w.transport.PinnedIPs...)
+	return transport
+}
+
 // Business logic methods
 
 // IsSubscribedTo checks if the subscription is interested in a specific event type
@@ -285,7 +357,7 @@ func (w *WebhookSubscription) AddEventType(eventType *WebhookEventType) error {
 		}
 	}
 	w.eventTypes = append(w.eventTypes, eventType)
-	w.updatedAt = time.Now()
+	w.updatedAt = clock.Now()
 	return nil
 }
 
@@ -300,30 +372,55 @@ func (w *WebhookSubscription) RemoveEventType(eventType *WebhookEventType) error
 	for i, et := range w.eventTypes {
 		if et.Equals(eventType) {
 			w.eventTypes = append(w.eventTypes[:i], w.eventTypes[i+1:]...)
-			w.updatedAt = time.Now()
+			w.updatedAt = clock.Now()
 			return nil
 		}
 	}
 	return fmt.Errorf("event type not found in subscription")
 }
 
-// SetFilter sets a filter for event delivery
+// SetFilter sets a filter for event delivery. key is one of the
+// WebhookFilterKeys and value a string or a list of strings.
 func (w *WebhookSubscription) SetFilter(key string, value interface{}) error {
 	if key == "" {
 		return fmt.Errorf("filter key cannot be empty")
 	}
-	w.filters[key] = value
-	w.updatedAt = time.Now()
+	values, err := ParseWebhookFilter(key, value)
+	if err != nil {
+		return err
+	}
+	w.filters[key] = values
+	w.updatedAt = clock.Now()
 	return nil
 }
 
+// RemoveFilter removes a filter, so events are no longer filtered by key
+func (w *WebhookSubscription) RemoveFilter(key string) {
+	if _, ok := w.filters[key]; !ok {
+		return
+	}
+	delete(w.filters, key)
+	w.updatedAt = clock.Now()
+}
+
 // SetHeader sets a custom header for webhook delivery
 func (w *WebhookSubscription) SetHeader(key string, value string) error {
 	if key == "" {
 		return fmt.Errorf("header key cannot be empty")
 	}
 	w.headers[key] = value
-	w.updatedAt = time.Now()
+	w.updatedAt = clock.Now()
+	return nil
+}
+
+// SetTransport replaces the transport security options used to reach the endpoint
+func (w *WebhookSubscription) SetTransport(transport WebhookTransport) error {
+	if err := transport.Validate(w.endpoint.Value()); err != nil {
+		return err
+	}
+	transport.PinnedIPs = append([]string(nil), transport.PinnedIPs...)
+	w.transport = transport
+	w.updatedAt = clock.Now()
 	return nil
 }
 
@@ -331,28 +428,28 @@ func (w *WebhookSubscription) SetHeader(key string, value string) error {
 func (w *WebhookSubscription) Activate() error {
 	w.status = SubscriptionStatusActive
 	w.failureCount = 0
-	w.updatedAt = time.Now()
+	w.updatedAt = clock.Now()
 	return nil
 }
 
 // Deactivate deactivates the subscription
 func (w *WebhookSubscription) Deactivate() error {
 	w.status = SubscriptionStatusInactive
-	w.updatedAt = time.Now()
+	w.updatedAt = clock.Now()
 	return nil
 }
 
 // Suspend suspends the subscription
 func (w *WebhookSubscription) Suspend() error {
 	w.status = SubscriptionStatusSuspended
-	w.updatedAt = time.Now()
+	w.updatedAt = clock.Now()
 	return nil
 }
 
 // RecordDelivery records a successful delivery attempt
 func (w *WebhookSubscription) RecordDelivery() error {
 	w.failureCount = 0
-	now := time.Now()
+	now := clock.Now()
 	w.lastDelivery = &now
 	w.updatedAt = now
 	return nil
@@ -364,7 +461,7 @@ func (w *WebhookSubscription) RecordFailure() error {
 	if w.failureCount >= w.maxFailures {
 		w.status = SubscriptionStatusSuspended
 	}
-	w.updatedAt = time.Now()
+	w.updatedAt = clock.Now()
 	return nil
 }
 
@@ -416,6 +513,10 @@ type WebhookDispatcher interface {
 
 	// GetEventDeliveryStatus retrieves the delivery status of an event
 	GetEventDeliveryStatus(ctx context.Context, eventID string) (*WebhookEvent, error)
+
+	// RedeliverEvent sends an event to one subscription again, whether or not
+	// it was delivered before, and returns the recorded attempt
+	RedeliverEvent(ctx context.Context, eventID string, subscriptionID string) (*WebhookDeliveryAttempt, error)
 }
 
 // WebhookManager defines the interface for managing webhook subscriptions
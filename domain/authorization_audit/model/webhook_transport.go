@@ -0,0 +1,104 @@
+package authorization_audit
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+)
+
+// Minimum TLS versions a subscription can require
+const (
+	WebhookTLSVersion12 = "1.2"
+	WebhookTLSVersion13 = "1.3"
+)
+
+// maxPinnedIPs bounds the destination addresses of a subscription
+const maxPinnedIPs = 10
+
+// WebhookTransport holds the transport security options of a subscription.
+// The zero value trusts the system roots and lets the connection negotiate
+// any TLS version Go supports by default.
+type WebhookTransport struct {
+	// CABundle is a PEM bundle of the authorities trusted for the endpoint,
+	// used instead of the system roots
+	CABundle string
+	// ClientCertificate and ClientKey are the PEM certificate and key
+	// presented for mutual TLS; both or neither are set
+	ClientCertificate string
+	ClientKey         string
+	// MinTLSVersion is WebhookTLSVersion12, WebhookTLSVersion13 or empty
+	MinTLSVersion string
+	// PinnedIPs are the only addresses connections to the endpoint are made
+	// to, whatever its host name resolves to
+	PinnedIPs []string
+}
+
+// IsZero reports whether no transport option is set
+func (t WebhookTransport) IsZero() bool {
+	return t.CABundle == "" && t.ClientCertificate == "" && t.ClientKey == "" &&
+		t.MinTLSVersion == "" && len(t.PinnedIPs) == 0
+}
+
+// MutualTLS reports whether a client certificate is presented
+func (t WebhookTransport) MutualTLS() bool {
+	return t.ClientCertificate != ""
+}
+
+// Equals checks if two transports have the same options
+func (t WebhookTransport) Equals(other WebhookTransport) bool {
+	return t.CABundle == other.CABundle &&
+		t.ClientCertificate == other.ClientCertificate &&
+		t.ClientKey == other.ClientKey &&
+		t.MinTLSVersion == other.MinTLSVersion &&
+		slices.Equal(t.PinnedIPs, other.PinnedIPs)
+}
+
+// TLSVersion returns the tls package constant of MinTLSVersion, or 0 when
+// no floor is set
+func (t WebhookTransport) TLSVersion() uint16 {
+	switch t.MinTLSVersion {
+	case WebhookTLSVersion12:
+		return tls.VersionTLS12
+	case WebhookTLSVersion13:
+		return tls.VersionTLS13
+	default:
+		return 0
+	}
+}
+
+// Validate checks the options against the endpoint they are used for. TLS
+// options require an https endpoint.
+func (t WebhookTransport) Validate(endpoint string) error {
+	tlsOptions := t.CABundle != "" || t.ClientCertificate != "" || t.ClientKey != "" || t.MinTLSVersion != ""
+	if target, err := url.Parse(endpoint); tlsOptions && (err != nil || target.Scheme != "https") {
+		return fmt.Errorf("TLS options require an https endpoint")
+	}
+
+	if t.CABundle != "" && !x509.NewCertPool().AppendCertsFromPEM([]byte(t.CABundle)) {
+		return fmt.Errorf("CA bundle holds no PEM certificate")
+	}
+	if (t.ClientCertificate == "") != (t.ClientKey == "") {
+		return fmt.Errorf("client certificate and client key must be set together")
+	}
+	if t.ClientCertificate != "" {
+		if _, err := tls.X509KeyPair([]byte(t.ClientCertificate), []byte(t.ClientKey)); err != nil {
+			return fmt.Errorf("invalid client certificate: %w", err)
+		}
+	}
+	if t.MinTLSVersion != "" && t.TLSVersion() == 0 {
+		return fmt.Errorf("invalid minimum TLS version %q: use %s or %s", t.MinTLSVersion, WebhookTLSVersion12, WebhookTLSVersion13)
+	}
+
+	if len(t.PinnedIPs) > maxPinnedIPs {
+		return fmt.Errorf("maximum %d pinned IPs allowed", maxPinnedIPs)
+	}
+	for _, ip := range t.PinnedIPs {
+		if net.ParseIP(ip) == nil {
+			return fmt.Errorf("invalid pinned IP %q", ip)
+		}
+	}
+	return nil
+}
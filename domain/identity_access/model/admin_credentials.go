@@ -3,6 +3,8 @@ package identity_access
 import (
 	"errors"
 	"time"
+
+	"github.com/aruncs31s/azf/shared/clock"
 )
 
 // AdminCredentials represents the aggregate root for admin authentication
@@ -45,8 +47,8 @@ func NewAdminCredentials(
 		id:        id,
 		username:  username,
 		password:  password,
-		createdAt: time.Now(),
-		updatedAt: time.Now(),
+		createdAt: clock.Now(),
+		updatedAt: clock.Now(),
 		isActive:  true,
 	}, nil
 }
@@ -88,20 +90,20 @@ func (ac *AdminCredentials) UpdatePassword(newPassword *AdminPassword) error {
 	}
 
 	ac.password = newPassword
-	ac.updatedAt = time.Now()
+	ac.updatedAt = clock.Now()
 	return nil
 }
 
 // Deactivate deactivates the credentials
 func (ac *AdminCredentials) Deactivate() {
 	ac.isActive = false
-	ac.updatedAt = time.Now()
+	ac.updatedAt = clock.Now()
 }
 
 // Activate activates the credentials
 func (ac *AdminCredentials) Activate() {
 	ac.isActive = true
-	ac.updatedAt = time.Now()
+	ac.updatedAt = clock.Now()
 }
 
 // VerifyPassword verifies if the provided password matches
@@ -120,6 +122,6 @@ func (ac *AdminCredentials) UpdateUsername(newUsername *AdminUsername) error {
 	}
 
 	ac.username = newUsername
-	ac.updatedAt = time.Now()
+	ac.updatedAt = clock.Now()
 	return nil
 }
@@ -0,0 +1,51 @@
+package identity_access
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrAdminSessionNotFound = errors.New("admin session not found")
+
+// AdminSession is a signed-in admin dashboard session. Only the SHA-256 hash
+// of the session cookie is stored, as ID. A session ends at IdleExpiresAt
+// unless it is used again, and at ExpiresAt regardless.
+type AdminSession struct {
+	ID            string
+	Username      string
+	UserID        string
+	ClientIP      string
+	UserAgent     string
+	CreatedAt     time.Time
+	LastSeenAt    time.Time
+	IdleExpiresAt time.Time
+	ExpiresAt     time.Time
+}
+
+// IsExpired reports whether the session has ended at now
+func (s *AdminSession) IsExpired(now time.Time) bool {
+	return !now.Before(s.IdleExpiresAt) || !now.Before(s.ExpiresAt)
+}
+
+// SessionStore stores admin dashboard sessions
+type SessionStore interface {
+	// Create stores a new session
+	Create(ctx context.Context, session *AdminSession) error
+
+	// FindByID returns the session with id, or ErrAdminSessionNotFound
+	FindByID(ctx context.Context, id string) (*AdminSession, error)
+
+	// FindAll returns every stored session, most recently used first
+	FindAll(ctx context.Context) ([]AdminSession, error)
+
+	// Touch records that the session with id was used at lastSeenAt,
+	// extending its idle expiry to idleExpiresAt
+	Touch(ctx context.Context, id string, lastSeenAt time.Time, idleExpiresAt time.Time) error
+
+	// Delete removes the session with id
+	Delete(ctx context.Context, id string) error
+
+	// DeleteExpired removes the sessions that have ended at now
+	DeleteExpired(ctx context.Context, now time.Time) error
+}
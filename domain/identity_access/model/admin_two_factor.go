@@ -0,0 +1,49 @@
+package identity_access
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrTwoFactorNotFound = errors.New("two-factor authentication not set up")
+
+// AdminTwoFactor is the TOTP second factor of an admin. Secret is the base32
+// shared secret; it is pending until the admin confirms it with a code, and
+// only then is the factor enabled. Only SHA-256 hashes of the unused backup
+// codes are stored.
+type AdminTwoFactor struct {
+	Username         string
+	Secret           string
+	Enabled          bool
+	BackupCodeHashes []string
+	// LastUsedStep is the TOTP time step of the last accepted code, so a
+	// code is not accepted twice
+	LastUsedStep int64
+	EnabledAt    *time.Time
+	UpdatedAt    time.Time
+}
+
+// UseBackupCode removes the backup code with hash, reporting whether it was
+// one of the unused codes
+func (t *AdminTwoFactor) UseBackupCode(hash string) bool {
+	for i, h := range t.BackupCodeHashes {
+		if h == hash {
+			t.BackupCodeHashes = append(t.BackupCodeHashes[:i], t.BackupCodeHashes[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// TwoFactorRepository stores the second factors of admins
+type TwoFactorRepository interface {
+	// FindByUsername returns the factor of the admin, or ErrTwoFactorNotFound
+	FindByUsername(ctx context.Context, username string) (*AdminTwoFactor, error)
+
+	// Save creates or replaces the factor of its admin
+	Save(ctx context.Context, factor *AdminTwoFactor) error
+
+	// Delete removes the factor of the admin
+	Delete(ctx context.Context, username string) error
+}
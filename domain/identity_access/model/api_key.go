@@ -0,0 +1,78 @@
+package identity_access
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrAPIKeyNotFound = errors.New("API key not found")
+
+// APIKey authenticates a client as a user and role without a JWT. Only the
+// SHA-256 hash of the key is stored; Prefix is kept so admins can tell keys
+// apart.
+type APIKey struct {
+	ID      string
+	Name    string
+	Prefix  string
+	KeyHash string
+	UserID  string
+	Role    string
+	Scopes  []string
+	// RateLimit is the key's requests per minute; zero applies the limits
+	// of its role
+	RateLimit  int
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedBy  string
+	CreatedAt  time.Time
+}
+
+// IsExpired reports whether the key has expired at now
+func (k *APIKey) IsExpired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
+// IsRevoked reports whether the key was revoked
+func (k *APIKey) IsRevoked() bool {
+	return k.RevokedAt != nil
+}
+
+// HasScopes reports whether the key was granted every one of scopes
+func (k *APIKey) HasScopes(scopes []string) bool {
+	for _, required := range scopes {
+		granted := false
+		for _, scope := range k.Scopes {
+			if scope == required {
+				granted = true
+				break
+			}
+		}
+		if !granted {
+			return false
+		}
+	}
+	return true
+}
+
+// APIKeyRepository stores API keys
+type APIKeyRepository interface {
+	// Create stores a newly issued key
+	Create(ctx context.Context, key *APIKey) error
+
+	// FindByID returns the key with id, or ErrAPIKeyNotFound
+	FindByID(ctx context.Context, id string) (*APIKey, error)
+
+	// FindByHash returns the key with hash, or ErrAPIKeyNotFound
+	FindByHash(ctx context.Context, hash string) (*APIKey, error)
+
+	// FindAll returns every key, newest first
+	FindAll(ctx context.Context) ([]APIKey, error)
+
+	// Revoke revokes the key with id at the given time
+	Revoke(ctx context.Context, id string, at time.Time) error
+
+	// Touch records that the key with id was used at the given time
+	Touch(ctx context.Context, id string, at time.Time) error
+}
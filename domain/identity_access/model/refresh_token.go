@@ -0,0 +1,56 @@
+package identity_access
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrRefreshTokenNotFound = errors.New("refresh token not found")
+	ErrRefreshTokenRevoked  = errors.New("refresh token has been revoked")
+)
+
+// RefreshToken is an issued admin refresh token. Only the SHA-256 hash of
+// the token is stored. Tokens rotated from the same login share a FamilyID,
+// so presenting a rotated token again revokes the whole family.
+type RefreshToken struct {
+	TokenHash  string
+	FamilyID   string
+	Username   string
+	UserID     string
+	IssuedAt   time.Time
+	ExpiresAt  time.Time
+	RevokedAt  *time.Time
+	ReplacedBy string
+}
+
+// IsExpired reports whether the token has expired at now
+func (t *RefreshToken) IsExpired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
+
+// IsRevoked reports whether the token was revoked or rotated
+func (t *RefreshToken) IsRevoked() bool {
+	return t.RevokedAt != nil
+}
+
+// RefreshTokenRepository stores admin refresh tokens
+type RefreshTokenRepository interface {
+	// Create stores a newly issued token
+	Create(ctx context.Context, token *RefreshToken) error
+
+	// FindByHash returns the token with hash, or ErrRefreshTokenNotFound
+	FindByHash(ctx context.Context, hash string) (*RefreshToken, error)
+
+	// Rotate revokes the token with currentHash, replacing it with next.
+	// It fails with ErrRefreshTokenRevoked when the token was already
+	// revoked, so a token can only be rotated once.
+	Rotate(ctx context.Context, currentHash string, next *RefreshToken) error
+
+	// Revoke revokes the token with hash
+	Revoke(ctx context.Context, hash string) error
+
+	// RevokeFamily revokes every token of a family
+	RevokeFamily(ctx context.Context, familyID string) error
+}
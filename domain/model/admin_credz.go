@@ -4,6 +4,7 @@ import (
 	"errors"
 	"time"
 
+	"github.com/aruncs31s/azf/shared/clock"
 	"github.com/aruncs31s/azf/utils"
 )
 
@@ -47,8 +48,8 @@ func NewAdminCredentials(
 		id:        id,
 		username:  username,
 		password:  password,
-		createdAt: time.Now(),
-		updatedAt: time.Now(),
+		createdAt: clock.Now(),
+		updatedAt: clock.Now(),
 		isActive:  true,
 	}, nil
 }
@@ -108,13 +109,13 @@ func (ac *AdminCredentials) Authenticate(username string, password string) error
 // Deactivate deactivates the credentials
 func (ac *AdminCredentials) Deactivate() {
 	ac.isActive = false
-	ac.updatedAt = time.Now()
+	ac.updatedAt = clock.Now()
 }
 
 // Activate activates the credentials
 func (ac *AdminCredentials) Activate() {
 	ac.isActive = true
-	ac.updatedAt = time.Now()
+	ac.updatedAt = clock.Now()
 }
 
 // UpdatePassword updates the admin password
@@ -124,6 +125,6 @@ func (ac *AdminCredentials) UpdatePassword(newPassword *AdminPassword) error {
 	}
 
 	ac.password = newPassword
-	ac.updatedAt = time.Now()
+	ac.updatedAt = clock.Now()
 	return nil
 }
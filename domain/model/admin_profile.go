@@ -4,6 +4,8 @@ import (
 	"errors"
 	"strings"
 	"time"
+
+	"github.com/aruncs31s/azf/shared/clock"
 )
 
 // AdminProfile represents the admin user profile entity
@@ -142,7 +144,7 @@ func NewAdminProfile(
 		return nil, ErrInvalidRole
 	}
 
-	now := time.Now()
+	now := clock.Now()
 	return &AdminProfile{
 		id:        id,
 		fullName:  fullName,
@@ -201,7 +203,7 @@ func (ap *AdminProfile) UpdateFullName(newName *AdminFullName) error {
 	}
 
 	ap.fullName = newName
-	ap.updatedAt = time.Now()
+	ap.updatedAt = clock.Now()
 	return nil
 }
 
@@ -212,7 +214,7 @@ func (ap *AdminProfile) UpdateEmail(newEmail *AdminEmail) error {
 	}
 
 	ap.email = newEmail
-	ap.updatedAt = time.Now()
+	ap.updatedAt = clock.Now()
 	return nil
 }
 
@@ -224,13 +226,13 @@ func (ap *AdminProfile) UpdateRole(newRole string) error {
 	}
 
 	ap.role = newRole
-	ap.updatedAt = time.Now()
+	ap.updatedAt = clock.Now()
 	return nil
 }
 
 // RecordLogin records a successful login
 func (ap *AdminProfile) RecordLogin() {
-	now := time.Now()
+	now := clock.Now()
 	ap.lastLogin = &now
 	ap.updatedAt = now
 }
@@ -238,13 +240,13 @@ func (ap *AdminProfile) RecordLogin() {
 // Deactivate deactivates the profile
 func (ap *AdminProfile) Deactivate() {
 	ap.isActive = false
-	ap.updatedAt = time.Now()
+	ap.updatedAt = clock.Now()
 }
 
 // Activate activates the profile
 func (ap *AdminProfile) Activate() {
 	ap.isActive = true
-	ap.updatedAt = time.Now()
+	ap.updatedAt = clock.Now()
 }
 
 // DisplayName returns a display-friendly name
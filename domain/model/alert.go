@@ -0,0 +1,157 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// AlertSeverity represents how urgent an alert is
+type AlertSeverity struct {
+	value string
+	rank  int
+}
+
+var (
+	AlertInfo     = &AlertSeverity{value: "INFO", rank: 1}
+	AlertWarning  = &AlertSeverity{value: "WARNING", rank: 2}
+	AlertCritical = &AlertSeverity{value: "CRITICAL", rank: 3}
+)
+
+var alertSeverities = map[string]*AlertSeverity{
+	"INFO":     AlertInfo,
+	"WARNING":  AlertWarning,
+	"CRITICAL": AlertCritical,
+}
+
+// Alert rules raised by the framework itself
+const (
+	AlertRuleAuditPipelineFailure      = "audit_pipeline_failure"
+	AlertRuleDenialStorm               = "denial_storm"
+	AlertRuleWebhookSubsystemSuspended = "webhook_subsystem_suspended"
+)
+
+func NewAlertSeverity(severity string) (*AlertSeverity, error) {
+	if severity == "" {
+		return nil, fmt.Errorf("alert severity cannot be empty")
+	}
+	s, ok := alertSeverities[severity]
+	if !ok {
+		return nil, fmt.Errorf("invalid alert severity: %s", severity)
+	}
+	return s, nil
+}
+
+func (as *AlertSeverity) Value() string {
+	if as == nil {
+		return ""
+	}
+	return as.value
+}
+
+func (as *AlertSeverity) String() string {
+	return as.Value()
+}
+
+// AtMost reports whether this severity is the same as or lower than other
+func (as *AlertSeverity) AtMost(other *AlertSeverity) bool {
+	if as == nil || other == nil {
+		return false
+	}
+	return as.rank <= other.rank
+}
+
+// Alert is a condition raised by an alert rule that should be sent to admins
+type Alert struct {
+	id       string
+	rule     string
+	severity *AlertSeverity
+	title    string
+	message  string
+	dedupKey string
+	firedAt  time.Time
+	details  map[string]interface{}
+}
+
+// NewAlert creates a new alert. Alerts with the same rule and dedup key are
+// treated as repeats of each other; the dedup key defaults to the rule.
+func NewAlert(
+	id string,
+	rule string,
+	severity *AlertSeverity,
+	title string,
+	message string,
+	dedupKey string,
+	firedAt time.Time,
+	details map[string]interface{},
+) (*Alert, error) {
+	if id == "" {
+		return nil, fmt.Errorf("alert ID cannot be empty")
+	}
+	if rule == "" {
+		return nil, fmt.Errorf("alert rule cannot be empty")
+	}
+	if severity == nil || severity.Value() == "" {
+		return nil, fmt.Errorf("alert severity is required")
+	}
+	if title == "" {
+		return nil, fmt.Errorf("alert title cannot be empty")
+	}
+	if firedAt.IsZero() {
+		return nil, fmt.Errorf("alert time cannot be zero")
+	}
+
+	if dedupKey == "" {
+		dedupKey = rule
+	}
+	if details == nil {
+		details = make(map[string]interface{})
+	}
+
+	return &Alert{
+		id:       id,
+		rule:     rule,
+		severity: severity,
+		title:    title,
+		message:  message,
+		dedupKey: dedupKey,
+		firedAt:  firedAt,
+		details:  details,
+	}, nil
+}
+
+// Getters
+func (a *Alert) ID() string {
+	return a.id
+}
+
+func (a *Alert) Rule() string {
+	return a.rule
+}
+
+func (a *Alert) Severity() *AlertSeverity {
+	return a.severity
+}
+
+func (a *Alert) Title() string {
+	return a.title
+}
+
+func (a *Alert) Message() string {
+	return a.message
+}
+
+func (a *Alert) DedupKey() string {
+	return a.dedupKey
+}
+
+func (a *Alert) FiredAt() time.Time {
+	return a.firedAt
+}
+
+func (a *Alert) Details() map[string]interface{} {
+	details := make(map[string]interface{}, len(a.details))
+	for k, v := range a.details {
+		details[k] = v
+	}
+	return details
+}
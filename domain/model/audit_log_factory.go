@@ -3,6 +3,8 @@ package model
 import (
 	"errors"
 	"time"
+
+	"github.com/aruncs31s/azf/shared/clock"
 )
 
 // AuditLogFactory handles creation of AuditLog entities with proper validation
@@ -25,7 +27,7 @@ func (f *AuditLogFactory) CreateSuccessLog(
 	status := &LogStatus{value: "SUCCESS"}
 	return NewAuditLog(
 		generateUniqueID(),
-		time.Now(),
+		clock.Now(),
 		action,
 		adminID,
 		ipAddress,
@@ -56,7 +58,7 @@ func (f *AuditLogFactory) CreateErrorLog(
 	status := &LogStatus{value: "FAILURE"}
 	return NewAuditLog(
 		generateUniqueID(),
-		time.Now(),
+		clock.Now(),
 		action,
 		adminID,
 		ipAddress,
@@ -71,7 +73,7 @@ func (f *AuditLogFactory) CreateErrorLog(
 
 // generateUniqueID generates a unique ID for audit logs
 func generateUniqueID() string {
-	return time.Now().Format("20060102150405.000") + RandomString(8)
+	return clock.Now().Format("20060102150405.000") + RandomString(8)
 }
 
 // RandomString generates a random string of specified length
@@ -2,6 +2,7 @@ package model
 
 import (
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -55,6 +56,13 @@ func (ar *AuthorizationResult) IsWarning() bool {
 	return ar != nil && ar.value == "WARNING"
 }
 
+// Rate limit states recorded on audit entries
+const (
+	RateLimitStatusOK       = "OK"
+	RateLimitStatusWarning  = "WARNING"
+	RateLimitStatusExceeded = "EXCEEDED"
+)
+
 // DenialReason explains why authorization was denied
 type DenialReason struct {
 	value string
@@ -66,7 +74,12 @@ var (
 	ReasonMethodNotAllowed  = &DenialReason{value: "METHOD_NOT_ALLOWED"}
 	ReasonResourceNotFound  = &DenialReason{value: "RESOURCE_NOT_FOUND"}
 	ReasonRateLimitExceeded = &DenialReason{value: "RATE_LIMIT_EXCEEDED"}
+	ReasonQuotaExceeded     = &DenialReason{value: "QUOTA_EXCEEDED"}
+	ReasonInsufficientScope = &DenialReason{value: "INSUFFICIENT_SCOPE"}
 	ReasonDeprecatedRoute   = &DenialReason{value: "DEPRECATED_ROUTE"}
+	ReasonRequestTooLarge   = &DenialReason{value: "REQUEST_TOO_LARGE"}
+	ReasonReadOnlyMode      = &DenialReason{value: "READ_ONLY_MODE"}
+	ReasonCustomRule        = &DenialReason{value: "CUSTOM_RULE"}
 	ReasonUnknown           = &DenialReason{value: "UNKNOWN"}
 )
 
@@ -76,7 +89,12 @@ var validDenialReasons = map[string]bool{
 	"METHOD_NOT_ALLOWED":  true,
 	"RESOURCE_NOT_FOUND":  true,
 	"RATE_LIMIT_EXCEEDED": true,
+	"QUOTA_EXCEEDED":      true,
+	"INSUFFICIENT_SCOPE":  true,
 	"DEPRECATED_ROUTE":    true,
+	"REQUEST_TOO_LARGE":   true,
+	"READ_ONLY_MODE":      true,
+	"CUSTOM_RULE":         true,
 	"UNKNOWN":             true,
 }
 
@@ -101,6 +119,9 @@ func (dr *DenialReason) String() string {
 	return dr.Value()
 }
 
+// MaxAuditRequestIDLength is the longest request ID an audit entry can store
+const MaxAuditRequestIDLength = 64
+
 // AuthorizationAuditLog tracks authorization check events
 type AuthorizationAuditLog struct {
 	id              string
@@ -120,6 +141,11 @@ type AuthorizationAuditLog struct {
 	policyVersion   int                    // Which version of policy was used
 	executionTimeMs float64                // Time taken to check permission
 	details         map[string]interface{} // Additional metadata
+	requestID       string                 // Request that produced the event
+	errorMessage    string                 // Why the request failed, empty if allowed
+	authMode        string                 // How the decision was enforced, e.g. "GRADUAL_ROLLOUT"
+	occurrenceCount int                    // Identical events collapsed into this entry
+	lastSeenAt      time.Time              // Time of the latest collapsed event
 }
 
 // NewAuthorizationAuditLog creates a new authorization audit log entry
@@ -195,6 +221,8 @@ func NewAuthorizationAuditLog(
 		policyVersion:   policyVersion,
 		executionTimeMs: executionTimeMs,
 		details:         details,
+		occurrenceCount: 1,
+		lastSeenAt:      timestamp,
 	}, nil
 }
 
@@ -275,10 +303,69 @@ func (aal *AuthorizationAuditLog) Details() map[string]interface{} {
 	return details
 }
 
+func (aal *AuthorizationAuditLog) RequestID() string {
+	return aal.requestID
+}
+
+func (aal *AuthorizationAuditLog) ErrorMessage() string {
+	return aal.errorMessage
+}
+
+// SetRequestID links the entry to the request that produced it
+func (aal *AuthorizationAuditLog) SetRequestID(requestID string) error {
+	if len(requestID) > MaxAuditRequestIDLength {
+		return fmt.Errorf("request ID cannot exceed %d characters", MaxAuditRequestIDLength)
+	}
+	aal.requestID = requestID
+	return nil
+}
+
+func (aal *AuthorizationAuditLog) AuthorizationMode() string {
+	return aal.authMode
+}
+
+// SetAuthorizationMode records how the decision was enforced, so warn-only
+// decisions can be told apart from enforced ones
+func (aal *AuthorizationAuditLog) SetAuthorizationMode(mode string) {
+	aal.authMode = mode
+}
+
+// SetErrorMessage records the error details of a failed request
+func (aal *AuthorizationAuditLog) SetErrorMessage(message string) {
+	aal.errorMessage = message
+}
+
+func (aal *AuthorizationAuditLog) OccurrenceCount() int {
+	return aal.occurrenceCount
+}
+
+func (aal *AuthorizationAuditLog) LastSeenAt() time.Time {
+	return aal.lastSeenAt
+}
+
+// DedupKey identifies events that are identical for rollup purposes
+func (aal *AuthorizationAuditLog) DedupKey() string {
+	return strings.Join([]string{
+		aal.userID,
+		aal.resource,
+		aal.action,
+		aal.result.Value(),
+		aal.denialReason.Value(),
+	}, "\x00")
+}
+
+// RecordOccurrence collapses another identical event seen at the given time into this entry
+func (aal *AuthorizationAuditLog) RecordOccurrence(at time.Time) {
+	aal.occurrenceCount++
+	if at.After(aal.lastSeenAt) {
+		aal.lastSeenAt = at
+	}
+}
+
 // IsCritical returns true if this is a critical event
 func (aal *AuthorizationAuditLog) IsCritical() bool {
 	// Critical if denied or rate limited or deprecated
-	return aal.result.IsDenied() || aal.rateLimitStatus == "EXCEEDED" || aal.deprecated
+	return aal.result.IsDenied() || aal.rateLimitStatus == RateLimitStatusExceeded || aal.deprecated
 }
 
 // IsRecent checks if the log entry is within the specified duration
@@ -1,16 +1,30 @@
 package repository
 
-import "github.com/aruncs31s/azf/domain/api_usage"
+import (
+	"context"
+	"time"
+
+	"github.com/aruncs31s/azf/domain/api_usage"
+)
 
 // APIUsageLogReader defines read operations for API usage logs
 type APIUsageLogReader interface {
 	FindByID(id string) (*api_usage.APIUsageLog, error)
 	FindAll(limit int, offset int) (*[]api_usage.APIUsageLog, error)
 	FindByEndpoint(endpoint string, limit int, offset int) (*[]api_usage.APIUsageLog, error)
+	// FindErrorsByEndpoint returns the most recent failed requests to endpoint, newest first
+	FindErrorsByEndpoint(endpoint string, limit int) (*[]api_usage.APIUsageLog, error)
 	FindByUserID(userID string, limit int, offset int) (*[]api_usage.APIUsageLog, error)
 	FindByDateRange(startDate string, endDate string, limit int, offset int) (*[]api_usage.APIUsageLog, error)
 	CountByEndpoint(endpoint string) (int64, error)
+	// CountByStatusCode returns the requests to endpoint per status code, most frequent first
+	CountByStatusCode(endpoint string) (*[]api_usage.APIUsageStatusCount, error)
 	CountTotal() (int64, error)
+	CountSince(since time.Time) (int64, error)
+	FindTimingsSince(since time.Time) (*[]api_usage.APIUsageTiming, error)
+	AggregateHourly(from time.Time, to time.Time) (*[]api_usage.APIUsageHourlyBucket, error)
+	AggregateByClient(dimension string, from time.Time, to time.Time, limit int) (*[]api_usage.ClientUsageAggregate, error)
+	AggregateForClients(dimension string, identities []string, from time.Time, to time.Time) (*[]api_usage.ClientUsageAggregate, error)
 }
 
 // APIUsageLogWriter defines write operations for API usage logs
@@ -37,6 +51,9 @@ type APIUsageStatsReader interface {
 	GetTopEndpointsByUsage(limit int) (*[]api_usage.APIEndpointRanking, error)
 	GetEndpointsByErrorRate(limit int) (*[]api_usage.APIEndpointRanking, error)
 	GetEndpointsByResponseTime(limit int) (*[]api_usage.APIEndpointRanking, error)
+	// GetEndpointRankings returns a page of endpoints ordered as query asks,
+	// and the number of endpoints matching it
+	GetEndpointRankings(query api_usage.EndpointRankingQuery) (*[]api_usage.APIEndpointRanking, int64, error)
 	CountTotal() (int64, error)
 }
 
@@ -54,3 +71,88 @@ type APIUsageStatsRepository interface {
 	APIUsageStatsReader
 	APIUsageStatsWriter
 }
+
+// UsageAnnotationRepository defines persistence operations for chart annotations
+type UsageAnnotationRepository interface {
+	Create(annotation *api_usage.UsageAnnotation) (*api_usage.UsageAnnotation, error)
+	FindByID(id string) (*api_usage.UsageAnnotation, error)
+	FindByTimeRange(from time.Time, to time.Time) (*[]api_usage.UsageAnnotation, error)
+	Delete(id string) error
+}
+
+// RateLimitOverrideRepository defines persistence operations for per-client rate limit overrides
+type RateLimitOverrideRepository interface {
+	Save(override *api_usage.RateLimitOverride) (*api_usage.RateLimitOverride, error)
+	FindByIdentity(identity string) (*api_usage.RateLimitOverride, error)
+	FindAll() (*[]api_usage.RateLimitOverride, error)
+	Delete(identity string) error
+}
+
+// UsageQuotaRepository defines persistence operations for daily and monthly usage quotas
+type UsageQuotaRepository interface {
+	// Save inserts the quota or replaces the one for the same subject and period
+	Save(quota *api_usage.UsageQuota) (*api_usage.UsageQuota, error)
+	FindByID(id string) (*api_usage.UsageQuota, error)
+	FindAll() (*[]api_usage.UsageQuota, error)
+	Delete(id string) error
+	// AddUsage adds requests to the quota's usage, unless its period no
+	// longer starts at periodStart
+	AddUsage(id string, periodStart time.Time, requests int64) error
+	// ResetPeriod moves the quota from the period starting at previousStart
+	// to the one starting at start, clearing its usage. It reports false when
+	// the quota was not in that period, e.g. another instance moved it first.
+	ResetPeriod(id string, previousStart time.Time, start time.Time) (bool, error)
+}
+
+// WebPushSubscriptionRepository defines persistence operations for admin browser push subscriptions
+type WebPushSubscriptionRepository interface {
+	// Save inserts the subscription or replaces the one with the same endpoint
+	Save(subscription *api_usage.WebPushSubscription) (*api_usage.WebPushSubscription, error)
+	FindByID(id string) (*api_usage.WebPushSubscription, error)
+	FindByAdmin(adminUsername string) (*[]api_usage.WebPushSubscription, error)
+	FindAll() (*[]api_usage.WebPushSubscription, error)
+	MarkPushed(id string, at time.Time) error
+	Delete(id string) error
+	DeleteByEndpoint(endpoint string) error
+	// LoadOrCreateVAPIDKeys returns the stored VAPID key pair, storing the
+	// one returned by generate if none exists yet
+	LoadOrCreateVAPIDKeys(generate func() (*api_usage.WebPushVAPIDKeys, error)) (*api_usage.WebPushVAPIDKeys, error)
+}
+
+// AlertIncidentRepository defines persistence operations for incidents opened with on-call providers
+type AlertIncidentRepository interface {
+	Save(incident *api_usage.AlertIncident) error
+	FindByID(id string) (*api_usage.AlertIncident, error)
+	// FindOpen returns the unresolved incident for the provider and incident key
+	FindOpen(provider string, incidentKey string) (*api_usage.AlertIncident, error)
+	FindAllOpen() (*[]api_usage.AlertIncident, error)
+	// FindRecent returns the latest incidents, optionally filtered by status
+	FindRecent(status string, limit int) (*[]api_usage.AlertIncident, error)
+}
+
+// FeatureFlagRepository defines persistence operations for subsystem feature flags
+type FeatureFlagRepository interface {
+	// Save inserts the flag or replaces the one with the same name and environment,
+	// recording the change in the same transaction
+	Save(flag *api_usage.FeatureFlag, change *api_usage.FeatureFlagChange) (*api_usage.FeatureFlag, error)
+	FindByEnvironment(environment string) (*[]api_usage.FeatureFlag, error)
+	// FindChanges returns the latest flag changes, newest first
+	FindChanges(limit int) (*[]api_usage.FeatureFlagChange, error)
+}
+
+// UsageAnalyticsBackend is the storage for raw API usage logs and the
+// per-endpoint statistics derived from them. Implementations are selected by
+// configuration so the analytics service does not depend on the store.
+type UsageAnalyticsBackend interface {
+	Name() string
+	Logs() APIUsageLogRepository
+	Stats() APIUsageStatsRepository
+	// Close flushes any buffered writes and releases backend resources
+	Close() error
+}
+
+// EncodedTextBackfiller encodes large text columns of usage and audit rows
+// written before text column encoding was enabled
+type EncodedTextBackfiller interface {
+	BackfillEncodedText(ctx context.Context, batchSize int) (int64, error)
+}
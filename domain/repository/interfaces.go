@@ -181,6 +181,9 @@ type CasbinEnforcer interface {
 
 // Transaction represents a database transaction
 type Transaction interface {
+	// Context returns a context that makes repository calls join the transaction
+	Context() context.Context
+
 	// Commit commits the transaction
 	Commit() error
 
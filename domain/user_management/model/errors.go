@@ -0,0 +1,17 @@
+package user_management
+
+import apperrors "github.com/aruncs31s/azf/shared/errors"
+
+// Errors returned by user repositories. They also match the shared
+// apperrors.ErrNotFound and apperrors.ErrConflict.
+var (
+	// ErrUserNotFound is returned when no user matches the lookup
+	ErrUserNotFound = apperrors.Newf(apperrors.ErrNotFound, "user not found")
+	// ErrDuplicateEmail is returned when another user already has the email
+	ErrDuplicateEmail = apperrors.Newf(apperrors.ErrConflict, "user with this email already exists")
+	// ErrDuplicateUsername is returned when another user already has the username
+	ErrDuplicateUsername = apperrors.Newf(apperrors.ErrConflict, "user with this username already exists")
+	// ErrUserConflict is returned for other constraint violations, such as a
+	// duplicate user ID or a reference to a missing record
+	ErrUserConflict = apperrors.Newf(apperrors.ErrConflict, "user conflicts with existing records")
+)
@@ -26,6 +26,12 @@ type UserSearchResult struct {
 	Offset  int
 }
 
+// RoleAssignment links a user to a role they hold
+type RoleAssignment struct {
+	UserID   string
+	RoleName string
+}
+
 // UserRepository defines the interface for user management persistence operations
 type UserRepository interface {
 	UserReader
@@ -38,6 +44,15 @@ type UserReader interface {
 	// ctx is used to manage the request lifetime, handle cancellation, and pass deadlines
 	GetByID(ctx context.Context, userID string) (*User, error)
 
+	// GetByIDs retrieves the users with the given IDs, keyed by ID
+	// IDs without a user are left out of the result
+	// ctx is used to manage the request lifetime, handle cancellation, and pass deadlines
+	GetByIDs(ctx context.Context, userIDs []string) (map[string]*User, error)
+
+	// ExistsByID reports whether a user with the given ID exists
+	// ctx is used to manage the request lifetime, handle cancellation, and pass deadlines
+	ExistsByID(ctx context.Context, userID string) (bool, error)
+
 	// GetByEmail retrieves a user by their email address
 	// ctx is used to manage the request lifetime, handle cancellation, and pass deadlines
 	GetByEmail(ctx context.Context, email string) (*User, error)
@@ -63,6 +78,14 @@ type UserReader interface {
 	// ctx is used to manage the request lifetime, handle cancellation, and pass deadlines
 	GetByRole(ctx context.Context, roleName string) ([]*User, error)
 
+	// CountByRole returns the number of users holding each role, keyed by role name
+	// ctx is used to manage the request lifetime, handle cancellation, and pass deadlines
+	CountByRole(ctx context.Context) (map[string]int64, error)
+
+	// ListRoleAssignments returns every role held by every user
+	// ctx is used to manage the request lifetime, handle cancellation, and pass deadlines
+	ListRoleAssignments(ctx context.Context) ([]RoleAssignment, error)
+
 	// GetByStatus retrieves all users with a specific status
 	// ctx is used to manage the request lifetime, handle cancellation, and pass deadlines
 	GetByStatus(ctx context.Context, status UserStatus) ([]*User, error)
@@ -82,10 +105,20 @@ type UserWriter interface {
 	// ctx is used to manage the request lifetime, handle cancellation, and pass deadlines
 	Update(ctx context.Context, user *User) (*User, error)
 
-	// Delete removes a user from the repository
+	// Delete soft-deletes a user; the user and its roles are hidden from
+	// every read until restored or purged
 	// ctx is used to manage the request lifetime, handle cancellation, and pass deadlines
 	Delete(ctx context.Context, userID string) error
 
+	// Restore brings back a soft-deleted user with its roles
+	// ctx is used to manage the request lifetime, handle cancellation, and pass deadlines
+	Restore(ctx context.Context, userID string) (*User, error)
+
+	// PurgeDeletedBefore permanently removes the users soft-deleted before
+	// the given time and returns them
+	// ctx is used to manage the request lifetime, handle cancellation, and pass deadlines
+	PurgeDeletedBefore(ctx context.Context, before time.Time) ([]*User, error)
+
 	// Block marks a user as blocked
 	// ctx is used to manage the request lifetime, handle cancellation, and pass deadlines
 	Block(ctx context.Context, userID string, reason string) (*User, error)
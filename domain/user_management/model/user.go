@@ -3,6 +3,8 @@ package user_management
 import (
 	"errors"
 	"time"
+
+	"github.com/aruncs31s/azf/shared/clock"
 )
 
 // User represents a domain user as an aggregate root
@@ -22,6 +24,7 @@ type User struct {
 	metadata      map[string]interface{}
 	oauthProvider string
 	oauthID       string
+	deletedAt     *time.Time
 }
 
 // NewUser creates a new User aggregate root
@@ -64,8 +67,8 @@ func NewUser(
 		status:        StatusActive,
 		roles:         make([]*UserRole, 0),
 		isAdmin:       false,
-		createdAt:     time.Now(),
-		updatedAt:     time.Now(),
+		createdAt:     clock.Now(),
+		updatedAt:     clock.Now(),
 		metadata:      make(map[string]interface{}),
 		oauthProvider: "",
 		oauthID:       "",
@@ -113,7 +116,7 @@ func (u *User) SetDisplayName(displayName string) error {
 		return errors.New("display name cannot exceed 100 characters")
 	}
 	u.displayName = displayName
-	u.updatedAt = time.Now()
+	u.updatedAt = clock.Now()
 	return nil
 }
 
@@ -131,7 +134,7 @@ func (u *User) SetStatus(status UserStatus) error {
 		return errors.New("invalid user status")
 	}
 	u.status = status
-	u.updatedAt = time.Now()
+	u.updatedAt = clock.Now()
 	return nil
 }
 
@@ -172,7 +175,7 @@ func (u *User) AssignRole(role *UserRole) error {
 	}
 
 	u.roles = append(u.roles, role)
-	u.updatedAt = time.Now()
+	u.updatedAt = clock.Now()
 	return nil
 }
 
@@ -188,7 +191,7 @@ func (u *User) RemoveRole(role *UserRole) error {
 	for i, r := range u.roles {
 		if r.Equals(role) {
 			u.roles = append(u.roles[:i], u.roles[i+1:]...)
-			u.updatedAt = time.Now()
+			u.updatedAt = clock.Now()
 			return nil
 		}
 	}
@@ -215,7 +218,7 @@ func (u *User) PromoteToAdmin() error {
 		return errors.New("user cannot be nil")
 	}
 	u.isAdmin = true
-	u.updatedAt = time.Now()
+	u.updatedAt = clock.Now()
 	return nil
 }
 
@@ -225,7 +228,7 @@ func (u *User) DemoteFromAdmin() error {
 		return errors.New("user cannot be nil")
 	}
 	u.isAdmin = false
-	u.updatedAt = time.Now()
+	u.updatedAt = clock.Now()
 	return nil
 }
 
@@ -250,7 +253,7 @@ func (u *User) Block(reason string) error {
 	}
 	u.status = StatusBlocked
 	u.blockedReason = reason
-	u.updatedAt = time.Now()
+	u.updatedAt = clock.Now()
 	return nil
 }
 
@@ -261,7 +264,7 @@ func (u *User) Unblock() error {
 	}
 	u.status = StatusActive
 	u.blockedReason = ""
-	u.updatedAt = time.Now()
+	u.updatedAt = clock.Now()
 	return nil
 }
 
@@ -281,7 +284,7 @@ func (u *User) RecordLogin() error {
 	if !u.status.CanLogin() {
 		return errors.New("user cannot login in current status")
 	}
-	now := time.Now()
+	now := clock.Now()
 	u.lastLoginAt = &now
 	u.updatedAt = now
 	return nil
@@ -311,6 +314,19 @@ func (u *User) GetUpdatedAt() time.Time {
 	return u.updatedAt
 }
 
+// GetDeletedAt returns when the user was soft-deleted, or nil
+func (u *User) GetDeletedAt() *time.Time {
+	if u == nil {
+		return nil
+	}
+	return u.deletedAt
+}
+
+// IsDeleted checks if the user is soft-deleted
+func (u *User) IsDeleted() bool {
+	return u.GetDeletedAt() != nil
+}
+
 // SetMetadata sets metadata for the user
 func (u *User) SetMetadata(key string, value interface{}) error {
 	if u == nil {
@@ -320,7 +336,7 @@ func (u *User) SetMetadata(key string, value interface{}) error {
 		return errors.New("metadata key cannot be empty")
 	}
 	u.metadata[key] = value
-	u.updatedAt = time.Now()
+	u.updatedAt = clock.Now()
 	return nil
 }
 
@@ -463,6 +479,15 @@ func (u *User) SetLastLoginAt(lastLoginAt *time.Time) error {
 	return nil
 }
 
+// SetDeletedAt sets the soft deletion time (used for loading from persistence)
+func (u *User) SetDeletedAt(deletedAt *time.Time) error {
+	if u == nil {
+		return errors.New("user cannot be nil")
+	}
+	u.deletedAt = deletedAt
+	return nil
+}
+
 // SetBlockedReason sets the blocked reason (used for loading from persistence)
 func (u *User) SetBlockedReason(blockedReason string) error {
 	if u == nil {
@@ -484,7 +509,7 @@ func (u *User) SetOAuthProvider(provider string) error {
 		return errors.New("OAuth provider cannot exceed 50 characters")
 	}
 	u.oauthProvider = provider
-	u.updatedAt = time.Now()
+	u.updatedAt = clock.Now()
 	return nil
 }
 
@@ -505,7 +530,7 @@ func (u *User) SetOAuthID(oauthID string) error {
 		return errors.New("OAuth ID cannot exceed 255 characters")
 	}
 	u.oauthID = oauthID
-	u.updatedAt = time.Now()
+	u.updatedAt = clock.Now()
 	return nil
 }
 
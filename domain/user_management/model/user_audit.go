@@ -0,0 +1,50 @@
+package user_management
+
+import (
+	"context"
+	"time"
+)
+
+// UserAuditAction is a lifecycle change recorded in the user audit trail
+type UserAuditAction string
+
+const (
+	// UserAuditDeleted is recorded when an admin soft-deletes a user
+	UserAuditDeleted UserAuditAction = "DELETED"
+	// UserAuditRestored is recorded when an admin restores a deleted user
+	UserAuditRestored UserAuditAction = "RESTORED"
+	// UserAuditPurged is recorded when a deleted user is removed for good
+	// after the retention period
+	UserAuditPurged UserAuditAction = "PURGED"
+)
+
+// UserAuditEntry records who deleted, restored or purged a user. Entries
+// keep the username, so they stay readable after the user is purged.
+type UserAuditEntry struct {
+	ID       string
+	UserID   string
+	Username string
+	Action   UserAuditAction
+	// ActorID and ActorName identify the admin who made the change; purges
+	// are made by the system
+	ActorID   string
+	ActorName string
+	Reason    string
+	CreatedAt time.Time
+}
+
+// UserAuditRepository stores the user audit trail
+type UserAuditRepository interface {
+	// Record adds an entry, assigning its ID and time when unset
+	// ctx is used to manage the request lifetime, handle cancellation, and pass deadlines
+	Record(ctx context.Context, entry *UserAuditEntry) error
+
+	// FindByUserID returns the entries of a user, newest first; limit <= 0
+	// returns every entry
+	// ctx is used to manage the request lifetime, handle cancellation, and pass deadlines
+	FindByUserID(ctx context.Context, userID string, limit int) ([]*UserAuditEntry, error)
+
+	// FindRecent returns the latest entries of every user, newest first
+	// ctx is used to manage the request lifetime, handle cancellation, and pass deadlines
+	FindRecent(ctx context.Context, limit int) ([]*UserAuditEntry, error)
+}
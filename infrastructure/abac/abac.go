@@ -0,0 +1,241 @@
+// Package abac evaluates the attribute conditions routes can declare on top
+// of role-based Casbin policies.
+//
+// Conditions are boolean expressions over request attributes, e.g.
+//
+//	owner_id == user_id
+//	tenant_id == request_tenant_id
+//	hour >= 9 && hour < 18 && weekday != 'Sunday'
+//
+// They are evaluated by the conditionMet function of the ABAC Casbin model
+// (config/casbin_abac_model.conf), which receives the route condition and
+// the request attributes as extra request values.
+package abac
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/util"
+	"github.com/casbin/govaluate"
+)
+
+// MatcherFunction is the name conditions are evaluated under in the ABAC model
+const MatcherFunction = "conditionMet"
+
+// claimPrefix prefixes the names of JWT claim attributes, e.g. claim_department
+const claimPrefix = "claim_"
+
+// MaxConditionLength bounds the length of a route condition
+const MaxConditionLength = 1000
+
+// attributeDescriptions documents the attributes conditions can use
+var attributeDescriptions = []AttributeDescription{
+	{Name: "user_id", Description: "ID of the authenticated user"},
+	{Name: "role", Description: "Role of the authenticated user"},
+	{Name: "tenant_id", Description: "Tenant of the user, from the tenant_id claim"},
+	{Name: "request_tenant_id", Description: "Tenant addressed by the request, from the X-Tenant-ID header or tenant_id parameter"},
+	{Name: "owner_id", Description: "Owner of the addressed resource, as set by earlier middleware or the user_id/owner_id path parameter"},
+	{Name: "resource_id", Description: "ID of the addressed resource, from the id path parameter"},
+	{Name: "ip", Description: "Client IP address"},
+	{Name: "method", Description: "HTTP method"},
+	{Name: "path", Description: "Request path"},
+	{Name: "hour", Description: "Hour of the request in server time, 0-23"},
+	{Name: "weekday", Description: "Day of the request in server time, e.g. 'Monday'"},
+	{Name: claimPrefix + "<name>", Description: "Any scalar JWT claim, e.g. claim_department"},
+}
+
+// functions are the Casbin built-ins available in conditions
+var functions = map[string]govaluate.ExpressionFunction{
+	"keyMatch":   util.KeyMatchFunc,
+	"keyMatch2":  util.KeyMatch2Func,
+	"regexMatch": util.RegexMatchFunc,
+	"ipMatch":    util.IPMatchFunc,
+}
+
+var claimNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// compiled caches parsed conditions by expression
+var compiled sync.Map
+
+// AttributeDescription documents an attribute available to conditions
+type AttributeDescription struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// AttributeDescriptions returns the attributes conditions can use
+func AttributeDescriptions() []AttributeDescription {
+	return append([]AttributeDescription(nil), attributeDescriptions...)
+}
+
+// Attributes are the request attributes conditions are evaluated against
+type Attributes struct {
+	UserID          string
+	Role            string
+	TenantID        string
+	RequestTenantID string
+	OwnerID         string
+	ResourceID      string
+	IPAddress       string
+	Method          string
+	Path            string
+	Time            time.Time
+	// Claims holds the JWT claims; scalar ones are exposed as claim_<name>
+	Claims map[string]interface{}
+}
+
+// Parameters returns the attributes keyed by the names conditions use
+func (a *Attributes) Parameters() map[string]interface{} {
+	if a == nil {
+		a = &Attributes{}
+	}
+	at := a.Time
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	params := map[string]interface{}{
+		"user_id":           a.UserID,
+		"role":              a.Role,
+		"tenant_id":         a.TenantID,
+		"request_tenant_id": a.RequestTenantID,
+		"owner_id":          a.OwnerID,
+		"resource_id":       a.ResourceID,
+		"ip":                a.IPAddress,
+		"method":            a.Method,
+		"path":              a.Path,
+		// Numbers are float64 so they compare with numeric literals
+		"hour":    float64(at.Hour()),
+		"weekday": at.Weekday().String(),
+	}
+	for name, value := range a.Claims {
+		if !claimNamePattern.MatchString(name) {
+			continue
+		}
+		switch v := value.(type) {
+		case string, bool, float64:
+			params[claimPrefix+name] = v
+		case int:
+			params[claimPrefix+name] = float64(v)
+		case int64:
+			params[claimPrefix+name] = float64(v)
+		}
+	}
+	return params
+}
+
+// ValidateCondition checks that a condition parses and only uses known attributes
+func ValidateCondition(condition string) error {
+	condition = strings.TrimSpace(condition)
+	if condition == "" {
+		return nil
+	}
+	if len(condition) > MaxConditionLength {
+		return fmt.Errorf("condition cannot exceed %d characters", MaxConditionLength)
+	}
+
+	expr, err := compile(condition)
+	if err != nil {
+		return err
+	}
+	known := (&Attributes{}).Parameters()
+	for _, name := range expr.Vars() {
+		if _, ok := known[name]; !ok && !strings.HasPrefix(name, claimPrefix) {
+			return fmt.Errorf("unknown attribute %q in condition", name)
+		}
+	}
+	return nil
+}
+
+// Evaluate reports whether the request attributes satisfy the condition. An
+// empty condition is always satisfied.
+func Evaluate(condition string, attrs *Attributes) (bool, error) {
+	condition = strings.TrimSpace(condition)
+	if condition == "" {
+		return true, nil
+	}
+
+	expr, err := compile(condition)
+	if err != nil {
+		return false, err
+	}
+	params := attrs.Parameters()
+	for _, name := range expr.Vars() {
+		// Claims missing from the token compare as nil instead of failing
+		if _, ok := params[name]; !ok && strings.HasPrefix(name, claimPrefix) {
+			params[name] = nil
+		}
+	}
+
+	result, err := expr.Evaluate(params)
+	if err != nil {
+		return false, fmt.Errorf("failed to evaluate condition %q: %w", condition, err)
+	}
+	met, ok := result.(bool)
+	if !ok {
+		return false, fmt.Errorf("condition %q does not evaluate to a boolean", condition)
+	}
+	return met, nil
+}
+
+func compile(condition string) (*govaluate.EvaluableExpression, error) {
+	if cached, ok := compiled.Load(condition); ok {
+		return cached.(*govaluate.EvaluableExpression), nil
+	}
+	expr, err := govaluate.NewEvaluableExpressionWithFunctions(condition, functions)
+	if err != nil {
+		return nil, fmt.Errorf("invalid condition %q: %w", condition, err)
+	}
+	compiled.Store(condition, expr)
+	return expr, nil
+}
+
+// IsABACModel reports whether the enforcer's model takes request attributes
+func IsABACModel(enforcer *casbin.Enforcer) bool {
+	if enforcer == nil {
+		return false
+	}
+	assertion, ok := enforcer.GetModel()["r"]["r"]
+	if !ok {
+		return false
+	}
+	for _, token := range assertion.Tokens {
+		if token == "r_attrs" {
+			return true
+		}
+	}
+	return false
+}
+
+// Register adds the condition matcher function to an enforcer using the ABAC
+// model. Enforcers using other models are left unchanged.
+func Register(enforcer *casbin.Enforcer) {
+	if !IsABACModel(enforcer) {
+		return
+	}
+	enforcer.AddFunction(MatcherFunction, conditionMet)
+}
+
+// RequestValues returns the values to enforce a request with. The ABAC model
+// also receives the route condition and the request attributes.
+func RequestValues(enforcer *casbin.Enforcer, sub, obj, act, condition string, attrs *Attributes) []interface{} {
+	if IsABACModel(enforcer) {
+		return []interface{}{sub, obj, act, condition, attrs}
+	}
+	return []interface{}{sub, obj, act}
+}
+
+// conditionMet is the matcher function of the ABAC model
+func conditionMet(args ...interface{}) (interface{}, error) {
+	if len(args) != 2 {
+		return false, fmt.Errorf("%s expects a condition and attributes, got %d arguments", MatcherFunction, len(args))
+	}
+	condition, _ := args[0].(string)
+	attrs, _ := args[1].(*Attributes)
+	return Evaluate(condition, attrs)
+}
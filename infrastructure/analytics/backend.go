@@ -0,0 +1,167 @@
+// Package analytics selects and provides the storage backend for API usage
+// analytics: the application database via GORM, or ClickHouse for
+// high-volume deployments.
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aruncs31s/azf/config"
+	"github.com/aruncs31s/azf/domain/repository"
+	"github.com/aruncs31s/azf/internal/persistence"
+	"github.com/aruncs31s/azf/shared/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	defaultBackend repository.UsageAnalyticsBackend
+	defaultMu      sync.Mutex
+)
+
+// NewBackend creates the usage analytics backend selected by cfg. The GORM
+// backend stores logs in db; the ClickHouse backend does not use it.
+func NewBackend(cfg config.UsageAnalyticsConfig, db *gorm.DB) (repository.UsageAnalyticsBackend, error) {
+	switch cfg.Backend {
+	case "", config.UsageAnalyticsBackendGorm:
+		if db == nil {
+			return nil, fmt.Errorf("usage analytics backend %q requires a database", config.UsageAnalyticsBackendGorm)
+		}
+		return persistence.NewGormUsageAnalyticsBackend(db), nil
+	case config.UsageAnalyticsBackendClickHouse:
+		return NewClickHouseBackend(cfg.ClickHouse)
+	default:
+		return nil, fmt.Errorf("unsupported usage analytics backend: %s", cfg.Backend)
+	}
+}
+
+// Default returns the shared usage analytics backend, creating it from the
+// environment configuration on first use. If the configured backend cannot be
+// created it falls back to the GORM backend on db.
+func Default(db *gorm.DB) repository.UsageAnalyticsBackend {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if defaultBackend != nil {
+		return defaultBackend
+	}
+
+	cfg := config.GetUsageAnalyticsConfig()
+	backend, err := NewBackend(cfg, db)
+	if err != nil {
+		logger.Error("Failed to create usage analytics backend, falling back to gorm",
+			zap.String("backend", cfg.Backend),
+			zap.Error(err))
+		backend = persistence.NewGormUsageAnalyticsBackend(db)
+	}
+
+	logger.Info("Usage analytics backend initialized", zap.String("backend", backend.Name()))
+	defaultBackend = backend
+	return defaultBackend
+}
+
+// SetDefault replaces the shared usage analytics backend
+func SetDefault(backend repository.UsageAnalyticsBackend) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultBackend = backend
+}
+
+// Close flushes and closes the shared usage analytics backend, if created
+func Close() error {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if defaultBackend == nil {
+		return nil
+	}
+	err := defaultBackend.Close()
+	defaultBackend = nil
+	return err
+}
+
+// clickHouseBackend stores raw usage logs in ClickHouse and answers
+// statistics with aggregate queries over them
+type clickHouseBackend struct {
+	logs  *clickHouseLogRepository
+	stats *clickHouseStatsRepository
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewClickHouseBackend connects to ClickHouse, creates the usage log table if
+// needed and starts the periodic batch flush
+func NewClickHouseBackend(cfg config.ClickHouseConfig) (repository.UsageAnalyticsBackend, error) {
+	client, err := newClickHouseClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.exec(context.Background(), clickHouseLogSchema, nil); err != nil {
+		return nil, fmt.Errorf("failed to create clickhouse usage log table: %w", err)
+	}
+	for _, migration := range clickHouseLogMigrations {
+		if err := client.exec(context.Background(), migration, nil); err != nil {
+			return nil, fmt.Errorf("failed to migrate clickhouse usage log table: %w", err)
+		}
+	}
+
+	backend := &clickHouseBackend{
+		logs:  newClickHouseLogRepository(client, cfg.BatchSize),
+		stats: newClickHouseStatsRepository(client),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+
+	flushInterval := cfg.FlushInterval
+	if flushInterval <= 0 {
+		flushInterval = 5 * time.Second
+	}
+	go backend.flushLoop(flushInterval)
+	return backend, nil
+}
+
+func (b *clickHouseBackend) flushLoop(interval time.Duration) {
+	defer close(b.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := b.logs.Flush(); err != nil {
+				logger.Warn("Failed to flush API usage logs to clickhouse", zap.Error(err))
+			}
+		case <-b.stop:
+			return
+		}
+	}
+}
+
+func (b *clickHouseBackend) Name() string {
+	return config.UsageAnalyticsBackendClickHouse
+}
+
+func (b *clickHouseBackend) Logs() repository.APIUsageLogRepository {
+	return b.logs
+}
+
+func (b *clickHouseBackend) Stats() repository.APIUsageStatsRepository {
+	return b.stats
+}
+
+// Close stops the flush loop and inserts any remaining buffered logs
+func (b *clickHouseBackend) Close() error {
+	select {
+	case <-b.stop:
+		return nil
+	default:
+		close(b.stop)
+	}
+	<-b.done
+	return b.logs.Flush()
+}
@@ -0,0 +1,154 @@
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aruncs31s/azf/config"
+)
+
+// clickHouseDateTimeFormat is the DateTime64(3) text format used in JSONEachRow
+const clickHouseDateTimeFormat = "2006-01-02 15:04:05.000"
+
+// clickHouseClient talks to ClickHouse over its HTTP interface. Queries use
+// server-side parameters ({name:Type} placeholders) rather than string
+// interpolation for user-supplied values.
+type clickHouseClient struct {
+	endpoint   string
+	database   string
+	username   string
+	password   string
+	httpClient *http.Client
+}
+
+func newClickHouseClient(cfg config.ClickHouseConfig) (*clickHouseClient, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("clickhouse URL is required")
+	}
+	if _, err := url.Parse(cfg.URL); err != nil {
+		return nil, fmt.Errorf("invalid clickhouse URL: %w", err)
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &clickHouseClient{
+		endpoint:   strings.TrimRight(cfg.URL, "/") + "/",
+		database:   cfg.Database,
+		username:   cfg.Username,
+		password:   cfg.Password,
+		httpClient: &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// exec runs a statement that returns no rows
+func (c *clickHouseClient) exec(ctx context.Context, query string, params map[string]string) error {
+	body, err := c.do(ctx, c.values(params), strings.NewReader(query))
+	if err != nil {
+		return err
+	}
+	return body.Close()
+}
+
+// insertJSONEachRow inserts rows into table in a single request
+func (c *clickHouseClient) insertJSONEachRow(ctx context.Context, table string, rows []any) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, row := range rows {
+		if err := encoder.Encode(row); err != nil {
+			return fmt.Errorf("failed to encode clickhouse row: %w", err)
+		}
+	}
+
+	values := c.values(nil)
+	values.Set("query", fmt.Sprintf("INSERT INTO %s FORMAT JSONEachRow", table))
+	body, err := c.do(ctx, values, &buf)
+	if err != nil {
+		return err
+	}
+	return body.Close()
+}
+
+// queryJSONEachRow runs a SELECT and decodes each JSONEachRow line into a T
+func queryJSONEachRow[T any](ctx context.Context, c *clickHouseClient, query string, params map[string]string) ([]T, error) {
+	body, err := c.do(ctx, c.values(params), strings.NewReader(query+" FORMAT JSONEachRow"))
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	rows := make([]T, 0)
+	decoder := json.NewDecoder(body)
+	for {
+		var row T
+		if err := decoder.Decode(&row); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to decode clickhouse row: %w", err)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func (c *clickHouseClient) values(params map[string]string) url.Values {
+	values := url.Values{}
+	if c.database != "" {
+		values.Set("database", c.database)
+	}
+	// Return 64-bit integers as JSON numbers so they decode into int64 fields
+	values.Set("output_format_json_quote_64bit_integers", "0")
+	for name, value := range params {
+		values.Set("param_"+name, value)
+	}
+	return values
+}
+
+func (c *clickHouseClient) do(ctx context.Context, values url.Values, body io.Reader) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint+"?"+values.Encode(), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build clickhouse request: %w", err)
+	}
+	if c.username != "" {
+		req.Header.Set("X-ClickHouse-User", c.username)
+	}
+	if c.password != "" {
+		req.Header.Set("X-ClickHouse-Key", c.password)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("clickhouse request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("clickhouse returned %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return resp.Body, nil
+}
+
+// clickHouseTime formats t for a DateTime64(3, 'UTC') column or parameter
+func clickHouseTime(t time.Time) string {
+	return t.UTC().Format(clickHouseDateTimeFormat)
+}
+
+// parseClickHouseTime parses a DateTime64(3, 'UTC') value from JSONEachRow output
+func parseClickHouseTime(value string) time.Time {
+	t, err := time.ParseInLocation(clickHouseDateTimeFormat, value, time.UTC)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
@@ -0,0 +1,472 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aruncs31s/azf/domain/api_usage"
+	"github.com/aruncs31s/azf/shared/logger"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const clickHouseLogTable = "api_usage_logs"
+
+// clickHouseLogSchema stores raw usage logs partitioned by month; the sort key
+// matches the common per-endpoint and time range lookups
+const clickHouseLogSchema = `CREATE TABLE IF NOT EXISTS api_usage_logs (
+	id String,
+	request_id String,
+	endpoint LowCardinality(String),
+	method LowCardinality(String),
+	status_code UInt16,
+	response_time Int64,
+	request_size Int64,
+	response_size Int64,
+	user_id Nullable(String),
+	api_key_id String,
+	client_ip String,
+	user_agent String CODEC(ZSTD),
+	error_message Nullable(String) CODEC(ZSTD),
+	conditional Bool,
+	has_validator Bool,
+	requested_at DateTime64(3, 'UTC'),
+	last_accessed_at DateTime64(3, 'UTC'),
+	created_at DateTime64(3, 'UTC')
+) ENGINE = MergeTree
+PARTITION BY toYYYYMM(requested_at)
+ORDER BY (endpoint, method, requested_at)`
+
+// clickHouseLogMigrations add the columns introduced after the table was
+// first created
+var clickHouseLogMigrations = []string{
+	"ALTER TABLE api_usage_logs ADD COLUMN IF NOT EXISTS request_id String AFTER id",
+	"ALTER TABLE api_usage_logs ADD COLUMN IF NOT EXISTS api_key_id String AFTER user_id",
+}
+
+const clickHouseLogColumns = `id, request_id, endpoint, method, status_code, response_time, request_size, response_size,
+	user_id, api_key_id, client_ip, user_agent, error_message, conditional, has_validator,
+	requested_at, last_accessed_at, created_at`
+
+// maxBufferedBatches bounds the buffer when ClickHouse is unreachable;
+// beyond it the oldest rows are dropped rather than growing without limit
+const maxBufferedBatches = 10
+
+// clickHouseLogRow is the JSONEachRow representation of an APIUsageLog
+type clickHouseLogRow struct {
+	ID             string  `json:"id"`
+	RequestID      string  `json:"request_id"`
+	Endpoint       string  `json:"endpoint"`
+	Method         string  `json:"method"`
+	StatusCode     int     `json:"status_code"`
+	ResponseTime   int64   `json:"response_time"`
+	RequestSize    int64   `json:"request_size"`
+	ResponseSize   int64   `json:"response_size"`
+	UserID         *string `json:"user_id"`
+	APIKeyID       string  `json:"api_key_id"`
+	ClientIP       string  `json:"client_ip"`
+	UserAgent      string  `json:"user_agent"`
+	ErrorMessage   *string `json:"error_message"`
+	Conditional    bool    `json:"conditional"`
+	HasValidator   bool    `json:"has_validator"`
+	RequestedAt    string  `json:"requested_at"`
+	LastAccessedAt string  `json:"last_accessed_at"`
+	CreatedAt      string  `json:"created_at"`
+}
+
+func toClickHouseLogRow(log api_usage.APIUsageLog) clickHouseLogRow {
+	return clickHouseLogRow{
+		ID:             log.ID,
+		RequestID:      log.RequestID,
+		Endpoint:       log.Endpoint,
+		Method:         log.Method,
+		StatusCode:     log.StatusCode,
+		ResponseTime:   log.ResponseTime,
+		RequestSize:    log.RequestSize,
+		ResponseSize:   log.ResponseSize,
+		UserID:         log.UserID,
+		APIKeyID:       log.APIKeyID,
+		ClientIP:       log.ClientIP,
+		UserAgent:      log.UserAgent,
+		ErrorMessage:   log.ErrorMessage,
+		Conditional:    log.Conditional,
+		HasValidator:   log.HasValidator,
+		RequestedAt:    clickHouseTime(log.RequestedAt),
+		LastAccessedAt: clickHouseTime(log.LastAccessedAt),
+		CreatedAt:      clickHouseTime(log.CreatedAt),
+	}
+}
+
+func (row clickHouseLogRow) toAPIUsageLog() api_usage.APIUsageLog {
+	return api_usage.APIUsageLog{
+		ID:             row.ID,
+		RequestID:      row.RequestID,
+		Endpoint:       row.Endpoint,
+		Method:         row.Method,
+		StatusCode:     row.StatusCode,
+		ResponseTime:   row.ResponseTime,
+		RequestSize:    row.RequestSize,
+		ResponseSize:   row.ResponseSize,
+		UserID:         row.UserID,
+		APIKeyID:       row.APIKeyID,
+		ClientIP:       row.ClientIP,
+		UserAgent:      row.UserAgent,
+		ErrorMessage:   row.ErrorMessage,
+		Conditional:    row.Conditional,
+		HasValidator:   row.HasValidator,
+		RequestedAt:    parseClickHouseTime(row.RequestedAt),
+		LastAccessedAt: parseClickHouseTime(row.LastAccessedAt),
+		CreatedAt:      parseClickHouseTime(row.CreatedAt),
+	}
+}
+
+// clickHouseLogRepository stores usage logs in ClickHouse. Writes are
+// buffered and inserted in batches, either when the batch is full or on the
+// flush interval, so logs become visible to reads after a short delay.
+type clickHouseLogRepository struct {
+	client    *clickHouseClient
+	batchSize int
+
+	mu      sync.Mutex
+	pending []any
+}
+
+func newClickHouseLogRepository(client *clickHouseClient, batchSize int) *clickHouseLogRepository {
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	return &clickHouseLogRepository{
+		client:    client,
+		batchSize: batchSize,
+	}
+}
+
+// Create queues a log for the next batch insert
+func (r *clickHouseLogRepository) Create(log *api_usage.APIUsageLog) (*api_usage.APIUsageLog, error) {
+	prepareLog(log)
+
+	r.mu.Lock()
+	r.pending = append(r.pending, toClickHouseLogRow(*log))
+	full := len(r.pending) >= r.batchSize
+	r.mu.Unlock()
+
+	if full {
+		if err := r.Flush(); err != nil {
+			return log, err
+		}
+	}
+	return log, nil
+}
+
+// BatchCreate queues logs and flushes them immediately
+func (r *clickHouseLogRepository) BatchCreate(logs *[]api_usage.APIUsageLog) error {
+	r.mu.Lock()
+	for i := range *logs {
+		prepareLog(&(*logs)[i])
+		r.pending = append(r.pending, toClickHouseLogRow((*logs)[i]))
+	}
+	r.mu.Unlock()
+	return r.Flush()
+}
+
+// Flush inserts all buffered logs in batches of batchSize. Rows from a failed
+// insert are put back at the front of the buffer for the next attempt.
+func (r *clickHouseLogRepository) Flush() error {
+	r.mu.Lock()
+	rows := r.pending
+	r.pending = nil
+	r.mu.Unlock()
+
+	for start := 0; start < len(rows); start += r.batchSize {
+		end := min(start+r.batchSize, len(rows))
+		if err := r.client.insertJSONEachRow(context.Background(), clickHouseLogTable, rows[start:end]); err != nil {
+			r.requeue(rows[start:])
+			return fmt.Errorf("failed to insert API usage logs into clickhouse: %w", err)
+		}
+	}
+	return nil
+}
+
+func (r *clickHouseLogRepository) requeue(rows []any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.pending = append(rows, r.pending...)
+	if limit := r.batchSize * maxBufferedBatches; len(r.pending) > limit {
+		dropped := len(r.pending) - limit
+		r.pending = r.pending[dropped:]
+		logger.Warn("Dropped buffered API usage logs; clickhouse unavailable", zap.Int("dropped", dropped))
+	}
+}
+
+func prepareLog(log *api_usage.APIUsageLog) {
+	if log.ID == "" {
+		log.ID = uuid.New().String()
+	}
+	if log.CreatedAt.IsZero() {
+		log.CreatedAt = time.Now()
+	}
+}
+
+func (r *clickHouseLogRepository) DeleteOlderThan(days int) error {
+	cutoffDate := time.Now().AddDate(0, 0, -days)
+	if err := r.client.exec(context.Background(),
+		"ALTER TABLE api_usage_logs DELETE WHERE requested_at < {cutoff:DateTime64(3, 'UTC')}",
+		map[string]string{"cutoff": clickHouseTime(cutoffDate)}); err != nil {
+		return fmt.Errorf("failed to delete old API usage logs: %w", err)
+	}
+	return nil
+}
+
+func (r *clickHouseLogRepository) DeleteAll() error {
+	r.mu.Lock()
+	r.pending = nil
+	r.mu.Unlock()
+
+	if err := r.client.exec(context.Background(), "TRUNCATE TABLE IF EXISTS api_usage_logs", nil); err != nil {
+		return fmt.Errorf("failed to delete all API usage logs: %w", err)
+	}
+	return nil
+}
+
+func (r *clickHouseLogRepository) findLogs(where string, params map[string]string, limit int, offset int) (*[]api_usage.APIUsageLog, error) {
+	query := "SELECT " + clickHouseLogColumns + " FROM api_usage_logs"
+	if where != "" {
+		query += " WHERE " + where
+	}
+	query += " ORDER BY requested_at DESC"
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d OFFSET %d", limit, max(offset, 0))
+	}
+
+	rows, err := queryJSONEachRow[clickHouseLogRow](context.Background(), r.client, query, params)
+	if err != nil {
+		return nil, err
+	}
+	logs := make([]api_usage.APIUsageLog, 0, len(rows))
+	for _, row := range rows {
+		logs = append(logs, row.toAPIUsageLog())
+	}
+	return &logs, nil
+}
+
+func (r *clickHouseLogRepository) FindByID(id string) (*api_usage.APIUsageLog, error) {
+	logs, err := r.findLogs("id = {id:String}", map[string]string{"id": id}, 1, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(*logs) == 0 {
+		return nil, nil
+	}
+	return &(*logs)[0], nil
+}
+
+func (r *clickHouseLogRepository) FindAll(limit int, offset int) (*[]api_usage.APIUsageLog, error) {
+	return r.findLogs("", nil, limit, offset)
+}
+
+func (r *clickHouseLogRepository) FindByEndpoint(endpoint string, limit int, offset int) (*[]api_usage.APIUsageLog, error) {
+	return r.findLogs("endpoint = {endpoint:String}", map[string]string{"endpoint": endpoint}, limit, offset)
+}
+
+func (r *clickHouseLogRepository) FindErrorsByEndpoint(endpoint string, limit int) (*[]api_usage.APIUsageLog, error) {
+	return r.findLogs(
+		"endpoint = {endpoint:String} AND (status_code < 200 OR status_code >= 300) AND status_code != 304",
+		map[string]string{"endpoint": endpoint},
+		limit, 0,
+	)
+}
+
+func (r *clickHouseLogRepository) FindByUserID(userID string, limit int, offset int) (*[]api_usage.APIUsageLog, error) {
+	return r.findLogs("user_id = {user_id:String}", map[string]string{"user_id": userID}, limit, offset)
+}
+
+func (r *clickHouseLogRepository) FindByDateRange(startDate string, endDate string, limit int, offset int) (*[]api_usage.APIUsageLog, error) {
+	return r.findLogs(
+		"requested_at BETWEEN parseDateTime64BestEffort({start:String}, 3, 'UTC') AND parseDateTime64BestEffort({end:String}, 3, 'UTC')",
+		map[string]string{"start": startDate, "end": endDate},
+		limit, offset,
+	)
+}
+
+type clickHouseCountRow struct {
+	Count int64 `json:"count"`
+}
+
+func (r *clickHouseLogRepository) count(where string, params map[string]string) (int64, error) {
+	query := "SELECT count() AS count FROM api_usage_logs"
+	if where != "" {
+		query += " WHERE " + where
+	}
+	rows, err := queryJSONEachRow[clickHouseCountRow](context.Background(), r.client, query, params)
+	if err != nil {
+		return 0, err
+	}
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	return rows[0].Count, nil
+}
+
+func (r *clickHouseLogRepository) CountByEndpoint(endpoint string) (int64, error) {
+	return r.count("endpoint = {endpoint:String}", map[string]string{"endpoint": endpoint})
+}
+
+func (r *clickHouseLogRepository) CountByStatusCode(endpoint string) (*[]api_usage.APIUsageStatusCount, error) {
+	counts, err := queryJSONEachRow[api_usage.APIUsageStatusCount](context.Background(), r.client,
+		`SELECT status_code, count() AS requests, avg(response_time) AS avg_response_time_ms
+FROM api_usage_logs
+WHERE endpoint = {endpoint:String}
+GROUP BY status_code
+ORDER BY requests DESC, status_code ASC`,
+		map[string]string{"endpoint": endpoint})
+	if err != nil {
+		return nil, err
+	}
+	return &counts, nil
+}
+
+func (r *clickHouseLogRepository) CountTotal() (int64, error) {
+	return r.count("", nil)
+}
+
+func (r *clickHouseLogRepository) CountSince(since time.Time) (int64, error) {
+	return r.count("requested_at >= {since:DateTime64(3, 'UTC')}", map[string]string{"since": clickHouseTime(since)})
+}
+
+type clickHouseTimingRow struct {
+	RequestedAt  string `json:"requested_at"`
+	ResponseTime int64  `json:"response_time"`
+}
+
+func (r *clickHouseLogRepository) FindTimingsSince(since time.Time) (*[]api_usage.APIUsageTiming, error) {
+	rows, err := queryJSONEachRow[clickHouseTimingRow](context.Background(), r.client,
+		"SELECT requested_at, response_time FROM api_usage_logs WHERE requested_at >= {since:DateTime64(3, 'UTC')}",
+		map[string]string{"since": clickHouseTime(since)})
+	if err != nil {
+		return nil, err
+	}
+	timings := make([]api_usage.APIUsageTiming, 0, len(rows))
+	for _, row := range rows {
+		timings = append(timings, api_usage.APIUsageTiming{
+			RequestedAt:  parseClickHouseTime(row.RequestedAt),
+			ResponseTime: row.ResponseTime,
+		})
+	}
+	return &timings, nil
+}
+
+type clickHouseHourlyRow struct {
+	Bucket            string `json:"bucket"`
+	RequestCount      int64  `json:"request_count"`
+	SuccessCount      int64  `json:"success_count"`
+	TotalResponseTime int64  `json:"total_response_time"`
+}
+
+func (r *clickHouseLogRepository) AggregateHourly(from time.Time, to time.Time) (*[]api_usage.APIUsageHourlyBucket, error) {
+	rows, err := queryJSONEachRow[clickHouseHourlyRow](context.Background(), r.client,
+		`SELECT toDateTime64(toStartOfHour(requested_at), 3, 'UTC') AS bucket,
+	count() AS request_count,
+	countIf((status_code >= 200 AND status_code < 300) OR status_code = 304) AS success_count,
+	sum(response_time) AS total_response_time
+FROM api_usage_logs
+WHERE requested_at >= {from:DateTime64(3, 'UTC')} AND requested_at < {to:DateTime64(3, 'UTC')}
+GROUP BY bucket
+ORDER BY bucket`,
+		map[string]string{"from": clickHouseTime(from), "to": clickHouseTime(to)})
+	if err != nil {
+		return nil, err
+	}
+	buckets := make([]api_usage.APIUsageHourlyBucket, 0, len(rows))
+	for _, row := range rows {
+		buckets = append(buckets, api_usage.APIUsageHourlyBucket{
+			Bucket:            parseClickHouseTime(row.Bucket),
+			RequestCount:      row.RequestCount,
+			SuccessCount:      row.SuccessCount,
+			TotalResponseTime: row.TotalResponseTime,
+		})
+	}
+	return &buckets, nil
+}
+
+// clickHouseClientAggregateSelect mirrors the GORM client aggregate: errors
+// are non-2xx responses other than 304, rate-limit hits are 429s
+const clickHouseClientAggregateSelect = `SELECT %s AS identity,
+	count() AS total_requests,
+	countIf((status_code < 200 OR status_code >= 300) AND status_code != 304) AS error_requests,
+	countIf(status_code = 429) AS rate_limited_requests,
+	avg(response_time) AS avg_response_time_ms,
+	sum(request_size) AS request_bytes,
+	sum(response_size) AS response_bytes
+FROM api_usage_logs
+WHERE requested_at >= {from:DateTime64(3, 'UTC')} AND requested_at < {to:DateTime64(3, 'UTC')} AND %s
+GROUP BY identity`
+
+// clickHouseDimensionColumn maps a client dimension to its usage log column
+func clickHouseDimensionColumn(dimension string) (string, error) {
+	switch dimension {
+	case api_usage.ClientDimensionUser:
+		return "assumeNotNull(user_id)", nil
+	case api_usage.ClientDimensionIP:
+		return "client_ip", nil
+	case api_usage.ClientDimensionAPIKey:
+		return "api_key_id", nil
+	default:
+		return "", fmt.Errorf("unsupported client dimension: %s", dimension)
+	}
+}
+
+func (r *clickHouseLogRepository) AggregateByClient(dimension string, from time.Time, to time.Time, limit int) (*[]api_usage.ClientUsageAggregate, error) {
+	column, err := clickHouseDimensionColumn(dimension)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := "identity != ''"
+	if dimension == api_usage.ClientDimensionUser {
+		filter = "user_id IS NOT NULL AND user_id != ''"
+	}
+	query := fmt.Sprintf(clickHouseClientAggregateSelect, column, filter) + " ORDER BY total_requests DESC"
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	}
+
+	aggregates, err := queryJSONEachRow[api_usage.ClientUsageAggregate](context.Background(), r.client, query, map[string]string{
+		"from": clickHouseTime(from),
+		"to":   clickHouseTime(to),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &aggregates, nil
+}
+
+func (r *clickHouseLogRepository) AggregateForClients(dimension string, identities []string, from time.Time, to time.Time) (*[]api_usage.ClientUsageAggregate, error) {
+	column, err := clickHouseDimensionColumn(dimension)
+	if err != nil {
+		return nil, err
+	}
+	if len(identities) == 0 {
+		aggregates := make([]api_usage.ClientUsageAggregate, 0)
+		return &aggregates, nil
+	}
+
+	quoted := make([]string, 0, len(identities))
+	for _, identity := range identities {
+		quoted = append(quoted, "'"+strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(identity)+"'")
+	}
+	query := fmt.Sprintf(clickHouseClientAggregateSelect, column, "identity IN {identities:Array(String)}")
+
+	aggregates, err := queryJSONEachRow[api_usage.ClientUsageAggregate](context.Background(), r.client, query, map[string]string{
+		"from":       clickHouseTime(from),
+		"to":         clickHouseTime(to),
+		"identities": "[" + strings.Join(quoted, ",") + "]",
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &aggregates, nil
+}
@@ -0,0 +1,270 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aruncs31s/azf/domain/api_usage"
+	"github.com/google/uuid"
+)
+
+// clickHouseStatsSelect derives per-endpoint statistics from the raw logs
+// with the same rules RecalculateStats applies in the GORM backend
+const clickHouseStatsSelect = `SELECT endpoint, method,
+	count() AS total_requests,
+	countIf((status_code >= 200 AND status_code < 300) OR status_code = 304) AS success_requests,
+	total_requests - success_requests AS error_requests,
+	toInt64(avg(response_time)) AS avg_response_time,
+	toInt64(quantileExact(0.95)(response_time)) AS p95_response_time,
+	max(response_time) AS max_response_time,
+	min(response_time) AS min_response_time,
+	countIf(requested_at > now64(3) - INTERVAL 1 DAY) AS last24_hours,
+	sum(request_size) AS total_request_bytes,
+	sum(response_size) AS total_response_bytes,
+	max(request_size) AS max_request_bytes,
+	countIf(conditional) AS conditional_requests,
+	countIf(status_code = 304) AS not_modified_responses,
+	countIf(has_validator) AS validator_responses,
+	sumIf(response_size, status_code >= 200 AND status_code < 300 AND NOT conditional) AS uncached_response_bytes,
+	max(requested_at) AS last_accessed_at,
+	min(created_at) AS created_at
+FROM api_usage_logs`
+
+// clickHouseStatsRow is the JSONEachRow representation of an aggregated APIUsageStats
+type clickHouseStatsRow struct {
+	Endpoint              string `json:"endpoint"`
+	Method                string `json:"method"`
+	TotalRequests         int64  `json:"total_requests"`
+	SuccessRequests       int64  `json:"success_requests"`
+	ErrorRequests         int64  `json:"error_requests"`
+	AvgResponseTime       int64  `json:"avg_response_time"`
+	P95ResponseTime       int64  `json:"p95_response_time"`
+	MaxResponseTime       int64  `json:"max_response_time"`
+	MinResponseTime       int64  `json:"min_response_time"`
+	Last24Hours           int64  `json:"last24_hours"`
+	TotalRequestBytes     int64  `json:"total_request_bytes"`
+	TotalResponseBytes    int64  `json:"total_response_bytes"`
+	MaxRequestBytes       int64  `json:"max_request_bytes"`
+	ConditionalRequests   int64  `json:"conditional_requests"`
+	NotModifiedResponses  int64  `json:"not_modified_responses"`
+	ValidatorResponses    int64  `json:"validator_responses"`
+	UncachedResponseBytes int64  `json:"uncached_response_bytes"`
+	LastAccessedAt        string `json:"last_accessed_at"`
+	CreatedAt             string `json:"created_at"`
+}
+
+func (row clickHouseStatsRow) toAPIUsageStats() api_usage.APIUsageStats {
+	return api_usage.APIUsageStats{
+		ID:                    clickHouseStatsID(row.Endpoint, row.Method),
+		Endpoint:              row.Endpoint,
+		Method:                row.Method,
+		TotalRequests:         row.TotalRequests,
+		SuccessRequests:       row.SuccessRequests,
+		ErrorRequests:         row.ErrorRequests,
+		AvgResponseTime:       row.AvgResponseTime,
+		P95ResponseTime:       row.P95ResponseTime,
+		MaxResponseTime:       row.MaxResponseTime,
+		MinResponseTime:       row.MinResponseTime,
+		Last24Hours:           row.Last24Hours,
+		TotalRequestBytes:     row.TotalRequestBytes,
+		TotalResponseBytes:    row.TotalResponseBytes,
+		MaxRequestBytes:       row.MaxRequestBytes,
+		ConditionalRequests:   row.ConditionalRequests,
+		NotModifiedResponses:  row.NotModifiedResponses,
+		ValidatorResponses:    row.ValidatorResponses,
+		UncachedResponseBytes: row.UncachedResponseBytes,
+		LastAccessedAt:        parseClickHouseTime(row.LastAccessedAt),
+		UpdatedAt:             time.Now(),
+		CreatedAt:             parseClickHouseTime(row.CreatedAt),
+	}
+}
+
+// clickHouseStatsID derives a stable ID for an endpoint's statistics, since
+// ClickHouse statistics are computed on read rather than stored
+func clickHouseStatsID(endpoint string, method string) string {
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte(method+" "+endpoint)).String()
+}
+
+// clickHouseStatsRepository serves endpoint statistics as aggregate queries
+// over the raw logs. Statistics are always current, so the write operations
+// that maintain the GORM stats table are no-ops here.
+type clickHouseStatsRepository struct {
+	client *clickHouseClient
+}
+
+func newClickHouseStatsRepository(client *clickHouseClient) *clickHouseStatsRepository {
+	return &clickHouseStatsRepository{client: client}
+}
+
+func (r *clickHouseStatsRepository) aggregate(where string, having string, params map[string]string, orderBy string, limit int, offset int) ([]api_usage.APIUsageStats, error) {
+	query := clickHouseStatsSelect
+	if where != "" {
+		query += " WHERE " + where
+	}
+	query += " GROUP BY endpoint, method"
+	if having != "" {
+		query += " HAVING " + having
+	}
+	query += " ORDER BY " + orderBy
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d OFFSET %d", limit, max(offset, 0))
+	}
+
+	rows, err := queryJSONEachRow[clickHouseStatsRow](context.Background(), r.client, query, params)
+	if err != nil {
+		return nil, err
+	}
+	stats := make([]api_usage.APIUsageStats, 0, len(rows))
+	for _, row := range rows {
+		stats = append(stats, row.toAPIUsageStats())
+	}
+	return stats, nil
+}
+
+// FindByID scans the aggregated statistics for the derived ID
+func (r *clickHouseStatsRepository) FindByID(id string) (*api_usage.APIUsageStats, error) {
+	stats, err := r.aggregate("", "", nil, "total_requests DESC", 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	for i := range stats {
+		if stats[i].ID == id {
+			return &stats[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *clickHouseStatsRepository) FindAll(limit int, offset int) (*[]api_usage.APIUsageStats, error) {
+	stats, err := r.aggregate("", "", nil, "total_requests DESC", limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+func (r *clickHouseStatsRepository) FindByEndpoint(endpoint string) (*api_usage.APIUsageStats, error) {
+	stats, err := r.aggregate("endpoint = {endpoint:String}", "", map[string]string{"endpoint": endpoint}, "total_requests DESC", 1, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(stats) == 0 {
+		return nil, nil
+	}
+	return &stats[0], nil
+}
+
+// clickHouseRankingColumns are the aggregate columns rankings are sorted by
+var clickHouseRankingColumns = map[string]string{
+	api_usage.RankingSortRequests:  "total_requests",
+	api_usage.RankingSortErrorRate: "error_requests / total_requests",
+	// The Wilson score lower bound with z = 1.96, as api_usage.WilsonLowerBound
+	api_usage.RankingSortErrorRateWilson: `(error_requests / total_requests + 1.9208 / total_requests
+		- 1.96 * sqrt(error_requests / total_requests * (1 - error_requests / total_requests) / total_requests
+			+ 0.9604 / (total_requests * total_requests))) / (1 + 3.8416 / total_requests)`,
+	api_usage.RankingSortP95:             "p95_response_time",
+	api_usage.RankingSortAvgResponseTime: "avg_response_time",
+	api_usage.RankingSortLast24Hours:     "last24_hours",
+}
+
+func (r *clickHouseStatsRepository) GetTopEndpointsByUsage(limit int) (*[]api_usage.APIEndpointRanking, error) {
+	rankings, _, err := r.GetEndpointRankings(api_usage.EndpointRankingQuery{SortBy: api_usage.RankingSortRequests, Limit: limit})
+	return rankings, err
+}
+
+func (r *clickHouseStatsRepository) GetEndpointsByErrorRate(limit int) (*[]api_usage.APIEndpointRanking, error) {
+	rankings, _, err := r.GetEndpointRankings(api_usage.EndpointRankingQuery{SortBy: api_usage.RankingSortErrorRate, MinRequests: 1, Limit: limit})
+	return rankings, err
+}
+
+func (r *clickHouseStatsRepository) GetEndpointsByResponseTime(limit int) (*[]api_usage.APIEndpointRanking, error) {
+	rankings, _, err := r.GetEndpointRankings(api_usage.EndpointRankingQuery{SortBy: api_usage.RankingSortAvgResponseTime, Limit: limit})
+	return rankings, err
+}
+
+func (r *clickHouseStatsRepository) GetEndpointRankings(query api_usage.EndpointRankingQuery) (*[]api_usage.APIEndpointRanking, int64, error) {
+	column, ok := clickHouseRankingColumns[query.SortBy]
+	if !ok {
+		return nil, 0, fmt.Errorf("unknown ranking sort %q", query.SortBy)
+	}
+	direction := "DESC"
+	if query.Ascending {
+		direction = "ASC"
+	}
+	having := ""
+	if query.MinRequests > 0 {
+		having = fmt.Sprintf("total_requests >= %d", query.MinRequests)
+	}
+
+	countQuery := "SELECT count() AS count FROM (SELECT endpoint, method, count() AS total_requests FROM api_usage_logs GROUP BY endpoint, method"
+	if having != "" {
+		countQuery += " HAVING " + having
+	}
+	countQuery += ")"
+	counts, err := queryJSONEachRow[clickHouseCountRow](context.Background(), r.client, countQuery, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	var total int64
+	if len(counts) > 0 {
+		total = counts[0].Count
+	}
+
+	stats, err := r.aggregate("", having, nil, column+" "+direction+", endpoint ASC, method ASC", query.Limit, query.Offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	rankings := make([]api_usage.APIEndpointRanking, 0, len(stats))
+	for i, stat := range stats {
+		rankings = append(rankings, api_usage.APIEndpointRanking{
+			Endpoint:        stat.Endpoint,
+			Method:          stat.Method,
+			TotalRequests:   stat.TotalRequests,
+			SuccessRequests: stat.SuccessRequests,
+			ErrorRequests:   stat.ErrorRequests,
+			AvgResponseTime: stat.AvgResponseTime,
+			P95ResponseTime: stat.P95ResponseTime,
+			Last24Hours:     stat.Last24Hours,
+			Rank:            query.Offset + i + 1,
+		}.WithErrorRate())
+	}
+	return &rankings, total, nil
+}
+
+func (r *clickHouseStatsRepository) CountTotal() (int64, error) {
+	rows, err := queryJSONEachRow[clickHouseCountRow](context.Background(), r.client,
+		"SELECT uniqExact(endpoint, method) AS count FROM api_usage_logs", nil)
+	if err != nil {
+		return 0, err
+	}
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	return rows[0].Count, nil
+}
+
+// Create is a no-op; statistics are derived from the logs
+func (r *clickHouseStatsRepository) Create(stats *api_usage.APIUsageStats) (*api_usage.APIUsageStats, error) {
+	return stats, nil
+}
+
+// Update is a no-op; statistics are derived from the logs
+func (r *clickHouseStatsRepository) Update(stats *api_usage.APIUsageStats) (*api_usage.APIUsageStats, error) {
+	return stats, nil
+}
+
+// Upsert is a no-op; statistics are derived from the logs
+func (r *clickHouseStatsRepository) Upsert(stats *api_usage.APIUsageStats) (*api_usage.APIUsageStats, error) {
+	return stats, nil
+}
+
+// RecalculateStats is a no-op; statistics are aggregated on every read
+func (r *clickHouseStatsRepository) RecalculateStats(endpoint string, method string) error {
+	return nil
+}
+
+// DeleteAll is a no-op; deleting the logs clears the statistics
+func (r *clickHouseStatsRepository) DeleteAll() error {
+	return nil
+}
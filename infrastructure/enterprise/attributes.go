@@ -0,0 +1,74 @@
+package enterprise
+
+import (
+	"time"
+
+	"github.com/aruncs31s/azf/infrastructure/abac"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ResourceOwnerContextKey is the gin context key under which middleware that
+// loads the addressed resource can record its owner for owner_id conditions
+const ResourceOwnerContextKey = "resource_owner_id"
+
+// SetResourceOwner records the owner of the resource a request addresses, so
+// owner_id conditions compare against it instead of the path parameters
+func SetResourceOwner(c *gin.Context, ownerID string) {
+	c.Set(ResourceOwnerContextKey, ownerID)
+}
+
+// AttributeExtractor builds the attributes route conditions are evaluated
+// against when the Casbin enforcer uses the ABAC model
+type AttributeExtractor interface {
+	ExtractAttributes(hc *HookContext) *abac.Attributes
+}
+
+// defaultAttributeExtractor reads attributes from the JWT claims and the gin context
+type defaultAttributeExtractor struct{}
+
+// NewDefaultAttributeExtractor creates the extractor used when none is configured
+func NewDefaultAttributeExtractor() AttributeExtractor {
+	return defaultAttributeExtractor{}
+}
+
+func (defaultAttributeExtractor) ExtractAttributes(hc *HookContext) *abac.Attributes {
+	attrs := &abac.Attributes{
+		UserID:    hc.UserID,
+		Role:      hc.Role,
+		IPAddress: hc.IPAddress,
+		Method:    hc.Action,
+		Path:      hc.Resource,
+		Time:      time.Now(),
+	}
+
+	c := hc.Gin
+	if c == nil {
+		return attrs
+	}
+	if value, exists := c.Get("jwt_claims"); exists {
+		switch claims := value.(type) {
+		case jwt.MapClaims:
+			attrs.Claims = claims
+		case map[string]interface{}:
+			attrs.Claims = claims
+		}
+	}
+	if tenantID, ok := attrs.Claims["tenant_id"].(string); ok {
+		attrs.TenantID = tenantID
+	}
+
+	attrs.RequestTenantID = firstNonEmpty(c.GetHeader("X-Tenant-ID"), c.Param("tenant_id"), c.Query("tenant_id"))
+	attrs.OwnerID = firstNonEmpty(c.GetString(ResourceOwnerContextKey), c.Param("owner_id"), c.Param("user_id"))
+	attrs.ResourceID = c.Param("id")
+	return attrs
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
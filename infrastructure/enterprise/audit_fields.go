@@ -0,0 +1,204 @@
+package enterprise
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// AuditFieldType is the type of a custom audit field
+type AuditFieldType string
+
+const (
+	AuditFieldString AuditFieldType = "string"
+	AuditFieldInt    AuditFieldType = "int"
+	AuditFieldFloat  AuditFieldType = "float"
+	AuditFieldBool   AuditFieldType = "bool"
+)
+
+const (
+	// auditFieldsContextKey holds the custom fields set on the gin context
+	auditFieldsContextKey = "azf_audit_fields"
+	// maxAuditFields bounds the custom fields stored per audit entry
+	maxAuditFields = 32
+	// maxAuditFieldLength truncates long string values
+	maxAuditFieldLength = 1024
+)
+
+var auditFieldNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// auditFieldSchema holds the registered custom audit fields
+var auditFieldSchema = struct {
+	mu     sync.RWMutex
+	fields map[string]AuditFieldType
+}{fields: make(map[string]AuditFieldType)}
+
+// RegisterAuditField declares a custom audit field. Only registered fields are
+// stored, and their values must match the registered type.
+func RegisterAuditField(name string, fieldType AuditFieldType) error {
+	if !auditFieldNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid audit field name %q: use lowercase letters, digits and underscores", name)
+	}
+	switch fieldType {
+	case AuditFieldString, AuditFieldInt, AuditFieldFloat, AuditFieldBool:
+	default:
+		return fmt.Errorf("invalid audit field type %q for %s", fieldType, name)
+	}
+
+	auditFieldSchema.mu.Lock()
+	defer auditFieldSchema.mu.Unlock()
+	if existing, exists := auditFieldSchema.fields[name]; exists && existing != fieldType {
+		return fmt.Errorf("audit field %s is already registered as %s", name, existing)
+	}
+	auditFieldSchema.fields[name] = fieldType
+	return nil
+}
+
+// AuditFields returns the registered custom audit fields and their types
+func AuditFields() map[string]AuditFieldType {
+	auditFieldSchema.mu.RLock()
+	defer auditFieldSchema.mu.RUnlock()
+
+	fields := make(map[string]AuditFieldType, len(auditFieldSchema.fields))
+	for name, fieldType := range auditFieldSchema.fields {
+		fields[name] = fieldType
+	}
+	return fields
+}
+
+func auditFieldType(name string) (AuditFieldType, bool) {
+	auditFieldSchema.mu.RLock()
+	defer auditFieldSchema.mu.RUnlock()
+	fieldType, exists := auditFieldSchema.fields[name]
+	return fieldType, exists
+}
+
+// SetAuditField attaches a custom field to the audit entry of the current
+// request. Handlers can call it before they return; the entry is written once
+// the request completes.
+func SetAuditField(c *gin.Context, name string, value interface{}) {
+	fields, _ := c.Get(auditFieldsContextKey)
+	fieldMap, ok := fields.(map[string]interface{})
+	if !ok {
+		fieldMap = make(map[string]interface{})
+		c.Set(auditFieldsContextKey, fieldMap)
+	}
+	fieldMap[name] = value
+}
+
+// collectAuditFields merges the fields from audit enrichment hooks with those
+// set on the gin context, which take precedence. Only registered fields with
+// values of the registered type are kept.
+func collectAuditFields(c *gin.Context, hookFields map[string]interface{}, logger *zap.Logger) map[string]interface{} {
+	fields := make(map[string]interface{}, len(hookFields))
+	add := func(source map[string]interface{}) {
+		for name, value := range source {
+			if _, exists := fields[name]; !exists && len(fields) >= maxAuditFields {
+				logger.Warn("Too many custom audit fields, dropping field", zap.String("field", name), zap.Int("max", maxAuditFields))
+				continue
+			}
+			fieldType, registered := auditFieldType(name)
+			if !registered {
+				logger.Warn("Dropping unregistered audit field", zap.String("field", name))
+				continue
+			}
+			normalized, err := normalizeAuditField(fieldType, value)
+			if err != nil {
+				logger.Warn("Dropping invalid audit field", zap.String("field", name), zap.Error(err))
+				continue
+			}
+			fields[name] = normalized
+		}
+	}
+
+	add(hookFields)
+	if c != nil {
+		if contextFields, exists := c.Get(auditFieldsContextKey); exists {
+			if fieldMap, ok := contextFields.(map[string]interface{}); ok {
+				add(fieldMap)
+			}
+		}
+	}
+	return fields
+}
+
+// normalizeAuditField converts value to the canonical Go type for fieldType
+func normalizeAuditField(fieldType AuditFieldType, value interface{}) (interface{}, error) {
+	switch fieldType {
+	case AuditFieldString:
+		if s, ok := value.(string); ok {
+			if len(s) > maxAuditFieldLength {
+				s = strings.ToValidUTF8(s[:maxAuditFieldLength], "")
+			}
+			return s, nil
+		}
+	case AuditFieldInt:
+		switch v := value.(type) {
+		case int:
+			return int64(v), nil
+		case int32:
+			return int64(v), nil
+		case int64:
+			return v, nil
+		case uint32:
+			return int64(v), nil
+		}
+	case AuditFieldFloat:
+		switch v := value.(type) {
+		case float64:
+			if !math.IsNaN(v) && !math.IsInf(v, 0) {
+				return v, nil
+			}
+		case float32:
+			return float64(v), nil
+		case int:
+			return float64(v), nil
+		case int64:
+			return float64(v), nil
+		}
+	case AuditFieldBool:
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+	}
+	return nil, fmt.Errorf("expected %s, got %T", fieldType, value)
+}
+
+// auditFieldFilterFragment returns the JSON fragment a stored metadata column
+// contains when field name equals value, parsing value as the field's type
+func auditFieldFilterFragment(name, value string) (string, error) {
+	fieldType, registered := auditFieldType(name)
+	if !registered {
+		return "", fmt.Errorf("unknown audit field: %s", name)
+	}
+
+	var typed interface{}
+	var err error
+	switch fieldType {
+	case AuditFieldString:
+		typed = value
+	case AuditFieldInt:
+		typed, err = strconv.ParseInt(value, 10, 64)
+	case AuditFieldFloat:
+		typed, err = strconv.ParseFloat(value, 64)
+	case AuditFieldBool:
+		typed, err = strconv.ParseBool(value)
+	}
+	if err != nil {
+		return "", fmt.Errorf("invalid %s value for audit field %s: %s", fieldType, name, value)
+	}
+
+	key, _ := json.Marshal(name)
+	encoded, err := json.Marshal(typed)
+	if err != nil {
+		return "", fmt.Errorf("invalid value for audit field %s: %w", name, err)
+	}
+	return string(key) + ":" + string(encoded), nil
+}
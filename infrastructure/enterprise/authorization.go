@@ -6,6 +6,8 @@ import (
 	"github.com/aruncs31s/azf/config"
 
 	"github.com/aruncs31s/azf/constants"
+	"github.com/aruncs31s/azf/infrastructure/metrics"
+	"github.com/aruncs31s/azf/infrastructure/tracing"
 	"github.com/casbin/casbin/v2"
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
@@ -28,8 +30,14 @@ func IniAuthorization(
 		Database:               db,
 		Redis:                  reddis,
 		PolicyFilePath:         config.CASBIN_POLICY_FILE,
+		PolicyStorage:          config.CasbinPolicyStorage(),
+		EnablePolicyWatcher:    config.CasbinPolicyWatcher(),
+		PolicyWatcherChannel:   config.CasbinPolicyWatcherChannel(),
 		Environment:            config.GetEnvironment(),
 		EnableAuditLogging:     config.AUDIT_LOGING,
+		AuditDedupWindow:       config.AuditDedupWindow(),
+		DenialStormThreshold:   config.DenialStormThreshold(),
+		DenialStormWindow:      config.DenialStormWindow(),
 		EnableRateLimit:        config.RATE_LIMITING,
 		EnableDeprecationCheck: config.DEPRICATION_CHECK,
 		GradualRolloutMode:     config.GetEnvironment() == constants.APP_SAGING,
@@ -39,6 +47,13 @@ func IniAuthorization(
 		Logger:                 logger,
 	}
 
+	if tp := tracing.Provider(); tp != nil {
+		setupOpts.TracerProvider = tp
+	}
+	if config.GetMetricsConfig().Enabled {
+		setupOpts.Metrics = metrics.Default()
+	}
+
 	setup, err := NewEnterpriseAuthorizationSetup(setupOpts)
 	if err != nil {
 		log.Fatal("Failed to initialize authorization:", err)
@@ -2,67 +2,185 @@ package enterprise
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/aruncs31s/azf/config"
 	"github.com/aruncs31s/azf/domain/model"
+	"github.com/aruncs31s/azf/domain/repository"
+	"github.com/aruncs31s/azf/internal/persistence"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
+// AuditOutbox stages the webhook events of audit logs. Stage is called with
+// the context of the transaction saving the logs, so the logs and their
+// events are stored together or not at all.
+type AuditOutbox interface {
+	Stage(ctx context.Context, logs []*model.AuthorizationAuditLog) error
+}
+
 // AuthorizationAuditRepository handles persistence of authorization audit logs
 type AuthorizationAuditRepository struct {
-	db     *gorm.DB
-	logger *zap.Logger
+	db           *gorm.DB
+	logger       *zap.Logger
+	codec        *persistence.TextCodec
+	transactions repository.TransactionManager
+
+	mu     sync.RWMutex
+	outbox AuditOutbox
 }
 
 // NewAuthorizationAuditRepository creates a new authorization audit repository
 func NewAuthorizationAuditRepository(db *gorm.DB, logger *zap.Logger) *AuthorizationAuditRepository {
 	return &AuthorizationAuditRepository{
-		db:     db,
-		logger: logger,
+		db:           db,
+		logger:       logger,
+		codec:        persistence.NewTextCodec(db, config.TextEncodingEnabled()),
+		transactions: persistence.NewTransactionManager(db),
 	}
 }
 
-// Save persists an authorization audit log to the database
-func (aar *AuthorizationAuditRepository) Save(ctx context.Context, log *model.AuthorizationAuditLog) error {
-	if log == nil {
-		return fmt.Errorf("authorization audit log cannot be nil")
+// SetOutbox stages webhook events for the logs saved from now on; nil stops it
+func (aar *AuthorizationAuditRepository) SetOutbox(outbox AuditOutbox) {
+	aar.mu.Lock()
+	defer aar.mu.Unlock()
+	aar.outbox = outbox
+}
+
+// saveWithOutbox runs create and stages the events of logs in one
+// transaction, or runs create alone when there is no outbox
+func (aar *AuthorizationAuditRepository) saveWithOutbox(ctx context.Context, logs []*model.AuthorizationAuditLog, create func(tx *gorm.DB) error) error {
+	aar.mu.RLock()
+	outbox := aar.outbox
+	aar.mu.RUnlock()
+	if outbox == nil {
+		return create(aar.db.WithContext(ctx))
 	}
 
-	// Convert domain model to database model
-	dbLog := &AuthorizationAuditLogDB{
+	return aar.transactions.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := create(persistence.Conn(ctx, aar.db)); err != nil {
+			return err
+		}
+		if err := outbox.Stage(ctx, logs); err != nil {
+			return fmt.Errorf("failed to stage webhook events: %w", err)
+		}
+		return nil
+	})
+}
+
+// encodeLog encodes the large text columns of a log before it is stored
+func (aar *AuthorizationAuditRepository) encodeLog(dbLog *AuthorizationAuditLogDB) error {
+	userAgent, err := aar.codec.Encode(dbLog.UserAgent, persistence.TextEncodingDictionary)
+	if err != nil {
+		return err
+	}
+	dbLog.UserAgent = userAgent
+	if dbLog.ErrorMsg != nil {
+		errorMsg, err := aar.codec.Encode(*dbLog.ErrorMsg, persistence.TextEncodingCompressed)
+		if err != nil {
+			return err
+		}
+		dbLog.ErrorMsg = &errorMsg
+	}
+	return nil
+}
+
+// decodeLogs restores encoded text columns of logs read from the database
+func (aar *AuthorizationAuditRepository) decodeLogs(logs []*AuthorizationAuditLogDB) []*AuthorizationAuditLogDB {
+	for _, log := range logs {
+		log.UserAgent = aar.codec.Decode(log.UserAgent)
+		if log.ErrorMsg != nil {
+			errorMsg := aar.codec.Decode(*log.ErrorMsg)
+			log.ErrorMsg = &errorMsg
+		}
+	}
+	return logs
+}
+
+// lastSeenAt returns the last occurrence time of a rolled-up log, or nil for single events
+func lastSeenAt(log *model.AuthorizationAuditLog) *time.Time {
+	if log.OccurrenceCount() <= 1 {
+		return nil
+	}
+	seen := log.LastSeenAt()
+	return &seen
+}
+
+// metadataJSON encodes the custom fields of a log, or returns nil when it has none
+func metadataJSON(log *model.AuthorizationAuditLog) *string {
+	details := log.Details()
+	if len(details) == 0 {
+		return nil
+	}
+	encoded, err := json.Marshal(details)
+	if err != nil {
+		return nil
+	}
+	metadata := string(encoded)
+	return &metadata
+}
+
+// nullableString returns nil for an empty string, so unknown values are
+// stored as NULL rather than as empty text
+func nullableString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+// toAuthorizationAuditLogDB converts a domain audit log to its database model
+func toAuthorizationAuditLogDB(log *model.AuthorizationAuditLog) *AuthorizationAuditLogDB {
+	return &AuthorizationAuditLogDB{
 		ID:              log.ID(),
 		UserID:          log.UserID(),
 		Role:            log.Role(),
 		Resource:        log.Resource(),
 		Action:          log.Action(),
 		Result:          log.Result().Value(),
-		Reason:          "",
+		Reason:          log.DenialReason().Value(),
 		IPAddress:       log.IPAddress(),
 		UserAgent:       log.UserAgent(),
 		Timestamp:       log.Timestamp(),
-		RequestID:       "",
-		ErrorMsg:        "",
+		RequestID:       nullableString(log.RequestID()),
+		ErrorMsg:        nullableString(log.ErrorMessage()),
+		AuthMode:        nullableString(log.AuthorizationMode()),
 		Environment:     log.Environment(),
 		APIVersion:      log.APIVersion(),
 		Deprecated:      log.Deprecated(),
 		RateLimitStatus: log.RateLimitStatus(),
 		PolicyVersion:   log.PolicyVersion(),
 		ExecutionTimeMs: log.ExecutionTimeMs(),
+		OccurrenceCount: log.OccurrenceCount(),
+		LastSeenAt:      lastSeenAt(log),
+		Metadata:        metadataJSON(log),
 	}
+}
 
-	if log.DenialReason() != nil {
-		dbLog.Reason = log.DenialReason().Value()
+// Save persists an authorization audit log to the database
+func (aar *AuthorizationAuditRepository) Save(ctx context.Context, log *model.AuthorizationAuditLog) error {
+	if log == nil {
+		return fmt.Errorf("authorization audit log cannot be nil")
 	}
 
-	result := aar.db.WithContext(ctx).Create(dbLog)
-	if result.Error != nil {
+	dbLog := toAuthorizationAuditLogDB(log)
+	if err := aar.encodeLog(dbLog); err != nil {
+		return fmt.Errorf("failed to encode audit log: %w", err)
+	}
+
+	err := aar.saveWithOutbox(ctx, []*model.AuthorizationAuditLog{log}, func(tx *gorm.DB) error {
+		return tx.Create(dbLog).Error
+	})
+	if err != nil {
 		aar.logger.Error("Failed to save authorization audit log",
-			zap.Error(result.Error),
+			zap.Error(err),
 			zap.String("user_id", log.UserID()),
 			zap.String("resource", log.Resource()))
-		return fmt.Errorf("failed to save audit log: %w", result.Error)
+		return fmt.Errorf("failed to save audit log: %w", err)
 	}
 
 	aar.logger.Debug("Authorization audit log saved",
@@ -81,37 +199,22 @@ func (aar *AuthorizationAuditRepository) SaveBatch(ctx context.Context, logs []*
 
 	dbLogs := make([]*AuthorizationAuditLogDB, len(logs))
 	for i, log := range logs {
-		dbLog := &AuthorizationAuditLogDB{
-			ID:              log.ID(),
-			UserID:          log.UserID(),
-			Role:            log.Role(),
-			Resource:        log.Resource(),
-			Action:          log.Action(),
-			Result:          log.Result().Value(),
-			IPAddress:       log.IPAddress(),
-			UserAgent:       log.UserAgent(),
-			Timestamp:       log.Timestamp(),
-			Environment:     log.Environment(),
-			APIVersion:      log.APIVersion(),
-			Deprecated:      log.Deprecated(),
-			RateLimitStatus: log.RateLimitStatus(),
-			PolicyVersion:   log.PolicyVersion(),
-			ExecutionTimeMs: log.ExecutionTimeMs(),
-		}
-
-		if log.DenialReason() != nil {
-			dbLog.Reason = log.DenialReason().Value()
+		dbLog := toAuthorizationAuditLogDB(log)
+		if err := aar.encodeLog(dbLog); err != nil {
+			return fmt.Errorf("failed to encode audit log: %w", err)
 		}
 
 		dbLogs[i] = dbLog
 	}
 
-	result := aar.db.WithContext(ctx).CreateInBatches(dbLogs, 100)
-	if result.Error != nil {
+	err := aar.saveWithOutbox(ctx, logs, func(tx *gorm.DB) error {
+		return tx.CreateInBatches(dbLogs, 100).Error
+	})
+	if err != nil {
 		aar.logger.Error("Failed to save authorization audit log batch",
-			zap.Error(result.Error),
+			zap.Error(err),
 			zap.Int("count", len(logs)))
-		return fmt.Errorf("failed to save audit log batch: %w", result.Error)
+		return fmt.Errorf("failed to save audit log batch: %w", err)
 	}
 
 	aar.logger.Debug("Authorization audit logs batch saved", zap.Int("count", len(logs)))
@@ -134,9 +237,42 @@ func (aar *AuthorizationAuditRepository) FindAll(ctx context.Context, limit int,
 		return nil, fmt.Errorf("failed to find audit logs: %w", result.Error)
 	}
 
-	return logs, nil
+	return aar.decodeLogs(logs), nil
+}
+
+// FindByField retrieves audit logs whose custom field name equals value.
+// value is parsed as the registered type of the field.
+func (aar *AuthorizationAuditRepository) FindByField(ctx context.Context, name, value string, limit int, offset int) ([]*AuthorizationAuditLogDB, error) {
+	fragment, err := auditFieldFilterFragment(name, value)
+	if err != nil {
+		return nil, err
+	}
+
+	// Metadata is stored as compact JSON, so the field is followed by a comma
+	// or the closing brace; this keeps 5 from matching 50
+	escaped := likeEscaper.Replace(fragment)
+	var logs []*AuthorizationAuditLogDB
+	result := aar.db.WithContext(ctx).
+		Where("metadata LIKE ? ESCAPE '!' OR metadata LIKE ? ESCAPE '!'", "%"+escaped+",%", "%"+escaped+"}").
+		Order("timestamp DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&logs)
+
+	if result.Error != nil {
+		aar.logger.Error("Failed to find audit logs by field",
+			zap.Error(result.Error),
+			zap.String("field", name))
+		return nil, fmt.Errorf("failed to find audit logs: %w", result.Error)
+	}
+
+	return aar.decodeLogs(logs), nil
 }
 
+// likeEscaper escapes LIKE wildcards; '!' is used as the escape character
+// because backslashes are treated differently across databases
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
 // FindByUserID retrieves audit logs for a specific user
 func (aar *AuthorizationAuditRepository) FindByUserID(ctx context.Context, userID string, limit int, offset int) ([]*AuthorizationAuditLogDB, error) {
 	var logs []*AuthorizationAuditLogDB
@@ -155,7 +291,7 @@ func (aar *AuthorizationAuditRepository) FindByUserID(ctx context.Context, userI
 		return nil, fmt.Errorf("failed to find audit logs: %w", result.Error)
 	}
 
-	return logs, nil
+	return aar.decodeLogs(logs), nil
 }
 
 // FindByRole retrieves audit logs for a specific role
@@ -176,7 +312,28 @@ func (aar *AuthorizationAuditRepository) FindByRole(ctx context.Context, role st
 		return nil, fmt.Errorf("failed to find audit logs: %w", result.Error)
 	}
 
-	return logs, nil
+	return aar.decodeLogs(logs), nil
+}
+
+// FindByRequestID retrieves the audit logs written for a request
+func (aar *AuthorizationAuditRepository) FindByRequestID(ctx context.Context, requestID string, limit int, offset int) ([]*AuthorizationAuditLogDB, error) {
+	var logs []*AuthorizationAuditLogDB
+
+	result := aar.db.WithContext(ctx).
+		Where("request_id = ?", requestID).
+		Order("timestamp DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&logs)
+
+	if result.Error != nil {
+		aar.logger.Error("Failed to find audit logs by request ID",
+			zap.Error(result.Error),
+			zap.String("request_id", requestID))
+		return nil, fmt.Errorf("failed to find audit logs: %w", result.Error)
+	}
+
+	return aar.decodeLogs(logs), nil
 }
 
 // FindByResource retrieves audit logs for a specific resource
@@ -197,7 +354,7 @@ func (aar *AuthorizationAuditRepository) FindByResource(ctx context.Context, res
 		return nil, fmt.Errorf("failed to find audit logs: %w", result.Error)
 	}
 
-	return logs, nil
+	return aar.decodeLogs(logs), nil
 }
 
 // FindByResult retrieves audit logs with a specific result (ALLOWED/DENIED)
@@ -223,7 +380,7 @@ func (aar *AuthorizationAuditRepository) FindByResult(ctx context.Context, resul
 		return nil, fmt.Errorf("failed to find audit logs: %w", dbResult.Error)
 	}
 
-	return logs, nil
+	return aar.decodeLogs(logs), nil
 }
 
 // FindDeniedAccess retrieves all denied access attempts
@@ -250,7 +407,196 @@ func (aar *AuthorizationAuditRepository) FindByTimeRange(ctx context.Context, st
 		return nil, fmt.Errorf("failed to find audit logs: %w", result.Error)
 	}
 
-	return logs, nil
+	return aar.decodeLogs(logs), nil
+}
+
+// AuditLogFilter selects audit logs; empty fields and zero times match all.
+// Field and FieldValue select logs whose custom audit field equals the value.
+type AuditLogFilter struct {
+	RequestID  string
+	UserID     string
+	Result     string
+	Resource   string
+	Field      string
+	FieldValue string
+	Start      time.Time
+	End        time.Time
+}
+
+// filterQuery returns a query on the audit log table restricted to filter
+func (aar *AuthorizationAuditRepository) filterQuery(ctx context.Context, filter AuditLogFilter) (*gorm.DB, error) {
+	query := aar.db.WithContext(ctx).Model(&AuthorizationAuditLogDB{})
+	if filter.RequestID != "" {
+		query = query.Where("request_id = ?", filter.RequestID)
+	}
+	if filter.UserID != "" {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Result != "" {
+		query = query.Where("result = ?", filter.Result)
+	}
+	if filter.Resource != "" {
+		query = query.Where("resource = ?", filter.Resource)
+	}
+	if filter.Field != "" {
+		fragment, err := auditFieldFilterFragment(filter.Field, filter.FieldValue)
+		if err != nil {
+			return nil, err
+		}
+		escaped := likeEscaper.Replace(fragment)
+		query = query.Where("metadata LIKE ? ESCAPE '!' OR metadata LIKE ? ESCAPE '!'", "%"+escaped+",%", "%"+escaped+"}")
+	}
+	if !filter.Start.IsZero() {
+		query = query.Where("timestamp >= ?", filter.Start)
+	}
+	if !filter.End.IsZero() {
+		query = query.Where("timestamp <= ?", filter.End)
+	}
+	return query, nil
+}
+
+// FindByFilter retrieves the audit logs matching filter, newest first
+func (aar *AuthorizationAuditRepository) FindByFilter(ctx context.Context, filter AuditLogFilter, limit int, offset int) ([]*AuthorizationAuditLogDB, error) {
+	query, err := aar.filterQuery(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var logs []*AuthorizationAuditLogDB
+	if err := query.Order("timestamp DESC").Limit(limit).Offset(offset).Find(&logs).Error; err != nil {
+		aar.logger.Error("Failed to find audit logs by filter", zap.Error(err))
+		return nil, fmt.Errorf("failed to find audit logs: %w", err)
+	}
+
+	return aar.decodeLogs(logs), nil
+}
+
+// AuditLogSummary aggregates the audit logs matching a filter. Event counts
+// count rolled-up rows once per collapsed event.
+type AuditLogSummary struct {
+	Rows               int64
+	Events             int64
+	Allowed            int64
+	Denied             int64
+	Warning            int64
+	AvgExecutionTimeMs float64
+	DenialReasons      map[string]int64
+	Resources          map[string]int64
+}
+
+// occurrencesSQL is the number of events a row stands for, as Occurrences
+const occurrencesSQL = "CASE WHEN occurrence_count < 1 THEN 1 ELSE occurrence_count END"
+
+// Summarize aggregates the audit logs matching filter in the database
+func (aar *AuthorizationAuditRepository) Summarize(ctx context.Context, filter AuditLogFilter) (*AuditLogSummary, error) {
+	summary := &AuditLogSummary{
+		DenialReasons: make(map[string]int64),
+		Resources:     make(map[string]int64),
+	}
+
+	var byResult []struct {
+		Result        string
+		RowCount      int64
+		Events        int64
+		ExecutionTime float64
+	}
+	query, err := aar.filterQuery(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if err := query.
+		Select("result, COUNT(*) AS row_count, SUM(" + occurrencesSQL + ") AS events, SUM(execution_time_ms) AS execution_time").
+		Group("result").
+		Scan(&byResult).Error; err != nil {
+		aar.logger.Error("Failed to summarize audit logs", zap.Error(err))
+		return nil, fmt.Errorf("failed to summarize audit logs: %w", err)
+	}
+	var totalExecutionTime float64
+	for _, row := range byResult {
+		summary.Rows += row.RowCount
+		summary.Events += row.Events
+		totalExecutionTime += row.ExecutionTime
+		switch row.Result {
+		case "ALLOWED":
+			summary.Allowed += row.Events
+		case "DENIED":
+			summary.Denied += row.Events
+		case "WARNING":
+			summary.Warning += row.Events
+		}
+	}
+	if summary.Rows > 0 {
+		summary.AvgExecutionTimeMs = totalExecutionTime / float64(summary.Rows)
+	}
+
+	groups := []struct {
+		column string
+		denied bool
+		into   map[string]int64
+	}{
+		{"reason", true, summary.DenialReasons},
+		{"resource", false, summary.Resources},
+	}
+	for _, group := range groups {
+		var counts []struct {
+			Value  string
+			Events int64
+		}
+		// The filter was checked by the first query
+		query, _ := aar.filterQuery(ctx, filter)
+		query = query.Where(group.column + " <> ''")
+		if group.denied {
+			query = query.Where("result = ?", "DENIED")
+		}
+		if err := query.
+			Select(group.column + " AS value, SUM(" + occurrencesSQL + ") AS events").
+			Group(group.column).
+			Scan(&counts).Error; err != nil {
+			aar.logger.Error("Failed to summarize audit logs", zap.Error(err), zap.String("group", group.column))
+			return nil, fmt.Errorf("failed to summarize audit logs: %w", err)
+		}
+		for _, count := range counts {
+			group.into[count.Value] = count.Events
+		}
+	}
+
+	return summary, nil
+}
+
+// StreamLogs calls fn with batches of at most batchSize logs matching
+// filter, newest first. Batches are read by keyset pagination on
+// (timestamp, id), so memory stays bounded and deep pages stay fast.
+func (aar *AuthorizationAuditRepository) StreamLogs(ctx context.Context, filter AuditLogFilter, batchSize int, fn func([]*AuthorizationAuditLogDB) error) error {
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+
+	var last *AuthorizationAuditLogDB
+	for {
+		query, err := aar.filterQuery(ctx, filter)
+		if err != nil {
+			return err
+		}
+		if last != nil {
+			query = query.Where("timestamp < ? OR (timestamp = ? AND id < ?)", last.Timestamp, last.Timestamp, last.ID)
+		}
+
+		var logs []*AuthorizationAuditLogDB
+		if err := query.Order("timestamp DESC, id DESC").Limit(batchSize).Find(&logs).Error; err != nil {
+			aar.logger.Error("Failed to stream audit logs", zap.Error(err))
+			return fmt.Errorf("failed to find audit logs: %w", err)
+		}
+		if len(logs) == 0 {
+			return nil
+		}
+		last = logs[len(logs)-1]
+		if err := fn(aar.decodeLogs(logs)); err != nil {
+			return err
+		}
+		if len(logs) < batchSize {
+			return nil
+		}
+	}
 }
 
 // FindByIPAddress retrieves audit logs from a specific IP address
@@ -271,7 +617,7 @@ func (aar *AuthorizationAuditRepository) FindByIPAddress(ctx context.Context, ip
 		return nil, fmt.Errorf("failed to find audit logs: %w", result.Error)
 	}
 
-	return logs, nil
+	return aar.decodeLogs(logs), nil
 }
 
 // FindDeprecatedRouteAccess retrieves access attempts to deprecated routes
@@ -291,7 +637,7 @@ func (aar *AuthorizationAuditRepository) FindDeprecatedRouteAccess(ctx context.C
 		return nil, fmt.Errorf("failed to find audit logs: %w", result.Error)
 	}
 
-	return logs, nil
+	return aar.decodeLogs(logs), nil
 }
 
 // FindRateLimitExceeded retrieves rate limit exceeded events
@@ -311,7 +657,7 @@ func (aar *AuthorizationAuditRepository) FindRateLimitExceeded(ctx context.Conte
 		return nil, fmt.Errorf("failed to find audit logs: %w", result.Error)
 	}
 
-	return logs, nil
+	return aar.decodeLogs(logs), nil
 }
 
 // GetDenialStats returns statistics about denied access attempts
@@ -326,7 +672,7 @@ func (aar *AuthorizationAuditRepository) GetDenialStats(ctx context.Context, use
 
 	result := aar.db.WithContext(ctx).
 		Table("authorization_audit_logs").
-		Select("resource, reason, COUNT(*) as count").
+		Select("resource, reason, SUM(occurrence_count) as count").
 		Where("result = ? AND user_id = ?", "DENIED", userID).
 		Group("resource, reason").
 		Order("count DESC").
@@ -359,7 +705,7 @@ func (aar *AuthorizationAuditRepository) GetResourceAccessStats(ctx context.Cont
 
 	result := aar.db.WithContext(ctx).
 		Table("authorization_audit_logs").
-		Select("role, action, result, COUNT(*) as count").
+		Select("role, action, result, SUM(occurrence_count) as count").
 		Where("resource = ?", resource).
 		Group("role, action, result").
 		Order("count DESC").
@@ -393,7 +739,7 @@ func (aar *AuthorizationAuditRepository) GetRoleAccessStats(ctx context.Context,
 
 	result := aar.db.WithContext(ctx).
 		Table("authorization_audit_logs").
-		Select("resource, action, result, COUNT(*) as count").
+		Select("resource, action, result, SUM(occurrence_count) as count").
 		Where("role = ?", role).
 		Group("resource, action, result").
 		Order("count DESC").
@@ -427,7 +773,7 @@ func (aar *AuthorizationAuditRepository) GetIPAddressStats(ctx context.Context,
 
 	result := aar.db.WithContext(ctx).
 		Table("authorization_audit_logs").
-		Select("user_id, role, result, COUNT(*) as count").
+		Select("user_id, role, result, SUM(occurrence_count) as count").
 		Where("ip_address = ?", ipAddress).
 		Group("user_id, role, result").
 		Order("count DESC").
@@ -488,24 +834,40 @@ func (aar *AuthorizationAuditRepository) Count(ctx context.Context) (int64, erro
 
 // AuthorizationAuditLogDB is the database model for authorization audit logs
 type AuthorizationAuditLogDB struct {
-	ID              string    `gorm:"primaryKey;type:varchar(36)" json:"id"`
-	UserID          string    `gorm:"index;type:varchar(36)" json:"user_id"`
-	Role            string    `gorm:"index;type:varchar(50)" json:"role"`
-	Resource        string    `gorm:"index;type:varchar(500)" json:"resource"`
-	Action          string    `gorm:"type:varchar(20)" json:"action"`
-	Result          string    `gorm:"index;type:varchar(20)" json:"result"`
-	Reason          string    `gorm:"type:text" json:"reason"`
-	IPAddress       string    `gorm:"index;type:varchar(50)" json:"ip_address"`
-	UserAgent       string    `gorm:"type:text" json:"user_agent"`
-	Timestamp       time.Time `gorm:"index;type:timestamp" json:"timestamp"`
-	RequestID       string    `gorm:"type:varchar(36)" json:"request_id"`
-	ErrorMsg        string    `gorm:"type:text" json:"error_msg"`
-	Environment     string    `gorm:"type:varchar(20)" json:"environment"`
-	APIVersion      string    `gorm:"type:varchar(20)" json:"api_version"`
-	Deprecated      bool      `gorm:"type:boolean" json:"deprecated"`
-	RateLimitStatus string    `gorm:"type:varchar(20)" json:"rate_limit_status"`
-	PolicyVersion   int       `gorm:"type:int" json:"policy_version"`
-	ExecutionTimeMs float64   `gorm:"type:float" json:"execution_time_ms"`
+	ID        string    `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	UserID    string    `gorm:"index;type:varchar(36)" json:"user_id"`
+	Role      string    `gorm:"index;type:varchar(50)" json:"role"`
+	Resource  string    `gorm:"index;type:varchar(500)" json:"resource"`
+	Action    string    `gorm:"type:varchar(20)" json:"action"`
+	Result    string    `gorm:"index;type:varchar(20)" json:"result"`
+	Reason    string    `gorm:"type:text" json:"reason"`
+	IPAddress string    `gorm:"index;type:varchar(50)" json:"ip_address"`
+	UserAgent string    `gorm:"type:text" json:"user_agent"`
+	Timestamp time.Time `gorm:"index;type:timestamp" json:"timestamp"`
+	// RequestID and ErrorMsg are NULL for rows written before they were recorded
+	RequestID *string `gorm:"index;type:varchar(64)" json:"request_id,omitempty"`
+	ErrorMsg  *string `gorm:"type:text" json:"error_msg,omitempty"`
+	// AuthMode is how the decision was enforced; WARNING rows were allowed by
+	// GRADUAL_ROLLOUT or SOFT_MIGRATION despite failing the policy check
+	AuthMode        *string    `gorm:"column:authorization_mode;type:varchar(30)" json:"authorization_mode,omitempty"`
+	Environment     string     `gorm:"type:varchar(20)" json:"environment"`
+	APIVersion      string     `gorm:"type:varchar(20)" json:"api_version"`
+	Deprecated      bool       `gorm:"type:boolean" json:"deprecated"`
+	RateLimitStatus string     `gorm:"type:varchar(20)" json:"rate_limit_status"`
+	PolicyVersion   int        `gorm:"type:int" json:"policy_version"`
+	ExecutionTimeMs float64    `gorm:"type:float" json:"execution_time_ms"`
+	OccurrenceCount int        `gorm:"type:int;not null;default:1" json:"occurrence_count"`
+	LastSeenAt      *time.Time `gorm:"type:timestamp" json:"last_seen_at,omitempty"`
+	// Metadata holds the custom audit fields as a JSON object
+	Metadata *string `gorm:"type:text" json:"metadata,omitempty"`
+}
+
+// Occurrences returns the number of identical events this row stands for
+func (l *AuthorizationAuditLogDB) Occurrences() int64 {
+	if l.OccurrenceCount < 1 {
+		return 1
+	}
+	return int64(l.OccurrenceCount)
 }
 
 // TableName specifies the table name
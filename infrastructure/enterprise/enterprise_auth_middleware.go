@@ -3,22 +3,31 @@ package enterprise
 import (
 	"context"
 	"fmt"
+	"math"
+	"net/http"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
-	"github.com/aruncs31s/azf/application/dto"
 	"github.com/aruncs31s/azf/config"
+	"github.com/aruncs31s/azf/infrastructure/abac"
+	"github.com/aruncs31s/azf/infrastructure/notification"
+	"github.com/aruncs31s/azf/infrastructure/tracing"
+	"github.com/aruncs31s/azf/initializer"
 	"github.com/aruncs31s/azf/shared/logger"
+	"github.com/aruncs31s/azf/shared/response"
 	"github.com/aruncs31s/azf/utils"
 
 	"github.com/aruncs31s/azf/application/middleware"
+	"github.com/aruncs31s/azf/domain/api_usage"
 	"github.com/aruncs31s/azf/domain/model"
+	"github.com/aruncs31s/azf/shared/clock"
 	helperImpl "github.com/aruncs31s/azf/shared/helper"
+	"github.com/aruncs31s/azf/shared/ids"
 	"github.com/aruncs31s/azf/shared/interface/helper"
 	"github.com/casbin/casbin/v2"
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
@@ -37,19 +46,60 @@ type AZFAuthMiddlewareConfig struct {
 	EnableDeprecationCheck bool
 	GradualRolloutMode     bool // If true, denies access but logs as WARNING instead of DENIED
 	AllowMissingPolicies   bool // If true, missing policies are allowed (soft migration)
+	// AuditDedupWindow collapses identical non-allowed events seen within the
+	// window into one audit row with an occurrence count. Zero disables it.
+	AuditDedupWindow time.Duration
+	// DenialStormThreshold raises a denial storm alert when this many
+	// non-allowed events are logged within DenialStormWindow. Zero disables it.
+	DenialStormThreshold int
+	DenialStormWindow    time.Duration
+	// FeatureFlags switches subsystems at runtime. Without it the Enable*
+	// settings above apply for the lifetime of the middleware.
+	FeatureFlags FeatureFlagProvider
+	// AttributeExtractor supplies the attributes route conditions are
+	// evaluated against when the enforcer uses the ABAC model. Defaults to
+	// NewDefaultAttributeExtractor.
+	AttributeExtractor AttributeExtractor
+	// Metrics records decisions and latencies for the metrics endpoint (optional)
+	Metrics *AuthorizationMetrics
+	// Quotas enforces daily and monthly usage quotas (optional)
+	Quotas QuotaChecker
+	// Clock timestamps audit logs and alerts. Defaults to clock.Default().
+	Clock clock.Clock
+	// IDGenerator creates the IDs of audit logs and alerts. Defaults to
+	// ids.Default().
+	IDGenerator ids.Generator
 }
 
+// FeatureFlagProvider reports whether a subsystem is switched on right now
+type FeatureFlagProvider interface {
+	// IsEnabled returns the flag's current value, or fallback if it was never switched
+	IsEnabled(flag string, fallback bool) bool
+}
+
+// maxAuditRollups bounds the number of open deduplication rollups; further
+// distinct events are logged individually until rollups are flushed
+const maxAuditRollups = 10000
+
 // AZFAuthMiddleware provides comprehensive authorization with audit trail
 type AZFAuthMiddleware struct {
 	config                *AZFAuthMiddlewareConfig
 	responseHelper        helper.ResponseHelper
 	requestHelper         helper.RequestHelper
 	auditBatch            []*model.AuthorizationAuditLog
+	auditRollups          map[string]*model.AuthorizationAuditLog
 	batchSize             int
 	batchFlushInterval    time.Duration
 	stopBatchProcessor    chan bool
 	batchProcessorRunning bool
 	auditMutex            sync.Mutex
+	hooks                 *HookRegistry
+
+	// Alert state, guarded by auditMutex
+	auditPipelineFailing bool
+	denialWindowStart    time.Time
+	denialCount          int
+	denialStorm          bool
 }
 
 // NewEnterpriseAuthMiddleware creates a new enterprise auth middleware
@@ -60,19 +110,28 @@ func NewEnterpriseAuthMiddleware(
 	if config.Logger == nil {
 		config.Logger = logger.GetLogger()
 	}
+	if config.AttributeExtractor == nil {
+		config.AttributeExtractor = NewDefaultAttributeExtractor()
+	}
+	config.Clock = clock.Or(config.Clock)
+	config.IDGenerator = ids.Or(config.IDGenerator)
+	abac.Register(config.CasbinEnforcer)
 
 	middleware := &AZFAuthMiddleware{
 		config:             config,
 		responseHelper:     responseHelper,
 		requestHelper:      requestHelper,
 		auditBatch:         make([]*model.AuthorizationAuditLog, 0),
+		auditRollups:       make(map[string]*model.AuthorizationAuditLog),
 		batchSize:          100,
 		batchFlushInterval: 10 * time.Second,
 		stopBatchProcessor: make(chan bool),
+		hooks:              NewHookRegistry(config.Logger),
 	}
 
-	// Start batch processor if audit logging is enabled
-	if config.EnableAuditLogging && config.AuditRepository != nil {
+	// Start the batch processor whenever audits can be stored, since audit
+	// logging may be switched on at runtime
+	if config.AuditRepository != nil {
 		middleware.startBatchProcessor()
 	}
 
@@ -84,13 +143,49 @@ func NewEnterpriseAuthMiddleware(
 // but for some reason if this is not available we  fallback to casbin middleware
 func (eam *AZFAuthMiddleware) GinMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// Preflight requests carry no credentials; they are answered by the
+		// CORS middleware and neither audited nor measured
+		if middleware.IsPreflightRequest(c.Request) {
+			c.Next()
+			return
+		}
+
+		startAuthorizeSpan(c)
+		start := time.Now()
 		eam.authorizeRequest(c)
+		endAuthorizeSpan(c)
+		handlerTime, handled := c.Get(handlerDurationKey)
+		overhead := time.Since(start)
+		if handled {
+			overhead -= handlerTime.(time.Duration)
+		}
+		eam.config.Metrics.observeOverhead(overhead, !handled)
 	}
 }
 
+// handlerDurationKey holds how long the handlers after the middleware took,
+// so that time is excluded from the middleware overhead
+const handlerDurationKey = "azf_handler_duration"
+
+// proceed hands the request to the next handlers, timing them
+func (eam *AZFAuthMiddleware) proceed(c *gin.Context) {
+	endAuthorizeSpan(c)
+	start := time.Now()
+	c.Next()
+	c.Set(handlerDurationKey, time.Since(start))
+}
+
 // authorizeRequest handles the authorization logic
 func (eam *AZFAuthMiddleware) authorizeRequest(c *gin.Context) {
-	requestID := uuid.New().String()
+	// Reuse the ID assigned by RequestIDMiddleware so audit entries can be
+	// matched with the X-Request-ID the client saw, or resolve it the same
+	// way when that middleware is not installed
+	requestID := middleware.GetRequestID(c)
+	if requestID == "" || len(requestID) > model.MaxAuditRequestIDLength {
+		requestID = middleware.ResolveRequestID(c.Request)
+		c.Set("request_id", requestID)
+		c.Writer.Header().Set("X-Request-ID", requestID)
+	}
 	startTime := time.Now()
 
 	// Get route information
@@ -103,6 +198,20 @@ func (eam *AZFAuthMiddleware) authorizeRequest(c *gin.Context) {
 	method := c.Request.Method
 	// Check if route is in registry
 	routeMetadata, routeExists := eam.config.RouteRegistry.Get(path, method)
+	if routeExists {
+		middleware.SetAPIVersion(c, routeMetadata.APIVersion)
+	}
+
+	// Enforce the per-route body size limit before anything else, public routes included
+	if routeExists && routeMetadata.MaxBodyBytes > 0 && c.Request.Body != nil {
+		if c.Request.ContentLength > routeMetadata.MaxBodyBytes {
+			eam.handleRequestTooLarge(c, routeMetadata, requestID, startTime)
+			return
+		}
+		// Chunked bodies have no declared length; cap them as they are read
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, routeMetadata.MaxBodyBytes)
+	}
+
 	// 1. Check if route is public - if so, allow access without authentication
 	if routeExists && routeMetadata.IsPublic {
 		eam.config.Logger.Debug(
@@ -110,17 +219,38 @@ func (eam *AZFAuthMiddleware) authorizeRequest(c *gin.Context) {
 			zap.String("path", path),
 			zap.String("method", method),
 		)
-		c.Set("meta", eam.buildResponseMeta("PUBLIC"))
-		c.Next()
+		middleware.SetAuthorizationMode(c, "PUBLIC")
+		eam.config.Metrics.recordDecision(routeMetadata, method, "", true, "PUBLIC")
+		traceDecision(c, routeMetadata, "", true, "PUBLIC")
+		eam.proceed(c)
 		return
 	}
 
 	// Get user context
 	userRole, userID, ipAddress := eam.extractUserContext(c)
 	if userRole == "" {
+		var route *RouteMetadata
+		if routeExists {
+			route = routeMetadata
+		}
+		eam.config.Metrics.recordDecision(route, method, "", false, config.AUTH_MODE_CASBIN)
+		traceDecision(c, route, "", false, config.AUTH_MODE_CASBIN)
 		eam.handleUnauthorized(c, "User role not found", requestID)
 		return
 	}
+	hc := &HookContext{
+		Gin:       c,
+		RequestID: requestID,
+		UserID:    userID,
+		Role:      userRole,
+		Resource:  path,
+		Action:    method,
+		IPAddress: ipAddress,
+	}
+	if routeExists {
+		hc.Route = routeMetadata
+	}
+
 	// 2. Check for deprecation
 	if routeExists && routeMetadata.Deprecated {
 		eam.config.Logger.Warn(
@@ -139,12 +269,32 @@ func (eam *AZFAuthMiddleware) authorizeRequest(c *gin.Context) {
 		}
 	}
 
-	// 3. Check rate limiting
-	if eam.config.EnableRateLimit && routeExists && routeMetadata.RateLimit != nil {
-		rateLimitStatus, err := eam.config.RateLimiter.CheckLimit(c.Request.Context(), userID, userRole)
+	// 2b. API keys may only call routes whose scopes they were granted
+	apiKey := middleware.GetAPIKey(c)
+	if apiKey != nil && routeExists && !apiKey.HasScopes(routeMetadata.RequiredScopes) {
+		eam.handleInsufficientScope(c, hc, startTime)
+		return
+	}
+
+	// 3. Check rate limiting. Requests with an API key count against the
+	// key rather than its user.
+	rateLimitAuditStatus := model.RateLimitStatusOK
+	rateLimitIdentifier, keyLimit := userID, 0
+	if apiKey != nil {
+		rateLimitIdentifier, keyLimit = "api_key:"+apiKey.ID, apiKey.RateLimit
+	}
+	if eam.rateLimitEnabled() && routeExists && (routeMetadata.RateLimit != nil || keyLimit > 0) {
+		rateLimitStatus, err := eam.config.RateLimiter.CheckRequest(c.Request.Context(), RateLimitRequest{
+			Identifier: rateLimitIdentifier,
+			Role:       userRole,
+			Endpoint:   routeMetadata.Method + ":" + routeMetadata.Path,
+			Route:      routeMetadata.RateLimit,
+			KeyLimit:   keyLimit,
+		})
 		if err != nil {
 			eam.config.Logger.Error("Rate limit check failed", zap.Error(err))
 		}
+		rateLimitAuditStatus = rateLimitStatus.AuditStatus()
 
 		if rateLimitStatus != nil && rateLimitStatus.LimitExceeded {
 			eam.config.Logger.Warn(
@@ -154,15 +304,22 @@ func (eam *AZFAuthMiddleware) authorizeRequest(c *gin.Context) {
 				zap.String("path", path),
 				zap.Int("retry_after", rateLimitStatus.RetryAfterSeconds),
 			)
+			eam.config.Metrics.recordRateLimited(routeMetadata, method, userRole)
 
 			// Log audit
-			if eam.config.EnableAuditLogging {
+			if eam.auditLoggingEnabled() {
+				detail := fmt.Sprintf("rate limit exceeded, retry after %d seconds", rateLimitStatus.RetryAfterSeconds)
+				if !rateLimitStatus.BlockedUntil.IsZero() {
+					detail = fmt.Sprintf("temporarily blocked, retry after %d seconds", rateLimitStatus.RetryAfterSeconds)
+				}
 				eam.logAuthorizationAudit(
 					requestID, userID, userRole, path, method,
 					model.AuthzDenied, model.ReasonRateLimitExceeded,
+					detail,
 					ipAddress, c.Request.UserAgent(),
 					time.Since(startTime).Milliseconds(),
-					true, // rate limit exceeded
+					routeMetadata, rateLimitAuditStatus, config.AUTH_MODE_CASBIN,
+					eam.auditDetails(c, hc),
 				)
 			}
 
@@ -170,6 +327,7 @@ func (eam *AZFAuthMiddleware) authorizeRequest(c *gin.Context) {
 			c.Header("X-Rate-Limit-Remaining", fmt.Sprintf("%d", rateLimitStatus.RemainingRequests))
 			c.Header("X-Rate-Limit-Reset", fmt.Sprintf("%d", rateLimitStatus.ResetAtTime.Unix()))
 
+			eam.hooks.runPreResponse(hc, false)
 			eam.responseHelper.BadRequest(c, "Rate limit exceeded", "")
 			c.Abort()
 			return
@@ -182,45 +340,112 @@ func (eam *AZFAuthMiddleware) authorizeRequest(c *gin.Context) {
 		}
 	}
 
-	// 4. Check authorization via Casbin
-	eam.config.Logger.Debug("About to check permission",
-		zap.String("user_id", userID),
-		zap.String("role", userRole),
-		zap.String("path", path),
-		zap.String("method", method),
-	)
-	allowed := eam.checkPermission(userRole, path, method)
+	// 3b. Check daily and monthly usage quotas
+	if !eam.checkQuota(c, hc, startTime) {
+		return
+	}
 
-	// 5. Log audit
-	if eam.config.EnableAuditLogging {
-		if allowed {
-			eam.logAuthorizationAudit(
-				requestID, userID, userRole, path, method,
-				model.AuthzAllowed, nil,
-				ipAddress, c.Request.UserAgent(),
-				time.Since(startTime).Milliseconds(),
-				false,
-			)
-		} else {
-			reason := model.ReasonPolicyNotFound
-			if routeExists {
-				reason = model.ReasonRoleNotFound
-			}
+	// 4. Run custom rules, then check authorization via Casbin
+	customDenial := eam.hooks.runPreAuthorization(hc)
+	policyAllowed := false
+	if customDenial == nil {
+		eam.config.Logger.Debug("About to check permission",
+			zap.String("user_id", userID),
+			zap.String("role", userRole),
+			zap.String("path", path),
+			zap.String("method", method),
+		)
+		policyAllowed = eam.checkPermission(hc)
+	}
+	allowed := eam.hooks.runPostDecision(hc, policyAllowed)
+
+	var reason *model.DenialReason
+	denialMessage := ""
+	switch {
+	case allowed:
+	case customDenial != nil:
+		reason = model.ReasonCustomRule
+		denialMessage = customDenial.Error()
+	case policyAllowed:
+		reason = model.ReasonCustomRule
+		denialMessage = "denied by post-decision hook"
+	case routeExists && routeMetadata.Condition != "" && abac.IsABACModel(eam.config.CasbinEnforcer):
+		reason = model.ReasonRoleNotFound
+		denialMessage = fmt.Sprintf("role %s is not permitted to %s %s or condition %q is not met", userRole, method, path, routeMetadata.Condition)
+	case routeExists:
+		reason = model.ReasonRoleNotFound
+		denialMessage = fmt.Sprintf("role %s is not permitted to %s %s", userRole, method, path)
+	default:
+		reason = model.ReasonPolicyNotFound
+		denialMessage = fmt.Sprintf("no policy found for %s %s", method, path)
+	}
+
+	// Denials are let through in gradual rollout and soft migration modes,
+	// except those from custom rules
+	authMode := config.AUTH_MODE_CASBIN
+	if !allowed && reason != model.ReasonCustomRule {
+		if eam.config.GradualRolloutMode {
+			authMode = config.AUTH_MODE_GRADUAL_ROLLOUT
+		} else if eam.config.AllowMissingPolicies && !routeExists {
+			authMode = config.AUTH_MODE_SOFT_MIGRATION
+		}
+	}
 
+	eam.config.Metrics.recordDecision(hc.Route, method, userRole, allowed, authMode)
+	traceDecision(c, hc.Route, userRole, allowed, authMode)
+
+	// 5. Log audit once the request completes, so handlers can add custom fields
+	if eam.auditLoggingEnabled() {
+		result := model.AuthzAllowed
+		if !allowed {
+			result = model.AuthzDenied
+			// Requests that failed the policy check but were let through are warnings
+			if authMode != config.AUTH_MODE_CASBIN {
+				result = model.AuthzWarning
+			}
+		}
+		executionTimeMs := time.Since(startTime).Milliseconds()
+		var auditRoute *RouteMetadata
+		if routeExists {
+			auditRoute = routeMetadata
+		}
+		defer func() {
 			eam.logAuthorizationAudit(
 				requestID, userID, userRole, path, method,
-				model.AuthzDenied, reason,
+				result, reason, denialMessage,
 				ipAddress, c.Request.UserAgent(),
-				time.Since(startTime).Milliseconds(),
-				false,
+				executionTimeMs,
+				auditRoute, rateLimitAuditStatus, authMode,
+				eam.auditDetails(c, hc),
 			)
-		}
+		}()
 	}
 
 	// 6. Handle authorization result
 	if !allowed {
+		// Custom rules deny even in gradual rollout and soft migration modes
+		if reason == model.ReasonCustomRule {
+			message := "Access denied"
+			if customDenial != nil {
+				message = customDenial.Error()
+			}
+			eam.config.Logger.Warn(
+				"Access denied by custom rule",
+				zap.String("user_id", userID),
+				zap.String("role", userRole),
+				zap.String("path", path),
+				zap.String("method", method),
+				zap.String("message", message),
+			)
+			middleware.SetAuthorizationMode(c, config.AUTH_MODE_CASBIN)
+			eam.hooks.runPreResponse(hc, false)
+			eam.responseHelper.Forbidden(c, message)
+			c.Abort()
+			return
+		}
+
 		// Check if we're in gradual rollout mode
-		if eam.config.GradualRolloutMode {
+		if authMode == config.AUTH_MODE_GRADUAL_ROLLOUT {
 			eam.config.Logger.Warn(
 				"Access denied (gradual rollout mode - allowing)",
 				zap.String("user_id", userID),
@@ -228,13 +453,14 @@ func (eam *AZFAuthMiddleware) authorizeRequest(c *gin.Context) {
 				zap.String("path", path),
 			)
 			c.Header("X-Authorization-Mode", "GRADUAL_ROLLOUT")
-			c.Set("meta", eam.buildResponseMeta(config.AUTH_MODE_GRADUAL_ROLLOUT))
-			c.Next()
+			middleware.SetAuthorizationMode(c, config.AUTH_MODE_GRADUAL_ROLLOUT)
+			eam.hooks.runPreResponse(hc, true)
+			eam.proceed(c)
 			return
 		}
 
 		// Check if missing policies are allowed
-		if eam.config.AllowMissingPolicies && !routeExists {
+		if authMode == config.AUTH_MODE_SOFT_MIGRATION {
 			eam.config.Logger.Debug(
 				"Route not in registry, allowing (soft migration mode)",
 				zap.String("user_id", userID),
@@ -242,8 +468,9 @@ func (eam *AZFAuthMiddleware) authorizeRequest(c *gin.Context) {
 				zap.String("path", path),
 			)
 			c.Header("X-Authorization-Mode", config.AUTH_MODE_SOFT_MIGRATION)
-			c.Set("meta", eam.buildResponseMeta(config.AUTH_MODE_SOFT_MIGRATION))
-			c.Next()
+			middleware.SetAuthorizationMode(c, config.AUTH_MODE_SOFT_MIGRATION)
+			eam.hooks.runPreResponse(hc, true)
+			eam.proceed(c)
 			return
 		}
 
@@ -255,7 +482,8 @@ func (eam *AZFAuthMiddleware) authorizeRequest(c *gin.Context) {
 			zap.String("method", method),
 		)
 
-		c.Set("meta", eam.buildResponseMeta(config.AUTH_MODE_CASBIN))
+		middleware.SetAuthorizationMode(c, config.AUTH_MODE_CASBIN)
+		eam.hooks.runPreResponse(hc, false)
 		eam.responseHelper.Forbidden(c, "Access denied")
 		c.Abort()
 		return
@@ -273,16 +501,9 @@ func (eam *AZFAuthMiddleware) authorizeRequest(c *gin.Context) {
 		zap.String("method", method),
 	)
 
-	c.Set("meta", eam.buildResponseMeta(config.AUTH_MODE_CASBIN))
-	c.Next()
-}
-func (eam *AZFAuthMiddleware) buildResponseMeta(mode string) dto.ResponseMeta {
-	return dto.ResponseMeta{
-		APIVersion:        os.Getenv("API_VERSION"),
-		AuthorizationMode: mode,
-		ResponseTimeMs:    "DEV: Will Implement Later",
-		RequestID:         uuid.NewString(),
-	}
+	middleware.SetAuthorizationMode(c, config.AUTH_MODE_CASBIN)
+	eam.hooks.runPreResponse(hc, true)
+	eam.proceed(c)
 }
 
 // extractUserContext extracts  user information from request
@@ -299,8 +520,10 @@ func (eam *AZFAuthMiddleware) extractUserContext(c *gin.Context) (role, userID,
 	return
 }
 
-// checkPermission checks if user has permission using Casbin
-func (eam *AZFAuthMiddleware) checkPermission(role, resource, action string) bool {
+// checkPermission checks if user has permission using Casbin. With the ABAC
+// model the route condition must also hold for the request attributes.
+func (eam *AZFAuthMiddleware) checkPermission(hc *HookContext) bool {
+	role, resource, action := hc.Role, hc.Resource, hc.Action
 	// Normalize path to align with policy patterns (e.g., convert numeric IDs to :id)
 	normalized := utils.NormalizePathForLookup(resource)
 
@@ -328,7 +551,32 @@ func (eam *AZFAuthMiddleware) checkPermission(role, resource, action string) boo
 	// 	return false
 	// }
 
-	allowed, err := enforcer.Enforce(role, normalized, action)
+	condition := ""
+	var attrs *abac.Attributes
+	if abac.IsABACModel(enforcer) {
+		if hc.Route != nil {
+			condition = hc.Route.Condition
+		}
+		attrs = eam.config.AttributeExtractor.ExtractAttributes(hc)
+	}
+
+	spanCtx := context.Background()
+	if hc.Gin != nil {
+		spanCtx = hc.Gin.Request.Context()
+	}
+	_, span := tracing.Start(spanCtx, "casbin.enforce",
+		tracing.String("azf.role", role),
+		tracing.String("azf.resource", normalized),
+		tracing.String("azf.action", action),
+	)
+	enforceStart := time.Now()
+	allowed, err := initializer.Enforce(enforcer, abac.RequestValues(enforcer, role, normalized, action, condition, attrs)...)
+	eam.config.Metrics.observeEnforce(time.Since(enforceStart))
+	span.SetAttributes(tracing.Bool("azf.allowed", allowed))
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
 	if err != nil {
 		eam.config.Logger.Error("Casbin enforce error", zap.Error(err),
 			zap.String("role", role),
@@ -353,13 +601,30 @@ func (eam *AZFAuthMiddleware) logAuthorizationAudit(
 	requestID, userID, role, resource, action string,
 	result *model.AuthorizationResult,
 	denialReason *model.DenialReason,
+	errorMessage string,
 	ipAddress, userAgent string,
 	executionTimeMs int64,
-	rateLimitExceeded bool,
+	routeMetadata *RouteMetadata,
+	rateLimitStatus string,
+	authMode string,
+	details map[string]interface{},
 ) {
+	// Unregistered routes fall back to the version the API reports in responses
+	apiVersion := os.Getenv("API_VERSION")
+	deprecated := false
+	if routeMetadata != nil {
+		if routeMetadata.APIVersion != "" {
+			apiVersion = routeMetadata.APIVersion
+		}
+		deprecated = routeMetadata.Deprecated
+	}
+	if rateLimitStatus == "" {
+		rateLimitStatus = model.RateLimitStatusOK
+	}
+
 	auditLog, err := model.NewAuthorizationAuditLog(
-		uuid.New().String(),
-		time.Now(),
+		eam.config.IDGenerator.NewID(),
+		eam.config.Clock.Now(),
 		userID,
 		role,
 		resource,
@@ -368,25 +633,41 @@ func (eam *AZFAuthMiddleware) logAuthorizationAudit(
 		denialReason,
 		ipAddress,
 		userAgent,
-		"v1",  // API version
-		false, // deprecated
+		apiVersion,
+		deprecated,
 		eam.config.Environment,
-		"OK", // rate limit status
+		rateLimitStatus,
 		eam.config.PolicyVersion,
 		float64(executionTimeMs),
-		make(map[string]interface{}),
+		details,
 	)
 
 	if err != nil {
 		eam.config.Logger.Error("Failed to create authorization audit log", zap.Error(err))
 		return
 	}
+	if err := auditLog.SetRequestID(requestID); err != nil {
+		eam.config.Logger.Warn("Audit log stored without request ID", zap.Error(err))
+	}
+	auditLog.SetErrorMessage(errorMessage)
+	auditLog.SetAuthorizationMode(authMode)
+	detectStorm := !result.IsAllowed() && eam.anomalyDetectionEnabled()
+
 	// Thread-safe append to batch
 	eam.auditMutex.Lock()
-	eam.auditBatch = append(eam.auditBatch, auditLog)
+	if !eam.rollupAuditLog(auditLog) {
+		eam.auditBatch = append(eam.auditBatch, auditLog)
+	}
 	shouldFlush := len(eam.auditBatch) >= eam.batchSize
+	stormCount := 0
+	if detectStorm {
+		stormCount = eam.countDenial(auditLog.Timestamp())
+	}
 	eam.auditMutex.Unlock()
 
+	if stormCount > 0 {
+		eam.raiseDenialStormAlert(stormCount)
+	}
 	if shouldFlush {
 		// Run flush asynchronously to avoid blocking request path
 		go eam.flushAuditBatch()
@@ -394,23 +675,251 @@ func (eam *AZFAuthMiddleware) logAuthorizationAudit(
 
 }
 
+// auditDetails returns the custom fields for a request's audit entry, from
+// audit enrichment hooks when hc is set and from SetAuditField
+func (eam *AZFAuthMiddleware) auditDetails(c *gin.Context, hc *HookContext) map[string]interface{} {
+	var hookFields map[string]interface{}
+	if hc != nil {
+		hookFields = eam.hooks.auditFields(hc)
+	}
+	return collectAuditFields(c, hookFields, eam.config.Logger)
+}
+
+// AuditDenied records a request that was denied outside the policy check,
+// such as an admin write blocked by read-only mode
+func (eam *AZFAuthMiddleware) AuditDenied(
+	requestID, userID, role, resource, action string,
+	reason *model.DenialReason,
+	errorMessage string,
+	ipAddress, userAgent string,
+) {
+	if !eam.auditLoggingEnabled() || eam.config.AuditRepository == nil {
+		return
+	}
+	eam.logAuthorizationAudit(
+		requestID, userID, role, resource, action,
+		model.AuthzDenied, reason, errorMessage,
+		ipAddress, userAgent,
+		0,
+		nil, model.RateLimitStatusOK, config.AUTH_MODE_CASBIN,
+		nil,
+	)
+}
+
+// rollupAuditLog collapses a repeated non-allowed event into the open rollup
+// for identical events. It returns true if the log was absorbed or opened a
+// new rollup, and false if it should be batched as is. Callers hold auditMutex.
+func (eam *AZFAuthMiddleware) rollupAuditLog(auditLog *model.AuthorizationAuditLog) bool {
+	window := eam.config.AuditDedupWindow
+	if window <= 0 || auditLog.Result().IsAllowed() {
+		return false
+	}
+
+	key := auditLog.DedupKey()
+	if open, ok := eam.auditRollups[key]; ok {
+		if auditLog.Timestamp().Sub(open.Timestamp()) < window {
+			open.RecordOccurrence(auditLog.Timestamp())
+			return true
+		}
+		// The window has passed; close the previous rollup and start a new one
+		eam.auditBatch = append(eam.auditBatch, open)
+		delete(eam.auditRollups, key)
+	}
+
+	if len(eam.auditRollups) >= maxAuditRollups {
+		return false
+	}
+	eam.auditRollups[key] = auditLog
+	return true
+}
+
+// closeAuditRollups moves rollups whose window has passed into the batch, or
+// all of them if all is true. Callers hold auditMutex.
+func (eam *AZFAuthMiddleware) closeAuditRollups(now time.Time, all bool) {
+	for key, open := range eam.auditRollups {
+		if all || now.Sub(open.Timestamp()) >= eam.config.AuditDedupWindow {
+			eam.auditBatch = append(eam.auditBatch, open)
+			delete(eam.auditRollups, key)
+		}
+	}
+}
+
 // flushAuditBatch saves batched audit logs to database
 func (eam *AZFAuthMiddleware) flushAuditBatch() {
-	if len(eam.auditBatch) == 0 {
+	eam.flushAudit(false)
+}
+
+// flushAudit saves batched audit logs and closed rollups to the database.
+// Logs that fail to save are kept for the next flush.
+func (eam *AZFAuthMiddleware) flushAudit(closeAllRollups bool) {
+	eam.auditMutex.Lock()
+	eam.closeAuditRollups(eam.config.Clock.Now(), closeAllRollups)
+	batch := eam.auditBatch
+	eam.auditBatch = make([]*model.AuthorizationAuditLog, 0)
+	eam.auditMutex.Unlock()
+
+	if len(batch) == 0 {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, span := tracing.Start(context.Background(), "azf.audit.flush", tracing.Int("azf.audit.batch_size", len(batch)))
+	defer span.End()
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	err := eam.config.AuditRepository.SaveBatch(ctx, eam.auditBatch)
+	err := eam.config.AuditRepository.SaveBatch(ctx, batch)
 	if err != nil {
-		eam.config.Logger.Error("Failed to flush audit batch", zap.Error(err), zap.Int("count", len(eam.auditBatch)))
+		span.RecordError(err)
+		eam.config.Logger.Error("Failed to flush audit batch", zap.Error(err), zap.Int("count", len(batch)))
+		eam.auditMutex.Lock()
+		eam.auditBatch = append(batch, eam.auditBatch...)
+		pending := len(eam.auditBatch)
+		eam.auditPipelineFailing = true
+		eam.auditMutex.Unlock()
+		eam.raiseAuditPipelineAlert(err, pending)
 		return
 	}
 
-	eam.config.Logger.Debug("Audit batch flushed", zap.Int("count", len(eam.auditBatch)))
-	eam.auditBatch = make([]*model.AuthorizationAuditLog, 0)
+	eam.config.Metrics.observeAuditFlush(len(batch))
+
+	eam.auditMutex.Lock()
+	recovered := eam.auditPipelineFailing
+	eam.auditPipelineFailing = false
+	eam.auditMutex.Unlock()
+	if recovered {
+		eam.config.Logger.Info("Audit pipeline recovered", zap.Int("count", len(batch)))
+		notification.Default().Resolve(model.AlertRuleAuditPipelineFailure, "")
+	}
+
+	eam.config.Logger.Debug("Audit batch flushed", zap.Int("count", len(batch)))
+}
+
+// raiseAuditPipelineAlert notifies admins that audit logs cannot be saved
+func (eam *AZFAuthMiddleware) raiseAuditPipelineAlert(err error, pending int) {
+	alert, alertErr := model.NewAlert(
+		eam.config.IDGenerator.NewID(),
+		model.AlertRuleAuditPipelineFailure,
+		model.AlertCritical,
+		"Authorization audit logs are not being saved",
+		fmt.Sprintf("Saving the authorization audit batch failed in %s: %v. %d audit logs are waiting to be saved.",
+			eam.config.Environment, err, pending),
+		"",
+		eam.config.Clock.Now(),
+		map[string]interface{}{"pending_logs": pending, "environment": eam.config.Environment},
+	)
+	if alertErr != nil {
+		eam.config.Logger.Error("Failed to create audit pipeline alert", zap.Error(alertErr))
+		return
+	}
+	notification.Default().Notify(alert)
+}
+
+// countDenial counts a non-allowed event towards denial storm detection and
+// returns the window's count if this event started a storm. Callers hold auditMutex.
+func (eam *AZFAuthMiddleware) countDenial(now time.Time) int {
+	if eam.config.DenialStormThreshold <= 0 || eam.config.DenialStormWindow <= 0 {
+		return 0
+	}
+
+	eam.rollDenialWindow(now)
+	eam.denialCount++
+	if eam.denialStorm || eam.denialCount < eam.config.DenialStormThreshold {
+		return 0
+	}
+	eam.denialStorm = true
+	return eam.denialCount
+}
+
+// rollDenialWindow starts a new counting window once the current one has
+// passed. It returns true if the storm ended because the finished window
+// stayed below the threshold. Callers hold auditMutex.
+func (eam *AZFAuthMiddleware) rollDenialWindow(now time.Time) bool {
+	if now.Sub(eam.denialWindowStart) < eam.config.DenialStormWindow {
+		return false
+	}
+
+	ended := eam.denialStorm && eam.denialCount < eam.config.DenialStormThreshold
+	if ended {
+		eam.denialStorm = false
+	}
+	eam.denialWindowStart = now
+	eam.denialCount = 0
+	return ended
+}
+
+// checkDenialStorm resolves the denial storm alert once denials have calmed
+// down, even if no further requests are denied
+func (eam *AZFAuthMiddleware) checkDenialStorm(now time.Time) {
+	if eam.config.DenialStormThreshold <= 0 || eam.config.DenialStormWindow <= 0 {
+		return
+	}
+
+	eam.auditMutex.Lock()
+	ended := eam.rollDenialWindow(now)
+	eam.auditMutex.Unlock()
+
+	if ended {
+		eam.config.Logger.Info("Denial storm ended")
+		notification.Default().Resolve(model.AlertRuleDenialStorm, "")
+	}
+}
+
+// raiseDenialStormAlert notifies admins that requests are being denied at an unusual rate
+func (eam *AZFAuthMiddleware) raiseDenialStormAlert(count int) {
+	alert, err := model.NewAlert(
+		eam.config.IDGenerator.NewID(),
+		model.AlertRuleDenialStorm,
+		model.AlertCritical,
+		"Authorization denial storm",
+		fmt.Sprintf("%d requests were denied within %s in %s (threshold %d).",
+			count, eam.config.DenialStormWindow, eam.config.Environment, eam.config.DenialStormThreshold),
+		"",
+		eam.config.Clock.Now(),
+		map[string]interface{}{
+			"denied_requests": count,
+			"window":          eam.config.DenialStormWindow.String(),
+			"threshold":       eam.config.DenialStormThreshold,
+			"environment":     eam.config.Environment,
+		},
+	)
+	if err != nil {
+		eam.config.Logger.Error("Failed to create denial storm alert", zap.Error(err))
+		return
+	}
+	eam.config.Logger.Warn("Denial storm detected", zap.Int("denied_requests", count))
+	notification.Default().Notify(alert)
+}
+
+// Hooks returns the lifecycle hook registry so embedding applications can add
+// custom rules, headers and audit fields without forking the middleware
+func (eam *AZFAuthMiddleware) Hooks() *HookRegistry {
+	return eam.hooks
+}
+
+// SetFeatureFlags installs the runtime switches for audit logging, rate
+// limiting and denial storm detection. Call it before serving requests.
+func (eam *AZFAuthMiddleware) SetFeatureFlags(flags FeatureFlagProvider) {
+	eam.config.FeatureFlags = flags
+}
+
+// featureEnabled returns the runtime value of flag, or fallback without feature flags
+func (eam *AZFAuthMiddleware) featureEnabled(flag string, fallback bool) bool {
+	if eam.config.FeatureFlags == nil {
+		return fallback
+	}
+	return eam.config.FeatureFlags.IsEnabled(flag, fallback)
+}
+
+func (eam *AZFAuthMiddleware) auditLoggingEnabled() bool {
+	return eam.featureEnabled(api_usage.FeatureAuditLogging, eam.config.EnableAuditLogging)
+}
+
+func (eam *AZFAuthMiddleware) rateLimitEnabled() bool {
+	return eam.config.RateLimiter != nil && eam.featureEnabled(api_usage.FeatureRateLimiting, eam.config.EnableRateLimit)
+}
+
+func (eam *AZFAuthMiddleware) anomalyDetectionEnabled() bool {
+	return eam.featureEnabled(api_usage.FeatureAnomalyDetection, true)
 }
 
 // startBatchProcessor starts the batch processor goroutine
@@ -429,8 +938,9 @@ func (eam *AZFAuthMiddleware) startBatchProcessor() {
 			select {
 			case <-ticker.C:
 				eam.flushAuditBatch()
+				eam.checkDenialStorm(eam.config.Clock.Now())
 			case <-eam.stopBatchProcessor:
-				eam.flushAuditBatch() // Final flush
+				eam.flushAudit(true) // Final flush, including open rollups
 				return
 			}
 		}
@@ -445,6 +955,131 @@ func (eam *AZFAuthMiddleware) Stop() {
 	}
 }
 
+// handleRequestTooLarge rejects a request whose declared body exceeds the route limit
+func (eam *AZFAuthMiddleware) handleRequestTooLarge(c *gin.Context, routeMetadata *RouteMetadata, requestID string, startTime time.Time) {
+	userRole, userID, ipAddress := eam.extractUserContext(c)
+
+	eam.config.Logger.Warn(
+		"Request body too large",
+		zap.String("request_id", requestID),
+		zap.String("user_id", userID),
+		zap.String("path", routeMetadata.Path),
+		zap.Int64("content_length", c.Request.ContentLength),
+		zap.Int64("max_body_bytes", routeMetadata.MaxBodyBytes),
+	)
+
+	if eam.auditLoggingEnabled() {
+		eam.logAuthorizationAudit(
+			requestID, userID, userRole, routeMetadata.Path, c.Request.Method,
+			model.AuthzDenied, model.ReasonRequestTooLarge,
+			fmt.Sprintf("request body of %d bytes exceeds the %d byte limit", c.Request.ContentLength, routeMetadata.MaxBodyBytes),
+			ipAddress, c.Request.UserAgent(),
+			time.Since(startTime).Milliseconds(),
+			routeMetadata, model.RateLimitStatusOK, config.AUTH_MODE_CASBIN,
+			eam.auditDetails(c, nil),
+		)
+	}
+
+	response.PayloadTooLarge(c, fmt.Sprintf("Request body exceeds the %d byte limit for this route", routeMetadata.MaxBodyBytes))
+	c.Abort()
+}
+
+// checkQuota counts the request against the quotas of its user, role and
+// API key, and rejects it when one is used up. It reports whether the
+// request may continue; a failed check lets it through.
+func (eam *AZFAuthMiddleware) checkQuota(c *gin.Context, hc *HookContext, startTime time.Time) bool {
+	if eam.config.Quotas == nil {
+		return true
+	}
+	quota, err := eam.config.Quotas.ConsumeQuota(c.Request.Context(), QuotaRequest{
+		UserID:   hc.UserID,
+		Role:     hc.Role,
+		APIKeyID: middleware.GetAPIKeyID(c),
+	})
+	if err != nil {
+		eam.config.Logger.Error("Quota check failed", zap.Error(err))
+		return true
+	}
+	if quota == nil {
+		return true
+	}
+
+	c.Header("X-Quota-Limit", fmt.Sprintf("%d", quota.Limit))
+	c.Header("X-Quota-Remaining", fmt.Sprintf("%d", quota.Remaining))
+	c.Header("X-Quota-Reset", fmt.Sprintf("%d", quota.ResetAt.Unix()))
+	if quota.Allowed {
+		return true
+	}
+
+	message := fmt.Sprintf("%s quota of %d requests for %s %s exceeded", quota.Period, quota.Limit, quota.SubjectType, quota.Subject)
+	eam.config.Logger.Warn(
+		"Usage quota exceeded",
+		zap.String("request_id", hc.RequestID),
+		zap.String("user_id", hc.UserID),
+		zap.String("role", hc.Role),
+		zap.String("subject_type", quota.SubjectType),
+		zap.String("subject", quota.Subject),
+		zap.String("period", quota.Period),
+		zap.Int64("limit", quota.Limit),
+	)
+
+	if eam.auditLoggingEnabled() {
+		eam.logAuthorizationAudit(
+			hc.RequestID, hc.UserID, hc.Role, hc.Resource, hc.Action,
+			model.AuthzDenied, model.ReasonQuotaExceeded, message,
+			hc.IPAddress, c.Request.UserAgent(),
+			time.Since(startTime).Milliseconds(),
+			hc.Route, model.RateLimitStatusExceeded, config.AUTH_MODE_CASBIN,
+			eam.auditDetails(c, hc),
+		)
+	}
+
+	c.Header("Retry-After", fmt.Sprintf("%d", int(math.Ceil(time.Until(quota.ResetAt).Seconds()))))
+	eam.hooks.runPreResponse(hc, false)
+	response.QuotaExceeded(c, message)
+	c.Abort()
+	return false
+}
+
+// handleInsufficientScope rejects a request whose API key lacks a scope
+// the route requires
+func (eam *AZFAuthMiddleware) handleInsufficientScope(c *gin.Context, hc *HookContext, startTime time.Time) {
+	message := fmt.Sprintf("API key lacks the scopes %s %s requires: %s",
+		hc.Action, hc.Resource, strings.Join(hc.Route.RequiredScopes, " "))
+	eam.config.Logger.Warn(
+		"API key scope denied",
+		zap.String("request_id", hc.RequestID),
+		zap.String("user_id", hc.UserID),
+		zap.String("api_key_id", middleware.GetAPIKeyID(c)),
+		zap.String("path", hc.Resource),
+		zap.Strings("required_scopes", hc.Route.RequiredScopes),
+	)
+	eam.config.Metrics.recordDecision(hc.Route, hc.Action, hc.Role, false, config.AUTH_MODE_CASBIN)
+	traceDecision(c, hc.Route, hc.Role, false, config.AUTH_MODE_CASBIN)
+
+	if eam.auditLoggingEnabled() {
+		eam.logAuthorizationAudit(
+			hc.RequestID, hc.UserID, hc.Role, hc.Resource, hc.Action,
+			model.AuthzDenied, model.ReasonInsufficientScope, message,
+			hc.IPAddress, c.Request.UserAgent(),
+			time.Since(startTime).Milliseconds(),
+			hc.Route, model.RateLimitStatusOK, config.AUTH_MODE_CASBIN,
+			eam.auditDetails(c, hc),
+		)
+	}
+
+	c.Header("WWW-Authenticate", fmt.Sprintf(`APIKey error="insufficient_scope", scope="%s"`, strings.Join(hc.Route.RequiredScopes, " ")))
+	middleware.SetAuthorizationMode(c, config.AUTH_MODE_CASBIN)
+	eam.hooks.runPreResponse(hc, false)
+	eam.responseHelper.Forbidden(c, message)
+	c.Abort()
+}
+
+// SetQuotaChecker sets the daily and monthly usage quotas requests are checked against
+func (eam *AZFAuthMiddleware) SetQuotaChecker(quotas QuotaChecker) {
+	eam.config.Quotas = quotas
+}
+
 // handleUnauthorized handles unauthorized requests
 func (eam *AZFAuthMiddleware) handleUnauthorized(c *gin.Context, message, requestID string) {
 	path := utils.NormalizePathForLookup(c.Request.URL.Path)
@@ -458,7 +1093,7 @@ func (eam *AZFAuthMiddleware) handleUnauthorized(c *gin.Context, message, reques
 	)
 
 	// Create audit log for registered routes
-	if eam.config.EnableAuditLogging {
+	if eam.auditLoggingEnabled() {
 		routeMetadata, routeExists := eam.config.RouteRegistry.Get(path, method)
 		if routeExists && routeMetadata.AuditRequired {
 			// Extract what we can from the request
@@ -467,10 +1102,11 @@ func (eam *AZFAuthMiddleware) handleUnauthorized(c *gin.Context, message, reques
 
 			eam.logAuthorizationAudit(
 				requestID, "", "", path, method, // No user info available
-				model.AuthzDenied, model.ReasonRoleNotFound,
+				model.AuthzDenied, model.ReasonRoleNotFound, message,
 				ipAddress, userAgent,
 				0, // execution time not available
-				false,
+				routeMetadata, model.RateLimitStatusOK, config.AUTH_MODE_CASBIN,
+				eam.auditDetails(c, nil),
 			)
 		}
 	}
@@ -0,0 +1,190 @@
+package enterprise
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// HookContext describes the request being authorized. It is shared by all
+// hooks of one request, so values stored with Set are visible to later hooks.
+type HookContext struct {
+	Gin       *gin.Context
+	RequestID string
+	UserID    string
+	Role      string
+	Resource  string
+	Action    string
+	IPAddress string
+	// Route is the registered metadata for the route, nil for unregistered routes
+	Route *RouteMetadata
+
+	values map[string]interface{}
+}
+
+// Set stores a value for later hooks of the same request
+func (hc *HookContext) Set(key string, value interface{}) {
+	if hc.values == nil {
+		hc.values = make(map[string]interface{})
+	}
+	hc.values[key] = value
+}
+
+// Get returns a value stored by an earlier hook
+func (hc *HookContext) Get(key string) (interface{}, bool) {
+	value, exists := hc.values[key]
+	return value, exists
+}
+
+// PreAuthorizationHook runs before the policy check. Returning an error denies
+// the request with the error message, whatever the policy says.
+type PreAuthorizationHook func(hc *HookContext) error
+
+// PostDecisionHook runs after the policy check with the decision so far. It
+// can deny an allowed request by returning false, but cannot allow a denied one.
+type PostDecisionHook func(hc *HookContext, allowed bool) bool
+
+// PreResponseHook runs right before the request is rejected or handed to the
+// route handler, e.g. to add response headers
+type PreResponseHook func(hc *HookContext, allowed bool)
+
+// AuditEnrichmentHook adds fields to the audit entry of the request
+type AuditEnrichmentHook func(hc *HookContext, fields map[string]interface{})
+
+// HookRegistry holds the lifecycle hooks of the authorization middleware.
+// Hooks run in registration order and may be registered at any time.
+type HookRegistry struct {
+	mu               sync.RWMutex
+	preAuthorization []PreAuthorizationHook
+	postDecision     []PostDecisionHook
+	preResponse      []PreResponseHook
+	auditEnrichment  []AuditEnrichmentHook
+	logger           *zap.Logger
+}
+
+// NewHookRegistry creates an empty hook registry
+func NewHookRegistry(logger *zap.Logger) *HookRegistry {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &HookRegistry{logger: logger}
+}
+
+// OnPreAuthorization registers a hook that runs before the policy check
+func (hr *HookRegistry) OnPreAuthorization(hook PreAuthorizationHook) {
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+	hr.preAuthorization = append(hr.preAuthorization, hook)
+}
+
+// OnPostDecision registers a hook that runs after the policy check
+func (hr *HookRegistry) OnPostDecision(hook PostDecisionHook) {
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+	hr.postDecision = append(hr.postDecision, hook)
+}
+
+// OnPreResponse registers a hook that runs before the request is rejected or passed on
+func (hr *HookRegistry) OnPreResponse(hook PreResponseHook) {
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+	hr.preResponse = append(hr.preResponse, hook)
+}
+
+// OnAuditEnrichment registers a hook that adds fields to audit entries
+func (hr *HookRegistry) OnAuditEnrichment(hook AuditEnrichmentHook) {
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+	hr.auditEnrichment = append(hr.auditEnrichment, hook)
+}
+
+// runPreAuthorization returns the first error raised by a pre-authorization
+// hook. A panicking hook denies the request so a broken rule fails closed.
+func (hr *HookRegistry) runPreAuthorization(hc *HookContext) error {
+	hr.mu.RLock()
+	hooks := hr.preAuthorization
+	hr.mu.RUnlock()
+
+	for _, hook := range hooks {
+		if err := hr.callPreAuthorization(hook, hc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (hr *HookRegistry) callPreAuthorization(hook PreAuthorizationHook, hc *HookContext) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			hr.logger.Error("Pre-authorization hook panicked", zap.Any("panic", r), zap.String("request_id", hc.RequestID))
+			err = fmt.Errorf("access denied")
+		}
+	}()
+	return hook(hc)
+}
+
+// runPostDecision passes the decision through every post-decision hook. A
+// panicking hook denies the request.
+func (hr *HookRegistry) runPostDecision(hc *HookContext, allowed bool) bool {
+	hr.mu.RLock()
+	hooks := hr.postDecision
+	hr.mu.RUnlock()
+
+	for _, hook := range hooks {
+		// Every hook sees the decision, but a denial cannot be overturned
+		result := hr.callPostDecision(hook, hc, allowed)
+		allowed = allowed && result
+	}
+	return allowed
+}
+
+func (hr *HookRegistry) callPostDecision(hook PostDecisionHook, hc *HookContext, allowed bool) (result bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			hr.logger.Error("Post-decision hook panicked", zap.Any("panic", r), zap.String("request_id", hc.RequestID))
+			result = false
+		}
+	}()
+	return hook(hc, allowed)
+}
+
+// runPreResponse calls every pre-response hook; panics are logged and ignored
+func (hr *HookRegistry) runPreResponse(hc *HookContext, allowed bool) {
+	hr.mu.RLock()
+	hooks := hr.preResponse
+	hr.mu.RUnlock()
+
+	for _, hook := range hooks {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					hr.logger.Error("Pre-response hook panicked", zap.Any("panic", r), zap.String("request_id", hc.RequestID))
+				}
+			}()
+			hook(hc, allowed)
+		}()
+	}
+}
+
+// auditFields collects the fields added by audit enrichment hooks; panics
+// are logged and the fields gathered so far are kept
+func (hr *HookRegistry) auditFields(hc *HookContext) map[string]interface{} {
+	hr.mu.RLock()
+	hooks := hr.auditEnrichment
+	hr.mu.RUnlock()
+
+	fields := make(map[string]interface{})
+	for _, hook := range hooks {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					hr.logger.Error("Audit enrichment hook panicked", zap.Any("panic", r), zap.String("request_id", hc.RequestID))
+				}
+			}()
+			hook(hc, fields)
+		}()
+	}
+	return fields
+}
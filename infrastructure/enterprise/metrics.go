@@ -0,0 +1,127 @@
+package enterprise
+
+import (
+	"time"
+
+	"github.com/aruncs31s/azf/infrastructure/metrics"
+)
+
+// unregisteredRoute labels requests to routes without metadata, keeping the
+// number of series bounded whatever paths clients request
+const unregisteredRoute = "unregistered"
+
+// AuthorizationMetrics records the authorization decisions, rate limit
+// rejections, audit flushes and latencies of the middleware. A nil
+// *AuthorizationMetrics records nothing.
+type AuthorizationMetrics struct {
+	decisions       *metrics.CounterVec
+	rateLimited     *metrics.CounterVec
+	auditFlushSize  *metrics.HistogramVec
+	enforceDuration *metrics.HistogramVec
+	overhead        *metrics.HistogramVec
+}
+
+// NewAuthorizationMetrics registers the authorization metrics in registry
+func NewAuthorizationMetrics(registry *metrics.Registry) *AuthorizationMetrics {
+	return &AuthorizationMetrics{
+		decisions: registry.NewCounterVec("azf_authorization_decisions_total",
+			"Authorization decisions by route, method, role, decision and authorization mode.",
+			"route", "method", "role", "decision", "mode"),
+		rateLimited: registry.NewCounterVec("azf_rate_limit_rejections_total",
+			"Requests rejected by the per-route rate limits.",
+			"route", "method", "role"),
+		auditFlushSize: registry.NewHistogramVec("azf_audit_flush_size",
+			"Number of audit log entries written per batch flush.",
+			[]float64{1, 5, 10, 25, 50, 100, 250, 500, 1000}),
+		enforceDuration: registry.NewHistogramVec("azf_casbin_enforce_duration_seconds",
+			"Latency of Casbin policy enforcement.",
+			nil),
+		overhead: registry.NewHistogramVec("azf_authorization_middleware_duration_seconds",
+			"Time spent in the authorization middleware, excluding the route handler.",
+			nil, "outcome"),
+	}
+}
+
+// routeLabel returns the registered path of route, so parameterized paths
+// share a series
+func routeLabel(route *RouteMetadata) string {
+	if route == nil {
+		return unregisteredRoute
+	}
+	return route.Path
+}
+
+func (m *AuthorizationMetrics) recordDecision(route *RouteMetadata, method, role string, allowed bool, mode string) {
+	if m == nil {
+		return
+	}
+	decision := "allowed"
+	if !allowed {
+		decision = "denied"
+	}
+	m.decisions.Inc(routeLabel(route), method, role, decision, mode)
+}
+
+func (m *AuthorizationMetrics) recordRateLimited(route *RouteMetadata, method, role string) {
+	if m == nil {
+		return
+	}
+	m.rateLimited.Inc(routeLabel(route), method, role)
+}
+
+func (m *AuthorizationMetrics) observeAuditFlush(size int) {
+	if m == nil || size == 0 {
+		return
+	}
+	m.auditFlushSize.Observe(float64(size))
+}
+
+func (m *AuthorizationMetrics) observeEnforce(d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.enforceDuration.Observe(d.Seconds())
+}
+
+func (m *AuthorizationMetrics) observeOverhead(d time.Duration, aborted bool) {
+	if m == nil {
+		return
+	}
+	outcome := "passed"
+	if aborted {
+		outcome = "rejected"
+	}
+	m.overhead.Observe(d.Seconds(), outcome)
+}
+
+// RateLimiterMetrics records the buckets held by the in-memory rate limiter
+// and the buckets it drops. A nil *RateLimiterMetrics records nothing.
+type RateLimiterMetrics struct {
+	buckets   *metrics.GaugeVec
+	evictions *metrics.CounterVec
+}
+
+// NewRateLimiterMetrics registers the rate limiter metrics in registry
+func NewRateLimiterMetrics(registry *metrics.Registry) *RateLimiterMetrics {
+	return &RateLimiterMetrics{
+		buckets: registry.NewGaugeVec("azf_rate_limit_buckets",
+			"Token buckets held by the in-memory rate limiter."),
+		evictions: registry.NewCounterVec("azf_rate_limit_bucket_evictions_total",
+			"Token buckets dropped by the in-memory rate limiter, by reason: lru when the bucket cap was reached, expired by the cleanup sweep.",
+			"reason"),
+	}
+}
+
+func (m *RateLimiterMetrics) setBuckets(count int) {
+	if m == nil {
+		return
+	}
+	m.buckets.Set(float64(count))
+}
+
+func (m *RateLimiterMetrics) recordEvictions(reason string, count int) {
+	if m == nil || count == 0 {
+		return
+	}
+	m.evictions.Add(float64(count), reason)
+}
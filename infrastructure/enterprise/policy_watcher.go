@@ -0,0 +1,117 @@
+package enterprise
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/casbin/casbin/v2/persist"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// DefaultPolicyWatcherChannel is the Redis channel policy changes are announced on
+const DefaultPolicyWatcherChannel = "azf:casbin:policy"
+
+// RedisPolicyWatcher is a Casbin watcher announcing policy changes over Redis
+// pub/sub, so every instance reloads its policies when one of them saves a change
+type RedisPolicyWatcher struct {
+	client     *redis.Client
+	channel    string
+	instanceID string
+	pubsub     *redis.PubSub
+	logger     *zap.Logger
+
+	mu       sync.Mutex
+	callback func(string)
+	done     chan struct{}
+	once     sync.Once
+}
+
+var _ persist.Watcher = (*RedisPolicyWatcher)(nil)
+
+// NewRedisPolicyWatcher subscribes to channel, DefaultPolicyWatcherChannel
+// when empty, and starts listening for changes of other instances
+func NewRedisPolicyWatcher(client *redis.Client, channel string, logger *zap.Logger) (*RedisPolicyWatcher, error) {
+	if client == nil {
+		return nil, fmt.Errorf("redis client is required for the policy watcher")
+	}
+	if channel == "" {
+		channel = DefaultPolicyWatcherChannel
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	pubsub := client.Subscribe(ctx, channel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		return nil, fmt.Errorf("failed to subscribe to policy channel %s: %w", channel, err)
+	}
+
+	w := &RedisPolicyWatcher{
+		client:     client,
+		channel:    channel,
+		instanceID: uuid.NewString(),
+		pubsub:     pubsub,
+		logger:     logger,
+		done:       make(chan struct{}),
+	}
+	go w.listen()
+	return w, nil
+}
+
+// SetUpdateCallback sets the function called when another instance changed the policies
+func (w *RedisPolicyWatcher) SetUpdateCallback(callback func(string)) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.callback = callback
+	return nil
+}
+
+// Update announces that this instance changed the policies
+func (w *RedisPolicyWatcher) Update() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := w.client.Publish(ctx, w.channel, w.instanceID).Err(); err != nil {
+		return fmt.Errorf("failed to publish policy change: %w", err)
+	}
+	return nil
+}
+
+// Close stops listening for changes
+func (w *RedisPolicyWatcher) Close() {
+	w.once.Do(func() {
+		close(w.done)
+		_ = w.pubsub.Close()
+	})
+}
+
+// listen calls the update callback for changes announced by other instances
+func (w *RedisPolicyWatcher) listen() {
+	messages := w.pubsub.Channel()
+	for {
+		select {
+		case <-w.done:
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			if msg.Payload == w.instanceID {
+				continue
+			}
+			w.mu.Lock()
+			callback := w.callback
+			w.mu.Unlock()
+			if callback == nil {
+				continue
+			}
+			if w.logger != nil {
+				w.logger.Debug("Policy change announced by another instance", zap.String("instance", msg.Payload))
+			}
+			callback(msg.Payload)
+		}
+	}
+}
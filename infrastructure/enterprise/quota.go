@@ -0,0 +1,34 @@
+package enterprise
+
+import (
+	"context"
+	"time"
+)
+
+// QuotaRequest identifies who a request counts against. Empty fields are
+// not checked.
+type QuotaRequest struct {
+	UserID   string
+	Role     string
+	APIKeyID string
+}
+
+// QuotaResult is the state of the quota a request was checked against:
+// the one it exceeded, or else the one with the fewest requests left
+type QuotaResult struct {
+	Allowed     bool
+	SubjectType string
+	Subject     string
+	Period      string
+	Limit       int64
+	Remaining   int64
+	ResetAt     time.Time
+}
+
+// QuotaChecker enforces daily and monthly usage quotas, which are checked
+// after the per-minute rate limits
+type QuotaChecker interface {
+	// ConsumeQuota counts the request against every quota that applies to
+	// it, unless one of them is used up. It returns nil when none applies.
+	ConsumeQuota(ctx context.Context, req QuotaRequest) (*QuotaResult, error)
+}
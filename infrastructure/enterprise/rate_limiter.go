@@ -1,11 +1,17 @@
 package enterprise
 
 import (
+	"container/list"
 	"context"
 	"fmt"
+	"math"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/aruncs31s/azf/domain/model"
+	"github.com/aruncs31s/azf/shared/clock"
+	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 )
@@ -19,28 +25,176 @@ type RateLimitResult struct {
 	LimitExceeded      bool
 	CurrentWindowCount int
 	WindowSize         time.Duration
+	// BlockedUntil is when the block rejecting the request ends; zero when
+	// the request was not rejected by a block
+	BlockedUntil time.Time
 }
 
-// RateLimitConfig defines rate limiting configuration
+// rateLimitWarningRatio is the share of the window's requests left at which
+// a caller is reported as close to the limit
+const rateLimitWarningRatio = 0.1
+
+// AuditStatus returns the rate limit state recorded on audit entries: EXCEEDED
+// when the request was rejected and WARNING when few requests remain
+func (r *RateLimitResult) AuditStatus() string {
+	if r == nil {
+		return model.RateLimitStatusOK
+	}
+	if r.LimitExceeded {
+		return model.RateLimitStatusExceeded
+	}
+	total := r.CurrentWindowCount + r.RemainingRequests
+	if total > 0 && float64(r.RemainingRequests) <= float64(total)*rateLimitWarningRatio {
+		return model.RateLimitStatusWarning
+	}
+	return model.RateLimitStatusOK
+}
+
+// RateLimitConfig defines rate limiting configuration. On a route it sets
+// limits for that endpoint alone, taking precedence over the limiter's.
 type RateLimitConfig struct {
 	DefaultRequestsPerMinute int
-	RoleSpecificLimits       map[string]int // role -> requests per minute
-	BurstAllowance           int            // Extra requests allowed temporarily
-	WindowDuration           time.Duration  // Time window for counting (default: 1 minute)
-	EnableRedis              bool           // Use Redis for distributed rate limiting
+	RoleSpecificLimits       map[string]int            // role -> requests per minute
+	UserLimits               map[string]int            // user ID -> requests per minute, ahead of role limits
+	BurstAllowance           int                       // Extra requests allowed temporarily
+	Weight                   int                       // Tokens a request to the route consumes (default: 1)
+	WindowDuration           time.Duration             // Time window for counting (default: 1 minute)
+	EnableRedis              bool                      // Use Redis for distributed rate limiting
+	Algorithm                RateLimitAlgorithm        // Counting algorithm of the Redis limiter (default: fixed window)
+	Overrides                RateLimitOverrideProvider // Optional per-client limits applied ahead of role limits
+	Clock                    clock.Clock               // Time source of the limiter (default: clock.Default())
+	MaxBuckets               int                       // Buckets the in-memory limiter keeps before evicting the least recently used (default: 100000)
+	AutoBanThreshold         int                       // Rejections within a window after which the identifier is blocked (default: 0, off)
+	AutoBanDuration          time.Duration             // How long an automatic block lasts (default: 15 minutes)
+}
+
+const (
+	// defaultMaxRateLimitBuckets bounds the in-memory limiter when no cap is set
+	defaultMaxRateLimitBuckets = 100000
+	// defaultAutoBanDuration is how long an automatic block lasts when
+	// AutoBanDuration is unset
+	defaultAutoBanDuration = 15 * time.Minute
+)
+
+// Validate checks that limits, burst and weight are not negative
+func (c *RateLimitConfig) Validate() error {
+	if c.DefaultRequestsPerMinute < 0 {
+		return fmt.Errorf("requests per minute cannot be negative")
+	}
+	if c.BurstAllowance < 0 {
+		return fmt.Errorf("burst allowance cannot be negative")
+	}
+	if c.Weight < 0 {
+		return fmt.Errorf("weight cannot be negative")
+	}
+	if c.MaxBuckets < 0 {
+		return fmt.Errorf("max buckets cannot be negative")
+	}
+	if c.AutoBanThreshold < 0 {
+		return fmt.Errorf("auto-ban threshold cannot be negative")
+	}
+	if c.AutoBanDuration < 0 {
+		return fmt.Errorf("auto-ban duration cannot be negative")
+	}
+	if c.Algorithm != "" {
+		if _, ok := rateLimitScripts[c.Algorithm]; !ok {
+			return fmt.Errorf("unknown rate limit algorithm %q", c.Algorithm)
+		}
+	}
+	for role, limit := range c.RoleSpecificLimits {
+		if limit < 0 {
+			return fmt.Errorf("requests per minute for role %s cannot be negative", role)
+		}
+	}
+	for userID, limit := range c.UserLimits {
+		if limit < 0 {
+			return fmt.Errorf("requests per minute for user %s cannot be negative", userID)
+		}
+	}
+	return nil
+}
+
+// autoBanDuration returns how long an automatic block lasts
+func (c *RateLimitConfig) autoBanDuration() time.Duration {
+	if c.AutoBanDuration > 0 {
+		return c.AutoBanDuration
+	}
+	return defaultAutoBanDuration
+}
+
+// hasLimits reports whether the config sets any limit of its own, rather
+// than only a weight
+func (c *RateLimitConfig) hasLimits() bool {
+	return c != nil && (c.DefaultRequestsPerMinute > 0 || len(c.RoleSpecificLimits) > 0 || len(c.UserLimits) > 0)
+}
+
+// RateLimitRequest is a request checked against the rate limits
+type RateLimitRequest struct {
+	Identifier string
+	Role       string
+	// Endpoint is the "METHOD:PATH" of the route. Requests to a route with
+	// limits of its own are counted for that endpoint alone; others share
+	// the identifier's bucket.
+	Endpoint string
+	// Route holds the limits and weight of the route; nil when it has none
+	Route *RateLimitConfig
+	// KeyLimit is the requests per minute of the API key the request
+	// authenticated with, ahead of every other limit; zero when it has none
+	KeyLimit int
+}
+
+// cost returns the tokens the request consumes
+func (r RateLimitRequest) cost() int {
+	if r.Route != nil && r.Route.Weight > 1 {
+		return r.Route.Weight
+	}
+	return 1
+}
+
+// scope returns the endpoint the request is counted for, or "" when it
+// counts towards the identifier's shared bucket
+func (r RateLimitRequest) scope() string {
+	if r.Route.hasLimits() {
+		return r.Endpoint
+	}
+	return ""
+}
+
+// RateLimitOverrideProvider supplies per-client limits that take precedence over role limits
+type RateLimitOverrideProvider interface {
+	// GetOverride returns the active override for identifier, if any
+	GetOverride(identifier string) (requestsPerMinute int, burstAllowance int, ok bool)
 }
 
 // RateLimiter interface for implementations
 type RateLimiter interface {
 	CheckLimit(ctx context.Context, identifier string, role string) (*RateLimitResult, error)
+	// CheckRequest checks a request with the per-endpoint limits, per-user
+	// limits and weight of its route
+	CheckRequest(ctx context.Context, req RateLimitRequest) (*RateLimitResult, error)
 	Reset(ctx context.Context, identifier string) error
+	// TemporaryBlock rejects every request of identifier for duration,
+	// whatever tokens it has left; a duration of zero or less lifts the
+	// block. Reset leaves blocks in place.
+	TemporaryBlock(ctx context.Context, identifier string, duration time.Duration) error
 	GetStats(ctx context.Context, identifier string) (map[string]interface{}, error)
 }
 
-// InMemoryRateLimiter uses in-memory storage for rate limiting
+// InMemoryRateLimiter uses in-memory storage for rate limiting. It keeps at
+// most MaxBuckets buckets, evicting the least recently used beyond that, so a
+// flood of distinct identifiers cannot grow it without bound.
 type InMemoryRateLimiter struct {
-	config         *RateLimitConfig
-	buckets        map[string]*TokenBucket
+	config  *RateLimitConfig
+	buckets map[string]*TokenBucket
+	// lru orders the bucket keys from the most to the least recently used
+	lru *list.List
+	// blocks holds when the block of each blocked identifier ends
+	blocks map[string]time.Time
+	// strikes counts the rejections of each identifier towards an auto-ban
+	strikes        map[string]*rateLimitStrikes
+	evictions      int64
+	expirations    int64
+	metrics        *RateLimiterMetrics
 	mu             sync.RWMutex
 	cleanupTicker  *time.Ticker
 	logger         *zap.Logger
@@ -57,6 +211,28 @@ type TokenBucket struct {
 	WindowStart      time.Time
 	WindowCount      int
 	CreatedAt        time.Time
+
+	// element is the bucket's entry in the limiter's LRU list
+	element *list.Element
+}
+
+// rateLimitStrikes counts an identifier's rejections in the window started
+// by its first one
+type rateLimitStrikes struct {
+	count       int
+	windowStart time.Time
+}
+
+// RateLimitBucketStats reports the buckets held by the in-memory rate limiter
+type RateLimitBucketStats struct {
+	Buckets    int `json:"buckets"`
+	MaxBuckets int `json:"max_buckets"`
+	// Evictions counts buckets dropped because the cap was reached
+	Evictions int64 `json:"evictions"`
+	// Expirations counts buckets dropped by the cleanup sweep
+	Expirations int64 `json:"expirations"`
+	// Blocked counts identifiers whose block has not ended
+	Blocked int `json:"blocked"`
 }
 
 // RedisRateLimiter uses Redis for distributed rate limiting
@@ -85,6 +261,9 @@ func NewInMemoryRateLimiter(config *RateLimitConfig, logger *zap.Logger) *InMemo
 	limiter := &InMemoryRateLimiter{
 		config:         config,
 		buckets:        make(map[string]*TokenBucket),
+		lru:            list.New(),
+		blocks:         make(map[string]time.Time),
+		strikes:        make(map[string]*rateLimitStrikes),
 		logger:         logger,
 		stopCleaning:   make(chan bool),
 		cleanupRunning: false,
@@ -121,31 +300,51 @@ func NewRedisRateLimiter(config *RateLimitConfig, client *redis.Client, logger *
 
 // CheckLimit checks if a request is within the rate limit
 func (rl *InMemoryRateLimiter) CheckLimit(ctx context.Context, identifier string, role string) (*RateLimitResult, error) {
+	return rl.CheckRequest(ctx, RateLimitRequest{Identifier: identifier, Role: role})
+}
+
+// CheckRequest checks if a request is within the rate limit of its route
+func (rl *InMemoryRateLimiter) CheckRequest(ctx context.Context, req RateLimitRequest) (*RateLimitResult, error) {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
-	now := time.Now()
-	limit := rl.config.DefaultRequestsPerMinute
+	identifier, role := req.Identifier, req.Role
+	now := clock.Or(rl.config.Clock).Now()
+	limit, burst := resolveLimit(rl.config, req)
+	cost := float64(req.cost())
+	key := rateLimitBucketKey(identifier, req.scope())
 
-	// Get role-specific limit if available
-	if roleLimit, exists := rl.config.RoleSpecificLimits[role]; exists {
-		limit = roleLimit
+	if until, blocked := rl.blocks[identifier]; blocked {
+		if now.Before(until) {
+			return blockedResult(until, now, rl.config.WindowDuration), nil
+		}
+		delete(rl.blocks, identifier)
 	}
 
 	// Get or create token bucket
-	bucket, exists := rl.buckets[identifier]
+	bucket, exists := rl.buckets[key]
 	if !exists {
-		tokensPerSec := float64(limit) / 60.0
 		bucket = &TokenBucket{
 			Tokens:           float64(limit),
-			MaxTokens:        float64(limit + rl.config.BurstAllowance),
-			RefillRatePerSec: tokensPerSec,
+			MaxTokens:        float64(limit + burst),
+			RefillRatePerSec: float64(limit) / 60.0,
 			LastRefillTime:   now,
 			WindowStart:      now,
 			WindowCount:      0,
 			CreatedAt:        now,
 		}
-		rl.buckets[identifier] = bucket
+		bucket.element = rl.lru.PushFront(key)
+		rl.buckets[key] = bucket
+		rl.evictLeastRecentlyUsed()
+		rl.metrics.setBuckets(len(rl.buckets))
+	} else {
+		rl.lru.MoveToFront(bucket.element)
+		if bucket.MaxTokens != float64(limit+burst) {
+			// The limit changed (override added, removed or expired); resize the bucket
+			bucket.MaxTokens = float64(limit + burst)
+			bucket.RefillRatePerSec = float64(limit) / 60.0
+			bucket.Tokens = min(bucket.MaxTokens, bucket.Tokens)
+		}
 	}
 
 	// Refill tokens based on time elapsed
@@ -161,7 +360,7 @@ func (rl *InMemoryRateLimiter) CheckLimit(ctx context.Context, identifier string
 	}
 
 	// Check if request is allowed
-	allowed := bucket.Tokens >= 1.0
+	allowed := bucket.Tokens >= cost
 	result := &RateLimitResult{
 		Allowed:            allowed,
 		LimitExceeded:      !allowed,
@@ -171,8 +370,8 @@ func (rl *InMemoryRateLimiter) CheckLimit(ctx context.Context, identifier string
 	}
 
 	if allowed {
-		bucket.Tokens -= 1.0
-		bucket.WindowCount++
+		bucket.Tokens -= cost
+		bucket.WindowCount += int(cost)
 		result.RemainingRequests = int(bucket.Tokens)
 	} else {
 		result.RetryAfterSeconds = int(rl.config.WindowDuration.Seconds())
@@ -180,54 +379,158 @@ func (rl *InMemoryRateLimiter) CheckLimit(ctx context.Context, identifier string
 			"Rate limit exceeded",
 			zap.String("identifier", identifier),
 			zap.String("role", role),
+			zap.String("endpoint", req.scope()),
 			zap.Int("limit", limit),
+			zap.Int("cost", int(cost)),
 			zap.Int("window_count", bucket.WindowCount),
 		)
+		rl.recordStrike(identifier, now)
 	}
 
 	return result, nil
 }
 
-// CheckLimit checks rate limit using Redis
-func (rl *RedisRateLimiter) CheckLimit(ctx context.Context, identifier string, role string) (*RateLimitResult, error) {
-	limit := rl.config.DefaultRequestsPerMinute
+// recordStrike counts a rejection of identifier and blocks it once the
+// auto-ban threshold is reached within a window. Callers hold the lock.
+func (rl *InMemoryRateLimiter) recordStrike(identifier string, now time.Time) {
+	threshold := rl.config.AutoBanThreshold
+	if threshold <= 0 {
+		return
+	}
+	strikes, exists := rl.strikes[identifier]
+	if !exists || now.Sub(strikes.windowStart) > rl.config.WindowDuration {
+		if !exists && len(rl.strikes) >= rl.maxBuckets() {
+			rl.pruneStrikes(now)
+		}
+		strikes = &rateLimitStrikes{windowStart: now}
+		rl.strikes[identifier] = strikes
+	}
+	strikes.count++
+	if strikes.count < threshold {
+		return
+	}
 
-	// Get role-specific limit
-	if roleLimit, exists := rl.config.RoleSpecificLimits[role]; exists {
-		limit = roleLimit
+	delete(rl.strikes, identifier)
+	duration := rl.config.autoBanDuration()
+	rl.blocks[identifier] = now.Add(duration)
+	rl.logger.Warn("Rate limit auto-ban",
+		zap.String("identifier", identifier),
+		zap.Int("rejections", strikes.count),
+		zap.Duration("duration", duration))
+}
+
+// pruneStrikes drops the strike counts whose window has passed. Callers hold
+// the lock.
+func (rl *InMemoryRateLimiter) pruneStrikes(now time.Time) {
+	for identifier, strikes := range rl.strikes {
+		if now.Sub(strikes.windowStart) > rl.config.WindowDuration {
+			delete(rl.strikes, identifier)
+		}
 	}
+}
 
-	// Create Redis key
-	key := fmt.Sprintf("rate_limit:%s:%s", role, identifier)
+// TemporaryBlock rejects every request of identifier for duration
+func (rl *InMemoryRateLimiter) TemporaryBlock(ctx context.Context, identifier string, duration time.Duration) error {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
 
-	now := time.Now()
-	windowStart := now.Truncate(rl.config.WindowDuration)
-	windowEnd := windowStart.Add(rl.config.WindowDuration)
+	if duration <= 0 {
+		delete(rl.blocks, identifier)
+		rl.logger.Info("Rate limit block lifted", zap.String("identifier", identifier))
+		return nil
+	}
+	rl.blocks[identifier] = clock.Or(rl.config.Clock).Now().Add(duration)
+	delete(rl.strikes, identifier)
+	rl.logger.Info("Rate limit block set", zap.String("identifier", identifier), zap.Duration("duration", duration))
+	return nil
+}
 
-	// Use Redis pipeline for atomic operations
-	pipe := rl.client.Pipeline()
+// blockedResult is the result of a request rejected by a block ending at until
+func blockedResult(until, now time.Time, window time.Duration) *RateLimitResult {
+	return &RateLimitResult{
+		Allowed:           false,
+		LimitExceeded:     true,
+		ResetAtTime:       until,
+		RetryAfterSeconds: int(math.Ceil(until.Sub(now).Seconds())),
+		WindowSize:        window,
+		BlockedUntil:      until,
+	}
+}
 
-	// Increment counter
-	incCmd := pipe.Incr(ctx, key)
-	// Set expiration
-	pipe.Expire(ctx, key, rl.config.WindowDuration)
+// addBlockStats adds the block ending at until to the stats of GetStats;
+// a zero until means the identifier is not blocked
+func addBlockStats(stats map[string]interface{}, until, now time.Time) {
+	if !now.Before(until) {
+		stats["blocked"] = false
+		return
+	}
+	stats["blocked"] = true
+	stats["blocked_until"] = until
+	stats["block_remaining_seconds"] = int(math.Ceil(until.Sub(now).Seconds()))
+}
 
-	_, err := pipe.Exec(ctx)
+// CheckLimit checks rate limit using Redis
+func (rl *RedisRateLimiter) CheckLimit(ctx context.Context, identifier string, role string) (*RateLimitResult, error) {
+	return rl.CheckRequest(ctx, RateLimitRequest{Identifier: identifier, Role: role})
+}
+
+// CheckRequest checks the rate limit of a request's route using Redis
+func (rl *RedisRateLimiter) CheckRequest(ctx context.Context, req RateLimitRequest) (*RateLimitResult, error) {
+	identifier, role := req.Identifier, req.Role
+	now := clock.Or(rl.config.Clock).Now()
+	blockTTL, err := rl.client.PTTL(ctx, rateLimitBlockKey(identifier)).Result()
 	if err != nil {
-		rl.logger.Error("Redis pipeline error", zap.Error(err))
+		rl.logger.Error("Redis rate limit block lookup error", zap.Error(err))
 		return nil, err
 	}
+	if blockTTL > 0 {
+		return blockedResult(now.Add(blockTTL), now, rl.config.WindowDuration), nil
+	}
+
+	limit, burst := resolveLimit(rl.config, req)
+	cost := int64(req.cost())
+
+	algorithm := rl.config.Algorithm
+	if algorithm == "" {
+		algorithm = RateLimitFixedWindow
+	}
+	script, ok := rateLimitScripts[algorithm]
+	if !ok {
+		return nil, fmt.Errorf("unknown rate limit algorithm %q", algorithm)
+	}
 
-	// Current count from increment command
-	count := incCmd.Val()
-	if count < 0 {
-		count = 0
+	// Create Redis key; the identifier stays last so Reset and GetStats
+	// find the endpoint keys too. Algorithms other than the fixed window
+	// keep their state in keys of their own, as it has another type.
+	prefix := "rate_limit:"
+	if algorithm != RateLimitFixedWindow {
+		prefix += string(algorithm) + ":"
+	}
+	key := fmt.Sprintf("%s%s:%s", prefix, role, identifier)
+	if endpoint := req.scope(); endpoint != "" {
+		key = fmt.Sprintf("%s%s:%s:%s", prefix, role, endpoint, identifier)
 	}
 
-	maxRequests := int64(limit + rl.config.BurstAllowance)
-	allowed := count <= int64(limit)
+	// The script checks and consumes in one step, so a rejected request
+	// consumes nothing and concurrent requests cannot both take the last slot
+	values, err := script.Run(ctx, rl.client, []string{key},
+		limit, cost, rl.config.WindowDuration.Milliseconds(), burst, uuid.NewString()).Int64Slice()
+	if err != nil {
+		rl.logger.Error("Redis rate limit script error", zap.String("algorithm", string(algorithm)), zap.Error(err))
+		return nil, err
+	}
+	if len(values) != 4 {
+		return nil, fmt.Errorf("rate limit script returned %d values", len(values))
+	}
+	allowed, count := values[0] == 1, values[1]
+	retryAfter := time.Duration(values[2]) * time.Millisecond
+	resetAfter := time.Duration(values[3]) * time.Millisecond
 
-	remaining := int(limit) - int(count)
+	capacity := int64(limit)
+	if algorithm == RateLimitLeakyBucket {
+		capacity += int64(burst)
+	}
+	remaining := capacity - count
 	if remaining < 0 {
 		remaining = 0
 	}
@@ -235,32 +538,112 @@ func (rl *RedisRateLimiter) CheckLimit(ctx context.Context, identifier string, r
 		Allowed:            allowed,
 		LimitExceeded:      !allowed,
 		CurrentWindowCount: int(count),
-		RemainingRequests:  remaining,
-		ResetAtTime:        windowEnd,
+		RemainingRequests:  int(remaining),
+		ResetAtTime:        now.Add(resetAfter),
 		WindowSize:         rl.config.WindowDuration,
 	}
 
 	if !allowed {
-		result.RetryAfterSeconds = int(windowEnd.Sub(now).Seconds())
+		result.RetryAfterSeconds = int(math.Ceil(retryAfter.Seconds()))
 		rl.logger.Warn(
 			"Rate limit exceeded (Redis)",
 			zap.String("identifier", identifier),
 			zap.String("role", role),
+			zap.String("endpoint", req.scope()),
+			zap.String("algorithm", string(algorithm)),
 			zap.Int("limit", limit),
+			zap.Int64("cost", cost),
 			zap.Int64("count", count),
-			zap.Int64("max", maxRequests),
+			zap.Int64("max", capacity),
 		)
+		rl.recordStrike(ctx, identifier)
 	}
 
 	return result, nil
 }
 
+// rateLimitBlockKey returns the Redis key whose TTL is the block of identifier
+func rateLimitBlockKey(identifier string) string {
+	return "rate_limit_block:" + identifier
+}
+
+// rateLimitStrikesKey returns the Redis key counting the rejections of
+// identifier towards an auto-ban
+func rateLimitStrikesKey(identifier string) string {
+	return "rate_limit_strikes:" + identifier
+}
+
+// recordStrike counts a rejection of identifier and blocks it once the
+// auto-ban threshold is reached within a window. Failures are logged; the
+// request was rejected either way.
+func (rl *RedisRateLimiter) recordStrike(ctx context.Context, identifier string) {
+	threshold := rl.config.AutoBanThreshold
+	if threshold <= 0 {
+		return
+	}
+	key := rateLimitStrikesKey(identifier)
+	count, err := rl.client.Incr(ctx, key).Result()
+	if err == nil && count == 1 {
+		err = rl.client.PExpire(ctx, key, rl.config.WindowDuration).Err()
+	}
+	if err != nil {
+		rl.logger.Error("Redis rate limit strike error", zap.Error(err))
+		return
+	}
+	if count < int64(threshold) {
+		return
+	}
+
+	duration := rl.config.autoBanDuration()
+	if err := rl.TemporaryBlock(ctx, identifier, duration); err != nil {
+		return
+	}
+	rl.logger.Warn("Rate limit auto-ban (Redis)",
+		zap.String("identifier", identifier),
+		zap.Int64("rejections", count),
+		zap.Duration("duration", duration))
+}
+
+// TemporaryBlock rejects every request of identifier for duration, on every
+// instance sharing the Redis server
+func (rl *RedisRateLimiter) TemporaryBlock(ctx context.Context, identifier string, duration time.Duration) error {
+	key := rateLimitBlockKey(identifier)
+	if duration <= 0 {
+		if err := rl.client.Del(ctx, key).Err(); err != nil {
+			rl.logger.Error("Redis delete error", zap.Error(err))
+			return err
+		}
+		rl.logger.Info("Rate limit block lifted (Redis)", zap.String("identifier", identifier))
+		return nil
+	}
+
+	until := clock.Or(rl.config.Clock).Now().Add(duration)
+	_, err := rl.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, key, until.UnixMilli(), duration)
+		pipe.Del(ctx, rateLimitStrikesKey(identifier))
+		return nil
+	})
+	if err != nil {
+		rl.logger.Error("Redis rate limit block error", zap.Error(err))
+		return err
+	}
+	rl.logger.Info("Rate limit block set (Redis)", zap.String("identifier", identifier), zap.Duration("duration", duration))
+	return nil
+}
+
 // Reset resets the rate limit for an identifier
 func (rl *InMemoryRateLimiter) Reset(ctx context.Context, identifier string) error {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
-	delete(rl.buckets, identifier)
+	rl.removeBucket(identifier)
+	prefix := identifier + rateLimitKeySeparator
+	for key := range rl.buckets {
+		if strings.HasPrefix(key, prefix) {
+			rl.removeBucket(key)
+		}
+	}
+	rl.metrics.setBuckets(len(rl.buckets))
 	rl.logger.Debug("Rate limit reset", zap.String("identifier", identifier))
 	return nil
 }
@@ -292,13 +675,27 @@ func (rl *InMemoryRateLimiter) GetStats(ctx context.Context, identifier string)
 	rl.mu.RLock()
 	defer rl.mu.RUnlock()
 
-	bucket, exists := rl.buckets[identifier]
-	if !exists {
-		return map[string]interface{}{
-			"exists": false,
-		}, nil
+	stats := map[string]interface{}{"exists": false}
+	if bucket, exists := rl.buckets[identifier]; exists {
+		stats = bucketStats(bucket)
 	}
 
+	prefix := identifier + rateLimitKeySeparator
+	endpoints := make(map[string]interface{})
+	for key, bucket := range rl.buckets {
+		if endpoint, ok := strings.CutPrefix(key, prefix); ok {
+			endpoints[endpoint] = bucketStats(bucket)
+		}
+	}
+	if len(endpoints) > 0 {
+		stats["endpoints"] = endpoints
+	}
+	addBlockStats(stats, rl.blocks[identifier], clock.Or(rl.config.Clock).Now())
+	return stats, nil
+}
+
+// bucketStats returns the state of a token bucket reported by GetStats
+func bucketStats(bucket *TokenBucket) map[string]interface{} {
 	return map[string]interface{}{
 		"exists":              true,
 		"tokens":              bucket.Tokens,
@@ -308,7 +705,7 @@ func (rl *InMemoryRateLimiter) GetStats(ctx context.Context, identifier string)
 		"window_start":        bucket.WindowStart,
 		"created_at":          bucket.CreatedAt,
 		"last_refill":         bucket.LastRefillTime,
-	}, nil
+	}
 }
 
 // GetStats returns statistics from Redis
@@ -324,14 +721,34 @@ func (rl *RedisRateLimiter) GetStats(ctx context.Context, identifier string) (ma
 	stats["total_keys"] = len(keys)
 
 	for _, key := range keys {
-		count, _ := rl.client.Get(ctx, key).Int64()
 		ttl, _ := rl.client.TTL(ctx, key).Result()
-		stats[key] = map[string]interface{}{
-			"count": count,
-			"ttl":   ttl,
+		keyStats := map[string]interface{}{"ttl": ttl}
+		// Each algorithm keeps its state in a different type
+		switch keyType, _ := rl.client.Type(ctx, key).Result(); keyType {
+		case "zset":
+			keyStats["count"], _ = rl.client.ZCard(ctx, key).Result()
+		case "hash":
+			state, _ := rl.client.HGetAll(ctx, key).Result()
+			for field, value := range state {
+				keyStats[field] = value
+			}
+		default:
+			keyStats["count"], _ = rl.client.Get(ctx, key).Int64()
 		}
+		stats[key] = keyStats
 	}
 
+	now := clock.Or(rl.config.Clock).Now()
+	blockTTL, err := rl.client.PTTL(ctx, rateLimitBlockKey(identifier)).Result()
+	if err != nil {
+		rl.logger.Error("Redis rate limit block lookup error", zap.Error(err))
+		return nil, err
+	}
+	var until time.Time
+	if blockTTL > 0 {
+		until = now.Add(blockTTL)
+	}
+	addBlockStats(stats, until, now)
 	return stats, nil
 }
 
@@ -362,15 +779,91 @@ func (rl *InMemoryRateLimiter) cleanupExpiredBuckets() {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
-	now := time.Now()
+	now := clock.Or(rl.config.Clock).Now()
 	cleanupThreshold := 30 * time.Minute
 
+	expired := 0
 	for identifier, bucket := range rl.buckets {
 		if now.Sub(bucket.CreatedAt) > cleanupThreshold {
-			delete(rl.buckets, identifier)
+			rl.removeBucket(identifier)
+			expired++
 			rl.logger.Debug("Cleaned up expired bucket", zap.String("identifier", identifier))
 		}
 	}
+	for identifier, until := range rl.blocks {
+		if !now.Before(until) {
+			delete(rl.blocks, identifier)
+		}
+	}
+	rl.pruneStrikes(now)
+	rl.expirations += int64(expired)
+	rl.metrics.recordEvictions("expired", expired)
+	rl.metrics.setBuckets(len(rl.buckets))
+}
+
+// evictLeastRecentlyUsed drops the least recently used buckets beyond the
+// cap. Callers hold the lock.
+func (rl *InMemoryRateLimiter) evictLeastRecentlyUsed() {
+	maxBuckets := rl.maxBuckets()
+	evicted := 0
+	for len(rl.buckets) > maxBuckets {
+		rl.removeBucket(rl.lru.Back().Value.(string))
+		evicted++
+	}
+	if evicted == 0 {
+		return
+	}
+	rl.evictions += int64(evicted)
+	rl.metrics.recordEvictions("lru", evicted)
+	rl.logger.Debug("Evicted least recently used rate limit buckets",
+		zap.Int("evicted", evicted),
+		zap.Int("max_buckets", maxBuckets))
+}
+
+// removeBucket drops the bucket of key. Callers hold the lock.
+func (rl *InMemoryRateLimiter) removeBucket(key string) {
+	if bucket, exists := rl.buckets[key]; exists {
+		rl.lru.Remove(bucket.element)
+		delete(rl.buckets, key)
+	}
+}
+
+// maxBuckets returns the configured bucket cap, or the default
+func (rl *InMemoryRateLimiter) maxBuckets() int {
+	if rl.config.MaxBuckets > 0 {
+		return rl.config.MaxBuckets
+	}
+	return defaultMaxRateLimitBuckets
+}
+
+// BucketStats reports the buckets held and dropped by the limiter
+func (rl *InMemoryRateLimiter) BucketStats() RateLimitBucketStats {
+	rl.mu.RLock()
+	defer rl.mu.RUnlock()
+
+	now := clock.Or(rl.config.Clock).Now()
+	blocked := 0
+	for _, until := range rl.blocks {
+		if now.Before(until) {
+			blocked++
+		}
+	}
+	return RateLimitBucketStats{
+		Buckets:     len(rl.buckets),
+		MaxBuckets:  rl.maxBuckets(),
+		Evictions:   rl.evictions,
+		Expirations: rl.expirations,
+		Blocked:     blocked,
+	}
+}
+
+// SetMetrics records the bucket count and evictions in m
+func (rl *InMemoryRateLimiter) SetMetrics(m *RateLimiterMetrics) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	rl.metrics = m
+	rl.metrics.setBuckets(len(rl.buckets))
 }
 
 // Stop stops the cleanup goroutine
@@ -402,6 +895,92 @@ func (rl *InMemoryRateLimiter) SetRoleLimit(role string, requestsPerMinute int,
 	)
 }
 
+// SetUserLimit updates (or adds) a per-user requests-per-minute limit
+func (rl *InMemoryRateLimiter) SetUserLimit(userID string, requestsPerMinute int) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	if rl.config.UserLimits == nil {
+		rl.config.UserLimits = make(map[string]int)
+	}
+	rl.config.UserLimits[userID] = requestsPerMinute
+
+	rl.logger.Debug("Updated user rate limit",
+		zap.String("user_id", userID),
+		zap.Int("requests_per_minute", requestsPerMinute),
+	)
+}
+
+// SetOverrideProvider sets the source of per-client limit overrides
+func (rl *InMemoryRateLimiter) SetOverrideProvider(provider RateLimitOverrideProvider) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	rl.config.Overrides = provider
+}
+
+// SetOverrideProvider sets the source of per-client limit overrides
+func (rl *RedisRateLimiter) SetOverrideProvider(provider RateLimitOverrideProvider) {
+	rl.config.Overrides = provider
+}
+
+// rateLimitKeySeparator separates the identifier from the endpoint in the
+// keys of endpoint buckets
+const rateLimitKeySeparator = "\x1f"
+
+// rateLimitBucketKey returns the key of the bucket counting identifier's
+// requests to endpoint, or all its requests when endpoint is ""
+func rateLimitBucketKey(identifier, endpoint string) string {
+	if endpoint == "" {
+		return identifier
+	}
+	return identifier + rateLimitKeySeparator + endpoint
+}
+
+// resolveLimit returns the requests-per-minute limit and burst allowance for
+// a request. An API key's own limit comes first, then per-user limits, role
+// limits and defaults; the route's limits come before the limiter's at each
+// level, with per-client overrides ahead of the limiter's user limits.
+func resolveLimit(config *RateLimitConfig, req RateLimitRequest) (int, int) {
+	route, identifier, role := req.Route, req.Identifier, req.Role
+	burst := config.BurstAllowance
+	if route.hasLimits() && route.BurstAllowance > 0 {
+		burst = route.BurstAllowance
+	}
+
+	if req.KeyLimit > 0 {
+		return req.KeyLimit, burst
+	}
+
+	if route != nil {
+		if limit, exists := route.UserLimits[identifier]; exists {
+			return limit, burst
+		}
+	}
+	if config.Overrides != nil {
+		if limit, overrideBurst, ok := config.Overrides.GetOverride(identifier); ok {
+			return limit, overrideBurst
+		}
+	}
+	if limit, exists := config.UserLimits[identifier]; exists {
+		return limit, burst
+	}
+
+	if route != nil {
+		if limit, exists := route.RoleSpecificLimits[role]; exists {
+			return limit, burst
+		}
+		if route.DefaultRequestsPerMinute > 0 {
+			return route.DefaultRequestsPerMinute, burst
+		}
+	}
+	limit := config.DefaultRequestsPerMinute
+	if roleLimit, exists := config.RoleSpecificLimits[role]; exists {
+		limit = roleLimit
+	}
+	return limit, burst
+}
+
 // min returns the minimum of two numbers
 func min(a, b float64) float64 {
 	if a < b {
@@ -0,0 +1,131 @@
+package enterprise
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aruncs31s/azf/infrastructure/metrics"
+	"github.com/aruncs31s/azf/shared/clock"
+	"go.uber.org/zap"
+)
+
+// TestInMemoryRateLimiterBoundsBuckets floods the limiter with distinct
+// identifiers, as a scan of random user IDs would, and checks that memory
+// stays at the cap while a busy caller keeps its bucket
+func TestInMemoryRateLimiterBoundsBuckets(t *testing.T) {
+	const maxBuckets = 1000
+	registry := metrics.NewRegistry()
+	limiter := NewInMemoryRateLimiter(&RateLimitConfig{
+		DefaultRequestsPerMinute: 5,
+		WindowDuration:           time.Minute,
+		MaxBuckets:               maxBuckets,
+		Clock:                    clock.NewFake(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)),
+	}, zap.NewNop())
+	defer limiter.Stop()
+	limiter.SetMetrics(NewRateLimiterMetrics(registry))
+	ctx := context.Background()
+
+	const requests = 200000
+	for i := 0; i < requests; i++ {
+		identifier := fmt.Sprintf("scan-%d", i)
+		if i%10 == 0 {
+			identifier = "busy"
+		}
+		if _, err := limiter.CheckLimit(ctx, identifier, "user"); err != nil {
+			t.Fatalf("CheckLimit() error = %v", err)
+		}
+	}
+
+	stats := limiter.BucketStats()
+	if stats.Buckets != maxBuckets {
+		t.Errorf("expected %d buckets, got %d", maxBuckets, stats.Buckets)
+	}
+	if want := int64(requests - requests/10 + 1 - maxBuckets); stats.Evictions != want {
+		t.Errorf("expected %d evictions, got %d", want, stats.Evictions)
+	}
+	if limiter.lru.Len() != len(limiter.buckets) {
+		t.Errorf("LRU list holds %d keys for %d buckets", limiter.lru.Len(), len(limiter.buckets))
+	}
+
+	// The busy caller was never evicted, so it is still over its limit
+	result, err := limiter.CheckLimit(ctx, "busy", "user")
+	if err != nil {
+		t.Fatalf("CheckLimit() error = %v", err)
+	}
+	if result.Allowed {
+		t.Error("expected the busy caller to stay rate limited")
+	}
+
+	exposition := httptest.NewRecorder()
+	registry.ServeHTTP(exposition, nil)
+	for _, line := range []string{
+		fmt.Sprintf("azf_rate_limit_buckets %d", maxBuckets),
+		fmt.Sprintf(`azf_rate_limit_bucket_evictions_total{reason="lru"} %d`, stats.Evictions),
+	} {
+		if !strings.Contains(exposition.Body.String(), line) {
+			t.Errorf("expected metrics to contain %q, got:\n%s", line, exposition.Body.String())
+		}
+	}
+}
+
+// TestInMemoryRateLimiterBlocks checks that blocks reject requests whatever
+// tokens are left, that repeated rejections trigger an auto-ban and that the
+// time left on a block is reported
+func TestInMemoryRateLimiterBlocks(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	limiter := NewInMemoryRateLimiter(&RateLimitConfig{
+		DefaultRequestsPerMinute: 2,
+		WindowDuration:           time.Minute,
+		AutoBanThreshold:         3,
+		AutoBanDuration:          10 * time.Minute,
+		Clock:                    fake,
+	}, zap.NewNop())
+	defer limiter.Stop()
+	ctx := context.Background()
+
+	if err := limiter.TemporaryBlock(ctx, "manual", 5*time.Minute); err != nil {
+		t.Fatalf("TemporaryBlock() error = %v", err)
+	}
+	result, err := limiter.CheckLimit(ctx, "manual", "user")
+	if err != nil {
+		t.Fatalf("CheckLimit() error = %v", err)
+	}
+	if result.Allowed || result.RetryAfterSeconds != 300 {
+		t.Errorf("expected a block with 300s left, got allowed=%v retry=%d", result.Allowed, result.RetryAfterSeconds)
+	}
+	if err := limiter.TemporaryBlock(ctx, "manual", 0); err != nil {
+		t.Fatalf("TemporaryBlock() error = %v", err)
+	}
+	if result, _ := limiter.CheckLimit(ctx, "manual", "user"); !result.Allowed {
+		t.Error("expected a lifted block to allow requests")
+	}
+
+	// Two requests pass, then three rejections ban the caller
+	for i := 0; i < 5; i++ {
+		if _, err := limiter.CheckLimit(ctx, "noisy", "user"); err != nil {
+			t.Fatalf("CheckLimit() error = %v", err)
+		}
+	}
+	fake.Advance(2 * time.Minute)
+	result, _ = limiter.CheckLimit(ctx, "noisy", "user")
+	if result.Allowed || result.BlockedUntil.IsZero() {
+		t.Fatal("expected the refilled caller to stay banned")
+	}
+
+	stats, err := limiter.GetStats(ctx, "noisy")
+	if err != nil {
+		t.Fatalf("GetStats() error = %v", err)
+	}
+	if stats["blocked"] != true || stats["block_remaining_seconds"] != 480 {
+		t.Errorf("expected a block with 480s left, got %v", stats)
+	}
+
+	fake.Advance(8 * time.Minute)
+	if result, _ := limiter.CheckLimit(ctx, "noisy", "user"); !result.Allowed {
+		t.Error("expected the ban to end")
+	}
+}
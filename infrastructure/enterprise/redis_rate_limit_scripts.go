@@ -0,0 +1,138 @@
+package enterprise
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RateLimitAlgorithm selects how RedisRateLimiter counts requests. The
+// in-memory limiter always uses a token bucket.
+type RateLimitAlgorithm string
+
+const (
+	// RateLimitFixedWindow counts requests in windows aligned to their first
+	// request; up to twice the limit can pass around a window boundary
+	RateLimitFixedWindow RateLimitAlgorithm = "fixed_window"
+	// RateLimitSlidingWindowLog keeps a timestamp per request and counts
+	// those in the last window. It is exact, but stores one entry per token.
+	RateLimitSlidingWindowLog RateLimitAlgorithm = "sliding_window_log"
+	// RateLimitSlidingWindowCounter estimates the last window's count from
+	// the current and previous window counters
+	RateLimitSlidingWindowCounter RateLimitAlgorithm = "sliding_window_counter"
+	// RateLimitLeakyBucket drains limit requests per window from a bucket
+	// holding limit plus burst, smoothing bursts into a steady rate
+	RateLimitLeakyBucket RateLimitAlgorithm = "leaky_bucket"
+)
+
+// ParseRateLimitAlgorithm returns the algorithm named s; empty selects the
+// fixed window
+func ParseRateLimitAlgorithm(s string) (RateLimitAlgorithm, error) {
+	algorithm := RateLimitAlgorithm(strings.ToLower(strings.TrimSpace(s)))
+	if algorithm == "" {
+		return RateLimitFixedWindow, nil
+	}
+	if _, ok := rateLimitScripts[algorithm]; !ok {
+		return "", fmt.Errorf("unknown rate limit algorithm %q", s)
+	}
+	return algorithm, nil
+}
+
+// The scripts take the key, then limit, cost, window in milliseconds, burst
+// and a member unique to the request. They return whether the request was
+// allowed, the usage after it, and the milliseconds until it may be retried
+// and until usage resets. Time is read from Redis so limiter instances with
+// skewed clocks agree.
+const rateLimitScriptPrelude = `
+if redis.replicate_commands then redis.replicate_commands() end
+local key = KEYS[1]
+local limit = tonumber(ARGV[1])
+local cost = tonumber(ARGV[2])
+local window = tonumber(ARGV[3])
+local burst = tonumber(ARGV[4])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+`
+
+var rateLimitScripts = map[RateLimitAlgorithm]*redis.Script{
+	RateLimitFixedWindow: redis.NewScript(rateLimitScriptPrelude + `
+local count = tonumber(redis.call('GET', key) or '0')
+local ttl = redis.call('PTTL', key)
+if ttl < 0 then ttl = window end
+if count + cost > limit then
+	return {0, count, ttl, ttl}
+end
+count = redis.call('INCRBY', key, cost)
+if count == cost then redis.call('PEXPIRE', key, window) end
+return {1, count, 0, ttl}
+`),
+
+	RateLimitSlidingWindowLog: redis.NewScript(rateLimitScriptPrelude + `
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+local count = redis.call('ZCARD', key)
+if count + cost > limit then
+	local retry = window
+	local freed = count + cost - limit
+	if freed <= count then
+		local entry = redis.call('ZRANGE', key, freed - 1, freed - 1, 'WITHSCORES')
+		retry = tonumber(entry[2]) + window - now
+	end
+	return {0, count, retry, retry}
+end
+for i = 1, cost do
+	redis.call('ZADD', key, now, ARGV[5] .. ':' .. i)
+end
+redis.call('PEXPIRE', key, window)
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+return {1, count + cost, 0, tonumber(oldest[2]) + window - now}
+`),
+
+	RateLimitSlidingWindowCounter: redis.NewScript(rateLimitScriptPrelude + `
+local index = math.floor(now / window)
+local state = redis.call('HMGET', key, 'window', 'current', 'previous')
+local last = tonumber(state[1])
+local current = tonumber(state[2]) or 0
+local previous = tonumber(state[3]) or 0
+if last == index - 1 then
+	previous = current
+	current = 0
+elseif last ~= index then
+	previous = 0
+	current = 0
+end
+local elapsed = (now - index * window) / window
+local untilNext = (index + 1) * window - now
+local estimate = previous * (1 - elapsed) + current
+if estimate + cost > limit then
+	local retry = untilNext
+	if current + cost <= limit and previous > 0 then
+		retry = math.ceil((1 - (limit - current - cost) / previous - elapsed) * window)
+	end
+	return {0, math.floor(estimate), retry, untilNext}
+end
+redis.call('HSET', key, 'window', index, 'current', current + cost, 'previous', previous)
+redis.call('PEXPIRE', key, 2 * window)
+return {1, math.ceil(estimate + cost), 0, untilNext}
+`),
+
+	RateLimitLeakyBucket: redis.NewScript(rateLimitScriptPrelude + `
+local capacity = limit + burst
+local rate = limit / window
+local state = redis.call('HMGET', key, 'level', 'last')
+local level = tonumber(state[1]) or 0
+local last = tonumber(state[2]) or now
+level = math.max(0, level - math.max(0, now - last) * rate)
+if level + cost > capacity then
+	local retry = window
+	if rate > 0 then retry = math.ceil((level + cost - capacity) / rate) end
+	return {0, math.ceil(level), retry, retry}
+end
+level = level + cost
+local drained = window
+if rate > 0 then drained = math.ceil(level / rate) end
+redis.call('HSET', key, 'level', tostring(level), 'last', now)
+redis.call('PEXPIRE', key, drained + 1000)
+return {1, math.ceil(level), 0, drained}
+`),
+}
@@ -0,0 +1,276 @@
+package enterprise
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RouteAnnotationPrefix starts the doc comment lines declaring route metadata
+// on a handler function:
+//
+//	// GetProfile returns the profile of the signed-in staff member
+//	//
+//	// @azf:route GET /api/v1/staff/profile
+//	// @azf:roles staff admin
+//	// @azf:tags staff
+//	// @azf:rate-limit 60 10
+//	func (h *StaffHandler) GetProfile(c *gin.Context) { ... }
+//
+// Directives: route METHOD PATH (repeatable), roles, public, description,
+// version, tags, scopes, audit, ownership, deprecated REPLACED_BY [REASON],
+// rate-limit PER_MINUTE [BURST], role-rate-limit ROLE PER_MINUTE,
+// user-rate-limit USER_ID PER_MINUTE, rate-limit-weight TOKENS,
+// max-body BYTES and condition EXPR. The description defaults to the first
+// sentence of the doc comment and the version to the path's /vN/ segment.
+const RouteAnnotationPrefix = "@azf:"
+
+// ExtractRouteAnnotations parses the Go files under dir, skipping tests and
+// vendor and testdata directories, and returns the metadata declared on
+// handler functions, in file and declaration order
+func ExtractRouteAnnotations(dir string) ([]*RouteMetadata, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != dir && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(path, ".go") && !strings.HasSuffix(path, "_test.go") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Go files in %s: %w", dir, err)
+	}
+	sort.Strings(files)
+
+	var routes []*RouteMetadata
+	declared := make(map[string]string)
+	fset := token.NewFileSet()
+	for _, file := range files {
+		parsed, err := parser.ParseFile(fset, file, nil, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+		for _, decl := range parsed.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Doc == nil {
+				continue
+			}
+			declaredRoutes, err := parseRouteAnnotations(fn.Name.Name, fn.Doc)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", fset.Position(fn.Pos()), fn.Name.Name, err)
+			}
+			for _, route := range declaredRoutes {
+				key := route.Method + ":" + route.Path
+				if previous, exists := declared[key]; exists {
+					return nil, fmt.Errorf("%s: route %s already declared on %s", fset.Position(fn.Pos()), key, previous)
+				}
+				declared[key] = fn.Name.Name
+				routes = append(routes, route)
+			}
+		}
+	}
+	return routes, nil
+}
+
+// parseRouteAnnotations returns the routes declared in the doc comment of
+// function name, or nil when it has no annotations
+func parseRouteAnnotations(name string, doc *ast.CommentGroup) ([]*RouteMetadata, error) {
+	var summary []string
+	var paths [][2]string
+	metadata := &RouteMetadata{}
+	annotated := false
+	versionSet := false
+
+	for _, line := range strings.Split(doc.Text(), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, RouteAnnotationPrefix) {
+			if !annotated && line != "" {
+				summary = append(summary, line)
+			}
+			continue
+		}
+		annotated = true
+
+		directive, args, _ := strings.Cut(strings.TrimPrefix(line, RouteAnnotationPrefix), " ")
+		args = strings.TrimSpace(args)
+		fields := strings.FieldsFunc(args, func(r rune) bool { return r == ' ' || r == ',' })
+
+		switch directive {
+		case "route":
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("@azf:route needs a method and a path")
+			}
+			paths = append(paths, [2]string{strings.ToUpper(fields[0]), fields[1]})
+		case "roles":
+			metadata.AllowedRoles = append(metadata.AllowedRoles, fields...)
+		case "public":
+			metadata.IsPublic = true
+		case "description":
+			metadata.Description = args
+		case "version":
+			metadata.APIVersion = args
+			versionSet = true
+		case "tags":
+			metadata.Tags = append(metadata.Tags, fields...)
+		case "scopes":
+			metadata.RequiredScopes = append(metadata.RequiredScopes, fields...)
+		case "audit":
+			metadata.AuditRequired = true
+		case "ownership":
+			metadata.OwnershipCheck = true
+		case "deprecated":
+			replacedBy, reason, _ := strings.Cut(args, " ")
+			metadata.Deprecated = true
+			metadata.ReplacedBy = replacedBy
+			metadata.DeprecatedReason = strings.TrimSpace(reason)
+		case "rate-limit":
+			if len(fields) < 1 || len(fields) > 2 {
+				return nil, fmt.Errorf("@azf:rate-limit needs requests per minute and an optional burst")
+			}
+			limits := annotatedRateLimit(metadata)
+			perMinute, err := strconv.Atoi(fields[0])
+			if err != nil {
+				return nil, fmt.Errorf("invalid @azf:rate-limit %q", args)
+			}
+			limits.DefaultRequestsPerMinute = perMinute
+			if len(fields) == 2 {
+				if limits.BurstAllowance, err = strconv.Atoi(fields[1]); err != nil {
+					return nil, fmt.Errorf("invalid @azf:rate-limit burst %q", fields[1])
+				}
+			}
+		case "role-rate-limit":
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("@azf:role-rate-limit needs a role and requests per minute")
+			}
+			perMinute, err := strconv.Atoi(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid @azf:role-rate-limit %q", args)
+			}
+			limits := annotatedRateLimit(metadata)
+			if limits.RoleSpecificLimits == nil {
+				limits.RoleSpecificLimits = make(map[string]int)
+			}
+			limits.RoleSpecificLimits[fields[0]] = perMinute
+		case "user-rate-limit":
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("@azf:user-rate-limit needs a user ID and requests per minute")
+			}
+			perMinute, err := strconv.Atoi(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid @azf:user-rate-limit %q", args)
+			}
+			limits := annotatedRateLimit(metadata)
+			if limits.UserLimits == nil {
+				limits.UserLimits = make(map[string]int)
+			}
+			limits.UserLimits[fields[0]] = perMinute
+		case "rate-limit-weight":
+			weight, err := strconv.Atoi(args)
+			if err != nil {
+				return nil, fmt.Errorf("invalid @azf:rate-limit-weight %q", args)
+			}
+			annotatedRateLimit(metadata).Weight = weight
+		case "max-body":
+			limit, err := strconv.ParseInt(args, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid @azf:max-body %q", args)
+			}
+			metadata.MaxBodyBytes = limit
+		case "condition":
+			metadata.Condition = args
+		default:
+			return nil, fmt.Errorf("unknown annotation %s%s", RouteAnnotationPrefix, directive)
+		}
+	}
+
+	if !annotated {
+		return nil, nil
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("route annotations need an @azf:route line")
+	}
+	if metadata.Description == "" {
+		metadata.Description = docSummary(name, summary)
+	}
+
+	routes := make([]*RouteMetadata, 0, len(paths))
+	for _, path := range paths {
+		route := *metadata
+		route.Method = path[0]
+		route.Path = path[1]
+		if !versionSet {
+			route.APIVersion = routeAPIVersion(route.Path)
+		}
+		if err := route.Validate(); err != nil {
+			return nil, err
+		}
+		routes = append(routes, &route)
+	}
+	return routes, nil
+}
+
+// MergeRouteMetadata returns existing with the routes of declared replacing
+// those with the same method and path; new routes are appended in order
+func MergeRouteMetadata(existing, declared []*RouteMetadata) []*RouteMetadata {
+	byKey := make(map[string]*RouteMetadata, len(declared))
+	for _, route := range declared {
+		byKey[strings.ToUpper(route.Method)+":"+route.Path] = route
+	}
+
+	merged := make([]*RouteMetadata, 0, len(existing)+len(declared))
+	for _, route := range existing {
+		key := strings.ToUpper(route.Method) + ":" + route.Path
+		if replacement, ok := byKey[key]; ok {
+			merged = append(merged, replacement)
+			delete(byKey, key)
+			continue
+		}
+		merged = append(merged, route)
+	}
+	for _, route := range declared {
+		if _, pending := byKey[strings.ToUpper(route.Method)+":"+route.Path]; pending {
+			merged = append(merged, route)
+		}
+	}
+	return merged
+}
+
+// annotatedRateLimit returns the rate limit config of metadata, creating it when unset
+func annotatedRateLimit(metadata *RouteMetadata) *RateLimitConfig {
+	if metadata.RateLimit == nil {
+		metadata.RateLimit = &RateLimitConfig{}
+	}
+	return metadata.RateLimit
+}
+
+// docSummary returns the first sentence of a doc comment without the
+// leading function name
+func docSummary(name string, lines []string) string {
+	text := strings.Join(lines, " ")
+	if end := strings.Index(text, ". "); end >= 0 {
+		text = text[:end]
+	}
+	text = strings.TrimSuffix(text, ".")
+	if first, rest, ok := strings.Cut(text, " "); ok && first == name {
+		text = rest
+	}
+	if text == "" {
+		return ""
+	}
+	return strings.ToUpper(text[:1]) + text[1:]
+}
@@ -0,0 +1,318 @@
+package enterprise
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/aruncs31s/azf/application/middleware"
+	"github.com/aruncs31s/azf/initializer"
+	"github.com/casbin/casbin/v2"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultRouteAPIVersion is used for declared routes whose path has no version segment
+const defaultRouteAPIVersion = "v1"
+
+// RouteBuilder declares routes together with their metadata, so the gin
+// route, its RouteRegistry entry and optionally its Casbin policies come
+// from a single definition instead of router code plus the JSON metadata file.
+//
+//	routes := enterprise.NewRouteBuilder(r, registry, enforcer).WithPolicies()
+//	routes.GET("/api/v1/staff/profile", h.GetProfile).Roles("staff", "admin")
+//	routes.DELETE("/api/v1/staff/:id", h.Delete).Roles("admin").Audit()
+//	if err := routes.Register(); err != nil { ... }
+type RouteBuilder struct {
+	router       gin.IRouter
+	registry     *RouteRegistry
+	enforcer     *casbin.Enforcer
+	syncPolicies bool
+	routes       []*RouteDefinition
+}
+
+// RouteDefinition is a route declared on a RouteBuilder; its methods set
+// the route metadata and can be chained
+type RouteDefinition struct {
+	relativePath string
+	handlers     []gin.HandlerFunc
+	metadata     *RouteMetadata
+}
+
+// NewRouteBuilder creates a builder registering routes on router. Metadata
+// is registered in registry and policies in enforcer when they are not nil.
+func NewRouteBuilder(router gin.IRouter, registry *RouteRegistry, enforcer *casbin.Enforcer) *RouteBuilder {
+	return &RouteBuilder{
+		router:   router,
+		registry: registry,
+		enforcer: enforcer,
+	}
+}
+
+// WithPolicies makes Register also add a policy for every allowed role of
+// the declared routes
+func (b *RouteBuilder) WithPolicies() *RouteBuilder {
+	b.syncPolicies = true
+	return b
+}
+
+// GET declares a GET route
+func (b *RouteBuilder) GET(path string, handlers ...gin.HandlerFunc) *RouteDefinition {
+	return b.Handle(http.MethodGet, path, handlers...)
+}
+
+// POST declares a POST route
+func (b *RouteBuilder) POST(path string, handlers ...gin.HandlerFunc) *RouteDefinition {
+	return b.Handle(http.MethodPost, path, handlers...)
+}
+
+// PUT declares a PUT route
+func (b *RouteBuilder) PUT(path string, handlers ...gin.HandlerFunc) *RouteDefinition {
+	return b.Handle(http.MethodPut, path, handlers...)
+}
+
+// PATCH declares a PATCH route
+func (b *RouteBuilder) PATCH(path string, handlers ...gin.HandlerFunc) *RouteDefinition {
+	return b.Handle(http.MethodPatch, path, handlers...)
+}
+
+// DELETE declares a DELETE route
+func (b *RouteBuilder) DELETE(path string, handlers ...gin.HandlerFunc) *RouteDefinition {
+	return b.Handle(http.MethodDelete, path, handlers...)
+}
+
+// Handle declares a route for method. The path is relative to the router,
+// so routes declared on a gin group get the group prefix in their metadata.
+func (b *RouteBuilder) Handle(method, path string, handlers ...gin.HandlerFunc) *RouteDefinition {
+	fullPath := joinRoutePath(b.router, path)
+	route := &RouteDefinition{
+		relativePath: path,
+		handlers:     handlers,
+		metadata: &RouteMetadata{
+			Path:       fullPath,
+			Method:     strings.ToUpper(method),
+			APIVersion: routeAPIVersion(fullPath),
+		},
+	}
+	b.routes = append(b.routes, route)
+	return route
+}
+
+// Register validates the declared routes and registers them: metadata
+// first, then the gin routes, then the policies when WithPolicies was set.
+// Nothing is registered when a route is invalid or already registered.
+func (b *RouteBuilder) Register() error {
+	routes := b.routes
+	if len(routes) == 0 {
+		return nil
+	}
+
+	metadatas := make([]*RouteMetadata, 0, len(routes))
+	declared := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		if len(route.handlers) == 0 {
+			return fmt.Errorf("route %s %s has no handler", route.metadata.Method, route.metadata.Path)
+		}
+		if err := route.metadata.Validate(); err != nil {
+			return err
+		}
+		key := route.metadata.Method + ":" + route.metadata.Path
+		if declared[key] {
+			return fmt.Errorf("route declared twice: %s", key)
+		}
+		declared[key] = true
+		if b.registry != nil {
+			if _, exists := b.registry.Get(route.metadata.Path, route.metadata.Method); exists {
+				return fmt.Errorf("route already registered: %s", key)
+			}
+		}
+		metadatas = append(metadatas, route.metadata)
+	}
+
+	if b.registry != nil {
+		if err := b.registry.RegisterMany(metadatas...); err != nil {
+			return err
+		}
+	}
+	for _, route := range routes {
+		b.router.Handle(route.metadata.Method, route.relativePath, route.handlers...)
+	}
+	b.routes = nil
+
+	if !b.syncPolicies || b.enforcer == nil {
+		return nil
+	}
+	rules := RoutePolicies(metadatas)
+	if len(rules) == 0 {
+		return nil
+	}
+	err := initializer.WithPolicyTransaction(b.enforcer, func(tx *initializer.PolicyTransaction) error {
+		for _, rule := range rules {
+			if _, err := tx.AddPolicy(rule); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add policies for declared routes: %w", err)
+	}
+	return nil
+}
+
+// Roles sets the roles allowed to call the route
+func (r *RouteDefinition) Roles(roles ...string) *RouteDefinition {
+	r.metadata.AllowedRoles = append(r.metadata.AllowedRoles, roles...)
+	return r
+}
+
+// Public marks the route as not requiring authentication
+func (r *RouteDefinition) Public() *RouteDefinition {
+	r.metadata.IsPublic = true
+	return r
+}
+
+// Describe sets the route description
+func (r *RouteDefinition) Describe(description string) *RouteDefinition {
+	r.metadata.Description = description
+	return r
+}
+
+// Version sets the API version; by default it is taken from a /vN/ path
+// segment, or v1 when the path has none
+func (r *RouteDefinition) Version(version string) *RouteDefinition {
+	r.metadata.APIVersion = version
+	return r
+}
+
+// Deprecated marks the route as deprecated in favour of replacedBy
+func (r *RouteDefinition) Deprecated(reason, replacedBy string) *RouteDefinition {
+	r.metadata.Deprecated = true
+	r.metadata.DeprecatedReason = reason
+	r.metadata.ReplacedBy = replacedBy
+	return r
+}
+
+// RateLimit sets the requests per minute and burst allowed on the route
+func (r *RouteDefinition) RateLimit(requestsPerMinute, burst int) *RouteDefinition {
+	limits := r.rateLimit()
+	limits.DefaultRequestsPerMinute = requestsPerMinute
+	limits.BurstAllowance = burst
+	return r
+}
+
+// RoleRateLimit sets the requests per minute allowed on the route for role
+func (r *RouteDefinition) RoleRateLimit(role string, requestsPerMinute int) *RouteDefinition {
+	limits := r.rateLimit()
+	if limits.RoleSpecificLimits == nil {
+		limits.RoleSpecificLimits = make(map[string]int)
+	}
+	limits.RoleSpecificLimits[role] = requestsPerMinute
+	return r
+}
+
+// Scopes sets the OAuth scopes the route requires
+func (r *RouteDefinition) Scopes(scopes ...string) *RouteDefinition {
+	r.metadata.RequiredScopes = append(r.metadata.RequiredScopes, scopes...)
+	return r
+}
+
+// Tags adds grouping tags to the route
+func (r *RouteDefinition) Tags(tags ...string) *RouteDefinition {
+	r.metadata.Tags = append(r.metadata.Tags, tags...)
+	return r
+}
+
+// CORS overrides the global CORS policy for the route, e.g. to allow the
+// origin of a partner's SPA
+func (r *RouteDefinition) CORS(cors RouteCORSConfig) *RouteDefinition {
+	r.metadata.CORS = &cors
+	return r
+}
+
+// WithoutResponseMeta keeps "meta" out of the route's JSON responses, for
+// responses that must match an external schema
+func (r *RouteDefinition) WithoutResponseMeta() *RouteDefinition {
+	r.handlers = append([]gin.HandlerFunc{middleware.WithoutResponseMeta()}, r.handlers...)
+	return r
+}
+
+// Audit marks the route's requests as requiring an audit log entry
+func (r *RouteDefinition) Audit() *RouteDefinition {
+	r.metadata.AuditRequired = true
+	return r
+}
+
+// OwnershipCheck marks the route as validating record ownership
+func (r *RouteDefinition) OwnershipCheck() *RouteDefinition {
+	r.metadata.OwnershipCheck = true
+	return r
+}
+
+// MaxBodyBytes rejects request bodies larger than limit with 413
+func (r *RouteDefinition) MaxBodyBytes(limit int64) *RouteDefinition {
+	r.metadata.MaxBodyBytes = limit
+	return r
+}
+
+// Condition sets the attribute expression that must also hold under the ABAC model
+func (r *RouteDefinition) Condition(expression string) *RouteDefinition {
+	r.metadata.Condition = expression
+	return r
+}
+
+// Metadata returns the metadata the route will be registered with
+func (r *RouteDefinition) Metadata() *RouteMetadata {
+	return r.metadata
+}
+
+// UserRateLimit sets the requests per minute allowed on the route for userID,
+// ahead of its role limits
+func (r *RouteDefinition) UserRateLimit(userID string, requestsPerMinute int) *RouteDefinition {
+	limits := r.rateLimit()
+	if limits.UserLimits == nil {
+		limits.UserLimits = make(map[string]int)
+	}
+	limits.UserLimits[userID] = requestsPerMinute
+	return r
+}
+
+// RateLimitWeight makes each request to the route consume weight tokens of
+// the caller's limit, for expensive endpoints
+func (r *RouteDefinition) RateLimitWeight(weight int) *RouteDefinition {
+	r.rateLimit().Weight = weight
+	return r
+}
+
+// rateLimit returns the route's rate limit config, creating it when unset
+func (r *RouteDefinition) rateLimit() *RateLimitConfig {
+	if r.metadata.RateLimit == nil {
+		r.metadata.RateLimit = &RateLimitConfig{}
+	}
+	return r.metadata.RateLimit
+}
+
+// joinRoutePath prefixes path with the base path of router when it is a gin group
+func joinRoutePath(router gin.IRouter, path string) string {
+	group, ok := router.(interface{ BasePath() string })
+	if !ok {
+		return path
+	}
+	base := strings.TrimSuffix(group.BasePath(), "/")
+	if path == "" {
+		if base == "" {
+			return "/"
+		}
+		return base
+	}
+	return base + "/" + strings.TrimPrefix(path, "/")
+}
+
+// routeAPIVersion returns the first vN segment of path, or defaultRouteAPIVersion
+func routeAPIVersion(path string) string {
+	for _, segment := range strings.Split(path, "/") {
+		if len(segment) > 1 && segment[0] == 'v' && isNumericSegment(segment[1:]) {
+			return segment
+		}
+	}
+	return defaultRouteAPIVersion
+}
@@ -0,0 +1,67 @@
+package enterprise
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+
+	"github.com/aruncs31s/azf/application/middleware"
+	"github.com/aruncs31s/azf/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// RouteCORSConfig overrides the global CORS policy for a route; unset fields
+// keep the global values
+type RouteCORSConfig struct {
+	AllowedOrigins   []string `json:"allowed_origins,omitempty"`
+	AllowedHeaders   []string `json:"allowed_headers,omitempty"`
+	AllowCredentials *bool    `json:"allow_credentials,omitempty"`
+}
+
+// Validate checks that origins are "*" or scheme://host[:port], and that
+// credentials are not allowed for every origin
+func (rc *RouteCORSConfig) Validate() error {
+	for _, origin := range rc.AllowedOrigins {
+		if origin == "*" {
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || u.Scheme == "" || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			return fmt.Errorf("invalid CORS origin %q, expected scheme://host[:port]", origin)
+		}
+	}
+	if rc.AllowCredentials != nil && *rc.AllowCredentials && slices.Contains(rc.AllowedOrigins, "*") {
+		return fmt.Errorf("CORS credentials cannot be allowed for every origin")
+	}
+	return nil
+}
+
+// Apply returns base with the route's overrides
+func (rc *RouteCORSConfig) Apply(base *middleware.CORSConfig) *middleware.CORSConfig {
+	policy := *base
+	if len(rc.AllowedOrigins) > 0 {
+		policy.AllowedOrigins = rc.AllowedOrigins
+	}
+	if len(rc.AllowedHeaders) > 0 {
+		policy.AllowedHeaders = rc.AllowedHeaders
+	}
+	if rc.AllowCredentials != nil {
+		policy.AllowCredentials = *rc.AllowCredentials
+	}
+	return &policy
+}
+
+// CORSResolver returns a resolver applying the CORS overrides in the
+// registry's route metadata to base
+func (rr *RouteRegistry) CORSResolver(base *middleware.CORSConfig) middleware.CORSResolver {
+	return func(c *gin.Context, method string) *middleware.CORSConfig {
+		if method == "" {
+			return nil
+		}
+		metadata, exists := rr.Get(utils.NormalizePathForLookup(c.Request.URL.Path), method)
+		if !exists || metadata.CORS == nil {
+			return nil
+		}
+		return metadata.CORS.Apply(base)
+	}
+}
@@ -3,6 +3,8 @@ package enterprise
 import (
 	"fmt"
 	"strings"
+
+	"github.com/aruncs31s/azf/infrastructure/abac"
 )
 
 // RouteMetadata contains metadata for a route that can be used to auto-generate policies
@@ -21,6 +23,12 @@ type RouteMetadata struct {
 	OwnershipCheck   bool             `json:"ownership_check"` // true if record ownership should be validated
 	AuditRequired    bool             `json:"audit_required"`  // true if action should be logged
 	Tags             []string         `json:"tags"`            // Grouping tags
+	MaxBodyBytes     int64            `json:"max_body_bytes"`  // Reject larger request bodies with 413; 0 means no limit
+	// Condition is an attribute expression that must also hold when the
+	// enforcer uses the ABAC model, e.g. "owner_id == user_id"
+	Condition string `json:"condition,omitempty"`
+	// CORS overrides the global CORS policy for the route
+	CORS *RouteCORSConfig `json:"cors,omitempty"`
 }
 
 // }
@@ -54,6 +62,26 @@ func (rm *RouteMetadata) Validate() error {
 		return fmt.Errorf("api_version is required for route %s %s", rm.Method, rm.Path)
 	}
 
+	if rm.MaxBodyBytes < 0 {
+		return fmt.Errorf("max_body_bytes cannot be negative for route %s %s", rm.Method, rm.Path)
+	}
+
+	if err := abac.ValidateCondition(rm.Condition); err != nil {
+		return fmt.Errorf("invalid condition for route %s %s: %w", rm.Method, rm.Path, err)
+	}
+
+	if rm.RateLimit != nil {
+		if err := rm.RateLimit.Validate(); err != nil {
+			return fmt.Errorf("invalid rate_limit for route %s %s: %w", rm.Method, rm.Path, err)
+		}
+	}
+
+	if rm.CORS != nil {
+		if err := rm.CORS.Validate(); err != nil {
+			return fmt.Errorf("invalid cors for route %s %s: %w", rm.Method, rm.Path, err)
+		}
+	}
+
 	return nil
 }
 
@@ -272,10 +300,14 @@ func (rm *RouteMetadata) ToSwaggerInfo() map[string]interface{} {
 	}
 
 	if rm.RateLimit != nil {
-		info["x-rate-limit"] = map[string]interface{}{
+		rateLimit := map[string]interface{}{
 			"default": rm.RateLimit.DefaultRequestsPerMinute,
 			"burst":   rm.RateLimit.BurstAllowance,
 		}
+		if rm.RateLimit.Weight > 1 {
+			rateLimit["weight"] = rm.RateLimit.Weight
+		}
+		info["x-rate-limit"] = rateLimit
 	}
 
 	return info
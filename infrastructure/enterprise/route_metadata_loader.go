@@ -2,13 +2,13 @@ package enterprise
 
 import (
 	"encoding/json"
-	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/aruncs31s/azf/config"
 	"github.com/aruncs31s/azf/shared/logger"
+	"github.com/aruncs31s/azf/utils"
 	"go.uber.org/zap"
 )
 
@@ -101,6 +101,21 @@ func SaveEnterpriseRouteMetadata(routes []*RouteMetadata, configPath string) err
 	return nil
 }
 
+// RoutePolicies returns the policy rules granting the allowed roles of each
+// non-public route: role, path, method
+func RoutePolicies(routes []*RouteMetadata) [][]string {
+	var rules [][]string
+	for _, route := range routes {
+		if route.IsPublic {
+			continue
+		}
+		for _, role := range route.AllowedRoles {
+			rules = append(rules, []string{role, route.Path, route.Method})
+		}
+	}
+	return rules
+}
+
 // UpdateCasbinPoliciesFromRoutes updates the Casbin policy CSV file based on route metadata
 func UpdateCasbinPoliciesFromRoutes(routes []*RouteMetadata, policyFilePath string) error {
 	if policyFilePath == "" {
@@ -123,21 +138,15 @@ func UpdateCasbinPoliciesFromRoutes(routes []*RouteMetadata, policyFilePath stri
 
 	// Generate new policies from routes
 	newPolicies := ""
-	for _, route := range routes {
-		if !route.IsPublic && len(route.AllowedRoles) > 0 {
-			for _, role := range route.AllowedRoles {
-				// Create policy: p, role, path, method
-				policy := fmt.Sprintf("p, %s, %s, %s\n", role, route.Path, route.Method)
-				newPolicies += policy
-			}
-		}
+	for _, rule := range RoutePolicies(routes) {
+		newPolicies += "p, " + strings.Join(rule, ", ") + "\n"
 	}
 
 	// Combine existing role assignments with new policies
 	fullContent := existingPolicies + newPolicies
 
 	// Write back to file
-	if err := os.WriteFile(policyFilePath, []byte(fullContent), 0644); err != nil {
+	if err := utils.WriteFileAtomic(policyFilePath, []byte(fullContent), 0644); err != nil {
 		logger.Error("Failed to update Casbin policy file",
 			zap.String("path", policyFilePath),
 			zap.Error(err))
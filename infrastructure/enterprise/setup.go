@@ -2,11 +2,19 @@ package enterprise
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"time"
 
 	"github.com/aruncs31s/azf/config"
+	"github.com/aruncs31s/azf/domain/model"
+	"github.com/aruncs31s/azf/infrastructure/metrics"
+	"github.com/aruncs31s/azf/infrastructure/tracing"
+	"github.com/aruncs31s/azf/initializer"
+	"github.com/aruncs31s/azf/internal/persistence"
+	"github.com/aruncs31s/azf/shared/clock"
+	"github.com/aruncs31s/azf/shared/ids"
 	"github.com/aruncs31s/azf/shared/logger"
 	"github.com/casbin/casbin/v2"
 	"github.com/redis/go-redis/v9"
@@ -24,6 +32,7 @@ type EnterpriseAuthorizationSetup struct {
 	rateLimiter     RateLimiter
 	auditRepository *AuthorizationAuditRepository
 	middleware      *AZFAuthMiddleware
+	policyWatcher   *RedisPolicyWatcher
 }
 
 // SetupOptions holds all options for enterprise authorization setup
@@ -37,9 +46,19 @@ type SetupOptions struct {
 	// Policy file path
 	PolicyFilePath string
 
+	// PolicyStorage selects where Casbin policies are kept when no
+	// CasbinEnforcer is given: config.CASBIN_POLICY_STORAGE_DATABASE creates
+	// an enforcer over Database, seeded from PolicyFilePath
+	PolicyStorage string
+
 	// Environment (developement, staging, production)
 	Environment string
 
+	// Policy watcher (optional): announce saved policy changes over Redis so
+	// every instance sharing the policy storage reloads them
+	EnablePolicyWatcher  bool
+	PolicyWatcherChannel string
+
 	// Rate limiting configuration
 	EnableRateLimit   bool
 	RateLimitConfig   *RateLimitConfig
@@ -49,6 +68,11 @@ type SetupOptions struct {
 	EnableAuditLogging bool
 	AuditBatchSize     int
 	AuditFlushInterval time.Duration
+	AuditDedupWindow   time.Duration // Collapse identical denials within this window (0 = off)
+
+	// Alert when this many requests are denied within DenialStormWindow (0 = off)
+	DenialStormThreshold int
+	DenialStormWindow    time.Duration
 
 	// Authorization configuration
 	EnableDeprecationCheck bool
@@ -58,6 +82,21 @@ type SetupOptions struct {
 
 	// Casbin enforcer instance (optional)
 	CasbinEnforcer *casbin.Enforcer
+	// AttributeExtractor supplies request attributes for route conditions
+	// under the ABAC model (optional)
+	AttributeExtractor AttributeExtractor
+	// Metrics registry the middleware records decisions and latencies in
+	// (optional, nil disables authorization metrics)
+	Metrics *metrics.Registry
+	// TracerProvider records spans for authorization checks, database calls
+	// and audit flushes (optional)
+	TracerProvider tracing.TracerProvider
+	// Clock is the time source of rate limits and audit logs (optional,
+	// defaults to clock.Default())
+	Clock clock.Clock
+	// IDGenerator creates audit log and alert IDs (optional, defaults to
+	// ids.Default())
+	IDGenerator ids.Generator
 	// Logger instance
 	Logger *zap.Logger
 }
@@ -87,6 +126,21 @@ func NewEnterpriseAuthorizationSetup(opts *SetupOptions) (*EnterpriseAuthorizati
 		}
 	}
 
+	if opts.TracerProvider != nil {
+		tracing.SetTracerProvider(opts.TracerProvider)
+		if err := opts.Database.Use(tracing.GormPlugin()); err != nil && !errors.Is(err, gorm.ErrRegistered) {
+			return nil, getFailedToInitializeErr("database tracing", err)
+		}
+	}
+
+	if opts.CasbinEnforcer == nil && opts.PolicyStorage == config.CASBIN_POLICY_STORAGE_DATABASE {
+		enforcer, err := initializer.NewDatabaseEnforcer(opts.Database, config.CasbinModelFile(), opts.PolicyFilePath)
+		if err != nil {
+			return nil, getFailedToInitializeErr("casbin enforcer", err)
+		}
+		opts.CasbinEnforcer = enforcer
+	}
+
 	setup := &EnterpriseAuthorizationSetup{
 		db:     opts.Database,
 		redis:  opts.Redis,
@@ -114,6 +168,10 @@ func NewEnterpriseAuthorizationSetup(opts *SetupOptions) (*EnterpriseAuthorizati
 		return nil, getFailedToInitializeErr("middleware", err)
 	}
 
+	if err := setup.initializePolicyWatcher(opts); err != nil {
+		return nil, getFailedToInitializeErr("policy watcher", err)
+	}
+
 	setup.logger.Info("Enterprise authorization setup completed",
 		zap.String("environment", opts.Environment),
 		zap.Bool("audit_logging", opts.EnableAuditLogging),
@@ -176,6 +234,32 @@ func (eas *EnterpriseAuthorizationSetup) initializeAuditRepository() error {
 		eas.logger.Info("Created authorization_audit_logs table")
 	}
 
+	// Add the rollup, custom field and mode columns to tables created before them
+	for _, column := range []string{"OccurrenceCount", "LastSeenAt", "Metadata", "AuthMode"} {
+		if eas.db.Migrator().HasColumn(&AuthorizationAuditLogDB{}, column) {
+			continue
+		}
+		if err := eas.db.Migrator().AddColumn(&AuthorizationAuditLogDB{}, column); err != nil {
+			return fmt.Errorf("failed to add %s to authorization audit log table: %w", column, err)
+		}
+	}
+
+	if err := eas.migrateAuditRequestColumns(); err != nil {
+		return err
+	}
+
+	// Encoded audit text columns reference the shared text dictionary
+	if err := eas.db.AutoMigrate(&persistence.TextDictionaryEntry{}); err != nil {
+		return fmt.Errorf("failed to migrate text dictionary table: %w", err)
+	}
+
+	// Partition the audit log by time when running on Timescale
+	if config.TimescaleEnabled() {
+		if err := persistence.SetupTimescale(eas.db); err != nil {
+			eas.logger.Warn("Failed to set up Timescale for audit logs", zap.Error(err))
+		}
+	}
+
 	// Get count of existing logs
 	count, err := eas.auditRepository.Count(context.Background())
 	if err != nil {
@@ -187,15 +271,60 @@ func (eas *EnterpriseAuthorizationSetup) initializeAuditRepository() error {
 	return nil
 }
 
-// initializeRateLimiter sets up the rate limiter
+// migrateAuditRequestColumns upgrades audit tables created before request IDs
+// and error messages were recorded: request_id is widened and indexed, and the
+// empty strings written by earlier versions become NULL so they read as unknown
+func (eas *EnterpriseAuthorizationSetup) migrateAuditRequestColumns() error {
+	migrator := eas.db.Migrator()
+	if migrator.HasIndex(&AuthorizationAuditLogDB{}, "RequestID") {
+		return nil
+	}
+
+	// SQLite does not enforce varchar lengths
+	if eas.db.Dialector.Name() != "sqlite" {
+		columnTypes, err := migrator.ColumnTypes(&AuthorizationAuditLogDB{})
+		if err != nil {
+			return fmt.Errorf("failed to inspect authorization audit log table: %w", err)
+		}
+		for _, columnType := range columnTypes {
+			if columnType.Name() != "request_id" {
+				continue
+			}
+			if length, ok := columnType.Length(); ok && length < model.MaxAuditRequestIDLength {
+				if err := migrator.AlterColumn(&AuthorizationAuditLogDB{}, "RequestID"); err != nil {
+					return fmt.Errorf("failed to widen request_id of authorization audit log table: %w", err)
+				}
+			}
+		}
+	}
+
+	for _, column := range []string{"request_id", "error_msg"} {
+		if err := eas.db.Model(&AuthorizationAuditLogDB{}).
+			Where(column+" = ?", "").
+			Update(column, nil).Error; err != nil {
+			return fmt.Errorf("failed to clear empty %s values: %w", column, err)
+		}
+	}
+
+	if err := migrator.CreateIndex(&AuthorizationAuditLogDB{}, "RequestID"); err != nil {
+		return fmt.Errorf("failed to index request_id of authorization audit log table: %w", err)
+	}
+	return nil
+}
+
+// initializeRateLimiter sets up the rate limiter. It is created even when rate
+// limiting is disabled so the rate_limiting feature flag can switch it on later.
 func (eas *EnterpriseAuthorizationSetup) initializeRateLimiter(opts *SetupOptions) error {
 	if !opts.EnableRateLimit {
-		eas.logger.Info("Rate limiting disabled")
-		return nil
+		eas.logger.Info("Rate limiting disabled until switched on by feature flag")
 	}
 
 	// Set default rate limit config if not provided
 	if opts.RateLimitConfig == nil {
+		algorithm, err := ParseRateLimitAlgorithm(config.RateLimitAlgorithm())
+		if err != nil {
+			return err
+		}
 		opts.RateLimitConfig = &RateLimitConfig{
 			DefaultRequestsPerMinute: 60,
 			RoleSpecificLimits: map[string]int{
@@ -204,23 +333,37 @@ func (eas *EnterpriseAuthorizationSetup) initializeRateLimiter(opts *SetupOption
 				"student":   config.STUDENT_LIMIT,
 				"moderator": config.MODERATOR_LIMIT,
 			},
-			BurstAllowance: config.BURST_ALLOWANCE,
-			WindowDuration: time.Minute,
-			EnableRedis:    opts.UseRedisRateLimit && opts.Redis != nil,
+			BurstAllowance:   config.BURST_ALLOWANCE,
+			WindowDuration:   time.Minute,
+			EnableRedis:      opts.UseRedisRateLimit && opts.Redis != nil,
+			Algorithm:        algorithm,
+			MaxBuckets:       config.RateLimitMaxBuckets(),
+			AutoBanThreshold: config.RateLimitAutoBanThreshold(),
+			AutoBanDuration:  config.RateLimitAutoBanDuration(),
 		}
 	}
 
+	if opts.RateLimitConfig.Clock == nil {
+		opts.RateLimitConfig.Clock = opts.Clock
+	}
+
 	// Create rate limiter
 	if opts.UseRedisRateLimit && opts.Redis != nil {
 		eas.rateLimiter = NewRedisRateLimiter(opts.RateLimitConfig, opts.Redis, eas.logger)
 		eas.logger.Info("Redis rate limiter initialized",
+			zap.String("algorithm", string(opts.RateLimitConfig.Algorithm)),
 			zap.Int("default_limit", opts.RateLimitConfig.DefaultRequestsPerMinute),
 			zap.Int("burst_allowance", opts.RateLimitConfig.BurstAllowance))
 	} else {
-		eas.rateLimiter = NewInMemoryRateLimiter(opts.RateLimitConfig, eas.logger)
+		limiter := NewInMemoryRateLimiter(opts.RateLimitConfig, eas.logger)
+		if opts.Metrics != nil {
+			limiter.SetMetrics(NewRateLimiterMetrics(opts.Metrics))
+		}
+		eas.rateLimiter = limiter
 		eas.logger.Info("In-memory rate limiter initialized",
 			zap.Int("default_limit", opts.RateLimitConfig.DefaultRequestsPerMinute),
-			zap.Int("burst_allowance", opts.RateLimitConfig.BurstAllowance))
+			zap.Int("burst_allowance", opts.RateLimitConfig.BurstAllowance),
+			zap.Int("max_buckets", limiter.maxBuckets()))
 	}
 
 	return nil
@@ -242,14 +385,26 @@ func (eas *EnterpriseAuthorizationSetup) initializeMiddleware(opts *SetupOptions
 		EnableDeprecationCheck: opts.EnableDeprecationCheck,
 		GradualRolloutMode:     opts.GradualRolloutMode,
 		AllowMissingPolicies:   opts.AllowMissingPolicies,
+		AuditDedupWindow:       opts.AuditDedupWindow,
+		DenialStormThreshold:   opts.DenialStormThreshold,
+		DenialStormWindow:      opts.DenialStormWindow,
+		AttributeExtractor:     opts.AttributeExtractor,
+		Clock:                  opts.Clock,
+		IDGenerator:            opts.IDGenerator,
+	}
+	if opts.Metrics != nil {
+		middlewareConfig.Metrics = NewAuthorizationMetrics(opts.Metrics)
 	}
 
 	eas.middleware = NewEnterpriseAuthMiddleware(middlewareConfig)
 
 	eas.logger.Info("Enterprise auth middleware initialized",
 		zap.Bool("audit_logging", opts.EnableAuditLogging),
+		zap.Duration("audit_dedup_window", opts.AuditDedupWindow),
+		zap.Int("denial_storm_threshold", opts.DenialStormThreshold),
 		zap.Bool("rate_limiting", opts.EnableRateLimit),
-		zap.Bool("deprecation_check", opts.EnableDeprecationCheck))
+		zap.Bool("deprecation_check", opts.EnableDeprecationCheck),
+		zap.Bool("metrics", opts.Metrics != nil))
 
 	return nil
 }
@@ -276,6 +431,64 @@ func (eas *EnterpriseAuthorizationSetup) SetRoleRateLimit(role string, requestsP
 	}
 }
 
+// SetUserRateLimit sets the rate limit of a specific user across all routes
+func (eas *EnterpriseAuthorizationSetup) SetUserRateLimit(userID string, requestsPerMinute int) {
+	if inMemLimiter, ok := eas.rateLimiter.(*InMemoryRateLimiter); ok {
+		inMemLimiter.SetUserLimit(userID, requestsPerMinute)
+		eas.logger.Info("Updated user rate limit",
+			zap.String("user_id", userID),
+			zap.Int("requests_per_minute", requestsPerMinute),
+		)
+	}
+}
+
+// SetRateLimitOverrides installs per-client limit overrides on the rate limiter
+func (eas *EnterpriseAuthorizationSetup) SetRateLimitOverrides(provider RateLimitOverrideProvider) {
+	switch limiter := eas.rateLimiter.(type) {
+	case *InMemoryRateLimiter:
+		limiter.SetOverrideProvider(provider)
+	case *RedisRateLimiter:
+		limiter.SetOverrideProvider(provider)
+	default:
+		return
+	}
+	eas.logger.Info("Rate limit overrides enabled")
+}
+
+// SetWebhookOutbox publishes saved audit logs as webhook events through outbox
+func (eas *EnterpriseAuthorizationSetup) SetWebhookOutbox(outbox AuditOutbox) {
+	if eas.auditRepository == nil {
+		return
+	}
+	eas.auditRepository.SetOutbox(outbox)
+	eas.logger.Info("Audit webhook outbox enabled")
+}
+
+// SetUsageQuotas enforces daily and monthly usage quotas on top of the rate limits
+func (eas *EnterpriseAuthorizationSetup) SetUsageQuotas(quotas QuotaChecker) {
+	if eas.middleware == nil {
+		return
+	}
+	eas.middleware.SetQuotaChecker(quotas)
+	eas.logger.Info("Usage quotas enabled")
+}
+
+// SetFeatureFlags lets audit logging, rate limiting and denial storm
+// detection be switched at runtime
+func (eas *EnterpriseAuthorizationSetup) SetFeatureFlags(flags FeatureFlagProvider) {
+	if eas.middleware == nil {
+		return
+	}
+	eas.middleware.SetFeatureFlags(flags)
+	eas.logger.Info("Feature flags enabled")
+}
+
+// Hooks returns the middleware's lifecycle hooks for registering custom rules,
+// response headers and audit fields
+func (eas *EnterpriseAuthorizationSetup) Hooks() *HookRegistry {
+	return eas.middleware.Hooks()
+}
+
 // GetRouteRegistry returns the route registry
 func (eas *EnterpriseAuthorizationSetup) GetRouteRegistry() *RouteRegistry {
 	return eas.routeRegistry
@@ -286,6 +499,15 @@ func (eas *EnterpriseAuthorizationSetup) GetPolicyValidator() PolicyValidator {
 	return eas.policyValidator
 }
 
+// RateLimitBucketStats reports the buckets of the in-memory rate limiter;
+// false when rate limits are counted in Redis
+func (eas *EnterpriseAuthorizationSetup) RateLimitBucketStats() (RateLimitBucketStats, bool) {
+	if inMemLimiter, ok := eas.rateLimiter.(*InMemoryRateLimiter); ok {
+		return inMemLimiter.BucketStats(), true
+	}
+	return RateLimitBucketStats{}, false
+}
+
 // GetRateLimiter returns the rate limiter
 func (eas *EnterpriseAuthorizationSetup) GetRateLimiter() RateLimiter {
 	return eas.rateLimiter
@@ -332,6 +554,32 @@ func (eas *EnterpriseAuthorizationSetup) CleanupOldAuditLogs(ctx context.Context
 	return eas.auditRepository.CleanupOldLogs(ctx, olderThan)
 }
 
+// initializePolicyWatcher shares policy changes of the enforcer with the
+// other instances when enabled
+func (eas *EnterpriseAuthorizationSetup) initializePolicyWatcher(opts *SetupOptions) error {
+	if !opts.EnablePolicyWatcher {
+		return nil
+	}
+	if opts.Redis == nil {
+		return fmt.Errorf("policy watcher requires a redis connection")
+	}
+	if opts.CasbinEnforcer == nil {
+		return fmt.Errorf("policy watcher requires a casbin enforcer")
+	}
+
+	watcher, err := NewRedisPolicyWatcher(opts.Redis, opts.PolicyWatcherChannel, eas.logger)
+	if err != nil {
+		return err
+	}
+	if err := initializer.WatchPolicies(opts.CasbinEnforcer, watcher); err != nil {
+		watcher.Close()
+		return err
+	}
+	eas.policyWatcher = watcher
+	eas.logger.Info("Policy watcher started", zap.String("channel", watcher.channel))
+	return nil
+}
+
 // Stop gracefully stops the setup (flushes batches, closes connections)
 func (eas *EnterpriseAuthorizationSetup) Stop() {
 	eas.logger.Info("Stopping enterprise authorization setup")
@@ -344,6 +592,10 @@ func (eas *EnterpriseAuthorizationSetup) Stop() {
 		inMemLimiter.Stop()
 	}
 
+	if eas.policyWatcher != nil {
+		eas.policyWatcher.Close()
+	}
+
 	eas.logger.Info("Enterprise authorization setup stopped")
 }
 
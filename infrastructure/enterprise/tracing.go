@@ -0,0 +1,67 @@
+package enterprise
+
+import (
+	"context"
+
+	"github.com/aruncs31s/azf/infrastructure/tracing"
+	"github.com/gin-gonic/gin"
+)
+
+// authorizeSpanKey holds the span of the request's authorization check
+const authorizeSpanKey = "azf_authorize_span"
+
+// authorizeSpan is the span of an authorization check and the request
+// context it was started from
+type authorizeSpan struct {
+	span   tracing.Span
+	parent context.Context
+	ended  bool
+}
+
+// startAuthorizeSpan starts the span of the authorization check. The request
+// carries the span until the check ends, so rate limiter, Casbin and
+// database spans become its children.
+func startAuthorizeSpan(c *gin.Context) {
+	parent := c.Request.Context()
+	ctx, span := tracing.Start(parent, "azf.authorize",
+		tracing.String("http.method", c.Request.Method),
+		tracing.String("http.target", c.Request.URL.Path),
+	)
+	c.Request = c.Request.WithContext(ctx)
+	c.Set(authorizeSpanKey, &authorizeSpan{span: span, parent: parent})
+}
+
+// traceDecision records the authorization decision on the span
+func traceDecision(c *gin.Context, route *RouteMetadata, role string, allowed bool, mode string) {
+	value, _ := c.Get(authorizeSpanKey)
+	trace, ok := value.(*authorizeSpan)
+	if !ok {
+		return
+	}
+	trace.span.SetAttributes(
+		tracing.String("azf.route", routeLabel(route)),
+		tracing.String("azf.role", role),
+		tracing.Bool("azf.allowed", allowed),
+		tracing.String("azf.authorization_mode", mode),
+	)
+}
+
+// endAuthorizeSpan ends the span, before the route handler runs or when the
+// request is rejected, and gives the request back its original context so
+// handler spans are not nested under the authorization check
+func endAuthorizeSpan(c *gin.Context) {
+	value, _ := c.Get(authorizeSpanKey)
+	trace, ok := value.(*authorizeSpan)
+	if !ok || trace.ended {
+		return
+	}
+	trace.ended = true
+	if c.IsAborted() {
+		trace.span.SetAttributes(
+			tracing.Bool("azf.rejected", true),
+			tracing.Int("http.status_code", c.Writer.Status()),
+		)
+	}
+	c.Request = c.Request.WithContext(trace.parent)
+	trace.span.End()
+}
@@ -0,0 +1,245 @@
+// Package ldap looks up and authenticates users against an LDAP server or
+// Active Directory. Every operation opens its own connection, binds as the
+// service account and closes it again; admin sign-ins are too rare to keep a
+// pool.
+package ldap
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+var (
+	// ErrUserNotFound is returned when no entry, or more than one, matches
+	// the username
+	ErrUserNotFound = errors.New("LDAP user not found")
+	// ErrInvalidCredentials is returned when the server rejects the password
+	ErrInvalidCredentials = errors.New("invalid LDAP credentials")
+)
+
+// defaultTimeout bounds connections and requests when Config.Timeout is unset
+const defaultTimeout = 5 * time.Second
+
+// usernameAttributes are read for the canonical username when
+// Config.UsernameAttribute is empty, in order
+var usernameAttributes = []string{"uid", "sAMAccountName"}
+
+// Config is how a Directory reaches the server and finds users in it
+type Config struct {
+	URL          string
+	BindDN       string
+	BindPassword string
+	BaseDN       string
+	// UserFilter finds a user; {username} is replaced by the escaped username
+	UserFilter        string
+	UsernameAttribute string
+	EmailAttribute    string
+	NameAttribute     string
+	// GroupAttribute lists the DNs of the user's groups on its entry
+	GroupAttribute string
+	// GroupBaseDN and GroupFilter search the groups listing the user, with
+	// {dn} replaced by the escaped DN of the user; skipped without GroupBaseDN
+	GroupBaseDN        string
+	GroupFilter        string
+	StartTLS           bool
+	InsecureSkipVerify bool
+	Timeout            time.Duration
+}
+
+// User is the directory entry of a user
+type User struct {
+	DN       string
+	Username string
+	Email    string
+	Name     string
+	// Groups holds the DNs of the user's groups
+	Groups []string
+}
+
+// Directory reads users from the server of its Config
+type Directory struct {
+	config Config
+}
+
+// NewDirectory creates a directory on config
+func NewDirectory(config Config) (*Directory, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("LDAP URL is not set")
+	}
+	if config.BaseDN == "" {
+		return nil, fmt.Errorf("LDAP base DN is not set")
+	}
+	if !strings.Contains(config.UserFilter, "{username}") {
+		return nil, fmt.Errorf("LDAP user filter %q has no {username} placeholder", config.UserFilter)
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultTimeout
+	}
+	return &Directory{config: config}, nil
+}
+
+// URL returns the address of the server
+func (d *Directory) URL() string {
+	return d.config.URL
+}
+
+// FindUser returns the single entry matching username, with its groups
+func (d *Directory) FindUser(ctx context.Context, username string) (*User, error) {
+	conn, err := d.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	attributes := []string{d.config.EmailAttribute, d.config.NameAttribute, d.config.GroupAttribute}
+	if d.config.UsernameAttribute != "" {
+		attributes = append(attributes, d.config.UsernameAttribute)
+	} else {
+		attributes = append(attributes, usernameAttributes...)
+	}
+	filter := strings.ReplaceAll(d.config.UserFilter, "{username}", ldap.EscapeFilter(username))
+	result, err := conn.Search(ldap.NewSearchRequest(
+		d.config.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		2, int(d.config.Timeout/time.Second), false, filter, attributes, nil))
+	if err != nil && !ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+		return nil, fmt.Errorf("failed to search LDAP user: %w", err)
+	}
+	if result == nil || len(result.Entries) != 1 {
+		return nil, ErrUserNotFound
+	}
+	entry := result.Entries[0]
+
+	user := &User{
+		DN:       entry.DN,
+		Username: d.username(entry, username),
+		Email:    entry.GetAttributeValue(d.config.EmailAttribute),
+		Name:     entry.GetAttributeValue(d.config.NameAttribute),
+		Groups:   entry.GetAttributeValues(d.config.GroupAttribute),
+	}
+	if d.config.GroupBaseDN != "" {
+		groups, err := d.searchGroups(conn, entry.DN)
+		if err != nil {
+			return nil, err
+		}
+		user.Groups = append(user.Groups, groups...)
+	}
+	return user, nil
+}
+
+// Authenticate binds as dn with password
+func (d *Directory) Authenticate(ctx context.Context, dn, password string) error {
+	if password == "" {
+		// An empty password would be an unauthenticated bind, which servers accept
+		return ErrInvalidCredentials
+	}
+	conn, err := d.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := conn.Bind(dn, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return ErrInvalidCredentials
+		}
+		return fmt.Errorf("failed to bind as LDAP user: %w", err)
+	}
+	return nil
+}
+
+// Ping connects and binds as the service account
+func (d *Directory) Ping(ctx context.Context) error {
+	conn, err := d.connect(ctx)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// connect dials the server and binds as the service account
+func (d *Directory) connect(ctx context.Context) (*ldap.Conn, error) {
+	conn, err := d.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if d.config.BindDN == "" {
+		err = conn.UnauthenticatedBind("")
+	} else {
+		err = conn.Bind(d.config.BindDN, d.config.BindPassword)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to bind LDAP service account: %w", err)
+	}
+	return conn, nil
+}
+
+// dial opens a connection, upgraded with StartTLS when configured
+func (d *Directory) dial(ctx context.Context) (*ldap.Conn, error) {
+	timeout := d.config.Timeout
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < timeout {
+		timeout = time.Until(deadline)
+	}
+	tlsConfig := &tls.Config{InsecureSkipVerify: d.config.InsecureSkipVerify}
+	conn, err := ldap.DialURL(d.config.URL,
+		ldap.DialWithDialer(&net.Dialer{Timeout: timeout}),
+		ldap.DialWithTLSConfig(tlsConfig))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to LDAP server: %w", err)
+	}
+	conn.SetTimeout(timeout)
+	if d.config.StartTLS {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to start TLS with LDAP server: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+// searchGroups returns the DNs of the groups listing the user with dn
+func (d *Directory) searchGroups(conn *ldap.Conn, dn string) ([]string, error) {
+	filter := strings.ReplaceAll(d.config.GroupFilter, "{dn}", ldap.EscapeFilter(dn))
+	result, err := conn.Search(ldap.NewSearchRequest(
+		d.config.GroupBaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		0, int(d.config.Timeout/time.Second), false, filter, []string{"dn"}, nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to search LDAP groups: %w", err)
+	}
+	groups := make([]string, 0, len(result.Entries))
+	for _, entry := range result.Entries {
+		groups = append(groups, entry.DN)
+	}
+	return groups, nil
+}
+
+// username returns the canonical username of entry, or the one signed in with
+func (d *Directory) username(entry *ldap.Entry, fallback string) string {
+	attributes := usernameAttributes
+	if d.config.UsernameAttribute != "" {
+		attributes = []string{d.config.UsernameAttribute}
+	}
+	for _, attribute := range attributes {
+		if value := entry.GetAttributeValue(attribute); value != "" {
+			return value
+		}
+	}
+	return fallback
+}
+
+// CommonName returns the value of the first RDN of dn, e.g. "Admins" of
+// "CN=Admins,OU=Groups,DC=example,DC=com", or "" when dn does not parse
+func CommonName(dn string) string {
+	parsed, err := ldap.ParseDN(dn)
+	if err != nil || len(parsed.RDNs) == 0 || len(parsed.RDNs[0].Attributes) == 0 {
+		return ""
+	}
+	return parsed.RDNs[0].Attributes[0].Value
+}
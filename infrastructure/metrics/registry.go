@@ -0,0 +1,298 @@
+// Package metrics keeps counters, gauges and histograms and serves them in the
+// Prometheus text exposition format, so azf can be scraped without pulling
+// in the Prometheus client library.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are histogram bounds in seconds suited to authorization
+// latencies, from 50µs to 1s
+var DefaultBuckets = []float64{0.00005, 0.0001, 0.00025, 0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1}
+
+// collector is a metric family that can write itself in text format
+type collector interface {
+	name() string
+	write(w *bufio.Writer)
+}
+
+// Registry holds metric families and serves them over HTTP
+type Registry struct {
+	mu         sync.RWMutex
+	collectors map[string]collector
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{collectors: make(map[string]collector)}
+}
+
+var defaultRegistry = NewRegistry()
+
+// Default returns the registry served on the metrics endpoint
+func Default() *Registry {
+	return defaultRegistry
+}
+
+// register adds c, or returns the family already registered under its name
+func (r *Registry) register(c collector) collector {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.collectors[c.name()]; ok {
+		return existing
+	}
+	r.collectors[c.name()] = c
+	return c
+}
+
+// NewCounterVec registers a counter family partitioned by labels. Registering
+// a name twice returns the first family; it panics if that is not a counter.
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return r.register(&CounterVec{family: newFamily(name, help, labels), values: make(map[string]*counterValue)}).(*CounterVec)
+}
+
+// NewGaugeVec registers a gauge family partitioned by labels. Registering a
+// name twice returns the first family; it panics if that is not a gauge.
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return r.register(&GaugeVec{family: newFamily(name, help, labels), values: make(map[string]*counterValue)}).(*GaugeVec)
+}
+
+// NewHistogramVec registers a histogram family partitioned by labels, with
+// buckets as upper bounds; nil buckets use DefaultBuckets
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	bounds := append([]float64(nil), buckets...)
+	sort.Float64s(bounds)
+	return r.register(&HistogramVec{family: newFamily(name, help, labels), buckets: bounds, values: make(map[string]*histogramValue)}).(*HistogramVec)
+}
+
+// ServeHTTP writes every metric family in the text exposition format
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	buf := bufio.NewWriter(w)
+	r.mu.RLock()
+	names := make([]string, 0, len(r.collectors))
+	for name := range r.collectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	collectors := make([]collector, len(names))
+	for i, name := range names {
+		collectors[i] = r.collectors[name]
+	}
+	r.mu.RUnlock()
+
+	for _, c := range collectors {
+		c.write(buf)
+	}
+	buf.Flush()
+}
+
+// family is the name, help and label names shared by a metric's series
+type family struct {
+	metricName string
+	help       string
+	labels     []string
+}
+
+func newFamily(name, help string, labels []string) family {
+	return family{metricName: name, help: help, labels: labels}
+}
+
+func (f family) name() string {
+	return f.metricName
+}
+
+// series returns the key of the series with the label values, and a copy of
+// the values; values missing from the end are empty
+func (f family) series(values []string) (string, []string) {
+	labels := make([]string, len(f.labels))
+	copy(labels, values)
+	return strings.Join(labels, "\xff"), labels
+}
+
+// header writes the HELP and TYPE lines of the family
+func (f family) header(w *bufio.Writer, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.metricName, escapeHelp(f.help), f.metricName, kind)
+}
+
+// labelPairs formats the series labels, plus an extra pair when extraName is set
+func (f family) labelPairs(values []string, extraName, extraValue string) string {
+	var b strings.Builder
+	for i, label := range f.labels {
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, `%s="%s"`, label, escapeLabel(values[i]))
+	}
+	if extraName != "" {
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, `%s="%s"`, extraName, extraValue)
+	}
+	if b.Len() == 0 {
+		return ""
+	}
+	return "{" + b.String() + "}"
+}
+
+// CounterVec is a family of monotonically increasing counters
+type CounterVec struct {
+	family
+	mu     sync.Mutex
+	values map[string]*counterValue
+}
+
+type counterValue struct {
+	labels []string
+	value  float64
+}
+
+// Inc adds one to the counter of the label values
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds delta, which must not be negative, to the counter of the label values
+func (c *CounterVec) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		return
+	}
+	key, labels := c.series(labelValues)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, ok := c.values[key]
+	if !ok {
+		v = &counterValue{labels: labels}
+		c.values[key] = v
+	}
+	v.value += delta
+}
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.header(w, "counter")
+	for _, key := range sortedKeys(c.values) {
+		v := c.values[key]
+		fmt.Fprintf(w, "%s%s %s\n", c.metricName, c.labelPairs(v.labels, "", ""), formatFloat(v.value))
+	}
+}
+
+// GaugeVec is a family of values that go up and down
+type GaugeVec struct {
+	family
+	mu     sync.Mutex
+	values map[string]*counterValue
+}
+
+// Set sets the gauge of the label values
+func (g *GaugeVec) Set(value float64, labelValues ...string) {
+	key, labels := g.series(labelValues)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	v, ok := g.values[key]
+	if !ok {
+		v = &counterValue{labels: labels}
+		g.values[key] = v
+	}
+	v.value = value
+}
+
+func (g *GaugeVec) write(w *bufio.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.header(w, "gauge")
+	for _, key := range sortedKeys(g.values) {
+		v := g.values[key]
+		fmt.Fprintf(w, "%s%s %s\n", g.metricName, g.labelPairs(v.labels, "", ""), formatFloat(v.value))
+	}
+}
+
+// HistogramVec is a family of histograms with shared buckets
+type HistogramVec struct {
+	family
+	buckets []float64
+	mu      sync.Mutex
+	values  map[string]*histogramValue
+}
+
+type histogramValue struct {
+	labels []string
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// Observe records value in the histogram of the label values
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	key, labels := h.series(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	v, ok := h.values[key]
+	if !ok {
+		v = &histogramValue{labels: labels, counts: make([]uint64, len(h.buckets))}
+		h.values[key] = v
+	}
+	// Counts are per bucket; write accumulates them
+	if i := sort.SearchFloat64s(h.buckets, value); i < len(h.buckets) {
+		v.counts[i]++
+	}
+	v.count++
+	v.sum += value
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.header(w, "histogram")
+	for _, key := range sortedKeys(h.values) {
+		v := h.values[key]
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += v.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, h.labelPairs(v.labels, "le", formatFloat(bound)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, h.labelPairs(v.labels, "le", "+Inf"), v.count)
+		labels := h.labelPairs(v.labels, "", "")
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.metricName, labels, formatFloat(v.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.metricName, labels, v.count)
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(v string) string {
+	return labelEscaper.Replace(strings.ToValidUTF8(v, "\uFFFD"))
+}
+
+func escapeHelp(help string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
@@ -0,0 +1,195 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/aruncs31s/azf/config"
+)
+
+// Channel delivers notifications to one destination (webhook, Slack, email)
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, notification *Notification) error
+}
+
+// Resolver is implemented by channels that keep an incident open until the
+// alert that raised it clears
+type Resolver interface {
+	Resolve(ctx context.Context, rule string, dedupKey string) error
+}
+
+// NewChannels creates a channel for every destination configured in cfg
+func NewChannels(cfg config.NotificationConfig) []Channel {
+	client := &http.Client{Timeout: cfg.Timeout}
+
+	var channels []Channel
+	if cfg.WebhookURL != "" {
+		channels = append(channels, NewWebhookChannel(cfg.WebhookURL, client))
+	}
+	if cfg.SlackWebhookURL != "" {
+		channels = append(channels, NewSlackChannel(cfg.SlackWebhookURL, client))
+	}
+	if cfg.Email.Host != "" && len(cfg.Email.To) > 0 {
+		channels = append(channels, NewEmailChannel(cfg.Email))
+	}
+	return channels
+}
+
+// switchedChannel drops notifications while its switch is off
+type switchedChannel struct {
+	Channel
+	enabled func() bool
+}
+
+// NewSwitchedChannel wraps channel so it only delivers while enabled returns
+// true, which lets a feature flag silence a destination without a restart
+func NewSwitchedChannel(channel Channel, enabled func() bool) Channel {
+	return &switchedChannel{Channel: channel, enabled: enabled}
+}
+
+func (s *switchedChannel) Send(ctx context.Context, notification *Notification) error {
+	if !s.enabled() {
+		return nil
+	}
+	return s.Channel.Send(ctx, notification)
+}
+
+// webhookChannel posts notifications as JSON to a generic webhook
+type webhookChannel struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookChannel creates a channel that posts notifications as JSON to url
+func NewWebhookChannel(url string, client *http.Client) Channel {
+	return &webhookChannel{url: url, client: client}
+}
+
+func (w *webhookChannel) Name() string {
+	return "webhook"
+}
+
+func (w *webhookChannel) Send(ctx context.Context, notification *Notification) error {
+	return postJSON(ctx, w.client, w.url, notification.payload())
+}
+
+// slackChannel posts notifications to a Slack incoming webhook
+type slackChannel struct {
+	url    string
+	client *http.Client
+}
+
+// NewSlackChannel creates a channel that posts notifications to a Slack incoming webhook
+func NewSlackChannel(url string, client *http.Client) Channel {
+	return &slackChannel{url: url, client: client}
+}
+
+func (s *slackChannel) Name() string {
+	return "slack"
+}
+
+func (s *slackChannel) Send(ctx context.Context, notification *Notification) error {
+	text := fmt.Sprintf("*%s*\n%s", notification.Subject(), notification.Text())
+	return postJSON(ctx, s.client, s.url, map[string]string{"text": text})
+}
+
+// emailChannel sends notifications as plain text email over SMTP
+type emailChannel struct {
+	cfg config.EmailNotificationConfig
+}
+
+// NewEmailChannel creates a channel that emails notifications to the configured recipients
+func NewEmailChannel(cfg config.EmailNotificationConfig) Channel {
+	return &emailChannel{cfg: cfg}
+}
+
+func (e *emailChannel) Name() string {
+	return "email"
+}
+
+func (e *emailChannel) Send(ctx context.Context, notification *Notification) error {
+	var auth smtp.Auth
+	if e.cfg.Username != "" {
+		auth = smtp.PlainAuth("", e.cfg.Username, e.cfg.Password, e.cfg.Host)
+	}
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", e.cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(e.cfg.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", notification.Subject())
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(notification.Text(), "\n", "\r\n"))
+
+	addr := fmt.Sprintf("%s:%d", e.cfg.Host, e.cfg.Port)
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(addr, auth, e.cfg.From, e.cfg.To, []byte(msg.String()))
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("failed to send alert email: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to send alert email: %w", ctx.Err())
+	}
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, body interface{}) error {
+	_, err := doJSON(ctx, client, http.MethodPost, url, nil, body, nil)
+	return err
+}
+
+// doJSON sends body as JSON and decodes a successful response into out when
+// it is not nil. It returns the response status code.
+func doJSON(ctx context.Context, client *http.Client, method, url string, headers map[string]string, body interface{}, out interface{}) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode notification: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create notification request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		io.Copy(io.Discard, resp.Body)
+		return resp.StatusCode, fmt.Errorf("notification endpoint returned status %d", resp.StatusCode)
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp.StatusCode, fmt.Errorf("failed to decode notification response: %w", err)
+		}
+		return resp.StatusCode, nil
+	}
+	io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,556 @@
+package notification
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aruncs31s/azf/config"
+	"github.com/aruncs31s/azf/domain/api_usage"
+	"github.com/aruncs31s/azf/domain/model"
+	"github.com/aruncs31s/azf/domain/repository"
+	"github.com/aruncs31s/azf/shared/logger"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// maxIncidentKeyLength is the longest dedup key PagerDuty accepts
+const maxIncidentKeyLength = 255
+
+// IncidentStatus is the state of an incident as reported by its provider
+type IncidentStatus struct {
+	Status         string // one of api_usage.IncidentTriggered, IncidentAcknowledged, IncidentResolved
+	ExternalID     string
+	AcknowledgedBy string
+	AcknowledgedAt *time.Time
+}
+
+// IncidentProvider opens and resolves incidents with an on-call service.
+// Incidents are identified by a key derived from the alert rule and dedup key.
+type IncidentProvider interface {
+	Name() string
+	Trigger(ctx context.Context, key string, alert *model.Alert, count int) error
+	Resolve(ctx context.Context, key string) error
+	// Status returns the provider's view of the incident, or nil if it is not available
+	Status(ctx context.Context, key string) (*IncidentStatus, error)
+}
+
+// NewIncidentProviders creates a provider for every on-call service configured in cfg
+func NewIncidentProviders(cfg config.IncidentConfig, client *http.Client) []IncidentProvider {
+	var providers []IncidentProvider
+	if cfg.PagerDutyRoutingKey != "" {
+		providers = append(providers, NewPagerDutyProvider(cfg, client))
+	}
+	if cfg.OpsgenieAPIKey != "" {
+		providers = append(providers, NewOpsgenieProvider(cfg, client))
+	}
+	return providers
+}
+
+// incidentKey returns the provider dedup key for an alert rule and dedup key
+func incidentKey(rule, dedupKey string) string {
+	key := "azf:" + rule
+	if dedupKey != "" && dedupKey != rule {
+		key += ":" + dedupKey
+	}
+	if len(key) > maxIncidentKeyLength {
+		sum := sha256.Sum256([]byte(dedupKey))
+		key = "azf:" + rule + ":" + hex.EncodeToString(sum[:16])
+	}
+	return key
+}
+
+// IncidentManager is a notification channel that opens an incident with each
+// provider for alerts at or above the minimum severity, resolves it when the
+// alert clears, and periodically syncs acknowledgments made in the provider
+// back to the stored incident
+type IncidentManager struct {
+	providers   []IncidentProvider
+	repo        repository.AlertIncidentRepository
+	minSeverity *model.AlertSeverity
+	timeout     time.Duration
+
+	mu        sync.Mutex // serializes incident updates
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewIncidentManager creates an incident manager and starts syncing
+// acknowledgments every cfg.SyncInterval
+func NewIncidentManager(cfg config.IncidentConfig, providers []IncidentProvider, repo repository.AlertIncidentRepository, timeout time.Duration) *IncidentManager {
+	minSeverity, err := model.NewAlertSeverity(cfg.MinSeverity)
+	if err != nil {
+		logger.Warn("Invalid incident severity, using CRITICAL", zap.String("severity", cfg.MinSeverity))
+		minSeverity = model.AlertCritical
+	}
+
+	m := &IncidentManager{
+		providers:   providers,
+		repo:        repo,
+		minSeverity: minSeverity,
+		timeout:     timeout,
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	if cfg.SyncInterval > 0 {
+		go m.syncLoop(cfg.SyncInterval)
+	} else {
+		close(m.done)
+	}
+	return m
+}
+
+// Providers returns the names of the configured providers
+func (m *IncidentManager) Providers() []string {
+	names := make([]string, len(m.providers))
+	for i, provider := range m.providers {
+		names[i] = provider.Name()
+	}
+	return names
+}
+
+func (m *IncidentManager) Name() string {
+	return "incidents"
+}
+
+// Send opens or updates an incident for every alert in the notification that
+// is at or above the minimum severity
+func (m *IncidentManager) Send(ctx context.Context, notification *Notification) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var lastErr error
+	for _, entry := range notification.Entries {
+		alert := entry.Alert
+		if !m.minSeverity.AtMost(alert.Severity()) {
+			continue
+		}
+		key := incidentKey(alert.Rule(), alert.DedupKey())
+		for _, provider := range m.providers {
+			if err := provider.Trigger(ctx, key, alert, entry.Count); err != nil {
+				lastErr = fmt.Errorf("%s: %w", provider.Name(), err)
+				continue
+			}
+			if err := m.recordTrigger(provider.Name(), key, alert, entry.Count); err != nil {
+				lastErr = err
+			}
+		}
+	}
+	return lastErr
+}
+
+// Resolve resolves the open incidents raised for the alert rule and dedup key
+func (m *IncidentManager) Resolve(ctx context.Context, rule string, dedupKey string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := incidentKey(rule, dedupKey)
+	var lastErr error
+	for _, provider := range m.providers {
+		incident, err := m.repo.FindOpen(provider.Name(), key)
+		if err != nil {
+			lastErr = fmt.Errorf("failed to load incident: %w", err)
+			continue
+		}
+		if incident == nil {
+			continue
+		}
+		if err := provider.Resolve(ctx, key); err != nil {
+			lastErr = fmt.Errorf("%s: %w", provider.Name(), err)
+			continue
+		}
+
+		now := time.Now()
+		incident.Status = api_usage.IncidentResolved
+		incident.ResolvedAt = &now
+		incident.UpdatedAt = now
+		if err := m.repo.Save(incident); err != nil {
+			lastErr = err
+			continue
+		}
+		logger.Info("Incident resolved",
+			zap.String("provider", provider.Name()),
+			zap.String("incident_key", key))
+	}
+	return lastErr
+}
+
+// Sync refreshes the status of every open incident from its provider so
+// acknowledgments and resolutions made by on-call staff are reflected locally
+func (m *IncidentManager) Sync(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	incidents, err := m.repo.FindAllOpen()
+	if err != nil {
+		return fmt.Errorf("failed to load open incidents: %w", err)
+	}
+
+	var lastErr error
+	for i := range *incidents {
+		incident := &(*incidents)[i]
+		provider := m.provider(incident.Provider)
+		if provider == nil {
+			continue
+		}
+
+		status, err := provider.Status(ctx, incident.IncidentKey)
+		if err != nil {
+			lastErr = fmt.Errorf("%s: %w", provider.Name(), err)
+			continue
+		}
+
+		now := time.Now()
+		incident.LastSyncedAt = &now
+		incident.UpdatedAt = now
+		if status != nil {
+			applyIncidentStatus(incident, status, now)
+		}
+		if err := m.repo.Save(incident); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// Close stops syncing acknowledgments
+func (m *IncidentManager) Close() error {
+	m.closeOnce.Do(func() {
+		close(m.stop)
+		<-m.done
+	})
+	return nil
+}
+
+func (m *IncidentManager) provider(name string) IncidentProvider {
+	for _, provider := range m.providers {
+		if provider.Name() == name {
+			return provider
+		}
+	}
+	return nil
+}
+
+// recordTrigger stores a new incident or bumps the open one. Callers hold m.mu.
+func (m *IncidentManager) recordTrigger(provider string, key string, alert *model.Alert, count int) error {
+	incident, err := m.repo.FindOpen(provider, key)
+	if err != nil {
+		return fmt.Errorf("failed to load incident: %w", err)
+	}
+
+	now := time.Now()
+	if incident == nil {
+		incident = &api_usage.AlertIncident{
+			ID:          uuid.New().String(),
+			Provider:    provider,
+			IncidentKey: key,
+			Rule:        alert.Rule(),
+			DedupKey:    alert.DedupKey(),
+			Status:      api_usage.IncidentTriggered,
+			CreatedAt:   now,
+		}
+		logger.Info("Incident opened",
+			zap.String("provider", provider),
+			zap.String("incident_key", key),
+			zap.String("severity", alert.Severity().Value()))
+	} else {
+		count += incident.TriggerCount
+	}
+
+	incident.Severity = alert.Severity().Value()
+	incident.Title = truncate(alert.Title(), 255)
+	incident.TriggerCount = count
+	incident.LastTriggeredAt = alert.FiredAt()
+	incident.UpdatedAt = now
+	return m.repo.Save(incident)
+}
+
+// applyIncidentStatus copies the provider's view of an incident onto the stored one
+func applyIncidentStatus(incident *api_usage.AlertIncident, status *IncidentStatus, now time.Time) {
+	if status.ExternalID != "" {
+		incident.ExternalID = status.ExternalID
+	}
+
+	switch status.Status {
+	case api_usage.IncidentResolved:
+		incident.Status = api_usage.IncidentResolved
+		incident.ResolvedAt = &now
+	case api_usage.IncidentAcknowledged:
+		incident.Status = api_usage.IncidentAcknowledged
+		if status.AcknowledgedBy != "" {
+			incident.AcknowledgedBy = status.AcknowledgedBy
+		}
+		if status.AcknowledgedAt != nil {
+			incident.AcknowledgedAt = status.AcknowledgedAt
+		} else if incident.AcknowledgedAt == nil {
+			incident.AcknowledgedAt = &now
+		}
+	case api_usage.IncidentTriggered:
+		// The acknowledgment timed out or was withdrawn
+		incident.Status = api_usage.IncidentTriggered
+		incident.AcknowledgedBy = ""
+		incident.AcknowledgedAt = nil
+	}
+}
+
+// syncLoop syncs incident status on every tick until the manager is closed
+func (m *IncidentManager) syncLoop(interval time.Duration) {
+	defer close(m.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), m.timeout*time.Duration(len(m.providers)+1))
+			if err := m.Sync(ctx); err != nil {
+				logger.Warn("Failed to sync incident status", zap.Error(err))
+			}
+			cancel()
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+// pagerDutyProvider opens incidents through the PagerDuty Events API v2 and
+// reads their status from the REST API when an API token is configured
+type pagerDutyProvider struct {
+	routingKey string
+	apiToken   string
+	eventsURL  string
+	apiURL     string
+	source     string
+	client     *http.Client
+}
+
+// NewPagerDutyProvider creates a PagerDuty incident provider
+func NewPagerDutyProvider(cfg config.IncidentConfig, client *http.Client) IncidentProvider {
+	return &pagerDutyProvider{
+		routingKey: cfg.PagerDutyRoutingKey,
+		apiToken:   cfg.PagerDutyAPIToken,
+		eventsURL:  cfg.PagerDutyEventsURL,
+		apiURL:     cfg.PagerDutyAPIURL,
+		source:     cfg.Source,
+		client:     client,
+	}
+}
+
+func (p *pagerDutyProvider) Name() string {
+	return "pagerduty"
+}
+
+func (p *pagerDutyProvider) Trigger(ctx context.Context, key string, alert *model.Alert, count int) error {
+	details := alert.Details()
+	details["message"] = alert.Message()
+	details["rule"] = alert.Rule()
+	details["count"] = count
+
+	event := map[string]interface{}{
+		"routing_key":  p.routingKey,
+		"event_action": "trigger",
+		"dedup_key":    key,
+		"payload": map[string]interface{}{
+			"summary":        truncate(alert.Title(), 1024),
+			"source":         p.source,
+			"severity":       pagerDutySeverity(alert.Severity()),
+			"timestamp":      alert.FiredAt().UTC().Format(time.RFC3339),
+			"component":      alert.Rule(),
+			"custom_details": details,
+		},
+	}
+	_, err := doJSON(ctx, p.client, http.MethodPost, p.eventsURL, nil, event, nil)
+	return err
+}
+
+func (p *pagerDutyProvider) Resolve(ctx context.Context, key string) error {
+	event := map[string]interface{}{
+		"routing_key":  p.routingKey,
+		"event_action": "resolve",
+		"dedup_key":    key,
+	}
+	_, err := doJSON(ctx, p.client, http.MethodPost, p.eventsURL, nil, event, nil)
+	return err
+}
+
+func (p *pagerDutyProvider) Status(ctx context.Context, key string) (*IncidentStatus, error) {
+	if p.apiToken == "" {
+		return nil, nil
+	}
+
+	var body struct {
+		Incidents []struct {
+			ID               string `json:"id"`
+			Status           string `json:"status"`
+			Acknowledgements []struct {
+				At           time.Time `json:"at"`
+				Acknowledger struct {
+					Summary string `json:"summary"`
+				} `json:"acknowledger"`
+			} `json:"acknowledgements"`
+		} `json:"incidents"`
+	}
+	headers := map[string]string{
+		"Authorization": "Token token=" + p.apiToken,
+		"Accept":        "application/vnd.pagerduty+json;version=2",
+	}
+	endpoint := p.apiURL + "/incidents?date_range=all&incident_key=" + url.QueryEscape(key)
+	if _, err := doJSON(ctx, p.client, http.MethodGet, endpoint, headers, nil, &body); err != nil {
+		return nil, err
+	}
+	if len(body.Incidents) == 0 {
+		return nil, nil
+	}
+
+	incident := body.Incidents[len(body.Incidents)-1]
+	status := &IncidentStatus{
+		Status:     incident.Status,
+		ExternalID: incident.ID,
+	}
+	if n := len(incident.Acknowledgements); n > 0 {
+		ack := incident.Acknowledgements[n-1]
+		at := ack.At
+		status.AcknowledgedBy = ack.Acknowledger.Summary
+		status.AcknowledgedAt = &at
+	}
+	return status, nil
+}
+
+func pagerDutySeverity(severity *model.AlertSeverity) string {
+	switch severity {
+	case model.AlertCritical:
+		return "critical"
+	case model.AlertWarning:
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+// opsgenieProvider opens incidents as Opsgenie alerts, using the incident key
+// as the alert alias
+type opsgenieProvider struct {
+	apiKey string
+	apiURL string
+	source string
+	client *http.Client
+}
+
+// NewOpsgenieProvider creates an Opsgenie incident provider
+func NewOpsgenieProvider(cfg config.IncidentConfig, client *http.Client) IncidentProvider {
+	return &opsgenieProvider{
+		apiKey: cfg.OpsgenieAPIKey,
+		apiURL: cfg.OpsgenieAPIURL,
+		source: cfg.Source,
+		client: client,
+	}
+}
+
+func (o *opsgenieProvider) Name() string {
+	return "opsgenie"
+}
+
+func (o *opsgenieProvider) headers() map[string]string {
+	return map[string]string{"Authorization": "GenieKey " + o.apiKey}
+}
+
+func (o *opsgenieProvider) Trigger(ctx context.Context, key string, alert *model.Alert, count int) error {
+	details := map[string]string{
+		"rule":  alert.Rule(),
+		"count": fmt.Sprintf("%d", count),
+	}
+	for k, v := range alert.Details() {
+		details[k] = fmt.Sprint(v)
+	}
+
+	body := map[string]interface{}{
+		"message":     truncate(alert.Title(), 130),
+		"alias":       key,
+		"description": truncate(alert.Message(), 15000),
+		"priority":    opsgeniePriority(alert.Severity()),
+		"source":      o.source,
+		"entity":      alert.Rule(),
+		"details":     details,
+	}
+	_, err := doJSON(ctx, o.client, http.MethodPost, o.apiURL+"/v2/alerts", o.headers(), body, nil)
+	return err
+}
+
+func (o *opsgenieProvider) Resolve(ctx context.Context, key string) error {
+	endpoint := o.apiURL + "/v2/alerts/" + url.PathEscape(key) + "/close?identifierType=alias"
+	body := map[string]string{
+		"source": o.source,
+		"note":   "Alert condition cleared",
+	}
+	_, err := doJSON(ctx, o.client, http.MethodPost, endpoint, o.headers(), body, nil)
+	return err
+}
+
+func (o *opsgenieProvider) Status(ctx context.Context, key string) (*IncidentStatus, error) {
+	var body struct {
+		Data struct {
+			ID           string    `json:"id"`
+			Status       string    `json:"status"`
+			Acknowledged bool      `json:"acknowledged"`
+			CreatedAt    time.Time `json:"createdAt"`
+			Report       struct {
+				AckTime        int64  `json:"ackTime"`
+				AcknowledgedBy string `json:"acknowledgedBy"`
+			} `json:"report"`
+		} `json:"data"`
+	}
+	endpoint := o.apiURL + "/v2/alerts/" + url.PathEscape(key) + "?identifierType=alias"
+	code, err := doJSON(ctx, o.client, http.MethodGet, endpoint, o.headers(), nil, &body)
+	if code == http.StatusNotFound {
+		// Opsgenie creates alerts asynchronously; it may not exist yet
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	status := &IncidentStatus{
+		Status:     api_usage.IncidentTriggered,
+		ExternalID: body.Data.ID,
+	}
+	switch {
+	case body.Data.Status == "closed":
+		status.Status = api_usage.IncidentResolved
+	case body.Data.Acknowledged:
+		status.Status = api_usage.IncidentAcknowledged
+		status.AcknowledgedBy = body.Data.Report.AcknowledgedBy
+		if body.Data.Report.AckTime > 0 && !body.Data.CreatedAt.IsZero() {
+			// ackTime is the number of milliseconds between creation and acknowledgment
+			at := body.Data.CreatedAt.Add(time.Duration(body.Data.Report.AckTime) * time.Millisecond)
+			status.AcknowledgedAt = &at
+		}
+	}
+	return status, nil
+}
+
+func opsgeniePriority(severity *model.AlertSeverity) string {
+	switch severity {
+	case model.AlertCritical:
+		return "P1"
+	case model.AlertWarning:
+		return "P3"
+	default:
+		return "P5"
+	}
+}
+
+// truncate shortens s to at most n bytes without splitting a UTF-8 sequence
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return strings.ToValidUTF8(s[:n], "")
+}
@@ -0,0 +1,403 @@
+// Package notification delivers alerts to admins over webhooks, Slack, email
+// and browser push, with per-rule throttling and a digest mode for
+// low-severity alerts. High-severity alerts can also open incidents in
+// PagerDuty or Opsgenie that are resolved when the alert clears.
+package notification
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aruncs31s/azf/config"
+	"github.com/aruncs31s/azf/domain/model"
+	"github.com/aruncs31s/azf/shared/logger"
+	"go.uber.org/zap"
+)
+
+// maxHousekeepingInterval bounds how often throttle state is pruned and the digest checked
+const maxHousekeepingInterval = time.Minute
+
+var (
+	defaultNotifier Notifier
+	defaultMu       sync.Mutex
+)
+
+// Notifier sends alerts to the configured channels
+type Notifier interface {
+	// Notify sends an alert, subject to throttling and digest batching
+	Notify(alert *model.Alert)
+	// Resolve reports that the condition behind an alert rule and dedup key has
+	// cleared, resolving any incident opened for it
+	Resolve(rule string, dedupKey string)
+	// FlushDigest sends the pending digest immediately
+	FlushDigest()
+	// Close sends the pending digest, waits for in-flight notifications and
+	// closes channels that hold resources
+	Close() error
+}
+
+// NotificationEntry is an alert in a notification along with the number of
+// identical alerts it stands for
+type NotificationEntry struct {
+	Alert *model.Alert
+	Count int
+}
+
+// Notification is a single message sent to a channel: either one alert or a
+// digest of several
+type Notification struct {
+	Digest  bool
+	Entries []NotificationEntry
+	SentAt  time.Time
+}
+
+// Subject returns a one-line summary of the notification
+func (n *Notification) Subject() string {
+	if n.Digest {
+		return fmt.Sprintf("[AZF] Alert digest: %d alerts", n.alertCount())
+	}
+	alert := n.Entries[0].Alert
+	return fmt.Sprintf("[AZF][%s] %s", alert.Severity().Value(), alert.Title())
+}
+
+// Text returns the plain text body of the notification
+func (n *Notification) Text() string {
+	var b strings.Builder
+	for i, entry := range n.Entries {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		alert := entry.Alert
+		if n.Digest {
+			fmt.Fprintf(&b, "- [%s] %s", alert.Severity().Value(), alert.Title())
+			if entry.Count > 1 {
+				fmt.Fprintf(&b, " (x%d)", entry.Count)
+			}
+			continue
+		}
+		b.WriteString(alert.Message())
+		if entry.Count > 1 {
+			fmt.Fprintf(&b, "\n%d similar alerts were suppressed since the last notification.", entry.Count-1)
+		}
+	}
+	return b.String()
+}
+
+// Severity returns the highest severity of the alerts in the notification
+func (n *Notification) Severity() *model.AlertSeverity {
+	severity := model.AlertInfo
+	for _, entry := range n.Entries {
+		if severity.AtMost(entry.Alert.Severity()) {
+			severity = entry.Alert.Severity()
+		}
+	}
+	return severity
+}
+
+func (n *Notification) alertCount() int {
+	count := 0
+	for _, entry := range n.Entries {
+		count += entry.Count
+	}
+	return count
+}
+
+// payload returns the JSON body sent to generic webhooks
+func (n *Notification) payload() map[string]interface{} {
+	alerts := make([]map[string]interface{}, len(n.Entries))
+	for i, entry := range n.Entries {
+		alerts[i] = map[string]interface{}{
+			"id":        entry.Alert.ID(),
+			"rule":      entry.Alert.Rule(),
+			"severity":  entry.Alert.Severity().Value(),
+			"title":     entry.Alert.Title(),
+			"message":   entry.Alert.Message(),
+			"dedup_key": entry.Alert.DedupKey(),
+			"fired_at":  entry.Alert.FiredAt(),
+			"count":     entry.Count,
+			"details":   entry.Alert.Details(),
+		}
+	}
+	return map[string]interface{}{
+		"subject": n.Subject(),
+		"digest":  n.Digest,
+		"sent_at": n.SentAt,
+		"alerts":  alerts,
+	}
+}
+
+// throttleState tracks when an alert key was last sent and how many repeats were held back since
+type throttleState struct {
+	lastSent   time.Time
+	suppressed int
+}
+
+// notifier implements Notifier
+type notifier struct {
+	cfg            config.NotificationConfig
+	channels       []Channel
+	digestSeverity *model.AlertSeverity // nil when digest mode is off
+
+	mu          sync.Mutex
+	throttle    map[string]*throttleState
+	digest      map[string]*NotificationEntry
+	digestOrder []string
+	lastDigest  time.Time
+
+	sending   sync.WaitGroup
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewNotifier creates a notifier that sends alerts to channels using the
+// throttle and digest settings in cfg
+func NewNotifier(cfg config.NotificationConfig, channels []Channel) Notifier {
+	n := &notifier{
+		cfg:        cfg,
+		channels:   channels,
+		throttle:   make(map[string]*throttleState),
+		digest:     make(map[string]*NotificationEntry),
+		lastDigest: time.Now(),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+
+	if cfg.DigestEnabled && cfg.DigestInterval > 0 {
+		severity, err := model.NewAlertSeverity(cfg.DigestSeverity)
+		if err != nil {
+			logger.Warn("Invalid digest severity, using INFO", zap.String("severity", cfg.DigestSeverity))
+			severity = model.AlertInfo
+		}
+		n.digestSeverity = severity
+	}
+
+	interval := maxHousekeepingInterval
+	if n.digestSeverity != nil && cfg.DigestInterval < interval {
+		interval = cfg.DigestInterval
+	}
+	go n.housekeepingLoop(interval)
+	return n
+}
+
+func (n *notifier) Notify(alert *model.Alert) {
+	if alert == nil {
+		return
+	}
+	key := alert.Rule() + "|" + alert.DedupKey()
+	now := time.Now()
+
+	n.mu.Lock()
+	if n.digestSeverity != nil && alert.Severity().AtMost(n.digestSeverity) {
+		if entry, ok := n.digest[key]; ok {
+			entry.Count++
+		} else {
+			n.digest[key] = &NotificationEntry{Alert: alert, Count: 1}
+			n.digestOrder = append(n.digestOrder, key)
+		}
+		n.mu.Unlock()
+		return
+	}
+
+	state, ok := n.throttle[key]
+	if ok && now.Sub(state.lastSent) < n.throttleWindow(alert.Rule()) {
+		state.suppressed++
+		n.mu.Unlock()
+		logger.Debug("Alert throttled",
+			zap.String("rule", alert.Rule()),
+			zap.String("dedup_key", alert.DedupKey()),
+			zap.Int("suppressed", state.suppressed))
+		return
+	}
+
+	count := 1
+	if ok {
+		count += state.suppressed
+	}
+	n.throttle[key] = &throttleState{lastSent: now}
+	n.mu.Unlock()
+
+	n.dispatch(&Notification{
+		Entries: []NotificationEntry{{Alert: alert, Count: count}},
+		SentAt:  now,
+	})
+}
+
+func (n *notifier) Resolve(rule string, dedupKey string) {
+	if dedupKey == "" {
+		dedupKey = rule
+	}
+
+	// The next alert for this key starts a new incident, so it must not be throttled
+	n.mu.Lock()
+	delete(n.throttle, rule+"|"+dedupKey)
+	n.mu.Unlock()
+
+	var resolvers []Resolver
+	for _, channel := range n.channels {
+		if resolver, ok := channel.(Resolver); ok {
+			resolvers = append(resolvers, resolver)
+		}
+	}
+	if len(resolvers) == 0 {
+		return
+	}
+
+	n.sending.Add(1)
+	go func() {
+		defer n.sending.Done()
+		for _, resolver := range resolvers {
+			ctx, cancel := context.WithTimeout(context.Background(), n.cfg.Timeout)
+			if err := resolver.Resolve(ctx, rule, dedupKey); err != nil {
+				logger.Warn("Failed to resolve alert",
+					zap.String("rule", rule),
+					zap.String("dedup_key", dedupKey),
+					zap.Error(err))
+			}
+			cancel()
+		}
+	}()
+}
+
+func (n *notifier) FlushDigest() {
+	n.mu.Lock()
+	entries := make([]NotificationEntry, 0, len(n.digestOrder))
+	for _, key := range n.digestOrder {
+		entries = append(entries, *n.digest[key])
+	}
+	n.digest = make(map[string]*NotificationEntry)
+	n.digestOrder = nil
+	n.lastDigest = time.Now()
+	n.mu.Unlock()
+
+	if len(entries) == 0 {
+		return
+	}
+	n.dispatch(&Notification{
+		Digest:  true,
+		Entries: entries,
+		SentAt:  time.Now(),
+	})
+}
+
+func (n *notifier) Close() error {
+	n.closeOnce.Do(func() {
+		close(n.stop)
+		<-n.done
+		n.FlushDigest()
+	})
+	n.sending.Wait()
+
+	for _, channel := range n.channels {
+		if closer, ok := channel.(io.Closer); ok {
+			closer.Close()
+		}
+	}
+	return nil
+}
+
+// throttleWindow returns the minimum time between notifications for rule
+func (n *notifier) throttleWindow(rule string) time.Duration {
+	if window, ok := n.cfg.RuleThrottleWindows[rule]; ok {
+		return window
+	}
+	return n.cfg.ThrottleWindow
+}
+
+// dispatch sends a notification to every channel in the background
+func (n *notifier) dispatch(notification *Notification) {
+	if len(n.channels) == 0 {
+		logger.Info("Alert raised but no notification channels are configured",
+			zap.String("subject", notification.Subject()))
+		return
+	}
+
+	n.sending.Add(1)
+	go func() {
+		defer n.sending.Done()
+		for _, channel := range n.channels {
+			ctx, cancel := context.WithTimeout(context.Background(), n.cfg.Timeout)
+			if err := channel.Send(ctx, notification); err != nil {
+				logger.Warn("Failed to send alert notification",
+					zap.String("channel", channel.Name()),
+					zap.String("subject", notification.Subject()),
+					zap.Error(err))
+			}
+			cancel()
+		}
+	}()
+}
+
+// housekeepingLoop sends the digest when due and prunes expired throttle state
+func (n *notifier) housekeepingLoop(interval time.Duration) {
+	defer close(n.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			n.pruneThrottle(now)
+			n.mu.Lock()
+			due := n.digestSeverity != nil && now.Sub(n.lastDigest) >= n.cfg.DigestInterval
+			n.mu.Unlock()
+			if due {
+				n.FlushDigest()
+			}
+		case <-n.stop:
+			return
+		}
+	}
+}
+
+// pruneThrottle drops throttle state whose window has passed. State holding
+// suppressed repeats is kept so the count is reported with the next alert.
+func (n *notifier) pruneThrottle(now time.Time) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for key, state := range n.throttle {
+		rule, _, _ := strings.Cut(key, "|")
+		if state.suppressed == 0 && now.Sub(state.lastSent) >= n.throttleWindow(rule) {
+			delete(n.throttle, key)
+		}
+	}
+}
+
+// Default returns the shared notifier, creating it from the environment
+// configuration on first use
+func Default() Notifier {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if defaultNotifier == nil {
+		cfg := config.GetNotificationConfig()
+		defaultNotifier = NewNotifier(cfg, NewChannels(cfg))
+	}
+	return defaultNotifier
+}
+
+// SetDefault replaces the shared notifier
+func SetDefault(n Notifier) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultNotifier = n
+}
+
+// Close sends any pending digest of the shared notifier and releases it
+func Close() error {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if defaultNotifier == nil {
+		return nil
+	}
+	err := defaultNotifier.Close()
+	defaultNotifier = nil
+	return err
+}
@@ -0,0 +1,121 @@
+package notification
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aruncs31s/azf/config"
+	"github.com/aruncs31s/azf/domain/model"
+)
+
+// recordingChannel keeps every notification it is asked to send
+type recordingChannel struct {
+	mu       sync.Mutex
+	sent     []*Notification
+	resolved []string
+}
+
+func (r *recordingChannel) Name() string {
+	return "recording"
+}
+
+func (r *recordingChannel) Send(ctx context.Context, notification *Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = append(r.sent, notification)
+	return nil
+}
+
+func (r *recordingChannel) Resolve(ctx context.Context, rule string, dedupKey string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.resolved = append(r.resolved, rule+"|"+dedupKey)
+	return nil
+}
+
+func newTestAlert(t *testing.T, rule string, severity *model.AlertSeverity, dedupKey string) *model.Alert {
+	alert, err := model.NewAlert("id-"+rule, rule, severity, "Test alert", "Something happened", dedupKey, time.Now(), nil)
+	if err != nil {
+		t.Fatalf("failed to create alert: %v", err)
+	}
+	return alert
+}
+
+// TestNotifierThrottle tests that repeats within the rule window are held back and counted
+func TestNotifierThrottle(t *testing.T) {
+	channel := &recordingChannel{}
+	n := NewNotifier(config.NotificationConfig{
+		Timeout:             time.Second,
+		ThrottleWindow:      time.Hour,
+		RuleThrottleWindows: map[string]time.Duration{"fast": 0},
+	}, []Channel{channel})
+
+	for i := 0; i < 5; i++ {
+		n.Notify(newTestAlert(t, "slow", model.AlertCritical, "key"))
+	}
+	n.Notify(newTestAlert(t, "slow", model.AlertCritical, "other-key"))
+	for i := 0; i < 3; i++ {
+		n.Notify(newTestAlert(t, "fast", model.AlertCritical, ""))
+	}
+	n.Close()
+
+	if len(channel.sent) != 5 {
+		t.Errorf("Expected 5 notifications (2 throttled keys, 3 unthrottled), got %d", len(channel.sent))
+	}
+}
+
+// TestNotifierDigest tests that low-severity alerts are batched into one digest
+func TestNotifierDigest(t *testing.T) {
+	channel := &recordingChannel{}
+	n := NewNotifier(config.NotificationConfig{
+		Timeout:        time.Second,
+		ThrottleWindow: time.Hour,
+		DigestEnabled:  true,
+		DigestInterval: time.Hour,
+		DigestSeverity: "WARNING",
+	}, []Channel{channel})
+
+	n.Notify(newTestAlert(t, "info", model.AlertInfo, ""))
+	n.Notify(newTestAlert(t, "warn", model.AlertWarning, ""))
+	n.Notify(newTestAlert(t, "warn", model.AlertWarning, ""))
+	n.Close()
+
+	if len(channel.sent) != 1 {
+		t.Fatalf("Expected 1 digest notification, got %d", len(channel.sent))
+	}
+	digest := channel.sent[0]
+	if !digest.Digest {
+		t.Errorf("Expected a digest notification")
+	}
+	if len(digest.Entries) != 2 {
+		t.Errorf("Expected 2 digest entries, got %d", len(digest.Entries))
+	}
+	if digest.alertCount() != 3 {
+		t.Errorf("Expected digest to cover 3 alerts, got %d", digest.alertCount())
+	}
+}
+
+// TestNotifierResolve tests that resolving an alert reaches resolving channels
+// and lets the next alert for the same key through the throttle
+func TestNotifierResolve(t *testing.T) {
+	channel := &recordingChannel{}
+	n := NewNotifier(config.NotificationConfig{
+		Timeout:        time.Second,
+		ThrottleWindow: time.Hour,
+	}, []Channel{channel})
+
+	n.Notify(newTestAlert(t, "pipeline", model.AlertCritical, ""))
+	n.Notify(newTestAlert(t, "pipeline", model.AlertCritical, ""))
+	n.Resolve("pipeline", "")
+	n.Notify(newTestAlert(t, "pipeline", model.AlertCritical, ""))
+	n.Close()
+
+	if len(channel.sent) != 2 {
+		t.Errorf("Expected 2 notifications (one throttled), got %d", len(channel.sent))
+	}
+	if len(channel.resolved) != 1 || channel.resolved[0] != "pipeline|pipeline" {
+		t.Errorf("Expected one resolve for pipeline|pipeline, got %v", channel.resolved)
+	}
+}
@@ -0,0 +1,366 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aruncs31s/azf/config"
+	"github.com/aruncs31s/azf/domain/api_usage"
+	"github.com/aruncs31s/azf/domain/model"
+	"github.com/aruncs31s/azf/domain/repository"
+	"github.com/aruncs31s/azf/shared/logger"
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	// webPushRecordSize is the aes128gcm record size; payloads fit in one record
+	webPushRecordSize = 4096
+	// maxWebPushBodyLength keeps the encrypted payload under the 4KB push service limit
+	maxWebPushBodyLength = 2000
+	// vapidTokenLifetime is how long a signed VAPID token is valid (at most 24h)
+	vapidTokenLifetime = 12 * time.Hour
+)
+
+// ErrWebPushSubscriptionGone is returned when the push service reports that a
+// subscription has expired or been revoked by the browser
+var ErrWebPushSubscriptionGone = errors.New("web push subscription is no longer valid")
+
+// GenerateVAPIDKeys creates a new P-256 application server key pair, encoded
+// as unpadded base64url like browsers expect
+func GenerateVAPIDKeys() (*api_usage.WebPushVAPIDKeys, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate VAPID keys: %w", err)
+	}
+	publicKey, err := key.PublicKey.Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode VAPID public key: %w", err)
+	}
+	privateKey, err := key.Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode VAPID private key: %w", err)
+	}
+	return &api_usage.WebPushVAPIDKeys{
+		PublicKey:  base64.RawURLEncoding.EncodeToString(publicKey),
+		PrivateKey: base64.RawURLEncoding.EncodeToString(privateKey),
+	}, nil
+}
+
+// WebPushSender signs (RFC 8292) and encrypts (RFC 8291) push messages and
+// posts them to browser push services
+type WebPushSender struct {
+	publicKey  string
+	privateKey *ecdsa.PrivateKey
+	subject    string
+	ttl        time.Duration
+	client     *http.Client
+}
+
+// NewWebPushSender creates a sender from a base64url VAPID key pair
+func NewWebPushSender(publicKey, privateKey, subject string, ttl time.Duration, client *http.Client) (*WebPushSender, error) {
+	raw, err := decodeBase64URL(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	key, err := ecdsa.ParseRawPrivateKey(elliptic.P256(), raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	derived, err := key.PublicKey.Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	if base64.RawURLEncoding.EncodeToString(derived) != strings.TrimRight(publicKey, "=") {
+		return nil, fmt.Errorf("VAPID public key does not match the private key")
+	}
+
+	return &WebPushSender{
+		publicKey:  base64.RawURLEncoding.EncodeToString(derived),
+		privateKey: key,
+		subject:    subject,
+		ttl:        ttl,
+		client:     client,
+	}, nil
+}
+
+// LoadWebPushSender creates a sender from the configured VAPID keys, or from
+// a key pair generated once and stored through repo when none are configured
+func LoadWebPushSender(cfg config.WebPushConfig, repo repository.WebPushSubscriptionRepository, client *http.Client) (*WebPushSender, error) {
+	publicKey, privateKey := cfg.VAPIDPublicKey, cfg.VAPIDPrivateKey
+	if publicKey == "" || privateKey == "" {
+		keys, err := repo.LoadOrCreateVAPIDKeys(GenerateVAPIDKeys)
+		if err != nil {
+			return nil, err
+		}
+		publicKey, privateKey = keys.PublicKey, keys.PrivateKey
+	}
+	return NewWebPushSender(publicKey, privateKey, cfg.Subject, cfg.TTL, client)
+}
+
+// PublicKey returns the application server key browsers subscribe with
+func (s *WebPushSender) PublicKey() string {
+	return s.publicKey
+}
+
+// Send encrypts payload for the subscription and posts it to its push service.
+// urgency is one of "very-low", "low", "normal" or "high".
+func (s *WebPushSender) Send(ctx context.Context, subscription *api_usage.WebPushSubscription, payload []byte, urgency string) error {
+	body, err := encryptWebPushPayload(subscription, payload)
+	if err != nil {
+		return err
+	}
+	token, err := s.vapidToken(subscription.Endpoint)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create push request: %w", err)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("vapid t=%s, k=%s", token, s.publicKey))
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", strconv.Itoa(int(s.ttl.Seconds())))
+	if urgency != "" {
+		req.Header.Set("Urgency", urgency)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send push message: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return ErrWebPushSubscriptionGone
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return fmt.Errorf("push service returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// SendTestMessage pushes a test notification to one subscription
+func (s *WebPushSender) SendTestMessage(subscription *api_usage.WebPushSubscription) error {
+	payload, err := WebPushPayload("[AZF] Test notification", "Push alerts are working for this browser.", model.AlertInfo.Value())
+	if err != nil {
+		return err
+	}
+	timeout := s.client.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return s.Send(ctx, subscription, payload, "normal")
+}
+
+// vapidToken signs a VAPID JWT for the origin of the push endpoint
+func (s *WebPushSender) vapidToken(endpoint string) (string, error) {
+	parsed, err := url.Parse(endpoint)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return "", fmt.Errorf("invalid push endpoint: %s", endpoint)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"aud": parsed.Scheme + "://" + parsed.Host,
+		"exp": time.Now().Add(vapidTokenLifetime).Unix(),
+		"sub": s.subject,
+	})
+	signed, err := token.SignedString(s.privateKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign VAPID token: %w", err)
+	}
+	return signed, nil
+}
+
+// encryptWebPushPayload encrypts payload with the aes128gcm content coding
+// using the subscription's p256dh key and auth secret (RFC 8291)
+func encryptWebPushPayload(subscription *api_usage.WebPushSubscription, payload []byte) ([]byte, error) {
+	uaPublicBytes, err := decodeBase64URL(subscription.P256dh)
+	if err != nil {
+		return nil, fmt.Errorf("invalid subscription p256dh key: %w", err)
+	}
+	authSecret, err := decodeBase64URL(subscription.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("invalid subscription auth secret: %w", err)
+	}
+	uaPublic, err := ecdh.P256().NewPublicKey(uaPublicBytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid subscription p256dh key: %w", err)
+	}
+
+	asPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate push encryption key: %w", err)
+	}
+	asPublicBytes := asPrivate.PublicKey().Bytes()
+	sharedSecret, err := asPrivate.ECDH(uaPublic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive push encryption key: %w", err)
+	}
+
+	keyInfo := "WebPush: info\x00" + string(uaPublicBytes) + string(asPublicBytes)
+	ikm, err := hkdf.Key(sha256.New, sharedSecret, authSecret, keyInfo, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive push encryption key: %w", err)
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate push encryption salt: %w", err)
+	}
+	prk, err := hkdf.Extract(sha256.New, ikm, salt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive push encryption key: %w", err)
+	}
+	contentKey, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive push encryption key: %w", err)
+	}
+	nonce, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: nonce\x00", 12)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive push encryption nonce: %w", err)
+	}
+
+	block, err := aes.NewCipher(contentKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt push message: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt push message: %w", err)
+	}
+
+	// A single record, terminated by the last-record delimiter
+	plaintext := append(append([]byte{}, payload...), 0x02)
+	if len(plaintext)+gcm.Overhead() > webPushRecordSize {
+		return nil, fmt.Errorf("push message is too large")
+	}
+
+	header := make([]byte, 0, 16+4+1+len(asPublicBytes))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, webPushRecordSize)
+	header = append(header, byte(len(asPublicBytes)))
+	header = append(header, asPublicBytes...)
+
+	return gcm.Seal(header, nonce, plaintext, nil), nil
+}
+
+// decodeBase64URL accepts base64url with or without padding, as browsers differ
+func decodeBase64URL(value string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+}
+
+// webPushChannel pushes notifications to the browsers of admins whose
+// subscriptions accept the notification's severity
+type webPushChannel struct {
+	sender *WebPushSender
+	repo   repository.WebPushSubscriptionRepository
+}
+
+// NewWebPushChannel creates a channel that pushes notifications to subscribed admin browsers
+func NewWebPushChannel(sender *WebPushSender, repo repository.WebPushSubscriptionRepository) Channel {
+	return &webPushChannel{sender: sender, repo: repo}
+}
+
+func (w *webPushChannel) Name() string {
+	return "webpush"
+}
+
+func (w *webPushChannel) Send(ctx context.Context, notification *Notification) error {
+	subscriptions, err := w.repo.FindAll()
+	if err != nil {
+		return fmt.Errorf("failed to load web push subscriptions: %w", err)
+	}
+	if subscriptions == nil || len(*subscriptions) == 0 {
+		return nil
+	}
+
+	severity := notification.Severity()
+	payload, err := WebPushPayload(notification.Subject(), notification.Text(), severity.Value())
+	if err != nil {
+		return err
+	}
+	urgency := "normal"
+	if severity == model.AlertCritical {
+		urgency = "high"
+	}
+
+	var failed int
+	for i := range *subscriptions {
+		subscription := &(*subscriptions)[i]
+		minSeverity, err := model.NewAlertSeverity(subscription.MinSeverity)
+		if err != nil {
+			minSeverity = model.AlertCritical
+		}
+		if !minSeverity.AtMost(severity) {
+			continue
+		}
+
+		err = w.sender.Send(ctx, subscription, payload, urgency)
+		switch {
+		case errors.Is(err, ErrWebPushSubscriptionGone):
+			logger.Info("Removing expired web push subscription",
+				zap.String("admin", subscription.AdminUsername),
+				zap.String("subscription_id", subscription.ID))
+			if err := w.repo.DeleteByEndpoint(subscription.Endpoint); err != nil {
+				logger.Warn("Failed to remove expired web push subscription", zap.Error(err))
+			}
+		case err != nil:
+			failed++
+			logger.Warn("Failed to push alert to admin browser",
+				zap.String("admin", subscription.AdminUsername),
+				zap.String("subscription_id", subscription.ID),
+				zap.Error(err))
+		default:
+			if err := w.repo.MarkPushed(subscription.ID, time.Now()); err != nil {
+				logger.Debug("Failed to record web push delivery", zap.Error(err))
+			}
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to push to %d subscriptions", failed)
+	}
+	return nil
+}
+
+// WebPushPayload builds the JSON message read by the admin dashboard service worker
+func WebPushPayload(title, body, severity string) ([]byte, error) {
+	if len(body) > maxWebPushBodyLength {
+		body = body[:maxWebPushBodyLength] + "..."
+	}
+	payload, err := json.Marshal(map[string]string{
+		"title":    title,
+		"body":     body,
+		"severity": severity,
+		"url":      "/admin-ui",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode push message: %w", err)
+	}
+	return payload, nil
+}
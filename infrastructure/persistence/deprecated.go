@@ -0,0 +1,65 @@
+// Package persistence is a deprecated alias of the repository implementations,
+// which are internal to azf as of the v1 package layout.
+//
+// Deprecated: repositories are an implementation detail of azf and now live
+// in an internal package. Configure storage through the azf package instead;
+// these aliases will be removed in the next major version.
+package persistence
+
+import "github.com/aruncs31s/azf/internal/persistence"
+
+type (
+	CasbinRuleAdapter        = persistence.CasbinRuleAdapter
+	CasbinRuleModel          = persistence.CasbinRuleModel
+	DBPoolConfig             = persistence.DBPoolConfig
+	GormTransactionManager   = persistence.GormTransactionManager
+	GormUserRepository       = persistence.GormUserRepository
+	RoleData                 = persistence.RoleData
+	TextCodec                = persistence.TextCodec
+	TextDictionaryEntry      = persistence.TextDictionaryEntry
+	TextEncoding             = persistence.TextEncoding
+	UserModel                = persistence.UserModel
+	UserRoleModel            = persistence.UserRoleModel
+	WebhookDeliveryModel     = persistence.WebhookDeliveryModel
+	WebhookEventModel        = persistence.WebhookEventModel
+	WebhookSubscriptionModel = persistence.WebhookSubscriptionModel
+)
+
+const (
+	TextEncodingCompressed   = persistence.TextEncodingCompressed
+	TextEncodingDictionary   = persistence.TextEncodingDictionary
+	UsageHourlyAggregateView = persistence.UsageHourlyAggregateView
+)
+
+var (
+	ErrForeignKeyViolation            = persistence.ErrForeignKeyViolation
+	ErrUniqueViolation                = persistence.ErrUniqueViolation
+	BackfillEncodedText               = persistence.BackfillEncodedText
+	BackfillUserRoles                 = persistence.BackfillUserRoles
+	ConfigureConnectionPool           = persistence.ConfigureConnectionPool
+	ConfigureConnectionPoolFromConfig = persistence.ConfigureConnectionPoolFromConfig
+	DefaultDBPoolConfig               = persistence.DefaultDBPoolConfig
+	GetDBStats                        = persistence.GetDBStats
+	HasContinuousAggregate            = persistence.HasContinuousAggregate
+	HealthCheck                       = persistence.HealthCheck
+	IsEncodedText                     = persistence.IsEncodedText
+	IsTimescaleAvailable              = persistence.IsTimescaleAvailable
+	NewAPIUsageRepository             = persistence.NewAPIUsageRepository
+	NewAPIUsageStatsRepository        = persistence.NewAPIUsageStatsRepository
+	NewAlertIncidentRepository        = persistence.NewAlertIncidentRepository
+	NewCasbinRuleAdapter              = persistence.NewCasbinRuleAdapter
+	NewEncodedTextBackfiller          = persistence.NewEncodedTextBackfiller
+	NewFeatureFlagRepository          = persistence.NewFeatureFlagRepository
+	NewGormUsageAnalyticsBackend      = persistence.NewGormUsageAnalyticsBackend
+	NewGormUserRepository             = persistence.NewGormUserRepository
+	NewRateLimitOverrideRepository    = persistence.NewRateLimitOverrideRepository
+	NewTextCodec                      = persistence.NewTextCodec
+	NewTransactionManager             = persistence.NewTransactionManager
+	NewUsageAnnotationRepository      = persistence.NewUsageAnnotationRepository
+	NewUserRepository                 = persistence.NewUserRepository
+	NewWebPushSubscriptionRepository  = persistence.NewWebPushSubscriptionRepository
+	NewWebhookDeliveryRepository      = persistence.NewWebhookDeliveryRepository
+	NewWebhookEventRepository         = persistence.NewWebhookEventRepository
+	NewWebhookSubscriptionRepository  = persistence.NewWebhookSubscriptionRepository
+	SetupTimescale                    = persistence.SetupTimescale
+)
@@ -0,0 +1,128 @@
+package saml
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+)
+
+// canonicalize returns the exclusive canonical form (xml-exc-c14n#, without
+// comments) of el, leaving out the element skip. inclusive lists the
+// prefixes of the InclusiveNamespaces PrefixList, with #default for the
+// default namespace.
+func canonicalize(el *element, skip *element, inclusive []string) []byte {
+	c := canonicalizer{skip: skip}
+	for _, prefix := range inclusive {
+		if prefix == "#default" {
+			prefix = ""
+		}
+		c.inclusive = append(c.inclusive, prefix)
+	}
+	c.element(el, map[string]string{})
+	return c.buf.Bytes()
+}
+
+type canonicalizer struct {
+	buf       bytes.Buffer
+	skip      *element
+	inclusive []string
+}
+
+// element writes el and its subtree. rendered holds the namespaces declared
+// by the output ancestors of el.
+func (c *canonicalizer) element(el *element, rendered map[string]string) {
+	// Exclusive canonicalization declares the namespaces an element visibly
+	// uses, unless an output ancestor already declared them
+	used := map[string]bool{el.prefix: true}
+	for _, a := range el.attrs {
+		if a.prefix != "" {
+			used[a.prefix] = true
+		}
+	}
+	for _, prefix := range c.inclusive {
+		used[prefix] = true
+	}
+
+	var declarations []string
+	scope := rendered
+	for prefix := range used {
+		if prefix == "xml" {
+			continue
+		}
+		space, ok := el.lookup(prefix)
+		if !ok && prefix != "" {
+			continue
+		}
+		previous, declared := rendered[prefix]
+		if declared && previous == space || !declared && prefix == "" && space == "" {
+			continue
+		}
+		if len(declarations) == 0 {
+			scope = make(map[string]string, len(rendered)+1)
+			for p, s := range rendered {
+				scope[p] = s
+			}
+		}
+		scope[prefix] = space
+		declarations = append(declarations, prefix)
+	}
+	sort.Strings(declarations)
+
+	attrs := make([]attr, len(el.attrs))
+	copy(attrs, el.attrs)
+	sort.Slice(attrs, func(i, j int) bool {
+		if attrs[i].space != attrs[j].space {
+			return attrs[i].space < attrs[j].space
+		}
+		return attrs[i].local < attrs[j].local
+	})
+
+	c.buf.WriteString("<" + el.name())
+	for _, prefix := range declarations {
+		if prefix == "" {
+			c.buf.WriteString(` xmlns="`)
+		} else {
+			c.buf.WriteString(" xmlns:" + prefix + `="`)
+		}
+		c.buf.WriteString(escapeAttr(scope[prefix]) + `"`)
+	}
+	for _, a := range attrs {
+		name := a.local
+		if a.prefix != "" {
+			name = a.prefix + ":" + a.local
+		}
+		c.buf.WriteString(" " + name + `="` + escapeAttr(a.value) + `"`)
+	}
+	c.buf.WriteString(">")
+
+	for _, n := range el.children {
+		switch child := n.(type) {
+		case *element:
+			if child != c.skip {
+				c.element(child, scope)
+			}
+		case text:
+			c.buf.WriteString(escapeText(string(child)))
+		case procInst:
+			c.buf.WriteString("<?" + child.target)
+			if child.inst != "" {
+				c.buf.WriteString(" " + child.inst)
+			}
+			c.buf.WriteString("?>")
+		}
+	}
+	c.buf.WriteString("</" + el.name() + ">")
+}
+
+var (
+	textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;")
+	attrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", `"`, "&quot;", "\t", "&#x9;", "\n", "&#xA;", "\r", "&#xD;")
+)
+
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+func escapeAttr(s string) string {
+	return attrEscaper.Replace(s)
+}
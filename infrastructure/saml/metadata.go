@@ -0,0 +1,146 @@
+package saml
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	nsMetadata = "urn:oasis:names:tc:SAML:2.0:metadata"
+
+	// BindingHTTPRedirect sends messages as deflated query parameters
+	BindingHTTPRedirect = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect"
+	// BindingHTTPPost sends messages as form fields
+	BindingHTTPPost = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+
+	// metadataTimeout bounds the fetch of identity provider metadata
+	metadataTimeout = 10 * time.Second
+	// metadataMaxBytes bounds the size of identity provider metadata
+	metadataMaxBytes = 1 << 20
+)
+
+// IdentityProvider is what the service provider trusts of an identity
+// provider: its entity ID, where to send users to sign in and the
+// certificates its responses are signed with
+type IdentityProvider struct {
+	EntityID     string
+	SSOURL       string
+	Certificates []*x509.Certificate
+}
+
+// ParseIdentityProviderMetadata reads an identity provider from its
+// metadata, the first entity with an IDPSSODescriptor of an
+// EntitiesDescriptor or a single EntityDescriptor. The metadata must come
+// from a trusted source, as its own signature is not checked.
+func ParseIdentityProviderMetadata(data []byte) (*IdentityProvider, error) {
+	root, err := parseXML(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SAML metadata: %w", err)
+	}
+
+	var entity, descriptor *element
+	root.walk(func(el *element) {
+		if entity == nil && el.is(nsMetadata, "EntityDescriptor") {
+			if d := el.child(nsMetadata, "IDPSSODescriptor"); d != nil {
+				entity, descriptor = el, d
+			}
+		}
+	})
+	if entity == nil {
+		return nil, fmt.Errorf("SAML metadata has no identity provider")
+	}
+
+	idp := &IdentityProvider{EntityID: entity.attr("entityID")}
+	for _, service := range descriptor.childrenNamed(nsMetadata, "SingleSignOnService") {
+		if service.attr("Binding") == BindingHTTPRedirect {
+			idp.SSOURL = service.attr("Location")
+			break
+		}
+	}
+	for _, key := range descriptor.childrenNamed(nsMetadata, "KeyDescriptor") {
+		keyInfo := key.child(nsDSig, "KeyInfo")
+		if use := key.attr("use"); keyInfo == nil || use != "" && use != "signing" {
+			continue
+		}
+		for _, data := range keyInfo.childrenNamed(nsDSig, "X509Data") {
+			for _, encoded := range data.childrenNamed(nsDSig, "X509Certificate") {
+				der, err := decodeBase64(encoded.text())
+				if err != nil {
+					return nil, fmt.Errorf("invalid certificate in SAML metadata: %w", err)
+				}
+				cert, err := x509.ParseCertificate(der)
+				if err != nil {
+					return nil, fmt.Errorf("invalid certificate in SAML metadata: %w", err)
+				}
+				idp.Certificates = append(idp.Certificates, cert)
+			}
+		}
+	}
+
+	switch {
+	case idp.EntityID == "":
+		return nil, fmt.Errorf("SAML metadata has no entity ID")
+	case idp.SSOURL == "":
+		return nil, fmt.Errorf("SAML identity provider %s has no HTTP-Redirect sign-in service", idp.EntityID)
+	case len(idp.Certificates) == 0:
+		return nil, fmt.Errorf("SAML identity provider %s has no signing certificate", idp.EntityID)
+	}
+	return idp, nil
+}
+
+// LoadIdentityProviderMetadata reads identity provider metadata from an
+// http(s) URL or a file
+func LoadIdentityProviderMetadata(ctx context.Context, location string) (*IdentityProvider, error) {
+	if !strings.HasPrefix(location, "http://") && !strings.HasPrefix(location, "https://") {
+		data, err := os.ReadFile(location)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read SAML metadata: %w", err)
+		}
+		return ParseIdentityProviderMetadata(data)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, metadataTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch SAML metadata: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("SAML metadata %s returned status %d", location, resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, metadataMaxBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch SAML metadata: %w", err)
+	}
+	return ParseIdentityProviderMetadata(data)
+}
+
+// Metadata returns the metadata of the service provider, which the identity
+// provider is set up with
+func (sp *ServiceProvider) Metadata() []byte {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	b.WriteString(`<md:EntityDescriptor xmlns:md="` + nsMetadata + `" entityID="` + escapeAttr(sp.EntityID) + `">`)
+	fmt.Fprintf(&b, `<md:SPSSODescriptor AuthnRequestsSigned="%t" WantAssertionsSigned="true" protocolSupportEnumeration="%s">`,
+		sp.Key != nil, nsProtocol)
+	if sp.Certificate != nil {
+		b.WriteString(`<md:KeyDescriptor use="signing"><ds:KeyInfo xmlns:ds="` + nsDSig + `"><ds:X509Data><ds:X509Certificate>`)
+		b.WriteString(base64.StdEncoding.EncodeToString(sp.Certificate.Raw))
+		b.WriteString(`</ds:X509Certificate></ds:X509Data></ds:KeyInfo></md:KeyDescriptor>`)
+	}
+	b.WriteString(`<md:AssertionConsumerService Binding="` + BindingHTTPPost + `" Location="` + escapeAttr(sp.ACSURL) + `" index="0" isDefault="true"/>`)
+	b.WriteString(`</md:SPSSODescriptor></md:EntityDescriptor>`)
+	return []byte(b.String())
+}
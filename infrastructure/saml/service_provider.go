@@ -0,0 +1,318 @@
+// Package saml implements the service provider side of SAML 2.0 web browser
+// SSO: sign-in requests sent with the HTTP-Redirect binding and responses
+// received with the HTTP-POST binding.
+//
+// Responses are only trusted when the response or its assertion carries an
+// enveloped XML signature (exclusive canonicalization, RSA with SHA-256 or
+// stronger) made by a certificate from the identity provider's metadata.
+// Only the signed element is read afterwards, so signature wrapping cannot
+// smuggle in an unsigned assertion. Encrypted assertions are not supported.
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aruncs31s/azf/shared/clock"
+)
+
+const (
+	nsProtocol  = "urn:oasis:names:tc:SAML:2.0:protocol"
+	nsAssertion = "urn:oasis:names:tc:SAML:2.0:assertion"
+
+	statusSuccess       = "urn:oasis:names:tc:SAML:2.0:status:Success"
+	confirmationBearer  = "urn:oasis:names:tc:SAML:2.0:cm:bearer"
+	algRSASHA256        = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"
+	defaultClockSkew    = 90 * time.Second
+	maxResponseBytes    = 1 << 20
+	authnRequestIDBytes = 20
+)
+
+// ErrInvalidResponse is returned for responses that are malformed, unsigned,
+// meant for another service provider or request, or expired
+var ErrInvalidResponse = errors.New("invalid SAML response")
+
+// ServiceProvider signs users in with an identity provider
+type ServiceProvider struct {
+	// EntityID names the service provider to the identity provider
+	EntityID string
+	// ACSURL is the assertion consumer service the identity provider posts
+	// responses to
+	ACSURL string
+	// IDP is the trusted identity provider
+	IDP *IdentityProvider
+	// Key signs sign-in requests when set, with Certificate published in the
+	// metadata
+	Key         *rsa.PrivateKey
+	Certificate *x509.Certificate
+	// ClockSkew is the clock difference with the identity provider that is
+	// tolerated (default: 90 seconds)
+	ClockSkew time.Duration
+	// Clock is the time source (default: clock.Default())
+	Clock clock.Clock
+}
+
+// Assertion is the verified content of a SAML assertion
+type Assertion struct {
+	ID           string
+	Issuer       string
+	NameID       string
+	SessionIndex string
+	// Attributes holds the values of each attribute by its Name, and by its
+	// FriendlyName when one is given
+	Attributes map[string][]string
+	// ExpiresAt is when the assertion may no longer be used
+	ExpiresAt time.Time
+}
+
+// AuthnRequestURL returns the URL sending the user to the identity provider
+// to sign in, and the ID of the request, which the response must answer
+func (sp *ServiceProvider) AuthnRequestURL(relayState string) (string, string, error) {
+	idBytes := make([]byte, authnRequestIDBytes)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", "", err
+	}
+	// IDs must not start with a digit
+	id := "_" + hex.EncodeToString(idBytes)
+
+	request := fmt.Sprintf(`<samlp:AuthnRequest xmlns:samlp="%s" xmlns:saml="%s" ID="%s" Version="2.0" IssueInstant="%s" Destination="%s" AssertionConsumerServiceURL="%s" ProtocolBinding="%s">`+
+		`<saml:Issuer>%s</saml:Issuer><samlp:NameIDPolicy AllowCreate="true"/></samlp:AuthnRequest>`,
+		nsProtocol, nsAssertion, id, sp.now().UTC().Format(time.RFC3339),
+		escapeAttr(sp.IDP.SSOURL), escapeAttr(sp.ACSURL), BindingHTTPPost, escapeText(sp.EntityID))
+
+	var deflated bytes.Buffer
+	writer, err := flate.NewWriter(&deflated, flate.BestCompression)
+	if err != nil {
+		return "", "", err
+	}
+	if _, err := writer.Write([]byte(request)); err != nil {
+		return "", "", err
+	}
+	if err := writer.Close(); err != nil {
+		return "", "", err
+	}
+
+	// The signature of the redirect binding covers the query parameters in
+	// this order, URL-encoded
+	query := "SAMLRequest=" + url.QueryEscape(base64.StdEncoding.EncodeToString(deflated.Bytes()))
+	if relayState != "" {
+		query += "&RelayState=" + url.QueryEscape(relayState)
+	}
+	if sp.Key != nil {
+		query += "&SigAlg=" + url.QueryEscape(algRSASHA256)
+		hashed := crypto.SHA256.New()
+		hashed.Write([]byte(query))
+		signature, err := rsa.SignPKCS1v15(rand.Reader, sp.Key, crypto.SHA256, hashed.Sum(nil))
+		if err != nil {
+			return "", "", err
+		}
+		query += "&Signature=" + url.QueryEscape(base64.StdEncoding.EncodeToString(signature))
+	}
+
+	separator := "?"
+	if strings.Contains(sp.IDP.SSOURL, "?") {
+		separator = "&"
+	}
+	return sp.IDP.SSOURL + separator + query, id, nil
+}
+
+// ParseResponse verifies a base64 encoded response posted to the assertion
+// consumer service in answer to the request with requestID, and returns its
+// assertion
+func (sp *ServiceProvider) ParseResponse(encoded string, requestID string) (*Assertion, error) {
+	assertion, err := sp.parseResponse(encoded, requestID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+	return assertion, nil
+}
+
+func (sp *ServiceProvider) parseResponse(encoded string, requestID string) (*Assertion, error) {
+	if len(encoded) > maxResponseBytes {
+		return nil, fmt.Errorf("response is too large")
+	}
+	if requestID == "" {
+		return nil, fmt.Errorf("no sign-in request is waiting for a response")
+	}
+	data, err := decodeBase64(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("response is not base64: %w", err)
+	}
+	response, err := parseXML(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if !response.is(nsProtocol, "Response") || response.attr("Version") != "2.0" {
+		return nil, fmt.Errorf("document is not a SAML 2.0 response")
+	}
+	if destination := response.attr("Destination"); destination != "" && destination != sp.ACSURL {
+		return nil, fmt.Errorf("response is meant for %s", destination)
+	}
+	if response.attr("InResponseTo") != requestID {
+		return nil, fmt.Errorf("response does not answer the sign-in request")
+	}
+	if issuer := response.child(nsAssertion, "Issuer"); issuer != nil && issuer.text() != sp.IDP.EntityID {
+		return nil, fmt.Errorf("response is issued by %s", issuer.text())
+	}
+	statusCode := response.path(nsProtocol, "Status", "StatusCode")
+	if statusCode == nil {
+		return nil, fmt.Errorf("response has no status")
+	}
+	if status := statusCode.attr("Value"); status != statusSuccess {
+		if nested := statusCode.child(nsProtocol, "StatusCode"); nested != nil {
+			status += " (" + nested.attr("Value") + ")"
+		}
+		return nil, fmt.Errorf("identity provider returned status %s", status)
+	}
+
+	if response.child(nsAssertion, "EncryptedAssertion") != nil {
+		return nil, fmt.Errorf("encrypted assertions are not supported")
+	}
+	assertions := response.childrenNamed(nsAssertion, "Assertion")
+	if len(assertions) != 1 {
+		return nil, fmt.Errorf("expected one assertion, found %d", len(assertions))
+	}
+	assertion := assertions[0]
+
+	// A signed response covers its assertion; otherwise the assertion must
+	// be signed itself
+	responseSigned := hasSignature(response)
+	if responseSigned {
+		if err := verifySignature(response, response, sp.IDP.Certificates); err != nil {
+			return nil, err
+		}
+	}
+	if hasSignature(assertion) || !responseSigned {
+		if err := verifySignature(response, assertion, sp.IDP.Certificates); err != nil {
+			return nil, err
+		}
+	}
+	return sp.readAssertion(assertion, requestID)
+}
+
+// readAssertion checks the issuer, subject confirmation and conditions of a
+// verified assertion and reads its subject and attributes
+func (sp *ServiceProvider) readAssertion(assertion *element, requestID string) (*Assertion, error) {
+	now := sp.now()
+	skew := sp.ClockSkew
+	if skew <= 0 {
+		skew = defaultClockSkew
+	}
+
+	result := &Assertion{ID: assertion.attr("ID"), Attributes: map[string][]string{}}
+	if issuer := assertion.child(nsAssertion, "Issuer"); issuer == nil || issuer.text() != sp.IDP.EntityID {
+		return nil, fmt.Errorf("assertion is not issued by %s", sp.IDP.EntityID)
+	}
+	result.Issuer = sp.IDP.EntityID
+
+	subject := assertion.child(nsAssertion, "Subject")
+	if subject == nil {
+		return nil, fmt.Errorf("assertion has no subject")
+	}
+	if nameID := subject.child(nsAssertion, "NameID"); nameID != nil {
+		result.NameID = nameID.text()
+	}
+	if result.NameID == "" {
+		return nil, fmt.Errorf("assertion has no NameID")
+	}
+
+	// One bearer confirmation must be for this service provider and request
+	confirmed := false
+	for _, confirmation := range subject.childrenNamed(nsAssertion, "SubjectConfirmation") {
+		data := confirmation.child(nsAssertion, "SubjectConfirmationData")
+		if confirmation.attr("Method") != confirmationBearer || data == nil {
+			continue
+		}
+		notOnOrAfter, err := parseTime(data.attr("NotOnOrAfter"))
+		if err != nil || notOnOrAfter.IsZero() || !now.Before(notOnOrAfter.Add(skew)) {
+			continue
+		}
+		if data.attr("Recipient") != sp.ACSURL {
+			continue
+		}
+		if inResponseTo := data.attr("InResponseTo"); inResponseTo != "" && inResponseTo != requestID {
+			continue
+		}
+		confirmed = true
+		if result.ExpiresAt.IsZero() || notOnOrAfter.After(result.ExpiresAt) {
+			result.ExpiresAt = notOnOrAfter
+		}
+	}
+	if !confirmed {
+		return nil, fmt.Errorf("assertion has no valid bearer confirmation for %s", sp.ACSURL)
+	}
+
+	conditions := assertion.child(nsAssertion, "Conditions")
+	if conditions == nil {
+		return nil, fmt.Errorf("assertion has no conditions")
+	}
+	notBefore, err := parseTime(conditions.attr("NotBefore"))
+	if err != nil {
+		return nil, err
+	}
+	if !notBefore.IsZero() && now.Add(skew).Before(notBefore) {
+		return nil, fmt.Errorf("assertion is not valid yet")
+	}
+	notOnOrAfter, err := parseTime(conditions.attr("NotOnOrAfter"))
+	if err != nil {
+		return nil, err
+	}
+	if !notOnOrAfter.IsZero() && !now.Before(notOnOrAfter.Add(skew)) {
+		return nil, fmt.Errorf("assertion has expired")
+	}
+	for _, restriction := range conditions.childrenNamed(nsAssertion, "AudienceRestriction") {
+		allowed := false
+		for _, audience := range restriction.childrenNamed(nsAssertion, "Audience") {
+			allowed = allowed || audience.text() == sp.EntityID
+		}
+		if !allowed {
+			return nil, fmt.Errorf("assertion is not meant for %s", sp.EntityID)
+		}
+	}
+
+	if statement := assertion.child(nsAssertion, "AuthnStatement"); statement != nil {
+		result.SessionIndex = statement.attr("SessionIndex")
+	}
+	for _, statement := range assertion.childrenNamed(nsAssertion, "AttributeStatement") {
+		for _, attribute := range statement.childrenNamed(nsAssertion, "Attribute") {
+			var values []string
+			for _, value := range attribute.childrenNamed(nsAssertion, "AttributeValue") {
+				values = append(values, value.text())
+			}
+			for _, name := range []string{attribute.attr("Name"), attribute.attr("FriendlyName")} {
+				if name != "" {
+					result.Attributes[name] = append(result.Attributes[name], values...)
+				}
+			}
+		}
+	}
+	return result, nil
+}
+
+func (sp *ServiceProvider) now() time.Time {
+	return clock.Or(sp.Clock).Now()
+}
+
+// parseTime parses an xs:dateTime attribute; empty values give the zero time
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q", value)
+	}
+	return t, nil
+}
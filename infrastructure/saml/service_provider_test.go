@@ -0,0 +1,176 @@
+package saml
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aruncs31s/azf/shared/clock"
+)
+
+const (
+	testIDP       = "https://idp.example.com"
+	testSP        = "https://app.example.com/admin-ui/saml/metadata"
+	testACS       = "https://app.example.com/admin-ui/saml/acs"
+	testRequestID = "_request-1"
+)
+
+var testNow = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func TestCanonicalize(t *testing.T) {
+	root, err := parseXML([]byte(`<a:r xmlns:a="urn:a" xmlns:b="urn:b" xmlns="urn:d"><a:c b:x="1" y="2"/><d>t&amp;&#13;</d></a:r>`))
+	if err != nil {
+		t.Fatalf("parseXML() error = %v", err)
+	}
+
+	first := root.children[0].(*element)
+	if got, want := string(canonicalize(first, nil, nil)), `<a:c xmlns:a="urn:a" xmlns:b="urn:b" y="2" b:x="1"></a:c>`; got != want {
+		t.Errorf("canonicalize() = %s, want %s", got, want)
+	}
+	if got, want := string(canonicalize(root, first, nil)), `<a:r xmlns:a="urn:a"><d xmlns="urn:d">t&amp;&#xD;</d></a:r>`; got != want {
+		t.Errorf("canonicalize() = %s, want %s", got, want)
+	}
+	if got, want := string(canonicalize(first, nil, []string{"#default"})), `<a:c xmlns="urn:d" xmlns:a="urn:a" xmlns:b="urn:b" y="2" b:x="1"></a:c>`; got != want {
+		t.Errorf("canonicalize() with inclusive namespaces = %s, want %s", got, want)
+	}
+}
+
+func TestParseResponse(t *testing.T) {
+	key, cert := testCertificate(t)
+	otherKey, _ := testCertificate(t)
+	sp := &ServiceProvider{
+		EntityID: testSP,
+		ACSURL:   testACS,
+		IDP:      &IdentityProvider{EntityID: testIDP, SSOURL: testIDP + "/sso", Certificates: []*x509.Certificate{cert}},
+		Clock:    clock.NewFake(testNow),
+	}
+
+	t.Run("signed assertion", func(t *testing.T) {
+		assertion, err := sp.ParseResponse(testResponse(t, key, testAssertion(testSP, testNow.Add(5*time.Minute)), ""), testRequestID)
+		if err != nil {
+			t.Fatalf("ParseResponse() error = %v", err)
+		}
+		if assertion.NameID != "jane@example.com" {
+			t.Errorf("expected NameID jane@example.com, got %s", assertion.NameID)
+		}
+		if got := assertion.Attributes["groups"]; len(got) != 2 || got[0] != "Admins" || got[1] != "Staff" {
+			t.Errorf("expected groups Admins and Staff, got %v", got)
+		}
+	})
+
+	rejected := map[string]string{
+		"unsigned":       testResponseXML(testAssertion(testSP, testNow.Add(5*time.Minute)), ""),
+		"other key":      testResponse(t, otherKey, testAssertion(testSP, testNow.Add(5*time.Minute)), ""),
+		"other audience": testResponse(t, key, testAssertion("https://other.example.com", testNow.Add(5*time.Minute)), ""),
+		"expired":        testResponse(t, key, testAssertion(testSP, testNow.Add(-5*time.Minute)), ""),
+		"tampered": strings.Replace(
+			decode(testResponse(t, key, testAssertion(testSP, testNow.Add(5*time.Minute)), "")),
+			"jane@example.com", "mallory@example.com", 1),
+		// A second, unsigned assertion next to the signed one
+		"wrapped": testResponse(t, key, testAssertion(testSP, testNow.Add(5*time.Minute)),
+			strings.Replace(testAssertion(testSP, testNow.Add(5*time.Minute)), "jane@", "mallory@", 1)),
+	}
+	for name, response := range rejected {
+		t.Run(name, func(t *testing.T) {
+			if strings.HasPrefix(response, "<") {
+				response = base64.StdEncoding.EncodeToString([]byte(response))
+			}
+			if _, err := sp.ParseResponse(response, testRequestID); !errors.Is(err, ErrInvalidResponse) {
+				t.Errorf("expected ErrInvalidResponse, got %v", err)
+			}
+		})
+	}
+
+	t.Run("other request", func(t *testing.T) {
+		response := testResponse(t, key, testAssertion(testSP, testNow.Add(5*time.Minute)), "")
+		if _, err := sp.ParseResponse(response, "_request-2"); !errors.Is(err, ErrInvalidResponse) {
+			t.Errorf("expected ErrInvalidResponse, got %v", err)
+		}
+	})
+}
+
+// testAssertion returns an unsigned assertion for audience valid until expires
+func testAssertion(audience string, expires time.Time) string {
+	notOnOrAfter := expires.Format(time.RFC3339)
+	return `<saml:Assertion xmlns:saml="` + nsAssertion + `" ID="_assertion-1" Version="2.0" IssueInstant="` + testNow.Format(time.RFC3339) + `">` +
+		`<saml:Issuer>` + testIDP + `</saml:Issuer>` +
+		`<saml:Subject><saml:NameID>jane@example.com</saml:NameID>` +
+		`<saml:SubjectConfirmation Method="` + confirmationBearer + `"><saml:SubjectConfirmationData InResponseTo="` + testRequestID + `" NotOnOrAfter="` + notOnOrAfter + `" Recipient="` + testACS + `"/></saml:SubjectConfirmation></saml:Subject>` +
+		`<saml:Conditions NotBefore="` + testNow.Add(-time.Minute).Format(time.RFC3339) + `" NotOnOrAfter="` + notOnOrAfter + `">` +
+		`<saml:AudienceRestriction><saml:Audience>` + audience + `</saml:Audience></saml:AudienceRestriction></saml:Conditions>` +
+		`<saml:AttributeStatement><saml:Attribute Name="groups"><saml:AttributeValue>Admins</saml:AttributeValue><saml:AttributeValue>Staff</saml:AttributeValue></saml:Attribute></saml:AttributeStatement>` +
+		`</saml:Assertion>`
+}
+
+// testResponseXML wraps assertions in a successful response
+func testResponseXML(assertions ...string) string {
+	return `<samlp:Response xmlns:samlp="` + nsProtocol + `" ID="_response-1" Version="2.0" InResponseTo="` + testRequestID + `" Destination="` + testACS + `">` +
+		`<samlp:Status><samlp:StatusCode Value="` + statusSuccess + `"/></samlp:Status>` +
+		strings.Join(assertions, "") + `</samlp:Response>`
+}
+
+// testResponse signs assertion with key and returns it, followed by extra,
+// in an encoded response
+func testResponse(t *testing.T, key *rsa.PrivateKey, assertion, extra string) string {
+	t.Helper()
+	parsed, err := parseXML([]byte(assertion))
+	if err != nil {
+		t.Fatalf("parseXML() error = %v", err)
+	}
+	digest := sha256.Sum256(canonicalize(parsed, nil, nil))
+
+	signedInfo := `<ds:SignedInfo><ds:CanonicalizationMethod Algorithm="` + nsExcC14N + `"/>` +
+		`<ds:SignatureMethod Algorithm="` + algRSASHA256 + `"/>` +
+		`<ds:Reference URI="#_assertion-1"><ds:Transforms><ds:Transform Algorithm="` + algEnvelopedSignature + `"/><ds:Transform Algorithm="` + nsExcC14N + `"/></ds:Transforms>` +
+		`<ds:DigestMethod Algorithm="http://www.w3.org/2001/04/xmlenc#sha256"/><ds:DigestValue>` + base64.StdEncoding.EncodeToString(digest[:]) + `</ds:DigestValue></ds:Reference></ds:SignedInfo>`
+	signatureXML, err := parseXML([]byte(`<ds:Signature xmlns:ds="` + nsDSig + `">` + signedInfo + `</ds:Signature>`))
+	if err != nil {
+		t.Fatalf("parseXML() error = %v", err)
+	}
+	hashed := sha256.Sum256(canonicalize(signatureXML.child(nsDSig, "SignedInfo"), nil, nil))
+	value, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hashed[:])
+	if err != nil {
+		t.Fatalf("SignPKCS1v15() error = %v", err)
+	}
+
+	signature := `<ds:Signature xmlns:ds="` + nsDSig + `">` + signedInfo + `<ds:SignatureValue>` + base64.StdEncoding.EncodeToString(value) + `</ds:SignatureValue></ds:Signature>`
+	signed := strings.Replace(assertion, "</saml:Issuer>", "</saml:Issuer>"+signature, 1)
+	return base64.StdEncoding.EncodeToString([]byte(testResponseXML(signed, extra)))
+}
+
+func testCertificate(t *testing.T) (*rsa.PrivateKey, *x509.Certificate) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "idp"},
+		NotBefore:    testNow.Add(-time.Hour),
+		NotAfter:     testNow.Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate() error = %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate() error = %v", err)
+	}
+	return key, cert
+}
+
+func decode(encoded string) string {
+	data, _ := base64.StdEncoding.DecodeString(encoded)
+	return string(data)
+}
@@ -0,0 +1,168 @@
+package saml
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	// Register the digests of the accepted algorithms
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+)
+
+const (
+	nsDSig    = "http://www.w3.org/2000/09/xmldsig#"
+	nsExcC14N = "http://www.w3.org/2001/10/xml-exc-c14n#"
+
+	algEnvelopedSignature = "http://www.w3.org/2000/09/xmldsig#enveloped-signature"
+)
+
+// signatureMethods are the accepted signature algorithms. SHA-1 is not
+// accepted.
+var signatureMethods = map[string]crypto.Hash{
+	"http://www.w3.org/2001/04/xmldsig-more#rsa-sha256": crypto.SHA256,
+	"http://www.w3.org/2001/04/xmldsig-more#rsa-sha384": crypto.SHA384,
+	"http://www.w3.org/2001/04/xmldsig-more#rsa-sha512": crypto.SHA512,
+}
+
+// digestMethods are the accepted digest algorithms
+var digestMethods = map[string]crypto.Hash{
+	"http://www.w3.org/2001/04/xmlenc#sha256":       crypto.SHA256,
+	"http://www.w3.org/2001/04/xmldsig-more#sha384": crypto.SHA384,
+	"http://www.w3.org/2001/04/xmlenc#sha512":       crypto.SHA512,
+}
+
+// hasSignature reports whether el carries an enveloped signature
+func hasSignature(el *element) bool {
+	return el.child(nsDSig, "Signature") != nil
+}
+
+// verifySignature checks the enveloped signature of el, made over el itself
+// by one of certs. Only the element the signature covers may be trusted
+// afterwards, so root is searched to make sure its ID is unique. Key
+// information sent with the signature is ignored.
+func verifySignature(root, el *element, certs []*x509.Certificate) error {
+	signatures := el.childrenNamed(nsDSig, "Signature")
+	if len(signatures) != 1 {
+		return fmt.Errorf("expected one signature on %s, found %d", el.local, len(signatures))
+	}
+	signature := signatures[0]
+	signedInfo := signature.child(nsDSig, "SignedInfo")
+	if signedInfo == nil {
+		return fmt.Errorf("signature has no SignedInfo")
+	}
+
+	c14nMethod := signedInfo.child(nsDSig, "CanonicalizationMethod")
+	if c14nMethod == nil || c14nMethod.attr("Algorithm") != nsExcC14N {
+		return fmt.Errorf("unsupported canonicalization method")
+	}
+	signatureMethod := signedInfo.child(nsDSig, "SignatureMethod")
+	if signatureMethod == nil {
+		return fmt.Errorf("signature has no SignatureMethod")
+	}
+	signatureHash, ok := signatureMethods[signatureMethod.attr("Algorithm")]
+	if !ok {
+		return fmt.Errorf("unsupported signature method %q", signatureMethod.attr("Algorithm"))
+	}
+
+	// The single reference must point at the signed element by its ID
+	references := signedInfo.childrenNamed(nsDSig, "Reference")
+	if len(references) != 1 {
+		return fmt.Errorf("expected one signature reference, found %d", len(references))
+	}
+	reference := references[0]
+	id := el.attr("ID")
+	if id == "" || reference.attr("URI") != "#"+id {
+		return fmt.Errorf("signature does not reference the signed element")
+	}
+	if matches := elementsWithID(root, id); matches != 1 {
+		return fmt.Errorf("ID %q is used by %d elements", id, matches)
+	}
+
+	var skip *element
+	var inclusive []string
+	if transforms := reference.child(nsDSig, "Transforms"); transforms != nil {
+		for _, transform := range transforms.childrenNamed(nsDSig, "Transform") {
+			switch transform.attr("Algorithm") {
+			case algEnvelopedSignature:
+				skip = signature
+			case nsExcC14N:
+				inclusive = inclusivePrefixes(transform)
+			default:
+				return fmt.Errorf("unsupported transform %q", transform.attr("Algorithm"))
+			}
+		}
+	}
+	digestMethod := reference.child(nsDSig, "DigestMethod")
+	if digestMethod == nil {
+		return fmt.Errorf("signature reference has no DigestMethod")
+	}
+	digestHash, ok := digestMethods[digestMethod.attr("Algorithm")]
+	if !ok {
+		return fmt.Errorf("unsupported digest method %q", digestMethod.attr("Algorithm"))
+	}
+	digestValue := reference.child(nsDSig, "DigestValue")
+	if digestValue == nil {
+		return fmt.Errorf("signature reference has no DigestValue")
+	}
+	expected, err := decodeBase64(digestValue.text())
+	if err != nil {
+		return fmt.Errorf("invalid digest value: %w", err)
+	}
+	digest := digestHash.New()
+	digest.Write(canonicalize(el, skip, inclusive))
+	if subtle.ConstantTimeCompare(digest.Sum(nil), expected) != 1 {
+		return fmt.Errorf("digest of %s does not match its signature", el.local)
+	}
+
+	signatureValue := signature.child(nsDSig, "SignatureValue")
+	if signatureValue == nil {
+		return fmt.Errorf("signature has no SignatureValue")
+	}
+	value, err := decodeBase64(signatureValue.text())
+	if err != nil {
+		return fmt.Errorf("invalid signature value: %w", err)
+	}
+	signed := signatureHash.New()
+	signed.Write(canonicalize(signedInfo, nil, inclusivePrefixes(c14nMethod)))
+	hashed := signed.Sum(nil)
+	for _, cert := range certs {
+		key, ok := cert.PublicKey.(*rsa.PublicKey)
+		if !ok {
+			continue
+		}
+		if rsa.VerifyPKCS1v15(key, signatureHash, hashed, value) == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("signature of %s is not made by the identity provider", el.local)
+}
+
+// inclusivePrefixes returns the PrefixList of the InclusiveNamespaces child
+// of a canonicalization method or transform
+func inclusivePrefixes(method *element) []string {
+	if namespaces := method.child(nsExcC14N, "InclusiveNamespaces"); namespaces != nil {
+		return strings.Fields(namespaces.attr("PrefixList"))
+	}
+	return nil
+}
+
+// elementsWithID counts the elements below root whose ID attribute is id
+func elementsWithID(root *element, id string) int {
+	count := 0
+	root.walk(func(el *element) {
+		if el.attr("ID") == id {
+			count++
+		}
+	})
+	return count
+}
+
+// decodeBase64 decodes base64 that may be wrapped over several lines
+func decodeBase64(value string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(value), ""))
+}
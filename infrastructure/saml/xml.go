@@ -0,0 +1,210 @@
+package saml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// nsXML is the namespace bound to the xml prefix
+const nsXML = "http://www.w3.org/XML/1998/namespace"
+
+// node is a child of an element: *element, text or procInst
+type node interface{}
+
+// text is character data, with entities and line endings already decoded
+type text string
+
+// procInst is a processing instruction inside the document element
+type procInst struct {
+	target string
+	inst   string
+}
+
+// attr is an attribute other than a namespace declaration
+type attr struct {
+	prefix string
+	local  string
+	space  string
+	value  string
+}
+
+// element is an XML element keeping the prefixes and namespace declarations
+// of the document, which canonicalization needs and encoding/xml drops
+type element struct {
+	prefix   string
+	local    string
+	space    string
+	attrs    []attr
+	declared map[string]string
+	children []node
+	parent   *element
+}
+
+// parseXML reads a document into a tree. Documents with a DTD are rejected.
+func parseXML(data []byte) (*element, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var root, current *element
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			el := &element{prefix: t.Name.Space, local: t.Name.Local, parent: current, declared: map[string]string{}}
+			for _, a := range t.Attr {
+				switch {
+				case a.Name.Space == "" && a.Name.Local == "xmlns":
+					el.declared[""] = a.Value
+				case a.Name.Space == "xmlns":
+					el.declared[a.Name.Local] = a.Value
+				default:
+					el.attrs = append(el.attrs, attr{prefix: a.Name.Space, local: a.Name.Local, value: a.Value})
+				}
+			}
+			var ok bool
+			if el.space, ok = el.lookup(el.prefix); !ok && el.prefix != "" {
+				return nil, fmt.Errorf("undeclared namespace prefix %q", el.prefix)
+			}
+			for i := range el.attrs {
+				if el.attrs[i].prefix == "" {
+					continue
+				}
+				if el.attrs[i].space, ok = el.lookup(el.attrs[i].prefix); !ok {
+					return nil, fmt.Errorf("undeclared namespace prefix %q", el.attrs[i].prefix)
+				}
+			}
+
+			if current == nil {
+				if root != nil {
+					return nil, fmt.Errorf("document has more than one root element")
+				}
+				root = el
+			} else {
+				current.children = append(current.children, el)
+			}
+			current = el
+		case xml.EndElement:
+			if current == nil || t.Name.Space != current.prefix || t.Name.Local != current.local {
+				return nil, fmt.Errorf("unexpected end element %s", t.Name.Local)
+			}
+			current = current.parent
+		case xml.CharData:
+			if current == nil {
+				if strings.TrimSpace(string(t)) != "" {
+					return nil, fmt.Errorf("text outside the root element")
+				}
+				continue
+			}
+			current.children = append(current.children, text(t))
+		case xml.ProcInst:
+			if current != nil {
+				current.children = append(current.children, procInst{target: t.Target, inst: string(t.Inst)})
+			}
+		case xml.Directive:
+			return nil, fmt.Errorf("documents with a DTD are not accepted")
+		}
+	}
+	if root == nil || current != nil {
+		return nil, fmt.Errorf("incomplete XML document")
+	}
+	return root, nil
+}
+
+// lookup returns the namespace bound to prefix at the element
+func (e *element) lookup(prefix string) (string, bool) {
+	if prefix == "xml" {
+		return nsXML, true
+	}
+	for el := e; el != nil; el = el.parent {
+		if space, ok := el.declared[prefix]; ok {
+			return space, true
+		}
+	}
+	return "", false
+}
+
+// is reports whether the element has the given namespace and name
+func (e *element) is(space, local string) bool {
+	return e.space == space && e.local == local
+}
+
+// attr returns the value of an unqualified attribute
+func (e *element) attr(local string) string {
+	for _, a := range e.attrs {
+		if a.space == "" && a.local == local {
+			return a.value
+		}
+	}
+	return ""
+}
+
+// child returns the first child element with the given name, or nil
+func (e *element) child(space, local string) *element {
+	for _, n := range e.children {
+		if el, ok := n.(*element); ok && el.is(space, local) {
+			return el
+		}
+	}
+	return nil
+}
+
+// childrenNamed returns the child elements with the given name
+func (e *element) childrenNamed(space, local string) []*element {
+	var matches []*element
+	for _, n := range e.children {
+		if el, ok := n.(*element); ok && el.is(space, local) {
+			matches = append(matches, el)
+		}
+	}
+	return matches
+}
+
+// path follows a chain of child elements in one namespace, returning nil
+// when one is missing
+func (e *element) path(space string, locals ...string) *element {
+	el := e
+	for _, local := range locals {
+		if el = el.child(space, local); el == nil {
+			return nil
+		}
+	}
+	return el
+}
+
+// text returns the character data of the element, without surrounding
+// whitespace
+func (e *element) text() string {
+	var b strings.Builder
+	for _, n := range e.children {
+		if t, ok := n.(text); ok {
+			b.WriteString(string(t))
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// walk calls fn for the element and every element below it
+func (e *element) walk(fn func(*element)) {
+	fn(e)
+	for _, n := range e.children {
+		if el, ok := n.(*element); ok {
+			el.walk(fn)
+		}
+	}
+}
+
+// name returns the qualified name of the element as written
+func (e *element) name() string {
+	if e.prefix == "" {
+		return e.local
+	}
+	return e.prefix + ":" + e.local
+}
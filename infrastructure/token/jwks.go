@@ -0,0 +1,173 @@
+package token
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aruncs31s/azf/shared/logger"
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultJWKSRefresh is how long fetched keys are used before fetching them again
+	DefaultJWKSRefresh = time.Hour
+	// jwksMinRefetch limits how often an unknown kid triggers a fetch
+	jwksMinRefetch = time.Minute
+	// jwksMaxBytes bounds the size of a fetched key set
+	jwksMaxBytes = 1 << 20
+)
+
+// jwksMethods are the algorithms accepted from identity providers; HMAC is
+// excluded, as a public key must never be used as a shared secret
+var jwksMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// JWKSProvider verifies tokens issued by an external identity provider,
+// such as Keycloak or Auth0, with the keys published at its JWKS URL. Keys
+// are fetched again after the refresh interval, and early when a token is
+// signed with an unknown key, so rotations at the provider are picked up.
+type JWKSProvider struct {
+	url     string
+	client  *http.Client
+	refresh time.Duration
+	parser  *jwt.Parser
+
+	mu          sync.Mutex
+	keys        map[string]jwksKey
+	fetchedAt   time.Time
+	lastAttempt time.Time
+}
+
+// jwksKey is a fetched public key with the algorithm it is restricted to
+type jwksKey struct {
+	key       crypto.PublicKey
+	algorithm string
+}
+
+// NewJWKSProvider creates a provider verifying tokens against the keys at
+// url. A nil client uses a client with a 10 second timeout; a zero refresh
+// uses DefaultJWKSRefresh.
+func NewJWKSProvider(url string, client *http.Client, refresh time.Duration, opts ValidationOptions) *JWKSProvider {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if refresh <= 0 {
+		refresh = DefaultJWKSRefresh
+	}
+	return &JWKSProvider{
+		url:     url,
+		client:  client,
+		refresh: refresh,
+		parser:  opts.parser(jwksMethods...),
+	}
+}
+
+// Sign returns ErrSigningNotSupported; tokens are issued by the identity provider
+func (p *JWKSProvider) Sign(jwt.MapClaims) (string, error) {
+	return "", ErrSigningNotSupported
+}
+
+func (p *JWKSProvider) Verify(tokenString string) (jwt.MapClaims, error) {
+	return verify(p.parser, tokenString, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, err := p.key(kid)
+		if err != nil {
+			return nil, err
+		}
+		if key.algorithm != "" && key.algorithm != t.Method.Alg() {
+			return nil, fmt.Errorf("key %s is not for %s", kid, t.Method.Alg())
+		}
+		return key.key, nil
+	})
+}
+
+// key returns the key kid, fetching the key set when it is stale or does
+// not have the key
+func (p *JWKSProvider) key(kid string) (jwksKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	key, found := p.lookup(kid)
+	stale := now.Sub(p.fetchedAt) >= p.refresh
+	if (found && !stale) || now.Sub(p.lastAttempt) < jwksMinRefetch && p.keys != nil {
+		if found {
+			return key, nil
+		}
+		return jwksKey{}, ErrUnknownKey
+	}
+
+	p.lastAttempt = now
+	keys, err := p.fetch()
+	if err != nil {
+		// Keep verifying with the keys fetched before
+		logger.Warn("Failed to fetch JWKS", zap.String("url", p.url), zap.Error(err))
+		if found {
+			return key, nil
+		}
+		return jwksKey{}, err
+	}
+	p.keys = keys
+	p.fetchedAt = now
+
+	if key, found = p.lookup(kid); !found {
+		return jwksKey{}, ErrUnknownKey
+	}
+	return key, nil
+}
+
+// lookup returns the key kid; tokens without a kid match a single key
+func (p *JWKSProvider) lookup(kid string) (jwksKey, bool) {
+	if key, ok := p.keys[kid]; ok {
+		return key, true
+	}
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	return jwksKey{}, false
+}
+
+// fetch downloads the signature keys of the key set
+func (p *JWKSProvider) fetch() (map[string]jwksKey, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), p.client.Timeout+time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("JWKS endpoint returned %s", resp.Status)
+	}
+
+	var set JSONWebKeySet
+	if err := json.NewDecoder(io.LimitReader(resp.Body, jwksMaxBytes)).Decode(&set); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+	keys := make(map[string]jwksKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.PublicKey()
+		if err != nil {
+			logger.Warn("Skipping unsupported JWKS key", zap.String("kid", jwk.KeyID), zap.Error(err))
+			continue
+		}
+		keys[jwk.KeyID] = jwksKey{key: key, algorithm: jwk.Algorithm}
+	}
+	return keys, nil
+}
@@ -0,0 +1,340 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// KeyProvider signs tokens with an RSA or ECDSA private key and verifies
+// them with the public keys it knows, selected by the kid header. Keys are
+// rotated by setting a new signing key while keeping the previous public
+// key until the tokens signed with it have expired.
+type KeyProvider struct {
+	mu         sync.RWMutex
+	method     jwt.SigningMethod
+	issuer     string
+	parser     *jwt.Parser
+	signingKey crypto.Signer
+	signingKID string
+	publicKeys map[string]crypto.PublicKey
+}
+
+// NewKeyProvider creates a provider for RS256, RS384, RS512, ES256, ES384
+// or ES512 tokens; set a signing key before signing tokens
+func NewKeyProvider(algorithm string, opts ValidationOptions) (*KeyProvider, error) {
+	method := jwt.GetSigningMethod(algorithm)
+	switch method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA:
+	default:
+		return nil, fmt.Errorf("unsupported JWT algorithm %q", algorithm)
+	}
+	return &KeyProvider{
+		method:     method,
+		issuer:     opts.Issuer,
+		parser:     opts.parser(method.Alg()),
+		publicKeys: make(map[string]crypto.PublicKey),
+	}, nil
+}
+
+// SetSigningKey makes key sign new tokens, and accepts the tokens it signs.
+// An empty kid is replaced by the RFC 7638 thumbprint of the key.
+func (p *KeyProvider) SetSigningKey(kid string, key crypto.Signer) error {
+	if err := p.checkKey(key.Public()); err != nil {
+		return err
+	}
+	if kid == "" {
+		var err error
+		if kid, err = Thumbprint(key.Public()); err != nil {
+			return err
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.signingKey = key
+	p.signingKID = kid
+	p.publicKeys[kid] = key.Public()
+	return nil
+}
+
+// AddVerificationKey accepts the tokens signed with the private key of key,
+// e.g. a rotated out signing key. An empty kid is replaced by the key's
+// RFC 7638 thumbprint.
+func (p *KeyProvider) AddVerificationKey(kid string, key crypto.PublicKey) error {
+	if err := p.checkKey(key); err != nil {
+		return err
+	}
+	if kid == "" {
+		var err error
+		if kid, err = Thumbprint(key); err != nil {
+			return err
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.publicKeys[kid] = key
+	return nil
+}
+
+// RemoveVerificationKey stops accepting the tokens signed with the key kid;
+// the signing key cannot be removed
+func (p *KeyProvider) RemoveVerificationKey(kid string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if kid != p.signingKID {
+		delete(p.publicKeys, kid)
+	}
+}
+
+func (p *KeyProvider) Sign(claims jwt.MapClaims) (string, error) {
+	p.mu.RLock()
+	key, kid := p.signingKey, p.signingKID
+	p.mu.RUnlock()
+	if key == nil {
+		return "", ErrSigningNotSupported
+	}
+
+	token := jwt.NewWithClaims(p.method, withIssuer(claims, p.issuer))
+	token.Header["kid"] = kid
+	return token.SignedString(key)
+}
+
+func (p *KeyProvider) Verify(tokenString string) (jwt.MapClaims, error) {
+	return verify(p.parser, tokenString, func(t *jwt.Token) (interface{}, error) {
+		p.mu.RLock()
+		defer p.mu.RUnlock()
+
+		kid, _ := t.Header["kid"].(string)
+		if key, ok := p.publicKeys[kid]; ok {
+			return key, nil
+		}
+		// Tokens without a kid are accepted when there is a single key
+		if kid == "" && len(p.publicKeys) == 1 {
+			for _, key := range p.publicKeys {
+				return key, nil
+			}
+		}
+		return nil, ErrUnknownKey
+	})
+}
+
+// JWKS returns the public keys accepted by the provider, for publishing to
+// services that verify azf tokens
+func (p *KeyProvider) JWKS() JSONWebKeySet {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	kids := make([]string, 0, len(p.publicKeys))
+	for kid := range p.publicKeys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	set := JSONWebKeySet{Keys: make([]JSONWebKey, 0, len(kids))}
+	for _, kid := range kids {
+		key, err := NewJSONWebKey(kid, p.method.Alg(), p.publicKeys[kid])
+		if err == nil {
+			set.Keys = append(set.Keys, key)
+		}
+	}
+	return set
+}
+
+// checkKey returns an error unless key fits the provider's algorithm
+func (p *KeyProvider) checkKey(key crypto.PublicKey) error {
+	switch method := p.method.(type) {
+	case *jwt.SigningMethodRSA:
+		if _, ok := key.(*rsa.PublicKey); !ok {
+			return fmt.Errorf("%s needs an RSA key", method.Alg())
+		}
+	case *jwt.SigningMethodECDSA:
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok || ecKey.Curve.Params().BitSize != method.CurveBits {
+			return fmt.Errorf("%s needs an ECDSA key on a %d-bit curve", method.Alg(), method.CurveBits)
+		}
+	}
+	return nil
+}
+
+// JSONWebKey is a public key in JWK format (RFC 7517)
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid,omitempty"`
+	Use       string `json:"use,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+	// RSA keys
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// ECDSA keys
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+	Y     string `json:"y,omitempty"`
+}
+
+// JSONWebKeySet is a set of public keys, as served on a JWKS endpoint
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// NewJSONWebKey returns key as a signature verification JWK
+func NewJSONWebKey(kid, algorithm string, key crypto.PublicKey) (JSONWebKey, error) {
+	jwk := JSONWebKey{KeyID: kid, Use: "sig", Algorithm: algorithm}
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(key.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (key.Curve.Params().BitSize + 7) / 8
+		jwk.KeyType = "EC"
+		jwk.Curve = key.Curve.Params().Name
+		jwk.X = base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, size)))
+		jwk.Y = base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, size)))
+	default:
+		return JSONWebKey{}, fmt.Errorf("unsupported key type %T", key)
+	}
+	return jwk, nil
+}
+
+// PublicKey returns the RSA or ECDSA public key of the JWK
+func (k JSONWebKey) PublicKey() (crypto.PublicKey, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA modulus: %w", err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Curve)
+		}
+		x, errX := base64.RawURLEncoding.DecodeString(k.X)
+		y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+		if errX != nil || errY != nil {
+			return nil, fmt.Errorf("invalid EC coordinates")
+		}
+		key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(key.X, key.Y) {
+			return nil, fmt.Errorf("EC point is not on %s", k.Curve)
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.KeyType)
+	}
+}
+
+// Thumbprint returns the RFC 7638 SHA-256 thumbprint of key, used as its kid
+func Thumbprint(key crypto.PublicKey) (string, error) {
+	jwk, err := NewJSONWebKey("", "", key)
+	if err != nil {
+		return "", err
+	}
+	// The required members in lexicographic order
+	var members any
+	if jwk.KeyType == "RSA" {
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.KeyType, jwk.N}
+	} else {
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{jwk.Curve, jwk.KeyType, jwk.X, jwk.Y}
+	}
+	data, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// LoadPrivateKeyFile reads an RSA or ECDSA private key from a PEM file in
+// PKCS #8, PKCS #1 or SEC 1 form
+func LoadPrivateKeyFile(path string) (crypto.Signer, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		if signer, ok := key.(crypto.Signer); ok {
+			return signer, nil
+		}
+		return nil, fmt.Errorf("%s: unsupported private key type %T", path, key)
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%s: no RSA or ECDSA private key found", path)
+}
+
+// LoadPublicKeyFile reads an RSA or ECDSA public key, or the key of a
+// certificate, from a PEM file
+func LoadPublicKeyFile(path string) (crypto.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	if block.Type == "CERTIFICATE" {
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return cert.PublicKey, nil
+	}
+	if key, err := x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%s: no RSA or ECDSA public key found", path)
+}
+
+// readPEM returns the first PEM block of the file at path
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: not a PEM file", path)
+	}
+	return block, nil
+}
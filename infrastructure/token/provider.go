@@ -0,0 +1,273 @@
+// Package token signs and verifies the JWTs azf issues and accepts. Tokens
+// are signed with a shared HMAC secret by default; RS256/ES256 keys with
+// rotation, and tokens of external identity providers verified against
+// their JWKS, are selected with the JWT_* settings.
+package token
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/aruncs31s/azf/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// MinSecretLength is the minimum length of an HMAC secret
+const MinSecretLength = 32
+
+var (
+	ErrSecretNotSet        = errors.New("JWT_SECRET environment variable is not set")
+	ErrSecretTooShort      = errors.New("JWT_SECRET must be at least 32 characters")
+	ErrSigningNotSupported = errors.New("token provider cannot sign tokens")
+	ErrUnknownKey          = errors.New("token signed with an unknown key")
+)
+
+// TokenProvider signs the tokens azf issues and verifies incoming tokens
+type TokenProvider interface {
+	// Sign returns claims signed as a JWT. Providers that only verify
+	// tokens return ErrSigningNotSupported.
+	Sign(claims jwt.MapClaims) (string, error)
+	// Verify validates the signature and registered claims of a token and
+	// returns its claims
+	Verify(token string) (jwt.MapClaims, error)
+}
+
+// KeySetProvider is a provider whose verification keys can be published,
+// so other services can verify the tokens azf issues
+type KeySetProvider interface {
+	JWKS() JSONWebKeySet
+}
+
+// ValidationOptions are the registered claims required of verified tokens;
+// empty values are not checked
+type ValidationOptions struct {
+	Issuer   string
+	Audience string
+	// Leeway tolerates clock skew when checking exp and nbf
+	Leeway time.Duration
+}
+
+// parser returns a JWT parser enforcing opts and accepting only methods
+func (opts ValidationOptions) parser(methods ...string) *jwt.Parser {
+	options := []jwt.ParserOption{jwt.WithValidMethods(methods)}
+	if opts.Issuer != "" {
+		options = append(options, jwt.WithIssuer(opts.Issuer))
+	}
+	if opts.Audience != "" {
+		options = append(options, jwt.WithAudience(opts.Audience))
+	}
+	if opts.Leeway > 0 {
+		options = append(options, jwt.WithLeeway(opts.Leeway))
+	}
+	return jwt.NewParser(options...)
+}
+
+// withIssuer sets the iss claim to issuer unless claims already have one
+func withIssuer(claims jwt.MapClaims, issuer string) jwt.MapClaims {
+	if issuer == "" {
+		return claims
+	}
+	if _, ok := claims["iss"]; ok {
+		return claims
+	}
+	signed := make(jwt.MapClaims, len(claims)+1)
+	for k, v := range claims {
+		signed[k] = v
+	}
+	signed["iss"] = issuer
+	return signed
+}
+
+// hmacProvider signs and verifies tokens with a shared secret
+type hmacProvider struct {
+	method jwt.SigningMethod
+	secret []byte
+	issuer string
+	parser *jwt.Parser
+}
+
+// NewHMACProvider creates a provider for HS256, HS384 or HS512 tokens
+// signed with secret
+func NewHMACProvider(algorithm string, secret []byte, opts ValidationOptions) (TokenProvider, error) {
+	method, ok := jwt.GetSigningMethod(algorithm).(*jwt.SigningMethodHMAC)
+	if !ok {
+		return nil, fmt.Errorf("unsupported HMAC algorithm %q", algorithm)
+	}
+	if len(secret) < MinSecretLength {
+		return nil, ErrSecretTooShort
+	}
+	return &hmacProvider{
+		method: method,
+		secret: secret,
+		issuer: opts.Issuer,
+		parser: opts.parser(method.Alg()),
+	}, nil
+}
+
+func (p *hmacProvider) Sign(claims jwt.MapClaims) (string, error) {
+	return jwt.NewWithClaims(p.method, withIssuer(claims, p.issuer)).SignedString(p.secret)
+}
+
+func (p *hmacProvider) Verify(tokenString string) (jwt.MapClaims, error) {
+	return verify(p.parser, tokenString, func(*jwt.Token) (interface{}, error) {
+		return p.secret, nil
+	})
+}
+
+// chainProvider signs with its first provider and accepts tokens any of
+// its providers accepts
+type chainProvider struct {
+	providers []TokenProvider
+}
+
+// NewChainProvider creates a provider signing with signer and accepting
+// the tokens of signer and verifiers, e.g. local admin tokens together with
+// the tokens of an external identity provider
+func NewChainProvider(signer TokenProvider, verifiers ...TokenProvider) TokenProvider {
+	return &chainProvider{providers: append([]TokenProvider{signer}, verifiers...)}
+}
+
+func (p *chainProvider) Sign(claims jwt.MapClaims) (string, error) {
+	return p.providers[0].Sign(claims)
+}
+
+// JWKS returns the published keys of the signing provider, if it has any
+func (p *chainProvider) JWKS() JSONWebKeySet {
+	if keys, ok := p.providers[0].(KeySetProvider); ok {
+		return keys.JWKS()
+	}
+	return JSONWebKeySet{Keys: []JSONWebKey{}}
+}
+
+// Verify reports an expired token over other failures, so clients learn
+// they should refresh it
+func (p *chainProvider) Verify(tokenString string) (jwt.MapClaims, error) {
+	var firstErr error
+	for _, provider := range p.providers {
+		claims, err := provider.Verify(tokenString)
+		if err == nil {
+			return claims, nil
+		}
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, err
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return nil, firstErr
+}
+
+// verify parses tokenString with parser and returns its claims
+func verify(parser *jwt.Parser, tokenString string, keyFunc jwt.Keyfunc) (jwt.MapClaims, error) {
+	parsed, err := parser.Parse(tokenString, keyFunc)
+	if err != nil {
+		return nil, err
+	}
+	claims, ok := parsed.Claims.(jwt.MapClaims)
+	if !ok || !parsed.Valid {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+	return claims, nil
+}
+
+var (
+	defaultMu       sync.Mutex
+	defaultProvider TokenProvider
+	// configured is the provider built from configuredFor, reused while
+	// the configuration does not change
+	configured    TokenProvider
+	configuredFor config.TokenConfig
+)
+
+// Default returns the provider set with SetDefault, or else the provider
+// configured by the JWT_* settings
+func Default() (TokenProvider, error) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if defaultProvider != nil {
+		return defaultProvider, nil
+	}
+	cfg := config.GetTokenConfig()
+	if configured != nil && reflect.DeepEqual(cfg, configuredFor) {
+		return configured, nil
+	}
+	provider, err := NewProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
+	configured, configuredFor = provider, cfg
+	return provider, nil
+}
+
+// SetDefault replaces the provider returned by Default; nil restores the
+// configured provider
+func SetDefault(provider TokenProvider) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultProvider = provider
+}
+
+// NewProvider creates the provider described by cfg: HMAC with the shared
+// secret or RS/ES keys read from PEM files, also accepting tokens verified
+// against cfg.JWKSURL when set. With only a JWKS URL and no local keys,
+// tokens are verified but not signed.
+func NewProvider(cfg config.TokenConfig) (TokenProvider, error) {
+	opts := ValidationOptions{Issuer: cfg.Issuer, Audience: cfg.Audience, Leeway: cfg.Leeway}
+	local, err := newLocalProvider(cfg, opts)
+	if cfg.JWKSURL == "" {
+		return local, err
+	}
+
+	remote := NewJWKSProvider(cfg.JWKSURL, nil, cfg.JWKSRefresh, ValidationOptions{
+		Issuer:   cfg.JWKSIssuer,
+		Audience: cfg.JWKSAudience,
+		Leeway:   cfg.Leeway,
+	})
+	if err != nil {
+		if errors.Is(err, ErrSecretNotSet) {
+			return remote, nil
+		}
+		return nil, err
+	}
+	return NewChainProvider(local, remote), nil
+}
+
+// newLocalProvider creates the provider for the tokens azf signs itself
+func newLocalProvider(cfg config.TokenConfig, opts ValidationOptions) (TokenProvider, error) {
+	if _, ok := jwt.GetSigningMethod(cfg.Algorithm).(*jwt.SigningMethodHMAC); ok {
+		if cfg.Secret == "" {
+			return nil, ErrSecretNotSet
+		}
+		return NewHMACProvider(cfg.Algorithm, []byte(cfg.Secret), opts)
+	}
+
+	provider, err := NewKeyProvider(cfg.Algorithm, opts)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.PrivateKeyFile == "" {
+		return nil, fmt.Errorf("JWT_PRIVATE_KEY_FILE is required for %s", cfg.Algorithm)
+	}
+	signer, err := LoadPrivateKeyFile(cfg.PrivateKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	if err := provider.SetSigningKey(cfg.KeyID, signer); err != nil {
+		return nil, err
+	}
+	for _, path := range cfg.PublicKeyFiles {
+		key, err := LoadPublicKeyFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := provider.AddVerificationKey("", key); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return provider, nil
+}
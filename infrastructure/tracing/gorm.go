@@ -0,0 +1,83 @@
+package tracing
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+const gormSpanKey = "azf:tracing:span"
+
+// gormPlugin starts a span for every database call made through GORM, as a
+// child of the span in the statement's context
+type gormPlugin struct{}
+
+// GormPlugin returns the GORM plugin tracing database calls; register it
+// with db.Use
+func GormPlugin() gorm.Plugin {
+	return gormPlugin{}
+}
+
+func (gormPlugin) Name() string {
+	return "azf:tracing"
+}
+
+func (gormPlugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	register := []struct {
+		operation string
+		before    func(name string, fn func(*gorm.DB)) error
+		after     func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", callbacks.Create().Before("gorm:create").Register, callbacks.Create().After("gorm:create").Register},
+		{"query", callbacks.Query().Before("gorm:query").Register, callbacks.Query().After("gorm:query").Register},
+		{"update", callbacks.Update().Before("gorm:update").Register, callbacks.Update().After("gorm:update").Register},
+		{"delete", callbacks.Delete().Before("gorm:delete").Register, callbacks.Delete().After("gorm:delete").Register},
+		{"row", callbacks.Row().Before("gorm:row").Register, callbacks.Row().After("gorm:row").Register},
+		{"raw", callbacks.Raw().Before("gorm:raw").Register, callbacks.Raw().After("gorm:raw").Register},
+	}
+	for _, r := range register {
+		if err := r.before("azf:tracing:before_"+r.operation, startGormSpan(r.operation)); err != nil {
+			return err
+		}
+		if err := r.after("azf:tracing:after_"+r.operation, endGormSpan); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func startGormSpan(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Statement == nil || db.Statement.Context == nil {
+			return
+		}
+		ctx, span := Start(db.Statement.Context, "db."+operation,
+			String("db.system", db.Dialector.Name()),
+			String("db.operation", operation),
+			String("db.sql.table", db.Statement.Table),
+		)
+		db.Statement.Context = ctx
+		db.InstanceSet(gormSpanKey, span)
+	}
+}
+
+func endGormSpan(db *gorm.DB) {
+	value, ok := db.InstanceGet(gormSpanKey)
+	if !ok {
+		return
+	}
+	span, ok := value.(Span)
+	if !ok {
+		return
+	}
+	// The statement has placeholders, not the bound values
+	span.SetAttributes(
+		String("db.statement", db.Statement.SQL.String()),
+		Int64("db.rows_affected", db.Statement.RowsAffected),
+	)
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+	}
+	span.End()
+}
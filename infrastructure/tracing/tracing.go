@@ -0,0 +1,105 @@
+// Package tracing creates the spans azf emits for authorization checks,
+// database calls and audit flushes. Its interfaces follow the OpenTelemetry
+// trace API, so an OpenTelemetry TracerProvider is plugged in with a thin
+// adapter, without azf depending on the OpenTelemetry SDK. Spans are not
+// recorded until a provider is set.
+package tracing
+
+import (
+	"context"
+	"sync"
+)
+
+// InstrumentationName is the tracer name of azf's spans
+const InstrumentationName = "github.com/aruncs31s/azf"
+
+// Attribute is a key and value recorded on a span
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// String returns a string attribute
+func String(key, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Int returns an integer attribute
+func Int(key string, value int) Attribute {
+	return Attribute{Key: key, Value: int64(value)}
+}
+
+// Int64 returns an integer attribute
+func Int64(key string, value int64) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Bool returns a boolean attribute
+func Bool(key string, value bool) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Span is an operation being traced
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	// RecordError records err and marks the span as failed
+	RecordError(err error)
+	End()
+}
+
+// Tracer starts spans
+type Tracer interface {
+	// Start starts a span as a child of the span in ctx, and returns a
+	// context holding the new span
+	Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+}
+
+// TracerProvider returns the tracers of instrumented libraries
+type TracerProvider interface {
+	Tracer(name string) Tracer
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(...Attribute) {}
+func (noopSpan) RecordError(error)          {}
+func (noopSpan) End()                       {}
+
+type noopTracer struct{}
+
+func (noopTracer) Start(ctx context.Context, _ string, _ ...Attribute) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+var (
+	mu       sync.RWMutex
+	provider TracerProvider
+	tracer   Tracer = noopTracer{}
+)
+
+// SetTracerProvider makes azf record its spans with tp; nil stops tracing
+func SetTracerProvider(tp TracerProvider) {
+	mu.Lock()
+	defer mu.Unlock()
+	provider = tp
+	if tp == nil {
+		tracer = noopTracer{}
+		return
+	}
+	tracer = tp.Tracer(InstrumentationName)
+}
+
+// Provider returns the provider set with SetTracerProvider, or nil
+func Provider() TracerProvider {
+	mu.RLock()
+	defer mu.RUnlock()
+	return provider
+}
+
+// Start starts a span with the configured provider
+func Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+	mu.RLock()
+	t := tracer
+	mu.RUnlock()
+	return t.Start(ctx, name, attrs...)
+}
@@ -0,0 +1,561 @@
+// Package webhook delivers authorization webhook events to subscribed
+// endpoints over HTTP.
+//
+// Each request carries the event as JSON and is signed with the secret of the
+// subscription it is sent for. Receivers verify it by computing
+//
+//	hex(HMAC-SHA256(secret, X-Webhook-Timestamp + "." + body))
+//
+// and comparing it with the X-Webhook-Signature header, which has the form
+// "sha256=<hex>". Failed deliveries are retried with the exponential backoff
+// of the event, and every attempt is recorded.
+//
+// Payloads are checked against the JSON schemas of their event type before
+// they are sent; events that do not match are failed without retry. Events
+// are only sent to subscriptions whose filters they pass.
+//
+// Subscriptions may set their own transport: a CA bundle, a client
+// certificate for mutual TLS, a minimum TLS version and the IPs connections
+// are pinned to. Administrators can
+// redeliver any event to one of its subscriptions by hand.
+//
+// Subscribers can fetch the stored events of their subscription again to
+// recover from outages. Those requests are signed like deliveries, with the
+// subscription secret, and must be sent within MaxSignatureAge of their
+// X-Webhook-Timestamp.
+//
+// Authorization decisions reach subscribers through an outbox: the events of
+// an audit log are written as pending rows in the transaction that saves the
+// log, and the worker delivers them from the event table.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	authorization_audit "github.com/aruncs31s/azf/domain/authorization_audit/model"
+	apperrors "github.com/aruncs31s/azf/shared/errors"
+	"github.com/aruncs31s/azf/shared/logger"
+	"go.uber.org/zap"
+)
+
+// Headers set on every delivery. They take precedence over custom
+// subscription headers of the same name.
+const (
+	HeaderSignature = "X-Webhook-Signature"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderEventID   = "X-Webhook-ID"
+)
+
+// maxDrainedBody bounds how much of a response is read before the connection is reused
+const maxDrainedBody = 64 << 10
+
+// MaxResponseSnippet bounds the part of a response body recorded with a delivery attempt
+const MaxResponseSnippet = 1 << 10
+
+// DefaultTimeout bounds a single delivery when no HTTP client is provided
+const DefaultTimeout = 10 * time.Second
+
+// Payload is the JSON body of a delivery. It follows the schemas of
+// SchemaVersion, published at /webhooks/schemas.
+type Payload struct {
+	ID            string                 `json:"id"`
+	Type          string                 `json:"type"`
+	SchemaVersion string                 `json:"schema_version"`
+	AuditLogID    string                 `json:"audit_log_id"`
+	Timestamp     time.Time              `json:"timestamp"`
+	Data          map[string]interface{} `json:"data"`
+}
+
+// Sign returns the signature of body sent at timestamp, as set in the
+// X-Webhook-Signature header
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature reports whether signature is the signature of body sent at
+// timestamp
+func VerifySignature(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// MaxSignatureAge bounds how far the timestamp of a signed subscriber request
+// may be from the current time, so captured requests cannot be replayed later
+const MaxSignatureAge = 5 * time.Minute
+
+// NewPayload returns the payload delivered for the event
+func NewPayload(event *authorization_audit.WebhookEvent) Payload {
+	return Payload{
+		ID:            event.ID(),
+		Type:          event.EventType().Value(),
+		SchemaVersion: authorization_audit.WebhookSchemaVersion,
+		AuditLogID:    event.AuditLogID(),
+		Timestamp:     event.Timestamp(),
+		Data:          event.Payload(),
+	}
+}
+
+// dispatcher implements authorization_audit.WebhookDispatcher
+type dispatcher struct {
+	events        authorization_audit.WebhookEventRepository
+	subscriptions authorization_audit.WebhookSubscriptionRepository
+	deliveries    authorization_audit.WebhookDeliveryRepository
+	client        *http.Client
+
+	// clients holds the clients of subscriptions with their own transport,
+	// by subscription ID
+	mu      sync.Mutex
+	clients map[string]transportClient
+}
+
+// transportClient is a client built for the transport options of a subscription
+type transportClient struct {
+	transport authorization_audit.WebhookTransport
+	client    *http.Client
+}
+
+// NewDispatcher creates a dispatcher that delivers events to the subscriptions
+// registered for their delivery URL. client may be nil, in which case one with
+// DefaultTimeout is used.
+func NewDispatcher(
+	events authorization_audit.WebhookEventRepository,
+	subscriptions authorization_audit.WebhookSubscriptionRepository,
+	deliveries authorization_audit.WebhookDeliveryRepository,
+	client *http.Client,
+) authorization_audit.WebhookDispatcher {
+	if client == nil {
+		client = &http.Client{Timeout: DefaultTimeout}
+	}
+	return &dispatcher{
+		events:        events,
+		subscriptions: subscriptions,
+		deliveries:    deliveries,
+		client:        client,
+		clients:       make(map[string]transportClient),
+	}
+}
+
+// DispatchEvent stores the event if it is new and delivers it. It returns an
+// error when the event was not delivered; failed deliveries stay scheduled
+// for retry when the failure is temporary.
+func (d *dispatcher) DispatchEvent(ctx context.Context, event *authorization_audit.WebhookEvent) error {
+	if event == nil {
+		return fmt.Errorf("webhook event cannot be nil")
+	}
+	if _, err := d.events.FindByID(ctx, event.ID()); err != nil {
+		if !errors.Is(err, authorization_audit.ErrWebhookEventNotFound) {
+			return err
+		}
+		if _, err := d.events.Create(ctx, event); err != nil {
+			return err
+		}
+	}
+
+	if err := d.deliver(ctx, event); err != nil {
+		return err
+	}
+	if !event.Status().IsDelivered() {
+		return fmt.Errorf("webhook event %s not delivered: %s", event.ID(), event.LastError())
+	}
+	return nil
+}
+
+// DispatchPending delivers the events that have not been attempted yet
+func (d *dispatcher) DispatchPending(ctx context.Context) error {
+	events, err := d.events.FindPending(ctx)
+	if err != nil {
+		return err
+	}
+	return d.deliverAll(ctx, events)
+}
+
+// RetryFailed delivers the events whose retry backoff has passed
+func (d *dispatcher) RetryFailed(ctx context.Context) error {
+	events, err := d.events.FindRetryable(ctx)
+	if err != nil {
+		return err
+	}
+	return d.deliverAll(ctx, events)
+}
+
+// GetEventDeliveryStatus returns the event with its current delivery state
+func (d *dispatcher) GetEventDeliveryStatus(ctx context.Context, eventID string) (*authorization_audit.WebhookEvent, error) {
+	return d.events.FindByID(ctx, eventID)
+}
+
+// deliverAll delivers events one after another. Delivery failures are
+// recorded on the events; only storage errors are returned.
+func (d *dispatcher) deliverAll(ctx context.Context, events []*authorization_audit.WebhookEvent) error {
+	var errs []error
+	for _, event := range events {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := d.deliver(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// deliver sends the event to every subscription at its delivery URL that has
+// not received it yet and stores the outcome on the event
+func (d *dispatcher) deliver(ctx context.Context, event *authorization_audit.WebhookEvent) error {
+	targets, filtered, err := d.targets(ctx, event)
+	if err != nil {
+		return err
+	}
+	// Nothing is owed to subscriptions whose filters reject the event
+	if len(targets) == 0 && filtered > 0 {
+		logger.GetLogger().Debug("webhook event filtered out",
+			zap.String("event_id", event.ID()),
+			zap.String("event_type", event.EventType().Value()),
+			zap.Int("filtered", filtered),
+		)
+		if err := event.MarkAsDelivered(); err != nil {
+			return err
+		}
+		_, err := d.events.Update(ctx, event)
+		return err
+	}
+	if len(targets) == 0 {
+		if err := event.MarkAsFailed("no active subscription for " + event.DeliveryURL()); err != nil {
+			return err
+		}
+		_, err := d.events.Update(ctx, event)
+		return err
+	}
+
+	body, err := encode(event)
+	if err != nil {
+		return err
+	}
+	// An event that breaks its schema would break receivers coded against
+	// it, and retrying cannot fix it
+	if err := authorization_audit.ValidateWebhookPayload(body); err != nil {
+		logger.GetLogger().Error("webhook event does not match its schema",
+			zap.String("event_id", event.ID()),
+			zap.String("event_type", event.EventType().Value()),
+			zap.Error(err),
+		)
+		if err := event.MarkAsFailed("payload does not match schema: " + err.Error()); err != nil {
+			return err
+		}
+		_, err := d.events.Update(ctx, event)
+		return err
+	}
+
+	var failures []string
+	retryable := false
+	for _, subscription := range targets {
+		result := d.send(ctx, event, subscription, body)
+		if err := d.deliveries.Record(ctx, result.attempt); err != nil {
+			return err
+		}
+
+		if result.attempt.Success {
+			subscription.RecordDelivery()
+		} else {
+			subscription.RecordFailure()
+			failures = append(failures, result.attempt.Error)
+			retryable = retryable || result.retryable
+			logger.GetLogger().Warn("webhook delivery failed",
+				zap.String("event_id", event.ID()),
+				zap.String("subscription_id", subscription.ID()),
+				zap.String("error", result.attempt.Error),
+			)
+		}
+		if _, err := d.subscriptions.Update(ctx, subscription); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case len(failures) == 0:
+		err = event.MarkAsDelivered()
+	case retryable:
+		// Past the last retry the event is abandoned, which is not an error here
+		if retryErr := event.MarkForRetry(strings.Join(failures, "; ")); retryErr != nil && !event.Status().Equals(authorization_audit.WebhookStatusAbandoned) {
+			err = retryErr
+		}
+	default:
+		err = event.MarkAsFailed(strings.Join(failures, "; "))
+	}
+	if err != nil {
+		return err
+	}
+	_, err = d.events.Update(ctx, event)
+	return err
+}
+
+// RedeliverEvent sends the event to the subscription regardless of the state
+// of the event and of the subscription filters. The attempt counts towards the
+// health of the subscription like any other. A failed redelivery leaves the
+// event as it was; a successful one completes the event when no other
+// subscription is still owed it.
+func (d *dispatcher) RedeliverEvent(ctx context.Context, eventID string, subscriptionID string) (*authorization_audit.WebhookDeliveryAttempt, error) {
+	event, err := d.events.FindByID(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	subscription, err := d.subscriptions.FindByID(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if subscription.Endpoint().Value() != event.DeliveryURL() {
+		return nil, apperrors.Newf(apperrors.ErrValidation, "webhook event %s is not addressed to subscription %s", eventID, subscriptionID)
+	}
+	if !subscription.Status().IsActive() {
+		return nil, apperrors.Newf(apperrors.ErrValidation, "webhook subscription %s is %s", subscriptionID, subscription.Status().Value())
+	}
+
+	body, err := encode(event)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorization_audit.ValidateWebhookPayload(body); err != nil {
+		return nil, apperrors.Newf(apperrors.ErrValidation, "webhook event %s does not match its schema: %w", eventID, err)
+	}
+	previous, err := d.deliveries.FindByEventID(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := d.send(ctx, event, subscription, body)
+	result.attempt.Attempt = len(previous) + 1
+	result.attempt.Redelivery = true
+	if err := d.deliveries.Record(ctx, result.attempt); err != nil {
+		return nil, err
+	}
+	if result.attempt.Success {
+		subscription.RecordDelivery()
+	} else {
+		subscription.RecordFailure()
+	}
+	if _, err := d.subscriptions.Update(ctx, subscription); err != nil {
+		return nil, err
+	}
+
+	if result.attempt.Success && !event.Status().IsDelivered() {
+		targets, _, err := d.targets(ctx, event)
+		if err != nil {
+			return nil, err
+		}
+		if len(targets) == 0 {
+			if err := event.MarkAsDelivered(); err != nil {
+				return nil, err
+			}
+			if _, err := d.events.Update(ctx, event); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return result.attempt, nil
+}
+
+// encode returns the JSON body delivered for the event
+func encode(event *authorization_audit.WebhookEvent) ([]byte, error) {
+	body, err := json.Marshal(NewPayload(event))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode webhook event %s: %w", event.ID(), err)
+	}
+	return body, nil
+}
+
+// targets returns the subscriptions the event still has to be delivered to,
+// and how many subscriptions would have received it but for their filters
+func (d *dispatcher) targets(ctx context.Context, event *authorization_audit.WebhookEvent) ([]*authorization_audit.WebhookSubscription, int, error) {
+	subscriptions, err := d.subscriptions.FindByEndpoint(ctx, event.DeliveryURL())
+	if err != nil {
+		return nil, 0, err
+	}
+	// Retries skip subscriptions an earlier attempt already reached
+	attempts, err := d.deliveries.FindByEventID(ctx, event.ID())
+	if err != nil {
+		return nil, 0, err
+	}
+	delivered := make(map[string]bool, len(attempts))
+	for _, attempt := range attempts {
+		if attempt.Success {
+			delivered[attempt.SubscriptionID] = true
+		}
+	}
+
+	targets := make([]*authorization_audit.WebhookSubscription, 0, len(subscriptions))
+	filtered := 0
+	for _, subscription := range subscriptions {
+		if delivered[subscription.ID()] || !subscription.CanDeliver() || !subscription.IsSubscribedTo(event.EventType()) {
+			continue
+		}
+		if !subscription.Matches(event) {
+			filtered++
+			continue
+		}
+		targets = append(targets, subscription)
+	}
+	return targets, filtered, nil
+}
+
+// sendResult is the outcome of a single delivery
+type sendResult struct {
+	attempt *authorization_audit.WebhookDeliveryAttempt
+	// retryable is set for failures that may succeed later: network errors,
+	// timeouts, rate limiting and server errors
+	retryable bool
+}
+
+// send posts body to the subscription endpoint, signed with its secret
+func (d *dispatcher) send(ctx context.Context, event *authorization_audit.WebhookEvent, subscription *authorization_audit.WebhookSubscription, body []byte) sendResult {
+	start := time.Now()
+	attempt := &authorization_audit.WebhookDeliveryAttempt{
+		EventID:        event.ID(),
+		SubscriptionID: subscription.ID(),
+		Attempt:        event.RetryCount() + 1,
+		URL:            subscription.Endpoint().Value(),
+		AttemptedAt:    start,
+	}
+	result := sendResult{attempt: attempt}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, attempt.URL, bytes.NewReader(body))
+	if err != nil {
+		attempt.Error = fmt.Sprintf("failed to create webhook request: %v", err)
+		return result
+	}
+	for name, value := range subscription.Headers() {
+		req.Header.Set(name, value)
+	}
+	timestamp := start.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, event.EventType().Value())
+	req.Header.Set(HeaderEventID, event.ID())
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(subscription.Secret(), timestamp, body))
+
+	client, err := d.clientFor(subscription)
+	if err != nil {
+		attempt.Error = err.Error()
+		return result
+	}
+	resp, err := client.Do(req)
+	attempt.Duration = time.Since(start)
+	if err != nil {
+		attempt.Error = fmt.Sprintf("failed to send webhook: %v", err)
+		result.retryable = true
+		return result
+	}
+	defer resp.Body.Close()
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, MaxResponseSnippet))
+	attempt.ResponseBody = strings.ToValidUTF8(string(snippet), "")
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainedBody-MaxResponseSnippet))
+
+	attempt.StatusCode = resp.StatusCode
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		attempt.Success = true
+		return result
+	}
+	attempt.Error = fmt.Sprintf("webhook endpoint returned status %d", resp.StatusCode)
+	result.retryable = resp.StatusCode >= 500 ||
+		resp.StatusCode == http.StatusRequestTimeout ||
+		resp.StatusCode == http.StatusTooManyRequests
+	return result
+}
+
+// clientFor returns the client that reaches the subscription endpoint with its
+// transport options. Clients are reused until the options change.
+func (d *dispatcher) clientFor(subscription *authorization_audit.WebhookSubscription) (*http.Client, error) {
+	transport := subscription.Transport()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	cached, ok := d.clients[subscription.ID()]
+	if transport.IsZero() {
+		if ok {
+			cached.client.CloseIdleConnections()
+			delete(d.clients, subscription.ID())
+		}
+		return d.client, nil
+	}
+	if ok && cached.transport.Equals(transport) {
+		return cached.client, nil
+	}
+
+	client, err := newTransportClient(transport, d.client.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		cached.client.CloseIdleConnections()
+	}
+	d.clients[subscription.ID()] = transportClient{transport: transport, client: client}
+	return client, nil
+}
+
+// newTransportClient builds a client applying the transport options
+func newTransportClient(options authorization_audit.WebhookTransport, timeout time.Duration) (*http.Client, error) {
+	config := &tls.Config{MinVersion: options.TLSVersion()}
+	if options.CABundle != "" {
+		roots := x509.NewCertPool()
+		if !roots.AppendCertsFromPEM([]byte(options.CABundle)) {
+			return nil, fmt.Errorf("webhook CA bundle holds no PEM certificate")
+		}
+		config.RootCAs = roots
+	}
+	if options.MutualTLS() {
+		certificate, err := tls.X509KeyPair([]byte(options.ClientCertificate), []byte(options.ClientKey))
+		if err != nil {
+			return nil, fmt.Errorf("invalid webhook client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = config
+	if len(options.PinnedIPs) > 0 {
+		// A proxy would choose the destination itself
+		transport.Proxy = nil
+		transport.DialContext = pinnedDialer(options.PinnedIPs)
+	}
+	return &http.Client{Transport: transport, Timeout: timeout}, nil
+}
+
+// pinnedDialer connects to the first reachable pinned IP on the requested
+// port instead of the address the host name resolves to. TLS still verifies
+// the certificate against the host name of the endpoint.
+func pinnedDialer(ips []string) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		_, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		var errs []error
+		for _, ip := range ips {
+			conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip, port))
+			if err == nil {
+				return conn, nil
+			}
+			errs = append(errs, err)
+		}
+		return nil, fmt.Errorf("no pinned IP reachable: %w", errors.Join(errs...))
+	}
+}
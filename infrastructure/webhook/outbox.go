@@ -0,0 +1,101 @@
+package webhook
+
+import (
+	"context"
+
+	authorization_audit "github.com/aruncs31s/azf/domain/authorization_audit/model"
+	"github.com/aruncs31s/azf/domain/model"
+	"github.com/aruncs31s/azf/shared/ids"
+)
+
+// Outbox stages the webhook events of authorization audit logs. Events are
+// staged as pending rows of the event table in the transaction that saves the
+// logs, and the worker delivers them from there, so a saved log is never left
+// without its events and a log that fails to save publishes none.
+type Outbox struct {
+	events        authorization_audit.WebhookEventRepository
+	subscriptions authorization_audit.WebhookSubscriptionRepository
+}
+
+// NewOutbox creates an outbox writing events to the given repositories
+func NewOutbox(
+	events authorization_audit.WebhookEventRepository,
+	subscriptions authorization_audit.WebhookSubscriptionRepository,
+) *Outbox {
+	return &Outbox{
+		events:        events,
+		subscriptions: subscriptions,
+	}
+}
+
+// Stage creates an event for every delivery URL with an active subscription
+// to the decision of a log whose filters it passes. ctx must carry the
+// transaction saving the logs.
+func (o *Outbox) Stage(ctx context.Context, logs []*model.AuthorizationAuditLog) error {
+	if len(logs) == 0 {
+		return nil
+	}
+	subscriptions, err := o.subscriptions.FindActive(ctx)
+	if err != nil {
+		return err
+	}
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	var events []*authorization_audit.WebhookEvent
+	for _, log := range logs {
+		eventType := authorization_audit.EventTypeAuthorizationGranted
+		if log.Result().IsDenied() {
+			eventType = authorization_audit.EventTypeAuthorizationDenied
+		}
+		payload := auditEventPayload(log)
+
+		staged := make(map[string]bool)
+		for _, subscription := range subscriptions {
+			url := subscription.Endpoint().Value()
+			if staged[url] || !subscription.CanDeliver() || !subscription.IsSubscribedTo(eventType) {
+				continue
+			}
+			event, err := authorization_audit.NewWebhookEvent(ids.New(), eventType, log.ID(), payload, log.Timestamp(), url)
+			if err != nil {
+				return err
+			}
+			if !subscription.Matches(event) {
+				continue
+			}
+			staged[url] = true
+			events = append(events, event)
+		}
+	}
+	if len(events) == 0 {
+		return nil
+	}
+	_, err = o.events.BulkCreate(ctx, events)
+	return err
+}
+
+// auditEventPayload returns the event data of an authorization audit log
+func auditEventPayload(log *model.AuthorizationAuditLog) map[string]interface{} {
+	payload := map[string]interface{}{
+		"user_id":  log.UserID(),
+		"role":     log.Role(),
+		"resource": log.Resource(),
+		"action":   log.Action(),
+	}
+	optional := map[string]string{
+		"reason":      log.DenialReason().Value(),
+		"request_id":  log.RequestID(),
+		"ip_address":  log.IPAddress(),
+		"environment": log.Environment(),
+	}
+	if tenant, ok := log.Details()["tenant_id"].(string); ok {
+		optional["tenant_id"] = tenant
+	}
+	for key, value := range optional {
+		if value != "" {
+			payload[key] = value
+		}
+	}
+	return payload
+}
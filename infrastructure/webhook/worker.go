@@ -0,0 +1,73 @@
+package webhook
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	authorization_audit "github.com/aruncs31s/azf/domain/authorization_audit/model"
+	"github.com/aruncs31s/azf/shared/logger"
+	"go.uber.org/zap"
+)
+
+// DefaultWorkerInterval is how often the worker looks for events to deliver
+const DefaultWorkerInterval = 30 * time.Second
+
+// Worker delivers pending events and retries failed ones in the background
+type Worker struct {
+	dispatcher authorization_audit.WebhookDispatcher
+	stop       chan struct{}
+	done       chan struct{}
+	stopOnce   sync.Once
+}
+
+// StartWorker starts a worker that runs the dispatcher every interval until
+// it is stopped
+func StartWorker(dispatcher authorization_audit.WebhookDispatcher, interval time.Duration) *Worker {
+	if interval <= 0 {
+		interval = DefaultWorkerInterval
+	}
+	w := &Worker{
+		dispatcher: dispatcher,
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	go w.loop(interval)
+	return w
+}
+
+// Stop stops the worker and waits for the current run to finish
+func (w *Worker) Stop() {
+	w.stopOnce.Do(func() { close(w.stop) })
+	<-w.done
+}
+
+func (w *Worker) loop(interval time.Duration) {
+	defer close(w.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.run(interval)
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// run delivers pending events, then retries the ones whose backoff passed.
+// A run is bounded by the interval so a slow endpoint cannot stall the worker.
+func (w *Worker) run(interval time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), interval)
+	defer cancel()
+
+	if err := w.dispatcher.DispatchPending(ctx); err != nil {
+		logger.Warn("Failed to dispatch pending webhook events", zap.Error(err))
+	}
+	if err := w.dispatcher.RetryFailed(ctx); err != nil {
+		logger.Warn("Failed to retry webhook events", zap.Error(err))
+	}
+}
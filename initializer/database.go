@@ -0,0 +1,131 @@
+package initializer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/aruncs31s/azf/config"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// DialectorFunc opens a gorm dialector for a DSN
+type DialectorFunc func(dsn string) gorm.Dialector
+
+// migrationLockName names the advisory lock that keeps instances sharing a
+// database from migrating it at the same time
+const migrationLockName = "azf_migrations"
+
+// migrationLockTimeoutSeconds is how long MySQL waits for the migration lock
+const migrationLockTimeoutSeconds = 60
+
+var (
+	dialectorsMu sync.RWMutex
+	dialectors   = map[string]DialectorFunc{
+		config.DBDriverSQLite: sqlite.Open,
+		config.DBDriverMySQL:  mysql.Open,
+	}
+)
+
+// RegisterDialector makes a database driver selectable with DB_DRIVER.
+// SQLite and MySQL are built in; Postgres is enabled by registering its
+// driver, e.g. RegisterDialector(config.DBDriverPostgres, postgres.Open).
+func RegisterDialector(driver string, open DialectorFunc) {
+	dialectorsMu.Lock()
+	defer dialectorsMu.Unlock()
+	dialectors[strings.ToLower(driver)] = open
+}
+
+// OpenDatabase connects to the database described by cfg and applies its
+// connection pool settings
+func OpenDatabase(cfg config.DBConfig) (*gorm.DB, error) {
+	driver := strings.ToLower(cfg.Driver)
+	if driver == "" {
+		driver = config.DBDriverSQLite
+	}
+
+	dialectorsMu.RLock()
+	open, ok := dialectors[driver]
+	dialectorsMu.RUnlock()
+	if !ok {
+		if driver == config.DBDriverPostgres {
+			return nil, fmt.Errorf("database driver %q is not registered; call initializer.RegisterDialector with gorm.io/driver/postgres", driver)
+		}
+		return nil, fmt.Errorf("unsupported database driver %q", driver)
+	}
+
+	dsn := cfg.DSN
+	if driver == config.DBDriverSQLite {
+		if dsn == "" {
+			dsn = config.DefaultSQLiteDSN
+		}
+		// best-effort ensure the directory of a file-backed database exists
+		if !strings.Contains(dsn, ":memory:") && !strings.Contains(dsn, "mode=memory") {
+			_ = os.MkdirAll(filepath.Dir(strings.TrimPrefix(dsn, "file:")), 0o755)
+		}
+	}
+
+	db, err := gorm.Open(open(dsn), &gorm.Config{
+		SkipDefaultTransaction: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s database: %w", driver, err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure %s connection pool: %w", driver, err)
+	}
+	if cfg.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+	if cfg.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	if cfg.ConnMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	}
+	if cfg.ConnMaxIdleTime > 0 {
+		sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	}
+	return db, nil
+}
+
+// withMigrationLock runs migrate while holding a database-wide lock on
+// Postgres and MySQL, so instances starting together against a shared
+// database do not race on schema changes. SQLite databases are not shared
+// and are migrated directly.
+func withMigrationLock(db *gorm.DB, migrate func(db *gorm.DB) error) error {
+	switch db.Dialector.Name() {
+	case config.DBDriverPostgres:
+		// Advisory locks belong to a session, so lock, migrate and unlock
+		// on a single connection
+		return db.Connection(func(conn *gorm.DB) error {
+			if err := conn.Exec("SELECT pg_advisory_lock(hashtext(?))", migrationLockName).Error; err != nil {
+				return fmt.Errorf("failed to acquire migration lock: %w", err)
+			}
+			defer conn.Exec("SELECT pg_advisory_unlock(hashtext(?))", migrationLockName)
+			return migrate(conn)
+		})
+	case config.DBDriverMySQL:
+		return db.Connection(func(conn *gorm.DB) error {
+			var acquired int
+			if err := conn.Raw("SELECT GET_LOCK(?, ?)", migrationLockName, migrationLockTimeoutSeconds).Scan(&acquired).Error; err != nil {
+				return fmt.Errorf("failed to acquire migration lock: %w", err)
+			}
+			if acquired != 1 {
+				return fmt.Errorf("timed out waiting for migration lock %q", migrationLockName)
+			}
+			defer conn.Exec("SELECT RELEASE_LOCK(?)", migrationLockName)
+			// Tables are created as InnoDB with full UTF-8 so indexes and
+			// foreign keys behave the same on every server default
+			return migrate(conn.Set("gorm:table_options", "ENGINE=InnoDB DEFAULT CHARSET=utf8mb4"))
+		})
+	default:
+		return migrate(db)
+	}
+}
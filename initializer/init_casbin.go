@@ -5,9 +5,9 @@ import (
 	"sync"
 
 	"github.com/aruncs31s/azf/config"
+	"github.com/aruncs31s/azf/infrastructure/abac"
 	"github.com/aruncs31s/azf/shared/logger"
 	"github.com/casbin/casbin/v2"
-	fileadapter "github.com/casbin/casbin/v2/persist/file-adapter"
 	"go.uber.org/zap"
 )
 
@@ -34,10 +34,10 @@ func InitCasbin() error {
 	var err error
 
 	// Create file adapter for the policy file
-	adapter := fileadapter.NewAdapter(config.CASBIN_POLICY_FILE)
+	adapter := newPolicyFileAdapter(config.CASBIN_POLICY_FILE)
 
 	// Create enforcer with model config and adapter
-	CasbinEnforcer, err = casbin.NewEnforcer(config.CASBIN_MODEL_FILE, adapter)
+	CasbinEnforcer, err = casbin.NewEnforcer(config.CasbinModelFile(), adapter)
 	if err != nil {
 		// Log the error and return it so callers can decide how to handle it
 		logger.Error("Failed to create Casbin enforcer", zap.Error(err), zap.String("file", config.CASBIN_POLICY_FILE))
@@ -52,6 +52,7 @@ func InitCasbin() error {
 		return err
 	}
 
+	abac.Register(CasbinEnforcer)
 	casbinInitialized = true
 
 	// Informational log using wrapper
@@ -82,7 +83,9 @@ func AddRolePolicy(role, resource, action string) bool {
 		log.Printf("Casbin enforcer not initialized")
 		return false
 	}
-	added, err := enforcer.AddPolicy(role, resource, action)
+	added, err := ApplyPolicyChange(enforcer, func(tx *PolicyTransaction) (bool, error) {
+		return tx.AddPolicy([]string{role, resource, action})
+	})
 	if err != nil {
 		log.Printf("Failed to add policy: %v", err)
 		return false
@@ -98,7 +101,9 @@ func RemoveRolePolicy(role, resource, action string) bool {
 		log.Printf("Casbin enforcer not initialized")
 		return false
 	}
-	removed, err := enforcer.RemovePolicy(role, resource, action)
+	removed, err := ApplyPolicyChange(enforcer, func(tx *PolicyTransaction) (bool, error) {
+		return tx.RemovePolicy([]string{role, resource, action})
+	})
 	if err != nil {
 		log.Printf("Failed to remove policy: %v", err)
 		return false
@@ -114,7 +119,9 @@ func AssignRoleToUser(user, role string) bool {
 		log.Printf("Casbin enforcer not initialized")
 		return false
 	}
-	added, err := enforcer.AddGroupingPolicy(user, role)
+	added, err := ApplyPolicyChange(enforcer, func(tx *PolicyTransaction) (bool, error) {
+		return tx.AddGroupingPolicy([]string{user, role})
+	})
 	if err != nil {
 		log.Printf("Failed to assign role: %v", err)
 		return false
@@ -129,7 +136,9 @@ func RemoveRoleFromUser(user, role string) bool {
 		log.Printf("Casbin enforcer not initialized")
 		return false
 	}
-	removed, err := enforcer.RemoveGroupingPolicy(user, role)
+	removed, err := ApplyPolicyChange(enforcer, func(tx *PolicyTransaction) (bool, error) {
+		return tx.RemoveGroupingPolicy([]string{user, role})
+	})
 	if err != nil {
 		log.Printf("Failed to remove role: %v", err)
 		return false
@@ -145,7 +154,8 @@ func CheckPermission(user, resource, action string) bool {
 		log.Printf("Casbin enforcer not initialized")
 		return false
 	}
-	allowed, err := enforcer.Enforce(user, resource, action)
+	// Checks without a route carry no condition
+	allowed, err := Enforce(enforcer, abac.RequestValues(enforcer, user, resource, action, "", nil)...)
 	if err != nil {
 		log.Printf("Error checking permission: %v", err)
 		return false
@@ -160,7 +170,12 @@ func GetRolesForUser(user string) []string {
 		log.Printf("Casbin enforcer not initialized")
 		return []string{}
 	}
-	roles, err := enforcer.GetRolesForUser(user)
+	var roles []string
+	err := ReadPolicies(enforcer, func() error {
+		var err error
+		roles, err = enforcer.GetRolesForUser(user)
+		return err
+	})
 	if err != nil {
 		log.Printf("Error getting roles: %v", err)
 		return []string{}
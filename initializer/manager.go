@@ -7,14 +7,12 @@ import (
 
 	"github.com/aruncs31s/azf/config"
 	"github.com/aruncs31s/azf/domain/api_usage"
-	"github.com/aruncs31s/azf/infrastructure/persistence"
+	"github.com/aruncs31s/azf/infrastructure/abac"
+	"github.com/aruncs31s/azf/internal/persistence"
 	"github.com/aruncs31s/azf/shared/logger"
 	"github.com/aruncs31s/azf/utils"
 	"github.com/casbin/casbin/v2"
-	fileadapter "github.com/casbin/casbin/v2/persist/file-adapter"
 	"go.uber.org/zap"
-	"gorm.io/driver/mysql"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
@@ -50,8 +48,9 @@ func NewManager(
 }
 
 // InitLocalDB ensures the Manager has a working *gorm.DB.
-// If a non-nil tempDB is passed, it will be used as-is. Otherwise the Manager attempts to
-// open a file-backed sqlite DB at /tmp/AZF_auth_z.db, falling back to an in-memory DB if the file cannot be created.
+// If a non-nil tempDB is passed, it will be used as-is. Otherwise the Manager opens the
+// database configured with DB_DRIVER and DB_DSN (see config.GetDBConfig), by default a
+// file-backed sqlite DB at tmp/AZF_auth_z.db.
 func (m *Manager) InitLocalDB(
 	tempDB *gorm.DB,
 ) error {
@@ -78,38 +77,46 @@ func (m *Manager) InitLocalDB(
 		return nil
 	}
 
-	// Try to create/open file-backed DB
-	localDBPath := "tmp/AZF_auth_z.db"
-	// best-effort ensure directory exists
-	_ = os.MkdirAll("tmp", 0o755)
+	return m.initDatabase(config.GetDBConfig())
+}
 
-	db, err := gorm.Open(sqlite.Open(localDBPath), &gorm.Config{
-		SkipDefaultTransaction: true,
-	})
+// InitDatabase opens the database described by cfg on the Manager and
+// migrates it, unless the Manager already has a DB
+func (m *Manager) InitDatabase(cfg config.DBConfig) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.DB != nil {
+		m.logger.Debug("Local DB already initialized on manager")
+		return nil
+	}
+	return m.initDatabase(cfg)
+}
+
+func (m *Manager) initDatabase(cfg config.DBConfig) error {
+	db, err := OpenDatabase(cfg)
 	if err != nil {
-		logger.GetLogger().Info(
-			"Using Mysql DB as fallback",
-		)
-		m.DB = connectToMYQSLDB()
-	} else {
-		m.DB = db
+		if cfg.Driver != "" && cfg.Driver != config.DBDriverSQLite {
+			m.logger.Error("failed to open database", zap.String("driver", cfg.Driver), zap.Error(err))
+			return err
+		}
+		// A local sqlite file that cannot be created falls back to memory
+		m.logger.Warn("failed to open sqlite database, using in-memory fallback", zap.Error(err))
+		cfg.DSN = "file::memory:?cache=shared"
+		if db, err = OpenDatabase(cfg); err != nil {
+			m.logger.Error("failed to open in-memory sqlite fallback", zap.Error(err))
+			return err
+		}
 	}
+	m.DB = db
 
 	if err := migrateTable(m.DB); err != nil {
-		m.logger.Error("migration failed on file-backed DB", zap.Error(err))
+		m.logger.Error("migration failed on database", zap.String("driver", db.Dialector.Name()), zap.Error(err))
 		return err
 	}
-	m.logger.Info("initialized file-backed local DB", zap.String("path", localDBPath))
+	m.logger.Info("initialized database", zap.String("driver", db.Dialector.Name()))
 	return nil
 }
-func connectToMYQSLDB() *gorm.DB {
-	dsn := config.GetLocalDBDSN()
-	db, err := gorm.Open(mysql.Open(dsn))
-	if err != nil {
-		return nil
-	}
-	return db
-}
 
 // migrateTable runs AutoMigrate for API usage tables on the provided DB.
 // kept as a helper to keep initialization logic together.
@@ -117,19 +124,61 @@ func migrateTable(db *gorm.DB) error {
 	if db == nil {
 		return errors.New("migrateTable: db is nil")
 	}
+	return withMigrationLock(db, migrateSchema)
+}
+
+// migrateSchema creates or updates every azf table
+func migrateSchema(db *gorm.DB) error {
 	if err := db.AutoMigrate(
 		api_usage.APIUsageStats{},
 		api_usage.APIUsageLog{},
+		api_usage.UsageAnnotation{},
+		api_usage.RateLimitOverride{},
+		api_usage.UsageQuota{},
+		api_usage.WebPushSubscription{},
+		api_usage.WebPushVAPIDKeys{},
+		api_usage.AlertIncident{},
+		api_usage.FeatureFlag{},
+		api_usage.FeatureFlagChange{},
 		&persistence.UserModel{},
+		&persistence.RoleModel{},
+		&persistence.UserRoleModel{},
+		&persistence.UserAuditModel{},
+		&persistence.TextDictionaryEntry{},
+		&persistence.WebhookEventModel{},
+		&persistence.WebhookSubscriptionModel{},
+		&persistence.WebhookDeliveryModel{},
+		&persistence.CasbinRuleModel{},
+		&persistence.RefreshTokenModel{},
+		&persistence.APIKeyModel{},
+		&persistence.AdminSessionModel{},
+		&persistence.AdminTwoFactorModel{},
 	); err != nil {
 		return err
 	}
+	if err := persistence.BackfillUserRoles(db); err != nil {
+		return err
+	}
+	if config.TimescaleEnabled() {
+		if err := persistence.SetupTimescale(db); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
 // InitCasbin initializes the casbin enforcer if it does not already exist on the Manager.
 // It accepts optional model and policy paths; if either is empty, defaults from config are used.
+// Policies are stored as selected by CASBIN_POLICY_STORAGE.
 func (m *Manager) InitCasbin(modelPath, policyPath string) error {
+	return m.InitCasbinWithStorage(modelPath, policyPath, config.CasbinPolicyStorage())
+}
+
+// InitCasbinWithStorage initializes the casbin enforcer with its policies in
+// storage, config.CASBIN_POLICY_STORAGE_FILE or config.CASBIN_POLICY_STORAGE_DATABASE.
+// Database storage uses the Manager database and is seeded from policyPath
+// when it holds no policies yet.
+func (m *Manager) InitCasbinWithStorage(modelPath, policyPath, storage string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -150,7 +199,11 @@ func (m *Manager) InitCasbin(modelPath, policyPath string) error {
 	} else {
 		logger.Log.Info(configDir + " folder exists")
 	}
-	if _, err := os.Stat(policyPath); os.IsNotExist(err) {
+	useDatabase := storage == config.CASBIN_POLICY_STORAGE_DATABASE
+	if useDatabase && m.DB == nil {
+		return errors.New("database policy storage requires an initialized database")
+	}
+	if _, err := os.Stat(policyPath); os.IsNotExist(err) && !useDatabase {
 		m.logger.Error(
 			"policy file does not exist",
 			zap.String("path", policyPath),
@@ -176,7 +229,7 @@ func (m *Manager) InitCasbin(modelPath, policyPath string) error {
 		)
 		// Attempt to copy the embedded default model file to the specified path
 		// Use embedded default instead of reading from relative path
-		data := config.DefaultModel // Embedded bytes, no file I/O needed
+		data := config.DefaultModelFor(modelPath) // Embedded bytes, no file I/O needed
 
 		if len(data) == 0 {
 			m.logger.Error("embedded default model file is empty")
@@ -189,7 +242,19 @@ func (m *Manager) InitCasbin(modelPath, policyPath string) error {
 		m.logger.Info("successfully copied default policy to", zap.String("path", modelPath))
 	}
 
-	adapter := fileadapter.NewAdapter(policyPath)
+	if useDatabase {
+		enf, err := NewDatabaseEnforcer(m.DB, modelPath, policyPath)
+		if err != nil {
+			m.logger.Error("failed to create database Casbin enforcer", zap.Error(err), zap.String("model", modelPath))
+			return err
+		}
+		m.Enforcer = enf
+		m.initialized = true
+		m.logger.Info("Casbin initialized on manager", zap.String("storage", storage))
+		return nil
+	}
+
+	adapter := newPolicyFileAdapter(policyPath)
 	enf, err := casbin.NewEnforcer(modelPath, adapter)
 	if err != nil {
 		m.logger.Error("failed to create Casbin enforcer", zap.Error(err), zap.String("policy", policyPath), zap.String("model", modelPath))
@@ -200,6 +265,7 @@ func (m *Manager) InitCasbin(modelPath, policyPath string) error {
 		m.logger.Error("failed to load Casbin policy", zap.Error(err), zap.String("policy", policyPath))
 		return err
 	}
+	abac.Register(enf)
 
 	m.Enforcer = enf
 	m.initialized = true
@@ -223,8 +289,9 @@ func (m *Manager) AddRolePolicy(role, resource, action string) (bool, error) {
 	if enf == nil {
 		return false, errors.New("enforcer not initialized")
 	}
-	added, err := enf.AddPolicy(role, resource, action)
-	return added, err
+	return ApplyPolicyChange(enf, func(tx *PolicyTransaction) (bool, error) {
+		return tx.AddPolicy([]string{role, resource, action})
+	})
 }
 
 // RemoveRolePolicy wraps Enforcer.RemovePolicy in a safe way.
@@ -236,8 +303,9 @@ func (m *Manager) RemoveRolePolicy(role, resource, action string) (bool, error)
 	if enf == nil {
 		return false, errors.New("enforcer not initialized")
 	}
-	removed, err := enf.RemovePolicy(role, resource, action)
-	return removed, err
+	return ApplyPolicyChange(enf, func(tx *PolicyTransaction) (bool, error) {
+		return tx.RemovePolicy([]string{role, resource, action})
+	})
 }
 
 // AssignRoleToUser adds a grouping policy (assigns role to user).
@@ -249,8 +317,9 @@ func (m *Manager) AssignRoleToUser(user, role string) (bool, error) {
 	if enf == nil {
 		return false, errors.New("enforcer not initialized")
 	}
-	added, err := enf.AddGroupingPolicy(user, role)
-	return added, err
+	return ApplyPolicyChange(enf, func(tx *PolicyTransaction) (bool, error) {
+		return tx.AddGroupingPolicy([]string{user, role})
+	})
 }
 
 // RemoveRoleFromUser removes a grouping policy.
@@ -262,8 +331,22 @@ func (m *Manager) RemoveRoleFromUser(user, role string) (bool, error) {
 	if enf == nil {
 		return false, errors.New("enforcer not initialized")
 	}
-	removed, err := enf.RemoveGroupingPolicy(user, role)
-	return removed, err
+	return ApplyPolicyChange(enf, func(tx *PolicyTransaction) (bool, error) {
+		return tx.RemoveGroupingPolicy([]string{user, role})
+	})
+}
+
+// SavePolicy writes the enforcer's policies to the policy file. Changes made
+// through the manager are saved automatically unless CASBIN_AUTO_SAVE is off.
+func (m *Manager) SavePolicy() error {
+	m.mu.RLock()
+	enf := m.Enforcer
+	m.mu.RUnlock()
+
+	if enf == nil {
+		return errors.New("enforcer not initialized")
+	}
+	return SavePolicies(enf)
 }
 
 // CheckPermission performs an enforcement check (user, resource, action).
@@ -275,7 +358,8 @@ func (m *Manager) CheckPermission(user, resource, action string) (bool, error) {
 	if enf == nil {
 		return false, errors.New("enforcer not initialized")
 	}
-	allowed, err := enf.Enforce(user, resource, action)
+	// Checks without a route carry no condition
+	allowed, err := Enforce(enf, abac.RequestValues(enf, user, resource, action, "", nil)...)
 	if err != nil {
 		return false, err
 	}
@@ -291,7 +375,12 @@ func (m *Manager) GetRolesForUser(user string) ([]string, error) {
 	if enf == nil {
 		return nil, errors.New("enforcer not initialized")
 	}
-	roles, err := enf.GetRolesForUser(user)
+	var roles []string
+	err := ReadPolicies(enf, func() error {
+		var err error
+		roles, err = enf.GetRolesForUser(user)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -350,6 +439,7 @@ func NewAndInitManager(
 	// If a casbin enforcer was supplied at construction, mark initialized.
 	if casbinEnforcer != nil {
 		m.mu.Lock()
+		abac.Register(casbinEnforcer)
 		m.Enforcer = casbinEnforcer
 		m.initialized = true
 		m.mu.Unlock()
@@ -357,7 +447,7 @@ func NewAndInitManager(
 	}
 	modelPath, err := utils.GetEnv("CASBIN_MODEL")
 	if err != nil {
-		modelPath = config.CASBIN_MODEL_FILE
+		modelPath = config.CasbinModelFile()
 	}
 	policyPath, err := utils.GetEnv("CASBIN_POLICY")
 	if err != nil {
@@ -0,0 +1,50 @@
+package initializer
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/aruncs31s/azf/utils"
+	"github.com/casbin/casbin/v2/model"
+	fileadapter "github.com/casbin/casbin/v2/persist/file-adapter"
+	"github.com/casbin/casbin/v2/util"
+)
+
+// policyFileAdapter is the Casbin file adapter with a SavePolicy that
+// replaces the policy file atomically, so a failed or concurrent save never
+// leaves a truncated file behind
+type policyFileAdapter struct {
+	*fileadapter.Adapter
+	filePath string
+}
+
+// newPolicyFileAdapter creates a policy file adapter for filePath
+func newPolicyFileAdapter(filePath string) *policyFileAdapter {
+	return &policyFileAdapter{
+		Adapter:  fileadapter.NewAdapter(filePath),
+		filePath: filePath,
+	}
+}
+
+// SavePolicy writes all policy rules to the policy file
+func (a *policyFileAdapter) SavePolicy(m model.Model) error {
+	if a.filePath == "" {
+		return errors.New("invalid file path, file path cannot be empty")
+	}
+
+	var lines []string
+	for _, sec := range []string{"p", "g"} {
+		ptypes := make([]string, 0, len(m[sec]))
+		for ptype := range m[sec] {
+			ptypes = append(ptypes, ptype)
+		}
+		sort.Strings(ptypes)
+		for _, ptype := range ptypes {
+			for _, rule := range m[sec][ptype].Policy {
+				lines = append(lines, ptype+", "+util.ArrayToString(rule))
+			}
+		}
+	}
+	return utils.WriteFileAtomic(a.filePath, []byte(strings.Join(lines, "\n")), 0o644)
+}
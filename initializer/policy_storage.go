@@ -0,0 +1,124 @@
+package initializer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aruncs31s/azf/config"
+	"github.com/aruncs31s/azf/infrastructure/abac"
+	"github.com/aruncs31s/azf/internal/persistence"
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/persist"
+	"gorm.io/gorm"
+)
+
+// NewDatabaseEnforcer creates an enforcer for modelPath whose policies are
+// stored in db. When db holds no policies yet they are seeded from
+// seedPolicyPath, or from the embedded default policy when that file does
+// not exist.
+func NewDatabaseEnforcer(db *gorm.DB, modelPath, seedPolicyPath string) (*casbin.Enforcer, error) {
+	if db == nil {
+		return nil, errors.New("database policy storage requires a database connection")
+	}
+	adapter := persistence.NewCasbinRuleAdapter(db)
+	enf, err := casbin.NewEnforcer(modelPath, adapter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Casbin enforcer: %w", err)
+	}
+	// The adapter writes each change as it is made; with auto-save off the
+	// changes are only written by SavePolicies
+	enf.EnableAutoSave(config.CasbinAutoSave())
+
+	if err := seedPolicies(enf, adapter, seedPolicyPath); err != nil {
+		return nil, err
+	}
+	if err := enf.LoadPolicy(); err != nil {
+		return nil, fmt.Errorf("failed to load Casbin policy: %w", err)
+	}
+	abac.Register(enf)
+	return enf, nil
+}
+
+// seedPolicies stores the policies of policyPath, or the embedded default
+// policy, when adapter holds none yet
+func seedPolicies(enf *casbin.Enforcer, adapter *persistence.CasbinRuleAdapter, policyPath string) error {
+	empty, err := adapter.IsEmpty()
+	if err != nil || !empty {
+		return err
+	}
+
+	data, err := os.ReadFile(policyPath)
+	if err != nil {
+		data = config.DefaultPolicy
+	}
+	m := enf.GetModel()
+	m.ClearPolicy()
+	for _, line := range strings.Split(string(data), "\n") {
+		if err := persist.LoadPolicyLine(strings.TrimSpace(line), m); err != nil {
+			return fmt.Errorf("failed to read seed policy: %w", err)
+		}
+	}
+	if err := adapter.SavePolicy(m); err != nil {
+		return fmt.Errorf("failed to seed Casbin policies: %w", err)
+	}
+	return nil
+}
+
+// StoresPoliciesInDatabase reports whether enforcer keeps its policies in the
+// database rather than a policy file
+func StoresPoliciesInDatabase(enforcer *casbin.Enforcer) bool {
+	if enforcer == nil {
+		return false
+	}
+	_, ok := enforcer.GetAdapter().(*persistence.CasbinRuleAdapter)
+	return ok
+}
+
+// savesIncrementally reports whether the adapter of enforcer already stored
+// each change as it was made, so a transaction has nothing left to save
+func savesIncrementally(enforcer *casbin.Enforcer) bool {
+	return StoresPoliciesInDatabase(enforcer) && config.CasbinAutoSave()
+}
+
+// ReplacePolicies makes rules the policy rules of enforcer, removing every
+// other policy rule. Role assignments are kept.
+func ReplacePolicies(enforcer *casbin.Enforcer, rules [][]string) error {
+	_, err := ApplyPolicyChange(enforcer, func(tx *PolicyTransaction) (bool, error) {
+		wanted := make(map[string]bool, len(rules))
+		for _, rule := range rules {
+			wanted[strings.Join(rule, ",")] = true
+		}
+
+		current, err := tx.Enforcer().GetPolicy()
+		if err != nil {
+			return false, fmt.Errorf("failed to get policies: %w", err)
+		}
+		changed := false
+		for _, rule := range current {
+			key := strings.Join(rule, ",")
+			if wanted[key] {
+				delete(wanted, key)
+				continue
+			}
+			removed, err := tx.RemovePolicy(rule)
+			if err != nil {
+				return false, err
+			}
+			changed = changed || removed
+		}
+		for _, rule := range rules {
+			if !wanted[strings.Join(rule, ",")] {
+				continue
+			}
+			added, err := tx.AddPolicy(rule)
+			if err != nil {
+				return false, err
+			}
+			changed = changed || added
+		}
+		return changed, nil
+	})
+	return err
+}
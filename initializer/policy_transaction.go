@@ -0,0 +1,334 @@
+package initializer
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/aruncs31s/azf/config"
+	"github.com/casbin/casbin/v2"
+)
+
+// ErrPolicyNotSaved is returned when policy changes could not be written to
+// the policy storage. The changes are rolled back, so the enforcer keeps
+// matching the storage.
+var ErrPolicyNotSaved = errors.New("policy changes could not be saved")
+
+// policyLocks holds the lock guarding the policies of each enforcer. The
+// enforcer itself is not safe for concurrent modification.
+var policyLocks sync.Map
+
+// policyLock returns the lock guarding the policies of enforcer
+func policyLock(enforcer *casbin.Enforcer) *sync.RWMutex {
+	lock, _ := policyLocks.LoadOrStore(enforcer, &sync.RWMutex{})
+	return lock.(*sync.RWMutex)
+}
+
+// Enforce evaluates a request against enforcer while no policy transaction is
+// changing it
+func Enforce(enforcer *casbin.Enforcer, rvals ...interface{}) (bool, error) {
+	if enforcer == nil {
+		return false, fmt.Errorf("casbin enforcer not available")
+	}
+	lock := policyLock(enforcer)
+	lock.RLock()
+	defer lock.RUnlock()
+	return enforcer.Enforce(rvals...)
+}
+
+// ReadPolicies runs fn, which reads the policies of enforcer, while no policy
+// transaction is changing them
+func ReadPolicies(enforcer *casbin.Enforcer, fn func() error) error {
+	if enforcer == nil {
+		return fmt.Errorf("casbin enforcer not available")
+	}
+	lock := policyLock(enforcer)
+	lock.RLock()
+	defer lock.RUnlock()
+	return fn()
+}
+
+// SavePolicies writes the current policies of enforcer to its storage, for
+// use when auto-save is disabled
+func SavePolicies(enforcer *casbin.Enforcer) error {
+	if enforcer == nil {
+		return fmt.Errorf("casbin enforcer not available")
+	}
+	if enforcer.GetAdapter() == nil {
+		return fmt.Errorf("casbin enforcer has no policy storage")
+	}
+	lock := policyLock(enforcer)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if err := enforcer.SavePolicy(); err != nil {
+		return fmt.Errorf("%w: %w", ErrPolicyNotSaved, err)
+	}
+	return nil
+}
+
+// ReloadPolicies runs rewrite, which changes the policy storage directly,
+// and reloads enforcer from the storage afterwards. No policy transaction
+// runs in between, so none of their saves can overwrite the rewrite.
+func ReloadPolicies(enforcer *casbin.Enforcer, rewrite func() error) error {
+	if enforcer == nil {
+		return rewrite()
+	}
+	lock := policyLock(enforcer)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if err := rewrite(); err != nil {
+		return err
+	}
+	if err := enforcer.LoadPolicy(); err != nil {
+		return fmt.Errorf("failed to reload policies: %w", err)
+	}
+	notifyPolicyWatcher(enforcer)
+	return nil
+}
+
+// PolicyTransaction applies Casbin policy changes and remembers how to undo
+// them, so a multi-step operation that fails part-way can be reverted
+type PolicyTransaction struct {
+	enforcer *casbin.Enforcer
+	undo     []func() error
+	// changed is set while there are changes that have not been saved
+	changed bool
+	// saved is set once changes of the transaction reached the storage
+	saved  bool
+	locked bool
+	done   bool
+}
+
+// WithPolicyTransaction runs fn in a policy transaction. The changes fn made
+// are undone when it returns an error. When it succeeds they are saved to the
+// policy storage if auto-save is enabled (see config.CasbinAutoSave); a failed
+// save rolls them back and returns ErrPolicyNotSaved. When enforcer is nil, fn
+// still runs but every policy change fails.
+//
+// The transaction holds the enforcer's policy lock from its first use until
+// it ends, so transactions on one enforcer are serialized and requests are
+// not enforced against half-applied changes. Transactions do not nest: fn
+// must not start another one or call Enforce or ReadPolicies.
+func WithPolicyTransaction(enforcer *casbin.Enforcer, fn func(tx *PolicyTransaction) error) error {
+	tx := &PolicyTransaction{enforcer: enforcer}
+	defer tx.release()
+
+	err := fn(tx)
+	if err == nil {
+		err = tx.Save()
+	}
+	if err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return errors.Join(err, rollbackErr)
+		}
+		return err
+	}
+	tx.Commit()
+	return nil
+}
+
+// ApplyPolicyChange runs a single policy change in its own transaction and
+// reports whether it changed anything
+func ApplyPolicyChange(enforcer *casbin.Enforcer, change func(tx *PolicyTransaction) (bool, error)) (bool, error) {
+	var changed bool
+	err := WithPolicyTransaction(enforcer, func(tx *PolicyTransaction) error {
+		var err error
+		changed, err = change(tx)
+		return err
+	})
+	return changed, err
+}
+
+// Enforcer returns the enforcer the transaction changes, for reads. It is
+// nil when Casbin is not initialized.
+func (tx *PolicyTransaction) Enforcer() *casbin.Enforcer {
+	tx.acquire()
+	return tx.enforcer
+}
+
+// AddPolicy adds a policy rule
+func (tx *PolicyTransaction) AddPolicy(rule []string) (bool, error) {
+	if err := tx.checkActive(); err != nil {
+		return false, err
+	}
+	added, err := tx.enforcer.AddPolicy(rule)
+	if err != nil {
+		return false, fmt.Errorf("failed to add policy %v: %w", rule, err)
+	}
+	if added {
+		tx.record(func() error { _, err := tx.enforcer.RemovePolicy(rule); return err })
+	}
+	return added, nil
+}
+
+// RemovePolicy removes a policy rule
+func (tx *PolicyTransaction) RemovePolicy(rule []string) (bool, error) {
+	if err := tx.checkActive(); err != nil {
+		return false, err
+	}
+	removed, err := tx.enforcer.RemovePolicy(rule)
+	if err != nil {
+		return false, fmt.Errorf("failed to remove policy %v: %w", rule, err)
+	}
+	if removed {
+		tx.record(func() error { _, err := tx.enforcer.AddPolicy(rule); return err })
+	}
+	return removed, nil
+}
+
+// AddGroupingPolicy adds a role assignment
+func (tx *PolicyTransaction) AddGroupingPolicy(rule []string) (bool, error) {
+	if err := tx.checkActive(); err != nil {
+		return false, err
+	}
+	added, err := tx.enforcer.AddGroupingPolicy(rule)
+	if err != nil {
+		return false, fmt.Errorf("failed to add grouping policy %v: %w", rule, err)
+	}
+	if added {
+		tx.record(func() error { _, err := tx.enforcer.RemoveGroupingPolicy(rule); return err })
+	}
+	return added, nil
+}
+
+// RemoveGroupingPolicy removes a role assignment
+func (tx *PolicyTransaction) RemoveGroupingPolicy(rule []string) (bool, error) {
+	if err := tx.checkActive(); err != nil {
+		return false, err
+	}
+	removed, err := tx.enforcer.RemoveGroupingPolicy(rule)
+	if err != nil {
+		return false, fmt.Errorf("failed to remove grouping policy %v: %w", rule, err)
+	}
+	if removed {
+		tx.record(func() error { _, err := tx.enforcer.AddGroupingPolicy(rule); return err })
+	}
+	return removed, nil
+}
+
+// RemoveFilteredPolicy removes the policy rules matching the field filter
+func (tx *PolicyTransaction) RemoveFilteredPolicy(fieldIndex int, fieldValues ...string) (int, error) {
+	if err := tx.checkActive(); err != nil {
+		return 0, err
+	}
+	rules, err := tx.enforcer.GetFilteredPolicy(fieldIndex, fieldValues...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get policies: %w", err)
+	}
+	for _, rule := range rules {
+		if _, err := tx.RemovePolicy(rule); err != nil {
+			return 0, err
+		}
+	}
+	return len(rules), nil
+}
+
+// RemoveFilteredGroupingPolicy removes the role assignments matching the field filter
+func (tx *PolicyTransaction) RemoveFilteredGroupingPolicy(fieldIndex int, fieldValues ...string) (int, error) {
+	if err := tx.checkActive(); err != nil {
+		return 0, err
+	}
+	rules, err := tx.enforcer.GetFilteredGroupingPolicy(fieldIndex, fieldValues...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get grouping policies: %w", err)
+	}
+	for _, rule := range rules {
+		if _, err := tx.RemoveGroupingPolicy(rule); err != nil {
+			return 0, err
+		}
+	}
+	return len(rules), nil
+}
+
+// Save writes the changes made so far to the policy storage when auto-save
+// is enabled. Operations that also commit elsewhere, such as a database
+// transaction, call it before that commit so a failed save can still abort
+// it; WithPolicyTransaction saves any remaining changes itself.
+func (tx *PolicyTransaction) Save() error {
+	if !tx.changed || tx.enforcer == nil || tx.enforcer.GetAdapter() == nil || !config.CasbinAutoSave() {
+		return nil
+	}
+	if savesIncrementally(tx.enforcer) {
+		tx.changed = false
+		tx.saved = true
+		notifyPolicyWatcher(tx.enforcer)
+		return nil
+	}
+	if err := tx.enforcer.SavePolicy(); err != nil {
+		return fmt.Errorf("%w: %w", ErrPolicyNotSaved, err)
+	}
+	tx.changed = false
+	tx.saved = true
+	return nil
+}
+
+// Commit keeps the changes made so far
+func (tx *PolicyTransaction) Commit() {
+	tx.undo = nil
+	tx.changed = false
+	tx.done = true
+}
+
+// Rollback undoes the changes made so far, most recent first. Changes that
+// were already saved are saved again in their undone state.
+func (tx *PolicyTransaction) Rollback() error {
+	if tx.done {
+		return nil
+	}
+	tx.done = true
+
+	var errs []error
+	for i := len(tx.undo) - 1; i >= 0; i-- {
+		if err := tx.undo[i](); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	tx.undo = nil
+	if tx.saved {
+		if savesIncrementally(tx.enforcer) {
+			notifyPolicyWatcher(tx.enforcer)
+		} else if err := tx.enforcer.SavePolicy(); err != nil {
+			errs = append(errs, fmt.Errorf("%w: %w", ErrPolicyNotSaved, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to roll back policy changes: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+func (tx *PolicyTransaction) record(undo func() error) {
+	tx.undo = append(tx.undo, undo)
+	tx.changed = true
+}
+
+func (tx *PolicyTransaction) checkActive() error {
+	if tx.enforcer == nil {
+		return fmt.Errorf("casbin enforcer not available")
+	}
+	if tx.done {
+		return fmt.Errorf("policy transaction is already finished")
+	}
+	tx.acquire()
+	return nil
+}
+
+// acquire takes the policy lock on the first use of the transaction
+func (tx *PolicyTransaction) acquire() {
+	if tx.locked || tx.done || tx.enforcer == nil {
+		return
+	}
+	policyLock(tx.enforcer).Lock()
+	tx.locked = true
+}
+
+// release gives up the policy lock taken by acquire
+func (tx *PolicyTransaction) release() {
+	if !tx.locked {
+		return
+	}
+	policyLock(tx.enforcer).Unlock()
+	tx.locked = false
+}
@@ -0,0 +1,61 @@
+package initializer
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/aruncs31s/azf/shared/logger"
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/persist"
+	"go.uber.org/zap"
+)
+
+// policyWatchers holds the watcher of each enforcer that has one
+var policyWatchers sync.Map
+
+// WatchPolicies makes enforcer share policy changes through watcher. Other
+// instances are notified once changes are saved, not while a transaction is
+// still applying them, and notifications from other instances reload
+// enforcer from its storage.
+func WatchPolicies(enforcer *casbin.Enforcer, watcher persist.Watcher) error {
+	if enforcer == nil {
+		return fmt.Errorf("casbin enforcer not available")
+	}
+	if err := enforcer.SetWatcher(watcher); err != nil {
+		return fmt.Errorf("failed to set policy watcher: %w", err)
+	}
+	// Transactions notify after saving; per-change notifications would make
+	// other instances reload half-applied or unsaved changes
+	enforcer.EnableAutoNotifyWatcher(false)
+	if err := watcher.SetUpdateCallback(func(string) { reloadFromStorage(enforcer) }); err != nil {
+		return fmt.Errorf("failed to set policy watcher callback: %w", err)
+	}
+	policyWatchers.Store(enforcer, watcher)
+	return nil
+}
+
+// reloadFromStorage reloads enforcer after another instance changed the
+// policies, once no transaction is running
+func reloadFromStorage(enforcer *casbin.Enforcer) {
+	lock := policyLock(enforcer)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if err := enforcer.LoadPolicy(); err != nil {
+		logger.Error("Failed to reload policies changed by another instance", zap.Error(err))
+		return
+	}
+	logger.Info("Reloaded policies changed by another instance")
+}
+
+// notifyPolicyWatcher tells other instances that the stored policies of
+// enforcer changed. Saves through Enforcer.SavePolicy notify by themselves.
+func notifyPolicyWatcher(enforcer *casbin.Enforcer) {
+	watcher, ok := policyWatchers.Load(enforcer)
+	if !ok {
+		return
+	}
+	if err := watcher.(persist.Watcher).Update(); err != nil {
+		logger.Warn("Failed to notify other instances of policy changes", zap.Error(err))
+	}
+}
@@ -0,0 +1,103 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// Thrift compact protocol field types
+const (
+	thriftBoolTrue  = 1
+	thriftBoolFalse = 2
+	thriftI32       = 5
+	thriftI64       = 6
+	thriftBinary    = 8
+	thriftList      = 9
+	thriftStruct    = 12
+)
+
+// thriftWriter encodes the Parquet metadata structs with the Thrift compact
+// protocol; only the types the file metadata needs are supported
+type thriftWriter struct {
+	buf       bytes.Buffer
+	lastField []int16
+}
+
+func (t *thriftWriter) structBegin() {
+	t.lastField = append(t.lastField, 0)
+}
+
+func (t *thriftWriter) structEnd() {
+	t.buf.WriteByte(0)
+	t.lastField = t.lastField[:len(t.lastField)-1]
+}
+
+func (t *thriftWriter) fieldHeader(id int16, fieldType byte) {
+	last := &t.lastField[len(t.lastField)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | fieldType)
+	} else {
+		t.buf.WriteByte(fieldType)
+		t.varint(zigzag(int64(id)))
+	}
+	*last = id
+}
+
+func (t *thriftWriter) i32Field(id int16, value int32) {
+	t.fieldHeader(id, thriftI32)
+	t.varint(zigzag(int64(value)))
+}
+
+func (t *thriftWriter) i64Field(id int16, value int64) {
+	t.fieldHeader(id, thriftI64)
+	t.varint(zigzag(value))
+}
+
+func (t *thriftWriter) stringField(id int16, value string) {
+	t.fieldHeader(id, thriftBinary)
+	t.binary(value)
+}
+
+func (t *thriftWriter) boolField(id int16, value bool) {
+	if value {
+		t.fieldHeader(id, thriftBoolTrue)
+	} else {
+		t.fieldHeader(id, thriftBoolFalse)
+	}
+}
+
+// structField starts a nested struct field; end it with structEnd
+func (t *thriftWriter) structField(id int16) {
+	t.fieldHeader(id, thriftStruct)
+	t.structBegin()
+}
+
+// listField starts a list field of size elements of elemType
+func (t *thriftWriter) listField(id int16, elemType byte, size int) {
+	t.fieldHeader(id, thriftList)
+	if size < 15 {
+		t.buf.WriteByte(byte(size)<<4 | elemType)
+		return
+	}
+	t.buf.WriteByte(0xF0 | elemType)
+	t.varint(uint64(size))
+}
+
+func (t *thriftWriter) i32Elem(value int32) {
+	t.varint(zigzag(int64(value)))
+}
+
+func (t *thriftWriter) binary(value string) {
+	t.varint(uint64(len(value)))
+	t.buf.WriteString(value)
+}
+
+func (t *thriftWriter) varint(value uint64) {
+	var scratch [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(scratch[:], value)
+	t.buf.Write(scratch[:n])
+}
+
+func zigzag(value int64) uint64 {
+	return uint64((value << 1) ^ (value >> 63))
+}
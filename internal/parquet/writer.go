@@ -0,0 +1,395 @@
+// Package parquet writes flat, uncompressed Parquet files row group by row
+// group, so large tables can be streamed with bounded memory
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// ColumnType is the logical type of a column
+type ColumnType int
+
+const (
+	String ColumnType = iota
+	Int64
+	Double
+	Boolean
+	// Timestamp columns hold time.Time values stored as UTC milliseconds
+	Timestamp
+)
+
+// Column describes a column; every column is optional and accepts nil
+type Column struct {
+	Name string
+	Type ColumnType
+}
+
+// DefaultRowGroupSize is the number of rows buffered before a row group is
+// written when Flush is not called earlier
+const DefaultRowGroupSize = 10000
+
+// Parquet physical types, converted types and encodings used by the writer
+const (
+	physicalBoolean   = 0
+	physicalInt64     = 2
+	physicalDouble    = 5
+	physicalByteArray = 6
+
+	convertedUTF8            = 0
+	convertedTimestampMillis = 9
+
+	repetitionOptional = 1
+	encodingPlain      = 0
+	encodingRLE        = 3
+	pageTypeData       = 0
+	codecUncompressed  = 0
+)
+
+var magic = []byte("PAR1")
+
+// Writer writes rows to a Parquet file. Rows are buffered and written as a
+// row group on Flush or every DefaultRowGroupSize rows; Close writes the
+// file footer and must be called for the file to be readable.
+type Writer struct {
+	out       io.Writer
+	columns   []Column
+	offset    int64
+	started   bool
+	closed    bool
+	numRows   int64
+	rowGroups []rowGroup
+	buffered  []columnBuffer
+	rows      int
+}
+
+// columnBuffer holds the values of a column for the current row group
+type columnBuffer struct {
+	present []bool
+	values  bytes.Buffer
+	bools   []bool
+}
+
+// rowGroup records where a written row group's column chunks are
+type rowGroup struct {
+	numRows   int64
+	totalSize int64
+	chunks    []columnChunk
+}
+
+type columnChunk struct {
+	offset    int64
+	size      int64
+	numValues int64
+}
+
+// NewWriter creates a writer for a file with columns
+func NewWriter(out io.Writer, columns []Column) *Writer {
+	return &Writer{
+		out:      out,
+		columns:  columns,
+		buffered: make([]columnBuffer, len(columns)),
+	}
+}
+
+// Write buffers a row; values must match the column types, and nil is null
+func (w *Writer) Write(row []any) error {
+	if w.closed {
+		return fmt.Errorf("parquet writer is closed")
+	}
+	if len(row) != len(w.columns) {
+		return fmt.Errorf("row has %d values, expected %d", len(row), len(w.columns))
+	}
+	for i, value := range row {
+		if err := w.buffered[i].append(w.columns[i], value); err != nil {
+			return err
+		}
+	}
+	w.rows++
+	if w.rows >= DefaultRowGroupSize {
+		return w.Flush()
+	}
+	return nil
+}
+
+// Flush writes the buffered rows as a row group
+func (w *Writer) Flush() error {
+	if w.rows == 0 {
+		return nil
+	}
+	if err := w.start(); err != nil {
+		return err
+	}
+
+	group := rowGroup{numRows: int64(w.rows)}
+	for i, column := range w.columns {
+		chunk, err := w.writeColumnChunk(column, &w.buffered[i])
+		if err != nil {
+			return err
+		}
+		group.totalSize += chunk.size
+		group.chunks = append(group.chunks, chunk)
+		w.buffered[i] = columnBuffer{}
+	}
+	w.rowGroups = append(w.rowGroups, group)
+	w.numRows += int64(w.rows)
+	w.rows = 0
+	return nil
+}
+
+// Close writes the remaining rows and the file footer
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if err := w.start(); err != nil {
+		return err
+	}
+	w.closed = true
+
+	footer := w.fileMetadata()
+	if err := w.write(footer); err != nil {
+		return err
+	}
+	var length [4]byte
+	binary.LittleEndian.PutUint32(length[:], uint32(len(footer)))
+	if err := w.write(length[:]); err != nil {
+		return err
+	}
+	return w.write(magic)
+}
+
+// start writes the leading magic bytes once
+func (w *Writer) start() error {
+	if w.started {
+		return nil
+	}
+	w.started = true
+	return w.write(magic)
+}
+
+func (w *Writer) write(data []byte) error {
+	n, err := w.out.Write(data)
+	w.offset += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write parquet data: %w", err)
+	}
+	return nil
+}
+
+// writeColumnChunk writes the buffered values of a column as a single data page
+func (w *Writer) writeColumnChunk(column Column, buffer *columnBuffer) (columnChunk, error) {
+	var page bytes.Buffer
+	levels := encodeDefinitionLevels(buffer.present)
+	var length [4]byte
+	binary.LittleEndian.PutUint32(length[:], uint32(len(levels)))
+	page.Write(length[:])
+	page.Write(levels)
+	if column.Type == Boolean {
+		page.Write(packBools(buffer.bools))
+	} else {
+		page.Write(buffer.values.Bytes())
+	}
+
+	header := &thriftWriter{}
+	header.structBegin()
+	header.i32Field(1, pageTypeData)
+	header.i32Field(2, int32(page.Len()))
+	header.i32Field(3, int32(page.Len()))
+	header.structField(5)
+	header.i32Field(1, int32(len(buffer.present)))
+	header.i32Field(2, encodingPlain)
+	header.i32Field(3, encodingRLE)
+	header.i32Field(4, encodingRLE)
+	header.structEnd()
+	header.structEnd()
+
+	chunk := columnChunk{
+		offset:    w.offset,
+		size:      int64(header.buf.Len() + page.Len()),
+		numValues: int64(len(buffer.present)),
+	}
+	if err := w.write(header.buf.Bytes()); err != nil {
+		return chunk, err
+	}
+	if err := w.write(page.Bytes()); err != nil {
+		return chunk, err
+	}
+	return chunk, nil
+}
+
+// fileMetadata encodes the FileMetaData footer
+func (w *Writer) fileMetadata() []byte {
+	t := &thriftWriter{}
+	t.structBegin()
+	t.i32Field(1, 1)
+
+	t.listField(2, thriftStruct, len(w.columns)+1)
+	t.structBegin()
+	t.stringField(4, "schema")
+	t.i32Field(5, int32(len(w.columns)))
+	t.structEnd()
+	for _, column := range w.columns {
+		t.structBegin()
+		t.i32Field(1, physicalType(column.Type))
+		t.i32Field(3, repetitionOptional)
+		t.stringField(4, column.Name)
+		if converted, ok := convertedType(column.Type); ok {
+			t.i32Field(6, converted)
+		}
+		t.structEnd()
+	}
+
+	t.i64Field(3, w.numRows)
+
+	t.listField(4, thriftStruct, len(w.rowGroups))
+	for _, group := range w.rowGroups {
+		t.structBegin()
+		t.listField(1, thriftStruct, len(group.chunks))
+		for i, chunk := range group.chunks {
+			column := w.columns[i]
+			t.structBegin()
+			t.i64Field(2, chunk.offset)
+			t.structField(3)
+			t.i32Field(1, physicalType(column.Type))
+			t.listField(2, thriftI32, 2)
+			t.i32Elem(encodingPlain)
+			t.i32Elem(encodingRLE)
+			t.listField(3, thriftBinary, 1)
+			t.binary(column.Name)
+			t.i32Field(4, codecUncompressed)
+			t.i64Field(5, chunk.numValues)
+			t.i64Field(6, chunk.size)
+			t.i64Field(7, chunk.size)
+			t.i64Field(9, chunk.offset)
+			t.structEnd()
+			t.structEnd()
+		}
+		t.i64Field(2, group.totalSize)
+		t.i64Field(3, group.numRows)
+		t.structEnd()
+	}
+
+	t.stringField(6, "azf parquet writer")
+	t.structEnd()
+	return t.buf.Bytes()
+}
+
+// append adds a value of column to the buffer
+func (b *columnBuffer) append(column Column, value any) error {
+	if value == nil {
+		b.present = append(b.present, false)
+		return nil
+	}
+
+	var scratch [8]byte
+	switch column.Type {
+	case String:
+		s, ok := value.(string)
+		if !ok {
+			return columnTypeError(column, value)
+		}
+		binary.LittleEndian.PutUint32(scratch[:4], uint32(len(s)))
+		b.values.Write(scratch[:4])
+		b.values.WriteString(s)
+	case Int64:
+		n, ok := value.(int64)
+		if !ok {
+			return columnTypeError(column, value)
+		}
+		binary.LittleEndian.PutUint64(scratch[:], uint64(n))
+		b.values.Write(scratch[:])
+	case Double:
+		f, ok := value.(float64)
+		if !ok {
+			return columnTypeError(column, value)
+		}
+		binary.LittleEndian.PutUint64(scratch[:], math.Float64bits(f))
+		b.values.Write(scratch[:])
+	case Boolean:
+		v, ok := value.(bool)
+		if !ok {
+			return columnTypeError(column, value)
+		}
+		b.bools = append(b.bools, v)
+	case Timestamp:
+		t, ok := value.(time.Time)
+		if !ok {
+			return columnTypeError(column, value)
+		}
+		binary.LittleEndian.PutUint64(scratch[:], uint64(t.UnixMilli()))
+		b.values.Write(scratch[:])
+	default:
+		return fmt.Errorf("unsupported type for column %s", column.Name)
+	}
+	b.present = append(b.present, true)
+	return nil
+}
+
+func columnTypeError(column Column, value any) error {
+	return fmt.Errorf("invalid value of type %T for column %s", value, column.Name)
+}
+
+func physicalType(columnType ColumnType) int32 {
+	switch columnType {
+	case Int64, Timestamp:
+		return physicalInt64
+	case Double:
+		return physicalDouble
+	case Boolean:
+		return physicalBoolean
+	default:
+		return physicalByteArray
+	}
+}
+
+func convertedType(columnType ColumnType) (int32, bool) {
+	switch columnType {
+	case String:
+		return convertedUTF8, true
+	case Timestamp:
+		return convertedTimestampMillis, true
+	default:
+		return 0, false
+	}
+}
+
+// encodeDefinitionLevels encodes the levels of an optional column (1 when
+// the value is present) as RLE runs with a bit width of 1
+func encodeDefinitionLevels(present []bool) []byte {
+	var out []byte
+	var scratch [binary.MaxVarintLen64]byte
+	for start := 0; start < len(present); {
+		end := start
+		for end < len(present) && present[end] == present[start] {
+			end++
+		}
+		n := binary.PutUvarint(scratch[:], uint64(end-start)<<1)
+		out = append(out, scratch[:n]...)
+		if present[start] {
+			out = append(out, 1)
+		} else {
+			out = append(out, 0)
+		}
+		start = end
+	}
+	return out
+}
+
+// packBools PLAIN-encodes booleans, least significant bit first
+func packBools(values []bool) []byte {
+	out := make([]byte, (len(values)+7)/8)
+	for i, v := range values {
+		if v {
+			out[i/8] |= 1 << (i % 8)
+		}
+	}
+	return out
+}
@@ -0,0 +1,136 @@
+package persistence
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	identity_access "github.com/aruncs31s/azf/domain/identity_access/model"
+	"github.com/redis/go-redis/v9"
+)
+
+// adminSessionKeyPrefix prefixes the Redis keys of admin sessions
+const adminSessionKeyPrefix = "azf:admin_session:"
+
+// adminSessionIndexKey is the set of stored session IDs, for listing them
+const adminSessionIndexKey = adminSessionKeyPrefix + "index"
+
+// redisAdminSessionRepository keeps each session under its ID until it
+// expires. IDs of expired sessions are pruned from the index when listing.
+type redisAdminSessionRepository struct {
+	client *redis.Client
+}
+
+// NewRedisAdminSessionRepository creates an admin session store on Redis,
+// for deployments sharing sessions between instances without a shared database
+func NewRedisAdminSessionRepository(client *redis.Client) identity_access.SessionStore {
+	return &redisAdminSessionRepository{client: client}
+}
+
+func (r *redisAdminSessionRepository) Create(ctx context.Context, session *identity_access.AdminSession) error {
+	if err := r.store(ctx, session); err != nil {
+		return fmt.Errorf("failed to store admin session: %w", err)
+	}
+	return nil
+}
+
+func (r *redisAdminSessionRepository) FindByID(ctx context.Context, id string) (*identity_access.AdminSession, error) {
+	data, err := r.client.Get(ctx, adminSessionKey(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, identity_access.ErrAdminSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find admin session: %w", err)
+	}
+	var model AdminSessionModel
+	if err := json.Unmarshal(data, &model); err != nil {
+		return nil, fmt.Errorf("failed to decode admin session: %w", err)
+	}
+	return model.toDomain(), nil
+}
+
+func (r *redisAdminSessionRepository) FindAll(ctx context.Context) ([]identity_access.AdminSession, error) {
+	ids, err := r.client.SMembers(ctx, adminSessionIndexKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list admin sessions: %w", err)
+	}
+
+	sessions := make([]identity_access.AdminSession, 0, len(ids))
+	for _, id := range ids {
+		session, err := r.FindByID(ctx, id)
+		if errors.Is(err, identity_access.ErrAdminSessionNotFound) {
+			r.client.SRem(ctx, adminSessionIndexKey, id)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *session)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+	return sessions, nil
+}
+
+func (r *redisAdminSessionRepository) Touch(ctx context.Context, id string, lastSeenAt time.Time, idleExpiresAt time.Time) error {
+	session, err := r.FindByID(ctx, id)
+	if errors.Is(err, identity_access.ErrAdminSessionNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	session.LastSeenAt = lastSeenAt
+	session.IdleExpiresAt = idleExpiresAt
+	if err := r.store(ctx, session); err != nil {
+		return fmt.Errorf("failed to update admin session: %w", err)
+	}
+	return nil
+}
+
+func (r *redisAdminSessionRepository) Delete(ctx context.Context, id string) error {
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, adminSessionKey(id))
+		pipe.SRem(ctx, adminSessionIndexKey, id)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete admin session: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired is a no-op: Redis expires each session at its earlier expiry
+func (r *redisAdminSessionRepository) DeleteExpired(ctx context.Context, now time.Time) error {
+	return nil
+}
+
+// store writes session to expire at the earlier of its idle and absolute expiry
+func (r *redisAdminSessionRepository) store(ctx context.Context, session *identity_access.AdminSession) error {
+	data, err := json.Marshal(adminSessionToModel(session))
+	if err != nil {
+		return err
+	}
+	expiresAt := session.ExpiresAt
+	if session.IdleExpiresAt.Before(expiresAt) {
+		expiresAt = session.IdleExpiresAt
+	}
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		ttl = time.Second
+	}
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, adminSessionKey(session.ID), data, ttl)
+		pipe.SAdd(ctx, adminSessionIndexKey, session.ID)
+		return nil
+	})
+	return err
+}
+
+func adminSessionKey(id string) string {
+	return adminSessionKeyPrefix + id
+}
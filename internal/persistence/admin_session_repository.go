@@ -0,0 +1,123 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	identity_access "github.com/aruncs31s/azf/domain/identity_access/model"
+	"gorm.io/gorm"
+)
+
+// AdminSessionModel is an admin dashboard session row
+type AdminSessionModel struct {
+	ID            string `gorm:"primaryKey;type:varchar(64)"`
+	Username      string `gorm:"index;type:varchar(100)"`
+	UserID        string `gorm:"type:varchar(36)"`
+	ClientIP      string `gorm:"type:varchar(45)"`
+	UserAgent     string `gorm:"type:text"`
+	CreatedAt     time.Time
+	LastSeenAt    time.Time
+	IdleExpiresAt time.Time `gorm:"index"`
+	ExpiresAt     time.Time `gorm:"index"`
+}
+
+func (AdminSessionModel) TableName() string {
+	return "admin_sessions"
+}
+
+type adminSessionRepository struct {
+	db *gorm.DB
+}
+
+// NewAdminSessionRepository creates an admin session store on db
+func NewAdminSessionRepository(db *gorm.DB) identity_access.SessionStore {
+	return &adminSessionRepository{db: db}
+}
+
+func (r *adminSessionRepository) Create(ctx context.Context, session *identity_access.AdminSession) error {
+	if err := r.db.WithContext(ctx).Create(adminSessionToModel(session)).Error; err != nil {
+		return fmt.Errorf("failed to store admin session: %w", err)
+	}
+	return nil
+}
+
+func (r *adminSessionRepository) FindByID(ctx context.Context, id string) (*identity_access.AdminSession, error) {
+	var model AdminSessionModel
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&model).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, identity_access.ErrAdminSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find admin session: %w", err)
+	}
+	return model.toDomain(), nil
+}
+
+func (r *adminSessionRepository) FindAll(ctx context.Context) ([]identity_access.AdminSession, error) {
+	var models []AdminSessionModel
+	if err := r.db.WithContext(ctx).Order("last_seen_at DESC").Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to list admin sessions: %w", err)
+	}
+	sessions := make([]identity_access.AdminSession, 0, len(models))
+	for _, model := range models {
+		sessions = append(sessions, *model.toDomain())
+	}
+	return sessions, nil
+}
+
+func (r *adminSessionRepository) Touch(ctx context.Context, id string, lastSeenAt time.Time, idleExpiresAt time.Time) error {
+	err := r.db.WithContext(ctx).Model(&AdminSessionModel{}).
+		Where("id = ?", id).
+		Updates(map[string]any{"last_seen_at": lastSeenAt, "idle_expires_at": idleExpiresAt}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update admin session: %w", err)
+	}
+	return nil
+}
+
+func (r *adminSessionRepository) Delete(ctx context.Context, id string) error {
+	if err := r.db.WithContext(ctx).Where("id = ?", id).Delete(&AdminSessionModel{}).Error; err != nil {
+		return fmt.Errorf("failed to delete admin session: %w", err)
+	}
+	return nil
+}
+
+func (r *adminSessionRepository) DeleteExpired(ctx context.Context, now time.Time) error {
+	err := r.db.WithContext(ctx).
+		Where("idle_expires_at <= ? OR expires_at <= ?", now, now).
+		Delete(&AdminSessionModel{}).Error
+	if err != nil {
+		return fmt.Errorf("failed to delete expired admin sessions: %w", err)
+	}
+	return nil
+}
+
+func adminSessionToModel(session *identity_access.AdminSession) *AdminSessionModel {
+	return &AdminSessionModel{
+		ID:            session.ID,
+		Username:      session.Username,
+		UserID:        session.UserID,
+		ClientIP:      session.ClientIP,
+		UserAgent:     session.UserAgent,
+		CreatedAt:     session.CreatedAt,
+		LastSeenAt:    session.LastSeenAt,
+		IdleExpiresAt: session.IdleExpiresAt,
+		ExpiresAt:     session.ExpiresAt,
+	}
+}
+
+func (m *AdminSessionModel) toDomain() *identity_access.AdminSession {
+	return &identity_access.AdminSession{
+		ID:            m.ID,
+		Username:      m.Username,
+		UserID:        m.UserID,
+		ClientIP:      m.ClientIP,
+		UserAgent:     m.UserAgent,
+		CreatedAt:     m.CreatedAt,
+		LastSeenAt:    m.LastSeenAt,
+		IdleExpiresAt: m.IdleExpiresAt,
+		ExpiresAt:     m.ExpiresAt,
+	}
+}
@@ -0,0 +1,87 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	identity_access "github.com/aruncs31s/azf/domain/identity_access/model"
+	"gorm.io/gorm"
+)
+
+// AdminTwoFactorModel is the TOTP second factor of an admin. Backup code
+// hashes are stored space separated.
+type AdminTwoFactorModel struct {
+	Username         string `gorm:"primaryKey;type:varchar(100)"`
+	Secret           string `gorm:"type:varchar(64)"`
+	Enabled          bool
+	BackupCodeHashes string `gorm:"type:text"`
+	LastUsedStep     int64
+	EnabledAt        *time.Time
+	UpdatedAt        time.Time
+}
+
+func (AdminTwoFactorModel) TableName() string {
+	return "admin_two_factor"
+}
+
+type adminTwoFactorRepository struct {
+	db *gorm.DB
+}
+
+// NewAdminTwoFactorRepository creates an admin two-factor repository on db
+func NewAdminTwoFactorRepository(db *gorm.DB) identity_access.TwoFactorRepository {
+	return &adminTwoFactorRepository{db: db}
+}
+
+func (r *adminTwoFactorRepository) FindByUsername(ctx context.Context, username string) (*identity_access.AdminTwoFactor, error) {
+	var model AdminTwoFactorModel
+	err := r.db.WithContext(ctx).Where("username = ?", username).First(&model).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, identity_access.ErrTwoFactorNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find two-factor authentication: %w", err)
+	}
+	return model.toDomain(), nil
+}
+
+func (r *adminTwoFactorRepository) Save(ctx context.Context, factor *identity_access.AdminTwoFactor) error {
+	if err := r.db.WithContext(ctx).Save(adminTwoFactorToModel(factor)).Error; err != nil {
+		return fmt.Errorf("failed to store two-factor authentication: %w", err)
+	}
+	return nil
+}
+
+func (r *adminTwoFactorRepository) Delete(ctx context.Context, username string) error {
+	if err := r.db.WithContext(ctx).Where("username = ?", username).Delete(&AdminTwoFactorModel{}).Error; err != nil {
+		return fmt.Errorf("failed to delete two-factor authentication: %w", err)
+	}
+	return nil
+}
+
+func adminTwoFactorToModel(factor *identity_access.AdminTwoFactor) *AdminTwoFactorModel {
+	return &AdminTwoFactorModel{
+		Username:         factor.Username,
+		Secret:           factor.Secret,
+		Enabled:          factor.Enabled,
+		BackupCodeHashes: strings.Join(factor.BackupCodeHashes, " "),
+		LastUsedStep:     factor.LastUsedStep,
+		EnabledAt:        factor.EnabledAt,
+		UpdatedAt:        factor.UpdatedAt,
+	}
+}
+
+func (m *AdminTwoFactorModel) toDomain() *identity_access.AdminTwoFactor {
+	return &identity_access.AdminTwoFactor{
+		Username:         m.Username,
+		Secret:           m.Secret,
+		Enabled:          m.Enabled,
+		BackupCodeHashes: strings.Fields(m.BackupCodeHashes),
+		LastUsedStep:     m.LastUsedStep,
+		EnabledAt:        m.EnabledAt,
+		UpdatedAt:        m.UpdatedAt,
+	}
+}
@@ -0,0 +1,70 @@
+package persistence
+
+import (
+	"fmt"
+
+	"github.com/aruncs31s/azf/domain/api_usage"
+	"github.com/aruncs31s/azf/domain/repository"
+	"gorm.io/gorm"
+)
+
+type alertIncidentRepository struct {
+	db *gorm.DB
+}
+
+// NewAlertIncidentRepository creates a new alert incident repository
+func NewAlertIncidentRepository(db *gorm.DB) repository.AlertIncidentRepository {
+	return &alertIncidentRepository{db: db}
+}
+
+func (r *alertIncidentRepository) Save(incident *api_usage.AlertIncident) error {
+	if err := r.db.Save(incident).Error; err != nil {
+		return fmt.Errorf("failed to save alert incident: %w", err)
+	}
+	return nil
+}
+
+func (r *alertIncidentRepository) FindByID(id string) (*api_usage.AlertIncident, error) {
+	var incident api_usage.AlertIncident
+	if err := r.db.Where("id = ?", id).First(&incident).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &incident, nil
+}
+
+func (r *alertIncidentRepository) FindOpen(provider string, incidentKey string) (*api_usage.AlertIncident, error) {
+	var incident api_usage.AlertIncident
+	err := r.db.Where("provider = ? AND incident_key = ? AND status <> ?", provider, incidentKey, api_usage.IncidentResolved).
+		Order("created_at DESC").
+		First(&incident).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &incident, nil
+}
+
+func (r *alertIncidentRepository) FindAllOpen() (*[]api_usage.AlertIncident, error) {
+	var incidents []api_usage.AlertIncident
+	if err := r.db.Where("status <> ?", api_usage.IncidentResolved).Order("created_at ASC").Find(&incidents).Error; err != nil {
+		return nil, err
+	}
+	return &incidents, nil
+}
+
+func (r *alertIncidentRepository) FindRecent(status string, limit int) (*[]api_usage.AlertIncident, error) {
+	var incidents []api_usage.AlertIncident
+	query := r.db.Order("created_at DESC").Limit(limit)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Find(&incidents).Error; err != nil {
+		return nil, err
+	}
+	return &incidents, nil
+}
@@ -0,0 +1,138 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	identity_access "github.com/aruncs31s/azf/domain/identity_access/model"
+	"gorm.io/gorm"
+)
+
+// APIKeyModel is an API key row. Scopes are stored space separated, as in
+// an OAuth scope parameter.
+type APIKeyModel struct {
+	ID         string `gorm:"primaryKey;type:varchar(36)"`
+	Name       string `gorm:"type:varchar(100)"`
+	Prefix     string `gorm:"type:varchar(16)"`
+	KeyHash    string `gorm:"uniqueIndex;type:varchar(64)"`
+	UserID     string `gorm:"index;type:varchar(36)"`
+	Role       string `gorm:"type:varchar(50)"`
+	Scopes     string `gorm:"type:text"`
+	RateLimit  int
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedBy  string `gorm:"type:varchar(100)"`
+	CreatedAt  time.Time
+}
+
+func (APIKeyModel) TableName() string {
+	return "api_keys"
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyRepository creates an API key repository on db
+func NewAPIKeyRepository(db *gorm.DB) identity_access.APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, key *identity_access.APIKey) error {
+	if err := r.db.WithContext(ctx).Create(apiKeyToModel(key)).Error; err != nil {
+		return fmt.Errorf("failed to store API key: %w", err)
+	}
+	return nil
+}
+
+func (r *apiKeyRepository) FindByID(ctx context.Context, id string) (*identity_access.APIKey, error) {
+	return r.find(ctx, "id = ?", id)
+}
+
+func (r *apiKeyRepository) FindByHash(ctx context.Context, hash string) (*identity_access.APIKey, error) {
+	return r.find(ctx, "key_hash = ?", hash)
+}
+
+func (r *apiKeyRepository) find(ctx context.Context, query string, arg string) (*identity_access.APIKey, error) {
+	var model APIKeyModel
+	err := r.db.WithContext(ctx).Where(query, arg).First(&model).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, identity_access.ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find API key: %w", err)
+	}
+	return model.toDomain(), nil
+}
+
+func (r *apiKeyRepository) FindAll(ctx context.Context) ([]identity_access.APIKey, error) {
+	var models []APIKeyModel
+	if err := r.db.WithContext(ctx).Order("created_at DESC").Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	keys := make([]identity_access.APIKey, 0, len(models))
+	for i := range models {
+		keys = append(keys, *models[i].toDomain())
+	}
+	return keys, nil
+}
+
+func (r *apiKeyRepository) Revoke(ctx context.Context, id string, at time.Time) error {
+	err := r.db.WithContext(ctx).Model(&APIKeyModel{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", at).Error
+	if err != nil {
+		return fmt.Errorf("failed to revoke API key: %w", err)
+	}
+	return nil
+}
+
+func (r *apiKeyRepository) Touch(ctx context.Context, id string, at time.Time) error {
+	err := r.db.WithContext(ctx).Model(&APIKeyModel{}).
+		Where("id = ?", id).
+		Update("last_used_at", at).Error
+	if err != nil {
+		return fmt.Errorf("failed to update API key last use: %w", err)
+	}
+	return nil
+}
+
+func apiKeyToModel(key *identity_access.APIKey) *APIKeyModel {
+	return &APIKeyModel{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		KeyHash:    key.KeyHash,
+		UserID:     key.UserID,
+		Role:       key.Role,
+		Scopes:     strings.Join(key.Scopes, " "),
+		RateLimit:  key.RateLimit,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+		CreatedBy:  key.CreatedBy,
+		CreatedAt:  key.CreatedAt,
+	}
+}
+
+func (m *APIKeyModel) toDomain() *identity_access.APIKey {
+	return &identity_access.APIKey{
+		ID:         m.ID,
+		Name:       m.Name,
+		Prefix:     m.Prefix,
+		KeyHash:    m.KeyHash,
+		UserID:     m.UserID,
+		Role:       m.Role,
+		Scopes:     strings.Fields(m.Scopes),
+		RateLimit:  m.RateLimit,
+		ExpiresAt:  m.ExpiresAt,
+		LastUsedAt: m.LastUsedAt,
+		RevokedAt:  m.RevokedAt,
+		CreatedBy:  m.CreatedBy,
+		CreatedAt:  m.CreatedAt,
+	}
+}
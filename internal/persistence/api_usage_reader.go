@@ -0,0 +1,383 @@
+package persistence
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aruncs31s/azf/domain/api_usage"
+	"github.com/aruncs31s/azf/domain/repository"
+	"gorm.io/gorm"
+)
+
+// === Reader Implementation ===
+
+type apiUsageLogReader struct {
+	db    *gorm.DB
+	codec *TextCodec
+
+	hourlyViewOnce sync.Once
+	hourlyView     bool
+}
+
+func newAPIUsageLogReader(db *gorm.DB, codec *TextCodec) repository.APIUsageLogReader {
+	return &apiUsageLogReader{db: db, codec: codec}
+}
+
+// decodeLog restores encoded text columns in place
+func (r *apiUsageLogReader) decodeLog(log *api_usage.APIUsageLog) {
+	log.UserAgent = r.codec.Decode(log.UserAgent)
+	log.ErrorMessage = r.codec.DecodePtr(log.ErrorMessage)
+}
+
+func (r *apiUsageLogReader) decodeLogs(logs []api_usage.APIUsageLog) {
+	for i := range logs {
+		r.decodeLog(&logs[i])
+	}
+}
+
+func (r *apiUsageLogReader) FindByID(id string) (*api_usage.APIUsageLog, error) {
+	var log api_usage.APIUsageLog
+	if err := r.db.Where("id = ?", id).First(&log).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	r.decodeLog(&log)
+	return &log, nil
+}
+
+func (r *apiUsageLogReader) FindAll(limit int, offset int) (*[]api_usage.APIUsageLog, error) {
+	var logs []api_usage.APIUsageLog
+	if err := r.db.Order("requested_at DESC").Limit(limit).Offset(offset).Find(&logs).Error; err != nil {
+		return nil, err
+	}
+	r.decodeLogs(logs)
+	return &logs, nil
+}
+
+func (r *apiUsageLogReader) FindByEndpoint(endpoint string, limit int, offset int) (*[]api_usage.APIUsageLog, error) {
+	var logs []api_usage.APIUsageLog
+	if err := r.db.Where("endpoint = ?", endpoint).Order("requested_at DESC").Limit(limit).Offset(offset).Find(&logs).Error; err != nil {
+		return nil, err
+	}
+	r.decodeLogs(logs)
+	return &logs, nil
+}
+
+// errorStatusCondition matches failed requests: non-2xx responses other than 304
+const errorStatusCondition = "(status_code < 200 OR status_code >= 300) AND status_code <> 304"
+
+func (r *apiUsageLogReader) FindErrorsByEndpoint(endpoint string, limit int) (*[]api_usage.APIUsageLog, error) {
+	var logs []api_usage.APIUsageLog
+	if err := r.db.Where("endpoint = ?", endpoint).Where(errorStatusCondition).Order("requested_at DESC").Limit(limit).Find(&logs).Error; err != nil {
+		return nil, err
+	}
+	r.decodeLogs(logs)
+	return &logs, nil
+}
+
+func (r *apiUsageLogReader) FindByUserID(userID string, limit int, offset int) (*[]api_usage.APIUsageLog, error) {
+	var logs []api_usage.APIUsageLog
+	if err := r.db.Where("user_id = ?", userID).Order("requested_at DESC").Limit(limit).Offset(offset).Find(&logs).Error; err != nil {
+		return nil, err
+	}
+	r.decodeLogs(logs)
+	return &logs, nil
+}
+
+func (r *apiUsageLogReader) FindByDateRange(startDate string, endDate string, limit int, offset int) (*[]api_usage.APIUsageLog, error) {
+	var logs []api_usage.APIUsageLog
+	if err := r.db.Where("requested_at BETWEEN ? AND ?", startDate, endDate).Order("requested_at DESC").Limit(limit).Offset(offset).Find(&logs).Error; err != nil {
+		return nil, err
+	}
+	r.decodeLogs(logs)
+	return &logs, nil
+}
+
+func (r *apiUsageLogReader) CountByEndpoint(endpoint string) (int64, error) {
+	var count int64
+	if err := r.db.Model(&api_usage.APIUsageLog{}).Where("endpoint = ?", endpoint).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *apiUsageLogReader) CountByStatusCode(endpoint string) (*[]api_usage.APIUsageStatusCount, error) {
+	var counts []api_usage.APIUsageStatusCount
+	if err := r.db.Model(&api_usage.APIUsageLog{}).
+		Select("status_code, COUNT(*) AS requests, AVG(response_time) AS avg_response_time").
+		Where("endpoint = ?", endpoint).
+		Group("status_code").
+		Order("requests DESC, status_code ASC").
+		Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	return &counts, nil
+}
+
+func (r *apiUsageLogReader) CountTotal() (int64, error) {
+	var count int64
+	if err := r.db.Model(&api_usage.APIUsageLog{}).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *apiUsageLogReader) CountSince(since time.Time) (int64, error) {
+	var count int64
+	if err := r.db.Model(&api_usage.APIUsageLog{}).Where("requested_at >= ?", since).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *apiUsageLogReader) FindTimingsSince(since time.Time) (*[]api_usage.APIUsageTiming, error) {
+	var timings []api_usage.APIUsageTiming
+	if err := r.db.Model(&api_usage.APIUsageLog{}).
+		Select("requested_at, response_time").
+		Where("requested_at >= ?", since).
+		Scan(&timings).Error; err != nil {
+		return nil, err
+	}
+	return &timings, nil
+}
+
+// AggregateHourly returns request counts and total latency per hour in
+// [from, to). On Timescale it reads the hourly continuous aggregate; elsewhere
+// it buckets the raw logs.
+func (r *apiUsageLogReader) AggregateHourly(from time.Time, to time.Time) (*[]api_usage.APIUsageHourlyBucket, error) {
+	r.hourlyViewOnce.Do(func() {
+		r.hourlyView = HasContinuousAggregate(r.db, UsageHourlyAggregateView)
+	})
+
+	var buckets []api_usage.APIUsageHourlyBucket
+	if r.hourlyView {
+		if err := r.db.Table(UsageHourlyAggregateView).
+			Select("bucket, request_count, success_count, COALESCE(total_response_time, 0) AS total_response_time").
+			Where("bucket >= ? AND bucket < ?", from, to).
+			Order("bucket").
+			Scan(&buckets).Error; err != nil {
+			return nil, err
+		}
+		return &buckets, nil
+	}
+
+	var logs []api_usage.APIUsageLog
+	if err := r.db.Model(&api_usage.APIUsageLog{}).
+		Select("requested_at, status_code, response_time").
+		Where("requested_at >= ? AND requested_at < ?", from, to).
+		Order("requested_at").
+		Find(&logs).Error; err != nil {
+		return nil, err
+	}
+	for _, log := range logs {
+		bucket := log.RequestedAt.Truncate(time.Hour)
+		if len(buckets) == 0 || !buckets[len(buckets)-1].Bucket.Equal(bucket) {
+			buckets = append(buckets, api_usage.APIUsageHourlyBucket{Bucket: bucket})
+		}
+		current := &buckets[len(buckets)-1]
+		current.RequestCount++
+		if log.IsSuccess() {
+			current.SuccessCount++
+		}
+		current.TotalResponseTime += log.ResponseTime
+	}
+	return &buckets, nil
+}
+
+// clientAggregateSelect aggregates requests, errors (non-2xx other than 304),
+// rate-limit hits (429) and bandwidth per client
+const clientAggregateSelect = `%s AS identity,
+	COUNT(*) AS total_requests,
+	SUM(CASE WHEN (status_code < 200 OR status_code >= 300) AND status_code <> 304 THEN 1 ELSE 0 END) AS error_requests,
+	SUM(CASE WHEN status_code = 429 THEN 1 ELSE 0 END) AS rate_limited_requests,
+	AVG(response_time) AS avg_response_time,
+	COALESCE(SUM(request_size), 0) AS request_bytes,
+	COALESCE(SUM(response_size), 0) AS response_bytes`
+
+// clientDimensionColumn maps a client dimension to its usage log column
+func clientDimensionColumn(dimension string) (string, error) {
+	switch dimension {
+	case api_usage.ClientDimensionUser:
+		return "user_id", nil
+	case api_usage.ClientDimensionIP:
+		return "client_ip", nil
+	case api_usage.ClientDimensionAPIKey:
+		return "api_key_id", nil
+	default:
+		return "", fmt.Errorf("unsupported client dimension: %s", dimension)
+	}
+}
+
+// AggregateByClient returns per-client usage ordered by request count; a limit <= 0 returns all clients
+func (r *apiUsageLogReader) AggregateByClient(dimension string, from time.Time, to time.Time, limit int) (*[]api_usage.ClientUsageAggregate, error) {
+	column, err := clientDimensionColumn(dimension)
+	if err != nil {
+		return nil, err
+	}
+
+	query := r.db.Model(&api_usage.APIUsageLog{}).
+		Select(fmt.Sprintf(clientAggregateSelect, column)).
+		Where("requested_at >= ? AND requested_at < ?", from, to).
+		Where(column + " IS NOT NULL AND " + column + " <> ''").
+		Group(column).
+		Order("total_requests DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var aggregates []api_usage.ClientUsageAggregate
+	if err := query.Scan(&aggregates).Error; err != nil {
+		return nil, err
+	}
+	return &aggregates, nil
+}
+
+func (r *apiUsageLogReader) AggregateForClients(dimension string, identities []string, from time.Time, to time.Time) (*[]api_usage.ClientUsageAggregate, error) {
+	column, err := clientDimensionColumn(dimension)
+	if err != nil {
+		return nil, err
+	}
+
+	var aggregates []api_usage.ClientUsageAggregate
+	if len(identities) == 0 {
+		return &aggregates, nil
+	}
+	if err := r.db.Model(&api_usage.APIUsageLog{}).
+		Select(fmt.Sprintf(clientAggregateSelect, column)).
+		Where("requested_at >= ? AND requested_at < ?", from, to).
+		Where(column+" IN ?", identities).
+		Group(column).
+		Scan(&aggregates).Error; err != nil {
+		return nil, err
+	}
+	return &aggregates, nil
+}
+
+// === Stats Reader Implementation ===
+
+type apiUsageStatsReader struct {
+	db *gorm.DB
+}
+
+func newAPIUsageStatsReader(db *gorm.DB) repository.APIUsageStatsReader {
+	return &apiUsageStatsReader{db: db}
+}
+
+func (r *apiUsageStatsReader) FindByID(id string) (*api_usage.APIUsageStats, error) {
+	var stats api_usage.APIUsageStats
+	if err := r.db.Where("id = ?", id).First(&stats).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &stats, nil
+}
+
+func (r *apiUsageStatsReader) FindAll(limit int, offset int) (*[]api_usage.APIUsageStats, error) {
+	var stats []api_usage.APIUsageStats
+	if err := r.db.Order("total_requests DESC").Limit(limit).Offset(offset).Find(&stats).Error; err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+func (r *apiUsageStatsReader) FindByEndpoint(endpoint string) (*api_usage.APIUsageStats, error) {
+	var stats api_usage.APIUsageStats
+	if err := r.db.Where("endpoint = ?", endpoint).First(&stats).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &stats, nil
+}
+
+func (r *apiUsageStatsReader) GetTopEndpointsByUsage(limit int) (*[]api_usage.APIEndpointRanking, error) {
+	rankings, _, err := r.GetEndpointRankings(api_usage.EndpointRankingQuery{SortBy: api_usage.RankingSortRequests, Limit: limit})
+	return rankings, err
+}
+
+func (r *apiUsageStatsReader) GetEndpointsByErrorRate(limit int) (*[]api_usage.APIEndpointRanking, error) {
+	rankings, _, err := r.GetEndpointRankings(api_usage.EndpointRankingQuery{SortBy: api_usage.RankingSortErrorRate, MinRequests: 1, Limit: limit})
+	return rankings, err
+}
+
+func (r *apiUsageStatsReader) GetEndpointsByResponseTime(limit int) (*[]api_usage.APIEndpointRanking, error) {
+	rankings, _, err := r.GetEndpointRankings(api_usage.EndpointRankingQuery{SortBy: api_usage.RankingSortAvgResponseTime, Limit: limit})
+	return rankings, err
+}
+
+// rankingColumns are the stats expressions rankings are sorted by
+var rankingColumns = map[string]string{
+	api_usage.RankingSortRequests:        "total_requests",
+	api_usage.RankingSortErrorRate:       "CASE WHEN total_requests > 0 THEN CAST(error_requests AS FLOAT) / total_requests ELSE 0 END",
+	api_usage.RankingSortP95:             "p95_response_time",
+	api_usage.RankingSortAvgResponseTime: "avg_response_time",
+	api_usage.RankingSortLast24Hours:     "last24_hours",
+}
+
+// rankingSelect are the stats columns of an endpoint ranking
+const rankingSelect = "endpoint, method, total_requests, success_requests, error_requests, avg_response_time, p95_response_time, last24_hours"
+
+func (r *apiUsageStatsReader) GetEndpointRankings(query api_usage.EndpointRankingQuery) (*[]api_usage.APIEndpointRanking, int64, error) {
+	db := r.db.Model(&api_usage.APIUsageStats{})
+	if query.MinRequests > 0 {
+		db = db.Where("total_requests >= ?", query.MinRequests)
+	}
+
+	// The error rate lower bound needs a square root, which not every
+	// database has, so those rankings are sorted here
+	if query.SortBy == api_usage.RankingSortErrorRateWilson {
+		var rankings []api_usage.APIEndpointRanking
+		if err := db.Select(rankingSelect).Scan(&rankings).Error; err != nil {
+			return nil, 0, err
+		}
+		for i := range rankings {
+			rankings[i] = rankings[i].WithErrorRate()
+		}
+		page := api_usage.RankByErrorRateLowerBound(rankings, query)
+		return &page, int64(len(rankings)), nil
+	}
+
+	column, ok := rankingColumns[query.SortBy]
+	if !ok {
+		return nil, 0, fmt.Errorf("unknown ranking sort %q", query.SortBy)
+	}
+	direction := "DESC"
+	if query.Ascending {
+		direction = "ASC"
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rankings []api_usage.APIEndpointRanking
+	err := db.Select(rankingSelect).
+		Order(column + " " + direction).
+		Order("endpoint ASC, method ASC").
+		Limit(query.Limit).
+		Offset(query.Offset).
+		Scan(&rankings).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range rankings {
+		rankings[i] = rankings[i].WithErrorRate()
+		rankings[i].Rank = query.Offset + i + 1
+	}
+	return &rankings, total, nil
+}
+
+func (r *apiUsageStatsReader) CountTotal() (int64, error) {
+	var count int64
+	if err := r.db.Model(&api_usage.APIUsageStats{}).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
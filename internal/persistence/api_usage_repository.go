@@ -1,6 +1,9 @@
 package persistence
 
 import (
+	"time"
+
+	"github.com/aruncs31s/azf/config"
 	"github.com/aruncs31s/azf/domain/api_usage"
 	"github.com/aruncs31s/azf/domain/repository"
 	"gorm.io/gorm"
@@ -10,8 +13,9 @@ import (
 func NewAPIUsageRepository(
 	db *gorm.DB,
 ) repository.APIUsageLogRepository {
-	reader := newAPIUsageLogReader(db)
-	writer := newAPIUsageLogWriter(db)
+	codec := NewTextCodec(db, config.TextEncodingEnabled())
+	reader := newAPIUsageLogReader(db, codec)
+	writer := newAPIUsageLogWriter(db, codec)
 	return &apiUsageRepository{
 		reader: reader,
 		writer: writer,
@@ -38,6 +42,10 @@ func (r *apiUsageRepository) FindByEndpoint(endpoint string, limit int, offset i
 	return r.reader.FindByEndpoint(endpoint, limit, offset)
 }
 
+func (r *apiUsageRepository) FindErrorsByEndpoint(endpoint string, limit int) (*[]api_usage.APIUsageLog, error) {
+	return r.reader.FindErrorsByEndpoint(endpoint, limit)
+}
+
 func (r *apiUsageRepository) FindByUserID(userID string, limit int, offset int) (*[]api_usage.APIUsageLog, error) {
 	return r.reader.FindByUserID(userID, limit, offset)
 }
@@ -50,10 +58,34 @@ func (r *apiUsageRepository) CountByEndpoint(endpoint string) (int64, error) {
 	return r.reader.CountByEndpoint(endpoint)
 }
 
+func (r *apiUsageRepository) CountByStatusCode(endpoint string) (*[]api_usage.APIUsageStatusCount, error) {
+	return r.reader.CountByStatusCode(endpoint)
+}
+
 func (r *apiUsageRepository) CountTotal() (int64, error) {
 	return r.reader.CountTotal()
 }
 
+func (r *apiUsageRepository) CountSince(since time.Time) (int64, error) {
+	return r.reader.CountSince(since)
+}
+
+func (r *apiUsageRepository) FindTimingsSince(since time.Time) (*[]api_usage.APIUsageTiming, error) {
+	return r.reader.FindTimingsSince(since)
+}
+
+func (r *apiUsageRepository) AggregateHourly(from time.Time, to time.Time) (*[]api_usage.APIUsageHourlyBucket, error) {
+	return r.reader.AggregateHourly(from, to)
+}
+
+func (r *apiUsageRepository) AggregateByClient(dimension string, from time.Time, to time.Time, limit int) (*[]api_usage.ClientUsageAggregate, error) {
+	return r.reader.AggregateByClient(dimension, from, to, limit)
+}
+
+func (r *apiUsageRepository) AggregateForClients(dimension string, identities []string, from time.Time, to time.Time) (*[]api_usage.ClientUsageAggregate, error) {
+	return r.reader.AggregateForClients(dimension, identities, from, to)
+}
+
 // Writer operations
 func (r *apiUsageRepository) Create(log *api_usage.APIUsageLog) (*api_usage.APIUsageLog, error) {
 	return r.writer.Create(log)
@@ -2,6 +2,8 @@ package persistence
 
 import (
 	"fmt"
+	"net/http"
+	"slices"
 	"time"
 
 	"github.com/aruncs31s/azf/domain/api_usage"
@@ -14,11 +16,27 @@ import (
 // === Writer Implementation ===
 
 type apiUsageLogWriter struct {
-	db *gorm.DB
+	db    *gorm.DB
+	codec *TextCodec
+}
+
+func newAPIUsageLogWriter(db *gorm.DB, codec *TextCodec) repository.APIUsageLogWriter {
+	return &apiUsageLogWriter{db: db, codec: codec}
 }
 
-func newAPIUsageLogWriter(db *gorm.DB) repository.APIUsageLogWriter {
-	return &apiUsageLogWriter{db: db}
+// encodeLog returns a copy of log with its large text columns encoded for storage
+func (w *apiUsageLogWriter) encodeLog(log api_usage.APIUsageLog) (api_usage.APIUsageLog, error) {
+	userAgent, err := w.codec.Encode(log.UserAgent, TextEncodingDictionary)
+	if err != nil {
+		return log, err
+	}
+	errorMessage, err := w.codec.EncodePtr(log.ErrorMessage, TextEncodingCompressed)
+	if err != nil {
+		return log, err
+	}
+	log.UserAgent = userAgent
+	log.ErrorMessage = errorMessage
+	return log, nil
 }
 
 func (w *apiUsageLogWriter) Create(
@@ -30,14 +48,18 @@ func (w *apiUsageLogWriter) Create(
 	if log.CreatedAt.IsZero() {
 		log.CreatedAt = time.Now()
 	}
-	if err := w.db.Create(log).Error; err != nil {
-
+	stored, err := w.encodeLog(*log)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.db.Create(&stored).Error; err != nil {
 		return nil, err
 	}
 	return log, nil
 }
 
 func (w *apiUsageLogWriter) BatchCreate(logs *[]api_usage.APIUsageLog) error {
+	stored := make([]api_usage.APIUsageLog, 0, len(*logs))
 	for i := range *logs {
 		if (*logs)[i].ID == "" {
 			(*logs)[i].ID = uuid.New().String()
@@ -45,8 +67,13 @@ func (w *apiUsageLogWriter) BatchCreate(logs *[]api_usage.APIUsageLog) error {
 		if (*logs)[i].CreatedAt.IsZero() {
 			(*logs)[i].CreatedAt = time.Now()
 		}
+		encoded, err := w.encodeLog((*logs)[i])
+		if err != nil {
+			return err
+		}
+		stored = append(stored, encoded)
 	}
-	if err := w.db.CreateInBatches(logs, 100).Error; err != nil {
+	if err := w.db.CreateInBatches(&stored, 100).Error; err != nil {
 		return err
 	}
 	return nil
@@ -111,6 +138,10 @@ func (r *apiUsageStatsRepository) GetEndpointsByResponseTime(limit int) (*[]api_
 	return r.reader.GetEndpointsByResponseTime(limit)
 }
 
+func (r *apiUsageStatsRepository) GetEndpointRankings(query api_usage.EndpointRankingQuery) (*[]api_usage.APIEndpointRanking, int64, error) {
+	return r.reader.GetEndpointRankings(query)
+}
+
 func (r *apiUsageStatsRepository) CountTotal() (int64, error) {
 	return r.reader.CountTotal()
 }
@@ -215,12 +246,21 @@ func (w *apiUsageStatsWriter) RecalculateStats(endpoint string, method string) e
 	var maxResponseTime int64 = 0
 	var successCount int64
 	var errorCount int64
+	var totalRequestBytes int64
+	var totalResponseBytes int64
+	var maxRequestBytes int64
+	var conditionalCount int64
+	var notModifiedCount int64
+	var validatorCount int64
+	var uncachedResponseBytes int64
 	last24hCount := int64(0)
 	now := time.Now()
 	oneDayAgo := now.AddDate(0, 0, -1)
 
+	responseTimes := make([]int64, 0, len(logs))
 	for _, log := range logs {
 		totalResponseTime += log.ResponseTime
+		responseTimes = append(responseTimes, log.ResponseTime)
 		if log.ResponseTime < minResponseTime {
 			minResponseTime = log.ResponseTime
 		}
@@ -228,7 +268,26 @@ func (w *apiUsageStatsWriter) RecalculateStats(endpoint string, method string) e
 			maxResponseTime = log.ResponseTime
 		}
 
-		if log.StatusCode >= 200 && log.StatusCode < 300 {
+		totalRequestBytes += log.RequestSize
+		totalResponseBytes += log.ResponseSize
+		if log.RequestSize > maxRequestBytes {
+			maxRequestBytes = log.RequestSize
+		}
+
+		if log.Conditional {
+			conditionalCount++
+		}
+		if log.HasValidator {
+			validatorCount++
+		}
+
+		if log.StatusCode == http.StatusNotModified {
+			notModifiedCount++
+		} else if log.IsSuccess() && !log.Conditional {
+			uncachedResponseBytes += log.ResponseSize
+		}
+
+		if log.IsSuccess() {
 			successCount++
 		} else {
 			errorCount++
@@ -246,13 +305,31 @@ func (w *apiUsageStatsWriter) RecalculateStats(endpoint string, method string) e
 	stats.SuccessRequests = successCount
 	stats.ErrorRequests = errorCount
 	stats.AvgResponseTime = totalResponseTime / stats.TotalRequests
+	stats.P95ResponseTime = percentile(responseTimes, 95)
 	stats.MinResponseTime = minResponseTime
 	stats.MaxResponseTime = maxResponseTime
 	stats.Last24Hours = last24hCount
+	stats.TotalRequestBytes = totalRequestBytes
+	stats.TotalResponseBytes = totalResponseBytes
+	stats.MaxRequestBytes = maxRequestBytes
+	stats.ConditionalRequests = conditionalCount
+	stats.NotModifiedResponses = notModifiedCount
+	stats.ValidatorResponses = validatorCount
+	stats.UncachedResponseBytes = uncachedResponseBytes
 
 	return w.db.Save(&stats).Error
 }
 
+// percentile returns the nearest-rank percentile p of values, sorting them in place
+func percentile(values []int64, p int) int64 {
+	if len(values) == 0 {
+		return 0
+	}
+	slices.Sort(values)
+	rank := (len(values)*p + 99) / 100
+	return values[max(rank, 1)-1]
+}
+
 func (w *apiUsageStatsWriter) DeleteAll() error {
 	if err := w.db.Delete(&api_usage.APIUsageStats{}).Error; err != nil {
 		return fmt.Errorf("failed to delete all API usage statistics: %w", err)
@@ -0,0 +1,194 @@
+package persistence
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/casbin/casbin/v2/model"
+	"github.com/casbin/casbin/v2/persist"
+	"gorm.io/gorm"
+)
+
+// casbinRuleFields is the number of rule values a CasbinRuleModel holds
+const casbinRuleFields = 6
+
+// CasbinRuleModel is the GORM model for a Casbin policy or grouping rule. It
+// uses the table layout of the Casbin gorm-adapter, so policies stored by
+// either can be read by the other.
+type CasbinRuleModel struct {
+	ID    uint   `gorm:"primaryKey;autoIncrement"`
+	Ptype string `gorm:"index:idx_casbin_rule_ptype_v0;type:varchar(100)"`
+	V0    string `gorm:"index:idx_casbin_rule_ptype_v0;type:varchar(255)"`
+	V1    string `gorm:"type:varchar(255)"`
+	V2    string `gorm:"type:varchar(255)"`
+	V3    string `gorm:"type:varchar(255)"`
+	V4    string `gorm:"type:varchar(255)"`
+	V5    string `gorm:"type:varchar(255)"`
+}
+
+func (CasbinRuleModel) TableName() string {
+	return "casbin_rule"
+}
+
+// newCasbinRule converts a rule of ptype to its model
+func newCasbinRule(ptype string, rule []string) (CasbinRuleModel, error) {
+	if len(rule) > casbinRuleFields {
+		return CasbinRuleModel{}, fmt.Errorf("casbin rule %v has more than %d values", rule, casbinRuleFields)
+	}
+	values := make([]string, casbinRuleFields)
+	copy(values, rule)
+	return CasbinRuleModel{
+		Ptype: ptype,
+		V0:    values[0],
+		V1:    values[1],
+		V2:    values[2],
+		V3:    values[3],
+		V4:    values[4],
+		V5:    values[5],
+	}, nil
+}
+
+// rule returns the ptype followed by the rule values, without trailing empty values
+func (r CasbinRuleModel) rule() []string {
+	line := []string{r.Ptype, r.V0, r.V1, r.V2, r.V3, r.V4, r.V5}
+	for len(line) > 1 && line[len(line)-1] == "" {
+		line = line[:len(line)-1]
+	}
+	return line
+}
+
+// CasbinRuleAdapter stores Casbin policies in the database, so every replica
+// sharing it enforces the same policies and changes need no file writes
+type CasbinRuleAdapter struct {
+	db *gorm.DB
+}
+
+var (
+	_ persist.Adapter      = (*CasbinRuleAdapter)(nil)
+	_ persist.BatchAdapter = (*CasbinRuleAdapter)(nil)
+)
+
+// NewCasbinRuleAdapter creates a Casbin adapter over the casbin_rule table
+func NewCasbinRuleAdapter(db *gorm.DB) *CasbinRuleAdapter {
+	return &CasbinRuleAdapter{db: db}
+}
+
+// IsEmpty reports whether no policies are stored yet
+func (a *CasbinRuleAdapter) IsEmpty() (bool, error) {
+	var count int64
+	if err := a.db.Model(&CasbinRuleModel{}).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to count casbin rules: %w", err)
+	}
+	return count == 0, nil
+}
+
+// LoadPolicy loads every stored rule into m
+func (a *CasbinRuleAdapter) LoadPolicy(m model.Model) error {
+	var rules []CasbinRuleModel
+	if err := a.db.Order("id").Find(&rules).Error; err != nil {
+		return fmt.Errorf("failed to load casbin rules: %w", err)
+	}
+	for _, rule := range rules {
+		if err := persist.LoadPolicyArray(rule.rule(), m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SavePolicy replaces the stored rules with the rules of m
+func (a *CasbinRuleAdapter) SavePolicy(m model.Model) error {
+	var rules []CasbinRuleModel
+	for _, sec := range []string{"p", "g"} {
+		for ptype, assertion := range m[sec] {
+			for _, rule := range assertion.Policy {
+				record, err := newCasbinRule(ptype, rule)
+				if err != nil {
+					return err
+				}
+				rules = append(rules, record)
+			}
+		}
+	}
+
+	return a.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&CasbinRuleModel{}).Error; err != nil {
+			return fmt.Errorf("failed to clear casbin rules: %w", err)
+		}
+		if len(rules) == 0 {
+			return nil
+		}
+		if err := tx.CreateInBatches(rules, 100).Error; err != nil {
+			return fmt.Errorf("failed to save casbin rules: %w", err)
+		}
+		return nil
+	})
+}
+
+// AddPolicy stores a rule unless it is stored already
+func (a *CasbinRuleAdapter) AddPolicy(sec string, ptype string, rule []string) error {
+	return a.AddPolicies(sec, ptype, [][]string{rule})
+}
+
+// AddPolicies stores the rules that are not stored already
+func (a *CasbinRuleAdapter) AddPolicies(sec string, ptype string, rules [][]string) error {
+	return a.db.Transaction(func(tx *gorm.DB) error {
+		for _, rule := range rules {
+			record, err := newCasbinRule(ptype, rule)
+			if err != nil {
+				return err
+			}
+			var count int64
+			if err := tx.Model(&CasbinRuleModel{}).Where(&record, "Ptype", "V0", "V1", "V2", "V3", "V4", "V5").Count(&count).Error; err != nil {
+				return fmt.Errorf("failed to look up casbin rule: %w", err)
+			}
+			if count > 0 {
+				continue
+			}
+			if err := tx.Create(&record).Error; err != nil {
+				return fmt.Errorf("failed to add casbin rule: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// RemovePolicy deletes a stored rule
+func (a *CasbinRuleAdapter) RemovePolicy(sec string, ptype string, rule []string) error {
+	return a.RemovePolicies(sec, ptype, [][]string{rule})
+}
+
+// RemovePolicies deletes the stored rules
+func (a *CasbinRuleAdapter) RemovePolicies(sec string, ptype string, rules [][]string) error {
+	return a.db.Transaction(func(tx *gorm.DB) error {
+		for _, rule := range rules {
+			record, err := newCasbinRule(ptype, rule)
+			if err != nil {
+				return err
+			}
+			if err := tx.Where(&record, "Ptype", "V0", "V1", "V2", "V3", "V4", "V5").Delete(&CasbinRuleModel{}).Error; err != nil {
+				return fmt.Errorf("failed to remove casbin rule: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// RemoveFilteredPolicy deletes the stored rules of ptype whose values from
+// fieldIndex on match fieldValues; empty values match anything
+func (a *CasbinRuleAdapter) RemoveFilteredPolicy(sec string, ptype string, fieldIndex int, fieldValues ...string) error {
+	if fieldIndex < 0 || fieldIndex+len(fieldValues) > casbinRuleFields {
+		return fmt.Errorf("invalid casbin rule filter at field %d with %d values", fieldIndex, len(fieldValues))
+	}
+	query := a.db.Where("ptype = ?", ptype)
+	for i, value := range fieldValues {
+		if strings.TrimSpace(value) == "" {
+			continue
+		}
+		query = query.Where(fmt.Sprintf("v%d = ?", fieldIndex+i), value)
+	}
+	if err := query.Delete(&CasbinRuleModel{}).Error; err != nil {
+		return fmt.Errorf("failed to remove casbin rules: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package persistence
+
+import (
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Errors wrapped around database errors caused by constraint violations
+var (
+	ErrUniqueViolation     = errors.New("unique constraint violation")
+	ErrForeignKeyViolation = errors.New("foreign key constraint violation")
+)
+
+// constraintViolationPatterns lists, per dialect, the error message fragments
+// of unique and foreign key violations and the marker after which the
+// violated constraint is named
+var constraintViolationPatterns = map[string]struct {
+	unique     []string
+	foreignKey []string
+	marker     string
+}{
+	"sqlite": {
+		unique:     []string{"UNIQUE constraint failed"},
+		foreignKey: []string{"FOREIGN KEY constraint failed"},
+		marker:     "constraint failed:",
+	},
+	"postgres": {
+		unique:     []string{"SQLSTATE 23505", "duplicate key value violates unique constraint"},
+		foreignKey: []string{"SQLSTATE 23503", "violates foreign key constraint"},
+		marker:     "constraint",
+	},
+	"mysql": {
+		unique:     []string{"Error 1062", "Duplicate entry"},
+		foreignKey: []string{"Error 1451", "Error 1452", "a foreign key constraint fails"},
+		marker:     "for key",
+	},
+}
+
+// sqlStateError is implemented by drivers, such as pgx, that expose the
+// SQLSTATE code of an error
+type sqlStateError interface {
+	SQLState() string
+}
+
+// constraintError is a database error caused by a constraint violation
+type constraintError struct {
+	sentinel   error
+	constraint string
+	err        error
+}
+
+func (e *constraintError) Error() string {
+	return e.err.Error()
+}
+
+func (e *constraintError) Unwrap() []error {
+	return []error{e.sentinel, e.err}
+}
+
+// translateDBError wraps unique and foreign key violations reported by the
+// database of db so that they match ErrUniqueViolation or
+// ErrForeignKeyViolation. Other errors are returned unchanged.
+func translateDBError(db *gorm.DB, err error) error {
+	var violation *constraintError
+	if err == nil || errors.As(err, &violation) {
+		return err
+	}
+
+	var sentinel error
+	switch {
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		sentinel = ErrUniqueViolation
+	case errors.Is(err, gorm.ErrForeignKeyViolated):
+		sentinel = ErrForeignKeyViolation
+	}
+
+	var stateErr sqlStateError
+	if sentinel == nil && errors.As(err, &stateErr) {
+		switch stateErr.SQLState() {
+		case "23505":
+			sentinel = ErrUniqueViolation
+		case "23503":
+			sentinel = ErrForeignKeyViolation
+		}
+	}
+
+	message := err.Error()
+	patterns, ok := constraintViolationPatterns[db.Dialector.Name()]
+	if sentinel == nil && ok {
+		if containsAny(message, patterns.unique) {
+			sentinel = ErrUniqueViolation
+		} else if containsAny(message, patterns.foreignKey) {
+			sentinel = ErrForeignKeyViolation
+		}
+	}
+	if sentinel == nil {
+		return err
+	}
+
+	violation = &constraintError{sentinel: sentinel, err: err}
+	if ok {
+		if i := strings.LastIndex(message, patterns.marker); i >= 0 {
+			violation.constraint = strings.TrimSpace(message[i+len(patterns.marker):])
+		}
+	}
+	return violation
+}
+
+// violatedConstraint returns the constraint named by the database for a
+// translated constraint violation, or an empty string when it is unknown
+func violatedConstraint(err error) string {
+	var violation *constraintError
+	if errors.As(err, &violation) {
+		return violation.constraint
+	}
+	return ""
+}
+
+func containsAny(s string, fragments []string) bool {
+	for _, fragment := range fragments {
+		if strings.Contains(s, fragment) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package persistence
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aruncs31s/azf/domain/api_usage"
+	"github.com/aruncs31s/azf/domain/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type featureFlagRepository struct {
+	db *gorm.DB
+}
+
+// NewFeatureFlagRepository creates a new feature flag repository
+func NewFeatureFlagRepository(db *gorm.DB) repository.FeatureFlagRepository {
+	return &featureFlagRepository{db: db}
+}
+
+// Save inserts the flag or replaces the existing one for the same name and
+// environment. change is stored alongside so every switch is audited.
+func (r *featureFlagRepository) Save(flag *api_usage.FeatureFlag, change *api_usage.FeatureFlagChange) (*api_usage.FeatureFlag, error) {
+	now := time.Now()
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var existing api_usage.FeatureFlag
+		err := tx.Where("name = ? AND environment = ?", flag.Name, flag.Environment).First(&existing).Error
+		switch {
+		case err == nil:
+			flag.CreatedAt = existing.CreatedAt
+			previous := existing.Enabled
+			change.Previous = &previous
+		case err == gorm.ErrRecordNotFound:
+			flag.CreatedAt = now
+		default:
+			return err
+		}
+		flag.UpdatedAt = now
+
+		if err := tx.Save(flag).Error; err != nil {
+			return err
+		}
+
+		if change.ID == "" {
+			change.ID = uuid.New().String()
+		}
+		change.ChangedAt = now
+		return tx.Create(change).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save feature flag: %w", err)
+	}
+	return flag, nil
+}
+
+func (r *featureFlagRepository) FindByEnvironment(environment string) (*[]api_usage.FeatureFlag, error) {
+	var flags []api_usage.FeatureFlag
+	if err := r.db.Where("environment = ?", environment).Order("name ASC").Find(&flags).Error; err != nil {
+		return nil, err
+	}
+	return &flags, nil
+}
+
+func (r *featureFlagRepository) FindChanges(limit int) (*[]api_usage.FeatureFlagChange, error) {
+	var changes []api_usage.FeatureFlagChange
+	if err := r.db.Order("changed_at DESC").Limit(limit).Find(&changes).Error; err != nil {
+		return nil, err
+	}
+	return &changes, nil
+}
@@ -0,0 +1,65 @@
+package persistence
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aruncs31s/azf/domain/api_usage"
+	"github.com/aruncs31s/azf/domain/repository"
+	"gorm.io/gorm"
+)
+
+type rateLimitOverrideRepository struct {
+	db *gorm.DB
+}
+
+// NewRateLimitOverrideRepository creates a new rate limit override repository
+func NewRateLimitOverrideRepository(db *gorm.DB) repository.RateLimitOverrideRepository {
+	return &rateLimitOverrideRepository{db: db}
+}
+
+// Save inserts the override or replaces the existing one for the same identity
+func (r *rateLimitOverrideRepository) Save(override *api_usage.RateLimitOverride) (*api_usage.RateLimitOverride, error) {
+	now := time.Now()
+	existing, err := r.FindByIdentity(override.Identity)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		override.CreatedAt = existing.CreatedAt
+	} else if override.CreatedAt.IsZero() {
+		override.CreatedAt = now
+	}
+	override.UpdatedAt = now
+
+	if err := r.db.Save(override).Error; err != nil {
+		return nil, fmt.Errorf("failed to save rate limit override: %w", err)
+	}
+	return override, nil
+}
+
+func (r *rateLimitOverrideRepository) FindByIdentity(identity string) (*api_usage.RateLimitOverride, error) {
+	var override api_usage.RateLimitOverride
+	if err := r.db.Where("identity = ?", identity).First(&override).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &override, nil
+}
+
+func (r *rateLimitOverrideRepository) FindAll() (*[]api_usage.RateLimitOverride, error) {
+	var overrides []api_usage.RateLimitOverride
+	if err := r.db.Order("identity ASC").Find(&overrides).Error; err != nil {
+		return nil, err
+	}
+	return &overrides, nil
+}
+
+func (r *rateLimitOverrideRepository) Delete(identity string) error {
+	if err := r.db.Where("identity = ?", identity).Delete(&api_usage.RateLimitOverride{}).Error; err != nil {
+		return fmt.Errorf("failed to delete rate limit override: %w", err)
+	}
+	return nil
+}
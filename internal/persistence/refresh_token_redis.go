@@ -0,0 +1,155 @@
+package persistence
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	identity_access "github.com/aruncs31s/azf/domain/identity_access/model"
+	"github.com/redis/go-redis/v9"
+)
+
+// refreshTokenKeyPrefix prefixes the Redis keys of refresh tokens and their families
+const refreshTokenKeyPrefix = "azf:refresh_token:"
+
+// redisRefreshTokenRepository keeps each token under its hash until it
+// expires, and the hashes of a family in a set
+type redisRefreshTokenRepository struct {
+	client *redis.Client
+}
+
+// NewRedisRefreshTokenRepository creates a refresh token repository on Redis,
+// for deployments sharing sessions between instances without a shared database
+func NewRedisRefreshTokenRepository(client *redis.Client) identity_access.RefreshTokenRepository {
+	return &redisRefreshTokenRepository{client: client}
+}
+
+func (r *redisRefreshTokenRepository) Create(ctx context.Context, token *identity_access.RefreshToken) error {
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		return r.store(ctx, pipe, token)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store refresh token: %w", err)
+	}
+	return nil
+}
+
+func (r *redisRefreshTokenRepository) FindByHash(ctx context.Context, hash string) (*identity_access.RefreshToken, error) {
+	return r.get(ctx, r.client, hash)
+}
+
+func (r *redisRefreshTokenRepository) Rotate(ctx context.Context, currentHash string, next *identity_access.RefreshToken) error {
+	err := r.client.Watch(ctx, func(tx *redis.Tx) error {
+		current, err := r.get(ctx, tx, currentHash)
+		if err != nil {
+			return err
+		}
+		if current.IsRevoked() {
+			return identity_access.ErrRefreshTokenRevoked
+		}
+		revokedAt := next.IssuedAt
+		current.RevokedAt = &revokedAt
+		current.ReplacedBy = next.TokenHash
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			if err := r.store(ctx, pipe, current); err != nil {
+				return err
+			}
+			return r.store(ctx, pipe, next)
+		})
+		return err
+	}, refreshTokenKey(currentHash))
+	// A concurrent change means the token was rotated or revoked meanwhile
+	if errors.Is(err, redis.TxFailedErr) {
+		return identity_access.ErrRefreshTokenRevoked
+	}
+	if err != nil && !errors.Is(err, identity_access.ErrRefreshTokenRevoked) && !errors.Is(err, identity_access.ErrRefreshTokenNotFound) {
+		return fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+	return err
+}
+
+func (r *redisRefreshTokenRepository) Revoke(ctx context.Context, hash string) error {
+	return r.revoke(ctx, hash)
+}
+
+func (r *redisRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	hashes, err := r.client.SMembers(ctx, refreshTokenFamilyKey(familyID)).Result()
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	for _, hash := range hashes {
+		if err := r.revoke(ctx, hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// revoke marks the token with hash revoked, keeping it until it expires so
+// a reuse is still detected
+func (r *redisRefreshTokenRepository) revoke(ctx context.Context, hash string) error {
+	token, err := r.get(ctx, r.client, hash)
+	if errors.Is(err, identity_access.ErrRefreshTokenNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if token.IsRevoked() {
+		return nil
+	}
+	now := time.Now()
+	token.RevokedAt = &now
+	if _, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		return r.store(ctx, pipe, token)
+	}); err != nil {
+		return fmt.Errorf("failed to revoke refresh token: %w", err)
+	}
+	return nil
+}
+
+// store queues writing token and adding it to its family while active
+func (r *redisRefreshTokenRepository) store(ctx context.Context, pipe redis.Pipeliner, token *identity_access.RefreshToken) error {
+	data, err := json.Marshal(refreshTokenToModel(token))
+	if err != nil {
+		return err
+	}
+	ttl := time.Until(token.ExpiresAt)
+	if ttl <= 0 {
+		ttl = time.Second
+	}
+	pipe.Set(ctx, refreshTokenKey(token.TokenHash), data, ttl)
+	// Only the newest token of a family is active, and it expires last
+	if !token.IsRevoked() {
+		familyKey := refreshTokenFamilyKey(token.FamilyID)
+		pipe.SAdd(ctx, familyKey, token.TokenHash)
+		pipe.Expire(ctx, familyKey, ttl)
+	}
+	return nil
+}
+
+func (r *redisRefreshTokenRepository) get(ctx context.Context, client redis.Cmdable, hash string) (*identity_access.RefreshToken, error) {
+	data, err := client.Get(ctx, refreshTokenKey(hash)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, identity_access.ErrRefreshTokenNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find refresh token: %w", err)
+	}
+	var model RefreshTokenModel
+	if err := json.Unmarshal(data, &model); err != nil {
+		return nil, fmt.Errorf("failed to decode refresh token: %w", err)
+	}
+	return model.toDomain(), nil
+}
+
+func refreshTokenKey(hash string) string {
+	return refreshTokenKeyPrefix + hash
+}
+
+func refreshTokenFamilyKey(familyID string) string {
+	return refreshTokenKeyPrefix + "family:" + familyID
+}
@@ -0,0 +1,121 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	identity_access "github.com/aruncs31s/azf/domain/identity_access/model"
+	"gorm.io/gorm"
+)
+
+// RefreshTokenModel is an admin refresh token row
+type RefreshTokenModel struct {
+	TokenHash  string `gorm:"primaryKey;type:varchar(64)"`
+	FamilyID   string `gorm:"index;type:varchar(36)"`
+	Username   string `gorm:"type:varchar(100)"`
+	UserID     string `gorm:"type:varchar(36)"`
+	IssuedAt   time.Time
+	ExpiresAt  time.Time `gorm:"index"`
+	RevokedAt  *time.Time
+	ReplacedBy string `gorm:"type:varchar(64)"`
+}
+
+func (RefreshTokenModel) TableName() string {
+	return "admin_refresh_tokens"
+}
+
+type refreshTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewRefreshTokenRepository creates a refresh token repository on db
+func NewRefreshTokenRepository(db *gorm.DB) identity_access.RefreshTokenRepository {
+	return &refreshTokenRepository{db: db}
+}
+
+func (r *refreshTokenRepository) Create(ctx context.Context, token *identity_access.RefreshToken) error {
+	if err := r.db.WithContext(ctx).Create(refreshTokenToModel(token)).Error; err != nil {
+		return fmt.Errorf("failed to store refresh token: %w", err)
+	}
+	return nil
+}
+
+func (r *refreshTokenRepository) FindByHash(ctx context.Context, hash string) (*identity_access.RefreshToken, error) {
+	var model RefreshTokenModel
+	err := r.db.WithContext(ctx).Where("token_hash = ?", hash).First(&model).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, identity_access.ErrRefreshTokenNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find refresh token: %w", err)
+	}
+	return model.toDomain(), nil
+}
+
+func (r *refreshTokenRepository) Rotate(ctx context.Context, currentHash string, next *identity_access.RefreshToken) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// The revoked_at condition makes concurrent rotations of the same
+		// token race for a single row update
+		result := tx.Model(&RefreshTokenModel{}).
+			Where("token_hash = ? AND revoked_at IS NULL", currentHash).
+			Updates(map[string]any{"revoked_at": next.IssuedAt, "replaced_by": next.TokenHash})
+		if result.Error != nil {
+			return fmt.Errorf("failed to rotate refresh token: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return identity_access.ErrRefreshTokenRevoked
+		}
+		if err := tx.Create(refreshTokenToModel(next)).Error; err != nil {
+			return fmt.Errorf("failed to store refresh token: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *refreshTokenRepository) Revoke(ctx context.Context, hash string) error {
+	err := r.db.WithContext(ctx).Model(&RefreshTokenModel{}).
+		Where("token_hash = ? AND revoked_at IS NULL", hash).
+		Update("revoked_at", time.Now()).Error
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh token: %w", err)
+	}
+	return nil
+}
+
+func (r *refreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	err := r.db.WithContext(ctx).Model(&RefreshTokenModel{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	return nil
+}
+
+func refreshTokenToModel(token *identity_access.RefreshToken) *RefreshTokenModel {
+	return &RefreshTokenModel{
+		TokenHash:  token.TokenHash,
+		FamilyID:   token.FamilyID,
+		Username:   token.Username,
+		UserID:     token.UserID,
+		IssuedAt:   token.IssuedAt,
+		ExpiresAt:  token.ExpiresAt,
+		RevokedAt:  token.RevokedAt,
+		ReplacedBy: token.ReplacedBy,
+	}
+}
+
+func (m *RefreshTokenModel) toDomain() *identity_access.RefreshToken {
+	return &identity_access.RefreshToken{
+		TokenHash:  m.TokenHash,
+		FamilyID:   m.FamilyID,
+		Username:   m.Username,
+		UserID:     m.UserID,
+		IssuedAt:   m.IssuedAt,
+		ExpiresAt:  m.ExpiresAt,
+		RevokedAt:  m.RevokedAt,
+		ReplacedBy: m.ReplacedBy,
+	}
+}
@@ -0,0 +1,153 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aruncs31s/azf/config"
+	"github.com/aruncs31s/azf/domain/repository"
+	"github.com/aruncs31s/azf/shared/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// encodedTextTable lists the text columns of a table stored through the TextCodec
+type encodedTextTable struct {
+	table   string
+	columns map[string]TextEncoding
+}
+
+var encodedTextTables = []encodedTextTable{
+	{
+		table: "api_usage_logs",
+		columns: map[string]TextEncoding{
+			"user_agent":    TextEncodingDictionary,
+			"error_message": TextEncodingCompressed,
+		},
+	},
+	{
+		table: "authorization_audit_logs",
+		columns: map[string]TextEncoding{
+			"user_agent": TextEncodingDictionary,
+			"error_msg":  TextEncodingCompressed,
+		},
+	},
+}
+
+// NewEncodedTextBackfiller creates a backfiller for the encoded text columns in db
+func NewEncodedTextBackfiller(db *gorm.DB) repository.EncodedTextBackfiller {
+	return &encodedTextBackfiller{
+		db:    db,
+		codec: NewTextCodec(db, config.TextEncodingEnabled()),
+	}
+}
+
+type encodedTextBackfiller struct {
+	db    *gorm.DB
+	codec *TextCodec
+}
+
+func (b *encodedTextBackfiller) BackfillEncodedText(ctx context.Context, batchSize int) (int64, error) {
+	return BackfillEncodedText(ctx, b.db, b.codec, batchSize)
+}
+
+// BackfillEncodedText encodes text columns of rows written before encoding was
+// enabled. Rows are processed in ID order in batches of batchSize, so the job
+// can be stopped through ctx and re-run safely. It returns the rows updated.
+func BackfillEncodedText(ctx context.Context, db *gorm.DB, codec *TextCodec, batchSize int) (int64, error) {
+	if codec == nil || !codec.enabled {
+		return 0, fmt.Errorf("text column encoding is disabled")
+	}
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+
+	var updated int64
+	for _, table := range encodedTextTables {
+		if !db.Migrator().HasTable(table.table) {
+			continue
+		}
+		count, err := backfillTable(ctx, db, codec, table, batchSize)
+		updated += count
+		if err != nil {
+			return updated, err
+		}
+		logger.Info("Backfilled encoded text columns",
+			zap.String("table", table.table),
+			zap.Int64("rows_updated", count))
+	}
+	return updated, nil
+}
+
+func backfillTable(ctx context.Context, db *gorm.DB, codec *TextCodec, table encodedTextTable, batchSize int) (int64, error) {
+	columns := []string{"id"}
+	for column := range table.columns {
+		columns = append(columns, column)
+	}
+
+	var updated int64
+	lastID := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return updated, err
+		}
+
+		var rows []map[string]any
+		if err := db.WithContext(ctx).Table(table.table).
+			Select(columns).
+			Where("id > ?", lastID).
+			Order("id").
+			Limit(batchSize).
+			Find(&rows).Error; err != nil {
+			return updated, fmt.Errorf("failed to read %s: %w", table.table, err)
+		}
+		if len(rows) == 0 {
+			return updated, nil
+		}
+
+		for _, row := range rows {
+			lastID = textValue(row["id"])
+
+			changes := make(map[string]any)
+			for column, encoding := range table.columns {
+				value, ok := row[column]
+				if !ok || value == nil {
+					continue
+				}
+				plain := textValue(value)
+				if plain == "" || IsEncodedText(plain) {
+					continue
+				}
+				encoded, err := codec.Encode(plain, encoding)
+				if err != nil {
+					return updated, err
+				}
+				if encoded != plain {
+					changes[column] = encoded
+				}
+			}
+			if len(changes) == 0 {
+				continue
+			}
+
+			if err := db.WithContext(ctx).Table(table.table).Where("id = ?", lastID).Updates(changes).Error; err != nil {
+				return updated, fmt.Errorf("failed to update %s row %s: %w", table.table, lastID, err)
+			}
+			updated++
+		}
+	}
+}
+
+// textValue converts a scanned text column to a string; MySQL returns []byte
+func textValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
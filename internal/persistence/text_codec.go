@@ -0,0 +1,262 @@
+package persistence
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Encoded text values carry a short prefix so plain values written before
+// encoding was enabled (or below the thresholds) are read back unchanged.
+// Plain values that happen to start with the marker are escaped by doubling it.
+const (
+	textMarker           = "~"
+	textDictionaryPrefix = "~d:"
+	textCompressedPrefix = "~z:"
+	textEscapedPrefix    = "~~"
+)
+
+const (
+	// minDictionaryTextLength is the shortest value worth a dictionary reference
+	minDictionaryTextLength = 24
+	// minCompressedTextLength is the shortest value worth compressing
+	minCompressedTextLength = 512
+	// maxCachedDictionaryEntries bounds the in-memory dictionary cache
+	maxCachedDictionaryEntries = 10000
+)
+
+// TextEncoding selects how a text column is stored
+type TextEncoding int
+
+const (
+	// TextEncodingDictionary stores repetitive values (user agents) once in
+	// the text dictionary and keeps a short reference in the column
+	TextEncodingDictionary TextEncoding = iota
+	// TextEncodingCompressed gzips large, mostly unique values (error messages)
+	TextEncodingCompressed
+)
+
+// TextDictionaryEntry is a distinct text value referenced from encoded columns
+type TextDictionaryEntry struct {
+	ID        uint64    `gorm:"primaryKey;autoIncrement" json:"id"`
+	Hash      string    `gorm:"uniqueIndex;type:char(64)" json:"hash"`
+	Value     string    `gorm:"type:text" json:"value"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for TextDictionaryEntry
+func (TextDictionaryEntry) TableName() string {
+	return "text_dictionary"
+}
+
+// TextCodec encodes large text columns on write and decodes them on read.
+// Dictionary entries are cached in memory; the cache is cleared when full.
+type TextCodec struct {
+	db      *gorm.DB
+	enabled bool
+
+	mu     sync.RWMutex
+	byID   map[uint64]string
+	byHash map[string]uint64
+}
+
+// NewTextCodec creates a text codec backed by the text dictionary in db.
+// When enabled is false values are written as-is but still decoded on read.
+func NewTextCodec(db *gorm.DB, enabled bool) *TextCodec {
+	return &TextCodec{
+		db:      db,
+		enabled: enabled,
+		byID:    make(map[uint64]string),
+		byHash:  make(map[string]uint64),
+	}
+}
+
+// Encode returns the stored form of value for a column with the given encoding
+func (c *TextCodec) Encode(value string, encoding TextEncoding) (string, error) {
+	if c == nil || !c.enabled {
+		return escapeText(value), nil
+	}
+
+	switch encoding {
+	case TextEncodingDictionary:
+		if len(value) < minDictionaryTextLength {
+			return escapeText(value), nil
+		}
+		id, err := c.intern(value)
+		if err != nil {
+			return "", err
+		}
+		return textDictionaryPrefix + strconv.FormatUint(id, 10), nil
+	case TextEncodingCompressed:
+		if len(value) < minCompressedTextLength {
+			return escapeText(value), nil
+		}
+		compressed, err := compressText(value)
+		if err != nil {
+			return "", err
+		}
+		if len(compressed) >= len(value) {
+			return escapeText(value), nil
+		}
+		return compressed, nil
+	default:
+		return escapeText(value), nil
+	}
+}
+
+// EncodePtr encodes an optional value, leaving nil as nil
+func (c *TextCodec) EncodePtr(value *string, encoding TextEncoding) (*string, error) {
+	if value == nil {
+		return nil, nil
+	}
+	encoded, err := c.Encode(*value, encoding)
+	if err != nil {
+		return nil, err
+	}
+	return &encoded, nil
+}
+
+// Decode returns the original value of a stored text column. Values that
+// cannot be decoded (e.g. a missing dictionary entry) are returned as stored.
+func (c *TextCodec) Decode(value string) string {
+	switch {
+	case strings.HasPrefix(value, textEscapedPrefix):
+		return value[len(textMarker):]
+	case strings.HasPrefix(value, textDictionaryPrefix):
+		id, err := strconv.ParseUint(value[len(textDictionaryPrefix):], 10, 64)
+		if err != nil || c == nil {
+			return value
+		}
+		if decoded, ok := c.lookup(id); ok {
+			return decoded
+		}
+		return value
+	case strings.HasPrefix(value, textCompressedPrefix):
+		decoded, err := decompressText(value)
+		if err != nil {
+			return value
+		}
+		return decoded
+	default:
+		return value
+	}
+}
+
+// DecodePtr decodes an optional value, leaving nil as nil
+func (c *TextCodec) DecodePtr(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	decoded := c.Decode(*value)
+	return &decoded
+}
+
+// IsEncodedText reports whether a stored value is in an encoded or escaped form
+func IsEncodedText(value string) bool {
+	return strings.HasPrefix(value, textDictionaryPrefix) ||
+		strings.HasPrefix(value, textCompressedPrefix) ||
+		strings.HasPrefix(value, textEscapedPrefix)
+}
+
+func escapeText(value string) string {
+	if strings.HasPrefix(value, textMarker) {
+		return textMarker + value
+	}
+	return value
+}
+
+// intern returns the dictionary ID for value, inserting it if new
+func (c *TextCodec) intern(value string) (uint64, error) {
+	sum := sha256.Sum256([]byte(value))
+	hash := hex.EncodeToString(sum[:])
+
+	c.mu.RLock()
+	id, ok := c.byHash[hash]
+	c.mu.RUnlock()
+	if ok {
+		return id, nil
+	}
+
+	entry := TextDictionaryEntry{Hash: hash, Value: value, CreatedAt: time.Now()}
+	if err := c.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&entry).Error; err != nil {
+		return 0, fmt.Errorf("failed to store text dictionary entry: %w", err)
+	}
+	if entry.ID == 0 {
+		// Another writer inserted the same value first
+		if err := c.db.Where("hash = ?", hash).First(&entry).Error; err != nil {
+			return 0, fmt.Errorf("failed to load text dictionary entry: %w", err)
+		}
+	}
+
+	c.cache(entry.ID, hash, value)
+	return entry.ID, nil
+}
+
+func (c *TextCodec) lookup(id uint64) (string, bool) {
+	c.mu.RLock()
+	value, ok := c.byID[id]
+	c.mu.RUnlock()
+	if ok {
+		return value, true
+	}
+
+	var entry TextDictionaryEntry
+	if err := c.db.Where("id = ?", id).First(&entry).Error; err != nil {
+		return "", false
+	}
+	c.cache(entry.ID, entry.Hash, entry.Value)
+	return entry.Value, true
+}
+
+func (c *TextCodec) cache(id uint64, hash string, value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.byID) >= maxCachedDictionaryEntries {
+		c.byID = make(map[uint64]string)
+		c.byHash = make(map[string]uint64)
+	}
+	c.byID[id] = value
+	c.byHash[hash] = id
+}
+
+func compressText(value string) (string, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write([]byte(value)); err != nil {
+		return "", fmt.Errorf("failed to compress text: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("failed to compress text: %w", err)
+	}
+	return textCompressedPrefix + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+func decompressText(value string) (string, error) {
+	compressed, err := base64.StdEncoding.DecodeString(value[len(textCompressedPrefix):])
+	if err != nil {
+		return "", err
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	decoded, err := io.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+	return string(decoded), nil
+}
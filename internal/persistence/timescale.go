@@ -0,0 +1,141 @@
+package persistence
+
+import (
+	"fmt"
+
+	"github.com/aruncs31s/azf/shared/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// UsageHourlyAggregateView is the continuous aggregate of API usage per hour
+// that backs the usage trend and latency heatmap queries on Timescale
+const UsageHourlyAggregateView = "api_usage_hourly"
+
+// timescaleHypertable describes a time-series table converted to a hypertable
+type timescaleHypertable struct {
+	table      string
+	timeColumn string
+}
+
+// timescaleHypertables lists the append-only tables partitioned by time on Timescale
+var timescaleHypertables = []timescaleHypertable{
+	{table: "api_usage_logs", timeColumn: "requested_at"},
+	{table: "authorization_audit_logs", timeColumn: "timestamp"},
+}
+
+const usageHourlyAggregateSQL = `CREATE MATERIALIZED VIEW IF NOT EXISTS api_usage_hourly
+WITH (timescaledb.continuous, timescaledb.materialized_only = false) AS
+SELECT time_bucket(INTERVAL '1 hour', requested_at) AS bucket,
+	COUNT(*) AS request_count,
+	COUNT(*) FILTER (WHERE (status_code >= 200 AND status_code < 300) OR status_code = 304) AS success_count,
+	SUM(response_time) AS total_response_time
+FROM api_usage_logs
+GROUP BY bucket
+WITH NO DATA`
+
+// IsTimescaleAvailable reports whether db is a Postgres database with the
+// timescaledb extension installed
+func IsTimescaleAvailable(db *gorm.DB) bool {
+	if db == nil || db.Dialector.Name() != "postgres" {
+		return false
+	}
+
+	var installed bool
+	if err := db.Raw("SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'timescaledb')").Scan(&installed).Error; err != nil {
+		return false
+	}
+	return installed
+}
+
+// HasContinuousAggregate reports whether the named Timescale continuous aggregate exists
+func HasContinuousAggregate(db *gorm.DB, view string) bool {
+	if !IsTimescaleAvailable(db) {
+		return false
+	}
+
+	var exists bool
+	if err := db.Raw("SELECT EXISTS (SELECT 1 FROM timescaledb_information.continuous_aggregates WHERE view_name = ?)", view).
+		Scan(&exists).Error; err != nil {
+		return false
+	}
+	return exists
+}
+
+// SetupTimescale converts the usage and audit log tables to hypertables and
+// creates the hourly usage continuous aggregate. It does nothing unless db is
+// Postgres with the timescaledb extension installed, and is safe to run on
+// every start. Tables that do not exist yet are skipped.
+func SetupTimescale(db *gorm.DB) error {
+	if !IsTimescaleAvailable(db) {
+		return nil
+	}
+
+	for _, hypertable := range timescaleHypertables {
+		if !db.Migrator().HasTable(hypertable.table) {
+			continue
+		}
+		created, err := createHypertable(db, hypertable)
+		if err != nil {
+			return err
+		}
+		if created {
+			logger.Info("Converted table to Timescale hypertable",
+				zap.String("table", hypertable.table),
+				zap.String("time_column", hypertable.timeColumn))
+		}
+	}
+
+	if db.Migrator().HasTable("api_usage_logs") && !HasContinuousAggregate(db, UsageHourlyAggregateView) {
+		if err := createUsageHourlyAggregate(db); err != nil {
+			return err
+		}
+		logger.Info("Created Timescale continuous aggregate", zap.String("view", UsageHourlyAggregateView))
+	}
+	return nil
+}
+
+// createHypertable converts a table to a hypertable, returning false if it already is one.
+// Timescale requires the time column in every unique index, so the ID primary
+// key is widened to (id, time column) first.
+func createHypertable(db *gorm.DB, hypertable timescaleHypertable) (bool, error) {
+	var exists bool
+	if err := db.Raw("SELECT EXISTS (SELECT 1 FROM timescaledb_information.hypertables WHERE hypertable_name = ?)", hypertable.table).
+		Scan(&exists).Error; err != nil {
+		return false, fmt.Errorf("failed to check hypertable %s: %w", hypertable.table, err)
+	}
+	if exists {
+		return false, nil
+	}
+
+	statements := []string{
+		fmt.Sprintf(`ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s_pkey`, hypertable.table, hypertable.table),
+		fmt.Sprintf(`ALTER TABLE %s ADD PRIMARY KEY (id, "%s")`, hypertable.table, hypertable.timeColumn),
+		fmt.Sprintf(`SELECT create_hypertable('%s', '%s', migrate_data => true, if_not_exists => true)`, hypertable.table, hypertable.timeColumn),
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return false, fmt.Errorf("failed to create hypertable %s: %w", hypertable.table, err)
+		}
+	}
+	return true, nil
+}
+
+// createUsageHourlyAggregate creates the hourly usage continuous aggregate,
+// backfills it from existing logs and schedules its refresh
+func createUsageHourlyAggregate(db *gorm.DB) error {
+	if err := db.Exec(usageHourlyAggregateSQL).Error; err != nil {
+		return fmt.Errorf("failed to create continuous aggregate %s: %w", UsageHourlyAggregateView, err)
+	}
+	if err := db.Exec("CALL refresh_continuous_aggregate('api_usage_hourly', NULL, NULL)").Error; err != nil {
+		return fmt.Errorf("failed to refresh continuous aggregate %s: %w", UsageHourlyAggregateView, err)
+	}
+	if err := db.Exec(`SELECT add_continuous_aggregate_policy('api_usage_hourly',
+		start_offset => INTERVAL '3 days',
+		end_offset => INTERVAL '1 hour',
+		schedule_interval => INTERVAL '30 minutes',
+		if_not_exists => true)`).Error; err != nil {
+		return fmt.Errorf("failed to schedule continuous aggregate %s: %w", UsageHourlyAggregateView, err)
+	}
+	return nil
+}
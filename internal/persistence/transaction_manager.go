@@ -0,0 +1,86 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aruncs31s/azf/domain/repository"
+	"gorm.io/gorm"
+)
+
+// txContextKey is the context key under which the active transaction is stored
+type txContextKey struct{}
+
+// GormTransactionManager runs repository calls in a shared GORM transaction.
+// Repositories pick the transaction up from the context they are called with.
+type GormTransactionManager struct {
+	db *gorm.DB
+}
+
+// NewTransactionManager creates a transaction manager over db
+func NewTransactionManager(db *gorm.DB) repository.TransactionManager {
+	return &GormTransactionManager{db: db}
+}
+
+// Begin starts a transaction. Repository calls join it when they are made
+// with the context returned by the transaction's Context method.
+func (m *GormTransactionManager) Begin(ctx context.Context) (repository.Transaction, error) {
+	if m.db == nil {
+		return nil, fmt.Errorf("transaction manager has no database")
+	}
+	tx := conn(ctx, m.db).Begin()
+	if tx.Error != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", tx.Error)
+	}
+	return &gormTransaction{tx: tx, ctx: context.WithValue(ctx, txContextKey{}, tx)}, nil
+}
+
+// WithTransaction runs fn in a transaction that is committed when fn returns
+// nil and rolled back otherwise. Nested calls use savepoints.
+func (m *GormTransactionManager) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if m.db == nil {
+		return fmt.Errorf("transaction manager has no database")
+	}
+	return conn(ctx, m.db).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txContextKey{}, tx))
+	})
+}
+
+// gormTransaction implements repository.Transaction
+type gormTransaction struct {
+	tx  *gorm.DB
+	ctx context.Context
+}
+
+func (t *gormTransaction) Context() context.Context {
+	return t.ctx
+}
+
+func (t *gormTransaction) Commit() error {
+	if err := t.tx.Commit().Error; err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (t *gormTransaction) Rollback() error {
+	if err := t.tx.Rollback().Error; err != nil {
+		return fmt.Errorf("failed to roll back transaction: %w", err)
+	}
+	return nil
+}
+
+// conn returns the transaction carried by ctx, or db when there is none,
+// bound to ctx
+func conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txContextKey{}).(*gorm.DB); ok && tx != nil {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
+
+// Conn returns the transaction carried by ctx, or db when there is none, so
+// repositories outside this package can join transactions of the manager
+func Conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	return conn(ctx, db)
+}
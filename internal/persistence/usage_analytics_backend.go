@@ -0,0 +1,37 @@
+package persistence
+
+import (
+	"github.com/aruncs31s/azf/domain/repository"
+	"gorm.io/gorm"
+)
+
+// NewGormUsageAnalyticsBackend creates a usage analytics backend that stores
+// logs and statistics in the application database
+func NewGormUsageAnalyticsBackend(db *gorm.DB) repository.UsageAnalyticsBackend {
+	return &gormUsageAnalyticsBackend{
+		logs:  NewAPIUsageRepository(db),
+		stats: NewAPIUsageStatsRepository(db),
+	}
+}
+
+type gormUsageAnalyticsBackend struct {
+	logs  repository.APIUsageLogRepository
+	stats repository.APIUsageStatsRepository
+}
+
+func (b *gormUsageAnalyticsBackend) Name() string {
+	return "gorm"
+}
+
+func (b *gormUsageAnalyticsBackend) Logs() repository.APIUsageLogRepository {
+	return b.logs
+}
+
+func (b *gormUsageAnalyticsBackend) Stats() repository.APIUsageStatsRepository {
+	return b.stats
+}
+
+// Close is a no-op; the database connection is owned by the caller
+func (b *gormUsageAnalyticsBackend) Close() error {
+	return nil
+}
@@ -0,0 +1,59 @@
+package persistence
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aruncs31s/azf/domain/api_usage"
+	"github.com/aruncs31s/azf/domain/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type usageAnnotationRepository struct {
+	db *gorm.DB
+}
+
+// NewUsageAnnotationRepository creates a new chart annotation repository
+func NewUsageAnnotationRepository(db *gorm.DB) repository.UsageAnnotationRepository {
+	return &usageAnnotationRepository{db: db}
+}
+
+func (r *usageAnnotationRepository) Create(annotation *api_usage.UsageAnnotation) (*api_usage.UsageAnnotation, error) {
+	if annotation.ID == "" {
+		annotation.ID = uuid.New().String()
+	}
+	if annotation.CreatedAt.IsZero() {
+		annotation.CreatedAt = time.Now()
+	}
+	if err := r.db.Create(annotation).Error; err != nil {
+		return nil, err
+	}
+	return annotation, nil
+}
+
+func (r *usageAnnotationRepository) FindByID(id string) (*api_usage.UsageAnnotation, error) {
+	var annotation api_usage.UsageAnnotation
+	if err := r.db.Where("id = ?", id).First(&annotation).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &annotation, nil
+}
+
+func (r *usageAnnotationRepository) FindByTimeRange(from time.Time, to time.Time) (*[]api_usage.UsageAnnotation, error) {
+	var annotations []api_usage.UsageAnnotation
+	if err := r.db.Where("occurred_at >= ? AND occurred_at < ?", from, to).Order("occurred_at ASC").Find(&annotations).Error; err != nil {
+		return nil, err
+	}
+	return &annotations, nil
+}
+
+func (r *usageAnnotationRepository) Delete(id string) error {
+	if err := r.db.Where("id = ?", id).Delete(&api_usage.UsageAnnotation{}).Error; err != nil {
+		return fmt.Errorf("failed to delete usage annotation: %w", err)
+	}
+	return nil
+}
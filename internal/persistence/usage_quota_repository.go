@@ -0,0 +1,102 @@
+package persistence
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aruncs31s/azf/domain/api_usage"
+	"github.com/aruncs31s/azf/domain/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type usageQuotaRepository struct {
+	db *gorm.DB
+}
+
+// NewUsageQuotaRepository creates a new usage quota repository
+func NewUsageQuotaRepository(db *gorm.DB) repository.UsageQuotaRepository {
+	return &usageQuotaRepository{db: db}
+}
+
+// Save inserts the quota or replaces the existing one for the same subject
+// and period, keeping the usage counted so far
+func (r *usageQuotaRepository) Save(quota *api_usage.UsageQuota) (*api_usage.UsageQuota, error) {
+	now := time.Now()
+	var existing api_usage.UsageQuota
+	err := r.db.Where("subject_type = ? AND subject = ? AND period = ?", quota.SubjectType, quota.Subject, quota.Period).
+		First(&existing).Error
+	switch {
+	case err == nil:
+		quota.ID = existing.ID
+		quota.Used = existing.Used
+		quota.PeriodStart = existing.PeriodStart
+		quota.CreatedAt = existing.CreatedAt
+	case err == gorm.ErrRecordNotFound:
+		if quota.ID == "" {
+			quota.ID = uuid.New().String()
+		}
+		if quota.PeriodStart.IsZero() {
+			quota.PeriodStart = api_usage.QuotaPeriodStart(quota.Period, now)
+		}
+		if quota.CreatedAt.IsZero() {
+			quota.CreatedAt = now
+		}
+	default:
+		return nil, err
+	}
+	quota.UpdatedAt = now
+
+	if err := r.db.Save(quota).Error; err != nil {
+		return nil, fmt.Errorf("failed to save usage quota: %w", err)
+	}
+	return quota, nil
+}
+
+func (r *usageQuotaRepository) FindByID(id string) (*api_usage.UsageQuota, error) {
+	var quota api_usage.UsageQuota
+	if err := r.db.Where("id = ?", id).First(&quota).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &quota, nil
+}
+
+func (r *usageQuotaRepository) FindAll() (*[]api_usage.UsageQuota, error) {
+	var quotas []api_usage.UsageQuota
+	if err := r.db.Order("subject_type ASC, subject ASC, period ASC").Find(&quotas).Error; err != nil {
+		return nil, err
+	}
+	return &quotas, nil
+}
+
+func (r *usageQuotaRepository) Delete(id string) error {
+	if err := r.db.Where("id = ?", id).Delete(&api_usage.UsageQuota{}).Error; err != nil {
+		return fmt.Errorf("failed to delete usage quota: %w", err)
+	}
+	return nil
+}
+
+// AddUsage increments the counter in place so instances flushing at the
+// same time do not overwrite each other's usage
+func (r *usageQuotaRepository) AddUsage(id string, periodStart time.Time, requests int64) error {
+	err := r.db.Model(&api_usage.UsageQuota{}).
+		Where("id = ? AND period_start = ?", id, periodStart).
+		Update("used", gorm.Expr("used + ?", requests)).Error
+	if err != nil {
+		return fmt.Errorf("failed to add usage quota usage: %w", err)
+	}
+	return nil
+}
+
+func (r *usageQuotaRepository) ResetPeriod(id string, previousStart time.Time, start time.Time) (bool, error) {
+	result := r.db.Model(&api_usage.UsageQuota{}).
+		Where("id = ? AND period_start = ?", id, previousStart).
+		Updates(map[string]interface{}{"used": 0, "period_start": start})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to reset usage quota: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
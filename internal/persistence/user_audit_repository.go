@@ -0,0 +1,96 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	user_management "github.com/aruncs31s/azf/domain/user_management/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UserAuditModel is the GORM model for the user audit trail
+type UserAuditModel struct {
+	ID        string    `gorm:"primaryKey;type:varchar(36)"`
+	UserID    string    `gorm:"index:idx_user_audit_user;type:varchar(36)"`
+	Username  string    `gorm:"type:varchar(50)"`
+	Action    string    `gorm:"type:varchar(20)"`
+	ActorID   string    `gorm:"type:varchar(36)"`
+	ActorName string    `gorm:"type:varchar(100)"`
+	Reason    string    `gorm:"type:text"`
+	CreatedAt time.Time `gorm:"index;index:idx_user_audit_user"`
+}
+
+func (UserAuditModel) TableName() string {
+	return "authz_user_audit"
+}
+
+type userAuditRepository struct {
+	db *gorm.DB
+}
+
+// NewUserAuditRepository creates a new user audit trail repository
+func NewUserAuditRepository(db *gorm.DB) user_management.UserAuditRepository {
+	return &userAuditRepository{db: db}
+}
+
+func (r *userAuditRepository) Record(ctx context.Context, entry *user_management.UserAuditEntry) error {
+	if entry.ID == "" {
+		entry.ID = uuid.NewString()
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	model := &UserAuditModel{
+		ID:        entry.ID,
+		UserID:    entry.UserID,
+		Username:  entry.Username,
+		Action:    string(entry.Action),
+		ActorID:   entry.ActorID,
+		ActorName: entry.ActorName,
+		Reason:    entry.Reason,
+		CreatedAt: entry.CreatedAt,
+	}
+	if err := conn(ctx, r.db).Create(model).Error; err != nil {
+		return fmt.Errorf("failed to record user audit entry: %w", err)
+	}
+	return nil
+}
+
+func (r *userAuditRepository) FindByUserID(ctx context.Context, userID string, limit int) ([]*user_management.UserAuditEntry, error) {
+	var models []UserAuditModel
+	query := conn(ctx, r.db).Where("user_id = ?", userID).Order("created_at DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to find user audit entries: %w", err)
+	}
+	return userAuditToDomain(models), nil
+}
+
+func (r *userAuditRepository) FindRecent(ctx context.Context, limit int) ([]*user_management.UserAuditEntry, error) {
+	var models []UserAuditModel
+	if err := conn(ctx, r.db).Order("created_at DESC").Limit(limit).Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to find user audit entries: %w", err)
+	}
+	return userAuditToDomain(models), nil
+}
+
+func userAuditToDomain(models []UserAuditModel) []*user_management.UserAuditEntry {
+	entries := make([]*user_management.UserAuditEntry, 0, len(models))
+	for _, model := range models {
+		entries = append(entries, &user_management.UserAuditEntry{
+			ID:        model.ID,
+			UserID:    model.UserID,
+			Username:  model.Username,
+			Action:    user_management.UserAuditAction(model.Action),
+			ActorID:   model.ActorID,
+			ActorName: model.ActorName,
+			Reason:    model.Reason,
+			CreatedAt: model.CreatedAt,
+		})
+	}
+	return entries
+}
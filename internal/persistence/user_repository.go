@@ -0,0 +1,607 @@
+package persistence
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	user_management "github.com/aruncs31s/azf/domain/user_management/model"
+	apperrors "github.com/aruncs31s/azf/shared/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UserModel struct {
+	ID            string `gorm:"primaryKey;type:varchar(36)"`
+	Email         string `gorm:"uniqueIndex;type:varchar(254)"`
+	Username      string `gorm:"uniqueIndex;type:varchar(50)"`
+	DisplayName   string `gorm:"type:varchar(100)"`
+	Status        string `gorm:"type:varchar(20)"`
+	Roles         string `gorm:"type:text"` // JSON, superseded by authz_user_roles
+	IsAdmin       bool
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	LastLoginAt   *time.Time
+	BlockedReason string `gorm:"type:text"`
+	Metadata      string `gorm:"type:text"` // JSON
+	OAuthProvider string `gorm:"size:50"`
+	OAuthID       string `gorm:"size:255"`
+	// DeletedAt soft-deletes the user; GORM leaves deleted rows out of
+	// every query that is not Unscoped
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+func (UserModel) TableName() string {
+	return "authz_users"
+}
+
+type RoleData struct {
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+}
+
+type GormUserRepository struct {
+	db *gorm.DB
+}
+
+func NewGormUserRepository(
+	db *gorm.DB,
+) *GormUserRepository {
+	return &GormUserRepository{db: db}
+}
+
+// NewUserRepository creates a new user repository
+func NewUserRepository(db *gorm.DB) user_management.UserRepository {
+	return NewGormUserRepository(db)
+}
+
+// Helper functions for conversion
+
+func domainToModel(user *user_management.User) (*UserModel, error) {
+	if user == nil {
+		return nil, errors.New("user cannot be nil")
+	}
+
+	// Serialize roles
+	roles := user.GetRoles()
+	rolesData := make([]RoleData, len(roles))
+	for i, role := range roles {
+		rolesData[i] = RoleData{
+			Name:        role.Name(),
+			Permissions: role.Permissions(),
+		}
+	}
+	rolesJSON, err := json.Marshal(rolesData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal roles: %w", err)
+	}
+
+	// Serialize metadata
+	metadata := user.GetAllMetadata()
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
+	model := &UserModel{
+		ID:            user.GetID(),
+		Email:         user.GetEmail(),
+		Username:      user.GetUsername(),
+		DisplayName:   user.GetDisplayName(),
+		Status:        string(user.GetStatus()),
+		Roles:         string(rolesJSON),
+		IsAdmin:       user.IsAdmin(),
+		CreatedAt:     user.GetCreatedAt(),
+		UpdatedAt:     user.GetUpdatedAt(),
+		LastLoginAt:   user.GetLastLoginAt(),
+		BlockedReason: user.GetBlockedReason(),
+		Metadata:      string(metadataJSON),
+		OAuthProvider: user.GetOAuthProvider(),
+		OAuthID:       user.GetOAuthID(),
+	}
+	if deletedAt := user.GetDeletedAt(); deletedAt != nil {
+		model.DeletedAt = gorm.DeletedAt{Time: *deletedAt, Valid: true}
+	}
+
+	return model, nil
+}
+
+// modelToDomain converts a user row with the roles loaded from the join table
+func modelToDomain(model *UserModel, roles []*user_management.UserRole) (*user_management.User, error) {
+	if model == nil {
+		return nil, errors.New("model cannot be nil")
+	}
+
+	// Create user with basic info
+	user, err := user_management.NewUser(model.ID, model.Email, model.Username, model.DisplayName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	// Set status (this updates updatedAt, will correct later)
+	status, err := user_management.NewUserStatus(model.Status)
+	if err != nil {
+		return nil, fmt.Errorf("invalid status: %w", err)
+	}
+	user.SetStatus(status)
+
+	// Set roles
+	if roles == nil {
+		roles = make([]*user_management.UserRole, 0)
+	}
+	user.SetRoles(roles)
+
+	// Set admin
+	user.SetIsAdmin(model.IsAdmin)
+
+	// Set timestamps (correct the updatedAt set by SetStatus)
+	user.SetCreatedAt(model.CreatedAt)
+	user.SetUpdatedAt(model.UpdatedAt)
+	user.SetLastLoginAt(model.LastLoginAt)
+	user.SetBlockedReason(model.BlockedReason)
+	if model.DeletedAt.Valid {
+		deletedAt := model.DeletedAt.Time
+		user.SetDeletedAt(&deletedAt)
+	}
+
+	// Set metadata
+	var metadata map[string]interface{}
+	if model.Metadata != "" {
+		if err := json.Unmarshal([]byte(model.Metadata), &metadata); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+		}
+	} else {
+		metadata = make(map[string]interface{})
+	}
+	user.SetAllMetadata(metadata)
+
+	// Set OAuth fields
+	user.SetOAuthProvider(model.OAuthProvider)
+	user.SetOAuthID(model.OAuthID)
+
+	return user, nil
+}
+
+// toDomain converts user rows, loading their roles in batches
+func (r *GormUserRepository) toDomain(ctx context.Context, models []UserModel) ([]*user_management.User, error) {
+	userIDs := make([]string, 0, len(models))
+	for _, model := range models {
+		userIDs = append(userIDs, model.ID)
+	}
+	roles, err := loadUserRoles(conn(ctx, r.db), userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]*user_management.User, 0, len(models))
+	for i := range models {
+		user, err := modelToDomain(&models[i], roles[models[i].ID])
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert model to domain: %w", err)
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+// toDomainOne converts a single user row
+func (r *GormUserRepository) toDomainOne(ctx context.Context, model *UserModel) (*user_management.User, error) {
+	users, err := r.toDomain(ctx, []UserModel{*model})
+	if err != nil {
+		return nil, err
+	}
+	return users[0], nil
+}
+
+// Implement UserReader
+
+func (r *GormUserRepository) GetByID(ctx context.Context, userID string) (*user_management.User, error) {
+	var model UserModel
+	if err := conn(ctx, r.db).Where("id = ?", userID).First(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, user_management.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user by ID: %w", err)
+	}
+	return r.toDomainOne(ctx, &model)
+}
+
+// userIDBatchSize keeps IN lists below the bound-parameter limits of the
+// supported databases (999 on older SQLite builds)
+const userIDBatchSize = 500
+
+func (r *GormUserRepository) GetByIDs(ctx context.Context, userIDs []string) (map[string]*user_management.User, error) {
+	users := make(map[string]*user_management.User, len(userIDs))
+
+	// Look up each ID once
+	seen := make(map[string]bool, len(userIDs))
+	ids := make([]string, 0, len(userIDs))
+	for _, userID := range userIDs {
+		if userID == "" || seen[userID] {
+			continue
+		}
+		seen[userID] = true
+		ids = append(ids, userID)
+	}
+
+	for start := 0; start < len(ids); start += userIDBatchSize {
+		end := min(start+userIDBatchSize, len(ids))
+		var models []UserModel
+		if err := conn(ctx, r.db).Where("id IN ?", ids[start:end]).Find(&models).Error; err != nil {
+			return nil, fmt.Errorf("failed to get users by IDs: %w", err)
+		}
+		batch, err := r.toDomain(ctx, models)
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range batch {
+			users[user.GetID()] = user
+		}
+	}
+	return users, nil
+}
+
+func (r *GormUserRepository) ExistsByID(ctx context.Context, userID string) (bool, error) {
+	var count int64
+	if err := conn(ctx, r.db).Model(&UserModel{}).Where("id = ?", userID).Limit(1).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check user existence: %w", err)
+	}
+	return count > 0, nil
+}
+
+func (r *GormUserRepository) GetByEmail(ctx context.Context, email string) (*user_management.User, error) {
+	var model UserModel
+	if err := conn(ctx, r.db).Where("email = ?", email).First(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, user_management.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user by email: %w", err)
+	}
+	return r.toDomainOne(ctx, &model)
+}
+
+func (r *GormUserRepository) GetByUsername(ctx context.Context, username string) (*user_management.User, error) {
+	var model UserModel
+	if err := conn(ctx, r.db).Where("username = ?", username).First(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, user_management.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user by username: %w", err)
+	}
+	return r.toDomainOne(ctx, &model)
+}
+
+func (r *GormUserRepository) GetByOAuthID(ctx context.Context, provider, oauthID string) (*user_management.User, error) {
+	var model UserModel
+	if err := conn(ctx, r.db).Where("oauth_provider = ? AND oauth_id = ?", provider, oauthID).First(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, user_management.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user by OAuth ID: %w", err)
+	}
+	return r.toDomainOne(ctx, &model)
+}
+
+func (r *GormUserRepository) Search(ctx context.Context, query string, filter *user_management.UserSearchFilter) (*user_management.UserSearchResult, error) {
+	db := conn(ctx, r.db).Model(&UserModel{})
+
+	// Apply filters; columns are qualified as the role filter joins authz_user_roles.
+	// The DELETED status lists the soft-deleted users.
+	if filter.Status != nil && *filter.Status == user_management.StatusDeleted {
+		db = db.Unscoped().Where("authz_users.deleted_at IS NOT NULL")
+	} else if filter.Status != nil {
+		db = db.Where("authz_users.status = ?", string(*filter.Status))
+	}
+	if filter.IsAdmin != nil {
+		db = db.Where("authz_users.is_admin = ?", *filter.IsAdmin)
+	}
+	if filter.RoleName != nil {
+		db = joinRole(db, *filter.RoleName)
+	}
+	if filter.CreatedAfter != nil {
+		db = db.Where("authz_users.created_at > ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		db = db.Where("authz_users.created_at < ?", *filter.CreatedBefore)
+	}
+	if filter.LastLoginAfter != nil {
+		db = db.Where("authz_users.last_login_at > ?", *filter.LastLoginAfter)
+	}
+
+	// Search query on username, displayName, email
+	if query != "" {
+		db = db.Where("authz_users.username LIKE ? OR authz_users.display_name LIKE ? OR authz_users.email LIKE ?", "%"+query+"%", "%"+query+"%", "%"+query+"%")
+	}
+
+	// Count total
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	// Apply pagination
+	limit := 20 // default
+	offset := 0
+	if filter.Limit > 0 {
+		limit = filter.Limit
+	}
+	if filter.Offset > 0 {
+		offset = filter.Offset
+	}
+	db = db.Limit(limit).Offset(offset)
+
+	// Fetch models
+	var models []UserModel
+	if err := db.Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to search users: %w", err)
+	}
+
+	// Convert to domain
+	users, err := r.toDomain(ctx, models)
+	if err != nil {
+		return nil, err
+	}
+
+	hasMore := int64(len(users)) == int64(limit) && int64(offset+len(users)) < total
+
+	return &user_management.UserSearchResult{
+		Users:   users,
+		Total:   total,
+		HasMore: hasMore,
+		Limit:   limit,
+		Offset:  offset,
+	}, nil
+}
+
+func (r *GormUserRepository) ListAll(ctx context.Context, limit, offset int) ([]*user_management.User, int64, error) {
+	var total int64
+	if err := conn(ctx, r.db).Model(&UserModel{}).Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	var models []UserModel
+	if err := conn(ctx, r.db).Limit(limit).Offset(offset).Find(&models).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	users, err := r.toDomain(ctx, models)
+	if err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+func (r *GormUserRepository) GetAdmins(ctx context.Context) ([]*user_management.User, error) {
+	var models []UserModel
+	if err := conn(ctx, r.db).Where("is_admin = ?", true).Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to get admins: %w", err)
+	}
+
+	return r.toDomain(ctx, models)
+}
+
+func (r *GormUserRepository) GetByRole(ctx context.Context, roleName string) ([]*user_management.User, error) {
+	var models []UserModel
+	if err := joinRole(conn(ctx, r.db).Model(&UserModel{}), roleName).Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to get users by role: %w", err)
+	}
+
+	return r.toDomain(ctx, models)
+}
+
+func (r *GormUserRepository) GetByStatus(ctx context.Context, status user_management.UserStatus) ([]*user_management.User, error) {
+	var models []UserModel
+	if err := conn(ctx, r.db).Where("status = ?", string(status)).Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to get users by status: %w", err)
+	}
+
+	return r.toDomain(ctx, models)
+}
+
+// Implement UserWriter
+
+func (r *GormUserRepository) Create(ctx context.Context, user *user_management.User) (*user_management.User, error) {
+	model, err := domainToModel(user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert domain to model: %w", err)
+	}
+
+	err = conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(model).Error; err != nil {
+			return err
+		}
+		return replaceUserRoles(tx, model.ID, user.GetRoles())
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", r.translateWriteError(err))
+	}
+
+	return r.toDomainOne(ctx, model)
+}
+
+func (r *GormUserRepository) Update(ctx context.Context, user *user_management.User) (*user_management.User, error) {
+	model, err := domainToModel(user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert domain to model: %w", err)
+	}
+
+	err = conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(model).Error; err != nil {
+			return err
+		}
+		return replaceUserRoles(tx, model.ID, user.GetRoles())
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", r.translateWriteError(err))
+	}
+
+	return r.toDomainOne(ctx, model)
+}
+
+func (r *GormUserRepository) Delete(ctx context.Context, userID string) error {
+	// Role rows are kept, so a restored user gets its roles back
+	result := conn(ctx, r.db).Where("id = ?", userID).Delete(&UserModel{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete user: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return user_management.ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *GormUserRepository) Restore(ctx context.Context, userID string) (*user_management.User, error) {
+	result := conn(ctx, r.db).Unscoped().Model(&UserModel{}).
+		Where("id = ? AND deleted_at IS NOT NULL", userID).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to restore user: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		exists, err := r.ExistsByID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, apperrors.Newf(apperrors.ErrConflict, "user %s is not deleted", userID)
+		}
+		return nil, user_management.ErrUserNotFound
+	}
+	return r.GetByID(ctx, userID)
+}
+
+func (r *GormUserRepository) PurgeDeletedBefore(ctx context.Context, before time.Time) ([]*user_management.User, error) {
+	var models []UserModel
+	if err := conn(ctx, r.db).Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+		Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to find deleted users: %w", err)
+	}
+	if len(models) == 0 {
+		return nil, nil
+	}
+	users, err := r.toDomain(ctx, models)
+	if err != nil {
+		return nil, err
+	}
+
+	userIDs := make([]string, 0, len(models))
+	for _, model := range models {
+		userIDs = append(userIDs, model.ID)
+	}
+	err = conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		for start := 0; start < len(userIDs); start += userIDBatchSize {
+			batch := userIDs[start:min(start+userIDBatchSize, len(userIDs))]
+			// Users restored since they were read are left alone
+			deleted := tx.Unscoped().Model(&UserModel{}).Select("id").Where("id IN ? AND deleted_at IS NOT NULL", batch)
+			if err := tx.Where("user_id IN (?)", deleted).Delete(&UserRoleModel{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Where("id IN ? AND deleted_at IS NOT NULL", batch).Delete(&UserModel{}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to purge deleted users: %w", err)
+	}
+	return users, nil
+}
+
+func (r *GormUserRepository) Block(ctx context.Context, userID string, reason string) (*user_management.User, error) {
+	return r.modify(ctx, userID, func(user *user_management.User) error {
+		return user.Block(reason)
+	})
+}
+
+func (r *GormUserRepository) Unblock(ctx context.Context, userID string) (*user_management.User, error) {
+	return r.modify(ctx, userID, func(user *user_management.User) error {
+		return user.Unblock()
+	})
+}
+
+func (r *GormUserRepository) AssignRole(ctx context.Context, userID string, role *user_management.UserRole) (*user_management.User, error) {
+	return r.modify(ctx, userID, func(user *user_management.User) error {
+		return user.AssignRole(role)
+	})
+}
+
+func (r *GormUserRepository) RemoveRole(ctx context.Context, userID string, role *user_management.UserRole) (*user_management.User, error) {
+	return r.modify(ctx, userID, func(user *user_management.User) error {
+		return user.RemoveRole(role)
+	})
+}
+
+func (r *GormUserRepository) PromoteToAdmin(ctx context.Context, userID string) (*user_management.User, error) {
+	return r.modify(ctx, userID, func(user *user_management.User) error {
+		return user.PromoteToAdmin()
+	})
+}
+
+func (r *GormUserRepository) DemoteFromAdmin(ctx context.Context, userID string) (*user_management.User, error) {
+	return r.modify(ctx, userID, func(user *user_management.User) error {
+		return user.DemoteFromAdmin()
+	})
+}
+
+// modify loads a user, applies change and saves the result in one
+// transaction. The row is locked for the duration where the database
+// supports it, so concurrent changes to the same user cannot overwrite
+// each other.
+func (r *GormUserRepository) modify(ctx context.Context, userID string, change func(user *user_management.User) error) (*user_management.User, error) {
+	var updated *user_management.User
+	err := NewTransactionManager(r.db).WithTransaction(ctx, func(ctx context.Context) error {
+		var model UserModel
+		err := conn(ctx, r.db).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", userID).First(&model).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return user_management.ErrUserNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get user by ID: %w", err)
+		}
+		user, err := r.toDomainOne(ctx, &model)
+		if err != nil {
+			return err
+		}
+		if err := change(user); err != nil {
+			return err
+		}
+		updated, err = r.Update(ctx, user)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// translateWriteError maps constraint violations of a user write to the
+// user_management conflict errors, keeping the database error wrapped
+func (r *GormUserRepository) translateWriteError(err error) error {
+	err = translateDBError(r.db, err)
+	switch {
+	case errors.Is(err, ErrUniqueViolation):
+		constraint := strings.ToLower(violatedConstraint(err))
+		switch {
+		case strings.Contains(constraint, "email"):
+			return fmt.Errorf("%w: %w", user_management.ErrDuplicateEmail, err)
+		case strings.Contains(constraint, "username"):
+			return fmt.Errorf("%w: %w", user_management.ErrDuplicateUsername, err)
+		}
+		return fmt.Errorf("%w: %w", user_management.ErrUserConflict, err)
+	case errors.Is(err, ErrForeignKeyViolation):
+		return fmt.Errorf("%w: %w", user_management.ErrUserConflict, err)
+	}
+	return err
+}
+
+// joinRole limits db to the users holding the role with exactly the given
+// name. The join matches at most one row per user, as (user_id, role_name)
+// is the key of authz_user_roles.
+func joinRole(db *gorm.DB, roleName string) *gorm.DB {
+	return db.Joins("JOIN authz_user_roles ON authz_user_roles.user_id = authz_users.id AND authz_user_roles.role_name = ?", roleName)
+}
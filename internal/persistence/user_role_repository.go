@@ -0,0 +1,250 @@
+package persistence
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	user_management "github.com/aruncs31s/azf/domain/user_management/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RoleModel is a row of authz_roles, the roles users can hold. Rows are
+// added when a role is first assigned and keep its permissions.
+type RoleModel struct {
+	Name        string `gorm:"primaryKey;type:varchar(100)"`
+	Permissions string `gorm:"type:text"` // JSON
+	CreatedAt   time.Time
+}
+
+func (RoleModel) TableName() string {
+	return "authz_roles"
+}
+
+// UserRoleModel is a row of the normalized user-role join table, which user
+// roles are read from. The roles JSON column of authz_users is still written
+// alongside it, so instances of older versions keep reading current roles.
+type UserRoleModel struct {
+	UserID    string `gorm:"primaryKey;type:varchar(36)"`
+	RoleName  string `gorm:"primaryKey;type:varchar(100);index"`
+	CreatedAt time.Time
+}
+
+func (UserRoleModel) TableName() string {
+	return "authz_user_roles"
+}
+
+// replaceUserRoles makes the join table rows of a user match its roles
+func replaceUserRoles(tx *gorm.DB, userID string, roles []*user_management.UserRole) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&UserRoleModel{}).Error; err != nil {
+		return fmt.Errorf("failed to clear user roles: %w", err)
+	}
+	if len(roles) == 0 {
+		return nil
+	}
+	if err := ensureRoles(tx, roleRows(roles)); err != nil {
+		return err
+	}
+	names := make([]string, 0, len(roles))
+	for _, role := range roles {
+		names = append(names, role.Name())
+	}
+	rows := userRoleRows(userID, names)
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error; err != nil {
+		return fmt.Errorf("failed to save user roles: %w", err)
+	}
+	return nil
+}
+
+// ensureRoles adds the roles that are not in authz_roles yet
+func ensureRoles(tx *gorm.DB, roles []RoleModel) error {
+	seen := make(map[string]bool, len(roles))
+	rows := make([]RoleModel, 0, len(roles))
+	for _, role := range roles {
+		if !seen[role.Name] {
+			seen[role.Name] = true
+			rows = append(rows, role)
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error; err != nil {
+		return fmt.Errorf("failed to save roles: %w", err)
+	}
+	return nil
+}
+
+// roleRows returns the authz_roles rows of roles
+func roleRows(roles []*user_management.UserRole) []RoleModel {
+	now := time.Now()
+	rows := make([]RoleModel, 0, len(roles))
+	for _, role := range roles {
+		permissions, _ := json.Marshal(role.Permissions())
+		rows = append(rows, RoleModel{Name: role.Name(), Permissions: string(permissions), CreatedAt: now})
+	}
+	return rows
+}
+
+// loadUserRoles returns the roles of the given users, with the permissions
+// recorded in authz_roles
+func loadUserRoles(db *gorm.DB, userIDs []string) (map[string][]*user_management.UserRole, error) {
+	roles := make(map[string][]*user_management.UserRole, len(userIDs))
+	for start := 0; start < len(userIDs); start += userIDBatchSize {
+		end := min(start+userIDBatchSize, len(userIDs))
+		var rows []struct {
+			UserID      string
+			RoleName    string
+			Permissions *string
+		}
+		err := db.Model(&UserRoleModel{}).
+			Select("authz_user_roles.user_id, authz_user_roles.role_name, authz_roles.permissions").
+			Joins("LEFT JOIN authz_roles ON authz_roles.name = authz_user_roles.role_name").
+			Where("authz_user_roles.user_id IN ?", userIDs[start:end]).
+			Order("authz_user_roles.created_at, authz_user_roles.role_name").
+			Scan(&rows).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to load user roles: %w", err)
+		}
+		for _, row := range rows {
+			var permissions []string
+			if row.Permissions != nil && *row.Permissions != "" {
+				if err := json.Unmarshal([]byte(*row.Permissions), &permissions); err != nil {
+					return nil, fmt.Errorf("failed to unmarshal permissions of role %s: %w", row.RoleName, err)
+				}
+			}
+			role, err := user_management.NewUserRole(row.RoleName, permissions)
+			if err != nil {
+				return nil, fmt.Errorf("invalid role data: %w", err)
+			}
+			roles[row.UserID] = append(roles[row.UserID], role)
+		}
+	}
+	return roles, nil
+}
+
+func userRoleRows(userID string, roleNames []string) []UserRoleModel {
+	now := time.Now()
+	rows := make([]UserRoleModel, 0, len(roleNames))
+	for _, roleName := range roleNames {
+		rows = append(rows, UserRoleModel{UserID: userID, RoleName: roleName, CreatedAt: now})
+	}
+	return rows
+}
+
+// rolesFromJSON returns the roles stored in a roles JSON column
+func rolesFromJSON(rolesJSON string) ([]RoleData, error) {
+	if rolesJSON == "" {
+		return nil, nil
+	}
+	var rolesData []RoleData
+	if err := json.Unmarshal([]byte(rolesJSON), &rolesData); err != nil {
+		return nil, err
+	}
+	roles := make([]RoleData, 0, len(rolesData))
+	for _, rd := range rolesData {
+		if rd.Name != "" {
+			roles = append(roles, rd)
+		}
+	}
+	return roles, nil
+}
+
+// BackfillUserRoles migrates the roles JSON column of every user into
+// authz_roles and the join table, and adds the roles of join table rows
+// written before authz_roles existed. Existing rows are kept, so it is safe
+// to run on every start.
+func BackfillUserRoles(db *gorm.DB) error {
+	if db == nil {
+		return fmt.Errorf("BackfillUserRoles: db is nil")
+	}
+
+	var models []UserModel
+	result := db.Select("id", "roles").FindInBatches(&models, userIDBatchSize, func(tx *gorm.DB, batch int) error {
+		roles := make([]RoleModel, 0)
+		rows := make([]UserRoleModel, 0, len(models))
+		for _, model := range models {
+			rolesData, err := rolesFromJSON(model.Roles)
+			if err != nil {
+				// Unreadable columns are left alone; their users keep the
+				// roles already in the join table
+				continue
+			}
+			names := make([]string, 0, len(rolesData))
+			for _, rd := range rolesData {
+				permissions, _ := json.Marshal(rd.Permissions)
+				roles = append(roles, RoleModel{Name: rd.Name, Permissions: string(permissions), CreatedAt: time.Now()})
+				names = append(names, rd.Name)
+			}
+			rows = append(rows, userRoleRows(model.ID, names)...)
+		}
+		if err := ensureRoles(db, roles); err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error
+	})
+	if result.Error != nil {
+		return fmt.Errorf("failed to backfill user roles: %w", result.Error)
+	}
+
+	var orphaned []string
+	err := db.Model(&UserRoleModel{}).
+		Distinct("role_name").
+		Where("role_name NOT IN (?)", db.Model(&RoleModel{}).Select("name")).
+		Pluck("role_name", &orphaned).Error
+	if err != nil {
+		return fmt.Errorf("failed to backfill roles: %w", err)
+	}
+	roles := make([]RoleModel, 0, len(orphaned))
+	for _, name := range orphaned {
+		roles = append(roles, RoleModel{Name: name, Permissions: "null", CreatedAt: time.Now()})
+	}
+	return ensureRoles(db, roles)
+}
+
+func (r *GormUserRepository) CountByRole(ctx context.Context) (map[string]int64, error) {
+	var rows []struct {
+		RoleName string
+		Count    int64
+	}
+	if err := activeUserRoles(conn(ctx, r.db)).
+		Select("authz_user_roles.role_name, COUNT(*) AS count").
+		Group("authz_user_roles.role_name").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to count users by role: %w", err)
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.RoleName] = row.Count
+	}
+	return counts, nil
+}
+
+func (r *GormUserRepository) ListRoleAssignments(ctx context.Context) ([]user_management.RoleAssignment, error) {
+	var models []UserRoleModel
+	if err := activeUserRoles(conn(ctx, r.db)).Order("authz_user_roles.user_id, authz_user_roles.role_name").Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to list role assignments: %w", err)
+	}
+
+	assignments := make([]user_management.RoleAssignment, 0, len(models))
+	for _, model := range models {
+		assignments = append(assignments, user_management.RoleAssignment{
+			UserID:   model.UserID,
+			RoleName: model.RoleName,
+		})
+	}
+	return assignments, nil
+}
+
+// activeUserRoles limits the join table to the rows of users that are not
+// soft-deleted. The rows of deleted users are kept for a restore.
+func activeUserRoles(db *gorm.DB) *gorm.DB {
+	return db.Model(&UserRoleModel{}).
+		Joins("JOIN authz_users ON authz_users.id = authz_user_roles.user_id AND authz_users.deleted_at IS NULL")
+}
@@ -0,0 +1,118 @@
+package persistence
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aruncs31s/azf/domain/api_usage"
+	"github.com/aruncs31s/azf/domain/repository"
+	"gorm.io/gorm"
+)
+
+type webPushSubscriptionRepository struct {
+	db *gorm.DB
+}
+
+// NewWebPushSubscriptionRepository creates a new web push subscription repository
+func NewWebPushSubscriptionRepository(db *gorm.DB) repository.WebPushSubscriptionRepository {
+	return &webPushSubscriptionRepository{db: db}
+}
+
+// Save inserts the subscription or replaces the existing one for the same
+// endpoint, so re-subscribing from the same browser keeps a single row
+func (r *webPushSubscriptionRepository) Save(subscription *api_usage.WebPushSubscription) (*api_usage.WebPushSubscription, error) {
+	now := time.Now()
+
+	var existing api_usage.WebPushSubscription
+	err := r.db.Where("endpoint = ?", subscription.Endpoint).First(&existing).Error
+	switch {
+	case err == nil:
+		subscription.ID = existing.ID
+		subscription.CreatedAt = existing.CreatedAt
+		subscription.LastPushedAt = existing.LastPushedAt
+	case err != gorm.ErrRecordNotFound:
+		return nil, fmt.Errorf("failed to look up web push subscription: %w", err)
+	case subscription.CreatedAt.IsZero():
+		subscription.CreatedAt = now
+	}
+	subscription.UpdatedAt = now
+
+	if err := r.db.Save(subscription).Error; err != nil {
+		return nil, fmt.Errorf("failed to save web push subscription: %w", err)
+	}
+	return subscription, nil
+}
+
+func (r *webPushSubscriptionRepository) FindByID(id string) (*api_usage.WebPushSubscription, error) {
+	var subscription api_usage.WebPushSubscription
+	if err := r.db.Where("id = ?", id).First(&subscription).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+func (r *webPushSubscriptionRepository) FindByAdmin(adminUsername string) (*[]api_usage.WebPushSubscription, error) {
+	var subscriptions []api_usage.WebPushSubscription
+	if err := r.db.Where("admin_username = ?", adminUsername).Order("created_at ASC").Find(&subscriptions).Error; err != nil {
+		return nil, err
+	}
+	return &subscriptions, nil
+}
+
+func (r *webPushSubscriptionRepository) FindAll() (*[]api_usage.WebPushSubscription, error) {
+	var subscriptions []api_usage.WebPushSubscription
+	if err := r.db.Order("created_at ASC").Find(&subscriptions).Error; err != nil {
+		return nil, err
+	}
+	return &subscriptions, nil
+}
+
+func (r *webPushSubscriptionRepository) MarkPushed(id string, at time.Time) error {
+	if err := r.db.Model(&api_usage.WebPushSubscription{}).Where("id = ?", id).Update("last_pushed_at", at).Error; err != nil {
+		return fmt.Errorf("failed to update web push subscription: %w", err)
+	}
+	return nil
+}
+
+func (r *webPushSubscriptionRepository) Delete(id string) error {
+	if err := r.db.Where("id = ?", id).Delete(&api_usage.WebPushSubscription{}).Error; err != nil {
+		return fmt.Errorf("failed to delete web push subscription: %w", err)
+	}
+	return nil
+}
+
+func (r *webPushSubscriptionRepository) DeleteByEndpoint(endpoint string) error {
+	if err := r.db.Where("endpoint = ?", endpoint).Delete(&api_usage.WebPushSubscription{}).Error; err != nil {
+		return fmt.Errorf("failed to delete web push subscription: %w", err)
+	}
+	return nil
+}
+
+func (r *webPushSubscriptionRepository) LoadOrCreateVAPIDKeys(generate func() (*api_usage.WebPushVAPIDKeys, error)) (*api_usage.WebPushVAPIDKeys, error) {
+	var keys api_usage.WebPushVAPIDKeys
+	err := r.db.Order("id ASC").First(&keys).Error
+	if err == nil {
+		return &keys, nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("failed to load VAPID keys: %w", err)
+	}
+
+	generated, err := generate()
+	if err != nil {
+		return nil, err
+	}
+	generated.ID = 1
+	generated.CreatedAt = time.Now()
+	if err := r.db.Create(generated).Error; err != nil {
+		// Another instance stored a key pair first; use that one
+		if loadErr := r.db.Order("id ASC").First(&keys).Error; loadErr == nil {
+			return &keys, nil
+		}
+		return nil, fmt.Errorf("failed to store VAPID keys: %w", err)
+	}
+	return generated, nil
+}
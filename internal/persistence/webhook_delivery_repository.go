@@ -0,0 +1,108 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	authorization_audit "github.com/aruncs31s/azf/domain/authorization_audit/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WebhookDeliveryModel is the GORM model for webhook delivery attempts
+type WebhookDeliveryModel struct {
+	ID             string `gorm:"primaryKey;type:varchar(36)"`
+	EventID        string `gorm:"index;type:varchar(36)"`
+	SubscriptionID string `gorm:"index:idx_webhook_delivery_subscription;type:varchar(36)"`
+	Attempt        int
+	URL            string `gorm:"type:varchar(2048)"`
+	StatusCode     int
+	Success        bool
+	Error          string `gorm:"type:text"`
+	ResponseBody   string `gorm:"type:text"`
+	DurationMs     int64
+	AttemptedAt    time.Time `gorm:"index:idx_webhook_delivery_subscription"`
+	Redelivery     bool
+}
+
+func (WebhookDeliveryModel) TableName() string {
+	return "authz_webhook_deliveries"
+}
+
+type webhookDeliveryRepository struct {
+	db *gorm.DB
+}
+
+// NewWebhookDeliveryRepository creates a new webhook delivery attempt repository
+func NewWebhookDeliveryRepository(db *gorm.DB) authorization_audit.WebhookDeliveryRepository {
+	return &webhookDeliveryRepository{db: db}
+}
+
+func (r *webhookDeliveryRepository) Record(ctx context.Context, attempt *authorization_audit.WebhookDeliveryAttempt) error {
+	if attempt.ID == "" {
+		attempt.ID = uuid.NewString()
+	}
+	if attempt.AttemptedAt.IsZero() {
+		attempt.AttemptedAt = time.Now()
+	}
+	model := &WebhookDeliveryModel{
+		ID:             attempt.ID,
+		EventID:        attempt.EventID,
+		SubscriptionID: attempt.SubscriptionID,
+		Attempt:        attempt.Attempt,
+		URL:            attempt.URL,
+		StatusCode:     attempt.StatusCode,
+		Success:        attempt.Success,
+		Error:          attempt.Error,
+		ResponseBody:   attempt.ResponseBody,
+		DurationMs:     attempt.Duration.Milliseconds(),
+		AttemptedAt:    attempt.AttemptedAt,
+		Redelivery:     attempt.Redelivery,
+	}
+	if err := conn(ctx, r.db).Create(model).Error; err != nil {
+		return fmt.Errorf("failed to record webhook delivery: %w", err)
+	}
+	return nil
+}
+
+func (r *webhookDeliveryRepository) FindByEventID(ctx context.Context, eventID string) ([]*authorization_audit.WebhookDeliveryAttempt, error) {
+	var models []WebhookDeliveryModel
+	if err := conn(ctx, r.db).Where("event_id = ?", eventID).Order("attempted_at ASC").Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to find webhook deliveries: %w", err)
+	}
+	return webhookDeliveriesToDomain(models), nil
+}
+
+func (r *webhookDeliveryRepository) FindBySubscriptionID(ctx context.Context, subscriptionID string, limit int) ([]*authorization_audit.WebhookDeliveryAttempt, error) {
+	var models []WebhookDeliveryModel
+	query := conn(ctx, r.db).Where("subscription_id = ?", subscriptionID).Order("attempted_at DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to find webhook deliveries: %w", err)
+	}
+	return webhookDeliveriesToDomain(models), nil
+}
+
+func webhookDeliveriesToDomain(models []WebhookDeliveryModel) []*authorization_audit.WebhookDeliveryAttempt {
+	attempts := make([]*authorization_audit.WebhookDeliveryAttempt, 0, len(models))
+	for _, model := range models {
+		attempts = append(attempts, &authorization_audit.WebhookDeliveryAttempt{
+			ID:             model.ID,
+			EventID:        model.EventID,
+			SubscriptionID: model.SubscriptionID,
+			Attempt:        model.Attempt,
+			URL:            model.URL,
+			StatusCode:     model.StatusCode,
+			Success:        model.Success,
+			Error:          model.Error,
+			ResponseBody:   model.ResponseBody,
+			Duration:       time.Duration(model.DurationMs) * time.Millisecond,
+			AttemptedAt:    model.AttemptedAt,
+			Redelivery:     model.Redelivery,
+		})
+	}
+	return attempts
+}
@@ -0,0 +1,249 @@
+package persistence
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	authorization_audit "github.com/aruncs31s/azf/domain/authorization_audit/model"
+	"gorm.io/gorm"
+)
+
+// WebhookEventModel is the GORM model for webhook events
+type WebhookEventModel struct {
+	ID          string    `gorm:"primaryKey;type:varchar(36)"`
+	EventType   string    `gorm:"index;type:varchar(50)"`
+	AuditLogID  string    `gorm:"index;type:varchar(36)"`
+	Payload     string    `gorm:"type:text"` // JSON
+	Timestamp   time.Time `gorm:"index"`
+	Status      string    `gorm:"index;type:varchar(20)"`
+	DeliveryURL string    `gorm:"type:varchar(2048)"`
+	RetryCount  int
+	MaxRetries  int
+	LastError   string `gorm:"type:text"`
+	LastAttempt *time.Time
+	NextRetry   *time.Time `gorm:"index"`
+	Metadata    string     `gorm:"type:text"` // JSON
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (WebhookEventModel) TableName() string {
+	return "authz_webhook_events"
+}
+
+type webhookEventRepository struct {
+	db *gorm.DB
+}
+
+// NewWebhookEventRepository creates a new webhook event repository
+func NewWebhookEventRepository(db *gorm.DB) authorization_audit.WebhookEventRepository {
+	return &webhookEventRepository{db: db}
+}
+
+func webhookEventToModel(event *authorization_audit.WebhookEvent) (*WebhookEventModel, error) {
+	if event == nil {
+		return nil, errors.New("webhook event cannot be nil")
+	}
+	payload, err := json.Marshal(event.Payload())
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+	metadata, err := json.Marshal(event.Metadata())
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal webhook metadata: %w", err)
+	}
+
+	return &WebhookEventModel{
+		ID:          event.ID(),
+		EventType:   event.EventType().Value(),
+		AuditLogID:  event.AuditLogID(),
+		Payload:     string(payload),
+		Timestamp:   event.Timestamp(),
+		Status:      event.Status().Value(),
+		DeliveryURL: event.DeliveryURL(),
+		RetryCount:  event.RetryCount(),
+		MaxRetries:  event.MaxRetries(),
+		LastError:   event.LastError(),
+		LastAttempt: event.LastAttempt(),
+		NextRetry:   event.NextRetry(),
+		Metadata:    string(metadata),
+	}, nil
+}
+
+func webhookEventToDomain(model *WebhookEventModel) (*authorization_audit.WebhookEvent, error) {
+	var payload, metadata map[string]interface{}
+	if model.Payload != "" {
+		if err := json.Unmarshal([]byte(model.Payload), &payload); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal webhook payload: %w", err)
+		}
+	}
+	if model.Metadata != "" {
+		if err := json.Unmarshal([]byte(model.Metadata), &metadata); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal webhook metadata: %w", err)
+		}
+	}
+
+	event, err := authorization_audit.RestoreWebhookEvent(authorization_audit.WebhookEventState{
+		ID:          model.ID,
+		EventType:   model.EventType,
+		AuditLogID:  model.AuditLogID,
+		Payload:     payload,
+		Timestamp:   model.Timestamp,
+		Status:      model.Status,
+		DeliveryURL: model.DeliveryURL,
+		RetryCount:  model.RetryCount,
+		MaxRetries:  model.MaxRetries,
+		LastError:   model.LastError,
+		LastAttempt: model.LastAttempt,
+		NextRetry:   model.NextRetry,
+		Metadata:    metadata,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook event %s: %w", model.ID, err)
+	}
+	return event, nil
+}
+
+func webhookEventsToDomain(models []WebhookEventModel) ([]*authorization_audit.WebhookEvent, error) {
+	events := make([]*authorization_audit.WebhookEvent, 0, len(models))
+	for i := range models {
+		event, err := webhookEventToDomain(&models[i])
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+func (r *webhookEventRepository) find(ctx context.Context, query func(db *gorm.DB) *gorm.DB) ([]*authorization_audit.WebhookEvent, error) {
+	var models []WebhookEventModel
+	if err := query(conn(ctx, r.db)).Order("timestamp ASC").Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to find webhook events: %w", err)
+	}
+	return webhookEventsToDomain(models)
+}
+
+func (r *webhookEventRepository) FindByID(ctx context.Context, id string) (*authorization_audit.WebhookEvent, error) {
+	var model WebhookEventModel
+	if err := conn(ctx, r.db).Where("id = ?", id).First(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, authorization_audit.ErrWebhookEventNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook event: %w", err)
+	}
+	return webhookEventToDomain(&model)
+}
+
+func (r *webhookEventRepository) FindByAuditLogID(ctx context.Context, auditLogID string) ([]*authorization_audit.WebhookEvent, error) {
+	return r.find(ctx, func(db *gorm.DB) *gorm.DB { return db.Where("audit_log_id = ?", auditLogID) })
+}
+
+func (r *webhookEventRepository) FindByEventType(ctx context.Context, eventType string) ([]*authorization_audit.WebhookEvent, error) {
+	return r.find(ctx, func(db *gorm.DB) *gorm.DB { return db.Where("event_type = ?", eventType) })
+}
+
+func (r *webhookEventRepository) FindByStatus(ctx context.Context, status string) ([]*authorization_audit.WebhookEvent, error) {
+	return r.find(ctx, func(db *gorm.DB) *gorm.DB { return db.Where("status = ?", status) })
+}
+
+func (r *webhookEventRepository) FindPending(ctx context.Context) ([]*authorization_audit.WebhookEvent, error) {
+	return r.FindByStatus(ctx, authorization_audit.WebhookStatusPending.Value())
+}
+
+// FindRetryable returns the events waiting for a retry whose backoff has passed
+func (r *webhookEventRepository) FindRetryable(ctx context.Context) ([]*authorization_audit.WebhookEvent, error) {
+	return r.find(ctx, func(db *gorm.DB) *gorm.DB {
+		return db.Where("status = ? AND next_retry <= ?", authorization_audit.WebhookStatusRetrying.Value(), time.Now())
+	})
+}
+
+func (r *webhookEventRepository) FindAll(ctx context.Context) ([]*authorization_audit.WebhookEvent, error) {
+	return r.find(ctx, func(db *gorm.DB) *gorm.DB { return db })
+}
+
+// FindRange pages through the range with a keyset on (timestamp, id), so
+// pages stay stable while new events are added
+func (r *webhookEventRepository) FindRange(ctx context.Context, query authorization_audit.WebhookEventRange) ([]*authorization_audit.WebhookEvent, error) {
+	db := conn(ctx, r.db).Where("delivery_url = ?", query.DeliveryURL)
+	if len(query.EventTypes) > 0 {
+		db = db.Where("event_type IN ?", query.EventTypes)
+	}
+	if !query.Since.IsZero() {
+		db = db.Where("timestamp >= ?", query.Since)
+	}
+	if !query.Until.IsZero() {
+		db = db.Where("timestamp < ?", query.Until)
+	}
+	if query.AfterID != "" {
+		db = db.Where("timestamp > ? OR (timestamp = ? AND id > ?)", query.AfterTimestamp, query.AfterTimestamp, query.AfterID)
+	}
+	if query.Limit > 0 {
+		db = db.Limit(query.Limit)
+	}
+
+	var models []WebhookEventModel
+	if err := db.Order("timestamp ASC, id ASC").Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to find webhook events: %w", err)
+	}
+	return webhookEventsToDomain(models)
+}
+
+func (r *webhookEventRepository) Create(ctx context.Context, event *authorization_audit.WebhookEvent) (*authorization_audit.WebhookEvent, error) {
+	model, err := webhookEventToModel(event)
+	if err != nil {
+		return nil, err
+	}
+	if err := conn(ctx, r.db).Create(model).Error; err != nil {
+		return nil, fmt.Errorf("failed to create webhook event: %w", err)
+	}
+	return event, nil
+}
+
+func (r *webhookEventRepository) Update(ctx context.Context, event *authorization_audit.WebhookEvent) (*authorization_audit.WebhookEvent, error) {
+	model, err := webhookEventToModel(event)
+	if err != nil {
+		return nil, err
+	}
+	result := conn(ctx, r.db).Model(&WebhookEventModel{}).Where("id = ?", model.ID).
+		Select("*").Omit("id", "created_at").Updates(model)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to update webhook event: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, authorization_audit.ErrWebhookEventNotFound
+	}
+	return event, nil
+}
+
+func (r *webhookEventRepository) Delete(ctx context.Context, id string) error {
+	result := conn(ctx, r.db).Where("id = ?", id).Delete(&WebhookEventModel{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete webhook event: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return authorization_audit.ErrWebhookEventNotFound
+	}
+	return nil
+}
+
+func (r *webhookEventRepository) BulkCreate(ctx context.Context, events []*authorization_audit.WebhookEvent) ([]*authorization_audit.WebhookEvent, error) {
+	if len(events) == 0 {
+		return events, nil
+	}
+	models := make([]*WebhookEventModel, 0, len(events))
+	for _, event := range events {
+		model, err := webhookEventToModel(event)
+		if err != nil {
+			return nil, err
+		}
+		models = append(models, model)
+	}
+	if err := conn(ctx, r.db).CreateInBatches(models, 100).Error; err != nil {
+		return nil, fmt.Errorf("failed to create webhook events: %w", err)
+	}
+	return events, nil
+}
@@ -0,0 +1,232 @@
+package persistence
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	authorization_audit "github.com/aruncs31s/azf/domain/authorization_audit/model"
+	"gorm.io/gorm"
+)
+
+// WebhookSubscriptionModel is the GORM model for webhook subscriptions
+type WebhookSubscriptionModel struct {
+	ID         string `gorm:"primaryKey;type:varchar(36)"`
+	Endpoint   string `gorm:"type:varchar(2048)"`
+	EventTypes string `gorm:"type:text"` // JSON
+	Status     string `gorm:"index;type:varchar(20)"`
+	// Secret is kept in plain text because payloads are signed with it
+	Secret       string `gorm:"type:text"`
+	Description  string `gorm:"type:text"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	LastDelivery *time.Time
+	FailureCount int
+	MaxFailures  int
+	Filters      string `gorm:"type:text"` // JSON
+	Headers      string `gorm:"type:text"` // JSON
+	Metadata     string `gorm:"type:text"` // JSON
+	// Transport holds the client key of mutual TLS, kept in plain text like Secret
+	Transport string `gorm:"type:text"` // JSON
+}
+
+func (WebhookSubscriptionModel) TableName() string {
+	return "authz_webhook_subscriptions"
+}
+
+type webhookSubscriptionRepository struct {
+	db *gorm.DB
+}
+
+// NewWebhookSubscriptionRepository creates a new webhook subscription repository
+func NewWebhookSubscriptionRepository(db *gorm.DB) authorization_audit.WebhookSubscriptionRepository {
+	return &webhookSubscriptionRepository{db: db}
+}
+
+func webhookSubscriptionToModel(subscription *authorization_audit.WebhookSubscription) (*WebhookSubscriptionModel, error) {
+	if subscription == nil {
+		return nil, errors.New("webhook subscription cannot be nil")
+	}
+	eventTypes := make([]string, 0, len(subscription.EventTypes()))
+	for _, eventType := range subscription.EventTypes() {
+		eventTypes = append(eventTypes, eventType.Value())
+	}
+
+	model := &WebhookSubscriptionModel{
+		ID:           subscription.ID(),
+		Endpoint:     subscription.Endpoint().Value(),
+		Status:       subscription.Status().Value(),
+		Secret:       subscription.Secret(),
+		Description:  subscription.Description(),
+		CreatedAt:    subscription.CreatedAt(),
+		UpdatedAt:    subscription.UpdatedAt(),
+		LastDelivery: subscription.LastDelivery(),
+		FailureCount: subscription.FailureCount(),
+		MaxFailures:  subscription.MaxFailures(),
+	}
+	fields := []struct {
+		target *string
+		value  interface{}
+		name   string
+	}{
+		{&model.EventTypes, eventTypes, "event types"},
+		{&model.Filters, subscription.Filters(), "filters"},
+		{&model.Headers, subscription.Headers(), "headers"},
+		{&model.Metadata, subscription.Metadata(), "metadata"},
+		{&model.Transport, subscription.Transport(), "transport"},
+	}
+	for _, field := range fields {
+		data, err := json.Marshal(field.value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal webhook subscription %s: %w", field.name, err)
+		}
+		*field.target = string(data)
+	}
+	return model, nil
+}
+
+func webhookSubscriptionToDomain(model *WebhookSubscriptionModel) (*authorization_audit.WebhookSubscription, error) {
+	state := authorization_audit.WebhookSubscriptionState{
+		ID:           model.ID,
+		Endpoint:     model.Endpoint,
+		Status:       model.Status,
+		Secret:       model.Secret,
+		Description:  model.Description,
+		CreatedAt:    model.CreatedAt,
+		UpdatedAt:    model.UpdatedAt,
+		LastDelivery: model.LastDelivery,
+		FailureCount: model.FailureCount,
+		MaxFailures:  model.MaxFailures,
+	}
+	fields := []struct {
+		data   string
+		target interface{}
+		name   string
+	}{
+		{model.EventTypes, &state.EventTypes, "event types"},
+		{model.Filters, &state.Filters, "filters"},
+		{model.Headers, &state.Headers, "headers"},
+		{model.Metadata, &state.Metadata, "metadata"},
+		{model.Transport, &state.Transport, "transport"},
+	}
+	for _, field := range fields {
+		if field.data == "" {
+			continue
+		}
+		if err := json.Unmarshal([]byte(field.data), field.target); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal webhook subscription %s: %w", field.name, err)
+		}
+	}
+
+	subscription, err := authorization_audit.RestoreWebhookSubscription(state)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook subscription %s: %w", model.ID, err)
+	}
+	return subscription, nil
+}
+
+func (r *webhookSubscriptionRepository) find(ctx context.Context, query func(db *gorm.DB) *gorm.DB) ([]*authorization_audit.WebhookSubscription, error) {
+	var models []WebhookSubscriptionModel
+	if err := query(conn(ctx, r.db)).Order("created_at ASC").Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to find webhook subscriptions: %w", err)
+	}
+	subscriptions := make([]*authorization_audit.WebhookSubscription, 0, len(models))
+	for i := range models {
+		subscription, err := webhookSubscriptionToDomain(&models[i])
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+	return subscriptions, nil
+}
+
+func (r *webhookSubscriptionRepository) FindByID(ctx context.Context, id string) (*authorization_audit.WebhookSubscription, error) {
+	var model WebhookSubscriptionModel
+	if err := conn(ctx, r.db).Where("id = ?", id).First(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, authorization_audit.ErrWebhookSubscriptionNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook subscription: %w", err)
+	}
+	return webhookSubscriptionToDomain(&model)
+}
+
+func (r *webhookSubscriptionRepository) FindByEndpoint(ctx context.Context, endpoint string) ([]*authorization_audit.WebhookSubscription, error) {
+	return r.find(ctx, func(db *gorm.DB) *gorm.DB { return db.Where("endpoint = ?", endpoint) })
+}
+
+func (r *webhookSubscriptionRepository) FindByStatus(ctx context.Context, status string) ([]*authorization_audit.WebhookSubscription, error) {
+	return r.find(ctx, func(db *gorm.DB) *gorm.DB { return db.Where("status = ?", status) })
+}
+
+func (r *webhookSubscriptionRepository) FindActive(ctx context.Context) ([]*authorization_audit.WebhookSubscription, error) {
+	return r.FindByStatus(ctx, authorization_audit.SubscriptionStatusActive.Value())
+}
+
+// FindByEventType returns the subscriptions listing eventType, whatever their status
+func (r *webhookSubscriptionRepository) FindByEventType(ctx context.Context, eventType string) ([]*authorization_audit.WebhookSubscription, error) {
+	target, err := authorization_audit.NewWebhookEventType(eventType)
+	if err != nil {
+		return nil, err
+	}
+	// Event types are stored as JSON, so match them after decoding
+	all, err := r.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	subscriptions := make([]*authorization_audit.WebhookSubscription, 0, len(all))
+	for _, subscription := range all {
+		for _, subscribed := range subscription.EventTypes() {
+			if subscribed.Equals(target) {
+				subscriptions = append(subscriptions, subscription)
+				break
+			}
+		}
+	}
+	return subscriptions, nil
+}
+
+func (r *webhookSubscriptionRepository) FindAll(ctx context.Context) ([]*authorization_audit.WebhookSubscription, error) {
+	return r.find(ctx, func(db *gorm.DB) *gorm.DB { return db })
+}
+
+func (r *webhookSubscriptionRepository) Create(ctx context.Context, subscription *authorization_audit.WebhookSubscription) (*authorization_audit.WebhookSubscription, error) {
+	model, err := webhookSubscriptionToModel(subscription)
+	if err != nil {
+		return nil, err
+	}
+	if err := conn(ctx, r.db).Create(model).Error; err != nil {
+		return nil, fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+	return subscription, nil
+}
+
+func (r *webhookSubscriptionRepository) Update(ctx context.Context, subscription *authorization_audit.WebhookSubscription) (*authorization_audit.WebhookSubscription, error) {
+	model, err := webhookSubscriptionToModel(subscription)
+	if err != nil {
+		return nil, err
+	}
+	result := conn(ctx, r.db).Model(&WebhookSubscriptionModel{}).Where("id = ?", model.ID).
+		Select("*").Omit("id", "created_at").Updates(model)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to update webhook subscription: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, authorization_audit.ErrWebhookSubscriptionNotFound
+	}
+	return subscription, nil
+}
+
+func (r *webhookSubscriptionRepository) Delete(ctx context.Context, id string) error {
+	result := conn(ctx, r.db).Where("id = ?", id).Delete(&WebhookSubscriptionModel{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete webhook subscription: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return authorization_audit.ErrWebhookSubscriptionNotFound
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+// Package middleware provides the Gin middleware applications put in front of
+// their routes. It is part of the stable azf API, unlike application/middleware
+// and infrastructure/enterprise, which it wraps and which may change between
+// minor versions.
+package middleware
+
+import (
+	appmiddleware "github.com/aruncs31s/azf/application/middleware"
+	"github.com/aruncs31s/azf/infrastructure/enterprise"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+// Authorize enforces the route metadata and Casbin policies of enterprise
+// authorization. Requests pass through unchecked until azf.InitAuthZModule
+// has set enterprise authorization up.
+func Authorize() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if enterprise.EnterpriseAuth == nil {
+			c.Next()
+			return
+		}
+		enterprise.EnterpriseAuth.GetMiddleware().GinMiddleware()(c)
+	}
+}
+
+// JWT authenticates requests with a bearer token signed with JWT_SECRET, or
+// with an X-API-Key issued from the admin UI, and makes its user ID and role
+// available to GetUserID and GetUserRole
+func JWT() gin.HandlerFunc {
+	return appmiddleware.JwtMiddleware()
+}
+
+// AdminSession requires a signed-in admin dashboard session
+func AdminSession() gin.HandlerFunc {
+	return appmiddleware.CheckAdminAuth()
+}
+
+// AdminToken requires a bearer token of the admin role, for automation
+func AdminToken() gin.HandlerFunc {
+	return appmiddleware.AdminAPIAuth()
+}
+
+// RateLimit limits each client IP to requestsPerSecond with burst
+func RateLimit(requestsPerSecond float64, burst int) gin.HandlerFunc {
+	return appmiddleware.RateLimitMiddleware(appmiddleware.NewIPRateLimiter(rate.Limit(requestsPerSecond), burst))
+}
+
+// CORS allows cross-origin requests from allowedOrigins only
+func CORS(allowedOrigins ...string) gin.HandlerFunc {
+	return appmiddleware.SecureCORSMiddleware(allowedOrigins)
+}
+
+// RequestID assigns every request an ID, reusing the X-Request-ID or
+// traceparent header of the client or an upstream gateway when present
+func RequestID() gin.HandlerFunc {
+	return appmiddleware.RequestIDMiddleware()
+}
+
+// ResponseMeta adds "meta" with the request ID, API version, authorization
+// mode and response time to every JSON object response
+func ResponseMeta() gin.HandlerFunc {
+	return appmiddleware.ResponseMetaMiddleware()
+}
+
+// WithoutResponseMeta opts a route out of ResponseMeta
+func WithoutResponseMeta() gin.HandlerFunc {
+	return appmiddleware.WithoutResponseMeta()
+}
+
+// Logging logs every request with its request ID
+func Logging() gin.HandlerFunc {
+	return appmiddleware.StructuredLoggingMiddleware()
+}
+
+// UsageTracking records API usage for the analytics dashboard
+func UsageTracking() gin.HandlerFunc {
+	return appmiddleware.APIUsageTrackingMiddleware()
+}
+
+// GetUserID returns the user ID of the authenticated request, or ""
+func GetUserID(c *gin.Context) string {
+	return appmiddleware.GetUserID(c)
+}
+
+// GetUserRole returns the role of the authenticated request, or ""
+func GetUserRole(c *gin.Context) string {
+	return appmiddleware.GetUserRole(c)
+}
+
+// GetAPIKeyID returns the ID of the API key the request authenticated
+// with, or "" for requests with a bearer token
+func GetAPIKeyID(c *gin.Context) string {
+	return appmiddleware.GetAPIKeyID(c)
+}
+
+// GetRequestID returns the ID assigned by RequestID, or ""
+func GetRequestID(c *gin.Context) string {
+	return appmiddleware.GetRequestID(c)
+}
@@ -0,0 +1,99 @@
+// Package clock abstracts the current time so time-dependent logic such as
+// rate limits, retention and retry backoff can be tested deterministically.
+//
+// Components take a Clock in their configuration or constructor and fall
+// back to the process default, which is the system clock unless SetDefault
+// replaces it.
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the current time
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock reads the time from the operating system
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// System is the clock of the operating system
+var System Clock = systemClock{}
+
+var (
+	defaultMu    sync.RWMutex
+	defaultClock Clock = System
+)
+
+// Default returns the clock used by components that were not given one
+func Default() Clock {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultClock
+}
+
+// SetDefault replaces the default clock; nil restores the system clock
+func SetDefault(c Clock) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if c == nil {
+		c = System
+	}
+	defaultClock = c
+}
+
+// Or returns c, or the default clock when c is nil
+func Or(c Clock) Clock {
+	if c != nil {
+		return c
+	}
+	return Default()
+}
+
+// Now returns the time of the default clock
+func Now() time.Time {
+	return Default().Now()
+}
+
+// Since returns the time elapsed since t on the default clock
+func Since(t time.Time) time.Duration {
+	return Now().Sub(t)
+}
+
+// Fake is a clock that only moves when told to. It is safe for concurrent use.
+type Fake struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFake creates a fake clock reading now
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+// Now returns the time the clock was set to
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Set moves the clock to now
+func (f *Fake) Set(now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = now
+}
+
+// Advance moves the clock forward by d
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
@@ -0,0 +1,42 @@
+package clock_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aruncs31s/azf/shared/clock"
+)
+
+func TestFake(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+
+	fake.Advance(time.Hour)
+	if got := fake.Now(); !got.Equal(start.Add(time.Hour)) {
+		t.Errorf("Expected %v, got %v", start.Add(time.Hour), got)
+	}
+
+	fake.Set(start)
+	if got := fake.Now(); !got.Equal(start) {
+		t.Errorf("Expected %v, got %v", start, got)
+	}
+}
+
+func TestSetDefault(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	clock.SetDefault(fake)
+	defer clock.SetDefault(nil)
+
+	fake.Advance(time.Minute)
+	if got := clock.Since(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)); got != time.Minute {
+		t.Errorf("Expected 1m elapsed, got %v", got)
+	}
+	if clock.Or(clock.System) != clock.System {
+		t.Error("Expected Or to keep the given clock")
+	}
+
+	clock.SetDefault(nil)
+	if clock.Default() != clock.System {
+		t.Error("Expected SetDefault(nil) to restore the system clock")
+	}
+}
@@ -16,6 +16,8 @@ const (
 	CodeValidation         = "VALIDATION_ERROR"
 	CodeConflict           = "CONFLICT"
 	CodeRateLimited        = "RATE_LIMITED"
+	CodeQuotaExceeded      = "QUOTA_EXCEEDED"
+	CodePayloadTooLarge    = "PAYLOAD_TOO_LARGE"
 	CodeTokenExpired       = "TOKEN_EXPIRED"
 	CodeTokenInvalid       = "TOKEN_INVALID"
 	CodeInsufficientRole   = "INSUFFICIENT_ROLE"
@@ -226,8 +228,35 @@ var (
 	ErrTokenExpired       = NewTokenExpiredError()
 	ErrInvalidCredentials = NewUnauthorizedError("invalid credentials")
 	ErrRateLimited        = NewRateLimitedError()
+
+	// ErrNotFound matches errors for records that do not exist
+	ErrNotFound = NewNotFoundError("resource")
+	// ErrConflict matches errors for writes that clash with existing records
+	ErrConflict = NewConflictError("resource conflicts with an existing one")
+	// ErrValidation matches errors for invalid input
+	ErrValidation = NewValidationError("validation failed")
 )
 
+// kindError is an error with its own message that matches a sentinel AppError
+type kindError struct {
+	kind *AppError
+	err  error
+}
+
+func (e *kindError) Error() string {
+	return e.err.Error()
+}
+
+func (e *kindError) Unwrap() []error {
+	return []error{e.kind, e.err}
+}
+
+// Newf formats an error that matches kind, such as ErrNotFound, with
+// errors.Is and takes its code and HTTP status. The format supports %w.
+func Newf(kind *AppError, format string, args ...interface{}) error {
+	return &kindError{kind: kind, err: fmt.Errorf(format, args...)}
+}
+
 // Helper functions
 
 // IsAppError checks if an error is an AppError
@@ -2,6 +2,7 @@ package errors_test
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"testing"
 
@@ -168,3 +169,26 @@ func TestSentinelErrors(t *testing.T) {
 		t.Error("ErrRateLimited has wrong code")
 	}
 }
+
+func TestNewf(t *testing.T) {
+	err := apperrors.Newf(apperrors.ErrNotFound, "user %s not found", "u1")
+	if err.Error() != "user u1 not found" {
+		t.Errorf("Expected 'user u1 not found', got '%s'", err.Error())
+	}
+	if !errors.Is(err, apperrors.ErrNotFound) {
+		t.Error("Expected error to match ErrNotFound")
+	}
+	if errors.Is(err, apperrors.ErrConflict) {
+		t.Error("Expected error not to match ErrConflict")
+	}
+
+	wrapped := fmt.Errorf("lookup failed: %w", err)
+	if apperrors.GetHTTPStatus(wrapped) != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, apperrors.GetHTTPStatus(wrapped))
+	}
+
+	other := apperrors.Newf(apperrors.ErrConflict, "email taken")
+	if errors.Is(err, other) || errors.Is(other, err) {
+		t.Error("Expected errors of different kinds not to match each other")
+	}
+}
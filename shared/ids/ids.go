@@ -0,0 +1,82 @@
+// Package ids abstracts the generation of entity IDs so tests can predict
+// them.
+//
+// Components take a Generator in their configuration or constructor and
+// fall back to the process default, which generates random UUIDs unless
+// SetDefault replaces it.
+package ids
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+)
+
+// Generator creates unique IDs
+type Generator interface {
+	NewID() string
+}
+
+// uuidGenerator creates random (version 4) UUIDs
+type uuidGenerator struct{}
+
+func (uuidGenerator) NewID() string {
+	return uuid.NewString()
+}
+
+// UUID generates random UUIDs
+var UUID Generator = uuidGenerator{}
+
+var (
+	defaultMu        sync.RWMutex
+	defaultGenerator Generator = UUID
+)
+
+// Default returns the generator used by components that were not given one
+func Default() Generator {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultGenerator
+}
+
+// SetDefault replaces the default generator; nil restores UUID
+func SetDefault(g Generator) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if g == nil {
+		g = UUID
+	}
+	defaultGenerator = g
+}
+
+// Or returns g, or the default generator when g is nil
+func Or(g Generator) Generator {
+	if g != nil {
+		return g
+	}
+	return Default()
+}
+
+// New returns an ID from the default generator
+func New() string {
+	return Default().NewID()
+}
+
+// Sequence generates the IDs prefix-1, prefix-2 and so on. It is safe for
+// concurrent use.
+type Sequence struct {
+	prefix string
+	next   atomic.Int64
+}
+
+// NewSequence creates a sequence of IDs starting with prefix
+func NewSequence(prefix string) *Sequence {
+	return &Sequence{prefix: prefix}
+}
+
+// NewID returns the next ID of the sequence
+func (s *Sequence) NewID() string {
+	return fmt.Sprintf("%s-%d", s.prefix, s.next.Add(1))
+}
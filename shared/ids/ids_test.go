@@ -0,0 +1,31 @@
+package ids_test
+
+import (
+	"testing"
+
+	"github.com/aruncs31s/azf/shared/ids"
+)
+
+func TestSequence(t *testing.T) {
+	seq := ids.NewSequence("log")
+	if got := seq.NewID(); got != "log-1" {
+		t.Errorf("Expected log-1, got %s", got)
+	}
+	if got := seq.NewID(); got != "log-2" {
+		t.Errorf("Expected log-2, got %s", got)
+	}
+}
+
+func TestSetDefault(t *testing.T) {
+	ids.SetDefault(ids.NewSequence("event"))
+	defer ids.SetDefault(nil)
+
+	if got := ids.New(); got != "event-1" {
+		t.Errorf("Expected event-1, got %s", got)
+	}
+
+	ids.SetDefault(nil)
+	if ids.Default() != ids.UUID {
+		t.Error("Expected SetDefault(nil) to restore UUID")
+	}
+}
@@ -0,0 +1,45 @@
+package logger
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+type contextKey int
+
+const (
+	loggerContextKey contextKey = iota
+	requestIDContextKey
+)
+
+// ContextWithRequestID returns ctx carrying requestID and a logger that adds
+// it to every entry
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	ctx = context.WithValue(ctx, requestIDContextKey, requestID)
+	return context.WithValue(ctx, loggerContextKey, FromContext(ctx).With(zap.String("request_id", requestID)))
+}
+
+// ContextWithLogger returns ctx carrying l, returned by FromContext
+func ContextWithLogger(ctx context.Context, l *zap.Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey, l)
+}
+
+// FromContext returns the logger carried by ctx, or the global logger
+func FromContext(ctx context.Context) *zap.Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(loggerContextKey).(*zap.Logger); ok {
+			return l
+		}
+	}
+	return GetLogger()
+}
+
+// RequestIDFromContext returns the request ID carried by ctx, or ""
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	requestID, _ := ctx.Value(requestIDContextKey).(string)
+	return requestID
+}
@@ -196,6 +196,31 @@ func RateLimited(c *gin.Context) {
 	})
 }
 
+// QuotaExceeded sends a 429 Too Many Requests response for a used up
+// daily or monthly quota
+func QuotaExceeded(c *gin.Context, message string) {
+	c.JSON(http.StatusTooManyRequests, APIResponse{
+		Success: false,
+		Error: &APIError{
+			Code:    apperrors.CodeQuotaExceeded,
+			Message: message,
+		},
+		RequestID: getRequestID(c),
+	})
+}
+
+// PayloadTooLarge sends a 413 Request Entity Too Large response
+func PayloadTooLarge(c *gin.Context, message string) {
+	c.JSON(http.StatusRequestEntityTooLarge, APIResponse{
+		Success: false,
+		Error: &APIError{
+			Code:    apperrors.CodePayloadTooLarge,
+			Message: message,
+		},
+		RequestID: getRequestID(c),
+	})
+}
+
 // InternalServerError sends a 500 Internal Server Error response
 func InternalServerError(c *gin.Context, message string) {
 	c.JSON(http.StatusInternalServerError, APIResponse{
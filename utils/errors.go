@@ -32,6 +32,8 @@ var (
 	ErrInvalidQualificationID    = errors.New("invalid qualification id")
 	ErrUnauthorized              = errors.New("unauthorized access/forbidden")
 	ErrNoAuthHeader              = errors.New("no authorization header")
+	ErrTokenExpired              = errors.New("token expired")
+	ErrInvalidAPIKey             = errors.New("invalid or expired API key")
 	// When the request data is invalid or malformed
 	//
 	// - e.g., missing required fields, incorrect data types, etc.